@@ -8,7 +8,6 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/redis/go-redis/v9"
-	"golang.org/x/sync/errgroup"
 
 	"yeti/internal/broker"
 	"yeti/internal/config"
@@ -16,10 +15,13 @@ import (
 	"yeti/internal/deduplication"
 	"yeti/internal/logger"
 	"yeti/pkg/bootstrap"
+	apperrors "yeti/pkg/errors"
 	"yeti/pkg/health"
 	"yeti/pkg/logging"
 	"yeti/pkg/metrics"
+	otelmetrics "yeti/pkg/metrics/otel"
 	"yeti/pkg/models"
+	"yeti/pkg/supervisor"
 	"yeti/pkg/tracing"
 )
 
@@ -29,7 +31,9 @@ type App struct {
 	redis          *redis.Client
 	service        *deduplication.Service
 	tracerProvider *tracing.TracerProvider
+	meterProvider  *otelmetrics.MeterProvider
 	server         *http.Server
+	supervisor     *supervisor.Supervisor
 }
 
 func NewApp(cfg *config.Config, log logger.Logger) *App {
@@ -55,22 +59,35 @@ func (a *App) Initialize(ctx context.Context) error {
 		return fmt.Errorf("failed to initialize broker: %w", err)
 	}
 
+	a.supervisor = supervisor.New("dedup-service", supervisor.Spec{}, a.Logger)
+
 	tp, err := tracing.Init(a.Config.Tracing, "dedup-service")
 	if err != nil {
 		return fmt.Errorf("failed to initialize tracing: %w", err)
 	}
 	a.tracerProvider = tp
 
+	mp, err := otelmetrics.Init(a.Config.Metrics, "dedup-service")
+	if err != nil {
+		return fmt.Errorf("failed to initialize OTLP metrics: %w", err)
+	}
+	a.meterProvider = mp
+
 	metrics.RegisterDedupMetrics()
 	metrics.RegisterBrokerMetrics()
 	if a.Config.CircuitBreaker.Enabled {
 		metrics.RegisterCircuitBreakerMetrics()
 	}
+	if a.Config.Tracing.Sampler.Type == "tail_sampling" {
+		metrics.RegisterTracingMetrics()
+	}
 
 	if err := a.initHTTPServer(ctx); err != nil {
 		return fmt.Errorf("failed to initialize HTTP server: %w", err)
 	}
 
+	a.InitConfigWatcher(ctx)
+
 	return nil
 }
 
@@ -82,29 +99,76 @@ func (a *App) initHTTPServer(ctx context.Context) error {
 	if a.redis != nil {
 		healthRegistry.Register(health.NewRedisChecker(a.redis))
 	}
+	if a.supervisor != nil {
+		healthRegistry.Register(health.NewSupervisorChecker(a.supervisor))
+	}
+	if a.Config.CircuitBreaker.Enabled && a.service != nil {
+		healthRegistry.Register(health.NewBreakerChecker("redis_circuit_breaker", a.service))
+	}
+	if a.Config.Broker.Type == "kafka" {
+		healthRegistry.Register(health.NewKafkaChecker(
+			a.Config.Broker.Kafka.Brokers,
+			a.Config.Broker.Kafka.GroupID,
+			a.Config.Broker.Kafka.InputTopic,
+			a.Config.Broker.Kafka.DLQTopic,
+			health.KafkaCheckerConfig{MaxLag: 10000},
+		))
+		if kc, ok := a.Consumer.(*broker.KafkaConsumer); ok {
+			healthRegistry.Register(health.NewKafkaConsumerStateChecker(kc))
+		}
+	}
 
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		h := healthRegistry.Check(r.Context())
-		statusCode := http.StatusOK
 		if h.Status == health.StatusUnhealthy {
-			statusCode = http.StatusServiceUnavailable
+			apperr := apperrors.ErrServiceUnavailable.WithDetail("checks", h.Checks)
+			apperrors.WriteHTTPError(w, apperr, logging.GetTraceID(r.Context()))
+			return
 		}
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(statusCode)
+		w.WriteHeader(http.StatusOK)
 		fmt.Fprintf(w, `{"status":"%s","timestamp":"%s"}`, h.Status, h.Timestamp.Format(time.RFC3339))
 	})
 
 	// Metrics endpoint
 	mux.Handle("/metrics", promhttp.Handler())
 
+	// Admin endpoints: cache inspection/purge/TTL-extension, the operator
+	// counterpart to the config-watcher-driven UpdateFieldsToHash.
+	if a.service != nil {
+		mux.Handle("/admin/dedup/stats", deduplication.StatsHandler(a.service))
+		mux.Handle("/admin/dedup/lookup", deduplication.LookupHandler(a.service))
+		mux.Handle("/admin/dedup/entry", deduplication.EntryHandler(a.service))
+		mux.Handle("/admin/dedup/purge", deduplication.PurgeHandler(a.service))
+		mux.Handle("/admin/dedup/extend", deduplication.ExtendHandler(a.service))
+	}
+
 	a.server = &http.Server{
 		Addr:    fmt.Sprintf(":%d", a.Config.Server.Port),
-		Handler: mux,
+		Handler: traceContextHandler(mux),
 	}
 
 	return nil
 }
 
+// traceContextHandler extracts an incoming request's W3C traceparent/
+// tracestate headers into its context via logging.ExtractW3CTraceContext,
+// the net/http-mux equivalent of tracing.GinMiddleware+
+// pkg/middleware.TraceContextMiddleware for the gin-based services - this
+// server has neither, so without it every *wCtx log call and
+// apperrors.WriteHTTPError on this mux would see an empty trace ID even
+// with an instrumented caller.
+func traceContextHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		carrier := map[string]string{
+			"traceparent": r.Header.Get("traceparent"),
+			"tracestate":  r.Header.Get("tracestate"),
+		}
+		ctx := logging.ExtractW3CTraceContext(r.Context(), carrier)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
 func (a *App) initRedis(ctx context.Context) error {
 	rdb, err := a.dbConnector.InitRedis(ctx)
 	if err != nil {
@@ -119,30 +183,73 @@ func (a *App) initService() error {
 
 	var repo deduplication.Repository
 	if a.Config.CircuitBreaker.Enabled {
-		repo = deduplication.NewCircuitBreakerRepository(baseRepo, a.Config.CircuitBreaker)
+		repo = deduplication.NewCircuitBreakerRepository(baseRepo, a.Config.CircuitBreaker, a.Logger)
 		initCtx := logging.WithServiceName(context.Background(), "dedup-service")
 		a.Logger.InfowCtx(initCtx, "Circuit breaker enabled for deduplication repository")
 	} else {
 		repo = baseRepo
 	}
 
-	svc := deduplication.NewService(repo, a.Config.Deduplication, a.Logger)
+	// Backend selects the fast path in front of repo's Redis round trip.
+	// An empty Backend falls back to Bloom.Enabled so configs predating
+	// this field keep behaving the way they always have.
+	backend := a.Config.Deduplication.Backend
+	if backend == "" && a.Config.Deduplication.Bloom.Enabled {
+		backend = "bloom"
+	}
+
+	// ttlSeconds backs both fast paths' rotation-window default: absent an
+	// explicit one, rotate every half TTL so the filter's own
+	// false-negative window (two rotations) lines up with how long Redis
+	// still remembers a key.
+	ttlSeconds := a.Config.Deduplication.TTLSeconds
+
+	switch backend {
+	case "bloom":
+		bloomCfg := a.Config.Deduplication.Bloom
+		rotationSeconds := bloomCfg.RotationIntervalSeconds
+		if rotationSeconds <= 0 {
+			rotationSeconds = ttlSeconds / 2
+		}
+		repo = deduplication.NewBloomRepository(repo, deduplication.BloomParams{
+			ExpectedItems:     bloomCfg.ExpectedItems,
+			FalsePositiveRate: bloomCfg.FalsePositiveRate,
+			RotationInterval:  time.Duration(rotationSeconds) * time.Second,
+			Distributed:       bloomCfg.Distributed,
+			Client:            a.redis,
+		})
+		initCtx := logging.WithServiceName(context.Background(), "dedup-service")
+		a.Logger.InfowCtx(initCtx, "Bloom filter fast path enabled for deduplication repository",
+			"distributed", bloomCfg.Distributed)
+	case "cuckoo":
+		cuckooCfg := a.Config.Deduplication.Cuckoo
+		rotationSeconds := cuckooCfg.RotationWindowSeconds
+		if rotationSeconds <= 0 {
+			rotationSeconds = ttlSeconds / 2
+		}
+		repo = deduplication.NewCuckooRepository(repo, deduplication.CuckooParams{
+			Capacity:          cuckooCfg.Capacity,
+			FalsePositiveRate: cuckooCfg.FalsePositiveRate,
+			RotationWindow:    time.Duration(rotationSeconds) * time.Second,
+			Distributed:       cuckooCfg.Distributed,
+			Client:            a.redis,
+		})
+		initCtx := logging.WithServiceName(context.Background(), "dedup-service")
+		a.Logger.InfowCtx(initCtx, "Cuckoo filter fast path enabled for deduplication repository",
+			"distributed", cuckooCfg.Distributed, "capacity", cuckooCfg.Capacity)
+	}
+
+	svc, err := deduplication.NewService(repo, a.Config.Deduplication, a.Logger)
+	if err != nil {
+		return fmt.Errorf("failed to create deduplication service: %w", err)
+	}
 	a.service = svc
 	return nil
 }
 
 func (a *App) Run(ctx context.Context) error {
-	g, gCtx := errgroup.WithContext(ctx)
-
-	// Start HTTP server
 	if a.server != nil {
-		g.Go(func() error {
-			a.Logger.InfowCtx(ctx, "HTTP server starting", "port", a.Config.Server.Port)
-			if err := a.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-				return fmt.Errorf("HTTP server error: %w", err)
-			}
-			return nil
-		})
+		a.supervisor.Add("http-server", &httpServerService{server: a.server, logger: a.Logger, port: a.Config.Server.Port})
 	}
 
 	if a.Config.Broker.Type == "kafka" && a.Config.Broker.Kafka.ConfigUpdateTopic != "" {
@@ -154,17 +261,15 @@ func (a *App) Run(ctx context.Context) error {
 			)
 		} else {
 			configConsumer.SetServiceName("dedup-service")
-			defer configConsumer.Close()
 			configEventHandler := deduplication.NewHandler(a.service, a.Logger)
 
-			g.Go(func() error {
-				configCtx := logging.WithServiceName(gCtx, "dedup-service")
-				a.Logger.InfowCtx(configCtx, "Starting config update event consumer",
-					"topic", a.Config.Broker.Kafka.ConfigUpdateTopic,
-				)
-				return configConsumer.Consume(gCtx, a.Config.Broker.Kafka.ConfigUpdateTopic, func(cCtx context.Context, msg models.MessageEnvelope) error {
+			a.supervisor.Add("config-consumer", &consumerService{
+				consumer:  configConsumer,
+				topic:     a.Config.Broker.Kafka.ConfigUpdateTopic,
+				ownsClose: true,
+				handler: func(cCtx context.Context, msg models.MessageEnvelope) error {
 					return configEventHandler.HandleConfigUpdateEvent(cCtx, msg)
-				})
+				},
 			})
 		}
 	}
@@ -178,11 +283,55 @@ func (a *App) Run(ctx context.Context) error {
 		outputTopic = "deduplicated_events"
 	}
 
-	g.Go(func() error {
-		return a.Consumer.Consume(gCtx, inputTopic, a.handleMessage(outputTopic))
+	a.supervisor.Add("main-consumer", &consumerService{
+		consumer: a.Consumer,
+		topic:    inputTopic,
+		handler:  a.handleMessage(outputTopic),
 	})
 
-	return g.Wait()
+	return a.supervisor.Serve(ctx)
+}
+
+// httpServerService adapts an *http.Server to supervisor.Service.
+type httpServerService struct {
+	server *http.Server
+	logger logger.Logger
+	port   int
+}
+
+func (s *httpServerService) Serve(ctx context.Context) error {
+	s.logger.InfowCtx(ctx, "HTTP server starting", "port", s.port)
+	if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("HTTP server error: %w", err)
+	}
+	return nil
+}
+
+func (s *httpServerService) Stop() {
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), constants.ShutdownTimeout)
+	defer cancel()
+	_ = s.server.Shutdown(shutdownCtx)
+}
+
+// consumerService adapts a broker.Consumer to supervisor.Service. ownsClose
+// is true for consumers created ad hoc in Run (e.g. the config-update
+// consumer); the main consumer is owned by bootstrap.Base and closed via
+// Base.Shutdown instead.
+type consumerService struct {
+	consumer  broker.Consumer
+	topic     string
+	handler   broker.HandlerFunc
+	ownsClose bool
+}
+
+func (s *consumerService) Serve(ctx context.Context) error {
+	return s.consumer.Consume(ctx, s.topic, s.handler)
+}
+
+func (s *consumerService) Stop() {
+	if s.ownsClose {
+		_ = s.consumer.Close()
+	}
 }
 
 func (a *App) handleMessage(outputTopic string) func(context.Context, models.MessageEnvelope) error {
@@ -226,12 +375,8 @@ func (a *App) Shutdown(ctx context.Context) error {
 			a.service.StopCacheMetricsUpdater()
 		}
 
-		if a.server != nil {
-			shutdownCtx, cancel := context.WithTimeout(context.Background(), constants.ShutdownTimeout)
-			defer cancel()
-			if err := a.server.Shutdown(shutdownCtx); err != nil {
-				errs = append(errs, fmt.Errorf("HTTP server shutdown error: %w", err))
-			}
+		if a.supervisor != nil {
+			a.supervisor.Stop()
 		}
 
 		if a.tracerProvider != nil {
@@ -240,6 +385,12 @@ func (a *App) Shutdown(ctx context.Context) error {
 			}
 		}
 
+		if a.meterProvider != nil {
+			if err := a.meterProvider.Shutdown(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("meter provider shutdown error: %w", err))
+			}
+		}
+
 		errs = append(errs, a.dbConnector.ShutdownDatabases(ctx, a.redis, nil, nil)...)
 
 		return errs
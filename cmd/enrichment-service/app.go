@@ -17,7 +17,9 @@ import (
 	"yeti/internal/config"
 	"yeti/internal/constants"
 	"yeti/internal/enrichment"
+	"yeti/internal/enrichment/provider"
 	"yeti/internal/logger"
+	"yeti/internal/management"
 	"yeti/pkg/bootstrap"
 	"yeti/pkg/health"
 	"yeti/pkg/logging"
@@ -28,10 +30,20 @@ import (
 
 type App struct {
 	*bootstrap.Base
-	dbConnector    *bootstrap.DatabaseConnector
-	redis          *redis.Client
-	mongoClient    *mongo.Client
-	postgresDB     *sql.DB
+	dbConnector *bootstrap.DatabaseConnector
+	container   *bootstrap.Container
+
+	redisModule    *bootstrap.RedisModule
+	mongoModule    *bootstrap.MongoModule
+	postgresModule *bootstrap.PostgresModule
+	brokerModule   *bootstrap.BrokerModule
+	tracingModule  *bootstrap.TracingModule
+	metricsModule  *bootstrap.MetricsModule
+
+	redis       *redis.Client
+	mongoClient *mongo.Client
+	postgresDB  *sql.DB
+
 	service        enrichment.Service
 	tracerProvider *tracing.TracerProvider
 	server         *http.Server
@@ -44,49 +56,58 @@ func NewApp(cfg *config.Config, log logger.Logger) *App {
 	return &App{
 		Base:        bootstrap.NewBase(cfg, log),
 		dbConnector: bootstrap.NewDatabaseConnector(cfg, log),
+		container:   bootstrap.NewContainer(),
 	}
 }
 
+// Initialize assembles the service's subsystems as bootstrap.Modules
+// installed into a bootstrap.Container: each Module registers its own
+// OnStart/OnStop hook (Redis and MongoDB are required and fail Start;
+// Postgres is declaratively optional and only warns), and Container.Start
+// runs them in registration order below, unwinding whatever already
+// started if one fails. This replaces the old hand-rolled
+// initRedis/initMongoDB/... sequence with its implicit ordering and
+// ad-hoc nil-checks.
 func (a *App) Initialize(ctx context.Context) error {
-	if err := a.initRedis(ctx); err != nil {
-		return fmt.Errorf("failed to initialize Redis: %w", err)
+	a.redisModule = bootstrap.NewRedisModule(a.container, a.dbConnector)
+	a.mongoModule = bootstrap.NewMongoModule(a.container, a.dbConnector)
+	a.postgresModule = bootstrap.NewPostgresModule(a.container, a.dbConnector, a.Logger)
+	a.brokerModule = bootstrap.NewBrokerModule(a.container, a.Config.Broker, a.Logger, "enrichment-service")
+	a.tracingModule = bootstrap.NewTracingModule(a.container, a.Config.Tracing, "enrichment-service")
+	a.metricsModule = bootstrap.NewMetricsModule(a.container, a.Config.Metrics, "enrichment-service")
+
+	if err := a.container.Start(ctx); err != nil {
+		return err
 	}
 
-	if err := a.initMongoDB(ctx); err != nil {
-		return fmt.Errorf("failed to initialize MongoDB: %w", err)
-	}
-
-	if err := a.initPostgreSQL(ctx); err != nil {
-		initCtx := logging.WithServiceName(ctx, "enrichment-service")
-		a.Logger.WarnwCtx(initCtx, "PostgreSQL initialization failed, PostgreSQL provider will be disabled",
-			"error", err,
-		)
-	}
+	a.redis = a.redisModule.Client
+	a.mongoClient = a.mongoModule.Client
+	a.postgresDB = a.postgresModule.DB
+	a.Producer = a.brokerModule.Producer
+	a.Consumer = a.brokerModule.Consumer
+	a.tracerProvider = a.tracingModule.Provider
 
 	if err := a.initService(ctx); err != nil {
 		return fmt.Errorf("failed to initialize service: %w", err)
 	}
 
-	if err := a.InitBroker("enrichment-service"); err != nil {
-		return fmt.Errorf("failed to initialize broker: %w", err)
-	}
-
-	tp, err := tracing.Init(a.Config.Tracing, "enrichment-service")
-	if err != nil {
-		return fmt.Errorf("failed to initialize tracing: %w", err)
-	}
-	a.tracerProvider = tp
-
 	metrics.RegisterEnrichmentMetrics()
+	metrics.SetRuleCardinalityCap(a.Config.Metrics.RuleCardinalityCap)
+	metrics.RegisterCELMetrics()
 	metrics.RegisterBrokerMetrics()
 	if a.Config.CircuitBreaker.Enabled {
 		metrics.RegisterCircuitBreakerMetrics()
 	}
+	if a.Config.Tracing.Sampler.Type == "tail_sampling" {
+		metrics.RegisterTracingMetrics()
+	}
 
 	if err := a.initHTTPServer(ctx); err != nil {
 		return fmt.Errorf("failed to initialize HTTP server: %w", err)
 	}
 
+	a.InitConfigWatcher(ctx)
+
 	return nil
 }
 
@@ -102,7 +123,19 @@ func (a *App) initHTTPServer(ctx context.Context) error {
 		healthRegistry.Register(health.NewMongoDBChecker(a.mongoClient))
 	}
 	if a.postgresDB != nil {
-		healthRegistry.Register(health.NewPostgreSQLChecker(a.postgresDB))
+		healthRegistry.Register(health.NewPostgreSQLChecker(a.postgresDB, health.PostgreSQLCheckerConfig{}))
+	}
+	if a.Config.Broker.Type == "kafka" {
+		healthRegistry.Register(health.NewKafkaChecker(
+			a.Config.Broker.Kafka.Brokers,
+			a.Config.Broker.Kafka.GroupID,
+			a.Config.Broker.Kafka.InputTopic,
+			a.Config.Broker.Kafka.DLQTopic,
+			health.KafkaCheckerConfig{MaxLag: 10000},
+		))
+		if kc, ok := a.Consumer.(*broker.KafkaConsumer); ok {
+			healthRegistry.Register(health.NewKafkaConsumerStateChecker(kc))
+		}
 	}
 
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -118,6 +151,12 @@ func (a *App) initHTTPServer(ctx context.Context) error {
 
 	mux.Handle("/metrics", promhttp.Handler())
 
+	if a.service != nil {
+		mux.Handle("/healthz/enrichment", enrichment.HealthzHandler(a.service))
+		mux.Handle("/healthz/enrichment/rules", enrichment.RuleHealthzHandler(a.service, "/healthz/enrichment/rules"))
+		mux.Handle("/healthz/enrichment/rules/", enrichment.RuleHealthzHandler(a.service, "/healthz/enrichment/rules"))
+	}
+
 	a.server = &http.Server{
 		Addr:    fmt.Sprintf(":%d", a.Config.Server.Port),
 		Handler: mux,
@@ -126,48 +165,47 @@ func (a *App) initHTTPServer(ctx context.Context) error {
 	return nil
 }
 
-func (a *App) initRedis(ctx context.Context) error {
-	rdb, err := a.dbConnector.InitRedis(ctx)
-	if err != nil {
-		return err
+// initBreakerEventPublisher wires provider.WrapWithCircuitBreaker's state
+// transitions to the same Kafka config-events topic management-service
+// publishes rule changes on, reusing its ConfigEventProducer (which already
+// has a PublishEnrichmentBreakerStateEvent method matching
+// provider.BreakerEventPublisher) rather than inventing a second producer
+// type just for this service. A no-op if Kafka config events aren't
+// configured, same as management-service's own configEventProducer wiring.
+func (a *App) initBreakerEventPublisher() {
+	if a.Config.Broker.Type != "kafka" || a.Config.Broker.Kafka.ConfigUpdateTopic == "" || a.Producer == nil {
+		return
 	}
-	a.redis = rdb
-	return nil
+	provider.SetBreakerEventPublisher(management.NewConfigEventProducer(a.Producer, a.Config.Broker.Kafka.ConfigUpdateTopic))
 }
 
-func (a *App) initMongoDB(ctx context.Context) error {
-	mongoClient, err := a.dbConnector.InitMongoDB(ctx)
-	if err != nil {
-		return err
-	}
-
-	if mongoClient != nil {
-		a.mongoClient = mongoClient
-	}
-	return nil
-}
+func (a *App) initService(ctx context.Context) error {
+	a.initBreakerEventPublisher()
 
-func (a *App) initPostgreSQL(ctx context.Context) error {
-	postgresDB, err := a.dbConnector.InitPostgreSQL(ctx)
+	mongoDb := a.mongoClient.Database(a.Config.Database.MongoDB.Database)
+	ruleStorage := a.Config.Enrichment.RuleStorage
+	repo, err := enrichment.NewRepositoryFromConfig(ruleStorage.Driver, mongoDb, a.postgresDB, ruleStorage.File, ruleStorage.HTTP)
 	if err != nil {
-		return err
-	}
-	if postgresDB != nil {
-		a.postgresDB = postgresDB
+		return fmt.Errorf("failed to initialize enrichment rule repository: %w", err)
 	}
-	return nil
-}
-
-func (a *App) initService(ctx context.Context) error {
-	mongoDb := a.mongoClient.Database(a.Config.Database.MongoDB.Database)
-	repo := enrichment.NewRepository(mongoDb)
 
 	var svc enrichment.Service
 	cbConfig := &a.Config.CircuitBreaker
+	retryCfg := &a.Config.Enrichment.Retry
+	l1Cfg := &a.Config.Enrichment.L1Cache
+	celCfg := &a.Config.Enrichment.CEL
 	if a.mongoClient != nil || a.postgresDB != nil {
-		svc = enrichment.NewServiceWithDatabaseProvidersAndCircuitBreaker(repo, a.redis, a.mongoClient, a.postgresDB, a.Logger, cbConfig)
+		registry, err := enrichment.DiscoverExternalProviders(ctx, mongoDb, a.Logger)
+		if err != nil {
+			initCtx := logging.WithServiceName(ctx, "enrichment-service")
+			a.Logger.WarnwCtx(initCtx, "Failed to discover external enrichment providers, continuing without them",
+				"error", err,
+			)
+			registry = nil
+		}
+		svc = enrichment.NewServiceWithExternalProvidersAndCELCache(repo, a.redis, a.mongoClient, a.postgresDB, a.Logger, cbConfig, retryCfg, l1Cfg, celCfg, registry)
 	} else {
-		svc = enrichment.NewServiceWithCircuitBreaker(repo, a.redis, a.Logger, cbConfig)
+		svc = enrichment.NewServiceWithCELCache(repo, a.redis, a.Logger, cbConfig, retryCfg, l1Cfg, celCfg)
 	}
 
 	if err := svc.ReloadRules(ctx); err != nil {
@@ -182,6 +220,10 @@ func (a *App) initService(ctx context.Context) error {
 }
 
 func (a *App) Run(ctx context.Context) error {
+	if a.service != nil {
+		defer a.service.Close()
+	}
+
 	g, gCtx := errgroup.WithContext(ctx)
 
 	if a.server != nil {
@@ -260,25 +302,30 @@ func (a *App) handleMessage(outputTopic string) func(context.Context, models.Mes
 	}
 }
 
+// Shutdown closes the HTTP server and then unwinds every bootstrap.Module
+// installed into a.container in reverse start order (tracing, then broker,
+// then Postgres/MongoDB/Redis) via Container.Stop, replacing the old
+// Base.Shutdown/DatabaseConnector.ShutdownDatabases pairing.
 func (a *App) Shutdown(ctx context.Context) error {
 	shutdownCtx := logging.WithServiceName(ctx, "enrichment-service")
 	a.Logger.InfowCtx(shutdownCtx, "Shutting down enrichment service")
 
-	additionalShutdown := func(ctx context.Context) []error {
-		var errs []error
+	var errs []error
 
-		if a.server != nil {
-			shutdownCtx, cancel := context.WithTimeout(context.Background(), constants.ShutdownTimeout)
-			defer cancel()
-			if err := a.server.Shutdown(shutdownCtx); err != nil {
-				errs = append(errs, fmt.Errorf("HTTP server shutdown error: %w", err))
-			}
+	if a.server != nil {
+		httpShutdownCtx, cancel := context.WithTimeout(context.Background(), constants.ShutdownTimeout)
+		defer cancel()
+		if err := a.server.Shutdown(httpShutdownCtx); err != nil {
+			errs = append(errs, fmt.Errorf("HTTP server shutdown error: %w", err))
 		}
+	}
 
-		errs = append(errs, a.dbConnector.ShutdownDatabases(ctx, a.redis, a.postgresDB, a.mongoClient)...)
+	errs = append(errs, a.container.Stop(ctx)...)
 
-		return errs
+	if len(errs) > 0 {
+		return fmt.Errorf("shutdown errors: %v", errs)
 	}
 
-	return a.Base.Shutdown(ctx, additionalShutdown)
+	a.Logger.Info("Application exited successfully")
+	return nil
 }
@@ -0,0 +1,120 @@
+// Command example-provider is a minimal reference implementation of the
+// external enrichment plugin contract described in
+// proto/enrichment/v1/provider.proto. It exists for integration tests and
+// as a template for plugin authors: register it with the management
+// service (POST /api/v1/providers, source_type "example") pointed at this
+// process's --addr, and rules with that source type will be enriched from
+// the canned dataset below.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"yeti/internal/enrichment/provider/providerpb"
+	"yeti/pkg/tlsutil"
+)
+
+func main() {
+	addr := flag.String("addr", ":9443", "address to listen on")
+	certFile := flag.String("cert-file", "", "TLS certificate (enables TLS when set, with key-file)")
+	keyFile := flag.String("key-file", "", "TLS key (enables TLS when set, with cert-file)")
+	caFile := flag.String("ca-file", "", "CA bundle to verify client certificates against (enables mTLS when set)")
+	flag.Parse()
+
+	lis, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatalf("example-provider: failed to listen on %s: %v", *addr, err)
+	}
+
+	srv := grpc.NewServer(serverOptions(*certFile, *keyFile, *caFile)...)
+
+	plugin := newExamplePlugin()
+	providerpb.RegisterProviderServer(srv, plugin)
+	providerpb.RegisterEnrichmentServer(srv, plugin)
+
+	healthSrv := health.NewServer()
+	healthSrv.SetServingStatus(providerpb.ServiceNameEnrichment, grpc_health_v1.HealthCheckResponse_SERVING)
+	grpc_health_v1.RegisterHealthServer(srv, healthSrv)
+
+	log.Printf("example-provider: listening on %s", *addr)
+	if err := srv.Serve(lis); err != nil {
+		log.Fatalf("example-provider: serve failed: %v", err)
+	}
+}
+
+func serverOptions(certFile, keyFile, caFile string) []grpc.ServerOption {
+	if certFile == "" || keyFile == "" {
+		return nil
+	}
+
+	tlsCfg, err := tlsutil.ServerTLSConfig(tlsutil.Config{CertFile: certFile, KeyFile: keyFile, CAFile: caFile})
+	if err != nil {
+		log.Fatalf("example-provider: %v", err)
+	}
+	return []grpc.ServerOption{grpc.Creds(credentials.NewTLS(tlsCfg))}
+}
+
+// examplePlugin implements both providerpb.ProviderServer (the original
+// Struct-based contract) and providerpb.EnrichmentServer (the typed
+// Fetch contract), backed by a small canned dataset keyed by field value
+// so integration tests can assert on known enrichment results.
+type examplePlugin struct {
+	data map[string]map[string]interface{}
+}
+
+func newExamplePlugin() *examplePlugin {
+	return &examplePlugin{
+		data: map[string]map[string]interface{}{
+			"1": {"tier": "gold", "region": "us-east"},
+			"2": {"tier": "silver", "region": "eu-west"},
+		},
+	}
+}
+
+func (p *examplePlugin) Fetch(ctx context.Context, req *providerpb.FetchRequest) (*providerpb.FetchResponse, error) {
+	var fieldValue interface{}
+	if err := json.Unmarshal([]byte(req.FieldValueJSON), &fieldValue); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid field_value_json: %v", err)
+	}
+
+	data, ok := p.data[fmt.Sprintf("%v", fieldValue)]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "no data for field value %v", fieldValue)
+	}
+
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to encode response: %v", err)
+	}
+
+	return &providerpb.FetchResponse{DataJSON: string(dataJSON), TTLSeconds: 300}, nil
+}
+
+func (p *examplePlugin) Lookup(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	key, _ := in.AsMap()["key"].(string)
+
+	data, ok := p.data[key]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "no data for key %s", key)
+	}
+
+	return structpb.NewStruct(map[string]interface{}{"data": data})
+}
+
+func (p *examplePlugin) HealthCheck(ctx context.Context, in *emptypb.Empty) (*structpb.Struct, error) {
+	return structpb.NewStruct(map[string]interface{}{"healthy": true})
+}
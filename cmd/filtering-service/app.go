@@ -13,6 +13,8 @@ import (
 
 	"yeti/internal/broker"
 	"yeti/internal/config"
+	"yeti/internal/config_handler"
+	"yeti/internal/configsource"
 	"yeti/internal/constants"
 	"yeti/internal/filtering"
 	"yeti/internal/logger"
@@ -20,6 +22,7 @@ import (
 	"yeti/pkg/health"
 	"yeti/pkg/logging"
 	"yeti/pkg/metrics"
+	otelmetrics "yeti/pkg/metrics/otel"
 	"yeti/pkg/models"
 	"yeti/pkg/tracing"
 )
@@ -30,6 +33,7 @@ type App struct {
 	db             *sql.DB
 	service        *filtering.Service
 	tracerProvider *tracing.TracerProvider
+	meterProvider  *otelmetrics.MeterProvider
 	server         *http.Server
 }
 
@@ -62,16 +66,29 @@ func (a *App) Initialize(ctx context.Context) error {
 	}
 	a.tracerProvider = tp
 
+	mp, err := otelmetrics.Init(a.Config.Metrics, "filtering-service")
+	if err != nil {
+		return fmt.Errorf("failed to initialize OTLP metrics: %w", err)
+	}
+	a.meterProvider = mp
+
 	metrics.RegisterFilteringMetrics()
+	metrics.SetRuleCardinalityCap(a.Config.Metrics.RuleCardinalityCap)
+	metrics.RegisterCELMetrics()
 	metrics.RegisterBrokerMetrics()
 	if a.Config.CircuitBreaker.Enabled {
 		metrics.RegisterCircuitBreakerMetrics()
 	}
+	if a.Config.Tracing.Sampler.Type == "tail_sampling" {
+		metrics.RegisterTracingMetrics()
+	}
 
 	if err := a.initHTTPServer(ctx); err != nil {
 		return fmt.Errorf("failed to initialize HTTP server: %w", err)
 	}
 
+	a.InitConfigWatcher(ctx)
+
 	return nil
 }
 
@@ -80,7 +97,19 @@ func (a *App) initHTTPServer(ctx context.Context) error {
 
 	healthRegistry := health.NewCheckerRegistry()
 	if a.db != nil {
-		healthRegistry.Register(health.NewPostgreSQLChecker(a.db))
+		healthRegistry.Register(health.NewPostgreSQLChecker(a.db, health.PostgreSQLCheckerConfig{}))
+	}
+	if a.Config.Broker.Type == "kafka" {
+		healthRegistry.Register(health.NewKafkaChecker(
+			a.Config.Broker.Kafka.Brokers,
+			a.Config.Broker.Kafka.GroupID,
+			a.Config.Broker.Kafka.InputTopic,
+			a.Config.Broker.Kafka.DLQTopic,
+			health.KafkaCheckerConfig{MaxLag: 10000},
+		))
+		if kc, ok := a.Consumer.(*broker.KafkaConsumer); ok {
+			healthRegistry.Register(health.NewKafkaConsumerStateChecker(kc))
+		}
 	}
 
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -144,48 +173,126 @@ func (a *App) Run(ctx context.Context) error {
 		})
 	}
 
+	if a.Config.ConfigSource.Type == "etcd" {
+		a.runEtcdConfigWatcher(ctx, g, gCtx)
+	} else {
+		a.runKafkaConfigConsumer(ctx, g, gCtx)
+	}
+
+	g.Go(func() error {
+		return a.service.StartReloader(gCtx)
+	})
+
+	inputTopic := a.Config.Broker.Kafka.InputTopic
+	g.Go(func() error {
+		return a.Consumer.Consume(gCtx, inputTopic, a.handleMessage)
+	})
+
+	return g.Wait()
+}
+
+// runKafkaConfigConsumer is the default config-update reload path: a Kafka
+// consumer on Broker.Kafka.ConfigUpdateTopic feeding filtering.Handler.
+func (a *App) runKafkaConfigConsumer(ctx context.Context, g *errgroup.Group, gCtx context.Context) {
 	configConsumer, err := broker.NewConsumer(a.Config.Broker, a.Logger)
 	if err != nil {
 		configCtx := logging.WithServiceName(ctx, "filtering-service")
 		a.Logger.WarnwCtx(configCtx, "Failed to create config event consumer, event-driven reload disabled",
 			"error", err,
 		)
-	} else {
-		configConsumer.SetServiceName("filtering-service")
-		defer configConsumer.Close()
-		configEventHandler := filtering.NewHandler(a.service, a.Logger)
+		return
+	}
 
-		g.Go(func() error {
-			configCtx := logging.WithServiceName(gCtx, "filtering-service")
-			a.Logger.InfowCtx(configCtx, "Starting config update event consumer",
-				"topic", a.Config.Broker.Kafka.ConfigUpdateTopic,
-			)
-			return configConsumer.Consume(gCtx, a.Config.Broker.Kafka.ConfigUpdateTopic, func(cCtx context.Context, msg models.MessageEnvelope) error {
-				return configEventHandler.HandleConfigUpdateEvent(cCtx, msg)
-			})
+	configConsumer.SetServiceName("filtering-service")
+	configEventHandler := filtering.NewHandler(a.service, a.Logger)
+	a.applyLagGate(configEventHandler)
+
+	g.Go(func() error {
+		defer configConsumer.Close()
+		configCtx := logging.WithServiceName(gCtx, "filtering-service")
+		a.Logger.InfowCtx(configCtx, "Starting config update event consumer",
+			"topic", a.Config.Broker.Kafka.ConfigUpdateTopic,
+		)
+		return configConsumer.Consume(gCtx, a.Config.Broker.Kafka.ConfigUpdateTopic, func(cCtx context.Context, msg models.MessageEnvelope) error {
+			return configEventHandler.HandleConfigUpdateEvent(cCtx, msg)
 		})
+	})
+}
+
+// applyLagGate wires handler's lag-readiness gate from
+// Broker.Kafka.LagGate, so a reload triggered by the Kafka config-update
+// consumer waits for InputTopic's consumer-group lag to drain before
+// committing - see config_handler.Handler.WithLagGate. It's a no-op when
+// LagGateConfig.Enabled is false (the default), and only makes sense for
+// the Kafka config consumer; runEtcdConfigWatcher never calls it since
+// etcd config sources have no Kafka lag to measure.
+func (a *App) applyLagGate(handler *filtering.Handler) {
+	lagGate := a.Config.Broker.Kafka.LagGate
+	if !lagGate.Enabled {
+		return
 	}
 
-	g.Go(func() error {
-		return a.service.StartReloader(gCtx)
+	checker := broker.NewLagChecker(a.Config.Broker.Kafka.Brokers, a.Config.Broker.Kafka.GroupID)
+	handler.WithLagGate(checker, a.Config.Broker.Kafka.InputTopic, config_handler.LagGateConfig{
+		Enabled:      lagGate.Enabled,
+		Deadline:     lagGate.Deadline,
+		PollInterval: lagGate.PollInterval,
+		Partitions:   lagGate.Partitions,
 	})
+}
+
+// runEtcdConfigWatcher is the ConfigSource.Type == "etcd" alternative to
+// runKafkaConfigConsumer: it watches Etcd.Prefix instead of a Kafka topic,
+// feeding the same filtering.Handler pipeline.
+func (a *App) runEtcdConfigWatcher(ctx context.Context, g *errgroup.Group, gCtx context.Context) {
+	watcher, err := configsource.NewEtcdWatcher(a.Config.ConfigSource.Etcd, models.EventTypeFilteringRuleUpdated, models.ServiceTypeFiltering, a.Logger)
+	if err != nil {
+		configCtx := logging.WithServiceName(ctx, "filtering-service")
+		a.Logger.WarnwCtx(configCtx, "Failed to create etcd config watcher, event-driven reload disabled",
+			"error", err,
+		)
+		return
+	}
+
+	configEventHandler := filtering.NewHandler(a.service, a.Logger)
 
-	inputTopic := a.Config.Broker.Kafka.InputTopic
 	g.Go(func() error {
-		return a.Consumer.Consume(gCtx, inputTopic, a.handleMessage)
-	})
+		defer watcher.Close()
+		configCtx := logging.WithServiceName(gCtx, "filtering-service")
+		a.Logger.InfowCtx(configCtx, "Starting etcd config watcher",
+			"prefix", a.Config.ConfigSource.Etcd.Prefix,
+		)
 
-	return g.Wait()
+		events, err := watcher.Watch(gCtx)
+		if err != nil {
+			return fmt.Errorf("failed to start etcd config watcher: %w", err)
+		}
+
+		for envelope := range events {
+			if err := configEventHandler.HandleConfigUpdateEvent(gCtx, envelope); err != nil {
+				a.Logger.ErrorwCtx(configCtx, "Failed to handle etcd config update event",
+					"error", err,
+				)
+			}
+		}
+
+		return gCtx.Err()
+	})
 }
 
 func (a *App) handleMessage(ctx context.Context, msg models.MessageEnvelope) error {
-	passed, appliedRules, err := a.service.Filter(ctx, msg)
+	passed, appliedRules, shadowResults, err := a.service.Filter(ctx, msg)
 	if err != nil {
 		a.Logger.ErrorwCtx(ctx, "Filter error",
 			"error", err,
 		)
 		return err
 	}
+	if len(shadowResults) > 0 {
+		a.Logger.DebugwCtx(ctx, "Shadow rule verdicts recorded",
+			"shadow_results_count", len(shadowResults),
+		)
+	}
 
 	if !passed {
 		a.Logger.InfowCtx(ctx, "Message filtered out")
@@ -238,6 +345,18 @@ func (a *App) Shutdown(ctx context.Context) error {
 			}
 		}
 
+		if a.meterProvider != nil {
+			if err := a.meterProvider.Shutdown(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("meter provider shutdown error: %w", err))
+			}
+		}
+
+		if a.service != nil {
+			if err := a.service.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("filtering service shutdown error: %w", err))
+			}
+		}
+
 		errs = append(errs, a.dbConnector.ShutdownDatabases(ctx, nil, a.db, nil)...)
 
 		return errs
@@ -4,24 +4,35 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"net"
 	"net/http"
+	"path/filepath"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
 	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/grpc"
 
-	_ "github.com/lib/pq" // PostgreSQL driver
+	_ "github.com/lib/pq"           // PostgreSQL driver
+	_ "github.com/mattn/go-sqlite3" // SQLite driver, used only when Management.EnrichmentStorage.Driver is "sqlite"
 
 	"yeti/internal/broker"
 	"yeti/internal/config"
 	"yeti/internal/constants"
+	"yeti/internal/enrichment/provider"
 	"yeti/internal/logger"
 	"yeti/internal/management"
+	"yeti/internal/management/scheduler"
 	"yeti/pkg/bootstrap"
 	"yeti/pkg/health"
 	"yeti/pkg/metrics"
+	otelmetrics "yeti/pkg/metrics/otel"
 	"yeti/pkg/middleware"
+	"yeti/pkg/migrations"
 	"yeti/pkg/ratelimit"
 	"yeti/pkg/tracing"
 
@@ -34,10 +45,19 @@ type App struct {
 	logger         logger.Logger
 	dbConnector    *bootstrap.DatabaseConnector
 	db             *sql.DB
+	sqliteDB       *sql.DB
 	mongoClient    *mongo.Client
 	server         *http.Server
 	router         *gin.Engine
 	tracerProvider *tracing.TracerProvider
+	meterProvider  *otelmetrics.MeterProvider
+	configWatcher  *config.Watcher
+	pusher         *metrics.Pusher
+	service        management.Service
+	grpcServer     *grpc.Server
+	redisClient    *redis.Client
+	scheduler      *scheduler.Scheduler
+	dlqIndexer     *management.DeadLetterIndexer
 }
 
 func NewApp(cfg *config.Config, log logger.Logger) *App {
@@ -61,21 +81,83 @@ func (a *App) Initialize(ctx context.Context) error {
 		return fmt.Errorf("failed to initialize server: %w", err)
 	}
 
+	a.initGRPCServer()
+
 	tp, err := tracing.Init(a.config.Tracing, "management-service")
 	if err != nil {
 		return fmt.Errorf("failed to initialize tracing: %w", err)
 	}
 	a.tracerProvider = tp
 
+	mp, err := otelmetrics.Init(a.config.Metrics, "management-service")
+	if err != nil {
+		return fmt.Errorf("failed to initialize OTLP metrics: %w", err)
+	}
+	a.meterProvider = mp
+
+	pusher, err := metrics.NewPusher(a.config.Metrics.Push, "management-service", prometheus.DefaultGatherer, a.logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize metrics pusher: %w", err)
+	}
+	a.pusher = pusher
+
+	a.initConfigWatcher(ctx)
+
 	return nil
 }
 
+// initConfigWatcher starts hot-reloading the config file this process was
+// started with, rebinding the log level when it changes and leaving the
+// running config untouched if a static section changed. Mirrors
+// bootstrap.Base.InitConfigWatcher for services that don't embed Base.
+func (a *App) initConfigWatcher(ctx context.Context) {
+	w := config.NewWatcher(config.CurrentConfigFile(), a.config, a.logger)
+
+	w.Subscribe("logging", func(change config.ConfigChange) {
+		newLogging, ok := change.New.(config.LoggingConfig)
+		if !ok {
+			return
+		}
+		if err := a.logger.SetLevel(newLogging.Level); err != nil {
+			a.logger.Warnw("failed to apply hot-reloaded log level", "level", newLogging.Level, "error", err)
+			return
+		}
+		a.logger.Infow("log level updated via config reload", "level", newLogging.Level)
+	})
+
+	w.Start(ctx)
+	a.configWatcher = w
+}
+
 func (a *App) initDatabase(ctx context.Context) error {
 	db, err := a.dbConnector.InitPostgreSQL(ctx)
 	if err != nil {
 		return err
 	}
 	a.db = db
+
+	if a.config.Database.RunMigrations && a.db != nil {
+		dir := filepath.Join(constants.DefaultMigrationsDir, "postgres")
+		if err := migrations.RunSQLMigrations(ctx, a.db, "postgres", dir); err != nil {
+			return fmt.Errorf("failed to run postgres migrations: %w", err)
+		}
+	}
+
+	if a.config.Management.EnrichmentStorage.Driver == "sqlite" {
+		sqliteDB, err := a.dbConnector.InitSQLite(ctx)
+		if err != nil {
+			return err
+		}
+		a.sqliteDB = sqliteDB
+
+		if a.config.Database.RunMigrations && a.sqliteDB != nil {
+			dir := filepath.Join(constants.DefaultMigrationsDir, "sqlite")
+			if err := migrations.RunSQLMigrations(ctx, a.sqliteDB, "sqlite3", dir); err != nil {
+				return fmt.Errorf("failed to run sqlite migrations: %w", err)
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -95,6 +177,7 @@ func (a *App) initRouter() error {
 
 	if a.config.Tracing.Enabled {
 		router.Use(tracing.GinMiddleware("management-service"))
+		router.Use(middleware.TraceContextMiddleware())
 	}
 
 	router.Use(middleware.RecoveryMiddleware(a.logger))
@@ -102,20 +185,46 @@ func (a *App) initRouter() error {
 	router.Use(middleware.RequestIDMiddleware())
 
 	if a.config.Management.RateLimit.Enabled {
+		rlCfg := a.config.Management.RateLimit
 		rateLimitConfig := ratelimit.RateLimitConfig{
-			RPS:             a.config.Management.RateLimit.RPS,
-			Burst:           a.config.Management.RateLimit.Burst,
-			CleanupInterval: time.Duration(a.config.Management.RateLimit.CleanupInterval) * time.Second,
-			MaxAge:          time.Duration(a.config.Management.RateLimit.MaxAge) * time.Second,
+			RPS:             rlCfg.RPS,
+			Burst:           rlCfg.Burst,
+			CleanupInterval: time.Duration(rlCfg.CleanupInterval) * time.Second,
+			MaxAge:          time.Duration(rlCfg.MaxAge) * time.Second,
+			Tiers:           make(map[string]ratelimit.TierLimit, len(rlCfg.Tiers)),
+		}
+		for tier, limit := range rlCfg.Tiers {
+			rateLimitConfig.Tiers[tier] = ratelimit.TierLimit{RPS: limit.RPS, Burst: limit.Burst}
+		}
+
+		// Keying on X-API-Key (the same header AuthMiddleware checks, read
+		// independently here since rate limiting runs before auth is
+		// resolved) gives an authenticated caller its own quota instead of
+		// sharing whatever IP it connects from; a caller with no key falls
+		// back to IPKeyFunc's "anonymous" tier.
+		store := ratelimit.Store(ratelimit.NewInMemoryStore(rateLimitConfig.CleanupInterval, rateLimitConfig.MaxAge))
+		if rlCfg.Backend == "redis" {
+			initCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			redisClient, err := a.dbConnector.InitRedis(initCtx)
+			cancel()
+			if err != nil {
+				a.logger.WarnwCtx(context.Background(), "Redis-backed rate limiting unavailable, falling back to in-memory", "error", err)
+			} else {
+				store = ratelimit.NewRedisStore(redisClient, "ratelimit:management:")
+			}
 		}
-		router.Use(ratelimit.RateLimitMiddleware(rateLimitConfig))
-		a.logger.InfowCtx(context.Background(), "Rate limiting enabled", "rps", rateLimitConfig.RPS, "burst", rateLimitConfig.Burst)
+
+		router.Use(ratelimit.RateLimitMiddleware(rateLimitConfig, store, ratelimit.HeaderKeyFunc("X-API-Key", "authenticated")))
+		a.logger.InfowCtx(context.Background(), "Rate limiting enabled", "rps", rateLimitConfig.RPS, "burst", rateLimitConfig.Burst, "backend", rlCfg.Backend)
 	}
 
 	repo := management.NewRepository(a.db)
 	versioningRepo := management.NewVersioningRepository(a.db)
 
-	var enrichmentRepo management.EnrichmentRepository
+	var mongoDB *mongo.Database
+	var sampleEventRepo management.SampleEventRepository
+	var apiKeyRepo management.APIKeyRepository
+	var providerRepo management.ProviderRepository
 	if a.config.Database.MongoDB.URI != "" {
 		initCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
@@ -129,12 +238,59 @@ func (a *App) initRouter() error {
 			if dbName == "" {
 				dbName = constants.DefaultMongoDBName
 			}
-			mongoDB := mongoClient.Database(dbName)
+			mongoDB = mongoClient.Database(dbName)
+
+			if a.config.Database.RunMigrations {
+				runOpts := migrations.RunOptions{}
+				if redisClient, err := a.dbConnector.InitRedis(initCtx); err != nil {
+					a.logger.WarnwCtx(initCtx, "Redis unavailable, running MongoDB migrations without a distributed lock", "error", err)
+				} else {
+					runOpts.Lock = migrations.NewDistributedLock(redisClient, uuid.New().String(), 2*time.Minute)
+				}
+
+				report, err := migrations.Run(initCtx, mongoDB, migrations.DefaultMongoSteps(), runOpts)
+				if err != nil {
+					a.logger.WarnwCtx(initCtx, "Failed to run MongoDB migrations", "error", err)
+				}
+				if report != nil {
+					for _, step := range report.Steps {
+						for _, idx := range step.Indexes {
+							a.logger.InfowCtx(initCtx, "MongoDB migration index", "migration", step.Name, "collection", idx.Collection, "index", idx.Name, "created", idx.Created)
+						}
+					}
+				}
+			}
 
-			enrichmentRepo = management.NewEnrichmentRepository(mongoDB)
+			sampleEventRepo = management.NewSampleEventRepository(mongoDB)
+			apiKeyRepo = management.NewAPIKeyRepository(mongoDB)
+			providerRepo = management.NewProviderRepository(mongoDB)
 		}
 	}
 
+	enrichmentRepo, err := management.NewEnrichmentRepositoryFromConfig(a.config.Management.EnrichmentStorage.Driver, mongoDB, a.db, a.sqliteDB)
+	if err != nil {
+		a.logger.WarnwCtx(context.Background(), "Enrichment repository unavailable, continuing without enrichment rules", "error", err)
+	}
+
+	// Dry-run/evaluate enrichment rules fetch through the same provider
+	// abstraction as enrichment-service, minus the circuit-breaker/retry
+	// wrapping: a rule evaluation endpoint should show an operator the
+	// provider's real current behavior. Redis/cache isn't wired up here -
+	// the only Redis connection this binary opens is the one
+	// initScheduler makes for schedule leader election, and only when
+	// Management.Scheduler.Enabled.
+	providers := map[string]provider.DataProvider{
+		constants.ProviderNameAPI:  provider.NewAPIProvider(),
+		constants.ProviderNameHTTP: provider.NewHTTPProvider(nil),
+		constants.ProviderNameGRPC: provider.NewGRPCSourceProvider(),
+	}
+	if a.mongoClient != nil {
+		providers[constants.ProviderNameMongoDB] = provider.NewMongoDBProvider(a.mongoClient)
+	}
+	if a.db != nil {
+		providers[constants.ProviderNamePostgreSQL] = provider.NewPostgreSQLProvider(a.db)
+	}
+
 	var configEventProducer *management.ConfigEventProducer
 	if a.config.Broker.Type == "kafka" && a.config.Broker.Kafka.ConfigUpdateTopic != "" {
 		producer, err := broker.NewProducer(a.config.Broker, a.logger)
@@ -147,6 +303,29 @@ func (a *App) initRouter() error {
 	}
 
 	opts := []management.ServiceOption{}
+
+	// Dead-letter tracking indexes whatever DLQ topic/subject/stream the
+	// configured broker type has set, so it's opt-in the same way the
+	// config event producer is: most deployments keep DLQTopic/DLQSubject/
+	// DLQStream unset during local development.
+	dlqRepo := management.NewDeadLetterRepository(a.db)
+	opts = append(opts, management.WithDeadLetters(dlqRepo))
+	if dlqTopic := broker.DLQTopic(a.config.Broker); dlqTopic != "" {
+		dlqConsumer, err := broker.NewConsumer(a.config.Broker, a.logger)
+		if err != nil {
+			a.logger.WarnwCtx(context.Background(), "Failed to create dead letter consumer, dead letter indexing will be disabled", "error", err)
+		} else {
+			a.dlqIndexer = management.NewDeadLetterIndexer(dlqRepo, dlqConsumer, dlqTopic, "management-service", a.logger)
+		}
+
+		replayProducer, err := broker.NewProducer(a.config.Broker, a.logger)
+		if err != nil {
+			a.logger.WarnwCtx(context.Background(), "Failed to create dead letter replay producer, replay will be disabled", "error", err)
+		} else {
+			opts = append(opts, management.WithDeadLetterReplayProducer(replayProducer))
+		}
+	}
+
 	if versioningRepo != nil {
 		opts = append(opts, management.WithVersioning(versioningRepo))
 	}
@@ -159,30 +338,37 @@ func (a *App) initRouter() error {
 	if a.config.Deduplication.HashAlgorithm != "" {
 		opts = append(opts, management.WithDeduplicationConfig(a.config.Deduplication))
 	}
+	if sampleEventRepo != nil {
+		opts = append(opts, management.WithSampleEventRepository(sampleEventRepo))
+	}
+	if providerRepo != nil {
+		opts = append(opts, management.WithProviderRepository(providerRepo))
+	}
+	opts = append(opts, management.WithEnrichmentProviders(providers))
 
 	svc := management.NewService(repo, opts...)
+	a.service = svc
+	management.SetMaxEstimatedCELCost(a.config.Management.CEL.MaxEstimatedCost)
 
-	filteringHandler := management.NewHandler(svc, a.logger)
-	enrichmentHandler := management.NewEnrichmentHandler(svc, a.logger)
-	deduplicationHandler := management.NewDeduplicationHandler(svc, a.logger)
-
-	filteringHandler.RegisterRoutes(router)
-	if enrichmentHandler != nil {
-		enrichmentHandler.RegisterEnrichmentRoutes(router)
-	}
-	if deduplicationHandler != nil {
-		deduplicationHandler.RegisterDeduplicationRoutes(router)
-	}
+	a.initScheduler(svc)
 
 	metrics.RegisterManagementMetrics()
+	metrics.RegisterCELMetrics()
 	metrics.RegisterCircuitBreakerMetrics()
+	metrics.RegisterPanicMetrics()
+	if a.config.Tracing.Sampler.Type == "tail_sampling" {
+		metrics.RegisterTracingMetrics()
+	}
 
 	healthRegistry := health.NewCheckerRegistry()
-	healthRegistry.Register(health.NewPostgreSQLChecker(a.db))
+	healthRegistry.Register(health.NewPostgreSQLChecker(a.db, health.PostgreSQLCheckerConfig{}))
 	if a.mongoClient != nil {
 		healthRegistry.Register(health.NewMongoDBChecker(a.mongoClient))
 	}
 
+	// /health, /metrics, and /swagger are registered before AuthMiddleware
+	// is added to the engine's middleware chain below, so they stay
+	// reachable without an API key regardless of Management.Auth.Enabled.
 	router.GET("/health", func(c *gin.Context) {
 		h := healthRegistry.Check(c.Request.Context())
 		statusCode := http.StatusOK
@@ -194,14 +380,114 @@ func (a *App) initRouter() error {
 		c.JSON(statusCode, h)
 	})
 
+	// /healthz/config reports the running config's validation state as
+	// structured JSON: fatal errors (there shouldn't be any, since Load
+	// already rejected a config that failed ValidateStatic) plus non-fatal
+	// ValidateWarnings findings, so ops tooling can see e.g. a zero dedup
+	// TTL without grepping logs.
+	router.GET("/healthz/config", func(c *gin.Context) {
+		errs := config.ValidateStatic(a.config)
+		warnings := config.ValidateWarnings(a.config)
+
+		statusCode := http.StatusOK
+		if errs != nil {
+			statusCode = http.StatusInternalServerError
+		}
+
+		c.JSON(statusCode, gin.H{
+			"valid":    errs == nil,
+			"errors":   errs,
+			"warnings": warnings,
+		})
+	})
+
 	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	var authSvc management.AuthService
+	if apiKeyRepo != nil {
+		authSvc = management.NewAuthService(apiKeyRepo)
+	}
+
+	if a.config.Management.Auth.Enabled && apiKeyRepo != nil && a.config.Management.Auth.BootstrapAPIKey != "" {
+		tenantID := a.config.Management.Auth.BootstrapTenantID
+		if tenantID == "" {
+			tenantID = management.DefaultTenantID
+		}
+		bootstrapCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := management.BootstrapAPIKey(bootstrapCtx, apiKeyRepo, tenantID, a.config.Management.Auth.BootstrapAPIKey); err != nil {
+			a.logger.WarnwCtx(bootstrapCtx, "Failed to seed bootstrap API key", "error", err)
+		}
+		cancel()
+	}
+
+	// AuthMiddleware always runs: with Management.Auth.Enabled false (the
+	// case for every deployment that hasn't provisioned keys), a missing
+	// X-API-Key scopes the caller to DefaultTenantID with every scope, so
+	// RequireScope below never blocks an unauthenticated caller. Once
+	// Enabled is true, a missing header is rejected instead.
+	router.Use(management.AuthMiddleware(authSvc, a.config.Management.Auth.Enabled))
+
+	filteringHandler := management.NewHandler(svc, a.logger)
+	enrichmentHandler := management.NewEnrichmentHandler(svc, a.logger)
+	deduplicationHandler := management.NewDeduplicationHandler(svc, a.logger)
+	bundleHandler := management.NewBundleHandler(svc, a.logger)
+	scheduleHandler := management.NewScheduleHandler(svc, a.logger)
+
+	filteringHandler.RegisterRoutes(router)
+	if enrichmentHandler != nil {
+		enrichmentHandler.RegisterEnrichmentRoutes(router)
+	}
+	if deduplicationHandler != nil {
+		deduplicationHandler.RegisterDeduplicationRoutes(router)
+	}
+	bundleHandler.RegisterBundleRoutes(router)
+	scheduleHandler.RegisterScheduleRoutes(router)
+
+	if apiKeyRepo != nil {
+		apiKeyHandler := management.NewAPIKeyHandler(authSvc, a.logger)
+		apiKeyHandler.RegisterAPIKeyRoutes(router)
+	}
+
+	if providerRepo != nil {
+		providerHandler := management.NewProviderHandler(svc, a.logger)
+		providerHandler.RegisterProviderRoutes(router)
+	}
+
+	debugHandler := management.NewDebugHandler(a.logger)
+	debugHandler.RegisterDebugRoutes(router)
+
 	a.router = router
 	return nil
 }
 
+// initScheduler wires up the management/scheduler subsystem when
+// Management.Scheduler.Enabled - it's the one thing in this binary that
+// needs a Redis connection (see the package doc comment on
+// internal/management/scheduler for why: leader election and next-fire
+// persistence across replicas and restarts). Left nil, like a.grpcServer,
+// when disabled, so Run/Shutdown just skip it.
+func (a *App) initScheduler(svc management.Service) {
+	if !a.config.Management.Scheduler.Enabled {
+		return
+	}
+
+	initCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	redisClient, err := a.dbConnector.InitRedis(initCtx)
+	cancel()
+	if err != nil {
+		a.logger.WarnwCtx(context.Background(), "Scheduler disabled: Redis connection unavailable", "error", err)
+		return
+	}
+	a.redisClient = redisClient
+
+	replicaID := uuid.New().String()
+	a.scheduler = scheduler.New(svc, redisClient, replicaID,
+		a.config.Management.Scheduler.PollInterval, a.config.Management.Scheduler.LeaderTTL, a.logger)
+	a.logger.InfowCtx(context.Background(), "Rule schedule evaluation enabled", "replica_id", replicaID)
+}
+
 func (a *App) initServer() error {
 	a.server = &http.Server{
 		Addr:    fmt.Sprintf(":%d", a.config.Server.Port),
@@ -210,6 +496,24 @@ func (a *App) initServer() error {
 	return nil
 }
 
+// initGRPCServer builds the gRPC counterpart to a.router - see
+// management.GRPCServer's doc comment. Left nil when GRPCPort is unset, so
+// Run/Shutdown skip it entirely rather than listening on a port nobody
+// asked for.
+func (a *App) initGRPCServer() {
+	if a.config.Server.GRPCPort == 0 {
+		return
+	}
+
+	recoveryCfg := middleware.RecoveryConfig{Logger: a.logger, Subsystem: "grpc"}
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(middleware.UnaryPanicInterceptor(recoveryCfg)),
+		grpc.ChainStreamInterceptor(middleware.StreamPanicInterceptor(recoveryCfg)),
+	)
+	management.NewGRPCServer(a.service).RegisterAll(srv)
+	a.grpcServer = srv
+}
+
 func (a *App) Run(ctx context.Context) error {
 	errChan := make(chan error, 1)
 	go func() {
@@ -219,6 +523,39 @@ func (a *App) Run(ctx context.Context) error {
 		}
 	}()
 
+	if a.config.Metrics.Push.Enabled || a.config.Metrics.Push.RemoteWrite.Enabled {
+		go func() {
+			if err := a.pusher.Start(ctx); err != nil && err != context.Canceled {
+				a.logger.ErrorwCtx(ctx, "Metrics pusher stopped", "error", err)
+			}
+		}()
+	}
+
+	if a.grpcServer != nil {
+		lis, err := net.Listen("tcp", fmt.Sprintf(":%d", a.config.Server.GRPCPort))
+		if err != nil {
+			return fmt.Errorf("grpc listen: %w", err)
+		}
+		go func() {
+			a.logger.InfowCtx(ctx, "gRPC server listening", "port", a.config.Server.GRPCPort)
+			if err := a.grpcServer.Serve(lis); err != nil {
+				errChan <- fmt.Errorf("grpc server error: %w", err)
+			}
+		}()
+	}
+
+	if a.scheduler != nil {
+		go a.scheduler.Run(ctx)
+	}
+
+	if a.dlqIndexer != nil {
+		go func() {
+			if err := a.dlqIndexer.Run(ctx); err != nil && err != context.Canceled {
+				a.logger.ErrorwCtx(ctx, "Dead letter indexer stopped", "error", err)
+			}
+		}()
+	}
+
 	select {
 	case <-ctx.Done():
 		return a.Shutdown(ctx)
@@ -241,15 +578,35 @@ func (a *App) Shutdown(ctx context.Context) error {
 		}
 	}
 
+	if a.grpcServer != nil {
+		a.grpcServer.GracefulStop()
+	}
+
+	if a.scheduler != nil {
+		a.scheduler.Stop()
+	}
+
 	if a.tracerProvider != nil {
 		if err := a.tracerProvider.Shutdown(ctx); err != nil {
 			errs = append(errs, fmt.Errorf("tracer provider shutdown error: %w", err))
 		}
 	}
 
-	dbErrs := a.dbConnector.ShutdownDatabases(ctx, nil, a.db, a.mongoClient)
+	if a.meterProvider != nil {
+		if err := a.meterProvider.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("meter provider shutdown error: %w", err))
+		}
+	}
+
+	dbErrs := a.dbConnector.ShutdownDatabases(ctx, a.redisClient, a.db, a.mongoClient)
 	errs = append(errs, dbErrs...)
 
+	if a.sqliteDB != nil {
+		if err := a.sqliteDB.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("sqlite close error: %w", err))
+		}
+	}
+
 	if len(errs) > 0 {
 		return fmt.Errorf("shutdown errors: %v", errs)
 	}
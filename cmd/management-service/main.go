@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/spf13/cobra"
 	"os"
@@ -15,7 +16,8 @@ import (
 )
 
 var (
-	configFile string
+	configFile  string
+	strictValid bool
 )
 
 // @title           Yeti Management Service API
@@ -46,12 +48,56 @@ func main() {
 	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Path to config file (required)")
 
 	rootCmd.AddCommand(serveCmd())
+	rootCmd.AddCommand(validateConfigCmd())
+	rootCmd.AddCommand(metricsCmd())
 
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
 	}
 }
 
+// validateConfigCmd runs the same static/dynamic validation serve would, but
+// exits instead of starting the service, so CI can check a config file
+// without standing up Postgres/Redis/a broker. Warnings (see
+// config.ValidateWarnings) are printed but don't fail the command unless
+// --strict is set, matching how serve itself only ever enforces fatal
+// ValidateStatic/ValidateDynamic errors.
+func validateConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate-config",
+		Short: "Validate a config file without starting the service",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if configFile == "" {
+				configFile = os.Getenv("CONFIG_FILE")
+				if configFile == "" {
+					return fmt.Errorf("config file is required")
+				}
+			}
+
+			cfg, err := config.Load(configFile)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return err
+			}
+
+			warnings := config.ValidateWarnings(cfg)
+			out, _ := json.MarshalIndent(map[string]interface{}{
+				"valid":    true,
+				"warnings": warnings,
+			}, "", "  ")
+			fmt.Println(string(out))
+
+			if strictValid && len(warnings) > 0 {
+				return fmt.Errorf("%d configuration warning(s) treated as errors (--strict)", len(warnings))
+			}
+
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&strictValid, "strict", false, "treat configuration warnings as errors")
+	return cmd
+}
+
 func serveCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "serve",
@@ -73,7 +119,7 @@ func serveCmd() *cobra.Command {
 				return err
 			}
 
-			log, err := logger.New(cfg.Logging.Level)
+			log, err := logger.NewWithSampling(cfg.Logging.Level, logger.SamplingConfig{Initial: cfg.Logging.Sampling.Initial, Thereafter: cfg.Logging.Sampling.Thereafter})
 			if err != nil {
 				earlyLog.Error("Failed to init logger: %v", err)
 				return err
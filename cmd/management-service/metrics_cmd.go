@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"yeti/internal/config"
+	"yeti/pkg/metrics/mixin"
+)
+
+var mixinOutDir string
+
+// metricsCmd groups metrics/observability tooling that doesn't need the
+// service itself running, the same way validateConfigCmd lets CI check a
+// config file without standing up Postgres/Redis/a broker.
+func metricsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "metrics",
+		Short: "Metrics and observability tooling",
+	}
+	cmd.AddCommand(genMixinCmd())
+	return cmd
+}
+
+// genMixinCmd renders config.SLO into a Prometheus recording/alert rules
+// file and a Grafana dashboard - see pkg/metrics/mixin - so operators get a
+// working observability bundle without hand-writing PromQL for each SLO
+// they declare.
+func genMixinCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gen-mixin",
+		Short: "Generate a Prometheus rules + Grafana dashboard bundle from config.SLO",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if configFile == "" {
+				configFile = os.Getenv("CONFIG_FILE")
+				if configFile == "" {
+					return fmt.Errorf("config file is required")
+				}
+			}
+
+			cfg, err := config.Load(configFile)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return err
+			}
+
+			bundle, err := mixin.Generate(cfg.SLO)
+			if err != nil {
+				return fmt.Errorf("failed to generate mixin: %w", err)
+			}
+
+			if err := os.MkdirAll(mixinOutDir, 0o755); err != nil {
+				return fmt.Errorf("failed to create output directory %s: %w", mixinOutDir, err)
+			}
+
+			rulesPath := filepath.Join(mixinOutDir, "slo_rules.yaml")
+			if err := os.WriteFile(rulesPath, bundle.RulesYAML, 0o644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", rulesPath, err)
+			}
+
+			dashboardPath := filepath.Join(mixinOutDir, "dashboard.json")
+			if err := os.WriteFile(dashboardPath, bundle.DashboardJSON, 0o644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", dashboardPath, err)
+			}
+
+			fmt.Printf("Wrote mixin bundle to %s\n", mixinOutDir)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&mixinOutDir, "out", "./mixin", "output directory for the generated rules/dashboard bundle")
+	return cmd
+}
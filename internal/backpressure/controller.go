@@ -0,0 +1,197 @@
+// Package backpressure turns the existing KafkaConsumerLag/
+// MessageQueueWaitDuration/CircuitBreakerState gauges into an actual control
+// loop instead of just dashboard inputs: Controller reads them off its own
+// prometheus.Gatherer on a tick, computes an EWMA of per-partition lag
+// growth and an approximate p95 queue-wait, and applies an AIMD
+// (additive-increase/multiplicative-decrease) policy to worker concurrency
+// and Kafka partition pause/resume.
+package backpressure
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"yeti/internal/config"
+	"yeti/internal/logger"
+	"yeti/pkg/metrics"
+)
+
+// WorkerLimiter is the concurrency knob Controller's AIMD policy drives.
+// Callers plug in their own worker-pool implementation; Controller only
+// ever asks for a new limit.
+type WorkerLimiter interface {
+	SetWorkerLimit(limit int)
+}
+
+// PartitionPauser is the partition-level knob Controller's AIMD policy
+// drives when lag keeps growing even after a concurrency cut. A
+// franz-go-backed Consumer implementation pauses/resumes via the consumer
+// group's own partition assignment; this package ships the decision engine
+// and the interface it needs against, not that binding.
+type PartitionPauser interface {
+	PausePartition(topic string, partition int32)
+	ResumePartition(topic string, partition int32)
+}
+
+type partitionKey struct {
+	topic     string
+	partition int32
+}
+
+// Controller is the AIMD control loop itself. Construct with NewController
+// and run with Start inside an errgroup, the same way
+// filtering.Service.StartReloader is run.
+type Controller struct {
+	cfg      config.BackpressureConfig
+	gatherer prometheus.Gatherer
+	limiter  WorkerLimiter
+	pauser   PartitionPauser
+	logger   logger.Logger
+
+	workerLimit int
+	lagEWMA     map[partitionKey]float64
+	paused      map[partitionKey]bool
+}
+
+// NewController builds a Controller. gatherer is typically
+// prometheus.DefaultGatherer; limiter/pauser are the caller's worker pool
+// and Kafka consumer respectively. workerLimit starts at cfg.MaxWorkers.
+func NewController(cfg config.BackpressureConfig, gatherer prometheus.Gatherer, limiter WorkerLimiter, pauser PartitionPauser, log logger.Logger) *Controller {
+	return &Controller{
+		cfg:         cfg,
+		gatherer:    gatherer,
+		limiter:     limiter,
+		pauser:      pauser,
+		logger:      log,
+		workerLimit: cfg.MaxWorkers,
+		lagEWMA:     make(map[partitionKey]float64),
+		paused:      make(map[partitionKey]bool),
+	}
+}
+
+// Start ticks every cfg.IntervalSeconds until ctx is cancelled, running one
+// AIMD decision per tick. A gather or decision error is logged and the loop
+// continues - a transient metrics-gathering hiccup shouldn't stop future
+// backpressure decisions.
+func (c *Controller) Start(ctx context.Context) error {
+	ticker := time.NewTicker(time.Duration(c.cfg.IntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.tick(ctx)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (c *Controller) tick(ctx context.Context) {
+	families, err := c.gatherer.Gather()
+	if err != nil {
+		c.logger.ErrorwCtx(ctx, "backpressure: failed to gather metrics", "error", err)
+		return
+	}
+
+	lagByPartition := kafkaConsumerLagByPartition(families)
+	queueWaitP95 := approximateQueueWaitP95Ms(families)
+
+	growing, worstKey, worstLag := c.updateLagEWMA(lagByPartition)
+	bad := growing || queueWaitP95 > c.cfg.QueueWaitThresholdMs
+
+	switch {
+	case bad:
+		c.decreaseWorkers(ctx)
+		if worstLag > float64(c.cfg.LagHighWatermark) {
+			c.pausePartition(ctx, worstKey)
+		}
+	case worstLag < float64(c.cfg.LagLowWatermark):
+		c.increaseWorkers(ctx)
+		c.resumeDrainedPartitions(ctx, lagByPartition)
+	}
+
+	metrics.SetBackpressurePausedPartitions(len(c.paused))
+}
+
+// updateLagEWMA folds this tick's lagByPartition samples into c.lagEWMA and
+// reports whether any partition's EWMA is both growing and above
+// LagHighWatermark, plus whichever partition has the highest EWMA (the
+// pause candidate).
+func (c *Controller) updateLagEWMA(lagByPartition map[partitionKey]int64) (growing bool, worstKey partitionKey, worstLag float64) {
+	for key, lag := range lagByPartition {
+		prev := c.lagEWMA[key]
+		ewma := c.cfg.EWMAAlpha*float64(lag) + (1-c.cfg.EWMAAlpha)*prev
+		c.lagEWMA[key] = ewma
+
+		if ewma > prev && ewma > float64(c.cfg.LagHighWatermark) {
+			growing = true
+		}
+		if ewma > worstLag {
+			worstLag = ewma
+			worstKey = key
+		}
+	}
+	return growing, worstKey, worstLag
+}
+
+func (c *Controller) decreaseWorkers(ctx context.Context) {
+	next := c.workerLimit / 2
+	if next < c.cfg.MinWorkers {
+		next = c.cfg.MinWorkers
+	}
+	if next == c.workerLimit {
+		return
+	}
+
+	c.workerLimit = next
+	c.limiter.SetWorkerLimit(c.workerLimit)
+	metrics.SetBackpressureWorkerLimit(c.workerLimit)
+	metrics.IncBackpressureDecision("decrease_workers")
+	c.logger.InfowCtx(ctx, "backpressure: decreased worker limit", "worker_limit", c.workerLimit)
+}
+
+func (c *Controller) increaseWorkers(ctx context.Context) {
+	next := c.workerLimit + 1
+	if next > c.cfg.MaxWorkers {
+		next = c.cfg.MaxWorkers
+	}
+	if next == c.workerLimit {
+		return
+	}
+
+	c.workerLimit = next
+	c.limiter.SetWorkerLimit(c.workerLimit)
+	metrics.SetBackpressureWorkerLimit(c.workerLimit)
+	metrics.IncBackpressureDecision("increase_workers")
+	c.logger.InfowCtx(ctx, "backpressure: increased worker limit", "worker_limit", c.workerLimit)
+}
+
+func (c *Controller) pausePartition(ctx context.Context, key partitionKey) {
+	if c.paused[key] {
+		return
+	}
+
+	c.paused[key] = true
+	c.pauser.PausePartition(key.topic, key.partition)
+	metrics.IncBackpressureDecision("pause_partition")
+	c.logger.InfowCtx(ctx, "backpressure: paused partition", "topic", key.topic, "partition", key.partition)
+}
+
+// resumeDrainedPartitions resumes every paused partition whose current lag
+// (not its EWMA - a resumed partition should be judged on where it actually
+// is right now) has drained below LagLowWatermark.
+func (c *Controller) resumeDrainedPartitions(ctx context.Context, lagByPartition map[partitionKey]int64) {
+	for key := range c.paused {
+		if lagByPartition[key] >= c.cfg.LagLowWatermark {
+			continue
+		}
+
+		delete(c.paused, key)
+		c.pauser.ResumePartition(key.topic, key.partition)
+		metrics.IncBackpressureDecision("resume_partition")
+		c.logger.InfowCtx(ctx, "backpressure: resumed partition", "topic", key.topic, "partition", key.partition)
+	}
+}
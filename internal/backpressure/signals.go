@@ -0,0 +1,102 @@
+package backpressure
+
+import (
+	"sort"
+	"strconv"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// kafkaConsumerLagByPartition extracts KafkaConsumerLag's current value per
+// (topic, partition), across every "service" label value - Controller
+// reacts to total lag per partition regardless of which service's consumer
+// reported it.
+func kafkaConsumerLagByPartition(families []*dto.MetricFamily) map[partitionKey]int64 {
+	lag := make(map[partitionKey]int64)
+
+	for _, family := range families {
+		if family.GetName() != "kafka_consumer_lag" {
+			continue
+		}
+
+		for _, m := range family.GetMetric() {
+			var topic string
+			var partition int64
+			for _, lp := range m.GetLabel() {
+				switch lp.GetName() {
+				case "topic":
+					topic = lp.GetValue()
+				case "partition":
+					partition, _ = strconv.ParseInt(lp.GetValue(), 10, 32)
+				}
+			}
+
+			key := partitionKey{topic: topic, partition: int32(partition)}
+			lag[key] += int64(m.GetGauge().GetValue())
+		}
+	}
+
+	return lag
+}
+
+// approximateQueueWaitP95Ms estimates MessageQueueWaitDuration's p95 across
+// every "service" label by summing its classic histogram buckets (it's
+// built via metrics.NewLatencyHistogram, so DefBuckets is always present)
+// and returning the upper bound of the bucket the 95th percentile of
+// observations falls into, without interpolating inside it. This is an
+// approximation - true quantile accuracy needs
+// either native histogram buckets or histogram_quantile evaluated
+// server-side in PromQL, neither of which this in-process reader has access
+// to - good enough for an AIMD threshold check, not for an SLO dashboard.
+func approximateQueueWaitP95Ms(families []*dto.MetricFamily) float64 {
+	var buckets []*dto.Bucket
+	var totalCount uint64
+
+	for _, family := range families {
+		if family.GetName() != "message_queue_wait_duration_ms" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			h := m.GetHistogram()
+			totalCount += h.GetSampleCount()
+			buckets = append(buckets, h.GetBucket()...)
+		}
+	}
+
+	if totalCount == 0 || len(buckets) == 0 {
+		return 0
+	}
+
+	merged := mergeBucketsByUpperBound(buckets)
+	target := float64(totalCount) * 0.95
+	for _, b := range merged {
+		if float64(b.GetCumulativeCount()) >= target {
+			return b.GetUpperBound()
+		}
+	}
+
+	return merged[len(merged)-1].GetUpperBound()
+}
+
+// mergeBucketsByUpperBound sums CumulativeCount across every series'
+// identical bucket boundary (le value) and returns them sorted ascending,
+// so multiple "service"-labeled histograms can be treated as one combined
+// distribution.
+func mergeBucketsByUpperBound(buckets []*dto.Bucket) []*dto.Bucket {
+	byBound := make(map[float64]uint64, len(buckets))
+	for _, b := range buckets {
+		byBound[b.GetUpperBound()] += b.GetCumulativeCount()
+	}
+
+	merged := make([]*dto.Bucket, 0, len(byBound))
+	for bound, count := range byBound {
+		bound, count := bound, count
+		merged = append(merged, &dto.Bucket{UpperBound: &bound, CumulativeCount: &count})
+	}
+
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].GetUpperBound() < merged[j].GetUpperBound()
+	})
+
+	return merged
+}
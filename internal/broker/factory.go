@@ -1,24 +1,75 @@
 package broker
 
 import (
+	"context"
 	"fmt"
 	"yeti/internal/config"
 	"yeti/internal/logger"
+	"yeti/pkg/schema"
 )
 
 func NewProducer(cfg config.BrokerConfig, log logger.Logger) (Producer, error) {
+	codec, err := schema.NewCodec(cfg.SchemaRegistry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize payload codec: %w", err)
+	}
+
+	if validator, ok := codec.(schema.SchemaValidator); ok {
+		if err := validator.ValidateSchema(context.Background()); err != nil {
+			return nil, fmt.Errorf("schema validation failed for %s codec: %w", cfg.SchemaRegistry.Codec, err)
+		}
+	}
+
 	switch cfg.Type {
 	case "kafka":
-		return NewKafkaProducer(cfg.Kafka, log), nil
+		driver, err := lookupKafkaDriver(cfg.Kafka.Driver)
+		if err != nil {
+			return nil, err
+		}
+		return driver.NewProducer(cfg.Kafka, log, codec)
+	case "nats":
+		return NewNATSProducer(cfg.NATS, log, codec)
+	case "redis-streams":
+		return NewRedisStreamsProducer(cfg.RedisStreams, log, codec)
 	default:
 		return nil, fmt.Errorf("unknown broker type: %s", cfg.Type)
 	}
 }
 
+// DLQTopic returns the configured DLQ topic/subject/stream name for cfg's
+// broker type, or "" if none is set (or the type is unrecognized). A caller
+// building a dead-letter consumer (see management.DeadLetterIndexer) needs
+// this because the field lives on a different sub-struct per broker type.
+func DLQTopic(cfg config.BrokerConfig) string {
+	switch cfg.Type {
+	case "kafka":
+		return cfg.Kafka.DLQTopic
+	case "nats":
+		return cfg.NATS.DLQSubject
+	case "redis-streams":
+		return cfg.RedisStreams.DLQStream
+	default:
+		return ""
+	}
+}
+
 func NewConsumer(cfg config.BrokerConfig, log logger.Logger) (Consumer, error) {
+	codec, err := schema.NewCodec(cfg.SchemaRegistry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize payload codec: %w", err)
+	}
+
 	switch cfg.Type {
 	case "kafka":
-		return NewKafkaConsumer(cfg.Kafka, log), nil
+		driver, err := lookupKafkaDriver(cfg.Kafka.Driver)
+		if err != nil {
+			return nil, err
+		}
+		return driver.NewConsumer(cfg.Kafka, cfg.RateLimit, log, codec)
+	case "nats":
+		return NewNATSConsumer(cfg.NATS, cfg.RateLimit, log, codec), nil
+	case "redis-streams":
+		return NewRedisStreamsConsumer(cfg.RedisStreams, cfg.RateLimit, log, codec), nil
 	default:
 		return nil, fmt.Errorf("unknown broker type: %s", cfg.Type)
 	}
@@ -0,0 +1,299 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	kafkago "github.com/segmentio/kafka-go"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"yeti/internal/config"
+	"yeti/internal/logger"
+	"yeti/pkg/logging"
+	"yeti/pkg/metrics"
+	"yeti/pkg/models"
+	"yeti/pkg/ratelimit"
+	"yeti/pkg/tracing"
+)
+
+// franzDriver is the KafkaDriver backed by github.com/twmb/franz-go. It
+// trades segmentio/kafka-go's reader/writer model for franz-go's
+// lower-allocation client and native KIP-848 (next-gen consumer group
+// protocol) support, while sharing the same retry/DLQ behavior via
+// processKafkaMessageWithRetry/publishKafkaDLQ.
+type franzDriver struct{}
+
+func init() {
+	RegisterKafkaDriver("franz", franzDriver{})
+}
+
+func (franzDriver) NewProducer(cfg config.KafkaConfig, log logger.Logger, codec models.Codec) (Producer, error) {
+	return NewFranzProducer(cfg, log, codec)
+}
+
+func (franzDriver) NewConsumer(cfg config.KafkaConfig, rateLimitCfg config.BrokerRateLimitConfig, log logger.Logger, codec models.Codec) (Consumer, error) {
+	return NewFranzConsumer(cfg, rateLimitCfg, log, codec), nil
+}
+
+// franzHeadersToKafka and kafkaHeadersToFranz convert between franz-go's and
+// segmentio/kafka-go's header types, which are structurally identical. This
+// lets both drivers inject and extract W3C trace context through the same
+// pkg/tracing helpers instead of duplicating propagation logic.
+func franzHeadersToKafka(headers []kgo.RecordHeader) []kafkago.Header {
+	out := make([]kafkago.Header, len(headers))
+	for i, h := range headers {
+		out[i] = kafkago.Header{Key: h.Key, Value: h.Value}
+	}
+	return out
+}
+
+func kafkaHeadersToFranz(headers []kafkago.Header) []kgo.RecordHeader {
+	out := make([]kgo.RecordHeader, len(headers))
+	for i, h := range headers {
+		out[i] = kgo.RecordHeader{Key: h.Key, Value: h.Value}
+	}
+	return out
+}
+
+type FranzProducer struct {
+	client *kgo.Client
+	logger logger.Logger
+	codec  models.Codec
+}
+
+func NewFranzProducer(cfg config.KafkaConfig, log logger.Logger, codec models.Codec) (*FranzProducer, error) {
+	if codec == nil {
+		codec = models.JSONCodec{}
+	}
+
+	client, err := kgo.NewClient(
+		kgo.SeedBrokers(cfg.Brokers...),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create franz-go client: %w", err)
+	}
+
+	return &FranzProducer{client: client, logger: log, codec: codec}, nil
+}
+
+func (p *FranzProducer) Publish(ctx context.Context, topic string, msg models.MessageEnvelope) error {
+	ctx, span := tracing.GetTracer("yeti-kafka").Start(ctx, "kafka.publish")
+	defer span.End()
+	tracing.SetKafkaMessageAttributes(span, topic, -1, msg.ID)
+
+	body, err := p.codec.Encode(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	headers := []kafkago.Header{{Key: contentTypeHeader, Value: []byte(p.codec.ContentType())}}
+	headers = tracing.InjectTraceContext(ctx, headers)
+	franzHeaders := kafkaHeadersToFranz(headers)
+
+	record := &kgo.Record{
+		Topic:     topic,
+		Key:       []byte(msg.ID),
+		Value:     body,
+		Headers:   franzHeaders,
+		Timestamp: time.Now(),
+	}
+
+	res := p.client.ProduceSync(ctx, record)
+	if err := res.FirstErr(); err != nil {
+		return fmt.Errorf("failed to write kafka message: %w", err)
+	}
+
+	return nil
+}
+
+func (p *FranzProducer) Close() error {
+	p.client.Close()
+	return nil
+}
+
+type FranzConsumer struct {
+	cfg         config.KafkaConfig
+	wg          sync.WaitGroup
+	client      *kgo.Client
+	logger      logger.Logger
+	dlqProducer Producer
+	serviceName string
+	codec       models.Codec
+	rateLimiter *ratelimit.BrokerLimiter
+}
+
+func NewFranzConsumer(cfg config.KafkaConfig, rateLimitCfg config.BrokerRateLimitConfig, log logger.Logger, codec models.Codec) *FranzConsumer {
+	if codec == nil {
+		codec = models.JSONCodec{}
+	}
+
+	consumer := &FranzConsumer{
+		cfg:         cfg,
+		logger:      log,
+		serviceName: "unknown",
+		codec:       codec,
+	}
+
+	if cfg.DLQTopic != "" {
+		if dlqProducer, err := NewFranzProducer(cfg, log, codec); err == nil {
+			consumer.dlqProducer = dlqProducer
+		} else {
+			log.Errorw("Failed to create franz-go DLQ producer", "error", err)
+		}
+	}
+
+	if rateLimitCfg.Enabled {
+		consumer.rateLimiter = ratelimit.NewBrokerLimiter(ratelimit.BrokerLimiterConfig{
+			MessagesPerSecond: rateLimitCfg.MessagesPerSecond,
+			Burst:             rateLimitCfg.Burst,
+			Strategy:          ratelimit.BrokerStrategy(rateLimitCfg.Strategy),
+		})
+	}
+
+	return consumer
+}
+
+func (c *FranzConsumer) SetServiceName(name string) {
+	c.serviceName = name
+}
+
+func (c *FranzConsumer) Consume(ctx context.Context, topic string, handler HandlerFunc) error {
+	client, err := kgo.NewClient(
+		kgo.SeedBrokers(c.cfg.Brokers...),
+		kgo.ConsumerGroup(c.cfg.GroupID),
+		kgo.ConsumeTopics(topic),
+		kgo.DisableAutoCommit(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create franz-go client: %w", err)
+	}
+	c.client = client
+	defer client.Close()
+
+	consumeCtx := logging.WithServiceName(ctx, c.serviceName)
+	c.logger.InfowCtx(consumeCtx, "Started consuming",
+		"topic", topic,
+		"brokers", c.cfg.Brokers,
+		"group_id", c.cfg.GroupID,
+		"service_name", c.serviceName,
+	)
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+
+		for {
+			if ctx.Err() != nil {
+				c.logger.InfowCtx(consumeCtx, "Stopped consuming",
+					"topic", topic,
+					"reason", "context canceled",
+				)
+				return
+			}
+
+			// Rate limiting pauses polling rather than dropping messages: we
+			// simply don't call PollFetches again until the limiter admits,
+			// mirroring the segmentio driver's fetch-pausing behavior to
+			// preserve at-least-once semantics.
+			if c.rateLimiter != nil {
+				wait, err := c.rateLimiter.Wait(ctx)
+				metrics.ObserveBrokerRateLimitWait(c.serviceName, topic, wait)
+				if err != nil {
+					if ctx.Err() != nil {
+						return
+					}
+					c.logger.ErrorwCtx(consumeCtx, "Rate limiter wait failed",
+						"error", err,
+						"topic", topic,
+					)
+					continue
+				}
+			}
+
+			fetches := client.PollFetches(ctx)
+			if fetches.IsClientClosed() {
+				return
+			}
+
+			fetches.EachError(func(_ string, _ int32, err error) {
+				c.logger.ErrorwCtx(consumeCtx, "Error fetching kafka message",
+					"error", err,
+					"topic", topic,
+				)
+			})
+
+			fetches.EachRecord(func(record *kgo.Record) {
+				c.handleRecord(ctx, consumeCtx, client, record, handler)
+			})
+		}
+	}()
+
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (c *FranzConsumer) handleRecord(ctx, consumeCtx context.Context, client *kgo.Client, record *kgo.Record, handler HandlerFunc) {
+	envelope, err := decodeKafkaEnvelope(c.codec, record.Value, kafkaHeaderValue(franzHeadersToKafka(record.Headers), contentTypeHeader))
+	if err != nil {
+		c.logger.ErrorwCtx(ctx, "Failed to decode message",
+			"error", err,
+			"topic", record.Topic,
+			"service_name", c.serviceName,
+		)
+		_ = client.CommitRecords(ctx, record)
+		return
+	}
+
+	msgCtx, span := tracing.StartSpanFromKafkaMessage(ctx, "kafka.consume", franzHeadersToKafka(record.Headers))
+	defer span.End()
+	tracing.SetKafkaMessageAttributes(span, record.Topic, int(record.Partition), string(record.Key))
+
+	if envelope.Metadata.TraceID != "" {
+		msgCtx = logging.WithTraceID(msgCtx, envelope.Metadata.TraceID)
+	}
+	msgCtx = logging.WithTraceContext(msgCtx)
+	msgCtx = logging.WithMessageID(msgCtx, envelope.ID)
+	msgCtx = logging.WithServiceName(msgCtx, c.serviceName)
+
+	if attempts, err := processKafkaMessageWithRetry(msgCtx, envelope, handler, record.Topic, c.serviceName, c.cfg.Retry, c.logger); err != nil {
+		c.logger.ErrorwCtx(msgCtx, "Failed to process message after retries",
+			"error", err,
+			"topic", record.Topic,
+		)
+		if c.dlqProducer != nil && c.cfg.DLQTopic != "" {
+			if dlqErr := publishKafkaDLQ(msgCtx, c.dlqProducer, envelope, err, attempts, record.Topic, c.cfg.DLQTopic, c.serviceName, c.logger); dlqErr != nil {
+				c.logger.ErrorwCtx(msgCtx, "Failed to send message to DLQ",
+					"error", dlqErr,
+					"topic", record.Topic,
+				)
+			}
+		} else {
+			c.logger.WarnwCtx(msgCtx, "No DLQ configured, committing message to avoid blocking",
+				"topic", record.Topic,
+			)
+		}
+		_ = client.CommitRecords(ctx, record)
+		return
+	}
+
+	if err := client.CommitRecords(ctx, record); err != nil {
+		c.logger.ErrorwCtx(msgCtx, "Failed to commit message",
+			"error", err,
+			"topic", record.Topic,
+		)
+	}
+}
+
+func (c *FranzConsumer) Close() error {
+	if c.client != nil {
+		c.client.Close()
+	}
+	var err error
+	if c.dlqProducer != nil {
+		err = c.dlqProducer.Close()
+	}
+	c.wg.Wait()
+	return err
+}
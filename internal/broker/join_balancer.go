@@ -0,0 +1,47 @@
+package broker
+
+import (
+	"sort"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// copartitionBalancer is a kafka.GroupBalancer that assigns partition index
+// i of every topic to the same group member, mirroring goka's
+// copartitioning rebalance strategy. JoinConsumer relies on this: its
+// readers for different topics must land matching partitions on the same
+// consumer instance, or a local (non-shuffled) stream join can't see both
+// sides of a key without a network hop. All subscribed topics must share
+// the same partition count, or partition i simply won't exist on the
+// thinner topics and those keys never complete.
+type copartitionBalancer struct{}
+
+func (copartitionBalancer) ProtocolName() string { return "copartition" }
+
+func (copartitionBalancer) UserData() ([]byte, error) { return nil, nil }
+
+func (copartitionBalancer) AssignGroups(members []kafka.GroupMember, partitions []kafka.Partition) kafka.GroupAssignments {
+	assignments := make(kafka.GroupAssignments, len(members))
+	for _, member := range members {
+		assignments[member.ID] = make(map[string][]int)
+	}
+
+	if len(members) == 0 {
+		return assignments
+	}
+
+	byTopic := make(map[string][]int)
+	for _, p := range partitions {
+		byTopic[p.Topic] = append(byTopic[p.Topic], p.ID)
+	}
+
+	for topic, ids := range byTopic {
+		sort.Ints(ids)
+		for _, partitionID := range ids {
+			member := members[partitionID%len(members)]
+			assignments[member.ID][topic] = append(assignments[member.ID][topic], partitionID)
+		}
+	}
+
+	return assignments
+}
@@ -0,0 +1,226 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"yeti/internal/logger"
+	"yeti/pkg/metrics"
+	"yeti/pkg/models"
+)
+
+// JoinHandlerFunc receives one tuple per join key, keyed by the topic name
+// each message arrived on. msgs contains every subscribed topic unless the
+// tuple was delivered because Window elapsed with JoinConsumerConfig's
+// AllowPartial set, in which case it contains only the topics that arrived
+// in time.
+type JoinHandlerFunc func(ctx context.Context, key string, msgs map[string]models.MessageEnvelope) error
+
+// JoinConsumerConfig configures a JoinConsumer.
+type JoinConsumerConfig struct {
+	Brokers []string
+	GroupID string
+	// Topics must be copartitioned (identical partition counts); see
+	// copartitionBalancer.
+	Topics []string
+	// Window bounds how long a partial tuple waits for its remaining
+	// topics. Defaults to 30s.
+	Window time.Duration
+	// AllowPartial delivers a tuple that's still missing topics once
+	// Window elapses, instead of dropping it.
+	AllowPartial bool
+	// StatePath is the BoltDB file backing pending tuples, so a restart
+	// mid-window doesn't lose them.
+	StatePath string
+}
+
+// JoinConsumer subscribes to N copartitioned Kafka topics and delivers a
+// JoinHandlerFunc one tuple per join key (the message key/partition key)
+// once every subscribed topic has produced a message for that key, or once
+// Window elapses. It unlocks stream-join use cases -- e.g. correlating a
+// filtering decision with an enrichment result -- that would otherwise need
+// an external stream processor.
+type JoinConsumer struct {
+	cfg     JoinConsumerConfig
+	logger  logger.Logger
+	codec   models.Codec
+	readers []*kafka.Reader
+	store   *joinStore
+
+	wg sync.WaitGroup
+}
+
+// NewJoinConsumer opens cfg.StatePath and creates one kafka.Reader per
+// topic, all sharing copartitionBalancer so matching partitions land on
+// this consumer instance together.
+func NewJoinConsumer(cfg JoinConsumerConfig, log logger.Logger) (*JoinConsumer, error) {
+	if len(cfg.Topics) < 2 {
+		return nil, fmt.Errorf("join consumer requires at least 2 topics, got %d", len(cfg.Topics))
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = 30 * time.Second
+	}
+
+	store, err := openJoinStore(cfg.StatePath, cfg.Topics)
+	if err != nil {
+		return nil, err
+	}
+
+	balancer := copartitionBalancer{}
+	readers := make([]*kafka.Reader, len(cfg.Topics))
+	for i, topic := range cfg.Topics {
+		readers[i] = kafka.NewReader(kafka.ReaderConfig{
+			Brokers:        cfg.Brokers,
+			Topic:          topic,
+			GroupID:        cfg.GroupID,
+			GroupBalancers: []kafka.GroupBalancer{balancer},
+			MinBytes:       10e3,
+			MaxBytes:       10e6,
+		})
+	}
+
+	return &JoinConsumer{
+		cfg:     cfg,
+		logger:  log,
+		codec:   models.JSONCodec{},
+		readers: readers,
+		store:   store,
+	}, nil
+}
+
+// Consume blocks, reading every subscribed topic and evicting stale tuples,
+// until ctx is cancelled. It returns ctx.Err() once every goroutine it
+// started has stopped.
+func (jc *JoinConsumer) Consume(ctx context.Context, handler JoinHandlerFunc) error {
+	for i, reader := range jc.readers {
+		topic := jc.cfg.Topics[i]
+		jc.wg.Add(1)
+		go func(topic string, reader *kafka.Reader) {
+			defer jc.wg.Done()
+			jc.consumeTopic(ctx, topic, reader, handler)
+		}(topic, reader)
+	}
+
+	jc.wg.Add(1)
+	go func() {
+		defer jc.wg.Done()
+		jc.evictLoop(ctx, handler)
+	}()
+
+	<-ctx.Done()
+	jc.wg.Wait()
+	return ctx.Err()
+}
+
+func (jc *JoinConsumer) consumeTopic(ctx context.Context, topic string, reader *kafka.Reader, handler JoinHandlerFunc) {
+	for {
+		m, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			jc.logger.ErrorwCtx(ctx, "Failed to fetch join message", "error", err, "topic", topic)
+			continue
+		}
+
+		envelope, err := jc.codec.Decode(m.Value)
+		if err != nil {
+			jc.logger.ErrorwCtx(ctx, "Failed to decode join message", "error", err, "topic", topic)
+			_ = reader.CommitMessages(ctx, m)
+			continue
+		}
+
+		key := string(m.Key)
+		if key == "" {
+			key = envelope.ID
+		}
+
+		tuple, err := jc.store.put(topic, key, envelope)
+		if err != nil {
+			jc.logger.ErrorwCtx(ctx, "Failed to record join tuple", "error", err, "topic", topic, "key", key)
+			_ = reader.CommitMessages(ctx, m)
+			continue
+		}
+
+		if tuple != nil {
+			jc.deliver(ctx, handler, key, tuple)
+		}
+
+		_ = reader.CommitMessages(ctx, m)
+	}
+}
+
+// evictLoop wakes up every Window/2 and hands any tuple that has outlived
+// Window to deliverExpired, so a key missing one topic doesn't wait on it
+// forever.
+func (jc *JoinConsumer) evictLoop(ctx context.Context, handler JoinHandlerFunc) {
+	interval := jc.cfg.Window / 2
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			stale, err := jc.store.evictStale(time.Now().Add(-jc.cfg.Window))
+			if err != nil {
+				jc.logger.ErrorwCtx(ctx, "Failed to evict stale join tuples", "error", err)
+				continue
+			}
+
+			for key, tuple := range stale {
+				if jc.cfg.AllowPartial {
+					jc.deliver(ctx, handler, key, tuple)
+				} else {
+					metrics.JoinTuplesExpiredTotal.WithLabelValues(jc.cfg.GroupID).Inc()
+					jc.logger.WarnwCtx(ctx, "Dropping expired join tuple",
+						"key", key,
+						"topics_seen", len(tuple),
+						"topics_expected", len(jc.cfg.Topics),
+					)
+				}
+			}
+
+			if pending, err := jc.store.pendingCount(); err == nil {
+				metrics.JoinPendingKeys.WithLabelValues(jc.cfg.GroupID).Set(float64(pending))
+			}
+		}
+	}
+}
+
+func (jc *JoinConsumer) deliver(ctx context.Context, handler JoinHandlerFunc, key string, tuple map[string]joinEntry) {
+	msgs := make(map[string]models.MessageEnvelope, len(tuple))
+	for topic, entry := range tuple {
+		msgs[topic] = entry.Envelope
+	}
+
+	if len(msgs) == len(jc.cfg.Topics) {
+		metrics.JoinTuplesCompletedTotal.WithLabelValues(jc.cfg.GroupID).Inc()
+	}
+
+	if err := handler(ctx, key, msgs); err != nil {
+		jc.logger.ErrorwCtx(ctx, "Join handler failed", "error", err, "key", key)
+	}
+}
+
+// Close closes every reader and the pending-tuple state file.
+func (jc *JoinConsumer) Close() error {
+	var firstErr error
+	for _, reader := range jc.readers {
+		if err := reader.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if err := jc.store.close(); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return firstErr
+}
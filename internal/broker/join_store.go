@@ -0,0 +1,161 @@
+package broker
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"yeti/pkg/models"
+)
+
+// joinEntry is what joinStore persists per (topic, key): the decoded
+// envelope plus when it arrived, so evictStale can tell a tuple has
+// outlived its window without needing a separate index.
+type joinEntry struct {
+	Envelope   models.MessageEnvelope `json:"envelope"`
+	ReceivedAt time.Time              `json:"received_at"`
+}
+
+// joinStore is the on-disk pending-tuple state for a JoinConsumer, backed
+// by BoltDB. Each topic gets its own bucket keyed by join key, so a message
+// arriving on one topic never has to scan the others to find out whether
+// it completes a tuple; it just checks whether every topic's bucket has an
+// entry for that key. Restarting a JoinConsumer reopens the same file, so a
+// half-joined key survives a restart instead of being silently dropped.
+type joinStore struct {
+	db     *bolt.DB
+	topics []string
+}
+
+func openJoinStore(path string, topics []string) (*joinStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open join state file %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, topic := range topics {
+			if _, err := tx.CreateBucketIfNotExists([]byte(topic)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to initialize join state buckets: %w", err)
+	}
+
+	return &joinStore{db: db, topics: topics}, nil
+}
+
+func (s *joinStore) close() error {
+	return s.db.Close()
+}
+
+// put records that topic produced envelope for key, then checks whether
+// every subscribed topic now has an entry for key. If so, it returns the
+// completed tuple and deletes it from the store; otherwise it returns a nil
+// map and the key stays pending for a later put or evictStale.
+func (s *joinStore) put(topic, key string, envelope models.MessageEnvelope) (map[string]joinEntry, error) {
+	var tuple map[string]joinEntry
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		data, err := json.Marshal(joinEntry{Envelope: envelope, ReceivedAt: time.Now()})
+		if err != nil {
+			return err
+		}
+		if err := tx.Bucket([]byte(topic)).Put([]byte(key), data); err != nil {
+			return err
+		}
+
+		candidate := make(map[string]joinEntry, len(s.topics))
+		for _, t := range s.topics {
+			raw := tx.Bucket([]byte(t)).Get([]byte(key))
+			if raw == nil {
+				return nil
+			}
+			var entry joinEntry
+			if err := json.Unmarshal(raw, &entry); err != nil {
+				return err
+			}
+			candidate[t] = entry
+		}
+
+		for _, t := range s.topics {
+			if err := tx.Bucket([]byte(t)).Delete([]byte(key)); err != nil {
+				return err
+			}
+		}
+		tuple = candidate
+		return nil
+	})
+
+	return tuple, err
+}
+
+// evictStale deletes and returns every key with at least one entry older
+// than cutoff, along with whatever partial tuple had accumulated for it.
+func (s *joinStore) evictStale(cutoff time.Time) (map[string]map[string]joinEntry, error) {
+	stale := make(map[string]map[string]joinEntry)
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		expiredKeys := make(map[string]struct{})
+		for _, topic := range s.topics {
+			b := tx.Bucket([]byte(topic))
+			c := b.Cursor()
+			for k, v := c.First(); k != nil; k, v = c.Next() {
+				var entry joinEntry
+				if err := json.Unmarshal(v, &entry); err != nil {
+					continue
+				}
+				if entry.ReceivedAt.Before(cutoff) {
+					expiredKeys[string(k)] = struct{}{}
+				}
+			}
+		}
+
+		for key := range expiredKeys {
+			tuple := make(map[string]joinEntry)
+			for _, topic := range s.topics {
+				b := tx.Bucket([]byte(topic))
+				raw := b.Get([]byte(key))
+				if raw == nil {
+					continue
+				}
+				var entry joinEntry
+				if err := json.Unmarshal(raw, &entry); err == nil {
+					tuple[topic] = entry
+				}
+				if err := b.Delete([]byte(key)); err != nil {
+					return err
+				}
+			}
+			stale[key] = tuple
+		}
+
+		return nil
+	})
+
+	return stale, err
+}
+
+// pendingCount returns the number of distinct keys with at least one topic
+// entry, for the JoinPendingKeys gauge.
+func (s *joinStore) pendingCount() (int, error) {
+	keys := make(map[string]struct{})
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		for _, topic := range s.topics {
+			c := tx.Bucket([]byte(topic)).Cursor()
+			for k, _ := c.First(); k != nil; k, _ = c.Next() {
+				keys[string(k)] = struct{}{}
+			}
+		}
+		return nil
+	})
+
+	return len(keys), err
+}
@@ -2,7 +2,7 @@ package broker
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -12,20 +12,36 @@ import (
 	"yeti/internal/config"
 	"yeti/internal/constants"
 	"yeti/internal/logger"
-	"yeti/pkg/errors"
 	"yeti/pkg/logging"
 	"yeti/pkg/metrics"
 	"yeti/pkg/models"
-	"yeti/pkg/retry"
+	"yeti/pkg/ratelimit"
 	"yeti/pkg/tracing"
 )
 
+// segmentioDriver is the default KafkaDriver, backed by
+// github.com/segmentio/kafka-go.
+type segmentioDriver struct{}
+
+func init() {
+	RegisterKafkaDriver("segmentio", segmentioDriver{})
+}
+
+func (segmentioDriver) NewProducer(cfg config.KafkaConfig, log logger.Logger, codec models.Codec) (Producer, error) {
+	return NewKafkaProducer(cfg, log, codec), nil
+}
+
+func (segmentioDriver) NewConsumer(cfg config.KafkaConfig, rateLimitCfg config.BrokerRateLimitConfig, log logger.Logger, codec models.Codec) (Consumer, error) {
+	return NewKafkaConsumer(cfg, rateLimitCfg, log, codec), nil
+}
+
 type KafkaProducer struct {
 	writer *kafka.Writer
 	logger logger.Logger
+	codec  models.Codec
 }
 
-func NewKafkaProducer(cfg config.KafkaConfig, log logger.Logger) *KafkaProducer {
+func NewKafkaProducer(cfg config.KafkaConfig, log logger.Logger, codec models.Codec) *KafkaProducer {
 	w := &kafka.Writer{
 		Addr:         kafka.TCP(cfg.Brokers...),
 		Balancer:     &kafka.LeastBytes{},
@@ -33,17 +49,24 @@ func NewKafkaProducer(cfg config.KafkaConfig, log logger.Logger) *KafkaProducer
 		WriteTimeout: constants.KafkaWriteTimeout,
 		Async:        false,
 	}
-	return &KafkaProducer{writer: w, logger: log}
+	if codec == nil {
+		codec = models.JSONCodec{}
+	}
+	return &KafkaProducer{writer: w, logger: log, codec: codec}
 }
 
 func (p *KafkaProducer) Publish(ctx context.Context, topic string, msg models.MessageEnvelope) error {
-	body, err := json.Marshal(msg)
+	ctx, span := tracing.GetTracer("yeti-kafka").Start(ctx, "kafka.publish")
+	defer span.End()
+	tracing.SetKafkaMessageAttributes(span, topic, -1, msg.ID)
+
+	body, err := p.codec.Encode(msg)
 	if err != nil {
-		return fmt.Errorf("failed to marshal message: %w", err)
+		return fmt.Errorf("failed to encode message: %w", err)
 	}
 
 	// Inject trace context into Kafka headers
-	headers := []kafka.Header{}
+	headers := []kafka.Header{{Key: contentTypeHeader, Value: []byte(p.codec.ContentType())}}
 	headers = tracing.InjectTraceContext(ctx, headers)
 
 	err = p.writer.WriteMessages(ctx,
@@ -74,17 +97,36 @@ type KafkaConsumer struct {
 	logger      logger.Logger
 	dlqProducer Producer
 	serviceName string
+	codec       models.Codec
+	rateLimiter *ratelimit.BrokerLimiter
+
+	stateMu   sync.RWMutex
+	state     ConsumerState
+	observers []ConsumerStateObserver
 }
 
-func NewKafkaConsumer(cfg config.KafkaConfig, log logger.Logger) *KafkaConsumer {
+func NewKafkaConsumer(cfg config.KafkaConfig, rateLimitCfg config.BrokerRateLimitConfig, log logger.Logger, codec models.Codec) *KafkaConsumer {
+	if codec == nil {
+		codec = models.JSONCodec{}
+	}
+
 	consumer := &KafkaConsumer{
 		cfg:         cfg,
 		logger:      log,
 		serviceName: "unknown",
+		codec:       codec,
 	}
 
 	if cfg.DLQTopic != "" {
-		consumer.dlqProducer = NewKafkaProducer(cfg, log)
+		consumer.dlqProducer = NewKafkaProducer(cfg, log, codec)
+	}
+
+	if rateLimitCfg.Enabled {
+		consumer.rateLimiter = ratelimit.NewBrokerLimiter(ratelimit.BrokerLimiterConfig{
+			MessagesPerSecond: rateLimitCfg.MessagesPerSecond,
+			Burst:             rateLimitCfg.Burst,
+			Strategy:          ratelimit.BrokerStrategy(rateLimitCfg.Strategy),
+		})
 	}
 
 	return consumer
@@ -102,6 +144,7 @@ func (c *KafkaConsumer) Consume(ctx context.Context, topic string, handler Handl
 			"service_name", c.serviceName,
 		)
 
+	c.setState(ctx, topic, StateConnecting)
 	c.reader = kafka.NewReader(kafka.ReaderConfig{
 		Brokers:  c.cfg.Brokers,
 		GroupID:  c.cfg.GroupID,
@@ -117,11 +160,38 @@ func (c *KafkaConsumer) Consume(ctx context.Context, topic string, handler Handl
 		c.logger.InfowCtx(consumeCtx, "Started consuming",
 			"topic", topic,
 		)
+		c.setState(consumeCtx, topic, StateRunning)
+		backOff := newConsumerBackoff()
 
 		for {
+			// Rate limiting pauses partition fetching rather than dropping
+			// messages: we simply don't call FetchMessage again until the
+			// limiter admits, which preserves at-least-once semantics since
+			// nothing is read off the partition until we're ready to
+			// process it.
+			if c.rateLimiter != nil {
+				wait, err := c.rateLimiter.Wait(ctx)
+				metrics.ObserveBrokerRateLimitWait(c.serviceName, topic, wait)
+				if err != nil {
+					if ctx.Err() != nil {
+						c.logger.InfowCtx(consumeCtx, "Stopped consuming",
+							"topic", topic,
+							"reason", "context canceled",
+						)
+						return
+					}
+					c.logger.ErrorwCtx(consumeCtx, "Rate limiter wait failed",
+						"error", err,
+						"topic", topic,
+					)
+					continue
+				}
+			}
+
 			m, err := c.reader.FetchMessage(ctx)
 			if err != nil {
 				if ctx.Err() != nil {
+					c.setState(consumeCtx, topic, StateStopped)
 					c.logger.InfowCtx(consumeCtx, "Stopped consuming",
 						"topic", topic,
 						"reason", "context canceled",
@@ -132,13 +202,17 @@ func (c *KafkaConsumer) Consume(ctx context.Context, topic string, handler Handl
 					"error", err,
 					"topic", topic,
 				)
-				time.Sleep(time.Second)
+				if reconnErr := c.reconnect(ctx, topic, backOff); reconnErr != nil {
+					c.setState(consumeCtx, topic, StateStopped)
+					return
+				}
 				continue
 			}
+			backOff.reset()
 
-			var envelope models.MessageEnvelope
-			if err := json.Unmarshal(m.Value, &envelope); err != nil {
-				c.logger.ErrorwCtx(ctx, "Failed to unmarshal message",
+			envelope, err := decodeKafkaEnvelope(c.codec, m.Value, kafkaHeaderValue(m.Headers, contentTypeHeader))
+			if err != nil {
+				c.logger.ErrorwCtx(ctx, "Failed to decode message",
 					"error", err,
 					"topic", topic,
 					"service_name", c.serviceName,
@@ -150,21 +224,23 @@ func (c *KafkaConsumer) Consume(ctx context.Context, topic string, handler Handl
 			// Extract trace context from Kafka headers and start span
 			msgCtx, span := tracing.StartSpanFromKafkaMessage(ctx, "kafka.consume", m.Headers)
 			defer span.End()
+			tracing.SetKafkaMessageAttributes(span, topic, m.Partition, string(m.Key))
 
 			// Enrich context with trace_id and message_id from envelope
 			if envelope.Metadata.TraceID != "" {
 				msgCtx = logging.WithTraceID(msgCtx, envelope.Metadata.TraceID)
 			}
+			msgCtx = logging.WithTraceContext(msgCtx)
 			msgCtx = logging.WithMessageID(msgCtx, envelope.ID)
 			msgCtx = logging.WithServiceName(msgCtx, c.serviceName)
 
-			if err := c.processMessageWithRetry(msgCtx, envelope, handler, topic); err != nil {
+			if attempts, err := c.processMessageWithRetry(msgCtx, envelope, handler, topic); err != nil {
 				c.logger.ErrorwCtx(msgCtx, "Failed to process message after retries",
 					"error", err,
 					"topic", topic,
 				)
 				if c.dlqProducer != nil && c.cfg.DLQTopic != "" {
-					if dlqErr := c.sendToDLQ(msgCtx, envelope, err, topic); dlqErr != nil {
+					if dlqErr := c.sendToDLQ(msgCtx, envelope, err, attempts, topic); dlqErr != nil {
 						c.logger.ErrorwCtx(msgCtx, "Failed to send message to DLQ",
 							"error", dlqErr,
 							"topic", topic,
@@ -194,6 +270,187 @@ func (c *KafkaConsumer) Consume(ctx context.Context, topic string, handler Handl
 	return ctx.Err()
 }
 
+// kafkaBatchEntry pairs a decoded envelope with the raw kafka.Message it came
+// from, so ConsumeBatch can commit or individually reprocess the underlying
+// message once the batch is handled.
+type kafkaBatchEntry struct {
+	msg      kafka.Message
+	envelope models.MessageEnvelope
+}
+
+// ConsumeBatch accumulates up to batchSize envelopes (or whatever arrives
+// within flushInterval, whichever comes first) and invokes handler once per
+// batch, letting callers do one bulk downstream operation instead of a round
+// trip per message. On success it commits only the last message in the
+// batch, which acks every prior offset on that partition. On failure it
+// falls back to processing each envelope in the batch individually through
+// processMessageWithRetry/DLQ, the same as Consume does for a single
+// message, so one bad record doesn't block or lose its batch-mates.
+func (c *KafkaConsumer) ConsumeBatch(ctx context.Context, topic string, batchSize int, flushInterval time.Duration, handler BatchHandlerFunc) error {
+	c.logger.Infow("Creating Kafka reader for batch consumption",
+		"topic", topic,
+		"brokers", c.cfg.Brokers,
+		"group_id", c.cfg.GroupID,
+		"service_name", c.serviceName,
+		"batch_size", batchSize,
+		"flush_interval", flushInterval,
+	)
+
+	c.setState(ctx, topic, StateConnecting)
+	c.reader = kafka.NewReader(kafka.ReaderConfig{
+		Brokers:  c.cfg.Brokers,
+		GroupID:  c.cfg.GroupID,
+		Topic:    topic,
+		MinBytes: 10e3,
+		MaxBytes: 10e6,
+	})
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		consumeCtx := logging.WithServiceName(ctx, c.serviceName)
+		c.logger.InfowCtx(consumeCtx, "Started consuming batches",
+			"topic", topic,
+			"batch_size", batchSize,
+			"flush_interval", flushInterval,
+		)
+		c.setState(consumeCtx, topic, StateRunning)
+		backOff := newConsumerBackoff()
+
+		batch := make([]kafkaBatchEntry, 0, batchSize)
+		batchStart := time.Now()
+
+		flush := func() {
+			if len(batch) == 0 {
+				return
+			}
+
+			envelopes := make([]models.MessageEnvelope, len(batch))
+			for i, entry := range batch {
+				envelopes[i] = entry.envelope
+			}
+
+			if err := handler(consumeCtx, envelopes); err != nil {
+				c.logger.ErrorwCtx(consumeCtx, "Failed to process batch, reprocessing messages individually",
+					"error", err,
+					"topic", topic,
+					"batch_size", len(batch),
+				)
+				c.reprocessBatchEntries(ctx, consumeCtx, topic, batch, handler)
+			} else if err := c.reader.CommitMessages(ctx, batch[len(batch)-1].msg); err != nil {
+				c.logger.ErrorwCtx(consumeCtx, "Failed to commit batch",
+					"error", err,
+					"topic", topic,
+				)
+			}
+
+			batch = batch[:0]
+		}
+
+		for {
+			if ctx.Err() != nil {
+				flush()
+				c.setState(consumeCtx, topic, StateStopped)
+				c.logger.InfowCtx(consumeCtx, "Stopped consuming",
+					"topic", topic,
+					"reason", "context canceled",
+				)
+				return
+			}
+
+			remaining := flushInterval - time.Since(batchStart)
+			if remaining <= 0 {
+				flush()
+				batchStart = time.Now()
+				remaining = flushInterval
+			}
+
+			fetchCtx, cancel := context.WithTimeout(ctx, remaining)
+			m, err := c.reader.FetchMessage(fetchCtx)
+			cancel()
+			if err != nil {
+				if ctx.Err() != nil {
+					continue
+				}
+				if errors.Is(err, context.DeadlineExceeded) {
+					flush()
+					batchStart = time.Now()
+					continue
+				}
+				c.logger.ErrorwCtx(consumeCtx, "Error fetching kafka message",
+					"error", err,
+					"topic", topic,
+				)
+				if reconnErr := c.reconnect(ctx, topic, backOff); reconnErr != nil {
+					c.setState(consumeCtx, topic, StateStopped)
+					return
+				}
+				continue
+			}
+			backOff.reset()
+
+			envelope, err := decodeKafkaEnvelope(c.codec, m.Value, kafkaHeaderValue(m.Headers, contentTypeHeader))
+			if err != nil {
+				c.logger.ErrorwCtx(ctx, "Failed to decode message",
+					"error", err,
+					"topic", topic,
+					"service_name", c.serviceName,
+				)
+				_ = c.reader.CommitMessages(ctx, m)
+				continue
+			}
+
+			batch = append(batch, kafkaBatchEntry{msg: m, envelope: envelope})
+			if len(batch) >= batchSize {
+				flush()
+				batchStart = time.Now()
+			}
+		}
+	}()
+
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// reprocessBatchEntries handles each entry of a batch that failed as a
+// whole, retrying it individually via handler (wrapped as a single-message
+// call) and routing permanent failures to the DLQ, then committing the
+// underlying message regardless of outcome so one bad record can't block
+// the partition.
+func (c *KafkaConsumer) reprocessBatchEntries(ctx, consumeCtx context.Context, topic string, batch []kafkaBatchEntry, handler BatchHandlerFunc) {
+	singleHandler := func(ctx context.Context, msg models.MessageEnvelope) error {
+		return handler(ctx, []models.MessageEnvelope{msg})
+	}
+
+	for _, entry := range batch {
+		if attempts, err := processKafkaMessageWithRetry(consumeCtx, entry.envelope, singleHandler, topic, c.serviceName, c.cfg.Retry, c.logger); err != nil {
+			c.logger.ErrorwCtx(consumeCtx, "Failed to process message after retries",
+				"error", err,
+				"topic", topic,
+			)
+			if c.dlqProducer != nil && c.cfg.DLQTopic != "" {
+				if dlqErr := publishKafkaDLQ(consumeCtx, c.dlqProducer, entry.envelope, err, attempts, topic, c.cfg.DLQTopic, c.serviceName, c.logger); dlqErr != nil {
+					c.logger.ErrorwCtx(consumeCtx, "Failed to send message to DLQ",
+						"error", dlqErr,
+						"topic", topic,
+					)
+				}
+			} else {
+				c.logger.WarnwCtx(consumeCtx, "No DLQ configured, committing message to avoid blocking",
+					"topic", topic,
+				)
+			}
+		}
+
+		if err := c.reader.CommitMessages(ctx, entry.msg); err != nil {
+			c.logger.ErrorwCtx(consumeCtx, "Failed to commit message",
+				"error", err,
+				"topic", topic,
+			)
+		}
+	}
+}
+
 func (c *KafkaConsumer) Close() error {
 	var err error
 	if c.reader != nil {
@@ -210,72 +467,10 @@ func (c *KafkaConsumer) Close() error {
 	return err
 }
 
-func (c *KafkaConsumer) processMessageWithRetry(ctx context.Context, envelope models.MessageEnvelope, handler HandlerFunc, topic string) error {
-	policy := retry.Policy{
-		MaxAttempts:     3,
-		InitialInterval: 1 * time.Second,
-		MaxInterval:     30 * time.Second,
-		Multiplier:      2.0,
-	}
-
-	if c.cfg.Retry.MaxAttempts > 0 {
-		policy.MaxAttempts = c.cfg.Retry.MaxAttempts
-	}
-	if c.cfg.Retry.InitialInterval > 0 {
-		policy.InitialInterval = c.cfg.Retry.InitialInterval
-	}
-	if c.cfg.Retry.MaxInterval > 0 {
-		policy.MaxInterval = c.cfg.Retry.MaxInterval
-	}
-	if c.cfg.Retry.Multiplier > 0 {
-		policy.Multiplier = c.cfg.Retry.Multiplier
-	}
-	if c.cfg.Retry.MaxElapsedTime > 0 {
-		policy.MaxElapsedTime = c.cfg.Retry.MaxElapsedTime
-	}
-
-	return retry.RetryWithCallback(ctx, policy, func() (err error) {
-		defer func() {
-			if r := recover(); r != nil {
-				err = errors.RecoverPanic(r)
-				c.logger.ErrorwCtx(ctx, "Panic recovered during message processing",
-					"error", err,
-					"topic", topic,
-				)
-			}
-		}()
-		return handler(ctx, envelope)
-	}, func(attempt int, err error, nextDelay time.Duration) {
-		metrics.RetryAttemptsTotal.WithLabelValues(c.serviceName, topic).Inc()
-		c.logger.WarnwCtx(ctx, "Retrying message processing",
-			"attempt", attempt,
-			"max_attempts", policy.MaxAttempts,
-			"next_delay", nextDelay,
-			"error", err,
-			"topic", topic,
-		)
-	})
+func (c *KafkaConsumer) processMessageWithRetry(ctx context.Context, envelope models.MessageEnvelope, handler HandlerFunc, topic string) (int, error) {
+	return processKafkaMessageWithRetry(ctx, envelope, handler, topic, c.serviceName, c.cfg.Retry, c.logger)
 }
 
-func (c *KafkaConsumer) sendToDLQ(ctx context.Context, envelope models.MessageEnvelope, originalErr error, sourceTopic string) error {
-	if envelope.Metadata.Enrichment == nil {
-		envelope.Metadata.Enrichment = make(map[string]interface{})
-	}
-	envelope.Metadata.Enrichment["dlq_reason"] = originalErr.Error()
-	envelope.Metadata.Enrichment["dlq_source_topic"] = sourceTopic
-	envelope.Metadata.Enrichment["dlq_timestamp"] = time.Now()
-
-	err := c.dlqProducer.Publish(ctx, c.cfg.DLQTopic, envelope)
-	if err != nil {
-		return fmt.Errorf("failed to publish to DLQ: %w", err)
-	}
-
-	metrics.DLQMessagesTotal.WithLabelValues(c.serviceName, sourceTopic, "max_retries_exceeded").Inc()
-	c.logger.InfowCtx(ctx, "Message sent to DLQ",
-		"source_topic", sourceTopic,
-		"dlq_topic", c.cfg.DLQTopic,
-		"reason", originalErr.Error(),
-	)
-
-	return nil
+func (c *KafkaConsumer) sendToDLQ(ctx context.Context, envelope models.MessageEnvelope, originalErr error, attempts int, sourceTopic string) error {
+	return publishKafkaDLQ(ctx, c.dlqProducer, envelope, originalErr, attempts, sourceTopic, c.cfg.DLQTopic, c.serviceName, c.logger)
 }
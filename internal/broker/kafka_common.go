@@ -0,0 +1,164 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"yeti/internal/config"
+	"yeti/internal/logger"
+	"yeti/pkg/errors"
+	"yeti/pkg/metrics"
+	"yeti/pkg/models"
+	"yeti/pkg/retry"
+)
+
+// contentTypeHeader is the Kafka message header producers stamp with the
+// encoding codec's models.Codec.ContentType(), so a consumer can dispatch
+// decoding by header rather than assuming every message on a topic was
+// written with whatever codec it's configured with today. This lets a
+// service migrate a topic from one codec to another (e.g. json -> avro)
+// topic-by-topic without a flag day: old and new messages coexist on the
+// same partition during the rollout.
+const contentTypeHeader = "content-type"
+
+// kafkaHeaderValue returns the value of the first header named key, or ""
+// if absent. Shared by both Kafka drivers via franzHeadersToKafka, so the
+// content-type dispatch logic only needs to know one header type.
+func kafkaHeaderValue(headers []kafka.Header, key string) string {
+	for _, h := range headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// decodeKafkaEnvelope decodes value using codec if contentType matches its
+// ContentType(), falls back to JSON if contentType is empty or explicitly
+// JSON (the legacy, unmarked wire format), and otherwise tries codec before
+// falling back to JSON so a stale or misconfigured header doesn't drop a
+// message that would've decoded fine.
+func decodeKafkaEnvelope(codec models.Codec, value []byte, contentType string) (models.MessageEnvelope, error) {
+	jsonCodec := models.JSONCodec{}
+	if contentType == "" || contentType == jsonCodec.ContentType() {
+		return jsonCodec.Decode(value)
+	}
+
+	msg, err := codec.Decode(value)
+	if err != nil && contentType != codec.ContentType() {
+		return jsonCodec.Decode(value)
+	}
+	return msg, err
+}
+
+// kafkaRetryPolicy builds a retry.Policy from config.RetryConfig, applying
+// the same defaults both Kafka driver implementations have always used when
+// a field is left unset.
+func kafkaRetryPolicy(cfg config.RetryConfig) retry.Policy {
+	policy := retry.Policy{
+		MaxAttempts:     3,
+		InitialInterval: 1 * time.Second,
+		MaxInterval:     30 * time.Second,
+		Multiplier:      2.0,
+	}
+
+	if cfg.MaxAttempts > 0 {
+		policy.MaxAttempts = cfg.MaxAttempts
+	}
+	if cfg.InitialInterval > 0 {
+		policy.InitialInterval = cfg.InitialInterval
+	}
+	if cfg.MaxInterval > 0 {
+		policy.MaxInterval = cfg.MaxInterval
+	}
+	if cfg.Multiplier > 0 {
+		policy.Multiplier = cfg.Multiplier
+	}
+	if cfg.MaxElapsedTime > 0 {
+		policy.MaxElapsedTime = cfg.MaxElapsedTime
+	}
+	if cfg.Jitter != "" {
+		policy.Jitter = retry.JitterStrategy(cfg.Jitter)
+	}
+
+	return policy
+}
+
+// processKafkaMessageWithRetry runs handler against envelope under the retry
+// policy derived from cfg, recovering panics the same way for every Kafka
+// driver. Shared by the segmentio and franz-go backends so a message that
+// fails transiently is retried identically regardless of which client
+// library fetched it. It returns the number of attempts actually made
+// (1 on a first-try success or a fatal error, up to policy.MaxAttempts on
+// exhaustion) alongside the final error, so a caller routing the message to
+// a DLQ can record how much work was spent on it.
+func processKafkaMessageWithRetry(ctx context.Context, envelope models.MessageEnvelope, handler HandlerFunc, topic, serviceName string, cfg config.RetryConfig, log logger.Logger) (int, error) {
+	policy := kafkaRetryPolicy(cfg)
+
+	attempts := 0
+	err := retry.RetryWithCallback(ctx, policy, func() (err error) {
+		attempts++
+		defer func() {
+			if r := recover(); r != nil {
+				err = errors.RecoverPanic(r)
+				log.ErrorwCtx(ctx, "Panic recovered during message processing",
+					"error", err,
+					"topic", topic,
+				)
+			}
+		}()
+		return handler(ctx, envelope)
+	}, func(attempt int, err error, nextDelay time.Duration) {
+		metrics.RetryAttemptsTotal.WithLabelValues(serviceName, topic).Inc()
+		log.WarnwCtx(ctx, "Retrying message processing",
+			"attempt", attempt,
+			"max_attempts", policy.MaxAttempts,
+			"next_delay", nextDelay,
+			"error", err,
+			"topic", topic,
+		)
+	})
+	return attempts, err
+}
+
+// publishKafkaDLQ annotates envelope with the failure reason, appends an
+// ErrorRecord describing it, and publishes it to dlqTopic via dlqProducer,
+// recording the same metrics and log line for every Kafka driver. attempts
+// is the number of processing attempts processKafkaMessageWithRetry made
+// before giving up on originalErr.
+func publishKafkaDLQ(ctx context.Context, dlqProducer Producer, envelope models.MessageEnvelope, originalErr error, attempts int, sourceTopic, dlqTopic, serviceName string, log logger.Logger) error {
+	if envelope.Metadata.Enrichment == nil {
+		envelope.Metadata.Enrichment = make(map[string]interface{})
+	}
+	now := time.Now()
+	envelope.Metadata.Enrichment["dlq_reason"] = originalErr.Error()
+	envelope.Metadata.Enrichment["dlq_source_topic"] = sourceTopic
+	envelope.Metadata.Enrichment["dlq_timestamp"] = now
+
+	envelope.Metadata.Errors = append(envelope.Metadata.Errors, models.ErrorRecord{
+		Service:     serviceName,
+		RuleID:      errors.RuleIDOf(originalErr),
+		ErrorClass:  errors.ClassOf(originalErr),
+		Message:     originalErr.Error(),
+		Attempts:    attempts,
+		FirstSeenAt: now,
+		LastSeenAt:  now,
+	})
+
+	if err := dlqProducer.Publish(ctx, dlqTopic, envelope); err != nil {
+		return fmt.Errorf("failed to publish to DLQ: %w", err)
+	}
+
+	metrics.DLQMessagesTotal.WithLabelValues(serviceName, sourceTopic, "max_retries_exceeded").Inc()
+	log.InfowCtx(ctx, "Message sent to DLQ",
+		"source_topic", sourceTopic,
+		"dlq_topic", dlqTopic,
+		"reason", originalErr.Error(),
+		"attempts", attempts,
+	)
+
+	return nil
+}
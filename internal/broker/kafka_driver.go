@@ -0,0 +1,57 @@
+package broker
+
+import (
+	"fmt"
+	"sync"
+
+	"yeti/internal/config"
+	"yeti/internal/logger"
+	"yeti/pkg/models"
+)
+
+// KafkaDriver constructs Kafka-backed producers and consumers for a specific
+// client library. Backends register themselves with RegisterKafkaDriver so
+// NewProducer/NewConsumer can select one by config.KafkaConfig.Driver,
+// mirroring how database/sql drivers register under a name.
+type KafkaDriver interface {
+	NewProducer(cfg config.KafkaConfig, log logger.Logger, codec models.Codec) (Producer, error)
+	NewConsumer(cfg config.KafkaConfig, rateLimitCfg config.BrokerRateLimitConfig, log logger.Logger, codec models.Codec) (Consumer, error)
+}
+
+const defaultKafkaDriver = "segmentio"
+
+var (
+	kafkaDriversMu sync.RWMutex
+	kafkaDrivers   = make(map[string]KafkaDriver)
+)
+
+// RegisterKafkaDriver makes a KafkaDriver available under name. It is
+// intended to be called from a driver package's init function and panics if
+// name is already registered.
+func RegisterKafkaDriver(name string, driver KafkaDriver) {
+	kafkaDriversMu.Lock()
+	defer kafkaDriversMu.Unlock()
+
+	if driver == nil {
+		panic("broker: RegisterKafkaDriver driver is nil")
+	}
+	if _, dup := kafkaDrivers[name]; dup {
+		panic("broker: RegisterKafkaDriver called twice for driver " + name)
+	}
+	kafkaDrivers[name] = driver
+}
+
+func lookupKafkaDriver(name string) (KafkaDriver, error) {
+	if name == "" {
+		name = defaultKafkaDriver
+	}
+
+	kafkaDriversMu.RLock()
+	defer kafkaDriversMu.RUnlock()
+
+	driver, ok := kafkaDrivers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown kafka driver: %s", name)
+	}
+	return driver, nil
+}
@@ -0,0 +1,155 @@
+package broker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"yeti/pkg/metrics"
+	"yeti/pkg/retry"
+)
+
+// ConsumerState models KafkaConsumer's connection lifecycle, mirroring
+// goka's partition-table state model so operators (and integration tests)
+// have a single signal for "is this consumer actually reading".
+type ConsumerState int
+
+const (
+	StateConnecting ConsumerState = iota
+	StateRebalancing
+	StateRunning
+	StateReconnecting
+	StateStopped
+)
+
+func (s ConsumerState) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateRebalancing:
+		return "rebalancing"
+	case StateRunning:
+		return "running"
+	case StateReconnecting:
+		return "reconnecting"
+	case StateStopped:
+		return "stopped"
+	default:
+		return "unknown"
+	}
+}
+
+// ConsumerStateObserver is notified synchronously whenever a KafkaConsumer's
+// state changes. Observers run on the consumer's fetch goroutine, so they
+// must not block.
+type ConsumerStateObserver func(old, new ConsumerState)
+
+// Subscribe registers an observer invoked on every state transition. It's
+// intended for integration tests that need to wait for a consumer to reach
+// StateRunning before publishing, and for operators wiring custom alerting.
+func (c *KafkaConsumer) Subscribe(observer ConsumerStateObserver) {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	c.observers = append(c.observers, observer)
+}
+
+// State returns the consumer's current ConsumerState.
+func (c *KafkaConsumer) State() ConsumerState {
+	c.stateMu.RLock()
+	defer c.stateMu.RUnlock()
+	return c.state
+}
+
+func (c *KafkaConsumer) setState(ctx context.Context, topic string, newState ConsumerState) {
+	c.stateMu.Lock()
+	old := c.state
+	c.state = newState
+	observers := append([]ConsumerStateObserver(nil), c.observers...)
+	c.stateMu.Unlock()
+
+	if old == newState {
+		return
+	}
+
+	metrics.KafkaConsumerState.WithLabelValues(c.serviceName, topic).Set(float64(newState))
+	c.logger.InfowCtx(ctx, "Kafka consumer state changed",
+		"topic", topic,
+		"from", old.String(),
+		"to", newState.String(),
+	)
+
+	for _, observer := range observers {
+		observer(old, newState)
+	}
+}
+
+// reconnect tears down the consumer's reader and recreates it, waiting out
+// an exponential backoff first so a flapping broker doesn't spin the fetch
+// loop. backOff is shared across calls for the lifetime of one Consume (or
+// ConsumeBatch) invocation so repeated failures grow the wait instead of
+// resetting it; callers should call backOff.Reset() after a successful
+// fetch.
+func (c *KafkaConsumer) reconnect(ctx context.Context, topic string, backOff *consumerBackoff) error {
+	c.setState(ctx, topic, StateReconnecting)
+
+	wait := backOff.next()
+	c.logger.WarnwCtx(ctx, "Kafka consumer reconnecting, backing off before recreating reader",
+		"topic", topic,
+		"backoff", wait,
+	)
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(wait):
+	}
+
+	if c.reader != nil {
+		_ = c.reader.Close()
+	}
+
+	c.setState(ctx, topic, StateConnecting)
+	c.reader = kafka.NewReader(kafka.ReaderConfig{
+		Brokers:  c.cfg.Brokers,
+		GroupID:  c.cfg.GroupID,
+		Topic:    topic,
+		MinBytes: 10e3,
+		MaxBytes: 10e6,
+	})
+	c.setState(ctx, topic, StateRunning)
+
+	return nil
+}
+
+// consumerBackoff wraps retry.ExponentialBackoff with a mutex: Consume and
+// ConsumeBatch both call reconnect from a single dedicated goroutine today,
+// but the type is safe to share if that ever changes.
+type consumerBackoff struct {
+	mu sync.Mutex
+	b  backoffIface
+}
+
+// backoffIface is the subset of github.com/cenkalti/backoff/v4.BackOff used
+// here, kept local so this file doesn't need the import just for a type name.
+type backoffIface interface {
+	NextBackOff() time.Duration
+	Reset()
+}
+
+func newConsumerBackoff() *consumerBackoff {
+	return &consumerBackoff{b: retry.ExponentialBackoff(1*time.Second, 30*time.Second, 2.0)}
+}
+
+func (b *consumerBackoff) next() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.NextBackOff()
+}
+
+func (b *consumerBackoff) reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.b.Reset()
+}
@@ -0,0 +1,121 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// LagChecker compares a consumer group's committed offsets against a
+// topic's partition end-offsets, for config_handler.Handler's two-phase
+// reload readiness gate: the handler polls Lag and only commits a prepared
+// reload once it reports zero, the same "don't mark ready until offsets
+// have caught up" check Knative-Kafka runs before flipping a subscription
+// ready.
+type LagChecker struct {
+	client  *kafka.Client
+	groupID string
+}
+
+// NewLagChecker builds a LagChecker that queries brokers directly (no
+// consumer group membership of its own, unlike KafkaConsumer) for groupID's
+// committed offsets.
+func NewLagChecker(brokers []string, groupID string) *LagChecker {
+	return &LagChecker{
+		client:  &kafka.Client{Addr: kafka.TCP(brokers...)},
+		groupID: groupID,
+	}
+}
+
+// Lag returns the sum, across partitions, of (end offset - committed
+// offset) for topic's partitions - all of them if partitions is empty,
+// otherwise just the ones listed. A partition the group has never
+// committed an offset for (a brand new topic/group) contributes its full
+// end offset, matching how kafka-go reports an unset commit as -1.
+func (l *LagChecker) Lag(ctx context.Context, topic string, partitions []int) (int64, error) {
+	if len(partitions) == 0 {
+		var err error
+		partitions, err = l.allPartitions(ctx, topic)
+		if err != nil {
+			return 0, fmt.Errorf("broker: failed to list partitions for %q: %w", topic, err)
+		}
+	}
+
+	endOffsets, err := l.endOffsets(ctx, topic, partitions)
+	if err != nil {
+		return 0, fmt.Errorf("broker: failed to read end offsets for %q: %w", topic, err)
+	}
+
+	committed, err := l.committedOffsets(ctx, topic, partitions)
+	if err != nil {
+		return 0, fmt.Errorf("broker: failed to read committed offsets for %q/%q: %w", topic, l.groupID, err)
+	}
+
+	var lag int64
+	for _, p := range partitions {
+		end := endOffsets[p]
+		offset := committed[p]
+		if offset < 0 {
+			offset = 0
+		}
+		if end > offset {
+			lag += end - offset
+		}
+	}
+	return lag, nil
+}
+
+func (l *LagChecker) allPartitions(ctx context.Context, topic string) ([]int, error) {
+	metadata, err := l.client.Metadata(ctx, &kafka.MetadataRequest{Topics: []string{topic}})
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range metadata.Topics {
+		if t.Name != topic {
+			continue
+		}
+		partitions := make([]int, len(t.Partitions))
+		for i, p := range t.Partitions {
+			partitions[i] = p.ID
+		}
+		return partitions, nil
+	}
+	return nil, fmt.Errorf("topic %q not found", topic)
+}
+
+func (l *LagChecker) endOffsets(ctx context.Context, topic string, partitions []int) (map[int]int64, error) {
+	offsetRequests := make([]kafka.OffsetRequest, len(partitions))
+	for i, p := range partitions {
+		offsetRequests[i] = kafka.LastOffsetOf(p)
+	}
+
+	resp, err := l.client.ListOffsets(ctx, &kafka.ListOffsetsRequest{
+		Topics: map[string][]kafka.OffsetRequest{topic: offsetRequests},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[int]int64, len(partitions))
+	for _, partitionOffsets := range resp.Topics[topic] {
+		result[partitionOffsets.Partition] = partitionOffsets.LastOffset
+	}
+	return result, nil
+}
+
+func (l *LagChecker) committedOffsets(ctx context.Context, topic string, partitions []int) (map[int]int64, error) {
+	resp, err := l.client.OffsetFetch(ctx, &kafka.OffsetFetchRequest{
+		GroupID: l.groupID,
+		Topics:  map[string][]int{topic: partitions},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[int]int64, len(partitions))
+	for _, partitionOffsets := range resp.Topics[topic] {
+		result[partitionOffsets.Partition] = partitionOffsets.CommittedOffset
+	}
+	return result, nil
+}
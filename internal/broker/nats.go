@@ -0,0 +1,356 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	"yeti/internal/config"
+	"yeti/internal/logger"
+	"yeti/pkg/errors"
+	"yeti/pkg/logging"
+	"yeti/pkg/metrics"
+	"yeti/pkg/models"
+	"yeti/pkg/ratelimit"
+	"yeti/pkg/retry"
+	"yeti/pkg/tracing"
+)
+
+type NATSProducer struct {
+	conn   *nats.Conn
+	js     nats.JetStreamContext
+	logger logger.Logger
+	codec  models.Codec
+}
+
+func NewNATSProducer(cfg config.NATSConfig, log logger.Logger, codec models.Codec) (*NATSProducer, error) {
+	if codec == nil {
+		codec = models.JSONCodec{}
+	}
+
+	conn, err := nats.Connect(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+
+	js, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to get JetStream context: %w", err)
+	}
+
+	return &NATSProducer{conn: conn, js: js, logger: log, codec: codec}, nil
+}
+
+func (p *NATSProducer) Publish(ctx context.Context, subject string, msg models.MessageEnvelope) error {
+	body, err := p.codec.Encode(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	natsMsg := &nats.Msg{
+		Subject: subject,
+		Data:    body,
+		Header:  nats.Header{},
+	}
+	natsMsg.Header = tracing.InjectTraceContextNATS(ctx, natsMsg.Header)
+
+	if _, err := p.js.PublishMsg(natsMsg, nats.Context(ctx)); err != nil {
+		return fmt.Errorf("failed to publish NATS message: %w", err)
+	}
+
+	return nil
+}
+
+func (p *NATSProducer) Close() error {
+	p.conn.Close()
+	return nil
+}
+
+type NATSConsumer struct {
+	cfg         config.NATSConfig
+	conn        *nats.Conn
+	sub         *nats.Subscription
+	logger      logger.Logger
+	codec       models.Codec
+	serviceName string
+	dlqProducer Producer
+	rateLimiter *ratelimit.BrokerLimiter
+}
+
+func NewNATSConsumer(cfg config.NATSConfig, rateLimitCfg config.BrokerRateLimitConfig, log logger.Logger, codec models.Codec) *NATSConsumer {
+	if codec == nil {
+		codec = models.JSONCodec{}
+	}
+
+	consumer := &NATSConsumer{
+		cfg:         cfg,
+		logger:      log,
+		serviceName: "unknown",
+		codec:       codec,
+	}
+
+	if rateLimitCfg.Enabled {
+		consumer.rateLimiter = ratelimit.NewBrokerLimiter(ratelimit.BrokerLimiterConfig{
+			MessagesPerSecond: rateLimitCfg.MessagesPerSecond,
+			Burst:             rateLimitCfg.Burst,
+			Strategy:          ratelimit.BrokerStrategy(rateLimitCfg.Strategy),
+		})
+	}
+
+	return consumer
+}
+
+func (c *NATSConsumer) SetServiceName(name string) {
+	c.serviceName = name
+}
+
+func (c *NATSConsumer) durableName(subject string) string {
+	if c.cfg.DurableName != "" {
+		return c.cfg.DurableName
+	}
+	return fmt.Sprintf("%s-%s", c.serviceName, subject)
+}
+
+func (c *NATSConsumer) Consume(ctx context.Context, subject string, handler HandlerFunc) error {
+	conn, err := nats.Connect(c.cfg.URL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	c.conn = conn
+
+	js, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to get JetStream context: %w", err)
+	}
+
+	if c.cfg.DLQSubject != "" {
+		dlqProducer, err := NewNATSProducer(c.cfg, c.logger, c.codec)
+		if err != nil {
+			conn.Close()
+			return fmt.Errorf("failed to create NATS DLQ producer: %w", err)
+		}
+		c.dlqProducer = dlqProducer
+	}
+
+	consumeCtx := logging.WithServiceName(ctx, c.serviceName)
+	c.logger.InfowCtx(consumeCtx, "Started consuming",
+		"subject", subject,
+		"durable", c.durableName(subject),
+	)
+
+	subOpts := []nats.SubOpt{nats.Durable(c.durableName(subject)), nats.ManualAck(), nats.AckExplicit()}
+	if c.cfg.Stream != "" {
+		subOpts = append(subOpts, nats.BindStream(c.cfg.Stream))
+	}
+	if c.cfg.AckWait > 0 {
+		subOpts = append(subOpts, nats.AckWait(c.cfg.AckWait))
+	}
+	if c.cfg.MaxDeliver > 0 {
+		subOpts = append(subOpts, nats.MaxDeliver(c.cfg.MaxDeliver))
+	}
+
+	sub, err := js.Subscribe(subject, func(m *nats.Msg) {
+		c.handleMessage(consumeCtx, m, subject, handler)
+	}, subOpts...)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to subscribe to NATS subject %s: %w", subject, err)
+	}
+	c.sub = sub
+
+	<-ctx.Done()
+	c.logger.InfowCtx(consumeCtx, "Stopped consuming",
+		"subject", subject,
+		"reason", "context canceled",
+	)
+	return ctx.Err()
+}
+
+func (c *NATSConsumer) handleMessage(ctx context.Context, m *nats.Msg, subject string, handler HandlerFunc) {
+	defer func() {
+		if r := recover(); r != nil {
+			err := errors.RecoverPanic(r)
+			c.logger.ErrorwCtx(ctx, "Panic recovered during message processing",
+				"error", err,
+				"subject", subject,
+			)
+			_ = m.Nak()
+		}
+	}()
+
+	// Rate limiting blocks the subscription callback rather than dropping
+	// the message: JetStream won't deliver the next message until this call
+	// returns, so a saturated limiter backpressures delivery much like
+	// pausing partition fetching does for Kafka, preserving at-least-once
+	// semantics.
+	if c.rateLimiter != nil {
+		wait, err := c.rateLimiter.Wait(ctx)
+		metrics.ObserveBrokerRateLimitWait(c.serviceName, subject, wait)
+		if err != nil {
+			c.logger.ErrorwCtx(ctx, "Rate limiter wait failed",
+				"error", err,
+				"subject", subject,
+			)
+			_ = m.Nak()
+			return
+		}
+	}
+
+	envelope, err := c.codec.Decode(m.Data)
+	if err != nil {
+		c.logger.ErrorwCtx(ctx, "Failed to decode message",
+			"error", err,
+			"subject", subject,
+			"service_name", c.serviceName,
+		)
+		_ = m.Ack()
+		return
+	}
+
+	msgCtx, span := tracing.StartSpanFromNATSMessage(ctx, "nats.consume", m.Header)
+	defer span.End()
+
+	if envelope.Metadata.TraceID != "" {
+		msgCtx = logging.WithTraceID(msgCtx, envelope.Metadata.TraceID)
+	}
+	msgCtx = logging.WithTraceContext(msgCtx)
+	msgCtx = logging.WithMessageID(msgCtx, envelope.ID)
+	msgCtx = logging.WithServiceName(msgCtx, c.serviceName)
+
+	if attempts, err := c.processMessageWithRetry(msgCtx, envelope, handler, subject); err != nil {
+		c.logger.ErrorwCtx(msgCtx, "Failed to process message after retries",
+			"error", err,
+			"subject", subject,
+		)
+		if c.dlqProducer != nil && c.cfg.DLQSubject != "" {
+			if dlqErr := c.sendToDLQ(msgCtx, envelope, err, attempts, subject); dlqErr != nil {
+				c.logger.ErrorwCtx(msgCtx, "Failed to send message to DLQ",
+					"error", dlqErr,
+					"subject", subject,
+				)
+			}
+		} else {
+			c.logger.WarnwCtx(msgCtx, "No DLQ configured, acking message to avoid blocking",
+				"subject", subject,
+			)
+		}
+		_ = m.Ack()
+		return
+	}
+
+	if err := m.Ack(); err != nil {
+		c.logger.ErrorwCtx(msgCtx, "Failed to ack message",
+			"error", err,
+			"subject", subject,
+		)
+	}
+}
+
+func (c *NATSConsumer) processMessageWithRetry(ctx context.Context, envelope models.MessageEnvelope, handler HandlerFunc, subject string) (int, error) {
+	policy := retry.Policy{
+		MaxAttempts:     3,
+		InitialInterval: 1 * time.Second,
+		MaxInterval:     30 * time.Second,
+		Multiplier:      2.0,
+	}
+
+	if c.cfg.Retry.MaxAttempts > 0 {
+		policy.MaxAttempts = c.cfg.Retry.MaxAttempts
+	}
+	if c.cfg.Retry.InitialInterval > 0 {
+		policy.InitialInterval = c.cfg.Retry.InitialInterval
+	}
+	if c.cfg.Retry.MaxInterval > 0 {
+		policy.MaxInterval = c.cfg.Retry.MaxInterval
+	}
+	if c.cfg.Retry.Multiplier > 0 {
+		policy.Multiplier = c.cfg.Retry.Multiplier
+	}
+	if c.cfg.Retry.MaxElapsedTime > 0 {
+		policy.MaxElapsedTime = c.cfg.Retry.MaxElapsedTime
+	}
+	if c.cfg.Retry.Jitter != "" {
+		policy.Jitter = retry.JitterStrategy(c.cfg.Retry.Jitter)
+	}
+
+	attempts := 0
+	err := retry.RetryWithCallback(ctx, policy, func() (err error) {
+		attempts++
+		defer func() {
+			if r := recover(); r != nil {
+				err = errors.RecoverPanic(r)
+				c.logger.ErrorwCtx(ctx, "Panic recovered during message processing",
+					"error", err,
+					"subject", subject,
+				)
+			}
+		}()
+		return handler(ctx, envelope)
+	}, func(attempt int, err error, nextDelay time.Duration) {
+		metrics.RetryAttemptsTotal.WithLabelValues(c.serviceName, subject).Inc()
+		c.logger.WarnwCtx(ctx, "Retrying message processing",
+			"attempt", attempt,
+			"max_attempts", policy.MaxAttempts,
+			"next_delay", nextDelay,
+			"error", err,
+			"subject", subject,
+		)
+	})
+	return attempts, err
+}
+
+func (c *NATSConsumer) sendToDLQ(ctx context.Context, envelope models.MessageEnvelope, originalErr error, attempts int, sourceSubject string) error {
+	if envelope.Metadata.Enrichment == nil {
+		envelope.Metadata.Enrichment = make(map[string]interface{})
+	}
+	now := time.Now()
+	envelope.Metadata.Enrichment["dlq_reason"] = originalErr.Error()
+	envelope.Metadata.Enrichment["dlq_source_topic"] = sourceSubject
+	envelope.Metadata.Enrichment["dlq_timestamp"] = now
+
+	envelope.Metadata.Errors = append(envelope.Metadata.Errors, models.ErrorRecord{
+		Service:     c.serviceName,
+		RuleID:      errors.RuleIDOf(originalErr),
+		ErrorClass:  errors.ClassOf(originalErr),
+		Message:     originalErr.Error(),
+		Attempts:    attempts,
+		FirstSeenAt: now,
+		LastSeenAt:  now,
+	})
+
+	err := c.dlqProducer.Publish(ctx, c.cfg.DLQSubject, envelope)
+	if err != nil {
+		return fmt.Errorf("failed to publish to DLQ: %w", err)
+	}
+
+	metrics.DLQMessagesTotal.WithLabelValues(c.serviceName, sourceSubject, "max_retries_exceeded").Inc()
+	c.logger.InfowCtx(ctx, "Message sent to DLQ",
+		"source_subject", sourceSubject,
+		"dlq_subject", c.cfg.DLQSubject,
+		"reason", originalErr.Error(),
+	)
+
+	return nil
+}
+
+func (c *NATSConsumer) Close() error {
+	var err error
+	if c.sub != nil {
+		err = c.sub.Unsubscribe()
+	}
+	if c.dlqProducer != nil {
+		if closeErr := c.dlqProducer.Close(); closeErr != nil {
+			if err == nil {
+				err = closeErr
+			}
+		}
+	}
+	if c.conn != nil {
+		c.conn.Close()
+	}
+	return err
+}
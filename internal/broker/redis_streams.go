@@ -0,0 +1,428 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"yeti/internal/config"
+	"yeti/internal/logger"
+	pkgerrors "yeti/pkg/errors"
+	"yeti/pkg/logging"
+	"yeti/pkg/metrics"
+	"yeti/pkg/models"
+	"yeti/pkg/ratelimit"
+	"yeti/pkg/retry"
+	"yeti/pkg/tracing"
+)
+
+type RedisStreamsProducer struct {
+	client *redis.Client
+	logger logger.Logger
+	codec  models.Codec
+}
+
+func NewRedisStreamsProducer(cfg config.RedisStreamsConfig, log logger.Logger, codec models.Codec) (*RedisStreamsProducer, error) {
+	if codec == nil {
+		codec = models.JSONCodec{}
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Addr,
+		Password: cfg.Password,
+		DB:       cfg.DB,
+	})
+
+	return &RedisStreamsProducer{client: client, logger: log, codec: codec}, nil
+}
+
+func (p *RedisStreamsProducer) Publish(ctx context.Context, stream string, msg models.MessageEnvelope) error {
+	ctx, span := tracing.GetTracer("yeti-redis-streams").Start(ctx, "redis_streams.publish")
+	defer span.End()
+	tracing.SetRedisStreamsMessageAttributes(span, stream, msg.ID)
+
+	body, err := p.codec.Encode(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	values := tracing.InjectTraceContextRedisStreams(ctx, map[string]interface{}{"payload": body})
+
+	if err := p.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: stream,
+		Values: values,
+	}).Err(); err != nil {
+		return fmt.Errorf("failed to publish to Redis stream %s: %w", stream, err)
+	}
+
+	return nil
+}
+
+func (p *RedisStreamsProducer) Close() error {
+	return p.client.Close()
+}
+
+// RedisStreamsConsumer reads a Redis Stream via a consumer group
+// (XREADGROUP), the Redis analogue of a JetStream durable consumer or
+// Kafka consumer group partition assignment. A background loop periodically
+// XAUTOCLAIMs entries idle longer than ClaimMinIdleSeconds, rescuing
+// messages left pending by a consumer that crashed before acking.
+type RedisStreamsConsumer struct {
+	cfg         config.RedisStreamsConfig
+	client      *redis.Client
+	logger      logger.Logger
+	codec       models.Codec
+	serviceName string
+	handler     HandlerFunc
+	dlqProducer Producer
+	rateLimiter *ratelimit.BrokerLimiter
+}
+
+func NewRedisStreamsConsumer(cfg config.RedisStreamsConfig, rateLimitCfg config.BrokerRateLimitConfig, log logger.Logger, codec models.Codec) *RedisStreamsConsumer {
+	if codec == nil {
+		codec = models.JSONCodec{}
+	}
+
+	consumer := &RedisStreamsConsumer{
+		cfg:         cfg,
+		logger:      log,
+		serviceName: "unknown",
+		codec:       codec,
+	}
+
+	if rateLimitCfg.Enabled {
+		consumer.rateLimiter = ratelimit.NewBrokerLimiter(ratelimit.BrokerLimiterConfig{
+			MessagesPerSecond: rateLimitCfg.MessagesPerSecond,
+			Burst:             rateLimitCfg.Burst,
+			Strategy:          ratelimit.BrokerStrategy(rateLimitCfg.Strategy),
+		})
+	}
+
+	return consumer
+}
+
+func (c *RedisStreamsConsumer) SetServiceName(name string) {
+	c.serviceName = name
+}
+
+func (c *RedisStreamsConsumer) consumerName() string {
+	if c.cfg.ConsumerName != "" {
+		return c.cfg.ConsumerName
+	}
+	return c.serviceName
+}
+
+func (c *RedisStreamsConsumer) Consume(ctx context.Context, stream string, handler HandlerFunc) error {
+	c.handler = handler
+	c.client = redis.NewClient(&redis.Options{
+		Addr:     c.cfg.Addr,
+		Password: c.cfg.Password,
+		DB:       c.cfg.DB,
+	})
+
+	if err := c.client.XGroupCreateMkStream(ctx, stream, c.cfg.ConsumerGroup, "0").Err(); err != nil && !errors.Is(err, redis.Nil) {
+		// BUSYGROUP means the group already exists, which is the expected
+		// steady-state case after the first consumer created it.
+		if !isBusyGroupErr(err) {
+			return fmt.Errorf("failed to create Redis consumer group %s on stream %s: %w", c.cfg.ConsumerGroup, stream, err)
+		}
+	}
+
+	if c.cfg.DLQStream != "" {
+		dlqProducer, err := NewRedisStreamsProducer(c.cfg, c.logger, c.codec)
+		if err != nil {
+			return fmt.Errorf("failed to create Redis streams DLQ producer: %w", err)
+		}
+		c.dlqProducer = dlqProducer
+	}
+
+	consumeCtx := logging.WithServiceName(ctx, c.serviceName)
+	c.logger.InfowCtx(consumeCtx, "Started consuming",
+		"stream", stream,
+		"consumer_group", c.cfg.ConsumerGroup,
+		"consumer_name", c.consumerName(),
+	)
+
+	if c.cfg.ClaimIntervalSeconds > 0 {
+		go c.claimLoop(consumeCtx, stream)
+	}
+
+	block := time.Duration(c.cfg.BlockMilliseconds) * time.Millisecond
+	if c.cfg.BlockMilliseconds == 0 {
+		block = 5 * time.Second
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			c.logger.InfowCtx(consumeCtx, "Stopped consuming",
+				"stream", stream,
+				"reason", "context canceled",
+			)
+			return ctx.Err()
+		default:
+		}
+
+		res, err := c.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    c.cfg.ConsumerGroup,
+			Consumer: c.consumerName(),
+			Streams:  []string{stream, ">"},
+			Count:    10,
+			Block:    block,
+		}).Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) || errors.Is(err, context.Canceled) {
+				continue
+			}
+			c.logger.ErrorwCtx(consumeCtx, "Failed to read from Redis stream",
+				"error", err,
+				"stream", stream,
+			)
+			continue
+		}
+
+		for _, streamResult := range res {
+			for _, entry := range streamResult.Messages {
+				c.handleMessage(consumeCtx, stream, entry, handler)
+			}
+		}
+	}
+}
+
+func (c *RedisStreamsConsumer) claimLoop(ctx context.Context, stream string) {
+	ticker := time.NewTicker(time.Duration(c.cfg.ClaimIntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	minIdle := time.Duration(c.cfg.ClaimMinIdleSeconds) * time.Second
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			var cursor string
+			for {
+				entries, nextCursor, err := c.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+					Stream:   stream,
+					Group:    c.cfg.ConsumerGroup,
+					Consumer: c.consumerName(),
+					MinIdle:  minIdle,
+					Start:    cursor,
+					Count:    100,
+				}).Result()
+				if err != nil {
+					c.logger.ErrorwCtx(ctx, "Failed to claim stuck Redis stream entries",
+						"error", err,
+						"stream", stream,
+					)
+					break
+				}
+
+				if len(entries) > 0 {
+					c.logger.WarnwCtx(ctx, "Claimed stuck Redis stream entries",
+						"stream", stream,
+						"count", len(entries),
+					)
+				}
+
+				for _, entry := range entries {
+					c.handleMessage(ctx, stream, entry, c.handler)
+				}
+
+				if nextCursor == "0" || nextCursor == "" {
+					break
+				}
+				cursor = nextCursor
+			}
+		}
+	}
+}
+
+// handleMessage acks/processes a stream entry, whether it came from a fresh
+// XREADGROUP read or was reclaimed from another consumer's pending list by
+// claimLoop.
+func (c *RedisStreamsConsumer) handleMessage(ctx context.Context, stream string, entry redis.XMessage, handler HandlerFunc) {
+	defer func() {
+		if r := recover(); r != nil {
+			err := pkgerrors.RecoverPanic(r)
+			c.logger.ErrorwCtx(ctx, "Panic recovered during message processing",
+				"error", err,
+				"stream", stream,
+			)
+		}
+	}()
+
+	if c.rateLimiter != nil {
+		wait, err := c.rateLimiter.Wait(ctx)
+		metrics.ObserveBrokerRateLimitWait(c.serviceName, stream, wait)
+		if err != nil {
+			c.logger.ErrorwCtx(ctx, "Rate limiter wait failed",
+				"error", err,
+				"stream", stream,
+			)
+			return
+		}
+	}
+
+	msgCtx, span := tracing.StartSpanFromRedisStreamsMessage(ctx, "redis_streams.consume", entry.Values)
+	defer span.End()
+	tracing.SetRedisStreamsMessageAttributes(span, stream, entry.ID)
+	ctx = msgCtx
+
+	payload, _ := entry.Values["payload"].(string)
+	envelope, err := c.codec.Decode([]byte(payload))
+	if err != nil {
+		c.logger.ErrorwCtx(ctx, "Failed to decode message",
+			"error", err,
+			"stream", stream,
+			"service_name", c.serviceName,
+		)
+		c.ack(ctx, stream, entry.ID)
+		return
+	}
+
+	msgCtx = logging.WithMessageID(ctx, envelope.ID)
+	msgCtx = logging.WithServiceName(msgCtx, c.serviceName)
+	if envelope.Metadata.TraceID != "" {
+		msgCtx = logging.WithTraceID(msgCtx, envelope.Metadata.TraceID)
+	}
+
+	if attempts, err := c.processMessageWithRetry(msgCtx, envelope, handler, stream); err != nil {
+		c.logger.ErrorwCtx(msgCtx, "Failed to process message after retries",
+			"error", err,
+			"stream", stream,
+		)
+		if c.dlqProducer != nil && c.cfg.DLQStream != "" {
+			if dlqErr := c.sendToDLQ(msgCtx, envelope, err, attempts, stream); dlqErr != nil {
+				c.logger.ErrorwCtx(msgCtx, "Failed to send message to DLQ",
+					"error", dlqErr,
+					"stream", stream,
+				)
+			}
+		} else {
+			c.logger.WarnwCtx(msgCtx, "No DLQ configured, acking message to avoid blocking",
+				"stream", stream,
+			)
+		}
+		c.ack(ctx, stream, entry.ID)
+		return
+	}
+
+	c.ack(ctx, stream, entry.ID)
+}
+
+func (c *RedisStreamsConsumer) ack(ctx context.Context, stream string, id string) {
+	if err := c.client.XAck(ctx, stream, c.cfg.ConsumerGroup, id).Err(); err != nil {
+		c.logger.ErrorwCtx(ctx, "Failed to ack Redis stream entry",
+			"error", err,
+			"stream", stream,
+			"id", id,
+		)
+	}
+}
+
+func (c *RedisStreamsConsumer) processMessageWithRetry(ctx context.Context, envelope models.MessageEnvelope, handler HandlerFunc, stream string) (int, error) {
+	policy := retry.Policy{
+		MaxAttempts:     3,
+		InitialInterval: 1 * time.Second,
+		MaxInterval:     30 * time.Second,
+		Multiplier:      2.0,
+	}
+
+	if c.cfg.Retry.MaxAttempts > 0 {
+		policy.MaxAttempts = c.cfg.Retry.MaxAttempts
+	}
+	if c.cfg.Retry.InitialInterval > 0 {
+		policy.InitialInterval = c.cfg.Retry.InitialInterval
+	}
+	if c.cfg.Retry.MaxInterval > 0 {
+		policy.MaxInterval = c.cfg.Retry.MaxInterval
+	}
+	if c.cfg.Retry.Multiplier > 0 {
+		policy.Multiplier = c.cfg.Retry.Multiplier
+	}
+	if c.cfg.Retry.MaxElapsedTime > 0 {
+		policy.MaxElapsedTime = c.cfg.Retry.MaxElapsedTime
+	}
+	if c.cfg.Retry.Jitter != "" {
+		policy.Jitter = retry.JitterStrategy(c.cfg.Retry.Jitter)
+	}
+
+	attempts := 0
+	err := retry.RetryWithCallback(ctx, policy, func() (err error) {
+		attempts++
+		defer func() {
+			if r := recover(); r != nil {
+				err = pkgerrors.RecoverPanic(r)
+				c.logger.ErrorwCtx(ctx, "Panic recovered during message processing",
+					"error", err,
+					"stream", stream,
+				)
+			}
+		}()
+		return handler(ctx, envelope)
+	}, func(attempt int, err error, nextDelay time.Duration) {
+		metrics.RetryAttemptsTotal.WithLabelValues(c.serviceName, stream).Inc()
+		c.logger.WarnwCtx(ctx, "Retrying message processing",
+			"attempt", attempt,
+			"max_attempts", policy.MaxAttempts,
+			"next_delay", nextDelay,
+			"error", err,
+			"stream", stream,
+		)
+	})
+	return attempts, err
+}
+
+func (c *RedisStreamsConsumer) sendToDLQ(ctx context.Context, envelope models.MessageEnvelope, originalErr error, attempts int, sourceStream string) error {
+	if envelope.Metadata.Enrichment == nil {
+		envelope.Metadata.Enrichment = make(map[string]interface{})
+	}
+	now := time.Now()
+	envelope.Metadata.Enrichment["dlq_reason"] = originalErr.Error()
+	envelope.Metadata.Enrichment["dlq_source_stream"] = sourceStream
+	envelope.Metadata.Enrichment["dlq_timestamp"] = now
+
+	envelope.Metadata.Errors = append(envelope.Metadata.Errors, models.ErrorRecord{
+		Service:     c.serviceName,
+		RuleID:      pkgerrors.RuleIDOf(originalErr),
+		ErrorClass:  pkgerrors.ClassOf(originalErr),
+		Message:     originalErr.Error(),
+		Attempts:    attempts,
+		FirstSeenAt: now,
+		LastSeenAt:  now,
+	})
+
+	if err := c.dlqProducer.Publish(ctx, c.cfg.DLQStream, envelope); err != nil {
+		return fmt.Errorf("failed to publish to DLQ: %w", err)
+	}
+
+	metrics.DLQMessagesTotal.WithLabelValues(c.serviceName, sourceStream, "max_retries_exceeded").Inc()
+	c.logger.InfowCtx(ctx, "Message sent to DLQ",
+		"source_stream", sourceStream,
+		"dlq_stream", c.cfg.DLQStream,
+		"reason", originalErr.Error(),
+	)
+
+	return nil
+}
+
+func (c *RedisStreamsConsumer) Close() error {
+	var err error
+	if c.dlqProducer != nil {
+		err = c.dlqProducer.Close()
+	}
+	if c.client != nil {
+		if closeErr := c.client.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+func isBusyGroupErr(err error) bool {
+	return err != nil && len(err.Error()) >= 9 && err.Error()[:9] == "BUSYGROUP"
+}
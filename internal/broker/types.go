@@ -2,7 +2,10 @@ package broker
 
 import (
 	"context"
+	"fmt"
+
 	"yeti/pkg/models"
+	"yeti/pkg/retry"
 )
 
 type Producer interface {
@@ -17,3 +20,34 @@ type Consumer interface {
 }
 
 type HandlerFunc func(ctx context.Context, msg models.MessageEnvelope) error
+
+// BatchHandlerFunc processes a batch of envelopes accumulated by
+// KafkaConsumer.ConsumeBatch, letting the caller do one bulk downstream
+// operation (e.g. a batched DB write) instead of a round trip per message.
+type BatchHandlerFunc func(ctx context.Context, msgs []models.MessageEnvelope) error
+
+// TypedHandlerFunc processes a decoded, type-safe payload instead of the raw
+// MessageEnvelope HandlerFunc gets, so a service that expects one payload
+// shape can declare it once as T instead of re-parsing msg.Payload by hand.
+type TypedHandlerFunc[T any] func(ctx context.Context, env *models.TypedEnvelope[T]) error
+
+// ConsumeTyped wraps handler in a HandlerFunc that decodes each envelope's
+// Payload into T and validates it against T's `validate` tags before
+// calling handler. A payload that fails to decode or validate is wrapped as
+// a retry.FatalError, so it skips straight to the DLQ (see
+// publishKafkaDLQ) with a payload_schema_mismatch reason instead of burning
+// retry attempts a malformed payload will never recover from.
+func ConsumeTyped[T any](ctx context.Context, consumer Consumer, topic string, handler TypedHandlerFunc[T]) error {
+	return consumer.Consume(ctx, topic, func(ctx context.Context, msg models.MessageEnvelope) error {
+		env, err := models.DecodeTypedEnvelope[T](msg)
+		if err != nil {
+			return retry.NewFatalError(fmt.Errorf("payload_schema_mismatch: %w", err))
+		}
+
+		if err := models.ValidateTypedEnvelope(env); err != nil {
+			return retry.NewFatalError(fmt.Errorf("payload_schema_mismatch: %w", err))
+		}
+
+		return handler(ctx, env)
+	})
+}
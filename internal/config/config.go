@@ -5,6 +5,11 @@ import (
 )
 
 type Config struct {
+	// Environment names the deployment tier this config describes (e.g.
+	// "production", "staging", "dev"). Empty is treated as non-production
+	// everywhere it's checked. It only gates non-fatal checks today - see
+	// ValidateWarnings - and carries no other runtime behavior.
+	Environment    string `mapstructure:"environment"`
 	Server         ServerConfig
 	Database       DatabaseConfig
 	Broker         BrokerConfig
@@ -15,6 +20,48 @@ type Config struct {
 	Management     ManagementConfig
 	CircuitBreaker CircuitBreakerConfig
 	Tracing        TracingConfig
+	Metrics        MetricsConfig
+	ConfigSource   ConfigSourceConfig
+	Secrets        SecretsConfig
+	SLO            SLOConfig
+	Backpressure   BackpressureConfig
+}
+
+// SecretsConfig configures how "${scheme:ref}" placeholders elsewhere in
+// Config (e.g. database.postgres.password: "${vault:secret/data/yeti#db_password}")
+// are resolved by a SecretResolver. "${env:VAR}" placeholders always work,
+// since EnvSecretProvider needs no configuration; Vault must be set to
+// resolve "${vault:path#field}" ones. See SecretResolver.ResolveConfig.
+type SecretsConfig struct {
+	Vault VaultConfig `mapstructure:"vault"`
+	// RefreshIntervalSeconds re-resolves every placeholder on this cadence
+	// (in addition to the existing file/SIGHUP triggers), so a secret's
+	// TTL expiring in Vault picks up its next value without either of
+	// those. Zero disables the timer.
+	RefreshIntervalSeconds int `mapstructure:"refresh_interval_seconds"`
+}
+
+type VaultConfig struct {
+	Address string `mapstructure:"address"`
+	Token   string `mapstructure:"token"`
+}
+
+// ConfigSourceConfig selects how a service learns about configuration
+// changes. Type "kafka" (the default) reloads from
+// Broker.Kafka.ConfigUpdateTopic; "etcd" watches Etcd.Prefix instead; "file"
+// means a service relies solely on the existing file/SIGHUP hot reload
+// (see pkg/config.Watcher) and runs no event-driven reload subsystem.
+type ConfigSourceConfig struct {
+	Type string           `mapstructure:"type"`
+	Etcd EtcdSourceConfig `mapstructure:"etcd"`
+}
+
+type EtcdSourceConfig struct {
+	Endpoints []string `mapstructure:"endpoints"`
+	// Prefix is the etcd key prefix watched for changes, e.g.
+	// "/yeti/dedup/rules/".
+	Prefix      string        `mapstructure:"prefix"`
+	DialTimeout time.Duration `mapstructure:"dial_timeout"`
 }
 
 type DynamicConfig struct{}
@@ -23,13 +70,19 @@ type ServerConfig struct {
 	Port                int           `mapstructure:"port"`
 	ReadTimeoutSeconds  time.Duration `mapstructure:"read_timeout_seconds"`
 	WriteTimeoutSeconds time.Duration `mapstructure:"write_timeout_seconds"`
+	// GRPCPort, when non-zero, starts management.GRPCServer alongside the
+	// REST API on this port - see cmd/management-service/app.go's
+	// initGRPCServer. Zero (the default) disables the gRPC listener
+	// entirely, leaving Port's REST API as the only surface.
+	GRPCPort int `mapstructure:"grpc_port"`
 }
 
 type DatabaseConfig struct {
 	Postgres      PostgresConfig
 	Redis         RedisConfig
 	MongoDB       MongoDBConfig
-	RunMigrations bool `mapstructure:"run_migrations"`
+	SQLite        SQLiteConfig `mapstructure:"sqlite"`
+	RunMigrations bool         `mapstructure:"run_migrations"`
 }
 
 type PostgresConfig struct {
@@ -54,10 +107,49 @@ type MongoDBConfig struct {
 	Database string `mapstructure:"database"`
 }
 
+// SQLiteConfig backs repositories that support an embedded/test-mode
+// driver (see management.NewEnrichmentRepositoryFromConfig). Path is a
+// filesystem path, or ":memory:" for an ephemeral in-process database;
+// empty means SQLite isn't configured.
+type SQLiteConfig struct {
+	Path string `mapstructure:"path"`
+}
+
 type BrokerConfig struct {
-	Type     string         `mapstructure:"type"`
-	RabbitMQ RabbitMQConfig `mapstructure:"rabbitmq"`
-	Kafka    KafkaConfig    `mapstructure:"kafka"`
+	Type           string                `mapstructure:"type"`
+	RabbitMQ       RabbitMQConfig        `mapstructure:"rabbitmq"`
+	Kafka          KafkaConfig           `mapstructure:"kafka"`
+	NATS           NATSConfig            `mapstructure:"nats"`
+	RedisStreams   RedisStreamsConfig    `mapstructure:"redis_streams"`
+	SchemaRegistry SchemaRegistryConfig  `mapstructure:"schema_registry"`
+	RateLimit      BrokerRateLimitConfig `mapstructure:"rate_limit"`
+}
+
+// BrokerRateLimitConfig throttles broker.Consumer's fetch loop to a
+// configured message rate, protecting downstream Redis/deduplication
+// lookups from bursty producers. Disabled (the default) applies no limit.
+type BrokerRateLimitConfig struct {
+	Enabled           bool    `mapstructure:"enabled"`
+	MessagesPerSecond float64 `mapstructure:"messages_per_second"`
+	Burst             int     `mapstructure:"burst"`
+	// Strategy is "token_bucket" (default, allows bursts up to Burst
+	// messages) or "leaky_bucket" (meters consumption at a constant rate
+	// with no burst allowance). See pkg/ratelimit.
+	Strategy string `mapstructure:"strategy"`
+}
+
+type SchemaRegistryConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	URL     string `mapstructure:"url"`
+	// Codec is "json" (default, no registry lookup), "avro", or "protobuf".
+	Codec   string        `mapstructure:"codec"`
+	Subject string        `mapstructure:"subject"`
+	Version int           `mapstructure:"version"` // 0 means "latest"
+	Timeout time.Duration `mapstructure:"timeout"`
+	// FallbackJSON decodes a message as plain JSON if schema-based
+	// decoding fails, instead of failing the message outright. Useful
+	// while migrating a topic from JSON to a schema-enforced codec.
+	FallbackJSON bool `mapstructure:"fallback_json"`
 }
 
 type RabbitMQConfig struct {
@@ -69,7 +161,66 @@ type RabbitMQConfig struct {
 	OutputQueue string `mapstructure:"output_queue"`
 }
 
+type NATSConfig struct {
+	URL                 string `mapstructure:"url"`
+	InputSubject        string `mapstructure:"input_subject"`
+	OutputSubject       string `mapstructure:"output_subject"`
+	ConfigUpdateSubject string `mapstructure:"config_update_subject"`
+	DLQSubject          string `mapstructure:"dlq_subject"`
+	// Stream is the JetStream stream name backing the durable consumer
+	// below. Empty lets the server derive one from the subject, which is
+	// fine for a stream auto-created by a producer but will fail
+	// validation once Stream is required for an explicit binding (see
+	// validateNATS).
+	Stream string `mapstructure:"stream"`
+	// DurableName is the JetStream durable consumer name. Empty falls back
+	// to the service name passed to Consumer.SetServiceName.
+	DurableName string `mapstructure:"durable_name"`
+	// AckWait is how long JetStream waits for an Ack before redelivering a
+	// message to this durable consumer. Zero falls back to the NATS
+	// client default (30s).
+	AckWait time.Duration `mapstructure:"ack_wait"`
+	// MaxDeliver caps redelivery attempts for a message this consumer
+	// hasn't acked; JetStream routes it to the DLQ-equivalent handling in
+	// NATSConsumer once exceeded. Zero (or negative) means unlimited,
+	// matching the NATS client default.
+	MaxDeliver int         `mapstructure:"max_deliver"`
+	Retry      RetryConfig `mapstructure:"retry"`
+}
+
+// RedisStreamsConfig configures a broker backed by a Redis Stream consumer
+// group (XREADGROUP), the lightweight alternative to NATS/Kafka for edge
+// deployments that already run Redis for deduplication/caching and don't
+// want to also operate a broker cluster.
+type RedisStreamsConfig struct {
+	Addr          string `mapstructure:"addr"`
+	Password      string `mapstructure:"password"`
+	DB            int    `mapstructure:"db"`
+	InputStream   string `mapstructure:"input_stream"`
+	OutputStream  string `mapstructure:"output_stream"`
+	DLQStream     string `mapstructure:"dlq_stream"`
+	ConsumerGroup string `mapstructure:"consumer_group"`
+	// ConsumerName identifies this process within ConsumerGroup. Empty
+	// falls back to the service name passed to Consumer.SetServiceName.
+	ConsumerName string `mapstructure:"consumer_name"`
+	// BlockMilliseconds is the XREADGROUP BLOCK duration: how long a read
+	// waits for a new entry before returning empty. Zero blocks forever.
+	BlockMilliseconds int `mapstructure:"block_milliseconds"`
+	// ClaimIntervalSeconds is how often the consumer scans the stream's
+	// pending entries list (PEL) for messages idle longer than
+	// ClaimMinIdleSeconds and claims them (XAUTOCLAIM), rescuing messages
+	// stuck after a consumer crashed mid-processing.
+	ClaimIntervalSeconds int         `mapstructure:"claim_interval_seconds"`
+	ClaimMinIdleSeconds  int         `mapstructure:"claim_min_idle_seconds"`
+	Retry                RetryConfig `mapstructure:"retry"`
+}
+
 type KafkaConfig struct {
+	// Driver selects the client library broker.NewProducer/NewConsumer use:
+	// "segmentio" (default, github.com/segmentio/kafka-go) or "franz"
+	// (github.com/twmb/franz-go, lower allocations and native KIP-848
+	// support). See broker.RegisterKafkaDriver.
+	Driver            string      `mapstructure:"driver"`
 	Brokers           []string    `mapstructure:"brokers"`
 	GroupID           string      `mapstructure:"group_id"`
 	InputTopic        string      `mapstructure:"input_topic"`
@@ -77,24 +228,159 @@ type KafkaConfig struct {
 	ConfigUpdateTopic string      `mapstructure:"config_update_topic"`
 	DLQTopic          string      `mapstructure:"dlq_topic"`
 	Retry             RetryConfig `mapstructure:"retry"`
+	// LagGate configures config_handler.Handler's two-phase reload
+	// readiness gate against InputTopic/GroupID; see LagGateConfig.
+	LagGate LagGateConfig `mapstructure:"lag_gate"`
+}
+
+// LagGateConfig controls whether config_handler.Handler defers committing
+// a prepared rule reload until InputTopic's consumer-group lag (committed
+// offset vs. partition end-offset, see broker.LagChecker) reaches zero -
+// closing the window where downstream processing continues against
+// in-flight messages produced under the pre-reload rules. When Enabled is
+// false (the default), Handler commits a prepared reload immediately, the
+// same as it always has.
+type LagGateConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Deadline bounds how long Handler waits for lag to reach zero before
+	// force-committing anyway; 0 means wait forever. A forced commit is
+	// logged and counted via metrics.ConfigReloadForcedCommitsTotal.
+	Deadline time.Duration `mapstructure:"deadline"`
+	// PollInterval is how often Handler re-checks lag while waiting.
+	// Defaults to 1s.
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+	// Partitions scopes the lag check to specific partition IDs; empty
+	// (the default) checks every partition of InputTopic.
+	Partitions []int `mapstructure:"partitions"`
 }
 
 type RetryConfig struct {
+	Enabled         bool          `mapstructure:"enabled"`
 	MaxAttempts     int           `mapstructure:"max_attempts"`
 	InitialInterval time.Duration `mapstructure:"initial_interval"`
 	MaxInterval     time.Duration `mapstructure:"max_interval"`
 	Multiplier      float64       `mapstructure:"multiplier"`
 	MaxElapsedTime  time.Duration `mapstructure:"max_elapsed_time"`
+	// Jitter is one of "none", "full", or "decorrelated". Empty is
+	// treated as "none" (pure exponential backoff). See pkg/retry.
+	Jitter string `mapstructure:"jitter"`
 }
 
 type LoggingConfig struct {
-	Level  string `mapstructure:"level"`
-	Format string `mapstructure:"format"`
+	Level    string         `mapstructure:"level"`
+	Format   string         `mapstructure:"format"`
+	Sampling SamplingConfig `mapstructure:"sampling"`
+}
+
+// SamplingConfig tunes zap's log sampler: per second, the first Initial
+// entries at a given level are logged, then only every Thereafter-th one
+// after that. Error level is always exempt regardless of these values,
+// since errors are rare enough not to need throttling and too important to
+// drop silently. Zero values disable sampling (every entry is logged).
+type SamplingConfig struct {
+	Initial    int `mapstructure:"initial"`
+	Thereafter int `mapstructure:"thereafter"`
 }
 
 type FilteringConfig struct {
 	Reload   ReloadConfig   `mapstructure:"reload"`
 	Fallback FallbackConfig `mapstructure:"fallback"`
+	CEL      CELConfig      `mapstructure:"cel"`
+
+	// GroupConcurrency bounds how many same-priority rules (see
+	// filtering.RuleGroup) a single Filter call evaluates at once. <= 0 falls
+	// back to filtering.defaultGroupConcurrency, matching the rest of this
+	// config's "<= 0 disables/defaults" convention.
+	GroupConcurrency int `mapstructure:"group_concurrency"`
+
+	// Audit configures the structured per-Filter-call audit trail (see
+	// internal/filtering/audit). Left at its zero value, Driver is "" and
+	// audit.NewSinkFromConfig builds a no-op sink, so existing deployments
+	// see no behavior change.
+	Audit FilteringAuditConfig `mapstructure:"audit"`
+}
+
+// FilteringAuditConfig selects and sizes the audit.Sink
+// filtering.NewService wires into every Filter call. Driver "" (the
+// default) and "none" discard every record; "stdout" JSON-encodes one line
+// per record to stdout; "kafka" requires Kafka.Topic; "file" requires
+// File.Path. SampleRate is a head-based per-call sampling chance between 0
+// and 1 - decided independently of the call's outcome - with
+// AlwaysLogOnReject/AlwaysLogOnError forcing a record through regardless of
+// SampleRate so denials and CEL fallbacks are never silently dropped by a
+// low sample rate.
+type FilteringAuditConfig struct {
+	Driver            string                    `mapstructure:"driver"`
+	SampleRate        float64                   `mapstructure:"sample_rate"`
+	AlwaysLogOnReject bool                      `mapstructure:"always_log_on_reject"`
+	AlwaysLogOnError  bool                      `mapstructure:"always_log_on_error"`
+	Kafka             FilteringAuditKafkaConfig `mapstructure:"kafka"`
+	File              FilteringAuditFileConfig  `mapstructure:"file"`
+}
+
+// FilteringAuditKafkaConfig backs the "kafka" audit driver. It's
+// deliberately narrower than BrokerConfig/KafkaConfig - broker.NewKafkaProducer
+// only actually needs a broker list to construct a working producer, and an
+// audit stream has no group/DLQ/retry concerns of its own to configure.
+type FilteringAuditKafkaConfig struct {
+	Brokers []string `mapstructure:"brokers"`
+	Topic   string   `mapstructure:"topic"`
+}
+
+// FilteringAuditFileConfig backs the "file" audit driver. MaxSizeBytes <= 0
+// disables rotation, matching the rest of this config's "<= 0
+// disables" convention; otherwise the current file is renamed aside once
+// writing the next record would cross MaxSizeBytes, and a fresh one is
+// opened in its place.
+type FilteringAuditFileConfig struct {
+	Path         string `mapstructure:"path"`
+	MaxSizeBytes int64  `mapstructure:"max_size_bytes"`
+}
+
+// CELConfig sizes a cel.Evaluator's compiled-program caches (see
+// cel.Evaluator, cel.NewEvaluatorWithCacheSize) and bounds how expensive a
+// single expression is allowed to be, both at validation time (worst-case
+// estimated cost) and at evaluation time (actual interpreter cost and
+// wall-clock duration), so a pathological or malicious rule can't stall the
+// filter/enrichment hot path. ProgramCacheSize/MaxEstimatedCost/MaxCost/
+// MaxEvalDurationMs <= 0 each independently disable that guard, matching
+// the rest of the repo's "<= 0 disables" convention.
+type CELConfig struct {
+	ProgramCacheSize int `mapstructure:"program_cache_size"`
+
+	// MaxEstimatedCost rejects a rule at validation time
+	// (cel.Evaluator.ValidateFilterExpression/ValidateTransformExpression)
+	// whose cel.Env.EstimateCost worst-case estimate exceeds it, catching an
+	// obviously pathological expression (e.g. nested comprehensions over
+	// payload) before it's ever saved.
+	MaxEstimatedCost uint64 `mapstructure:"max_estimated_cost"`
+	// MaxCost is passed to cel.Program via cel.CostLimit, aborting a single
+	// evaluation whose actual interpreter cost crosses it instead of relying
+	// on the (necessarily conservative) MaxEstimatedCost estimate alone.
+	MaxCost uint64 `mapstructure:"max_cost"`
+	// MaxEvalDurationMs bounds a single EvaluateFilter/EvaluateTransform
+	// call's wall-clock time via a context.WithTimeout derived from it,
+	// catching an expression (e.g. an expensive regex) that CEL's cost model
+	// underestimates.
+	MaxEvalDurationMs int `mapstructure:"max_eval_duration_ms"`
+
+	// RuleBreaker trips a rule's CEL expression out of evaluation once its
+	// cost-limit/timeout failures exceed a threshold, instead of paying to
+	// evaluate (and fail) it on every message.
+	RuleBreaker CELRuleBreakerConfig `mapstructure:"rule_breaker"`
+}
+
+// CELRuleBreakerConfig configures the per-rule circuit breaker
+// cel.Evaluator.EvaluateFilterForRule trips when a rule's CEL expression
+// repeatedly fails with a cost-limit or eval-timeout error specifically
+// (ordinary compile/type errors are the caller's existing error_handling
+// concern, not this breaker's). Disabled - every evaluation always runs -
+// when FailureThreshold <= 0.
+type CELRuleBreakerConfig struct {
+	FailureThreshold   int `mapstructure:"failure_threshold"`
+	SuccessThreshold   int `mapstructure:"success_threshold"`
+	WindowSize         int `mapstructure:"window_size"`
+	OpenTimeoutSeconds int `mapstructure:"open_timeout_seconds"`
 }
 
 type FallbackConfig struct {
@@ -110,20 +396,251 @@ type DeduplicationConfig struct {
 	TTLSeconds    int      `mapstructure:"ttl_seconds"`
 	OnRedisError  string   `mapstructure:"on_redis_error"`
 	FieldsToHash  []string `mapstructure:"fields_to_hash"`
+	// Salt is folded into every computed fingerprint alongside
+	// FieldsToHash, so two tenants hashing identical field values don't
+	// collide with each other's dedup keys.
+	Salt string `mapstructure:"salt"`
+	// HMACKey is the key deduplication.Hasher uses when HashAlgorithm is
+	// "hmac-sha256". Like every other secret-holding field (see
+	// Database.Postgres.Password), it may be a "${vault:...}" or
+	// "${env:...}" placeholder resolved by SecretResolver.ResolveConfig at
+	// load time - the rotation story is "change what the reference points
+	// at and restart/reload", the same as any other resolved secret.
+	HMACKey string `mapstructure:"hmac_key"`
+	// Backend selects what sits in front of the Redis SETNX round trip:
+	// "redis_set" (the default) talks to Redis directly on every call;
+	// "bloom" and "cuckoo" wrap it with the matching fast path below,
+	// skipping the round trip whenever the filter can prove a key is new.
+	// Left empty, a service falls back to Bloom.Enabled for backward
+	// compatibility with configs predating this field.
+	Backend string       `mapstructure:"backend"`
+	Bloom   BloomConfig  `mapstructure:"bloom"`
+	Cuckoo  CuckooConfig `mapstructure:"cuckoo"`
+	// L1Cache sizes the in-process cache deduplication.Service consults
+	// before every Backend's Redis round trip (SetNX, or the Bloom/Cuckoo
+	// fast path's own fallback to it) - see deduplication.DedupCache.
+	L1Cache DedupL1CacheConfig `mapstructure:"l1_cache"`
+	// CanonicalSerialization switches deduplication.Hasher.ComputeHash's
+	// field serialization from fmt.Sprintf's "%v" to json.Marshal for
+	// map/slice-valued fields, so a fingerprint doesn't depend on Go's
+	// randomized map iteration order - see deduplication.NewHasherWithOptions.
+	// Defaults to false so existing fingerprints don't change underfoot.
+	CanonicalSerialization bool `mapstructure:"canonical_serialization"`
+	// Policies lets different upstreams dedup on different fields/TTL/error
+	// handling instead of the single FieldsToHash/TTLSeconds/OnRedisError
+	// above applying to every message. deduplication.Service evaluates them
+	// in order and applies the first match's override; a message matching
+	// none of them falls back to this struct's own top-level fields, same
+	// as before Policies existed.
+	Policies []DedupPolicyConfig `mapstructure:"policies"`
+	// CEL sizes/bounds the evaluator Policies' Match expressions run
+	// through. Zero-valued (the default for configs predating Policies)
+	// falls back to cel.DefaultProgramCacheSize with no cost/duration bound.
+	CEL DedupCELConfig `mapstructure:"cel"`
 }
 
-type EnrichmentConfig struct{}
+// DedupPolicyConfig is one DeduplicationConfig.Policies entry: Match is a
+// CEL predicate evaluated against the full envelope via the same
+// pkg/cel.Evaluator a filtering rule's Expression runs through (e.g.
+// `source == "stripe"`), and Fields/TTLSeconds/OnRedisError override
+// DeduplicationConfig's top-level fields of the same name for any message
+// it matches. An unset Fields/TTLSeconds/OnRedisError on a matching policy
+// falls back to the top-level config value, so a policy only needs to
+// override what's actually different for that source.
+type DedupPolicyConfig struct {
+	Match        string   `mapstructure:"match"`
+	Fields       []string `mapstructure:"fields"`
+	TTLSeconds   int      `mapstructure:"ttl_seconds"`
+	OnRedisError string   `mapstructure:"on_redis_error"`
+}
+
+// DedupCELConfig sizes the pkg/cel.Evaluator Service compiles
+// DeduplicationConfig.Policies' Match expressions against. Unlike
+// FilteringConfig.CEL, there is no per-policy circuit breaker or cost
+// estimate at validation time - policies come from static config, not an
+// operator-editable rule store, so the abuse surface CELRuleBreakerConfig
+// and MaxEstimatedCost guard against doesn't apply here.
+type DedupCELConfig struct {
+	ProgramCacheSize  int    `mapstructure:"program_cache_size"`
+	MaxCost           uint64 `mapstructure:"max_cost"`
+	MaxEvalDurationMs int    `mapstructure:"max_eval_duration_ms"`
+}
+
+// DedupL1CacheConfig configures deduplication.Service's in-process L1
+// cache (deduplication.DedupCache). Size <= 0 disables it entirely,
+// falling back to the pre-existing Backend-only path. TTLSeconds <= 0
+// falls back to DeduplicationConfig.TTLSeconds, so an L1 entry never
+// outlives the Redis key it is shadowing.
+type DedupL1CacheConfig struct {
+	Size       int `mapstructure:"size"`
+	TTLSeconds int `mapstructure:"ttl_seconds"`
+}
+
+// BloomConfig configures deduplication.BloomRepository's local rolling
+// Bloom-filter fast path in front of the Redis SETNX round trip. See
+// deduplication.BloomParams for what each field does; zero values fall
+// back to deduplication.NewBloomRepository's defaults.
+type BloomConfig struct {
+	Enabled           bool    `mapstructure:"enabled"`
+	ExpectedItems     uint64  `mapstructure:"expected_items"`
+	FalsePositiveRate float64 `mapstructure:"false_positive_rate"`
+	// Distributed switches from a per-process filter to a Redis-backed
+	// counting Bloom filter (SETBIT/BITCOUNT on a key shared by every
+	// replica), trading the local fast path's speed for cross-replica
+	// accuracy.
+	Distributed bool `mapstructure:"distributed"`
+	// RotationIntervalSeconds is how long the local filter serves as the
+	// active generation before BloomRepository starts a fresh one - see
+	// deduplication.BloomParams.RotationInterval. Zero falls back to half
+	// of DeduplicationConfig.TTLSeconds, so the filter's own false-negative
+	// window stays aligned with how long Redis still remembers a key.
+	RotationIntervalSeconds int `mapstructure:"rotation_interval_seconds"`
+}
+
+// CuckooConfig configures deduplication.CuckooRepository's fixed-capacity
+// Cuckoo-filter fast path, selected by Backend: "cuckoo". See
+// deduplication.CuckooParams for what each field does; zero values fall
+// back to deduplication.NewCuckooRepository's defaults. Unlike
+// BloomConfig, Capacity bounds memory outright instead of just sizing the
+// filter for an expected count - past it, the filter reports full and
+// CuckooRepository falls back to Redis for every call until the active
+// generation rotates out.
+type CuckooConfig struct {
+	Capacity          uint64  `mapstructure:"capacity"`
+	FalsePositiveRate float64 `mapstructure:"false_positive_rate"`
+	// RotationWindowSeconds is how long the active generation accepts
+	// inserts before CuckooRepository starts a fresh one and retires the
+	// current active to previous - see CuckooRepository's doc comment for
+	// the two-generation scheme this bounds false positives over time
+	// with. Zero falls back to half of DeduplicationConfig.TTLSeconds, so
+	// the filter's own false-negative window stays aligned with how long
+	// Redis still remembers a key.
+	RotationWindowSeconds int `mapstructure:"rotation_window_seconds"`
+	// Distributed switches from per-process filters to two Redis-shared
+	// bucket tables, trading the local fast path's speed for
+	// cross-replica accuracy. See deduplication.CuckooParams.Distributed.
+	Distributed bool `mapstructure:"distributed"`
+}
+
+type EnrichmentConfig struct {
+	Retry       RetryConfig                 `mapstructure:"retry"`
+	L1Cache     L1CacheConfig               `mapstructure:"l1_cache"`
+	CEL         CELConfig                   `mapstructure:"cel"`
+	RuleStorage EnrichmentRuleStorageConfig `mapstructure:"rule_storage"`
+}
+
+// EnrichmentRuleStorageConfig selects which enrichment.Repository driver
+// enrichment.NewRepositoryFromConfig builds. Driver "mongodb" (the default
+// when empty, preserving existing deployments) requires
+// Database.MongoDB.URI; "postgres" requires Database.Postgres.Host; "file"
+// requires File.Path; "http" requires HTTP.URL.
+type EnrichmentRuleStorageConfig struct {
+	Driver string                `mapstructure:"driver"`
+	File   FileRuleStorageConfig `mapstructure:"file"`
+	HTTP   HTTPRuleStorageConfig `mapstructure:"http"`
+}
+
+// FileRuleStorageConfig backs enrichment's "file" rule storage driver: Path
+// is a directory of one JSON-encoded enrichment.Rule per "*.json" file,
+// typically a checkout this process doesn't manage itself - an operator (or
+// a sidecar running `git pull` on a schedule) keeps it up to date, and
+// ReloadRules simply re-reads whatever is on disk the next time it runs.
+// YAML isn't supported: it would need a new module dependency this driver
+// doesn't otherwise require.
+type FileRuleStorageConfig struct {
+	Path string `mapstructure:"path"`
+}
+
+// HTTPRuleStorageConfig backs enrichment's "http" rule storage driver: URL
+// must return a JSON array of enrichment.Rule. A response carrying an ETag
+// header lets ReloadRules issue a conditional GET (If-None-Match) and reuse
+// the last decoded rule set on 304 Not Modified, instead of re-fetching and
+// re-decoding the full body every time.
+type HTTPRuleStorageConfig struct {
+	URL       string `mapstructure:"url"`
+	TimeoutMs int    `mapstructure:"timeout_ms"`
+}
+
+// L1CacheConfig sizes the in-process LRU cache serviceImpl keeps in front of
+// the Redis L2 cache (see serviceImpl.fetchSourceData). Size <= 0 disables
+// the L1 cache entirely, falling back to the Redis-only path.
+type L1CacheConfig struct {
+	Size int `mapstructure:"size"`
+}
 
 type ManagementConfig struct {
-	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
+	RateLimit         RateLimitConfig         `mapstructure:"rate_limit"`
+	Auth              AuthConfig              `mapstructure:"auth"`
+	EnrichmentStorage EnrichmentStorageConfig `mapstructure:"enrichment_storage"`
+	// CEL only uses MaxEstimatedCost - the management service validates
+	// rules (estimated cost only; it doesn't evaluate them) rather than
+	// running them, so ProgramCacheSize/MaxCost/MaxEvalDurationMs/
+	// RuleBreaker don't apply here.
+	CEL       CELConfig       `mapstructure:"cel"`
+	Scheduler SchedulerConfig `mapstructure:"scheduler"`
+}
+
+// SchedulerConfig controls the management/scheduler subsystem that drives
+// Schedule-based rule activation/deactivation. It requires Database.Redis to
+// be reachable (for leader election and next-fire persistence); when
+// Enabled is false the management-service binary never connects to Redis
+// or starts the scheduler goroutine.
+type SchedulerConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// PollInterval is how often the leader replica evaluates schedules for
+	// due activations/deactivations. Defaults to 30s when zero.
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+	// LeaderTTL is how long the leader-election Redis key is held before it
+	// must be renewed; a replica that fails to renew in time loses
+	// leadership to whichever replica next acquires the key. Defaults to
+	// 15s when zero.
+	LeaderTTL time.Duration `mapstructure:"leader_ttl"`
+}
+
+// EnrichmentStorageConfig selects which EnrichmentRepository driver
+// management.NewEnrichmentRepositoryFromConfig builds. Driver "mongodb"
+// (the default when empty, preserving existing deployments) requires
+// Database.MongoDB.URI; "postgres" requires Database.Postgres.Host;
+// "sqlite" requires Database.SQLite.Path.
+type EnrichmentStorageConfig struct {
+	Driver string `mapstructure:"driver"`
 }
 
+// AuthConfig gates the management service's X-API-Key/RBAC subsystem.
+// Disabled (the default) leaves every caller scoped to the implicit
+// "default" tenant with full access, preserving existing single-tenant
+// deployments. BootstrapAPIKey, if set, is hashed and inserted as a
+// config:write-scoped key for BootstrapTenantID on startup so an operator
+// has a way in before any key exists in Mongo.
+type AuthConfig struct {
+	Enabled           bool   `mapstructure:"enabled"`
+	BootstrapAPIKey   string `mapstructure:"bootstrap_api_key"`
+	BootstrapTenantID string `mapstructure:"bootstrap_tenant_id"`
+}
+
+// RateLimitConfig throttles the management API. RPS/Burst are the limit
+// applied to a request whose tier (see ratelimit.KeyFunc) isn't found in
+// Tiers - or when Tiers is empty - preserving the original single-tier
+// behavior. Backend selects where limiter state lives: "memory" (the
+// default) keeps it in this process only, fine for a single replica;
+// "redis" shares it across every replica via Database.Redis, which matters
+// once the management API runs behind a load balancer.
 type RateLimitConfig struct {
-	Enabled         bool    `mapstructure:"enabled"`
-	RPS             float64 `mapstructure:"rps"`
-	Burst           int     `mapstructure:"burst"`
-	CleanupInterval int     `mapstructure:"cleanup_interval"`
-	MaxAge          int     `mapstructure:"max_age"`
+	Enabled         bool                           `mapstructure:"enabled"`
+	RPS             float64                        `mapstructure:"rps"`
+	Burst           int                            `mapstructure:"burst"`
+	CleanupInterval int                            `mapstructure:"cleanup_interval"`
+	MaxAge          int                            `mapstructure:"max_age"`
+	Backend         string                         `mapstructure:"backend"`
+	Tiers           map[string]RateLimitTierConfig `mapstructure:"tiers"`
+}
+
+// RateLimitTierConfig overrides RateLimitConfig's RPS/Burst for one tier
+// name (e.g. "anonymous", "authenticated", "premium") that a
+// ratelimit.KeyFunc assigns a request to.
+type RateLimitTierConfig struct {
+	RPS   float64 `mapstructure:"rps"`
+	Burst int     `mapstructure:"burst"`
 }
 
 type CircuitBreakerConfig struct {
@@ -133,18 +650,161 @@ type CircuitBreakerConfig struct {
 	Timeout      time.Duration `mapstructure:"timeout"`
 	FailureRatio float64       `mapstructure:"failure_ratio"`
 	MinRequests  uint32        `mapstructure:"min_requests"`
+	// Adaptive switches the breaker from the coarse counter-based
+	// ReadyToTrip above to circuitbreaker.AdaptiveWrapper's rolling
+	// per-endpoint error-rate/latency tracking when Adaptive.Enabled.
+	Adaptive AdaptiveCircuitBreakerConfig `mapstructure:"adaptive"`
+	// Fallback governs what an enrichment source's Fetch/FetchBatch
+	// returns while its circuit breaker is open, mirroring
+	// FilteringConfig.Fallback's on_error shape: "allow" lets the message
+	// through with no enrichment data from that source, "deny" fails the
+	// fetch outright regardless of the rule's own error_handling, and ""
+	// (the default) leaves the breaker's own "circuit breaker is open"
+	// error to flow into the rule's existing error_handling path
+	// unchanged. See provider.WrapWithCircuitBreaker.
+	Fallback FallbackConfig `mapstructure:"fallback"`
+}
+
+// AdaptiveCircuitBreakerConfig configures circuitbreaker.AdaptiveWrapper,
+// an alternative to CircuitBreakerConfig's coarse counter-based breaker
+// that tracks a rolling per-endpoint window of error rate and p99 latency
+// instead of one pool-wide counter. See circuitbreaker.AdaptiveConfig for
+// what each field does; zero values fall back to
+// circuitbreaker.DefaultAdaptiveConfig's defaults.
+type AdaptiveCircuitBreakerConfig struct {
+	Enabled             bool          `mapstructure:"enabled"`
+	BucketInterval      time.Duration `mapstructure:"bucket_interval"`
+	BucketCount         int           `mapstructure:"bucket_count"`
+	MinRequestVolume    int           `mapstructure:"min_request_volume"`
+	ErrorRateThreshold  float64       `mapstructure:"error_rate_threshold"`
+	LatencyP99Deviation float64       `mapstructure:"latency_p99_deviation"`
+	EjectionBaseTimeout time.Duration `mapstructure:"ejection_base_timeout"`
+	EjectionMaxTimeout  time.Duration `mapstructure:"ejection_max_timeout"`
+}
+
+// BackpressureConfig configures internal/backpressure.Controller: a
+// goroutine that reads KafkaConsumerLag/MessageQueueWaitDuration and applies
+// an AIMD policy to worker concurrency and partition pause/resume, turning
+// those gauges from dashboards-only signals into an actual control loop.
+type BackpressureConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// IntervalSeconds is how often Controller.Start ticks.
+	IntervalSeconds int `mapstructure:"interval_seconds"`
+	// EWMAAlpha weights each tick's lag sample against the running EWMA
+	// (0 < alpha <= 1; higher reacts faster to recent samples).
+	EWMAAlpha float64 `mapstructure:"ewma_alpha"`
+	// LagHighWatermark: a partition's lag EWMA above this, while still
+	// growing, counts as a "bad" signal (halve workers, pause the
+	// most-lagged partition).
+	LagHighWatermark int64 `mapstructure:"lag_high_watermark"`
+	// LagLowWatermark: the most-lagged partition's EWMA below this allows
+	// additive growth back toward MaxWorkers and resuming paused
+	// partitions that have drained.
+	LagLowWatermark int64 `mapstructure:"lag_low_watermark"`
+	// QueueWaitThresholdMs: an approximate p95 MessageQueueWaitDuration
+	// above this also counts as a "bad" signal.
+	QueueWaitThresholdMs float64 `mapstructure:"queue_wait_threshold_ms"`
+	MinWorkers           int     `mapstructure:"min_workers"`
+	MaxWorkers           int     `mapstructure:"max_workers"`
 }
 
 type TracingConfig struct {
-	Enabled     bool          `mapstructure:"enabled"`
-	ServiceName string        `mapstructure:"service_name"`
-	OTLP        OTLPConfig    `mapstructure:"otlp"`
-	Sampler     SamplerConfig `mapstructure:"sampler"`
+	Enabled      bool               `mapstructure:"enabled"`
+	ServiceName  string             `mapstructure:"service_name"`
+	Protocol     string             `mapstructure:"protocol"` // "grpc" (default) or "http/protobuf"
+	OTLP         OTLPConfig         `mapstructure:"otlp"`
+	Sampler      SamplerConfig      `mapstructure:"sampler"`
+	TailSampling TailSamplingConfig `mapstructure:"tail_sampling"`
+}
+
+// MetricsConfig configures exporting the same instruments RegisterXMetrics
+// registers with Prometheus to an OTLP collector as well, via
+// pkg/metrics/otel. It reuses TracingConfig's OTLPConfig/OTLPTLSConfig
+// shapes since it is the same collector endpoint in most deployments, just a
+// different OTLP signal.
+type MetricsConfig struct {
+	Enabled               bool       `mapstructure:"enabled"`
+	ServiceName           string     `mapstructure:"service_name"`
+	Protocol              string     `mapstructure:"protocol"` // "grpc" (default) or "http/protobuf"
+	ExportIntervalSeconds int        `mapstructure:"export_interval_seconds"`
+	OTLP                  OTLPConfig `mapstructure:"otlp"`
+
+	// RuleCardinalityCap bounds how many distinct rule_id label values
+	// metrics.Registry keeps active at once per rule-scoped metric group
+	// (filtering, enrichment) - see pkg/metrics/registry.go. <= 0 falls
+	// back to pkg/metrics's own default, matching this config's "<= 0
+	// defaults" convention.
+	RuleCardinalityCap int `mapstructure:"rule_cardinality_cap"`
+
+	// Push configures metrics.Pusher - pushing the current Prometheus
+	// registry to a Pushgateway and/or a remote-write endpoint, for
+	// short-lived jobs (e.g. cmd/management-service one-off CLI commands)
+	// that come and go between scrapes.
+	Push PushConfig `mapstructure:"push"`
 }
 
 type OTLPConfig struct {
-	Endpoint string `mapstructure:"endpoint"`
-	Insecure bool   `mapstructure:"insecure"`
+	Endpoint string            `mapstructure:"endpoint"`
+	Insecure bool              `mapstructure:"insecure"`
+	Headers  map[string]string `mapstructure:"headers"`
+	TLS      OTLPTLSConfig     `mapstructure:"tls"`
+}
+
+type OTLPTLSConfig struct {
+	CertFile           string `mapstructure:"cert_file"`
+	KeyFile            string `mapstructure:"key_file"`
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"`
+}
+
+// PushConfig configures metrics.Pusher pushing this process's current
+// Prometheus registry to a Pushgateway, and/or sending it via Prometheus
+// remote-write to a central Thanos/Cortex/Mimir endpoint. Both are
+// independent and optional: a deployment can run either, both, or neither
+// alongside the usual scrape-based collection.
+type PushConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// URL is the Pushgateway base URL (e.g. "http://pushgateway:9091").
+	URL string `mapstructure:"url"`
+	// JobName is the Pushgateway "job" grouping key. Falls back to the
+	// serviceName passed to metrics.NewPusher when empty.
+	JobName string `mapstructure:"job_name"`
+	// IntervalSeconds is how often Pusher.Start pushes while running
+	// continuously. <= 0 disables periodic pushing, matching this config's
+	// "<= 0 disables" convention, so only an explicit Pusher.Push call
+	// (e.g. at the end of a short-lived CLI job) sends anything.
+	IntervalSeconds int `mapstructure:"interval_seconds"`
+	// GroupingKey adds Pushgateway grouping labels beyond "job" (e.g.
+	// "instance" for per-replica pushes into a shared Pushgateway).
+	GroupingKey map[string]string `mapstructure:"grouping_key"`
+	BasicAuth   BasicAuthConfig   `mapstructure:"basic_auth"`
+	BearerToken string            `mapstructure:"bearer_token"`
+	TLS         OTLPTLSConfig     `mapstructure:"tls"`
+
+	RemoteWrite RemoteWriteConfig `mapstructure:"remote_write"`
+}
+
+// BasicAuthConfig is shared by PushConfig/RemoteWriteConfig for a
+// Pushgateway or remote-write endpoint authenticating with HTTP basic auth
+// instead of (or alongside) a bearer token.
+type BasicAuthConfig struct {
+	User     string `mapstructure:"user"`
+	Password string `mapstructure:"password"`
+}
+
+// RemoteWriteConfig configures metrics.Pusher sending this process's
+// samples via Prometheus remote-write (snappy-compressed protobuf over
+// HTTP) to a central Thanos/Cortex/Mimir endpoint - for federating metrics
+// from multiple yeti deployments into a single view.
+type RemoteWriteConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	URL     string `mapstructure:"url"`
+	// IntervalSeconds is how often Pusher.Start ships samples to URL. <= 0
+	// disables periodic remote-write, same convention as
+	// PushConfig.IntervalSeconds.
+	IntervalSeconds int             `mapstructure:"interval_seconds"`
+	BasicAuth       BasicAuthConfig `mapstructure:"basic_auth"`
+	BearerToken     string          `mapstructure:"bearer_token"`
+	TLS             OTLPTLSConfig   `mapstructure:"tls"`
 }
 
 type SamplerConfig struct {
@@ -152,6 +812,59 @@ type SamplerConfig struct {
 	Param float64 `mapstructure:"param"`
 }
 
+// TailSamplingConfig configures the "tail_sampling" sampler type: every span
+// is recorded and buffered per trace-id until the root span ends (or
+// DecisionWaitSeconds elapses), at which point Policies decide whether the
+// trace is kept or dropped.
+type TailSamplingConfig struct {
+	MaxTracesInFlight   int                        `mapstructure:"max_traces_in_flight"`
+	DecisionWaitSeconds int                        `mapstructure:"decision_wait_seconds"`
+	Policies            []TailSamplingPolicyConfig `mapstructure:"policies"`
+}
+
+type TailSamplingPolicyConfig struct {
+	Type           string  `mapstructure:"type"` // "latency_ms", "status_error", "attribute_regex", "probabilistic"
+	LatencyMS      int64   `mapstructure:"latency_ms"`
+	AttributeKey   string  `mapstructure:"attribute_key"`
+	AttributeRegex string  `mapstructure:"attribute_regex"`
+	Probability    float64 `mapstructure:"probability"`
+}
+
+// SLOConfig declares the Service Level Objectives that
+// "metrics gen-mixin" (see pkg/metrics/mixin) turns into multi-window
+// multi-burn-rate Prometheus alert rules, recording rules, and a Grafana
+// dashboard, so operators get a working observability bundle without
+// hand-writing PromQL for each one.
+type SLOConfig struct {
+	Targets []SLOTarget `mapstructure:"targets"`
+}
+
+// SLOTarget is one SLO, e.g. "99% of filtering messages processed under
+// 100ms over 30d" or "enrichment provider error rate < 0.1% over 30d".
+type SLOTarget struct {
+	// Name identifies this SLO in generated rule/alert names (e.g.
+	// "filtering_latency") - must be a valid Prometheus metric name
+	// fragment.
+	Name string `mapstructure:"name"`
+	// Kind is "latency" (Metric must name a histogram this package
+	// declares, e.g. "filtering_processing_duration_ms") or "error_rate"
+	// (Metric must name a counter whose "status"-like label distinguishes
+	// failures, e.g. "enrichment_provider_requests_total").
+	Kind string `mapstructure:"kind"`
+	// Metric is the Prometheus metric name (not the Go identifier) this
+	// target is measured against.
+	Metric string `mapstructure:"metric"`
+	// Objective is the fraction of events that must be "good", e.g. 0.99
+	// for "99%".
+	Objective float64 `mapstructure:"objective"`
+	// ThresholdMs is the latency bound "good" events must be under. Only
+	// used when Kind is "latency".
+	ThresholdMs float64 `mapstructure:"threshold_ms"`
+	// Window is the rolling compliance window the Objective is measured
+	// over, e.g. "30d".
+	Window string `mapstructure:"window"`
+}
+
 func Load(configFile string) (*Config, error) {
 	return LoadConfig(configFile)
 }
@@ -1,12 +1,20 @@
 package config
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
 	"github.com/spf13/viper"
 )
 
+// CurrentConfigFile returns the path viper last loaded the config from, for
+// callers (e.g. the config Watcher) that need to re-read the same file
+// without re-threading it through their constructors.
+func CurrentConfigFile() string {
+	return viper.ConfigFileUsed()
+}
+
 func LoadConfig(configFile string) (*Config, error) {
 	viper.Reset()
 
@@ -31,10 +39,18 @@ func LoadConfig(configFile string) (*Config, error) {
 		return nil, fmt.Errorf("failed to apply environment overrides: %w", err)
 	}
 
+	if err := NewSecretResolver(cfg.Secrets).ResolveConfig(context.Background(), &cfg); err != nil {
+		return nil, fmt.Errorf("failed to resolve secret references: %w", err)
+	}
+
 	if err := ValidateStatic(&cfg); err != nil {
 		return nil, fmt.Errorf("configuration validation failed: %w", err)
 	}
 
+	if err := ValidateDynamic(context.Background(), &cfg); err != nil {
+		return nil, fmt.Errorf("configuration validation failed: %w", err)
+	}
+
 	return &cfg, nil
 }
 
@@ -64,14 +80,32 @@ func bindEnvVariables() {
 	viper.BindEnv("server.port", "SERVER_PORT")
 	viper.BindEnv("server.read_timeout_seconds", "SERVER_READ_TIMEOUT_SECONDS")
 	viper.BindEnv("server.write_timeout_seconds", "SERVER_WRITE_TIMEOUT_SECONDS")
+	viper.BindEnv("server.grpc_port", "SERVER_GRPC_PORT")
 
 	viper.BindEnv("logging.level", "LOGGING_LEVEL")
 	viper.BindEnv("logging.format", "LOGGING_FORMAT")
+	viper.BindEnv("logging.sampling.initial", "LOGGING_SAMPLING_INITIAL")
+	viper.BindEnv("logging.sampling.thereafter", "LOGGING_SAMPLING_THEREAFTER")
 
 	viper.BindEnv("tracing.otlp.endpoint", "TRACING_OTLP_ENDPOINT")
 	viper.BindEnv("tracing.otlp.insecure", "TRACING_OTLP_INSECURE")
 	viper.BindEnv("tracing.enabled", "TRACING_ENABLED")
 	viper.BindEnv("tracing.service_name", "TRACING_SERVICE_NAME")
+	viper.BindEnv("tracing.protocol", "TRACING_PROTOCOL")
+	viper.BindEnv("tracing.otlp.tls.cert_file", "TRACING_OTLP_TLS_CERT_FILE")
+	viper.BindEnv("tracing.otlp.tls.key_file", "TRACING_OTLP_TLS_KEY_FILE")
+
+	viper.BindEnv("metrics.otlp.endpoint", "METRICS_OTLP_ENDPOINT")
+	viper.BindEnv("metrics.otlp.insecure", "METRICS_OTLP_INSECURE")
+	viper.BindEnv("metrics.enabled", "METRICS_ENABLED")
+	viper.BindEnv("metrics.service_name", "METRICS_SERVICE_NAME")
+	viper.BindEnv("metrics.protocol", "METRICS_PROTOCOL")
+	viper.BindEnv("metrics.rule_cardinality_cap", "METRICS_RULE_CARDINALITY_CAP")
+
+	viper.BindEnv("metrics.push.enabled", "METRICS_PUSH_ENABLED")
+	viper.BindEnv("metrics.push.url", "METRICS_PUSH_URL")
+	viper.BindEnv("metrics.push.remote_write.enabled", "METRICS_REMOTE_WRITE_ENABLED")
+	viper.BindEnv("metrics.push.remote_write.url", "METRICS_REMOTE_WRITE_URL")
 }
 
 func applyEnvOverrides(cfg *Config) error {
@@ -89,5 +123,34 @@ func applyEnvOverrides(cfg *Config) error {
 		cfg.Tracing.OTLP.Endpoint = otlpEndpoint
 	}
 
+	if protocol := viper.GetString("TRACING_PROTOCOL"); protocol != "" {
+		cfg.Tracing.Protocol = protocol
+	}
+
+	if otlpEndpoint := viper.GetString("METRICS_OTLP_ENDPOINT"); otlpEndpoint != "" {
+		cfg.Metrics.OTLP.Endpoint = otlpEndpoint
+	}
+
+	if protocol := viper.GetString("METRICS_PROTOCOL"); protocol != "" {
+		cfg.Metrics.Protocol = protocol
+	}
+
+	if cardinalityCap := viper.GetInt("METRICS_RULE_CARDINALITY_CAP"); cardinalityCap != 0 {
+		cfg.Metrics.RuleCardinalityCap = cardinalityCap
+	}
+
+	if viper.IsSet("METRICS_PUSH_ENABLED") {
+		cfg.Metrics.Push.Enabled = viper.GetBool("METRICS_PUSH_ENABLED")
+	}
+	if pushURL := viper.GetString("METRICS_PUSH_URL"); pushURL != "" {
+		cfg.Metrics.Push.URL = pushURL
+	}
+	if viper.IsSet("METRICS_REMOTE_WRITE_ENABLED") {
+		cfg.Metrics.Push.RemoteWrite.Enabled = viper.GetBool("METRICS_REMOTE_WRITE_ENABLED")
+	}
+	if remoteWriteURL := viper.GetString("METRICS_REMOTE_WRITE_URL"); remoteWriteURL != "" {
+		cfg.Metrics.Push.RemoteWrite.URL = remoteWriteURL
+	}
+
 	return nil
 }
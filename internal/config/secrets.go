@@ -0,0 +1,216 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// placeholderPattern matches a whole-value secret reference like
+// "${vault:secret/data/yeti#password}" or "${env:DB_PASSWORD}". Only
+// whole-string placeholders are supported, not interpolation inside a
+// larger string - that's the shape every field that holds a secret
+// actually needs.
+var placeholderPattern = regexp.MustCompile(`^\$\{(\w+):(.+)\}$`)
+
+// SecretProvider resolves a single reference (the part of a placeholder
+// after "scheme:") into its secret value. A SecretResolver holds one
+// SecretProvider per scheme, so a Config can mix "${env:...}" and
+// "${vault:...}" placeholders in different fields.
+type SecretProvider interface {
+	Scheme() string
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// EnvSecretProvider resolves "${env:VAR}" placeholders from the process
+// environment.
+type EnvSecretProvider struct{}
+
+func (EnvSecretProvider) Scheme() string { return "env" }
+
+func (EnvSecretProvider) Resolve(_ context.Context, ref string) (string, error) {
+	value, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", fmt.Errorf("environment variable %q is not set", ref)
+	}
+	return value, nil
+}
+
+// VaultSecretProvider resolves "${vault:path#field}" placeholders against a
+// HashiCorp Vault KV v2 mount over its HTTP API. No Vault SDK is vendored
+// in this tree, so this speaks the API directly - the same read a `vault kv
+// get` issues - rather than depending on one.
+type VaultSecretProvider struct {
+	Address    string
+	Token      string
+	HTTPClient *http.Client
+}
+
+func (p *VaultSecretProvider) Scheme() string { return "vault" }
+
+func (p *VaultSecretProvider) httpClient() *http.Client {
+	if p.HTTPClient != nil {
+		return p.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// Resolve expects ref in "path#field" form, e.g.
+// "secret/data/yeti#db_password".
+func (p *VaultSecretProvider) Resolve(ctx context.Context, ref string) (string, error) {
+	path, field, ok := strings.Cut(ref, "#")
+	if !ok {
+		return "", fmt.Errorf("vault reference %q must be in \"path#field\" form", ref)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(p.Address, "/")+"/v1/"+path, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to build vault request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", p.Token)
+
+	resp, err := p.httpClient().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("vault request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read vault response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault request for %s returned status %d: %s", path, resp.StatusCode, body)
+	}
+
+	var parsed struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return "", fmt.Errorf("failed to parse vault response: %w", err)
+	}
+
+	value, ok := parsed.Data.Data[field]
+	if !ok {
+		return "", fmt.Errorf("vault secret %q has no field %q", path, field)
+	}
+	str, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("vault secret %q field %q is not a string", path, field)
+	}
+	return str, nil
+}
+
+// SecretResolver resolves "${scheme:ref}" placeholders against whichever
+// SecretProvider registered that scheme, and walks a Config replacing
+// every string field that holds one.
+type SecretResolver struct {
+	providers map[string]SecretProvider
+}
+
+// NewSecretResolver builds a SecretResolver from cfg: EnvSecretProvider is
+// always registered, and VaultSecretProvider is added when cfg.Vault.Address
+// is set.
+func NewSecretResolver(cfg SecretsConfig) *SecretResolver {
+	r := &SecretResolver{providers: map[string]SecretProvider{}}
+	r.Register(EnvSecretProvider{})
+	if cfg.Vault.Address != "" {
+		r.Register(&VaultSecretProvider{Address: cfg.Vault.Address, Token: cfg.Vault.Token})
+	}
+	return r
+}
+
+// Register adds or replaces the provider for p.Scheme().
+func (r *SecretResolver) Register(p SecretProvider) {
+	r.providers[p.Scheme()] = p
+}
+
+// ResolveValue resolves value if it's a whole "${scheme:ref}" placeholder,
+// and returns it unchanged otherwise.
+func (r *SecretResolver) ResolveValue(ctx context.Context, value string) (string, error) {
+	match := placeholderPattern.FindStringSubmatch(value)
+	if match == nil {
+		return value, nil
+	}
+
+	scheme, ref := match[1], match[2]
+	provider, ok := r.providers[scheme]
+	if !ok {
+		return value, fmt.Errorf("no secret provider registered for scheme %q", scheme)
+	}
+	return provider.Resolve(ctx, ref)
+}
+
+// ResolveConfig replaces every "${scheme:ref}" placeholder found in cfg's
+// string fields in place. It's best-effort: a field whose placeholder can't
+// be resolved is left as-is rather than aborting the whole walk, so one
+// stale or unreachable secret doesn't block resolving the rest - the
+// caller decides whether that's acceptable by running ValidateDynamic
+// afterward, which fails on anything still unresolved. Every resolution
+// error encountered along the way is returned, joined into one error.
+func (r *SecretResolver) ResolveConfig(ctx context.Context, cfg *Config) error {
+	var errs []error
+	walkStringFields(reflect.ValueOf(cfg).Elem(), "", func(path string, v reflect.Value) {
+		resolved, err := r.ResolveValue(ctx, v.String())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			return
+		}
+		v.SetString(resolved)
+	})
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to resolve %d secret reference(s): %v", len(errs), errs)
+	}
+	return nil
+}
+
+// FindUnresolvedPlaceholders returns the dot-path of every string field in
+// cfg that still holds a "${scheme:ref}" placeholder, for ValidateDynamic
+// to report.
+func FindUnresolvedPlaceholders(cfg *Config) []string {
+	var paths []string
+	walkStringFields(reflect.ValueOf(cfg).Elem(), "", func(path string, v reflect.Value) {
+		if placeholderPattern.MatchString(v.String()) {
+			paths = append(paths, path)
+		}
+	})
+	return paths
+}
+
+// walkStringFields recurses through v - a struct, or a slice/array of
+// them - calling fn with every settable string field it finds and its
+// dot-path (e.g. "Database.Postgres.Password"). Map fields (e.g.
+// OTLPConfig.Headers) aren't walked: map values aren't addressable via
+// reflection, and no config field that holds secrets is a map today.
+func walkStringFields(v reflect.Value, path string, fn func(string, reflect.Value)) {
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Field(i)
+			if !field.CanSet() {
+				continue
+			}
+			fieldPath := t.Field(i).Name
+			if path != "" {
+				fieldPath = path + "." + fieldPath
+			}
+			walkStringFields(field, fieldPath, fn)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			walkStringFields(v.Index(i), fmt.Sprintf("%s[%d]", path, i), fn)
+		}
+	case reflect.String:
+		fn(path, v)
+	}
+}
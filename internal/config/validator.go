@@ -1,6 +1,7 @@
 package config
 
 import (
+	"context"
 	"fmt"
 	"strings"
 )
@@ -14,207 +15,551 @@ func (e *ValidationError) Error() string {
 	return fmt.Sprintf("validation error for field '%s': %s", e.Field, e.Message)
 }
 
-func ValidateStatic(cfg *Config) error {
-	var errors []error
+// ValidationErrors collects every *ValidationError a validation pass found,
+// instead of stopping at the first one. It implements the Go 1.20 multi-error
+// shape (Unwrap() []error) so callers can still errors.As/errors.Is against
+// an individual *ValidationError, while Error() renders the full list for
+// logs and the /healthz/config endpoint can range over it directly for
+// structured per-field JSON.
+type ValidationErrors []*ValidationError
 
-	if err := validateServer(cfg.Server); err != nil {
-		errors = append(errors, err)
+func (e ValidationErrors) Error() string {
+	if len(e) == 0 {
+		return ""
+	}
+	if len(e) == 1 {
+		return e[0].Error()
 	}
 
-	if err := validateBroker(cfg.Broker); err != nil {
-		errors = append(errors, err)
+	msgs := make([]string, len(e))
+	for i, ve := range e {
+		msgs[i] = ve.Error()
 	}
+	return fmt.Sprintf("%d configuration validation errors: %s", len(e), strings.Join(msgs, "; "))
+}
 
-	if err := validateDatabase(cfg.Database); err != nil {
-		errors = append(errors, err)
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, ve := range e {
+		errs[i] = ve
 	}
+	return errs
+}
+
+func ValidateStatic(cfg *Config) error {
+	var errs ValidationErrors
 
-	if err := validateDeduplication(cfg.Deduplication); err != nil {
-		errors = append(errors, err)
+	errs = append(errs, validateServer(cfg.Server)...)
+	errs = append(errs, validateBroker(cfg.Broker)...)
+	errs = append(errs, validateDatabase(cfg.Database)...)
+	errs = append(errs, validateDeduplication(cfg.Deduplication)...)
+	errs = append(errs, validateEnrichment(cfg.Enrichment)...)
+	errs = append(errs, validateConfigSource(cfg.ConfigSource)...)
+	errs = append(errs, validateManagementAuth(cfg.Management.Auth)...)
+	errs = append(errs, validateEnrichmentStorage(cfg.Management.EnrichmentStorage, cfg.Database)...)
+	errs = append(errs, validateEnrichmentRuleStorage(cfg.Enrichment.RuleStorage, cfg.Database)...)
+
+	if len(errs) > 0 {
+		return errs
 	}
 
-	if len(errors) > 0 {
-		return fmt.Errorf("configuration validation failed: %v", errors)
+	return nil
+}
+
+// ValidateWarnings checks for configuration that is legal and will start up
+// fine, but is probably a mistake: non-fatal issues ValidateStatic doesn't
+// reject outright. Callers decide what to do with them - log and continue
+// (the default at service startup), or promote them to fatal errors (the
+// --strict mode of a CI config-validation run; see cmd/*/main.go's
+// validate-config command).
+func ValidateWarnings(cfg *Config) ValidationErrors {
+	var warnings ValidationErrors
+
+	if cfg.Deduplication.TTLSeconds == 0 && (cfg.Database.Redis.Host != "" || cfg.Database.Redis.Port > 0) {
+		warnings = append(warnings, &ValidationError{
+			Field:   "deduplication.ttl_seconds",
+			Message: "ttl_seconds is 0, so deduplication entries never expire from Redis; set a TTL unless that's intentional",
+		})
+	}
+
+	if strings.EqualFold(cfg.Environment, "production") {
+		if cfg.Broker.Type == "kafka" && !cfg.Broker.Kafka.Retry.Enabled {
+			warnings = append(warnings, &ValidationError{
+				Field:   "broker.kafka.retry.enabled",
+				Message: "Kafka retry is disabled in a production environment; a transient consumer error will be dropped instead of retried",
+			})
+		}
+		if cfg.Broker.Type == "kafka" && cfg.Broker.Kafka.Retry.Enabled && cfg.Broker.Kafka.Retry.MaxAttempts == 0 {
+			warnings = append(warnings, &ValidationError{
+				Field:   "broker.kafka.retry.max_attempts",
+				Message: "max_attempts is 0 in a production environment; set a positive attempt count or the retry policy's default applies silently",
+			})
+		}
+	}
+
+	return warnings
+}
+
+// ValidateDynamic runs checks that can only be made after secret placeholders
+// have been resolved against cfg (see SecretResolver.ResolveConfig), on top
+// of the structural checks ValidateStatic already ran on the raw config. A
+// field still holding a "${scheme:ref}" placeholder here means its provider
+// failed to resolve it, which ResolveConfig already reported - ValidateDynamic
+// exists so a Loader or Watcher has one place to decide the whole refresh is
+// unsafe to apply, rather than every caller re-deriving that from a resolve
+// error. ctx is accepted for symmetry with future checks that may need to
+// make their own calls (e.g. confirming a rotated credential can still reach
+// its backend) but is unused today.
+func ValidateDynamic(ctx context.Context, cfg *Config) error {
+	if unresolved := FindUnresolvedPlaceholders(cfg); len(unresolved) > 0 {
+		return fmt.Errorf("configuration has unresolved secret reference(s): %s", strings.Join(unresolved, ", "))
 	}
 
 	return nil
 }
 
-func validateServer(cfg ServerConfig) error {
+func validateServer(cfg ServerConfig) ValidationErrors {
+	var errs ValidationErrors
+
 	if cfg.Port < 1 || cfg.Port > 65535 {
-		return &ValidationError{
+		errs = append(errs, &ValidationError{
 			Field:   "server.port",
 			Message: fmt.Sprintf("port must be between 1 and 65535, got %d", cfg.Port),
-		}
+		})
 	}
 
 	if cfg.ReadTimeoutSeconds <= 0 {
-		return &ValidationError{
+		errs = append(errs, &ValidationError{
 			Field:   "server.read_timeout_seconds",
 			Message: "read timeout must be positive",
-		}
+		})
 	}
 
 	if cfg.WriteTimeoutSeconds <= 0 {
-		return &ValidationError{
+		errs = append(errs, &ValidationError{
 			Field:   "server.write_timeout_seconds",
 			Message: "write timeout must be positive",
-		}
+		})
 	}
 
-	return nil
+	return errs
 }
 
-func validateBroker(cfg BrokerConfig) error {
+func validateBroker(cfg BrokerConfig) ValidationErrors {
+	var errs ValidationErrors
+
 	if cfg.Type == "" {
-		return &ValidationError{
+		errs = append(errs, &ValidationError{
 			Field:   "broker.type",
 			Message: "broker type is required",
-		}
+		})
+	} else {
+		switch cfg.Type {
+		case "kafka":
+			errs = append(errs, validateKafka(cfg.Kafka)...)
+		case "rabbitmq":
+			errs = append(errs, validateRabbitMQ(cfg.RabbitMQ)...)
+		case "nats":
+			errs = append(errs, validateNATS(cfg.NATS)...)
+		case "redis-streams":
+			errs = append(errs, validateRedisStreams(cfg.RedisStreams)...)
+		default:
+			errs = append(errs, &ValidationError{
+				Field:   "broker.type",
+				Message: fmt.Sprintf("unknown broker type: %s (supported: kafka, rabbitmq, nats, redis-streams)", cfg.Type),
+			})
+		}
+	}
+
+	errs = append(errs, validateSchemaRegistry(cfg.SchemaRegistry)...)
+	errs = append(errs, validateBrokerRateLimit(cfg.RateLimit)...)
+
+	return errs
+}
+
+func validateBrokerRateLimit(cfg BrokerRateLimitConfig) ValidationErrors {
+	var errs ValidationErrors
+
+	if !cfg.Enabled {
+		return errs
 	}
 
-	switch cfg.Type {
-	case "kafka":
-		return validateKafka(cfg.Kafka)
-	case "rabbitmq":
-		return validateRabbitMQ(cfg.RabbitMQ)
+	if cfg.MessagesPerSecond <= 0 {
+		errs = append(errs, &ValidationError{
+			Field:   "broker.rate_limit.messages_per_second",
+			Message: "messages_per_second must be positive when rate limiting is enabled",
+		})
+	}
+
+	if cfg.Burst <= 0 {
+		errs = append(errs, &ValidationError{
+			Field:   "broker.rate_limit.burst",
+			Message: "burst must be positive when rate limiting is enabled",
+		})
+	}
+
+	switch cfg.Strategy {
+	case "", "token_bucket", "leaky_bucket":
 	default:
-		return &ValidationError{
-			Field:   "broker.type",
-			Message: fmt.Sprintf("unknown broker type: %s (supported: kafka, rabbitmq)", cfg.Type),
-		}
+		errs = append(errs, &ValidationError{
+			Field:   "broker.rate_limit.strategy",
+			Message: fmt.Sprintf("unknown rate limit strategy: %s (supported: token_bucket, leaky_bucket)", cfg.Strategy),
+		})
+	}
+
+	return errs
+}
+
+func validateSchemaRegistry(cfg SchemaRegistryConfig) ValidationErrors {
+	var errs ValidationErrors
+
+	if !cfg.Enabled {
+		return errs
+	}
+
+	if cfg.URL == "" {
+		errs = append(errs, &ValidationError{
+			Field:   "broker.schema_registry.url",
+			Message: "schema registry URL is required when schema_registry is enabled",
+		})
+	}
+
+	switch cfg.Codec {
+	case "", "json", "avro", "protobuf":
+	default:
+		errs = append(errs, &ValidationError{
+			Field:   "broker.schema_registry.codec",
+			Message: fmt.Sprintf("unknown codec: %s (supported: json, avro, protobuf)", cfg.Codec),
+		})
+	}
+
+	if cfg.Codec != "" && cfg.Codec != "json" && cfg.Subject == "" {
+		errs = append(errs, &ValidationError{
+			Field:   "broker.schema_registry.subject",
+			Message: "subject is required for avro/protobuf codecs",
+		})
 	}
+
+	return errs
 }
 
-func validateKafka(cfg KafkaConfig) error {
+func validateKafka(cfg KafkaConfig) ValidationErrors {
+	var errs ValidationErrors
+
+	if cfg.Driver != "" && cfg.Driver != "segmentio" && cfg.Driver != "franz" {
+		errs = append(errs, &ValidationError{
+			Field:   "broker.kafka.driver",
+			Message: "driver must be \"segmentio\" or \"franz\"",
+		})
+	}
+
 	if len(cfg.Brokers) == 0 {
-		return &ValidationError{
+		errs = append(errs, &ValidationError{
 			Field:   "broker.kafka.brokers",
 			Message: "at least one Kafka broker is required",
-		}
+		})
 	}
 
 	for i, broker := range cfg.Brokers {
 		if broker == "" {
-			return &ValidationError{
+			errs = append(errs, &ValidationError{
 				Field:   fmt.Sprintf("broker.kafka.brokers[%d]", i),
 				Message: "broker address cannot be empty",
-			}
+			})
 		}
 	}
 
 	if cfg.GroupID == "" {
-		return &ValidationError{
+		errs = append(errs, &ValidationError{
 			Field:   "broker.kafka.group_id",
 			Message: "Kafka consumer group ID is required",
-		}
+		})
 	}
 
 	if cfg.Retry.MaxAttempts < 0 {
-		return &ValidationError{
+		errs = append(errs, &ValidationError{
 			Field:   "broker.kafka.retry.max_attempts",
 			Message: "max_attempts must be non-negative",
-		}
+		})
 	}
 
 	if cfg.Retry.InitialInterval < 0 {
-		return &ValidationError{
+		errs = append(errs, &ValidationError{
 			Field:   "broker.kafka.retry.initial_interval",
 			Message: "initial_interval must be non-negative",
-		}
+		})
 	}
 
 	if cfg.Retry.MaxInterval < 0 {
-		return &ValidationError{
+		errs = append(errs, &ValidationError{
 			Field:   "broker.kafka.retry.max_interval",
 			Message: "max_interval must be non-negative",
-		}
+		})
 	}
 
 	if cfg.Retry.MaxInterval > 0 && cfg.Retry.InitialInterval > 0 && cfg.Retry.MaxInterval < cfg.Retry.InitialInterval {
-		return &ValidationError{
+		errs = append(errs, &ValidationError{
 			Field:   "broker.kafka.retry.max_interval",
 			Message: "max_interval must be greater than or equal to initial_interval",
-		}
+		})
 	}
 
 	if cfg.Retry.Multiplier <= 0 {
-		return &ValidationError{
+		errs = append(errs, &ValidationError{
 			Field:   "broker.kafka.retry.multiplier",
 			Message: "multiplier must be positive",
-		}
+		})
 	}
 
-	return nil
+	errs = append(errs, validateRetryJitter(cfg.Retry, "broker.kafka.retry.jitter")...)
+
+	return errs
+}
+
+func validateRetryJitter(cfg RetryConfig, field string) ValidationErrors {
+	switch cfg.Jitter {
+	case "", "none", "full", "decorrelated":
+		return nil
+	default:
+		return ValidationErrors{{
+			Field:   field,
+			Message: fmt.Sprintf("unknown jitter strategy: %s (supported: none, full, decorrelated)", cfg.Jitter),
+		}}
+	}
 }
 
-func validateRabbitMQ(cfg RabbitMQConfig) error {
+func validateRabbitMQ(cfg RabbitMQConfig) ValidationErrors {
+	var errs ValidationErrors
+
 	if cfg.Host == "" {
-		return &ValidationError{
+		errs = append(errs, &ValidationError{
 			Field:   "broker.rabbitmq.host",
 			Message: "RabbitMQ host is required",
-		}
+		})
 	}
 
 	if cfg.Port < 1 || cfg.Port > 65535 {
-		return &ValidationError{
+		errs = append(errs, &ValidationError{
 			Field:   "broker.rabbitmq.port",
 			Message: fmt.Sprintf("port must be between 1 and 65535, got %d", cfg.Port),
+		})
+	}
+
+	return errs
+}
+
+func validateConfigSource(cfg ConfigSourceConfig) ValidationErrors {
+	switch cfg.Type {
+	case "", "kafka", "file":
+		return nil
+	case "etcd":
+		var errs ValidationErrors
+		if len(cfg.Etcd.Endpoints) == 0 {
+			errs = append(errs, &ValidationError{
+				Field:   "config_source.etcd.endpoints",
+				Message: "at least one etcd endpoint is required",
+			})
+		}
+		if cfg.Etcd.Prefix == "" {
+			errs = append(errs, &ValidationError{
+				Field:   "config_source.etcd.prefix",
+				Message: "etcd key prefix is required",
+			})
+		}
+		return errs
+	default:
+		return ValidationErrors{{
+			Field:   "config_source.type",
+			Message: fmt.Sprintf("unknown config source type: %s (supported: kafka, etcd, file)", cfg.Type),
+		}}
+	}
+}
+
+func validateNATS(cfg NATSConfig) ValidationErrors {
+	var errs ValidationErrors
+
+	if cfg.URL == "" {
+		errs = append(errs, &ValidationError{
+			Field:   "broker.nats.url",
+			Message: "NATS URL is required",
+		})
+	}
+
+	if cfg.InputSubject == "" {
+		errs = append(errs, &ValidationError{
+			Field:   "broker.nats.input_subject",
+			Message: "NATS input subject is required",
+		})
+	} else {
+		errs = append(errs, validateNATSSubject(cfg.InputSubject, "broker.nats.input_subject")...)
+	}
+
+	if cfg.Stream == "" {
+		errs = append(errs, &ValidationError{
+			Field:   "broker.nats.stream",
+			Message: "NATS JetStream stream name is required",
+		})
+	}
+
+	if cfg.AckWait < 0 {
+		errs = append(errs, &ValidationError{
+			Field:   "broker.nats.ack_wait",
+			Message: "ack_wait cannot be negative",
+		})
+	}
+
+	if cfg.Retry.MaxAttempts < 0 {
+		errs = append(errs, &ValidationError{
+			Field:   "broker.nats.retry.max_attempts",
+			Message: "retry max_attempts cannot be negative",
+		})
+	}
+
+	errs = append(errs, validateRetryJitter(cfg.Retry, "broker.nats.retry.jitter")...)
+
+	return errs
+}
+
+// validateNATSSubject enforces the subset of NATS subject syntax that
+// matters for a config value: non-empty tokens separated by ".", with no
+// whitespace and no literal ">" except as the final full-token wildcard
+// (matching zero or more trailing tokens). "*" single-token wildcards are
+// allowed anywhere since JetStream consumers commonly bind to them.
+func validateNATSSubject(subject string, field string) ValidationErrors {
+	var errs ValidationErrors
+
+	if strings.ContainsAny(subject, " \t\n") {
+		errs = append(errs, &ValidationError{
+			Field:   field,
+			Message: fmt.Sprintf("invalid NATS subject %q: subjects cannot contain whitespace", subject),
+		})
+		return errs
+	}
+
+	tokens := strings.Split(subject, ".")
+	for i, token := range tokens {
+		if token == "" {
+			errs = append(errs, &ValidationError{
+				Field:   field,
+				Message: fmt.Sprintf("invalid NATS subject %q: empty token between dots", subject),
+			})
+			continue
+		}
+		if strings.Contains(token, ">") && (token != ">" || i != len(tokens)-1) {
+			errs = append(errs, &ValidationError{
+				Field:   field,
+				Message: fmt.Sprintf("invalid NATS subject %q: \">\" is only valid as the final token", subject),
+			})
 		}
 	}
 
-	return nil
+	return errs
+}
+
+func validateRedisStreams(cfg RedisStreamsConfig) ValidationErrors {
+	var errs ValidationErrors
+
+	if cfg.Addr == "" {
+		errs = append(errs, &ValidationError{
+			Field:   "broker.redis_streams.addr",
+			Message: "Redis address is required",
+		})
+	}
+
+	if cfg.InputStream == "" {
+		errs = append(errs, &ValidationError{
+			Field:   "broker.redis_streams.input_stream",
+			Message: "input_stream is required",
+		})
+	}
+
+	if cfg.ConsumerGroup == "" {
+		errs = append(errs, &ValidationError{
+			Field:   "broker.redis_streams.consumer_group",
+			Message: "consumer_group is required",
+		})
+	}
+
+	if cfg.BlockMilliseconds < 0 {
+		errs = append(errs, &ValidationError{
+			Field:   "broker.redis_streams.block_milliseconds",
+			Message: "block_milliseconds cannot be negative",
+		})
+	}
+
+	if cfg.ClaimIntervalSeconds < 0 {
+		errs = append(errs, &ValidationError{
+			Field:   "broker.redis_streams.claim_interval_seconds",
+			Message: "claim_interval_seconds cannot be negative",
+		})
+	}
+
+	if cfg.ClaimMinIdleSeconds < 0 {
+		errs = append(errs, &ValidationError{
+			Field:   "broker.redis_streams.claim_min_idle_seconds",
+			Message: "claim_min_idle_seconds cannot be negative",
+		})
+	}
+
+	if cfg.Retry.MaxAttempts < 0 {
+		errs = append(errs, &ValidationError{
+			Field:   "broker.redis_streams.retry.max_attempts",
+			Message: "retry max_attempts cannot be negative",
+		})
+	}
+
+	errs = append(errs, validateRetryJitter(cfg.Retry, "broker.redis_streams.retry.jitter")...)
+
+	return errs
 }
 
-func validateDatabase(cfg DatabaseConfig) error {
+func validateDatabase(cfg DatabaseConfig) ValidationErrors {
+	var errs ValidationErrors
+
 	if cfg.Postgres.Host != "" || cfg.Postgres.Port > 0 {
-		if err := validatePostgres(cfg.Postgres); err != nil {
-			return err
-		}
+		errs = append(errs, validatePostgres(cfg.Postgres)...)
 	}
 
 	if cfg.Redis.Host != "" || cfg.Redis.Port > 0 {
-		if err := validateRedis(cfg.Redis); err != nil {
-			return err
-		}
+		errs = append(errs, validateRedis(cfg.Redis)...)
 	}
 
 	if cfg.MongoDB.URI != "" {
-		if err := validateMongoDB(cfg.MongoDB); err != nil {
-			return err
-		}
+		errs = append(errs, validateMongoDB(cfg.MongoDB)...)
 	}
 
-	return nil
+	return errs
 }
 
-func validatePostgres(cfg PostgresConfig) error {
+func validatePostgres(cfg PostgresConfig) ValidationErrors {
+	var errs ValidationErrors
+
 	if cfg.Host == "" {
-		return &ValidationError{
+		errs = append(errs, &ValidationError{
 			Field:   "database.postgres.host",
 			Message: "PostgreSQL host is required",
-		}
+		})
 	}
 
 	if cfg.Port < 1 || cfg.Port > 65535 {
-		return &ValidationError{
+		errs = append(errs, &ValidationError{
 			Field:   "database.postgres.port",
 			Message: fmt.Sprintf("port must be between 1 and 65535, got %d", cfg.Port),
-		}
+		})
 	}
 
 	if cfg.User == "" {
-		return &ValidationError{
+		errs = append(errs, &ValidationError{
 			Field:   "database.postgres.user",
 			Message: "PostgreSQL user is required",
-		}
+		})
 	}
 
 	if cfg.DBName == "" {
-		return &ValidationError{
+		errs = append(errs, &ValidationError{
 			Field:   "database.postgres.dbname",
 			Message: "PostgreSQL database name is required",
-		}
+		})
 	}
 
 	validSSLModes := map[string]bool{
@@ -222,92 +567,218 @@ func validatePostgres(cfg PostgresConfig) error {
 		"require": true, "verify-ca": true, "verify-full": true,
 	}
 	if cfg.SSLMode != "" && !validSSLModes[strings.ToLower(cfg.SSLMode)] {
-		return &ValidationError{
+		errs = append(errs, &ValidationError{
 			Field:   "database.postgres.sslmode",
 			Message: fmt.Sprintf("invalid SSL mode: %s (valid: disable, allow, prefer, require, verify-ca, verify-full)", cfg.SSLMode),
-		}
+		})
 	}
 
-	return nil
+	return errs
 }
 
-func validateRedis(cfg RedisConfig) error {
+func validateRedis(cfg RedisConfig) ValidationErrors {
+	var errs ValidationErrors
+
 	if cfg.Host == "" {
-		return &ValidationError{
+		errs = append(errs, &ValidationError{
 			Field:   "database.redis.host",
 			Message: "Redis host is required",
-		}
+		})
 	}
 
 	if cfg.Port < 1 || cfg.Port > 65535 {
-		return &ValidationError{
+		errs = append(errs, &ValidationError{
 			Field:   "database.redis.port",
 			Message: fmt.Sprintf("port must be between 1 and 65535, got %d", cfg.Port),
-		}
+		})
 	}
 
 	if cfg.TTLSeconds < 0 {
-		return &ValidationError{
+		errs = append(errs, &ValidationError{
 			Field:   "database.redis.ttl_seconds",
 			Message: "TTL must be non-negative",
-		}
+		})
 	}
 
-	return nil
+	return errs
 }
 
-func validateMongoDB(cfg MongoDBConfig) error {
+func validateMongoDB(cfg MongoDBConfig) ValidationErrors {
+	var errs ValidationErrors
+
 	if cfg.URI == "" {
-		return &ValidationError{
+		errs = append(errs, &ValidationError{
 			Field:   "database.mongodb.uri",
 			Message: "MongoDB URI is required",
-		}
-	}
-
-	if !strings.HasPrefix(cfg.URI, "mongodb://") && !strings.HasPrefix(cfg.URI, "mongodb+srv://") {
-		return &ValidationError{
+		})
+	} else if !strings.HasPrefix(cfg.URI, "mongodb://") && !strings.HasPrefix(cfg.URI, "mongodb+srv://") {
+		errs = append(errs, &ValidationError{
 			Field:   "database.mongodb.uri",
 			Message: "MongoDB URI must start with mongodb:// or mongodb+srv://",
-		}
+		})
 	}
 
 	if cfg.Database == "" {
-		return &ValidationError{
+		errs = append(errs, &ValidationError{
 			Field:   "database.mongodb.database",
 			Message: "MongoDB database name is required",
+		})
+	}
+
+	return errs
+}
+
+func validateEnrichment(cfg EnrichmentConfig) ValidationErrors {
+	return validateRetryJitter(cfg.Retry, "enrichment.retry.jitter")
+}
+
+// validateEnrichmentStorage checks that Driver, if set, is one
+// management.NewEnrichmentRepositoryFromConfig actually knows about, and
+// that db has the connection details that driver needs - the same
+// requirement NewEnrichmentRepositoryFromConfig enforces at construction
+// time, caught here instead so a misconfigured driver fails fast at startup
+// rather than the first time the enrichment repository is built.
+func validateEnrichmentStorage(cfg EnrichmentStorageConfig, db DatabaseConfig) ValidationErrors {
+	switch cfg.Driver {
+	case "", "mongodb":
+		return nil
+	case "postgres":
+		if db.Postgres.Host == "" {
+			return ValidationErrors{{
+				Field:   "management.enrichment_storage.driver",
+				Message: "driver \"postgres\" requires database.postgres.host to be set",
+			}}
+		}
+	case "sqlite":
+		if db.SQLite.Path == "" {
+			return ValidationErrors{{
+				Field:   "management.enrichment_storage.driver",
+				Message: "driver \"sqlite\" requires database.sqlite.path to be set",
+			}}
 		}
+	default:
+		return ValidationErrors{{
+			Field:   "management.enrichment_storage.driver",
+			Message: fmt.Sprintf("unknown enrichment storage driver %q", cfg.Driver),
+		}}
 	}
 
 	return nil
 }
 
-func validateDeduplication(cfg DeduplicationConfig) error {
+// validateEnrichmentRuleStorage is validateEnrichmentStorage's counterpart
+// for enrichment.NewRepositoryFromConfig, the runtime enrichment.Repository
+// factory: it recognizes the same "" / "mongodb" / "postgres" drivers plus
+// "file" and "http", which management's admin-CRUD repository has no
+// equivalent of.
+func validateEnrichmentRuleStorage(cfg EnrichmentRuleStorageConfig, db DatabaseConfig) ValidationErrors {
+	switch cfg.Driver {
+	case "", "mongodb":
+		return nil
+	case "postgres":
+		if db.Postgres.Host == "" {
+			return ValidationErrors{{
+				Field:   "enrichment.rule_storage.driver",
+				Message: "driver \"postgres\" requires database.postgres.host to be set",
+			}}
+		}
+	case "file":
+		if cfg.File.Path == "" {
+			return ValidationErrors{{
+				Field:   "enrichment.rule_storage.driver",
+				Message: "driver \"file\" requires enrichment.rule_storage.file.path to be set",
+			}}
+		}
+	case "http":
+		if cfg.HTTP.URL == "" {
+			return ValidationErrors{{
+				Field:   "enrichment.rule_storage.driver",
+				Message: "driver \"http\" requires enrichment.rule_storage.http.url to be set",
+			}}
+		}
+	default:
+		return ValidationErrors{{
+			Field:   "enrichment.rule_storage.driver",
+			Message: fmt.Sprintf("unknown enrichment rule storage driver %q", cfg.Driver),
+		}}
+	}
+
+	return nil
+}
+
+func validateManagementAuth(cfg AuthConfig) ValidationErrors {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	if cfg.BootstrapAPIKey != "" && cfg.BootstrapTenantID == "" {
+		return ValidationErrors{{
+			Field:   "management.auth.bootstrap_tenant_id",
+			Message: "bootstrap_tenant_id is required when bootstrap_api_key is set",
+		}}
+	}
+
+	return nil
+}
+
+func validateDeduplication(cfg DeduplicationConfig) ValidationErrors {
+	var errs ValidationErrors
+
 	validAlgorithms := map[string]bool{
-		"md5": true, "sha256": true, "sha1": true,
+		"md5": true, "sha256": true, "sha1": true, "hmac-sha256": true,
+		"xxhash64": true, "xxh3": true, "blake3": true, "siphash": true,
 	}
 	if cfg.HashAlgorithm != "" && !validAlgorithms[strings.ToLower(cfg.HashAlgorithm)] {
-		return &ValidationError{
+		errs = append(errs, &ValidationError{
 			Field:   "deduplication.hash_algorithm",
-			Message: fmt.Sprintf("invalid hash algorithm: %s (valid: md5, sha256, sha1)", cfg.HashAlgorithm),
-		}
+			Message: fmt.Sprintf("invalid hash algorithm: %s (valid: md5, sha256, sha1, hmac-sha256, xxhash64, xxh3, blake3, siphash)", cfg.HashAlgorithm),
+		})
+	}
+	keyedAlgorithm := strings.ToLower(cfg.HashAlgorithm) == "hmac-sha256" || strings.ToLower(cfg.HashAlgorithm) == "siphash"
+	if keyedAlgorithm && cfg.HMACKey == "" {
+		errs = append(errs, &ValidationError{
+			Field:   "deduplication.hmac_key",
+			Message: fmt.Sprintf("hmac_key is required when hash_algorithm is %s", strings.ToLower(cfg.HashAlgorithm)),
+		})
 	}
 
 	if cfg.TTLSeconds < 0 {
-		return &ValidationError{
+		errs = append(errs, &ValidationError{
 			Field:   "deduplication.ttl_seconds",
 			Message: "TTL must be non-negative",
-		}
+		})
 	}
 
 	validOnError := map[string]bool{
 		"allow": true, "reject": true, "fail": true,
 	}
 	if cfg.OnRedisError != "" && !validOnError[strings.ToLower(cfg.OnRedisError)] {
-		return &ValidationError{
+		errs = append(errs, &ValidationError{
 			Field:   "deduplication.on_redis_error",
 			Message: fmt.Sprintf("invalid on_redis_error value: %s (valid: allow, reject, fail)", cfg.OnRedisError),
+		})
+	}
+
+	for i, policy := range cfg.Policies {
+		if policy.Match == "" {
+			errs = append(errs, &ValidationError{
+				Field:   fmt.Sprintf("deduplication.policies[%d].match", i),
+				Message: "match expression cannot be empty",
+			})
+		}
+		if policy.TTLSeconds < 0 {
+			errs = append(errs, &ValidationError{
+				Field:   fmt.Sprintf("deduplication.policies[%d].ttl_seconds", i),
+				Message: "TTL must be non-negative",
+			})
+		}
+		if policy.OnRedisError != "" && !validOnError[strings.ToLower(policy.OnRedisError)] {
+			errs = append(errs, &ValidationError{
+				Field:   fmt.Sprintf("deduplication.policies[%d].on_redis_error", i),
+				Message: fmt.Sprintf("invalid on_redis_error value: %s (valid: allow, reject, fail)", policy.OnRedisError),
+			})
 		}
 	}
 
-	return nil
+	return errs
 }
@@ -0,0 +1,387 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+
+	"yeti/internal/logger"
+)
+
+// ConfigChange describes a change to one hot-reloadable config section,
+// delivered to subscribers registered via Watcher.Subscribe.
+type ConfigChange struct {
+	Section string
+	Old     interface{}
+	New     interface{}
+}
+
+type configSubscriber struct {
+	section string
+	fn      func(ConfigChange)
+}
+
+// Watcher layers hot reload on top of LoadConfig: it watches the config file
+// for changes via viper's fsnotify-backed WatchConfig, reloads on SIGHUP, and
+// - when Secrets.RefreshIntervalSeconds is set - reloads on that cadence too,
+// so a secret's TTL expiring in Vault is picked up without either of those.
+// Each reload re-resolves every "${scheme:ref}" placeholder with a
+// SecretResolver, re-validates the new config with ValidateStatic and
+// ValidateDynamic, diffs it section by section against the running config,
+// and either dispatches ConfigChange notifications for sections that can be
+// safely rebound at runtime (logging level, tracing sampler, circuit breaker
+// thresholds, database/broker credentials, ...), or rejects the whole reload
+// with a clear error if a static section (Postgres host, Kafka brokers, ...)
+// changed, leaving the running config intact. The same rejection covers a
+// resolve or validation failure, so a secret rotated to a bad value can't
+// poison the running process.
+type Watcher struct {
+	configFile string
+	log        logger.Logger
+	resolver   *SecretResolver
+
+	mu      sync.RWMutex
+	current *Config
+
+	subMu sync.RWMutex
+	subs  []configSubscriber
+
+	sigCh chan os.Signal
+}
+
+// NewWatcher creates a Watcher around the config already loaded from
+// configFile via LoadConfig. log may be nil. The SecretResolver used on every
+// reload is built from initial.Secrets, matching how LoadConfig built the one
+// used for the initial load.
+func NewWatcher(configFile string, initial *Config, log logger.Logger) *Watcher {
+	return &Watcher{
+		configFile: configFile,
+		log:        log,
+		resolver:   NewSecretResolver(initial.Secrets),
+		current:    initial,
+		sigCh:      make(chan os.Signal, 1),
+	}
+}
+
+// Subscribe registers fn to be called whenever the given section changes and
+// the reload carrying that change is accepted. section is one of the dot
+// paths used in diffSections (e.g. "logging", "tracing.sampler").
+func (w *Watcher) Subscribe(section string, fn func(ConfigChange)) {
+	w.subMu.Lock()
+	defer w.subMu.Unlock()
+	w.subs = append(w.subs, configSubscriber{section: section, fn: fn})
+}
+
+// Current returns the most recently accepted configuration.
+func (w *Watcher) Current() *Config {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current
+}
+
+// Start begins watching the config file for changes, listening for SIGHUP,
+// and - if the initial config set Secrets.RefreshIntervalSeconds - ticking on
+// that interval, reloading on any of the three. It returns immediately;
+// watching stops when ctx is done.
+func (w *Watcher) Start(ctx context.Context) {
+	viper.OnConfigChange(func(e fsnotify.Event) {
+		w.reload("config file changed: " + e.Name)
+	})
+	viper.WatchConfig()
+
+	signal.Notify(w.sigCh, syscall.SIGHUP)
+
+	go func() {
+		<-ctx.Done()
+		signal.Stop(w.sigCh)
+	}()
+
+	go func() {
+		for {
+			select {
+			case <-w.sigCh:
+				w.reload("SIGHUP received")
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	if interval := w.Current().Secrets.RefreshIntervalSeconds; interval > 0 {
+		go w.runRefreshTicker(ctx, time.Duration(interval)*time.Second)
+	}
+}
+
+func (w *Watcher) runRefreshTicker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.reload("secret refresh interval elapsed")
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (w *Watcher) reload(trigger string) {
+	newCfg, err := w.reloadConfig()
+	if err != nil {
+		w.logWarn("config reload rejected", "trigger", trigger, "error", err)
+		return
+	}
+
+	w.mu.Lock()
+	oldCfg := w.current
+	changes, err := diffSections(oldCfg, newCfg)
+	if err != nil {
+		w.mu.Unlock()
+		w.logWarn("config reload rejected", "trigger", trigger, "error", err)
+		return
+	}
+	w.current = newCfg
+	w.mu.Unlock()
+
+	w.logInfo("config reload accepted", "trigger", trigger, "sections_changed", len(changes))
+	w.dispatch(changes)
+}
+
+func (w *Watcher) dispatch(changes []ConfigChange) {
+	if len(changes) == 0 {
+		return
+	}
+
+	w.subMu.RLock()
+	defer w.subMu.RUnlock()
+
+	for _, change := range changes {
+		for _, sub := range w.subs {
+			if sub.section == change.Section {
+				sub.fn(change)
+			}
+		}
+	}
+}
+
+func (w *Watcher) logWarn(msg string, keysAndValues ...interface{}) {
+	if w.log != nil {
+		w.log.Warnw(msg, keysAndValues...)
+	}
+}
+
+func (w *Watcher) logInfo(msg string, keysAndValues ...interface{}) {
+	if w.log != nil {
+		w.log.Infow(msg, keysAndValues...)
+	}
+}
+
+// reloadConfig re-reads the already-configured viper instance without
+// resetting it (unlike LoadConfig, which is only meant for process startup),
+// so the fsnotify watcher and env bindings registered by Start survive. It
+// then re-resolves secret placeholders and re-validates exactly like
+// LoadConfig, so a bad reload - a malformed file, or a secret rotated to an
+// unreachable value - is caught here and never reaches diffSections.
+func (w *Watcher) reloadConfig() (*Config, error) {
+	if err := viper.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	if err := viper.Unmarshal(&cfg); err != nil {
+		return nil, err
+	}
+
+	if err := applyEnvOverrides(&cfg); err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+
+	if err := w.resolver.ResolveConfig(ctx, &cfg); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateStatic(&cfg); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateDynamic(ctx, &cfg); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// staticSectionError marks a field that changed between reloads but cannot
+// be safely applied without a process restart.
+type staticSectionError struct {
+	section string
+}
+
+func (e *staticSectionError) Error() string {
+	return "section '" + e.section + "' cannot be hot-reloaded; restart the service to apply this change"
+}
+
+// postgresCredentials and postgresConnection split PostgresConfig along the
+// line that matters for hot reload: credentials are expected to rotate (via
+// a "${vault:...}" placeholder) and can be rebound on a live connection pool,
+// while host/port/dbname/sslmode changing means talking to a different
+// database, which needs a restart.
+type postgresCredentials struct {
+	User     string
+	Password string
+}
+
+type postgresConnection struct {
+	Host    string
+	Port    int
+	DBName  string
+	SSLMode string
+}
+
+func postgresCredentialsOf(cfg PostgresConfig) postgresCredentials {
+	return postgresCredentials{User: cfg.User, Password: cfg.Password}
+}
+
+func postgresConnectionOf(cfg PostgresConfig) postgresConnection {
+	return postgresConnection{Host: cfg.Host, Port: cfg.Port, DBName: cfg.DBName, SSLMode: cfg.SSLMode}
+}
+
+// redisCredentials and redisConnection are RedisConfig's equivalent split.
+type redisCredentials struct {
+	Password string
+}
+
+type redisConnection struct {
+	Host string
+	Port int
+	DB   int
+}
+
+func redisCredentialsOf(cfg RedisConfig) redisCredentials {
+	return redisCredentials{Password: cfg.Password}
+}
+
+func redisConnectionOf(cfg RedisConfig) redisConnection {
+	return redisConnection{Host: cfg.Host, Port: cfg.Port, DB: cfg.DB}
+}
+
+// rabbitMQCredentials and rabbitMQConnection are RabbitMQConfig's equivalent
+// split.
+type rabbitMQCredentials struct {
+	User     string
+	Password string
+}
+
+type rabbitMQConnection struct {
+	Host        string
+	Port        int
+	InputQueue  string
+	OutputQueue string
+}
+
+func rabbitMQCredentialsOf(cfg RabbitMQConfig) rabbitMQCredentials {
+	return rabbitMQCredentials{User: cfg.User, Password: cfg.Password}
+}
+
+func rabbitMQConnectionOf(cfg RabbitMQConfig) rabbitMQConnection {
+	return rabbitMQConnection{Host: cfg.Host, Port: cfg.Port, InputQueue: cfg.InputQueue, OutputQueue: cfg.OutputQueue}
+}
+
+// diffSections compares old and new section by section. Sections considered
+// safe to rebind at runtime produce a ConfigChange; any other section that
+// changed causes the whole reload to be rejected so the running config is
+// never left in a partially-applied state.
+func diffSections(old, new *Config) ([]ConfigChange, error) {
+	var changes []ConfigChange
+
+	record := func(section string, hot bool, oldVal, newVal interface{}) error {
+		if reflect.DeepEqual(oldVal, newVal) {
+			return nil
+		}
+		if !hot {
+			return &staticSectionError{section: section}
+		}
+		changes = append(changes, ConfigChange{Section: section, Old: oldVal, New: newVal})
+		return nil
+	}
+
+	staticChecks := []struct {
+		section string
+		oldVal  interface{}
+		newVal  interface{}
+	}{
+		{"server", old.Server, new.Server},
+		{"database.postgres.connection", postgresConnectionOf(old.Database.Postgres), postgresConnectionOf(new.Database.Postgres)},
+		{"database.redis.connection", redisConnectionOf(old.Database.Redis), redisConnectionOf(new.Database.Redis)},
+		{"database.mongodb", old.Database.MongoDB, new.Database.MongoDB},
+		{"database.sqlite", old.Database.SQLite, new.Database.SQLite},
+		{"database.run_migrations", old.Database.RunMigrations, new.Database.RunMigrations},
+		// Secrets isn't re-read from the already-running Watcher's resolver
+		// (built once, from the config the process started with), so a
+		// changed Vault address/token needs a restart to take effect too.
+		{"secrets", old.Secrets, new.Secrets},
+		{"broker.type", old.Broker.Type, new.Broker.Type},
+		{"broker.rabbitmq.connection", rabbitMQConnectionOf(old.Broker.RabbitMQ), rabbitMQConnectionOf(new.Broker.RabbitMQ)},
+		{"broker.kafka.brokers", old.Broker.Kafka.Brokers, new.Broker.Kafka.Brokers},
+		{"broker.kafka.group_id", old.Broker.Kafka.GroupID, new.Broker.Kafka.GroupID},
+		{"broker.kafka.input_topic", old.Broker.Kafka.InputTopic, new.Broker.Kafka.InputTopic},
+		{"broker.kafka.output_topic", old.Broker.Kafka.OutputTopic, new.Broker.Kafka.OutputTopic},
+		{"broker.kafka.config_update_topic", old.Broker.Kafka.ConfigUpdateTopic, new.Broker.Kafka.ConfigUpdateTopic},
+		{"broker.kafka.dlq_topic", old.Broker.Kafka.DLQTopic, new.Broker.Kafka.DLQTopic},
+		{"filtering", old.Filtering, new.Filtering},
+		{"deduplication", old.Deduplication, new.Deduplication},
+		{"management", old.Management, new.Management},
+		{"tracing.enabled", old.Tracing.Enabled, new.Tracing.Enabled},
+		{"tracing.service_name", old.Tracing.ServiceName, new.Tracing.ServiceName},
+		{"tracing.protocol", old.Tracing.Protocol, new.Tracing.Protocol},
+		{"tracing.otlp", old.Tracing.OTLP, new.Tracing.OTLP},
+		{"metrics.enabled", old.Metrics.Enabled, new.Metrics.Enabled},
+		{"metrics.service_name", old.Metrics.ServiceName, new.Metrics.ServiceName},
+		{"metrics.protocol", old.Metrics.Protocol, new.Metrics.Protocol},
+		{"metrics.otlp", old.Metrics.OTLP, new.Metrics.OTLP},
+		{"metrics.rule_cardinality_cap", old.Metrics.RuleCardinalityCap, new.Metrics.RuleCardinalityCap},
+		{"metrics.push", old.Metrics.Push, new.Metrics.Push},
+		{"backpressure", old.Backpressure, new.Backpressure},
+	}
+	for _, c := range staticChecks {
+		if err := record(c.section, false, c.oldVal, c.newVal); err != nil {
+			return nil, err
+		}
+	}
+
+	hotChecks := []struct {
+		section string
+		oldVal  interface{}
+		newVal  interface{}
+	}{
+		{"logging", old.Logging, new.Logging},
+		{"circuit_breaker", old.CircuitBreaker, new.CircuitBreaker},
+		{"broker.kafka.retry", old.Broker.Kafka.Retry, new.Broker.Kafka.Retry},
+		{"tracing.sampler", old.Tracing.Sampler, new.Tracing.Sampler},
+		{"tracing.tail_sampling", old.Tracing.TailSampling, new.Tracing.TailSampling},
+		// Credential-only fields, split out of their parent struct so that
+		// rotating a secret (e.g. a Vault-backed password nearing its TTL)
+		// dispatches a ConfigChange instead of being rejected alongside the
+		// host/port/topic fields the rest of this section's struct holds.
+		{"database.postgres.credentials", postgresCredentialsOf(old.Database.Postgres), postgresCredentialsOf(new.Database.Postgres)},
+		{"database.redis.credentials", redisCredentialsOf(old.Database.Redis), redisCredentialsOf(new.Database.Redis)},
+		{"broker.rabbitmq.credentials", rabbitMQCredentialsOf(old.Broker.RabbitMQ), rabbitMQCredentialsOf(new.Broker.RabbitMQ)},
+	}
+	for _, c := range hotChecks {
+		if err := record(c.section, true, c.oldVal, c.newVal); err != nil {
+			return nil, err
+		}
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,241 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"yeti/internal/logger"
+)
+
+const baseTestConfigYAML = `
+server:
+  port: 8080
+  read_timeout_seconds: 5s
+  write_timeout_seconds: 5s
+broker:
+  type: kafka
+  kafka:
+    brokers:
+      - localhost:9092
+    group_id: test-group
+logging:
+  level: info
+  format: json
+`
+
+func writeTestConfig(t *testing.T, path, contents string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o644))
+}
+
+func loadTestConfig(t *testing.T, path string) *Config {
+	t.Helper()
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+	return cfg
+}
+
+func TestWatcher_Reload_AcceptsHotSectionChange(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	writeTestConfig(t, path, baseTestConfigYAML)
+
+	cfg := loadTestConfig(t, path)
+	require.Equal(t, "info", cfg.Logging.Level)
+
+	w := NewWatcher(CurrentConfigFile(), cfg, logger.NopLogger())
+
+	var received ConfigChange
+	w.Subscribe("logging", func(change ConfigChange) {
+		received = change
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w.Start(ctx)
+
+	writeTestConfig(t, path, `
+server:
+  port: 8080
+  read_timeout_seconds: 5s
+  write_timeout_seconds: 5s
+broker:
+  type: kafka
+  kafka:
+    brokers:
+      - localhost:9092
+    group_id: test-group
+logging:
+  level: debug
+  format: json
+`)
+
+	w.reload("test")
+
+	assert.Equal(t, "logging", received.Section)
+	assert.Equal(t, "debug", w.Current().Logging.Level)
+}
+
+func TestWatcher_Reload_RejectsStaticSectionChange(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	writeTestConfig(t, path, baseTestConfigYAML)
+
+	cfg := loadTestConfig(t, path)
+
+	w := NewWatcher(CurrentConfigFile(), cfg, logger.NopLogger())
+
+	var dispatched bool
+	w.Subscribe("broker.kafka.brokers", func(change ConfigChange) {
+		dispatched = true
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w.Start(ctx)
+
+	writeTestConfig(t, path, `
+server:
+  port: 8080
+  read_timeout_seconds: 5s
+  write_timeout_seconds: 5s
+broker:
+  type: kafka
+  kafka:
+    brokers:
+      - other-broker:9092
+    group_id: test-group
+logging:
+  level: info
+  format: json
+`)
+
+	w.reload("test")
+
+	assert.False(t, dispatched, "static section change should not be dispatched")
+	assert.Equal(t, []string{"localhost:9092"}, w.Current().Broker.Kafka.Brokers,
+		"running config must be left intact when a static section changes")
+}
+
+func TestWatcher_Reload_InvalidConfigLeavesRunningConfigIntact(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	writeTestConfig(t, path, baseTestConfigYAML)
+
+	cfg := loadTestConfig(t, path)
+
+	w := NewWatcher(CurrentConfigFile(), cfg, logger.NopLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w.Start(ctx)
+
+	writeTestConfig(t, path, `
+server:
+  port: 8080
+  read_timeout_seconds: 5s
+  write_timeout_seconds: 5s
+broker:
+  type: kafka
+  kafka:
+    brokers: []
+    group_id: test-group
+logging:
+  level: info
+  format: json
+`)
+
+	w.reload("test")
+
+	assert.Equal(t, []string{"localhost:9092"}, w.Current().Broker.Kafka.Brokers)
+}
+
+func TestWatcher_Reload_RotatesCredentialsWithoutRestart(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	config := baseTestConfigYAML + `
+database:
+  postgres:
+    host: localhost
+    port: 5432
+    user: yeti
+    password: old-password
+    dbname: yeti
+    sslmode: disable
+`
+	writeTestConfig(t, path, config)
+
+	cfg := loadTestConfig(t, path)
+	require.Equal(t, "old-password", cfg.Database.Postgres.Password)
+
+	w := NewWatcher(CurrentConfigFile(), cfg, logger.NopLogger())
+
+	var received ConfigChange
+	w.Subscribe("database.postgres.credentials", func(change ConfigChange) {
+		received = change
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w.Start(ctx)
+
+	writeTestConfig(t, path, baseTestConfigYAML+`
+database:
+  postgres:
+    host: localhost
+    port: 5432
+    user: yeti
+    password: new-password
+    dbname: yeti
+    sslmode: disable
+`)
+
+	w.reload("test")
+
+	assert.Equal(t, "database.postgres.credentials", received.Section)
+	assert.Equal(t, "new-password", w.Current().Database.Postgres.Password)
+}
+
+func TestWatcher_Reload_RejectsConnectionSectionChange(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	writeTestConfig(t, path, baseTestConfigYAML+`
+database:
+  postgres:
+    host: localhost
+    port: 5432
+    user: yeti
+    password: old-password
+    dbname: yeti
+    sslmode: disable
+`)
+
+	cfg := loadTestConfig(t, path)
+
+	w := NewWatcher(CurrentConfigFile(), cfg, logger.NopLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w.Start(ctx)
+
+	writeTestConfig(t, path, baseTestConfigYAML+`
+database:
+  postgres:
+    host: other-host
+    port: 5432
+    user: yeti
+    password: old-password
+    dbname: yeti
+    sslmode: disable
+`)
+
+	w.reload("test")
+
+	assert.Equal(t, "localhost", w.Current().Database.Postgres.Host,
+		"running config must be left intact when a connection field changes")
+}
@@ -3,15 +3,54 @@ package config_handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"sync"
+	"time"
 
 	"yeti/internal/logger"
+	"yeti/pkg/metrics"
 	"yeti/pkg/models"
 )
 
+// ErrNoRuleDelta is returned by RuleDeltaApplier.ApplyRuleDelta when a
+// non-"delete" event carries no embedded Rule payload to apply - either
+// because the event predates the Rule field or the producer otherwise chose
+// not to set it. (A "delete" needs no payload: ApplyRuleDelta removes the
+// rule by RuleID directly.) Handler treats it the same as
+// RuleDeltaApplier not being implemented at all: fall back to
+// RuleReloader/ConfigReloader.
+var ErrNoRuleDelta = errors.New("config_handler: event carries no rule delta to apply")
+
+// debounceWindow discards repeat events for the same rule_id that arrive
+// within this window of a reload already applied for it, so a burst of
+// rapid edits in the management API (or a producer retry) doesn't trigger
+// a reload storm.
+const debounceWindow = 250 * time.Millisecond
+
 type ConfigReloader interface {
 	ReloadRules(ctx context.Context) error
 }
 
+// RuleReloader is an optional, finer-grained ConfigReloader: if the
+// underlying filtering/enrichment Service implements it (both do), the
+// handler reloads only the rule named in the event instead of the full
+// rule set. Handler falls back to ConfigReloader.ReloadRules when the
+// event has no rule_id or the reloader doesn't implement this interface.
+type RuleReloader interface {
+	ReloadRule(ctx context.Context, ruleID string) error
+}
+
+// RuleDeltaApplier is RuleReloader's faster sibling: if the underlying
+// Service implements it (both filtering and enrichment do), the handler
+// applies the event's embedded Rule payload directly to the in-memory rule
+// set, skipping the repository round trip ReloadRule makes. Handler falls
+// back to RuleReloader when ApplyRuleDelta returns ErrNoRuleDelta (the
+// event carries no payload to apply) or the reloader doesn't implement
+// this interface at all.
+type RuleDeltaApplier interface {
+	ApplyRuleDelta(ctx context.Context, event models.ConfigUpdateEvent) error
+}
+
 type ConfigUpdater interface {
 	UpdateFieldsToHash(fields []string) error
 }
@@ -22,6 +61,16 @@ type Handler struct {
 	reloader            ConfigReloader
 	updater             ConfigUpdater
 	logger              logger.Logger
+
+	lastReloadMu sync.Mutex
+	lastReload   map[string]time.Time
+
+	// lagChecker/lagTopic/lagGateConfig back reloadRuleGated's readiness
+	// gate; set via WithLagGate. lagChecker is nil (gate disabled) unless
+	// WithLagGate was called.
+	lagChecker    LagChecker
+	lagTopic      string
+	lagGateConfig LagGateConfig
 }
 
 func NewHandler(expectedEventType, expectedServiceType string, log logger.Logger) *Handler {
@@ -29,6 +78,7 @@ func NewHandler(expectedEventType, expectedServiceType string, log logger.Logger
 		expectedEventType:   expectedEventType,
 		expectedServiceType: expectedServiceType,
 		logger:              log,
+		lastReload:          make(map[string]time.Time),
 	}
 }
 
@@ -50,6 +100,64 @@ func (h *Handler) WithUpdater(updater ConfigUpdater) *Handler {
 	return h
 }
 
+// reloadRule performs the actual reload for event, preferring a
+// RuleDeltaApplier.ApplyRuleDelta (applies the event's embedded rule
+// in-process, no repository round trip), then a targeted
+// RuleReloader.ReloadRule, and falling back to a full ReloadRules last
+// (including when a targeted reload itself errors, so a stale single rule
+// doesn't leave the in-memory set out of sync with Postgres/Mongo).
+func (h *Handler) reloadRule(ctx context.Context, event models.ConfigUpdateEvent) error {
+	start := time.Now()
+
+	if deltaApplier, ok := h.reloader.(RuleDeltaApplier); ok {
+		err := deltaApplier.ApplyRuleDelta(ctx, event)
+		if err == nil {
+			metrics.ObserveConfigReloadDuration(h.expectedServiceType, "delta", time.Since(start))
+			return nil
+		}
+		if !errors.Is(err, ErrNoRuleDelta) {
+			h.logger.Warnw("Rule delta apply failed, falling back to targeted/full reload",
+				"rule_id", event.RuleID,
+				"error", err,
+			)
+		}
+	}
+
+	trigger := "event"
+
+	ruleReloader, ok := h.reloader.(RuleReloader)
+	if ok && event.RuleID != "" {
+		if err := ruleReloader.ReloadRule(ctx, event.RuleID); err == nil {
+			metrics.ObserveConfigReloadDuration(h.expectedServiceType, trigger, time.Since(start))
+			return nil
+		}
+		h.logger.Warnw("Targeted rule reload failed, falling back to full reload",
+			"rule_id", event.RuleID,
+		)
+	}
+
+	err := h.reloader.ReloadRules(ctx)
+	metrics.ObserveConfigReloadDuration(h.expectedServiceType, trigger, time.Since(start))
+	return err
+}
+
+func (h *Handler) debounced(ruleID string) bool {
+	h.lastReloadMu.Lock()
+	defer h.lastReloadMu.Unlock()
+
+	last, ok := h.lastReload[ruleID]
+	return ok && time.Since(last) < debounceWindow
+}
+
+func (h *Handler) markReloaded(ruleID string) {
+	if ruleID == "" {
+		return
+	}
+	h.lastReloadMu.Lock()
+	h.lastReload[ruleID] = time.Now()
+	h.lastReloadMu.Unlock()
+}
+
 func (h *Handler) HandleConfigUpdateEvent(ctx context.Context, envelope models.MessageEnvelope) error {
 	eventType, ok := envelope.Metadata.Enrichment["event_type"].(string)
 	if !ok {
@@ -97,12 +205,23 @@ func (h *Handler) HandleConfigUpdateEvent(ctx context.Context, envelope models.M
 		"rule_id", event.RuleID,
 	)
 
+	if !event.Timestamp.IsZero() {
+		metrics.ObserveConfigReloadNotificationLag(h.expectedServiceType, time.Since(event.Timestamp))
+	}
+
 	if h.reloader != nil {
-		if err := h.reloader.ReloadRules(ctx); err != nil {
+		if event.RuleID != "" && h.debounced(event.RuleID) {
+			h.logger.Infow("Skipping reload, rule already reloaded within debounce window",
+				"rule_id", event.RuleID,
+				"action", event.Action,
+			)
+		} else if err := h.reloadRuleGated(ctx, event); err != nil {
 			h.logger.Errorw("Failed to reload rules after config update", "error", err)
 			return err
+		} else {
+			h.markReloaded(event.RuleID)
+			h.logger.Infow("Rules reloaded successfully after config update", "action", event.Action)
 		}
-		h.logger.Infow("Rules reloaded successfully after config update", "action", event.Action)
 	}
 
 	if h.updater == nil {
@@ -0,0 +1,76 @@
+package config_handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"yeti/internal/logger"
+	"yeti/pkg/models"
+)
+
+type fakeRuleReloader struct {
+	fullReloads   int
+	ruleReloads   []string
+	reloadRuleErr error
+}
+
+func (f *fakeRuleReloader) ReloadRules(ctx context.Context) error {
+	f.fullReloads++
+	return nil
+}
+
+func (f *fakeRuleReloader) ReloadRule(ctx context.Context, ruleID string) error {
+	f.ruleReloads = append(f.ruleReloads, ruleID)
+	return f.reloadRuleErr
+}
+
+func filteringEnvelope(ruleID, action string) models.MessageEnvelope {
+	return models.MessageEnvelope{
+		ID: "evt-1",
+		Payload: map[string]interface{}{
+			"event_type":   models.EventTypeFilteringRuleUpdated,
+			"service_type": models.ServiceTypeFiltering,
+			"rule_id":      ruleID,
+			"action":       action,
+			"timestamp":    time.Now(),
+		},
+	}
+}
+
+func TestHandler_HandleConfigUpdateEvent_UsesTargetedReload(t *testing.T) {
+	reloader := &fakeRuleReloader{}
+	h := NewHandlerWithReloader(models.EventTypeFilteringRuleUpdated, models.ServiceTypeFiltering, reloader, logger.NopLogger())
+
+	envelope := filteringEnvelope("rule-1", "update")
+
+	require.NoError(t, h.HandleConfigUpdateEvent(context.Background(), envelope))
+	assert.Equal(t, []string{"rule-1"}, reloader.ruleReloads)
+	assert.Equal(t, 0, reloader.fullReloads)
+}
+
+func TestHandler_HandleConfigUpdateEvent_DebouncesRepeatEvents(t *testing.T) {
+	reloader := &fakeRuleReloader{}
+	h := NewHandlerWithReloader(models.EventTypeFilteringRuleUpdated, models.ServiceTypeFiltering, reloader, logger.NopLogger())
+
+	envelope := filteringEnvelope("rule-1", "update")
+
+	require.NoError(t, h.HandleConfigUpdateEvent(context.Background(), envelope))
+	require.NoError(t, h.HandleConfigUpdateEvent(context.Background(), envelope))
+
+	assert.Len(t, reloader.ruleReloads, 1)
+}
+
+func TestHandler_HandleConfigUpdateEvent_FallsBackToFullReloadOnRuleReloadError(t *testing.T) {
+	reloader := &fakeRuleReloader{reloadRuleErr: assert.AnError}
+	h := NewHandlerWithReloader(models.EventTypeFilteringRuleUpdated, models.ServiceTypeFiltering, reloader, logger.NopLogger())
+
+	envelope := filteringEnvelope("rule-1", "update")
+
+	require.NoError(t, h.HandleConfigUpdateEvent(context.Background(), envelope))
+	assert.Equal(t, []string{"rule-1"}, reloader.ruleReloads)
+	assert.Equal(t, 1, reloader.fullReloads)
+}
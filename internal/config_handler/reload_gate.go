@@ -0,0 +1,153 @@
+package config_handler
+
+import (
+	"context"
+	"time"
+
+	"yeti/pkg/metrics"
+	"yeti/pkg/models"
+)
+
+// defaultLagPollInterval is how often Handler re-checks lag while waiting
+// for a LagChecker-gated reload to become safe to commit, when
+// config.LagGateConfig.PollInterval is left unset.
+const defaultLagPollInterval = 1 * time.Second
+
+// TwoPhaseReloader is ConfigReloader's richer optional form: if the
+// underlying Service implements it, Handler calls PrepareReload instead of
+// reloadRule directly and only calls the returned ReloadTransaction's
+// Commit once LagChecker reports the input topic has drained (or the
+// configured deadline elapses) - closing the stale-config window where
+// in-flight messages produced under the old rules are still being
+// processed downstream when the swap happens, the same kind of readiness
+// check Knative-Kafka's offsets-checker runs before marking a subscription
+// ready. A reloader that doesn't implement this gets an equivalent
+// deferredTransaction built around its ordinary ConfigReloader/
+// RuleReloader/RuleDeltaApplier methods (see Handler.prepareReload), so the
+// lag gate works the same way regardless of which reload path a Service
+// supports - none of filtering.Service/enrichment.Service stage a shadow
+// rule set behind a swap today, so PrepareReload for them (via
+// deferredTransaction) just defers *when* the existing, already-safe
+// reload call runs rather than staging new rule state itself.
+type TwoPhaseReloader interface {
+	ConfigReloader
+	PrepareReload(ctx context.Context, event models.ConfigUpdateEvent) (ReloadTransaction, error)
+}
+
+// ReloadTransaction is PrepareReload's result. Commit makes the prepared
+// reload take effect; Abort discards it without ever applying it (e.g. the
+// handler's context was canceled while waiting on lag).
+type ReloadTransaction interface {
+	Commit(ctx context.Context) error
+	Abort(ctx context.Context) error
+}
+
+// deferredTransaction adapts a plain reloadRule call into a
+// ReloadTransaction for reloaders that don't implement TwoPhaseReloader:
+// Commit runs it, Abort does nothing (there's nothing to undo - run hasn't
+// been called yet).
+type deferredTransaction struct {
+	run func(ctx context.Context) error
+}
+
+func (t deferredTransaction) Commit(ctx context.Context) error { return t.run(ctx) }
+func (t deferredTransaction) Abort(context.Context) error      { return nil }
+
+// LagChecker is the subset of broker.LagChecker Handler's readiness gate
+// needs, so config_handler doesn't have to import broker's Kafka client
+// types just to accept one.
+type LagChecker interface {
+	Lag(ctx context.Context, topic string, partitions []int) (int64, error)
+}
+
+// LagGateConfig mirrors config.LagGateConfig; duplicated here (rather than
+// importing internal/config) for the same reason scheduler.Schedule
+// duplicates management.Schedule - so config_handler doesn't need to
+// depend on the config package's much larger surface just for four fields.
+type LagGateConfig struct {
+	Enabled      bool
+	Deadline     time.Duration
+	PollInterval time.Duration
+	Partitions   []int
+}
+
+// WithLagGate equips Handler with a readiness gate: once cfg.Enabled,
+// prepared reloads only commit once checker reports zero lag on topic (or
+// cfg.Deadline elapses). Without a call to WithLagGate, Handler.reloadRule
+// applies exactly the way it always has - immediately, no gate.
+func (h *Handler) WithLagGate(checker LagChecker, topic string, cfg LagGateConfig) *Handler {
+	h.lagChecker = checker
+	h.lagTopic = topic
+	h.lagGateConfig = cfg
+	return h
+}
+
+// prepareReload builds the ReloadTransaction reloadRuleGated commits (after
+// the lag gate, if any, clears): the reloader's own PrepareReload if it
+// implements TwoPhaseReloader, otherwise a deferredTransaction wrapping
+// reloadRule.
+func (h *Handler) prepareReload(ctx context.Context, event models.ConfigUpdateEvent) (ReloadTransaction, error) {
+	if tp, ok := h.reloader.(TwoPhaseReloader); ok {
+		return tp.PrepareReload(ctx, event)
+	}
+	return deferredTransaction{run: func(ctx context.Context) error {
+		return h.reloadRule(ctx, event)
+	}}, nil
+}
+
+// reloadRuleGated is reloadRule's lag-aware entry point: it always prepares
+// the reload, but only commits once the lag gate (when
+// h.lagGateConfig.Enabled) says the input topic has drained of messages
+// produced under the old rules, or its deadline elapses - whichever comes
+// first. Without WithLagGate having been called, it commits immediately.
+func (h *Handler) reloadRuleGated(ctx context.Context, event models.ConfigUpdateEvent) error {
+	txn, err := h.prepareReload(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	if h.lagChecker == nil || !h.lagGateConfig.Enabled {
+		return txn.Commit(ctx)
+	}
+
+	poll := h.lagGateConfig.PollInterval
+	if poll <= 0 {
+		poll = defaultLagPollInterval
+	}
+	var deadline time.Time
+	if h.lagGateConfig.Deadline > 0 {
+		deadline = time.Now().Add(h.lagGateConfig.Deadline)
+	}
+
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+
+	for {
+		lag, err := h.lagChecker.Lag(ctx, h.lagTopic, h.lagGateConfig.Partitions)
+		if err != nil {
+			h.logger.Warnw("Lag check failed, treating as not yet drained",
+				"topic", h.lagTopic, "rule_id", event.RuleID, "error", err)
+		} else if lag <= 0 {
+			return txn.Commit(ctx)
+		}
+
+		if !deadline.IsZero() && !time.Now().Before(deadline) {
+			metrics.IncConfigReloadForcedCommit(h.expectedServiceType)
+			h.logger.Warnw("Reload force-committed: lag-readiness deadline elapsed",
+				"topic", h.lagTopic,
+				"rule_id", event.RuleID,
+				"action", event.Action,
+				"lag", lag,
+				"deadline", h.lagGateConfig.Deadline,
+			)
+			return txn.Commit(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			_ = txn.Abort(ctx)
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package configsource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"yeti/internal/config"
+	"yeti/internal/logger"
+	"yeti/pkg/models"
+	"yeti/pkg/retry"
+)
+
+// EtcdWatcher watches an etcd key prefix (e.g. "/yeti/dedup/rules/") and
+// translates every PUT/DELETE under it into a models.MessageEnvelope
+// carrying the given eventType/serviceType, matching the envelope shape
+// internal/management.ConfigEventProducer publishes to Kafka so both
+// sources feed the same config_handler.Handler pipeline. Watching resumes
+// from the last observed revision after a reconnect, so no events are
+// missed across a transient etcd outage.
+type EtcdWatcher struct {
+	client      *clientv3.Client
+	prefix      string
+	eventType   string
+	serviceType string
+	logger      logger.Logger
+	lastRev     int64
+}
+
+func NewEtcdWatcher(cfg config.EtcdSourceConfig, eventType, serviceType string, log logger.Logger) (*EtcdWatcher, error) {
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   cfg.Endpoints,
+		DialTimeout: cfg.DialTimeout,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create etcd client: %w", err)
+	}
+
+	return &EtcdWatcher{
+		client:      client,
+		prefix:      cfg.Prefix,
+		eventType:   eventType,
+		serviceType: serviceType,
+		logger:      log,
+	}, nil
+}
+
+func (w *EtcdWatcher) Watch(ctx context.Context) (<-chan models.MessageEnvelope, error) {
+	out := make(chan models.MessageEnvelope)
+
+	go w.run(ctx, out)
+
+	return out, nil
+}
+
+func (w *EtcdWatcher) run(ctx context.Context, out chan<- models.MessageEnvelope) {
+	defer close(out)
+
+	backOff := retry.FullJitterBackoff(time.Second, 30*time.Second)
+
+	for ctx.Err() == nil {
+		opts := []clientv3.OpOption{clientv3.WithPrefix()}
+		if w.lastRev > 0 {
+			opts = append(opts, clientv3.WithRev(w.lastRev+1))
+		}
+
+		watchCtx, cancel := context.WithCancel(ctx)
+		watchChan := w.client.Watch(watchCtx, w.prefix, opts...)
+
+		w.logger.InfowCtx(ctx, "Watching etcd prefix for config updates",
+			"prefix", w.prefix,
+			"from_revision", w.lastRev,
+		)
+
+		connected := w.drain(ctx, watchChan, out)
+		cancel()
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		if connected {
+			backOff.Reset()
+			continue
+		}
+
+		delay := backOff.NextBackOff()
+		w.logger.WarnwCtx(ctx, "etcd watch disconnected before any event, reconnecting after backoff",
+			"prefix", w.prefix,
+			"delay", delay,
+		)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// drain forwards events from a single etcd watch channel until it closes or
+// errors, returning whether at least one valid response was observed (so
+// the caller only backs off on watches that never connect).
+func (w *EtcdWatcher) drain(ctx context.Context, watchChan clientv3.WatchChan, out chan<- models.MessageEnvelope) bool {
+	connected := false
+
+	for resp := range watchChan {
+		if err := resp.Err(); err != nil {
+			w.logger.WarnwCtx(ctx, "etcd watch error", "error", err, "prefix", w.prefix)
+			return connected
+		}
+
+		connected = true
+		w.lastRev = resp.Header.Revision
+
+		for _, ev := range resp.Events {
+			envelope, err := w.toEnvelope(ev)
+			if err != nil {
+				w.logger.WarnwCtx(ctx, "Failed to translate etcd event to config update event",
+					"error", err,
+					"key", string(ev.Kv.Key),
+				)
+				continue
+			}
+
+			select {
+			case out <- envelope:
+			case <-ctx.Done():
+				return connected
+			}
+		}
+	}
+
+	return connected
+}
+
+func (w *EtcdWatcher) toEnvelope(ev *clientv3.Event) (models.MessageEnvelope, error) {
+	action := models.ActionUpdate
+	if ev.Type == clientv3.EventTypeDelete {
+		action = models.ActionDelete
+	}
+
+	event := models.ConfigUpdateEvent{
+		EventType:   w.eventType,
+		ServiceType: w.serviceType,
+		RuleID:      strings.TrimPrefix(string(ev.Kv.Key), w.prefix),
+		Action:      action,
+		Timestamp:   time.Now(),
+	}
+
+	eventJSON, err := json.Marshal(event)
+	if err != nil {
+		return models.MessageEnvelope{}, fmt.Errorf("failed to marshal config event: %w", err)
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(eventJSON, &payload); err != nil {
+		return models.MessageEnvelope{}, fmt.Errorf("failed to unmarshal config event: %w", err)
+	}
+
+	envelope := models.MessageEnvelope{
+		ID:        uuid.New().String(),
+		Source:    "configsource-etcd",
+		Timestamp: time.Now(),
+		Payload:   payload,
+		Metadata: models.Metadata{
+			Enrichment: map[string]interface{}{
+				"event_type":   event.EventType,
+				"service_type": event.ServiceType,
+			},
+		},
+	}
+
+	return envelope, nil
+}
+
+func (w *EtcdWatcher) Close() error {
+	return w.client.Close()
+}
@@ -0,0 +1,23 @@
+// Package configsource abstracts how a service learns about configuration
+// changes (filtering/enrichment rule updates, dedup config changes) behind
+// a single Watcher interface, so the config_handler.Handler pipeline that
+// already consumes Kafka config-update events can just as well be fed by a
+// non-Kafka source such as etcd.
+package configsource
+
+import (
+	"context"
+
+	"yeti/pkg/models"
+)
+
+// Watcher streams config-update events as models.MessageEnvelope values,
+// shaped exactly like the envelopes published to a Kafka config-update
+// topic, so callers can pass them straight into
+// config_handler.Handler.HandleConfigUpdateEvent.
+type Watcher interface {
+	// Watch starts watching and returns a channel of envelopes. The channel
+	// is closed when ctx is canceled or the watcher fails unrecoverably.
+	Watch(ctx context.Context) (<-chan models.MessageEnvelope, error)
+	Close() error
+}
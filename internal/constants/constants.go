@@ -14,6 +14,11 @@ const (
 const (
 	CacheKeyPrefixDedup  = "dedup:"
 	CacheKeyPrefixEnrich = "enrich:"
+	// CacheKeyPrefixIdem namespaces deduplication.Repository.Remember's
+	// idempotency cache, distinct from CacheKeyPrefixDedup's own SETNX
+	// keyspace - a Remember key isn't a hashed message, it's caller-chosen
+	// (a request ID, a Kafka message key), so the two must not collide.
+	CacheKeyPrefixIdem = "idem:"
 )
 
 const (
@@ -29,6 +34,11 @@ const (
 	ShutdownTimeout = 5 * time.Second
 )
 
+// DefaultMigrationsDir is the repo-root-relative directory migration runners
+// (see pkg/migrations) read numbered SQL files from, with one subdirectory
+// per SQL driver ("postgres", "sqlite").
+const DefaultMigrationsDir = "migrations"
+
 const (
 	DefaultLimit       = 100
 	MaxLimit           = 1000
@@ -39,6 +49,13 @@ const (
 	DefaultTTLSeconds = 3600
 )
 
+const (
+	// DefaultSampleCorpusSize bounds how many recent sample events a
+	// dry-run rule evaluation replays against when the caller doesn't
+	// specify a corpus size.
+	DefaultSampleCorpusSize = 200
+)
+
 const (
 	HTTPStatusOKMin = 200
 	HTTPStatusOKMax = 300
@@ -63,11 +80,41 @@ const (
 	SourceTypePostgreSQL = "postgresql"
 	SourceTypeCache      = "cache"
 	SourceTypeRedis      = "redis"
+	// SourceTypeHTTP is like SourceTypeAPI but backed by provider.HTTPProvider
+	// instead of provider.APIProvider: request templating against
+	// {header:X}/{query:Y} placeholders, bearer/basic/mTLS auth, and
+	// ResponseJSONPath extraction. SourceTypeAPI is kept as-is for existing
+	// rules rather than migrated, since APIProvider's simpler contract is
+	// still a valid (if less capable) choice.
+	SourceTypeHTTP = "http"
+	// SourceTypeGRPC is a rule-driven gRPC source: SourceConfig.Address picks
+	// the plugin to dial per rule, unlike an ExternalProvider registration
+	// (see management.ExternalProvider) which dials once at startup under a
+	// fixed source type name.
+	SourceTypeGRPC = "grpc"
+	// SourceTypeKafkaLookup is a rule-driven source backed by
+	// provider.KafkaLookupProvider: SourceConfig.KafkaBrokers/KafkaTopic pick
+	// a compacted topic to consume in the background into an in-memory
+	// keyed table, so Fetch is an O(1) lookup instead of a live broker
+	// round trip. One background consumer is kept warm per distinct
+	// (brokers, topic) pair, mirroring SourceTypeGRPC's per-address dial.
+	SourceTypeKafkaLookup = "kafka_lookup"
+	// SourceTypeFile is a rule-driven source backed by
+	// provider.FileSourceProvider: SourceConfig.FilePath/FileFormat name a
+	// CSV or JSON file on disk that's decoded into an in-memory keyed table
+	// and reloaded whenever its mtime changes. Unrelated to the "file"
+	// EnrichmentRuleStorageConfig driver, which is where rule *definitions*
+	// (not enrichment data) can be stored.
+	SourceTypeFile = "file"
 )
 
 const (
-	ProviderNameMongoDB    = "mongodb"
-	ProviderNamePostgreSQL = "postgresql"
-	ProviderNameCache      = "cache"
-	ProviderNameAPI        = "api"
+	ProviderNameMongoDB     = "mongodb"
+	ProviderNamePostgreSQL  = "postgresql"
+	ProviderNameCache       = "cache"
+	ProviderNameAPI         = "api"
+	ProviderNameHTTP        = "http"
+	ProviderNameGRPC        = "grpc"
+	ProviderNameKafkaLookup = "kafka_lookup"
+	ProviderNameFile        = "file"
 )
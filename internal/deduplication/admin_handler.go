@@ -0,0 +1,157 @@
+package deduplication
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	apperrors "yeti/pkg/errors"
+	"yeti/pkg/logging"
+)
+
+// StatsHandler serves GET /admin/dedup/stats - Service.Stats' snapshot of
+// cache size, L1 hit rate, the active field list, TTL, and breaker state.
+func StatsHandler(svc *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		stats, err := svc.Stats(r.Context())
+		if err != nil {
+			apperrors.WriteHTTPError(w, apperrors.ErrInternal.WithCause(err), logging.GetTraceID(r.Context()))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(stats)
+	}
+}
+
+type lookupResponse struct {
+	Hash   string `json:"hash"`
+	Exists bool   `json:"exists"`
+	TTLSec int64  `json:"ttl_seconds,omitempty"`
+}
+
+// LookupHandler serves GET /admin/dedup/lookup?hash=… (the hash itself) or
+// ?id=…&source=… (hashed on the operator's behalf via Service.HashForLookup
+// - see its doc comment for when that won't match the original message's
+// hash).
+func LookupHandler(svc *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		hash := r.URL.Query().Get("hash")
+		if hash == "" {
+			id := r.URL.Query().Get("id")
+			source := r.URL.Query().Get("source")
+			if id == "" || source == "" {
+				apperrors.WriteHTTPError(w, apperrors.ErrValidation.WithDetail("message", "must provide either hash, or both id and source"), logging.GetTraceID(r.Context()))
+				return
+			}
+			computed, err := svc.HashForLookup(id, source)
+			if err != nil {
+				apperrors.WriteHTTPError(w, apperrors.ErrInternal.WithCause(err), logging.GetTraceID(r.Context()))
+				return
+			}
+			hash = computed
+		}
+
+		exists, ttl, err := svc.LookupEntry(r.Context(), hash)
+		if err != nil {
+			apperrors.WriteHTTPError(w, apperrors.ErrInternal.WithCause(err), logging.GetTraceID(r.Context()))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(lookupResponse{Hash: hash, Exists: exists, TTLSec: int64(ttl.Seconds())})
+	}
+}
+
+// EntryHandler serves DELETE /admin/dedup/entry?hash=…, dropping hash so a
+// message that was accidentally suppressed can be reprocessed. See
+// Service.DeleteEntry's doc comment for the Bloom/Cuckoo fast-path caveat.
+func EntryHandler(svc *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		hash := r.URL.Query().Get("hash")
+		if hash == "" {
+			apperrors.WriteHTTPError(w, apperrors.ErrValidation.WithDetail("message", "hash is required"), logging.GetTraceID(r.Context()))
+			return
+		}
+
+		existed, err := svc.DeleteEntry(r.Context(), hash)
+		if err != nil {
+			apperrors.WriteHTTPError(w, apperrors.ErrInternal.WithCause(err), logging.GetTraceID(r.Context()))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]bool{"existed": existed})
+	}
+}
+
+// PurgeHandler serves POST /admin/dedup/purge?prefix=…, batch-deleting
+// every cache entry under constants.CacheKeyPrefixDedup+prefix (an empty
+// prefix purges the whole dedup keyspace). See Service.PurgeEntries' doc
+// comment for the full-L1-purge side effect.
+func PurgeHandler(svc *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		prefix := r.URL.Query().Get("prefix")
+
+		deleted, err := svc.PurgeEntries(r.Context(), prefix)
+		if err != nil {
+			apperrors.WriteHTTPError(w, apperrors.ErrInternal.WithCause(err), logging.GetTraceID(r.Context()))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]int{"deleted": deleted})
+	}
+}
+
+// ExtendHandler serves POST /admin/dedup/extend?hash=…&ttl_seconds=…,
+// resetting hash's TTL via Service.ExtendEntryTTL.
+func ExtendHandler(svc *Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		hash := r.URL.Query().Get("hash")
+		if hash == "" {
+			apperrors.WriteHTTPError(w, apperrors.ErrValidation.WithDetail("message", "hash is required"), logging.GetTraceID(r.Context()))
+			return
+		}
+
+		ttlSeconds, err := strconv.Atoi(r.URL.Query().Get("ttl_seconds"))
+		if err != nil || ttlSeconds <= 0 {
+			apperrors.WriteHTTPError(w, apperrors.ErrValidation.WithDetail("message", "ttl_seconds must be a positive integer"), logging.GetTraceID(r.Context()))
+			return
+		}
+
+		existed, err := svc.ExtendEntryTTL(r.Context(), hash, ttlSeconds)
+		if err != nil {
+			apperrors.WriteHTTPError(w, apperrors.ErrInternal.WithCause(err), logging.GetTraceID(r.Context()))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]bool{"existed": existed})
+	}
+}
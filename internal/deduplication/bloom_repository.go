@@ -0,0 +1,271 @@
+package deduplication
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"yeti/pkg/bloom"
+	"yeti/pkg/metrics"
+)
+
+// writeBehindTimeout bounds BloomRepository's asynchronous catch-up write to
+// inner after a Bloom-confirmed-unique key skips the synchronous round trip.
+const writeBehindTimeout = 5 * time.Second
+
+// BloomParams configures BloomRepository's Bloom-filter fast path. Zero
+// values fall back to the defaults NewBloomRepository documents.
+type BloomParams struct {
+	// ExpectedItems and FalsePositiveRate size the underlying filter exactly
+	// as bloom.New does.
+	ExpectedItems     uint64
+	FalsePositiveRate float64
+	// RotationInterval is the local filter's rotation period; see
+	// bloom.RollingParams.RotationInterval. Ignored when Distributed.
+	RotationInterval time.Duration
+
+	// Distributed backs the filter with a Redis-shared Bloom filter bit
+	// array (SETBIT/GETBIT on RedisKey, via Client) instead of a
+	// per-process one, so every replica sharing Client sees the same
+	// filter state — at the cost of a Redis round trip per Test/Add that
+	// the local mode avoids. Client is required when Distributed is set.
+	Distributed bool
+	Client      *redis.Client
+	RedisKey    string
+}
+
+func (p BloomParams) withDefaults() BloomParams {
+	if p.ExpectedItems == 0 {
+		p.ExpectedItems = 1_000_000
+	}
+	if p.FalsePositiveRate <= 0 {
+		p.FalsePositiveRate = 0.01
+	}
+	if p.RotationInterval <= 0 {
+		p.RotationInterval = time.Hour
+	}
+	if p.RedisKey == "" {
+		p.RedisKey = "dedup:bloom"
+	}
+	return p
+}
+
+// BloomRepository decorates a Repository with a Bloom-filter fast path in
+// front of SetNX's Redis round trip. A Bloom filter never false-negatives,
+// so when it reports a key definitely absent, the key is guaranteed unique
+// and BloomRepository returns without ever calling inner's synchronous
+// SetNX — only a "possibly present" result (which might be a false
+// positive) falls through to inner to get an authoritative answer. The
+// filter's own false-negative window is itself bounded by rotating every
+// RotationInterval (see bloom.RollingFilter), which matches Redis's own
+// ttl-based expiry: a key older than roughly 2*RotationInterval is allowed
+// to look "new" again exactly as it would once its Redis key expired.
+//
+// Skipping inner on the fast path means Redis never durably records that
+// key on the request's critical path; BloomRepository instead writes it
+// behind asynchronously (see writeBehind) so Redis — and whatever inner
+// maintains off SetNX, like RedisRepository's cardinality HLL — catches up
+// shortly after, without the caller waiting on it.
+//
+// A local (non-Distributed) filter only reflects what this one process has
+// seen. Run with Distributed so every replica shares the same filter state
+// via Redis, at the cost of the round trip the local mode is built to
+// avoid.
+type BloomRepository struct {
+	inner Repository
+
+	local  *bloom.RollingFilter
+	client *redis.Client
+	m, k   uint64
+	key    string
+
+	// keys serializes SetNX's test-then-add sequence per key; see keyLock.
+	keys keyLock
+
+	// fallthroughs/falsePositives back metrics.RecordDedupFastPathFallthrough's
+	// observed false-positive rate; see recordFallthrough.
+	fallthroughs   uint64
+	falsePositives uint64
+}
+
+// NewBloomRepository wraps inner with a Bloom-filter fast path per params.
+// params.Client is only used (and may be left nil) when params.Distributed.
+func NewBloomRepository(inner Repository, params BloomParams) *BloomRepository {
+	params = params.withDefaults()
+
+	r := &BloomRepository{inner: inner}
+	if params.Distributed && params.Client != nil {
+		r.client = params.Client
+		r.key = params.RedisKey
+		r.m, r.k = bloom.Size(params.ExpectedItems, params.FalsePositiveRate)
+		return r
+	}
+
+	r.local = bloom.NewRolling(bloom.RollingParams{
+		ExpectedItems:     params.ExpectedItems,
+		FalsePositiveRate: params.FalsePositiveRate,
+		RotationInterval:  params.RotationInterval,
+	})
+	return r
+}
+
+// SetNX holds keys' stripe for its whole test-then-add sequence: without
+// that, two concurrent calls for the same key (the normal "duplicate
+// message redelivered/retried concurrently" case this package exists to
+// catch) could both observe the filter reporting "absent" before either
+// had recorded the key, and both would then take the fast path and report
+// the key unique - silently defeating deduplication for exactly the
+// concurrency case it's supposed to guarantee against. Holding the stripe
+// across the Redis round trips in distributed mode only serializes callers
+// within this process; two replicas racing the same key still go through
+// inner's own authoritative SetNX on the slow path, since the filter alone
+// can't vouch for a key it hasn't already recorded.
+func (r *BloomRepository) SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	unlock := r.keys.lock(key)
+	possiblySeen, err := r.test(ctx, key)
+	if err != nil {
+		unlock()
+		// The filter itself errored (only possible in distributed mode, on
+		// a failed Redis round trip): fall through to inner, which is
+		// authoritative regardless of what the filter says.
+		return r.inner.SetNX(ctx, key, value, ttl)
+	}
+
+	if !possiblySeen {
+		r.add(ctx, key)
+		unlock()
+		r.writeBehind(key, value, ttl)
+		metrics.IncDedupFastPathSkipped("bloom")
+		return true, nil
+	}
+	unlock()
+
+	unique, err := r.inner.SetNX(ctx, key, value, ttl)
+	if err == nil {
+		r.recordFallthrough(unique)
+	}
+	return unique, err
+}
+
+// recordFallthrough tallies one fast-path fallthrough - the filter said
+// "possibly present" - toward metrics.RecordDedupFastPathFallthrough.
+// wasUnique true means Redis went on to confirm the message as unique after
+// all: the filter's "possibly present" verdict was an observed false
+// positive.
+func (r *BloomRepository) recordFallthrough(wasUnique bool) {
+	fallthroughs := atomic.AddUint64(&r.fallthroughs, 1)
+	var falsePositives uint64
+	if wasUnique {
+		falsePositives = atomic.AddUint64(&r.falsePositives, 1)
+	} else {
+		falsePositives = atomic.LoadUint64(&r.falsePositives)
+	}
+	metrics.RecordDedupFastPathFallthrough("bloom", wasUnique, float64(falsePositives)/float64(fallthroughs))
+}
+
+func (r *BloomRepository) GetCacheSize(ctx context.Context, prefix string) (int, error) {
+	return r.inner.GetCacheSize(ctx, prefix)
+}
+
+// Lookup, Delete, ExtendTTL, and Purge all forward straight to inner - they
+// operate on Redis, the source of truth for SetNX's own decision, not on
+// local. A Bloom filter can't un-add an item, so a key Delete removes from
+// Redis can still come back false on the very next Process call until
+// local rotates it out on its own RotationInterval.
+func (r *BloomRepository) Lookup(ctx context.Context, key string) (bool, time.Duration, error) {
+	return r.inner.Lookup(ctx, key)
+}
+
+func (r *BloomRepository) Delete(ctx context.Context, key string) (bool, error) {
+	return r.inner.Delete(ctx, key)
+}
+
+func (r *BloomRepository) ExtendTTL(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return r.inner.ExtendTTL(ctx, key, ttl)
+}
+
+func (r *BloomRepository) Purge(ctx context.Context, prefix string) (int, error) {
+	return r.inner.Purge(ctx, prefix)
+}
+
+// Remember forwards straight to inner - an idempotency key is caller-chosen,
+// not a hashed message the Bloom filter was ever asked about, so there's no
+// fast path for it to take part in.
+func (r *BloomRepository) Remember(ctx context.Context, key string, ttl, waitDeadline time.Duration, fn func() (IdempotentResult, error)) (IdempotentResult, bool, error) {
+	return r.inner.Remember(ctx, key, ttl, waitDeadline, fn)
+}
+
+// SetNXBatch forwards straight to inner, bypassing the Bloom fast path
+// entirely: Service.ProcessBatch's whole point is trading N round trips
+// for one pipelined flush, and looping Test/Add per entry here would just
+// reintroduce the per-item overhead batching is meant to remove.
+func (r *BloomRepository) SetNXBatch(ctx context.Context, entries []SetNXEntry) ([]bool, error) {
+	return r.inner.SetNXBatch(ctx, entries)
+}
+
+// State forwards to inner's breakerStateReporter, if it has one, so
+// Service.BreakerState still sees CircuitBreakerRepository's state through
+// this fast path's decorator layer rather than reporting "disabled".
+func (r *BloomRepository) State() string {
+	if reporter, ok := r.inner.(breakerStateReporter); ok {
+		return reporter.State()
+	}
+	return "disabled"
+}
+
+func (r *BloomRepository) test(ctx context.Context, key string) (bool, error) {
+	if r.client == nil {
+		return r.local.Test(key), nil
+	}
+
+	positions := bloom.Positions([]byte(key), r.m, r.k)
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.IntCmd, len(positions))
+	for i, pos := range positions {
+		cmds[i] = pipe.GetBit(ctx, r.key, int64(pos))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, fmt.Errorf("redis bloom GETBIT failed: %w", err)
+	}
+
+	for _, cmd := range cmds {
+		if cmd.Val() == 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (r *BloomRepository) add(ctx context.Context, key string) {
+	if r.client == nil {
+		r.local.Add(key)
+		return
+	}
+
+	positions := bloom.Positions([]byte(key), r.m, r.k)
+	pipe := r.client.Pipeline()
+	for _, pos := range positions {
+		pipe.SetBit(ctx, r.key, int64(pos), 1)
+	}
+	// Best-effort: a failed SETBIT only costs a later false negative for
+	// this one key, not SetNX's own correctness.
+	_, _ = pipe.Exec(ctx)
+}
+
+// writeBehind persists a Bloom-confirmed-unique key to inner asynchronously,
+// off the path SetNX already returned on, so Redis (and whatever it
+// maintains off SetNX, e.g. RedisRepository's cardinality HLL) stays
+// eventually consistent with what the filter already knows for certain. It
+// uses a detached context with its own timeout rather than ctx, since the
+// caller — and ctx along with it — is typically long gone before this
+// completes.
+func (r *BloomRepository) writeBehind(key string, value interface{}, ttl time.Duration) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), writeBehindTimeout)
+		defer cancel()
+		_, _ = r.inner.SetNX(ctx, key, value, ttl)
+	}()
+}
@@ -0,0 +1,85 @@
+package deduplication
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// latencyRepository stands in for a real Redis dependency by sleeping
+// redisRTT on every SetNX, so a benchmark's wall-clock time reflects how
+// many synchronous round trips it actually made rather than measuring
+// nothing but loop overhead against an in-memory fake.
+type latencyRepository struct {
+	redisRTT time.Duration
+}
+
+func (l *latencyRepository) SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	time.Sleep(l.redisRTT)
+	return true, nil
+}
+
+func (l *latencyRepository) GetCacheSize(ctx context.Context, prefix string) (int, error) {
+	return 0, nil
+}
+
+func (l *latencyRepository) Lookup(ctx context.Context, key string) (bool, time.Duration, error) {
+	return false, 0, nil
+}
+
+func (l *latencyRepository) Delete(ctx context.Context, key string) (bool, error) {
+	return false, nil
+}
+
+func (l *latencyRepository) ExtendTTL(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return false, nil
+}
+
+func (l *latencyRepository) Purge(ctx context.Context, prefix string) (int, error) {
+	return 0, nil
+}
+
+func (l *latencyRepository) SetNXBatch(ctx context.Context, entries []SetNXEntry) ([]bool, error) {
+	results := make([]bool, len(entries))
+	for i := range entries {
+		time.Sleep(l.redisRTT)
+		results[i] = true
+	}
+	return results, nil
+}
+
+func (l *latencyRepository) Remember(ctx context.Context, key string, ttl, waitDeadline time.Duration, fn func() (IdempotentResult, error)) (IdempotentResult, bool, error) {
+	time.Sleep(l.redisRTT)
+	result, err := fn()
+	return result, false, err
+}
+
+// benchmarkSetNX drives uniqueKeys worth of SetNX calls through repo per
+// b.N, repeating the same key 10% of the time to model realistic duplicate
+// traffic.
+func benchmarkSetNX(b *testing.B, repo Repository) {
+	const uniqueKeys = 200
+	ctx := context.Background()
+
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < uniqueKeys; j++ {
+			key := fmt.Sprintf("key-%d", j)
+			if j%10 == 0 {
+				key = "key-0" // repeat an already-seen key
+			}
+			_, _ = repo.SetNX(ctx, key, "v", time.Minute)
+		}
+	}
+}
+
+func BenchmarkSetNXWithoutBloom(b *testing.B) {
+	repo := &latencyRepository{redisRTT: 200 * time.Microsecond}
+	benchmarkSetNX(b, repo)
+}
+
+func BenchmarkSetNXWithLocalBloom(b *testing.B) {
+	inner := &latencyRepository{redisRTT: 200 * time.Microsecond}
+	repo := NewBloomRepository(inner, BloomParams{ExpectedItems: 10000, FalsePositiveRate: 0.01})
+	benchmarkSetNX(b, repo)
+}
@@ -0,0 +1,91 @@
+package deduplication
+
+import (
+	"fmt"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// DedupCache is the L1 in-process fast path Service.Process consults
+// before every Backend's Redis SetNX round trip (see Service.checkDuplicate).
+// It exists as an interface, rather than a concrete type on Service, so an
+// operator who outgrows the default LRU - e.g. wanting Ristretto's
+// admission policy or freecache's off-heap storage - can plug in an
+// alternative without touching Service itself.
+type DedupCache interface {
+	// Get reports whether key is present and unexpired - true means some
+	// earlier call already marked key as seen, so Process can report a
+	// duplicate without a Redis round trip.
+	Get(key string) (seen bool)
+
+	// Set marks key as seen for ttl, called once Redis itself has
+	// confirmed key as unique (see Service.checkDuplicate) so a
+	// subsequent L1 hit never outlives the thing it is shadowing.
+	Set(key string, ttl time.Duration)
+
+	// Remove drops key, used to apply an operator-triggered purge or an
+	// incoming dedup:invalidate Pub/Sub message (see
+	// cacheInvalidationBroadcaster).
+	Remove(key string)
+
+	// Purge drops every entry, used when the fields a hash is computed
+	// over change (Service.UpdateFieldsToHash) - every existing entry
+	// describes a hash under fields that no longer apply.
+	Purge()
+
+	// Len reports the number of entries currently cached, for
+	// metrics.SetDedupL1CacheSize.
+	Len() int
+}
+
+// lruCacheEntry is the value LRUDedupCache stores per key. expiresAt is
+// checked on read since golang-lru/v2's plain Cache has no native
+// per-entry TTL - the same scheme enrichment.l1Entry uses.
+type lruCacheEntry struct {
+	expiresAt time.Time
+}
+
+// LRUDedupCache is the default DedupCache: a size-bounded, in-process LRU
+// with a per-entry TTL. It is safe for concurrent use - golang-lru/v2's
+// Cache already serializes its own operations internally.
+type LRUDedupCache struct {
+	cache *lru.Cache[string, lruCacheEntry]
+}
+
+// NewLRUDedupCache builds an LRUDedupCache holding at most size entries.
+func NewLRUDedupCache(size int) (*LRUDedupCache, error) {
+	cache, err := lru.New[string, lruCacheEntry](size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dedup L1 cache: %w", err)
+	}
+	return &LRUDedupCache{cache: cache}, nil
+}
+
+func (c *LRUDedupCache) Get(key string) bool {
+	entry, ok := c.cache.Get(key)
+	if !ok {
+		return false
+	}
+	if time.Now().After(entry.expiresAt) {
+		c.cache.Remove(key)
+		return false
+	}
+	return true
+}
+
+func (c *LRUDedupCache) Set(key string, ttl time.Duration) {
+	c.cache.Add(key, lruCacheEntry{expiresAt: time.Now().Add(ttl)})
+}
+
+func (c *LRUDedupCache) Remove(key string) {
+	c.cache.Remove(key)
+}
+
+func (c *LRUDedupCache) Purge() {
+	c.cache.Purge()
+}
+
+func (c *LRUDedupCache) Len() int {
+	return c.cache.Len()
+}
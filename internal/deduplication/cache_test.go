@@ -0,0 +1,81 @@
+package deduplication
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRUDedupCache_MissThenHit(t *testing.T) {
+	c, err := NewLRUDedupCache(10)
+	if err != nil {
+		t.Fatalf("NewLRUDedupCache returned error: %v", err)
+	}
+
+	if c.Get("dedup:abc") {
+		t.Fatalf("expected miss on an empty cache")
+	}
+
+	c.Set("dedup:abc", time.Minute)
+
+	if !c.Get("dedup:abc") {
+		t.Fatalf("expected hit after Set")
+	}
+}
+
+func TestLRUDedupCache_ExpiresEntry(t *testing.T) {
+	c, err := NewLRUDedupCache(10)
+	if err != nil {
+		t.Fatalf("NewLRUDedupCache returned error: %v", err)
+	}
+
+	c.Set("dedup:abc", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+
+	if c.Get("dedup:abc") {
+		t.Fatalf("expected expired entry to report a miss")
+	}
+	if c.Len() != 0 {
+		t.Fatalf("expected expired entry to be evicted on read, got len %d", c.Len())
+	}
+}
+
+func TestLRUDedupCache_RemoveAndPurge(t *testing.T) {
+	c, err := NewLRUDedupCache(10)
+	if err != nil {
+		t.Fatalf("NewLRUDedupCache returned error: %v", err)
+	}
+
+	c.Set("dedup:a", time.Minute)
+	c.Set("dedup:b", time.Minute)
+
+	c.Remove("dedup:a")
+	if c.Get("dedup:a") {
+		t.Fatalf("expected dedup:a to be gone after Remove")
+	}
+	if !c.Get("dedup:b") {
+		t.Fatalf("expected dedup:b to still be present")
+	}
+
+	c.Purge()
+	if c.Len() != 0 {
+		t.Fatalf("expected Purge to empty the cache, got len %d", c.Len())
+	}
+}
+
+func TestLRUDedupCache_EvictsOldestBeyondSize(t *testing.T) {
+	c, err := NewLRUDedupCache(2)
+	if err != nil {
+		t.Fatalf("NewLRUDedupCache returned error: %v", err)
+	}
+
+	c.Set("dedup:a", time.Minute)
+	c.Set("dedup:b", time.Minute)
+	c.Set("dedup:c", time.Minute)
+
+	if c.Len() != 2 {
+		t.Fatalf("expected size-bounded cache to hold at most 2 entries, got %d", c.Len())
+	}
+	if c.Get("dedup:a") {
+		t.Fatalf("expected dedup:a to have been evicted as the least recently used entry")
+	}
+}
@@ -6,8 +6,13 @@ import (
 	"time"
 
 	"github.com/sony/gobreaker"
+	"go.opentelemetry.io/otel/attribute"
+
 	"yeti/internal/config"
+	"yeti/internal/logger"
 	"yeti/pkg/circuitbreaker"
+	"yeti/pkg/metrics"
+	"yeti/pkg/tracing"
 )
 
 type CircuitBreakerRepository struct {
@@ -15,7 +20,7 @@ type CircuitBreakerRepository struct {
 	cb   *circuitbreaker.Wrapper
 }
 
-func NewCircuitBreakerRepository(repo Repository, cfg config.CircuitBreakerConfig) *CircuitBreakerRepository {
+func NewCircuitBreakerRepository(repo Repository, cfg config.CircuitBreakerConfig, log logger.Logger) *CircuitBreakerRepository {
 	if !cfg.Enabled {
 		return &CircuitBreakerRepository{
 			repo: repo,
@@ -42,6 +47,21 @@ func NewCircuitBreakerRepository(repo Repository, cfg config.CircuitBreakerConfi
 			return failureRatio >= cfg.FailureRatio
 		}
 	}
+	cbConfig.OnStateChange = func(name string, from, to gobreaker.State) {
+		if log != nil {
+			log.WarnwCtx(context.Background(), "deduplication circuit breaker state changed",
+				"name", name, "from", from.String(), "to", to.String())
+		}
+		metrics.SetDedupBreakerState(to.String())
+
+		_, span := tracing.GetTracer("dedup-service").Start(context.Background(), "deduplication.breaker_state_change")
+		span.SetAttributes(
+			attribute.String("deduplication.breaker_name", name),
+			attribute.String("deduplication.breaker_from", from.String()),
+			attribute.String("deduplication.breaker_to", to.String()),
+		)
+		span.End()
+	}
 
 	return &CircuitBreakerRepository{
 		repo: repo,
@@ -54,12 +74,9 @@ func (r *CircuitBreakerRepository) SetNX(ctx context.Context, key string, value
 		return r.repo.SetNX(ctx, key, value, ttl)
 	}
 
-	result, err := r.cb.ExecuteWithContext(ctx, func() (interface{}, error) {
+	success, err := circuitbreaker.Do(ctx, r.cb, func() (bool, error) {
 		return r.repo.SetNX(ctx, key, value, ttl)
 	})
-
-	r.cb.RecordRequest(err == nil)
-
 	if err != nil {
 		if r.cb.IsOpen() {
 			return false, fmt.Errorf("circuit breaker is open for redis-dedup: %w", err)
@@ -67,11 +84,6 @@ func (r *CircuitBreakerRepository) SetNX(ctx context.Context, key string, value
 		return false, err
 	}
 
-	success, ok := result.(bool)
-	if !ok {
-		return false, fmt.Errorf("repository returned invalid result type")
-	}
-
 	return success, nil
 }
 
@@ -94,12 +106,112 @@ func (r *CircuitBreakerRepository) GetCacheSize(ctx context.Context, prefix stri
 		return r.repo.GetCacheSize(ctx, prefix)
 	}
 
-	result, err := r.cb.ExecuteWithContext(ctx, func() (interface{}, error) {
+	size, err := circuitbreaker.Do(ctx, r.cb, func() (int, error) {
 		return r.repo.GetCacheSize(ctx, prefix)
 	})
+	if err != nil {
+		if r.cb.IsOpen() {
+			return 0, fmt.Errorf("circuit breaker is open for redis-dedup: %w", err)
+		}
+		return 0, err
+	}
+
+	return size, nil
+}
+
+// lookupResult is Lookup's circuitbreaker.Do payload - Do is generic over a
+// single return type, so exists/ttl travel together rather than as two
+// separate breaker-gated calls.
+type lookupResult struct {
+	exists bool
+	ttl    time.Duration
+}
+
+func (r *CircuitBreakerRepository) Lookup(ctx context.Context, key string) (bool, time.Duration, error) {
+	if r.cb == nil {
+		return r.repo.Lookup(ctx, key)
+	}
+
+	res, err := circuitbreaker.Do(ctx, r.cb, func() (lookupResult, error) {
+		exists, ttl, err := r.repo.Lookup(ctx, key)
+		return lookupResult{exists: exists, ttl: ttl}, err
+	})
+	if err != nil {
+		if r.cb.IsOpen() {
+			return false, 0, fmt.Errorf("circuit breaker is open for redis-dedup: %w", err)
+		}
+		return false, 0, err
+	}
+
+	return res.exists, res.ttl, nil
+}
+
+func (r *CircuitBreakerRepository) Delete(ctx context.Context, key string) (bool, error) {
+	if r.cb == nil {
+		return r.repo.Delete(ctx, key)
+	}
+
+	existed, err := circuitbreaker.Do(ctx, r.cb, func() (bool, error) {
+		return r.repo.Delete(ctx, key)
+	})
+	if err != nil {
+		if r.cb.IsOpen() {
+			return false, fmt.Errorf("circuit breaker is open for redis-dedup: %w", err)
+		}
+		return false, err
+	}
+
+	return existed, nil
+}
+
+func (r *CircuitBreakerRepository) ExtendTTL(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	if r.cb == nil {
+		return r.repo.ExtendTTL(ctx, key, ttl)
+	}
+
+	existed, err := circuitbreaker.Do(ctx, r.cb, func() (bool, error) {
+		return r.repo.ExtendTTL(ctx, key, ttl)
+	})
+	if err != nil {
+		if r.cb.IsOpen() {
+			return false, fmt.Errorf("circuit breaker is open for redis-dedup: %w", err)
+		}
+		return false, err
+	}
+
+	return existed, nil
+}
+
+// SetNXBatch wraps the whole pipelined flush in a single circuitbreaker.Do
+// call - one trip/success verdict for the batch as a whole, not one per
+// entry, since entries all share the one Redis round trip SetNXBatch
+// issues.
+func (r *CircuitBreakerRepository) SetNXBatch(ctx context.Context, entries []SetNXEntry) ([]bool, error) {
+	if r.cb == nil {
+		return r.repo.SetNXBatch(ctx, entries)
+	}
+
+	results, err := circuitbreaker.Do(ctx, r.cb, func() ([]bool, error) {
+		return r.repo.SetNXBatch(ctx, entries)
+	})
+	if err != nil {
+		if r.cb.IsOpen() {
+			return nil, fmt.Errorf("circuit breaker is open for redis-dedup: %w", err)
+		}
+		return nil, err
+	}
+
+	return results, nil
+}
 
-	r.cb.RecordRequest(err == nil)
+func (r *CircuitBreakerRepository) Purge(ctx context.Context, prefix string) (int, error) {
+	if r.cb == nil {
+		return r.repo.Purge(ctx, prefix)
+	}
 
+	deleted, err := circuitbreaker.Do(ctx, r.cb, func() (int, error) {
+		return r.repo.Purge(ctx, prefix)
+	})
 	if err != nil {
 		if r.cb.IsOpen() {
 			return 0, fmt.Errorf("circuit breaker is open for redis-dedup: %w", err)
@@ -107,10 +219,15 @@ func (r *CircuitBreakerRepository) GetCacheSize(ctx context.Context, prefix stri
 		return 0, err
 	}
 
-	size, ok := result.(int)
-	if !ok {
-		return 0, fmt.Errorf("repository returned invalid result type")
-	}
+	return deleted, nil
+}
 
-	return size, nil
+// Remember forwards straight to repo, bypassing the breaker: fn is arbitrary
+// caller code (an HTTP handler, a Kafka consumer's side effect), not a
+// bounded Redis operation, so folding its duration and errors into the
+// redis-dedup breaker's trip decision would conflate "fn failed" with
+// "Redis is unhealthy". Remember's own Redis calls still surface as
+// ordinary errors to the caller either way.
+func (r *CircuitBreakerRepository) Remember(ctx context.Context, key string, ttl, waitDeadline time.Duration, fn func() (IdempotentResult, error)) (IdempotentResult, bool, error) {
+	return r.repo.Remember(ctx, key, ttl, waitDeadline, fn)
 }
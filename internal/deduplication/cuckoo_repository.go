@@ -0,0 +1,455 @@
+package deduplication
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"yeti/pkg/cuckoo"
+	"yeti/pkg/metrics"
+)
+
+// insertScript is CuckooRepository's distributed-mode atomic
+// lookup-then-insert: given two bucket tables, each packed into a single
+// Redis string (cuckoo.BucketSize() bytes per bucket, one byte per
+// fingerprint slot, 0 meaning empty - see cuckoo.Size/cuckoo.Locate), it
+// checks fp against both of its candidate buckets in both the active and
+// previous generation first (a hit means the key is already known, no
+// insert needed) and otherwise inserts fp into the active table, evicting
+// and relocating an existing fingerprint up to cuckoo.MaxKicks() times
+// exactly as cuckoo.Filter.Insert does in-process. Run as a single EVAL so
+// two concurrent Process calls racing on the same bucket can't both
+// observe a free slot and overwrite each other's insert.
+//
+// The alternate-index hash used by the eviction loop (altIndex, below) is
+// its own self-contained multiplicative hash rather than a port of
+// cuckoo.Filter's FNV-based one: a kick has to compute the next bucket
+// entirely inside this script to keep the whole operation atomic, so it
+// doesn't need to match the in-process Filter's hash, only to be
+// consistent with itself across calls.
+//
+// Return codes: 0 = fp already present (duplicate), 1 = inserted (new),
+// -1 = both candidate buckets full and every relocation attempt failed
+// (filter saturated).
+const insertScript = `
+local activeKey = KEYS[1]
+local prevKey = KEYS[2]
+local m = tonumber(ARGV[1])
+local b = tonumber(ARGV[2])
+local i1 = tonumber(ARGV[3])
+local fp = tonumber(ARGV[4])
+local maxKicks = tonumber(ARGV[5])
+
+local function altIndex(i, f)
+  local h = (f * 2654435761) % m
+  return bit.bxor(i, h) % m
+end
+
+local function readByte(key, offset)
+  local chunk = redis.call('GETRANGE', key, offset, offset)
+  if chunk == '' then return 0 end
+  return string.byte(chunk)
+end
+
+local function writeByte(key, offset, v)
+  redis.call('SETRANGE', key, offset, string.char(v))
+end
+
+local function bucketHas(key, i, f)
+  local start = i * b
+  for s = 0, b - 1 do
+    if readByte(key, start + s) == f then return true end
+  end
+  return false
+end
+
+local function insertAt(key, i, f)
+  local start = i * b
+  for s = 0, b - 1 do
+    if readByte(key, start + s) == 0 then
+      writeByte(key, start + s, f)
+      return true
+    end
+  end
+  return false
+end
+
+local i2 = altIndex(i1, fp)
+
+if bucketHas(prevKey, i1, fp) or bucketHas(prevKey, i2, fp)
+    or bucketHas(activeKey, i1, fp) or bucketHas(activeKey, i2, fp) then
+  return 0
+end
+
+if insertAt(activeKey, i1, fp) or insertAt(activeKey, i2, fp) then
+  return 1
+end
+
+local i = i1
+if math.random(0, 1) == 1 then i = i2 end
+for k = 1, maxKicks do
+  local start = i * b
+  local slot = math.random(0, b - 1)
+  local evicted = readByte(activeKey, start + slot)
+  writeByte(activeKey, start + slot, fp)
+  fp = evicted
+  i = altIndex(i, fp)
+  if insertAt(activeKey, i, fp) then return 1 end
+end
+
+return -1
+`
+
+// CuckooParams configures CuckooRepository's fixed-capacity fast path.
+// Zero values fall back to the defaults NewCuckooRepository documents.
+type CuckooParams struct {
+	// Capacity and FalsePositiveRate size the underlying filter(s)
+	// exactly as cuckoo.New does.
+	Capacity          uint64
+	FalsePositiveRate float64
+	// RotationWindow is the active generation's lifetime; see
+	// CuckooRepository's doc comment for the two-generation rotation
+	// scheme this bounds false positives over time with.
+	RotationWindow time.Duration
+
+	// Distributed backs the filter with two Redis-shared bucket tables
+	// (see insertScript) instead of per-process ones, so every replica
+	// sharing Client sees the same filter state - at the cost of a Redis
+	// EVAL per Test/Add the local mode avoids. Client is required when
+	// Distributed is set.
+	Distributed    bool
+	Client         *redis.Client
+	RedisKeyPrefix string
+}
+
+func (p CuckooParams) withDefaults() CuckooParams {
+	if p.Capacity == 0 {
+		p.Capacity = 1_000_000
+	}
+	if p.FalsePositiveRate <= 0 {
+		p.FalsePositiveRate = 0.01
+	}
+	if p.RotationWindow <= 0 {
+		p.RotationWindow = time.Hour
+	}
+	if p.RedisKeyPrefix == "" {
+		p.RedisKeyPrefix = "dedup:cuckoo"
+	}
+	return p
+}
+
+// CuckooStats reports a CuckooRepository's current saturation, for an
+// operator dashboard or a management API warning once the active
+// generation is close to full - see cuckoo.Filter.Insert's "filter full"
+// failure mode this is meant to give advance warning of. Exposing this
+// through the management service's HTTP API is left as follow-on work:
+// dedup-service and management-service are separate binaries today, with
+// no existing channel for one service's runtime stats to reach the
+// other's API.
+type CuckooStats struct {
+	LoadFactor             float64
+	EstimatedFalsePositive float64
+	ActiveSince            time.Time
+	// Capacity is the active generation's total fingerprint slot count
+	// (bucket count * cuckoo.BucketSize()), for metrics.SetDedupFilterCapacity -
+	// LoadFactor alone doesn't tell an operator how large the filter
+	// actually is, only how full it is.
+	Capacity uint64
+}
+
+// CuckooRepository decorates a Repository with a Cuckoo-filter fast path
+// in front of SetNX's Redis round trip, the same shape BloomRepository
+// wraps inner with (see its doc comment), but backed by a fixed-capacity
+// Cuckoo filter instead of a Bloom filter. Unlike a Bloom filter, a Cuckoo
+// filter's memory is bounded by Capacity regardless of how many distinct
+// keys are ever inserted: once it's full, Insert starts failing (and
+// CuckooRepository falls back to inner) instead of the false-positive
+// rate silently climbing, and Stats surfaces the load factor that
+// predicts it so an operator can see saturation coming.
+//
+// Two generations ("active", "previous") are kept, matching
+// bloom.RollingFilter's rotation scheme: a lookup checks both, an insert
+// only ever writes to active, and active is retired to previous and
+// replaced with a fresh, empty filter every RotationWindow - bounding how
+// long a key already seen keeps reporting "possibly present" to at most
+// 2*RotationWindow, the same way a Redis key's own ttl bounds it.
+//
+// Local (non-Distributed) mode keeps both generations in process memory
+// via pkg/cuckoo.RollingFilter. Distributed mode instead packs each
+// generation's bucket table into its own Redis string and performs
+// lookup-then-insert through one EVAL of insertScript per call, so
+// concurrent workers sharing Client can't race past each other's eviction
+// kicks the way two independent GETBIT/SETBIT pipelines could.
+type CuckooRepository struct {
+	inner Repository
+
+	local *cuckoo.RollingFilter
+
+	client    *redis.Client
+	keyPrefix string
+	m         uint64
+	rotation  time.Duration
+
+	mu          sync.Mutex
+	activeGen   int // 0 or 1: which physical Redis key is "active" right now
+	rotateAt    time.Time
+	activeSince time.Time
+
+	// keys serializes setNXLocal's test-then-add sequence per key; see
+	// keyLock. setNXDistributed doesn't need it: insertScript already does
+	// its lookup-then-insert as a single atomic EVAL.
+	keys keyLock
+
+	// fallthroughs/falsePositives back metrics.RecordDedupFastPathFallthrough's
+	// observed false-positive rate; see recordFallthrough. A "filter full"
+	// fallthrough (res == -1) doesn't count here - that's a capacity
+	// failure, not the filter being wrong about membership.
+	fallthroughs   uint64
+	falsePositives uint64
+}
+
+// NewCuckooRepository wraps inner with a Cuckoo-filter fast path per
+// params. params.Client is only used (and may be left nil) when
+// params.Distributed.
+func NewCuckooRepository(inner Repository, params CuckooParams) *CuckooRepository {
+	params = params.withDefaults()
+
+	r := &CuckooRepository{inner: inner}
+	if params.Distributed && params.Client != nil {
+		now := time.Now()
+		r.client = params.Client
+		r.keyPrefix = params.RedisKeyPrefix
+		r.m = cuckoo.Size(params.Capacity)
+		r.rotation = params.RotationWindow
+		r.rotateAt = now.Add(params.RotationWindow)
+		r.activeSince = now
+		return r
+	}
+
+	r.local = cuckoo.NewRolling(cuckoo.RollingParams{
+		Capacity:          params.Capacity,
+		FalsePositiveRate: params.FalsePositiveRate,
+		RotationInterval:  params.RotationWindow,
+	})
+	return r
+}
+
+func (r *CuckooRepository) SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	if r.client != nil {
+		return r.setNXDistributed(ctx, key, value, ttl)
+	}
+	return r.setNXLocal(ctx, key, value, ttl)
+}
+
+// setNXLocal holds key's stripe across Test and Add, the same race
+// BloomRepository.SetNX guards against: without it, two concurrent calls
+// for the same key could both see Test report "absent" before either had
+// Added it, and both would take the fast path and report the key unique.
+func (r *CuckooRepository) setNXLocal(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	unlock := r.keys.lock(key)
+
+	if r.local.Test(key) {
+		unlock()
+		unique, err := r.inner.SetNX(ctx, key, value, ttl)
+		if err == nil {
+			r.recordFallthrough(unique)
+		}
+		return unique, err
+	}
+
+	if !r.local.Add(key) {
+		unlock()
+		// Active generation reported full: the fast path can't vouch for
+		// this key, fall back to inner for an authoritative answer. Not a
+		// false-positive fallthrough - the filter never claimed the key was
+		// "possibly present", it just couldn't take on more entries.
+		return r.inner.SetNX(ctx, key, value, ttl)
+	}
+	unlock()
+
+	r.writeBehind(key, value, ttl)
+	metrics.IncDedupFastPathSkipped("cuckoo")
+	return true, nil
+}
+
+// recordFallthrough tallies one "possibly present" fast-path fallthrough
+// toward metrics.RecordDedupFastPathFallthrough. wasUnique true means Redis
+// went on to confirm the message as unique after all: the filter's verdict
+// was an observed false positive.
+func (r *CuckooRepository) recordFallthrough(wasUnique bool) {
+	fallthroughs := atomic.AddUint64(&r.fallthroughs, 1)
+	var falsePositives uint64
+	if wasUnique {
+		falsePositives = atomic.AddUint64(&r.falsePositives, 1)
+	} else {
+		falsePositives = atomic.LoadUint64(&r.falsePositives)
+	}
+	metrics.RecordDedupFastPathFallthrough("cuckoo", wasUnique, float64(falsePositives)/float64(fallthroughs))
+}
+
+func (r *CuckooRepository) setNXDistributed(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	activeKey, prevKey, err := r.bucketKeys(ctx)
+	if err != nil {
+		return r.inner.SetNX(ctx, key, value, ttl)
+	}
+
+	i1, fp := cuckoo.Locate([]byte(key), r.m)
+	res, err := r.client.Eval(ctx, insertScript, []string{activeKey, prevKey},
+		r.m, cuckoo.BucketSize(), i1, fp, cuckoo.MaxKicks()).Int()
+	if err != nil {
+		// The script itself errored: fall through to inner, which is
+		// authoritative regardless of what the filter says.
+		return r.inner.SetNX(ctx, key, value, ttl)
+	}
+
+	if res == 1 {
+		r.writeBehind(key, value, ttl)
+		metrics.IncDedupFastPathSkipped("cuckoo")
+		return true, nil
+	}
+
+	unique, err := r.inner.SetNX(ctx, key, value, ttl)
+	if err == nil && res == 0 {
+		// res == 0: the filter said "possibly present", so this fallthrough
+		// counts toward the observed false-positive rate. res == -1 (filter
+		// full) doesn't - that's a capacity failure, not a membership claim.
+		r.recordFallthrough(unique)
+	}
+	return unique, err
+}
+
+// bucketKeys returns the current active/previous Redis keys, rotating
+// first if RotationWindow has elapsed since the active generation began.
+func (r *CuckooRepository) bucketKeys(ctx context.Context) (active, previous string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Now().After(r.rotateAt) {
+		newActive := 1 - r.activeGen
+		// The generation about to become active held data from two
+		// rotations ago; clear it so it starts empty instead of carrying
+		// forward fingerprints that should have aged out.
+		if delErr := r.client.Del(ctx, r.genKey(newActive)).Err(); delErr != nil {
+			return "", "", fmt.Errorf("redis cuckoo rotate DEL failed: %w", delErr)
+		}
+		r.activeGen = newActive
+		now := time.Now()
+		r.rotateAt = now.Add(r.rotation)
+		r.activeSince = now
+	}
+
+	return r.genKey(r.activeGen), r.genKey(1 - r.activeGen), nil
+}
+
+func (r *CuckooRepository) genKey(gen int) string {
+	return fmt.Sprintf("%s:gen%d", r.keyPrefix, gen)
+}
+
+func (r *CuckooRepository) GetCacheSize(ctx context.Context, prefix string) (int, error) {
+	return r.inner.GetCacheSize(ctx, prefix)
+}
+
+// Lookup, Delete, ExtendTTL, and Purge all forward straight to inner - they
+// operate on Redis, the source of truth for SetNX's own decision, not on
+// local. A key Delete removes from Redis can still come back false on the
+// very next Process call if local still holds it (RollingFilter has no
+// generation-aware eviction), same caveat BloomRepository's forwarding
+// carries; it rotates out within 2*RotationWindow regardless.
+func (r *CuckooRepository) Lookup(ctx context.Context, key string) (bool, time.Duration, error) {
+	return r.inner.Lookup(ctx, key)
+}
+
+func (r *CuckooRepository) Delete(ctx context.Context, key string) (bool, error) {
+	return r.inner.Delete(ctx, key)
+}
+
+func (r *CuckooRepository) ExtendTTL(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return r.inner.ExtendTTL(ctx, key, ttl)
+}
+
+func (r *CuckooRepository) Purge(ctx context.Context, prefix string) (int, error) {
+	return r.inner.Purge(ctx, prefix)
+}
+
+// Remember forwards straight to inner, same rationale as BloomRepository's:
+// an idempotency key never goes through the Cuckoo fast path.
+func (r *CuckooRepository) Remember(ctx context.Context, key string, ttl, waitDeadline time.Duration, fn func() (IdempotentResult, error)) (IdempotentResult, bool, error) {
+	return r.inner.Remember(ctx, key, ttl, waitDeadline, fn)
+}
+
+// SetNXBatch forwards straight to inner, bypassing the Cuckoo fast path
+// entirely - same rationale as BloomRepository.SetNXBatch: batching's
+// throughput gain comes from the one pipelined round trip, not from
+// looping Test/Insert per entry ahead of it.
+func (r *CuckooRepository) SetNXBatch(ctx context.Context, entries []SetNXEntry) ([]bool, error) {
+	return r.inner.SetNXBatch(ctx, entries)
+}
+
+// State forwards to inner's breakerStateReporter, if it has one, so
+// Service.BreakerState still sees CircuitBreakerRepository's state through
+// this fast path's decorator layer rather than reporting "disabled".
+func (r *CuckooRepository) State() string {
+	if reporter, ok := r.inner.(breakerStateReporter); ok {
+		return reporter.State()
+	}
+	return "disabled"
+}
+
+// Stats reports the active generation's current saturation. In
+// distributed mode this reads the whole active bucket table back from
+// Redis to count occupied slots, so - unlike Test/Add's per-call cost -
+// it isn't cheap; call it on a dashboard-refresh cadence, not per message.
+func (r *CuckooRepository) Stats(ctx context.Context) (CuckooStats, error) {
+	if r.client == nil {
+		s := r.local.Stats()
+		return CuckooStats{
+			LoadFactor:             s.LoadFactor,
+			EstimatedFalsePositive: s.EstimatedFalsePositive,
+			ActiveSince:            s.ActiveSince,
+			Capacity:               s.Capacity,
+		}, nil
+	}
+
+	r.mu.Lock()
+	activeKey := r.genKey(r.activeGen)
+	activeSince := r.activeSince
+	r.mu.Unlock()
+
+	raw, err := r.client.Get(ctx, activeKey).Result()
+	if err != nil && err != redis.Nil {
+		return CuckooStats{}, fmt.Errorf("redis cuckoo GET failed: %w", err)
+	}
+
+	occupied := 0
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != 0 {
+			occupied++
+		}
+	}
+
+	return CuckooStats{
+		LoadFactor:             float64(occupied) / float64(r.m*uint64(cuckoo.BucketSize())),
+		EstimatedFalsePositive: cuckoo.FalsePositiveRate(),
+		ActiveSince:            activeSince,
+		Capacity:               r.m * uint64(cuckoo.BucketSize()),
+	}, nil
+}
+
+// writeBehind persists a filter-confirmed-unique key to inner
+// asynchronously, off the path SetNX already returned on, so Redis - and
+// whatever inner maintains off SetNX, e.g. RedisRepository's cardinality
+// HLL - stays eventually consistent with what the filter already knows
+// for certain. It uses a detached context with its own timeout rather
+// than ctx, since the caller - and ctx along with it - is typically long
+// gone before this completes. Mirrors BloomRepository.writeBehind.
+func (r *CuckooRepository) writeBehind(key string, value interface{}, ttl time.Duration) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), writeBehindTimeout)
+		defer cancel()
+		_, _ = r.inner.SetNX(ctx, key, value, ttl)
+	}()
+}
@@ -0,0 +1,78 @@
+package deduplication
+
+import (
+	"fmt"
+	"strings"
+)
+
+// fieldPath is a parsed field-access expression such as "payload.user.id"
+// or "headers['x-request-id']", split into the key segments used to walk a
+// nested map[string]interface{}. Dotted and bracket-quoted segments may be
+// mixed freely; a bracket segment lets a key contain characters ('.', '[')
+// that a dotted segment can't represent unambiguously.
+type fieldPath struct {
+	raw  string
+	keys []string
+}
+
+// parseFieldPath parses expr into its key segments. It does not resolve
+// expr against any data - see fieldPath.resolve.
+func parseFieldPath(expr string) (fieldPath, error) {
+	if expr == "" {
+		return fieldPath{}, fmt.Errorf("empty field expression")
+	}
+
+	var keys []string
+	i, n := 0, len(expr)
+	for i < n {
+		switch expr[i] {
+		case '.':
+			i++
+		case '[':
+			end := strings.IndexByte(expr[i:], ']')
+			if end == -1 {
+				return fieldPath{}, fmt.Errorf("field expression %q has an unterminated '['", expr)
+			}
+			key := strings.Trim(expr[i+1:i+end], `'"`)
+			if key == "" {
+				return fieldPath{}, fmt.Errorf("field expression %q has an empty bracket segment", expr)
+			}
+			keys = append(keys, key)
+			i += end + 1
+		default:
+			end := i
+			for end < n && expr[end] != '.' && expr[end] != '[' {
+				end++
+			}
+			if end == i {
+				return fieldPath{}, fmt.Errorf("field expression %q is malformed at position %d", expr, i)
+			}
+			keys = append(keys, expr[i:end])
+			i = end
+		}
+	}
+	if len(keys) == 0 {
+		return fieldPath{}, fmt.Errorf("field expression %q has no segments", expr)
+	}
+	return fieldPath{raw: expr, keys: keys}, nil
+}
+
+// resolve walks data by p's key segments, returning (value, true) only if
+// every segment found a map entry. A missing segment anywhere along the
+// path resolves to (nil, false), the nested equivalent of the flat
+// lookup's existing "field absent" case.
+func (p fieldPath) resolve(data map[string]interface{}) (interface{}, bool) {
+	var current interface{} = data
+	for _, key := range p.keys {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		val, exists := m[key]
+		if !exists {
+			return nil, false
+		}
+		current = val
+	}
+	return current, true
+}
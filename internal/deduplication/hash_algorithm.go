@@ -0,0 +1,84 @@
+package deduplication
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strings"
+	"sync"
+)
+
+// HashAlgorithm is a pluggable digest a Hasher can select by name (see
+// Register/lookupAlgorithm below) instead of ComputeHash switching on a
+// hardcoded list. Sum hex-encodes its digest so every algorithm - whatever
+// its native hash.Hash.Size() - plugs into ComputeHash's string return the
+// same way.
+type HashAlgorithm interface {
+	// Name is the registered name this algorithm answers to - what
+	// DeduplicationConfig.HashAlgorithm names in config.
+	Name() string
+	// New returns a fresh hash.Hash for this algorithm, for a caller that
+	// wants to stream input rather than call Sum directly.
+	New() hash.Hash
+	// Sum hashes input in one shot and returns its hex-encoded digest.
+	Sum(input []byte) string
+}
+
+var (
+	algorithmsMu sync.RWMutex
+	algorithms   = make(map[string]func() HashAlgorithm)
+)
+
+// Register makes a HashAlgorithm available under name (matched
+// case-insensitively by NewHasher/NewHasherWithKey against
+// DeduplicationConfig.HashAlgorithm). It is intended to be called from an
+// algorithm package's init function and panics if name is already
+// registered, mirroring broker.RegisterKafkaDriver.
+func Register(name string, factory func() HashAlgorithm) {
+	algorithmsMu.Lock()
+	defer algorithmsMu.Unlock()
+
+	if factory == nil {
+		panic("deduplication: Register factory is nil")
+	}
+	key := strings.ToLower(name)
+	if _, dup := algorithms[key]; dup {
+		panic("deduplication: Register called twice for algorithm " + name)
+	}
+	algorithms[key] = factory
+}
+
+func lookupAlgorithm(name string) (HashAlgorithm, error) {
+	algorithmsMu.RLock()
+	defer algorithmsMu.RUnlock()
+
+	factory, ok := algorithms[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown hash algorithm %q", name)
+	}
+	return factory(), nil
+}
+
+func init() {
+	Register("md5", func() HashAlgorithm { return stdlibAlgorithm{"md5", md5.New} })
+	Register("sha1", func() HashAlgorithm { return stdlibAlgorithm{"sha1", sha1.New} })
+	Register("sha256", func() HashAlgorithm { return stdlibAlgorithm{"sha256", sha256.New} })
+}
+
+// stdlibAlgorithm adapts any standard-library hash.Hash constructor into a
+// HashAlgorithm.
+type stdlibAlgorithm struct {
+	name string
+	new  func() hash.Hash
+}
+
+func (a stdlibAlgorithm) Name() string   { return a.name }
+func (a stdlibAlgorithm) New() hash.Hash { return a.new() }
+func (a stdlibAlgorithm) Sum(input []byte) string {
+	h := a.new()
+	h.Write(input)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,81 @@
+package deduplication
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"hash"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/dchest/siphash"
+	"github.com/zeebo/blake3"
+	"github.com/zeebo/xxh3"
+)
+
+// xxhashAlgorithm, xxh3Algorithm and blake3Algorithm register the
+// non-cryptographic/modern algorithms split out of hash_algorithm.go's
+// stdlib-only built-ins, same as franz_kafka.go sits next to kafka.go for
+// broker.KafkaDriver - each pulls in its own third-party dependency rather
+// than the standard library.
+func init() {
+	Register("xxhash64", func() HashAlgorithm { return xxhashAlgorithm{} })
+	Register("xxh3", func() HashAlgorithm { return xxh3Algorithm{} })
+	Register("blake3", func() HashAlgorithm { return blake3Algorithm{} })
+}
+
+type xxhashAlgorithm struct{}
+
+func (xxhashAlgorithm) Name() string   { return "xxhash64" }
+func (xxhashAlgorithm) New() hash.Hash { return xxhash.New() }
+func (a xxhashAlgorithm) Sum(input []byte) string {
+	h := a.New()
+	h.Write(input)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+type xxh3Algorithm struct{}
+
+func (xxh3Algorithm) Name() string   { return "xxh3" }
+func (xxh3Algorithm) New() hash.Hash { return xxh3.New() }
+func (a xxh3Algorithm) Sum(input []byte) string {
+	h := a.New()
+	h.Write(input)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+type blake3Algorithm struct{}
+
+func (blake3Algorithm) Name() string   { return "blake3" }
+func (blake3Algorithm) New() hash.Hash { return blake3.New() }
+func (blake3Algorithm) Sum(input []byte) string {
+	h := blake3.New()
+	h.Write(input)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// siphashKeyed computes keyed SipHash-2-4 digests for Hasher's "siphash"
+// algorithm. Unlike the algorithms above it needs a runtime key, so (like
+// "hmac-sha256") it isn't in the name-only registry - ComputeHash
+// constructs it directly with h.hmacKey once per call.
+type siphashKeyed struct {
+	k0, k1 uint64
+}
+
+// newSiphashKeyed derives a 128-bit key (k0, k1) from key by splitting its
+// bytes across two uint64s, zero-padding short keys and truncating long
+// ones - the same "string folds into however many bits the algorithm
+// wants" treatment HMACKey already gets for hmac-sha256.
+func newSiphashKeyed(key string) siphashKeyed {
+	var padded [16]byte
+	copy(padded[:], key)
+	return siphashKeyed{
+		k0: binary.BigEndian.Uint64(padded[0:8]),
+		k1: binary.BigEndian.Uint64(padded[8:16]),
+	}
+}
+
+func (k siphashKeyed) New() hash.Hash { return siphash.New128(k.k0, k.k1) }
+func (k siphashKeyed) Sum(input []byte) string {
+	h := k.New()
+	h.Write(input)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -1,21 +1,100 @@
 package deduplication
 
 import (
-	"crypto/md5"
+	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"strings"
 )
 
+// Hasher computes a deterministic fingerprint for a message's configured
+// FieldsToHash. Each field is a "field expression": a JSONPath-style path
+// (payload.user.id, headers['x-request-id'], see fieldPath) with optional
+// "|directive" normalization suffixes (see normalizeDirective), resolved
+// against the message and folded together with salt (if set) before
+// hashing with algorithm. algorithm is resolved against the HashAlgorithm
+// registry (see Register) except for the two keyed algorithms,
+// "hmac-sha256" and "siphash", which ComputeHash constructs directly from
+// hmacKey since a registry factory takes no arguments.
 type Hasher struct {
 	algorithm string
+	salt      string
+	hmacKey   string
+	canonical bool
 }
 
+// NewHasher builds a Hasher with no salt or HMAC key, for any
+// non-key-requiring algorithm ("md5", "sha256", "xxhash64", "xxh3",
+// "blake3", ...). Use NewHasherWithKey for "hmac-sha256", "siphash" or
+// tenant-isolating salt.
 func NewHasher(algorithm string) *Hasher {
 	return &Hasher{algorithm: algorithm}
 }
 
+// NewHasherWithKey builds a Hasher that folds salt into every hash input
+// (see ComputeHash) and, for algorithm "hmac-sha256" or "siphash", uses
+// hmacKey as the keyed algorithm's key. salt and hmacKey are both optional
+// for any other algorithm.
+func NewHasherWithKey(algorithm, salt, hmacKey string) *Hasher {
+	return &Hasher{algorithm: algorithm, salt: salt, hmacKey: hmacKey}
+}
+
+// NewHasherWithOptions is NewHasherWithKey plus canonical, which - when
+// true - switches ComputeHash's field serialization from fmt.Sprintf's
+// "%v" (whose output for a map depends on Go's randomized map iteration
+// order, not the order FieldsToHash or the payload itself were built in)
+// to json.Marshal, which sorts map keys and nests consistently regardless
+// of either. canonical defaults to false everywhere else in this file so
+// existing fingerprints - and the Redis keys already computed from them -
+// don't change under configs that don't opt in.
+func NewHasherWithOptions(algorithm, salt, hmacKey string, canonical bool) *Hasher {
+	return &Hasher{algorithm: algorithm, salt: salt, hmacKey: hmacKey, canonical: canonical}
+}
+
+// fieldExpr is a single FieldsToHash entry, parsed into the path it
+// resolves and the normalization directives applied to the result.
+type fieldExpr struct {
+	path       fieldPath
+	directives []normalizeDirective
+}
+
+// parseFieldExpr splits a FieldsToHash entry on "|" into its path (first
+// segment) and normalization directives (the rest), e.g.
+// "payload.user.id|lowercase|trim".
+func parseFieldExpr(expr string) (fieldExpr, error) {
+	parts := strings.Split(expr, "|")
+	path, err := parseFieldPath(parts[0])
+	if err != nil {
+		return fieldExpr{}, err
+	}
+	directives, err := parseNormalizeDirectives(parts[1:])
+	if err != nil {
+		return fieldExpr{}, fmt.Errorf("field %q: %w", expr, err)
+	}
+	return fieldExpr{path: path, directives: directives}, nil
+}
+
+// ValidateFieldExpr reports whether expr is a well-formed field
+// expression, without resolving it against any data. It's what
+// ValidateDeduplicationConfig calls for each entry in FieldsToHash.
+func ValidateFieldExpr(expr string) error {
+	_, err := parseFieldExpr(expr)
+	return err
+}
+
+func (f fieldExpr) resolveAndNormalize(msg map[string]interface{}) interface{} {
+	val, exists := f.path.resolve(msg)
+	if !exists {
+		val = ""
+	}
+	for _, d := range f.directives {
+		val = d.apply(val)
+	}
+	return val
+}
+
 func (h *Hasher) ComputeHash(msg map[string]interface{}, fields []string) (string, error) {
 	if len(fields) == 0 {
 		return "", fmt.Errorf("no fields specified for hashing")
@@ -24,24 +103,54 @@ func (h *Hasher) ComputeHash(msg map[string]interface{}, fields []string) (strin
 	var builder strings.Builder
 
 	for _, field := range fields {
-		val, exists := msg[field]
-		if !exists {
-			val = ""
+		expr, err := parseFieldExpr(field)
+		if err != nil {
+			return "", err
 		}
-		builder.WriteString(fmt.Sprintf("%v|", val))
+		builder.WriteString(h.serializeField(expr.resolveAndNormalize(msg)))
+		builder.WriteByte('|')
+	}
+	if h.salt != "" {
+		builder.WriteString(h.salt)
 	}
 
-	input := builder.String()
+	input := []byte(builder.String())
 
 	switch h.algorithm {
-	case "sha256":
-		sum := sha256.Sum256([]byte(input))
-		return hex.EncodeToString(sum[:]), nil
-	case "md5":
-		sum := md5.Sum([]byte(input))
-		return hex.EncodeToString(sum[:]), nil
+	case "hmac-sha256":
+		mac := hmac.New(sha256.New, []byte(h.hmacKey))
+		mac.Write(input)
+		return hex.EncodeToString(mac.Sum(nil)), nil
+	case "siphash":
+		return newSiphashKeyed(h.hmacKey).Sum(input), nil
+	default:
+		algo, err := lookupAlgorithm(h.algorithm)
+		if err != nil {
+			return "", fmt.Errorf("compute hash: %w", err)
+		}
+		return algo.Sum(input), nil
+	}
+}
+
+// serializeField renders a resolved field value for hashing. Plain values
+// (strings, numbers, bools) always go through fmt.Sprintf's "%v", which is
+// stable for them; h.canonical only changes how a map or slice value -
+// typically a nested JSON object/array a field path resolved to as a
+// whole - gets serialized, since fmt's "%v" walks a Go map in its
+// randomized iteration order while json.Marshal sorts map keys and nests
+// consistently regardless of build order.
+func (h *Hasher) serializeField(val interface{}) string {
+	if !h.canonical {
+		return fmt.Sprintf("%v", val)
+	}
+	switch val.(type) {
+	case map[string]interface{}, []interface{}:
+		encoded, err := json.Marshal(val)
+		if err != nil {
+			return fmt.Sprintf("%v", val)
+		}
+		return string(encoded)
 	default:
-		sum := md5.Sum([]byte(input))
-		return hex.EncodeToString(sum[:]), nil
+		return fmt.Sprintf("%v", val)
 	}
 }
@@ -0,0 +1,201 @@
+package deduplication
+
+import "testing"
+
+func TestComputeHash_NestedAndBracketFields(t *testing.T) {
+	msg := map[string]interface{}{
+		"payload": map[string]interface{}{
+			"user": map[string]interface{}{"id": "u-1"},
+		},
+		"headers": map[string]interface{}{"x-request-id": "req-1"},
+	}
+
+	h := NewHasher("sha256")
+	hash1, err := h.ComputeHash(msg, []string{"payload.user.id", "headers['x-request-id']"})
+	if err != nil {
+		t.Fatalf("ComputeHash returned error: %v", err)
+	}
+
+	msg["payload"].(map[string]interface{})["user"].(map[string]interface{})["id"] = "u-2"
+	hash2, err := h.ComputeHash(msg, []string{"payload.user.id", "headers['x-request-id']"})
+	if err != nil {
+		t.Fatalf("ComputeHash returned error: %v", err)
+	}
+
+	if hash1 == hash2 {
+		t.Fatalf("expected different hashes for different nested field values")
+	}
+}
+
+func TestComputeHash_MissingFieldResolvesEmpty(t *testing.T) {
+	h := NewHasher("md5")
+	hash1, err := h.ComputeHash(map[string]interface{}{"id": "a"}, []string{"id", "missing.nested.field"})
+	if err != nil {
+		t.Fatalf("ComputeHash returned error: %v", err)
+	}
+	hash2, err := h.ComputeHash(map[string]interface{}{"id": "a"}, []string{"id", "another.missing.field"})
+	if err != nil {
+		t.Fatalf("ComputeHash returned error: %v", err)
+	}
+	if hash1 != hash2 {
+		t.Fatalf("expected two different missing fields to both resolve to the same empty value")
+	}
+}
+
+func TestComputeHash_NormalizationDirectives(t *testing.T) {
+	h := NewHasher("sha256")
+	msg1 := map[string]interface{}{"email": "  User@Example.com  "}
+	msg2 := map[string]interface{}{"email": "user@example.com"}
+
+	hash1, err := h.ComputeHash(msg1, []string{"email|trim|lowercase"})
+	if err != nil {
+		t.Fatalf("ComputeHash returned error: %v", err)
+	}
+	hash2, err := h.ComputeHash(msg2, []string{"email|trim|lowercase"})
+	if err != nil {
+		t.Fatalf("ComputeHash returned error: %v", err)
+	}
+	if hash1 != hash2 {
+		t.Fatalf("expected trim|lowercase to normalize both emails to the same fingerprint")
+	}
+}
+
+func TestComputeHash_SortArrayDirectiveIgnoresOrder(t *testing.T) {
+	h := NewHasher("sha256")
+	msg1 := map[string]interface{}{"tags": []interface{}{"b", "a", "c"}}
+	msg2 := map[string]interface{}{"tags": []interface{}{"a", "b", "c"}}
+
+	hash1, err := h.ComputeHash(msg1, []string{"tags|sort_array"})
+	if err != nil {
+		t.Fatalf("ComputeHash returned error: %v", err)
+	}
+	hash2, err := h.ComputeHash(msg2, []string{"tags|sort_array"})
+	if err != nil {
+		t.Fatalf("ComputeHash returned error: %v", err)
+	}
+	if hash1 != hash2 {
+		t.Fatalf("expected sort_array to make tag order irrelevant to the fingerprint")
+	}
+}
+
+func TestComputeHash_SaltIsolatesOtherwiseIdenticalInput(t *testing.T) {
+	msg := map[string]interface{}{"id": "shared"}
+
+	hashA, err := NewHasherWithKey("sha256", "tenant-a", "").ComputeHash(msg, []string{"id"})
+	if err != nil {
+		t.Fatalf("ComputeHash returned error: %v", err)
+	}
+	hashB, err := NewHasherWithKey("sha256", "tenant-b", "").ComputeHash(msg, []string{"id"})
+	if err != nil {
+		t.Fatalf("ComputeHash returned error: %v", err)
+	}
+	if hashA == hashB {
+		t.Fatalf("expected different salts to produce different fingerprints for identical input")
+	}
+}
+
+func TestComputeHash_HMACSHA256RequiresMatchingKey(t *testing.T) {
+	msg := map[string]interface{}{"id": "shared"}
+
+	hash1, err := NewHasherWithKey("hmac-sha256", "", "key-one").ComputeHash(msg, []string{"id"})
+	if err != nil {
+		t.Fatalf("ComputeHash returned error: %v", err)
+	}
+	hash2, err := NewHasherWithKey("hmac-sha256", "", "key-two").ComputeHash(msg, []string{"id"})
+	if err != nil {
+		t.Fatalf("ComputeHash returned error: %v", err)
+	}
+	if hash1 == hash2 {
+		t.Fatalf("expected different HMAC keys to produce different fingerprints")
+	}
+}
+
+func TestComputeHash_UnknownAlgorithmReturnsError(t *testing.T) {
+	h := NewHasher("not-a-real-algorithm")
+	if _, err := h.ComputeHash(map[string]interface{}{"id": "a"}, []string{"id"}); err == nil {
+		t.Fatalf("expected unknown algorithm to return an error instead of silently defaulting to md5")
+	}
+}
+
+func TestComputeHash_RegisteredAlgorithmsProduceDistinctFingerprints(t *testing.T) {
+	msg := map[string]interface{}{"id": "shared"}
+	seen := make(map[string]string)
+	for _, algo := range []string{"md5", "sha256", "xxhash64", "xxh3", "blake3"} {
+		hash, err := NewHasher(algo).ComputeHash(msg, []string{"id"})
+		if err != nil {
+			t.Fatalf("ComputeHash(%q) returned error: %v", algo, err)
+		}
+		if hash == "" {
+			t.Fatalf("ComputeHash(%q) returned an empty fingerprint", algo)
+		}
+		for otherAlgo, otherHash := range seen {
+			if hash == otherHash {
+				t.Fatalf("%q and %q produced the same fingerprint for identical input", algo, otherAlgo)
+			}
+		}
+		seen[algo] = hash
+	}
+}
+
+func TestComputeHash_SiphashRequiresMatchingKey(t *testing.T) {
+	msg := map[string]interface{}{"id": "shared"}
+
+	hash1, err := NewHasherWithKey("siphash", "", "key-one").ComputeHash(msg, []string{"id"})
+	if err != nil {
+		t.Fatalf("ComputeHash returned error: %v", err)
+	}
+	hash2, err := NewHasherWithKey("siphash", "", "key-two").ComputeHash(msg, []string{"id"})
+	if err != nil {
+		t.Fatalf("ComputeHash returned error: %v", err)
+	}
+	if hash1 == hash2 {
+		t.Fatalf("expected different SipHash keys to produce different fingerprints")
+	}
+}
+
+func TestComputeHash_CanonicalSerializationIgnoresMapBuildOrder(t *testing.T) {
+	h := NewHasherWithOptions("sha256", "", "", true)
+
+	msg1 := map[string]interface{}{"payload": map[string]interface{}{"a": 1, "b": 2}}
+	msg2 := map[string]interface{}{"payload": map[string]interface{}{"b": 2, "a": 1}}
+
+	hash1, err := h.ComputeHash(msg1, []string{"payload"})
+	if err != nil {
+		t.Fatalf("ComputeHash returned error: %v", err)
+	}
+	hash2, err := h.ComputeHash(msg2, []string{"payload"})
+	if err != nil {
+		t.Fatalf("ComputeHash returned error: %v", err)
+	}
+	if hash1 != hash2 {
+		t.Fatalf("expected canonical serialization to ignore map build order")
+	}
+}
+
+func TestValidateFieldExpr(t *testing.T) {
+	valid := []string{
+		"id",
+		"payload.user.id",
+		"headers['x-request-id']",
+		"payload.user.id|lowercase|trim",
+		"created_at|round_timestamp:60s",
+	}
+	for _, expr := range valid {
+		if err := ValidateFieldExpr(expr); err != nil {
+			t.Errorf("expected %q to be valid, got error: %v", expr, err)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"headers['x-request-id'",
+		"field|not_a_real_directive",
+		"created_at|round_timestamp",
+		"created_at|round_timestamp:not-a-duration",
+	}
+	for _, expr := range invalid {
+		if err := ValidateFieldExpr(expr); err == nil {
+			t.Errorf("expected %q to be invalid", expr)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package deduplication
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"yeti/internal/constants"
+)
+
+// IdempotentResult is the outcome Repository.Remember caches for a key: an
+// HTTP handler's status/body, or (for a side-effect-only Kafka consumer
+// call via RememberMessage) a zero Status/Body standing in for "it ran".
+type IdempotentResult struct {
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body,omitempty"`
+	At     time.Time       `json:"at"`
+}
+
+// idemInFlightSentinel marks a key whose fn is currently running, so a
+// concurrent caller can tell "still in progress" apart from "no cached
+// result yet" (the key simply doesn't exist) without a separate Redis call.
+const idemInFlightSentinel = "in-flight"
+
+// idemInFlightMarkerTTL bounds how long a crashed holder's in-flight
+// marker can block every other caller for the same key - longer than any
+// fn is expected to legitimately run, short enough that a dead holder
+// doesn't wedge the key forever.
+const idemInFlightMarkerTTL = 30 * time.Second
+
+// idemPollInterval is how often RedisRepository.Remember re-checks a key
+// it lost the race to acquire.
+const idemPollInterval = 100 * time.Millisecond
+
+// Remember implements Repository.Remember; see its doc comment.
+func (r *RedisRepository) Remember(ctx context.Context, key string, ttl, waitDeadline time.Duration, fn func() (IdempotentResult, error)) (IdempotentResult, bool, error) {
+	fullKey := constants.CacheKeyPrefixIdem + key
+	deadline := time.Now().Add(waitDeadline)
+
+	for {
+		acquired, err := r.client.SetNX(ctx, fullKey, idemInFlightSentinel, idemInFlightMarkerTTL).Result()
+		if err != nil {
+			return IdempotentResult{}, false, fmt.Errorf("redis idempotency SETNX failed: %w", err)
+		}
+		if acquired {
+			return r.runAndCacheIdempotent(ctx, fullKey, ttl, fn)
+		}
+
+		result, found, err := r.peekIdempotentResult(ctx, fullKey)
+		if err != nil {
+			return IdempotentResult{}, false, err
+		}
+		if found {
+			return result, true, nil
+		}
+
+		// Either still in flight, or the holder's attempt just finished
+		// (success replaced the marker with a result we'll see on the next
+		// peek) or failed and cleaned up after itself (in which case the
+		// next SETNX above will win and we'll run fn ourselves). Either
+		// way, wait and retry rather than giving up immediately.
+		if !time.Now().Before(deadline) {
+			return IdempotentResult{}, false, fmt.Errorf("timed out after %s waiting for idempotent key %q", waitDeadline, key)
+		}
+		select {
+		case <-ctx.Done():
+			return IdempotentResult{}, false, ctx.Err()
+		case <-time.After(idemPollInterval):
+		}
+	}
+}
+
+// peekIdempotentResult reports the cached result under fullKey, if any -
+// found is false both when fullKey doesn't exist and when it's still
+// idemInFlightSentinel, since Remember's retry loop treats both the same
+// way (wait, then try again).
+func (r *RedisRepository) peekIdempotentResult(ctx context.Context, fullKey string) (IdempotentResult, bool, error) {
+	value, err := r.client.Get(ctx, fullKey).Result()
+	if err == redis.Nil {
+		return IdempotentResult{}, false, nil
+	}
+	if err != nil {
+		return IdempotentResult{}, false, fmt.Errorf("redis idempotency GET failed: %w", err)
+	}
+	if value == idemInFlightSentinel {
+		return IdempotentResult{}, false, nil
+	}
+
+	var result IdempotentResult
+	if err := json.Unmarshal([]byte(value), &result); err != nil {
+		return IdempotentResult{}, false, fmt.Errorf("failed to decode cached idempotency result: %w", err)
+	}
+	return result, true, nil
+}
+
+// runAndCacheIdempotent calls fn, having just won the SETNX race for
+// fullKey, and either caches its result for ttl or - on error - deletes
+// fullKey so a later retry isn't stuck behind a failed attempt for the rest
+// of ttl.
+func (r *RedisRepository) runAndCacheIdempotent(ctx context.Context, fullKey string, ttl time.Duration, fn func() (IdempotentResult, error)) (IdempotentResult, bool, error) {
+	result, err := fn()
+	if err != nil {
+		if delErr := r.client.Del(ctx, fullKey).Err(); delErr != nil {
+			return IdempotentResult{}, false, fmt.Errorf("idempotency key cleanup failed after fn error %v: %w", err, delErr)
+		}
+		return IdempotentResult{}, false, err
+	}
+
+	result.At = time.Now()
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		_ = r.client.Del(ctx, fullKey).Err()
+		return IdempotentResult{}, false, fmt.Errorf("failed to encode idempotency result: %w", err)
+	}
+	if err := r.client.Set(ctx, fullKey, encoded, ttl).Err(); err != nil {
+		return IdempotentResult{}, false, fmt.Errorf("redis idempotency SET failed: %w", err)
+	}
+	return result, false, nil
+}
+
+// statusRecorder is an http.ResponseWriter that buffers a handler's
+// response instead of sending it, so RememberHTTP can capture the first
+// call's outcome before deciding whether it's the one that gets cached.
+type statusRecorder struct {
+	header http.Header
+	status int
+	body   []byte
+}
+
+func newStatusRecorder() *statusRecorder {
+	return &statusRecorder{header: http.Header{}, status: http.StatusOK}
+}
+
+func (s *statusRecorder) Header() http.Header { return s.header }
+
+func (s *statusRecorder) WriteHeader(status int) { s.status = status }
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	s.body = append(s.body, b...)
+	return len(b), nil
+}
+
+// RememberHTTP wraps next with Repository.Remember, keyed by keyFn(r) - e.g.
+// an Idempotency-Key request header. The first request for a key runs next
+// and its status/body are cached for ttl; every other request for that key
+// within the window, including one arriving while the first is still being
+// handled (it waits up to waitDeadline), replays the cached response
+// instead of running next again. keyFn returning "" opts a request out of
+// idempotency entirely (next runs directly, nothing is cached) - e.g. for a
+// request carrying no idempotency key at all.
+func RememberHTTP(repo Repository, ttl, waitDeadline time.Duration, keyFn func(*http.Request) string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := keyFn(r)
+		if key == "" {
+			next(w, r)
+			return
+		}
+
+		result, replayed, err := repo.Remember(r.Context(), key, ttl, waitDeadline, func() (IdempotentResult, error) {
+			rec := newStatusRecorder()
+			next(rec, r)
+			return IdempotentResult{Status: rec.status, Body: rec.body}, nil
+		})
+		if err != nil {
+			http.Error(w, fmt.Sprintf("idempotency check failed: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		if replayed {
+			w.Header().Set("Idempotency-Replayed", "true")
+		}
+		w.WriteHeader(result.Status)
+		_, _ = w.Write(result.Body)
+	}
+}
+
+// RememberMessage is Repository.Remember's counterpart for a Kafka consumer:
+// fn has no response to replay, just a side effect (e.g. writing to a
+// downstream sink) that at-least-once delivery or a producer retry must not
+// repeat. key should identify the message - its Kafka key, or a header the
+// producer sets - not its offset, since a redelivery gets a new offset but
+// the same key. replayed reports whether an earlier delivery had already
+// run fn, so this call skipped it; it is false for the call that ran fn.
+func RememberMessage(ctx context.Context, repo Repository, key string, ttl, waitDeadline time.Duration, fn func() error) (replayed bool, err error) {
+	_, replayed, err = repo.Remember(ctx, key, ttl, waitDeadline, func() (IdempotentResult, error) {
+		return IdempotentResult{}, fn()
+	})
+	return replayed, err
+}
@@ -0,0 +1,32 @@
+package deduplication
+
+import (
+	"hash/fnv"
+	"sync"
+)
+
+// keyStripes is the number of mutexes keyLock stripes serialization across.
+// A fixed stripe count keeps memory bounded under sustained unique-key
+// traffic - unlike one mutex per key, it never grows - at the cost of two
+// unrelated keys occasionally sharing a stripe and contending for no real
+// reason.
+const keyStripes = 256
+
+// keyLock serializes the check-then-mark sequence BloomRepository.SetNX and
+// CuckooRepository.setNXLocal each perform (Test, then Add) so two
+// concurrent calls for the same key can't both observe the fast-path filter
+// reporting "absent" before either has recorded it - the classic
+// check-then-act race that would let both calls take the unique fast path
+// for what's actually the same key arriving twice.
+type keyLock struct {
+	mus [keyStripes]sync.Mutex
+}
+
+// lock acquires key's stripe and returns the matching unlock func.
+func (l *keyLock) lock(key string) func() {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	mu := &l.mus[h.Sum32()%keyStripes]
+	mu.Lock()
+	return mu.Unlock
+}
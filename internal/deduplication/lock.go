@@ -0,0 +1,196 @@
+package deduplication
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// lockKeyPrefix namespaces Lock's keyspace from everything else a shared
+// Redis client is used for - constants.CacheKeyPrefixDedup's SETNX checks,
+// constants.CacheKeyPrefixIdem's Remember cache, and whatever else.
+const lockKeyPrefix = "lock:"
+
+// ErrLockHeld is returned by Lock when resource is already held by someone
+// else.
+var ErrLockHeld = errors.New("deduplication: lock already held")
+
+// unlockScript deletes KEYS[1] only if it still holds ARGV[1] - this
+// handle's token - so Unlock (and the auto-refresh goroutine giving up)
+// can never delete a lock some other holder has since acquired after this
+// one's lease expired. A blind DEL can't tell the two apart; this can.
+const unlockScript = `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+  return redis.call("DEL", KEYS[1])
+else
+  return 0
+end
+`
+
+// refreshScript re-PEXPIREs KEYS[1] to ARGV[2] milliseconds, same
+// token-compare guard as unlockScript: a lease that already expired and
+// was re-acquired by someone else must not have its TTL extended by this
+// handle's background refresh.
+const refreshScript = `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+  return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+else
+  return 0
+end
+`
+
+var (
+	unlockLuaScript  = redis.NewScript(unlockScript)
+	refreshLuaScript = redis.NewScript(refreshScript)
+)
+
+// fenceKeyPrefix namespaces the INCR counters Lock reads Fence from - one
+// per resource, separate from the lock key itself so a fencing token
+// survives and keeps increasing across repeated acquisitions of the same
+// resource rather than resetting with every new lock key.
+const fenceKeyPrefix = "lock:fence:"
+
+// Locked is a held Lock's handle.
+type Locked struct {
+	client *redis.Client
+	key    string
+	token  string
+	ttl    time.Duration
+
+	// Fence is this acquisition's fencing token: monotonically increasing
+	// per resource (backed by INCR on fenceKeyPrefix+resource), so a
+	// resource Lock guards can reject a write carrying a lower Fence than
+	// one it's already accepted - the standard defense against a holder
+	// whose lease expired and who hasn't yet noticed Unlock/Refresh
+	// failing, racing a write against whoever holds the lock now.
+	Fence int64
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	stopped  chan struct{}
+}
+
+// Lock acquires resource via SET resource token NX PX ttl, returning
+// ErrLockHeld if someone else already holds it. While held, a background
+// goroutine re-PEXPIREs the lease every ttl/2 so the caller doesn't have to
+// call Refresh itself just to stay holder - Refresh exists for a caller
+// that wants to extend past the auto-refresh schedule on its own terms
+// (e.g. right before starting unusually long work). The goroutine stops
+// once Unlock is called, or gives up silently if a refresh ever reports the
+// lock no longer belongs to this handle (lost to TTL expiry under heavy
+// load, e.g. a long GC pause) - Refresh/Unlock's own return values, not the
+// goroutine, are how a caller finds out its lease died early.
+func Lock(ctx context.Context, client *redis.Client, resource string, ttl time.Duration) (*Locked, error) {
+	key := lockKeyPrefix + resource
+	token, err := newLockToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate lock token: %w", err)
+	}
+
+	acquired, err := client.SetNX(ctx, key, token, ttl).Result()
+	if err != nil {
+		return nil, fmt.Errorf("redis lock SETNX failed: %w", err)
+	}
+	if !acquired {
+		return nil, ErrLockHeld
+	}
+
+	fence, err := client.Incr(ctx, fenceKeyPrefix+resource).Result()
+	if err != nil {
+		// The lock itself was acquired; best-effort release it rather than
+		// leaving it held with no usable fencing token, using a fresh
+		// context since ctx may already be the caller's and on its way out.
+		_, _ = unlockLuaScript.Run(context.Background(), client, []string{key}, token).Result()
+		return nil, fmt.Errorf("redis lock fencing INCR failed: %w", err)
+	}
+
+	l := &Locked{
+		client:  client,
+		key:     key,
+		token:   token,
+		ttl:     ttl,
+		Fence:   fence,
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go l.autoRefresh()
+	return l, nil
+}
+
+// newLockToken generates a random 16-byte token, hex-encoded, unique enough
+// that two concurrent Lock calls for the same resource never collide even
+// if they somehow raced past SETNX (they can't, but the token also has to
+// be unguessable - a holder proves ownership by producing it back, so it
+// must not be predictable from ttl/resource/time alone).
+func newLockToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// autoRefresh re-PEXPIREs l's lease every l.ttl/2 until Unlock stops it or
+// a refresh reports the lock no longer belongs to this handle.
+func (l *Locked) autoRefresh() {
+	defer close(l.stopped)
+
+	interval := l.ttl / 2
+	if interval <= 0 {
+		interval = l.ttl
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), l.ttl)
+			held, err := l.refresh(ctx)
+			cancel()
+			if err != nil || !held {
+				return
+			}
+		}
+	}
+}
+
+// refresh re-PEXPIREs l's key to l.ttl if it still holds l.token, reporting
+// whether it does.
+func (l *Locked) refresh(ctx context.Context) (bool, error) {
+	res, err := refreshLuaScript.Run(ctx, l.client, []string{l.key}, l.token, l.ttl.Milliseconds()).Result()
+	if err != nil {
+		return false, fmt.Errorf("redis lock refresh script failed: %w", err)
+	}
+	n, _ := res.(int64)
+	return n == 1, nil
+}
+
+// Refresh extends l's lease to l.ttl from now, reporting whether l still
+// holds it - false means the lease already expired and was (or could be)
+// taken by someone else, and the caller no longer has exclusive access to
+// resource regardless of what it does next.
+func (l *Locked) Refresh(ctx context.Context) (bool, error) {
+	return l.refresh(ctx)
+}
+
+// Unlock stops the auto-refresh goroutine and releases l's key if it still
+// holds l.token - a no-op, not an error, if the lease already expired and
+// moved on to another holder.
+func (l *Locked) Unlock(ctx context.Context) error {
+	l.stopOnce.Do(func() { close(l.stop) })
+	<-l.stopped
+
+	if _, err := unlockLuaScript.Run(ctx, l.client, []string{l.key}, l.token).Result(); err != nil {
+		return fmt.Errorf("redis lock unlock script failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package deduplication
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// validNormalizeDirectives is the set of directive names FieldsToHash
+// entries may use after a "|", independent of whether they take an
+// argument (round_timestamp does, via "round_timestamp:60s").
+var validNormalizeDirectives = map[string]bool{
+	"lowercase":          true,
+	"trim":               true,
+	"strip_query_params": true,
+	"round_timestamp":    true,
+	"sort_array":         true,
+}
+
+// normalizeDirective is one "|directive" or "|directive:arg" suffix on a
+// FieldsToHash entry, applied (in order) to the value fieldPath.resolve
+// found before it's folded into the hash input.
+type normalizeDirective struct {
+	name string
+	arg  string
+}
+
+// parseNormalizeDirectives validates and parses the "|"-separated
+// directive suffixes following a field expression's path segment.
+func parseNormalizeDirectives(parts []string) ([]normalizeDirective, error) {
+	directives := make([]normalizeDirective, 0, len(parts))
+	for _, part := range parts {
+		name, arg, _ := strings.Cut(part, ":")
+		if !validNormalizeDirectives[name] {
+			return nil, fmt.Errorf("unknown normalization directive %q", name)
+		}
+		if name == "round_timestamp" {
+			if arg == "" {
+				return nil, fmt.Errorf("round_timestamp directive requires a duration argument, e.g. round_timestamp:60s")
+			}
+			if _, err := time.ParseDuration(arg); err != nil {
+				return nil, fmt.Errorf("invalid round_timestamp duration %q: %w", arg, err)
+			}
+		}
+		directives = append(directives, normalizeDirective{name: name, arg: arg})
+	}
+	return directives, nil
+}
+
+// apply normalizes val, the raw value fieldPath.resolve found for this
+// field (before any string conversion), returning the value that should
+// actually be folded into the hash input.
+func (d normalizeDirective) apply(val interface{}) interface{} {
+	switch d.name {
+	case "lowercase":
+		return strings.ToLower(fmt.Sprintf("%v", val))
+	case "trim":
+		return strings.TrimSpace(fmt.Sprintf("%v", val))
+	case "strip_query_params":
+		raw := fmt.Sprintf("%v", val)
+		u, err := url.Parse(raw)
+		if err != nil || u.RawQuery == "" {
+			return raw
+		}
+		u.RawQuery = ""
+		return u.String()
+	case "round_timestamp":
+		dur, err := time.ParseDuration(d.arg)
+		t, ok := parseTimeValue(val)
+		if err != nil || !ok || dur <= 0 {
+			return fmt.Sprintf("%v", val)
+		}
+		return t.Truncate(dur).Unix()
+	case "sort_array":
+		items, ok := val.([]interface{})
+		if !ok {
+			return fmt.Sprintf("%v", val)
+		}
+		strs := make([]string, len(items))
+		for i, item := range items {
+			strs[i] = fmt.Sprintf("%v", item)
+		}
+		sort.Strings(strs)
+		return strings.Join(strs, ",")
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
+// parseTimeValue interprets val as a timestamp: an already-parsed
+// time.Time, an RFC3339 string, or a Unix-seconds number - the shapes a
+// JSON-decoded msg.Payload field realistically arrives in.
+func parseTimeValue(val interface{}) (time.Time, bool) {
+	switch v := val.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			return t, true
+		}
+	case float64:
+		return time.Unix(int64(v), 0).UTC(), true
+	case int64:
+		return time.Unix(v, 0).UTC(), true
+	case int:
+		return time.Unix(int64(v), 0).UTC(), true
+	}
+	return time.Time{}, false
+}
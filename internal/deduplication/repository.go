@@ -6,13 +6,67 @@ import (
 	"time"
 
 	"github.com/redis/go-redis/v9"
+
+	"yeti/internal/constants"
 )
 
 type Repository interface {
 	SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error)
 	GetCacheSize(ctx context.Context, prefix string) (int, error)
+
+	// Lookup, Delete, ExtendTTL, and Purge back the admin API (see
+	// admin_handler.go) and nothing on Service's own hot path - the fast-path
+	// decorators (BloomRepository, CuckooRepository) forward them straight
+	// to inner, same as GetCacheSize, since an operator inspecting or
+	// un-deduping one key cares about what Redis actually holds, not the
+	// fast path's probabilistic view of it.
+
+	// Lookup reports whether key exists and, if so, its remaining TTL.
+	// ttl is 0 for a key with no expiry set.
+	Lookup(ctx context.Context, key string) (exists bool, ttl time.Duration, err error)
+	// Delete removes key, reporting whether it existed.
+	Delete(ctx context.Context, key string) (existed bool, err error)
+	// ExtendTTL resets key's expiry to ttl, reporting whether key existed.
+	ExtendTTL(ctx context.Context, key string, ttl time.Duration) (existed bool, err error)
+	// Purge deletes every key matching prefix+"*", batching deletes via a
+	// SCAN cursor walk (rather than KEYS) so a large keyspace doesn't block
+	// Redis for the call's whole duration. Returns the number of keys
+	// deleted.
+	Purge(ctx context.Context, prefix string) (deleted int, err error)
+
+	// SetNXBatch is SetNX's pipelined counterpart for Service.ProcessBatch:
+	// every entry's SETNX flushes in a single Redis round trip instead of
+	// one per entry, with results in the same order as entries. A failure
+	// fails the whole batch - see Service.ProcessBatch's doc comment for
+	// how that interacts with per-message onRedisError fallback.
+	SetNXBatch(ctx context.Context, entries []SetNXEntry) ([]bool, error)
+
+	// Remember runs fn at most once per key within ttl: the first caller
+	// caches fn's IdempotentResult under constants.CacheKeyPrefixIdem+key,
+	// and every other call with the same key - a producer retry, an
+	// at-least-once redelivery - replays that cached result instead of
+	// re-invoking fn. A call arriving while fn is still running for the
+	// same key waits for it to finish, up to waitDeadline, rather than
+	// racing it; see idempotency.go. replayed is false only for the call
+	// that actually ran fn.
+	Remember(ctx context.Context, key string, ttl, waitDeadline time.Duration, fn func() (IdempotentResult, error)) (result IdempotentResult, replayed bool, err error)
+}
+
+// SetNXEntry is one Repository.SetNXBatch request; Key/Value/TTL mirror
+// SetNX's own parameters.
+type SetNXEntry struct {
+	Key   string
+	Value interface{}
+	TTL   time.Duration
 }
 
+// hllKey is the HyperLogLog key GetCacheSize estimates cardinality from,
+// maintained incrementally by SetNX via PFADD instead of recomputed by
+// scanning every matching key. Every key SetNX is called with shares
+// constants.CacheKeyPrefixDedup (see Service.Process), so one HLL key
+// covers the whole keyspace GetCacheSize is ever asked about.
+const hllKey = constants.CacheKeyPrefixDedup + "hll"
+
 type RedisRepository struct {
 	client *redis.Client
 }
@@ -21,25 +75,173 @@ func NewRepository(client *redis.Client) Repository {
 	return &RedisRepository{client: client}
 }
 
+// invalidationChannel is the Redis Pub/Sub channel PublishInvalidation and
+// SubscribeInvalidations share, so every instance pointed at the same
+// Redis sees the same cross-instance dedup L1 cache invalidations - one
+// channel for the whole keyspace, same as hllKey.
+const invalidationChannel = "dedup:invalidate"
+
 func (r *RedisRepository) SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
 	success, err := r.client.SetNX(ctx, key, value, ttl).Result()
 	if err != nil {
 		return false, fmt.Errorf("redis SetNX failed: %w", err)
 	}
+	if success {
+		// Best-effort: a failed PFADD shouldn't fail the dedup check itself,
+		// it only means GetCacheSize's estimate drifts slightly low.
+		r.client.PFAdd(ctx, hllKey, key)
+	}
 	return success, nil
 }
 
+// GetCacheSize reports an estimate of the number of distinct keys ever
+// inserted (regardless of prefix, see hllKey) via SETNX/PFADD, backed by
+// PFCOUNT on the HyperLogLog hllKey maintains — O(1) regardless of how many
+// keys exist, unlike a SCAN that walks (and blocks on) every matching key.
 func (r *RedisRepository) GetCacheSize(ctx context.Context, prefix string) (int, error) {
-	iter := r.client.Scan(ctx, 0, prefix+"*", 0).Iterator()
-	count := 0
-	for iter.Next(ctx) {
-		if ctx.Err() != nil {
-			return 0, ctx.Err()
+	count, err := r.client.PFCount(ctx, hllKey).Result()
+	if err != nil {
+		return 0, fmt.Errorf("redis PFCOUNT failed: %w", err)
+	}
+	return int(count), nil
+}
+
+// PublishInvalidation broadcasts key on invalidationChannel so every other
+// instance sharing this Redis drops it from its own L1 cache - see
+// Service.consumeInvalidations. It backs Service's cacheInvalidationBroadcaster
+// type assertion.
+func (r *RedisRepository) PublishInvalidation(ctx context.Context, key string) error {
+	if err := r.client.Publish(ctx, invalidationChannel, key).Err(); err != nil {
+		return fmt.Errorf("redis publish invalidation failed: %w", err)
+	}
+	return nil
+}
+
+// SubscribeInvalidations subscribes to invalidationChannel, returning a
+// channel of invalidated keys and a close func the caller must invoke to
+// stop the subscription and release the underlying connection. The
+// returned channel also carries this instance's own PublishInvalidation
+// calls, since Redis Pub/Sub doesn't filter out a subscriber's own
+// publishes - Service.consumeInvalidations tolerates that as a harmless
+// re-removal of an already-removed key.
+func (r *RedisRepository) SubscribeInvalidations(ctx context.Context) (<-chan string, func() error) {
+	pubsub := r.client.Subscribe(ctx, invalidationChannel)
+
+	out := make(chan string)
+	go func() {
+		defer close(out)
+		for msg := range pubsub.Channel() {
+			select {
+			case out <- msg.Payload:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, pubsub.Close
+}
+
+func (r *RedisRepository) Lookup(ctx context.Context, key string) (bool, time.Duration, error) {
+	ttl, err := r.client.PTTL(ctx, key).Result()
+	if err != nil {
+		return false, 0, fmt.Errorf("redis PTTL failed: %w", err)
+	}
+	switch {
+	case ttl == -2:
+		return false, 0, nil
+	case ttl == -1:
+		return true, 0, nil
+	default:
+		return true, ttl, nil
+	}
+}
+
+func (r *RedisRepository) Delete(ctx context.Context, key string) (bool, error) {
+	n, err := r.client.Del(ctx, key).Result()
+	if err != nil {
+		return false, fmt.Errorf("redis DEL failed: %w", err)
+	}
+	return n > 0, nil
+}
+
+func (r *RedisRepository) ExtendTTL(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	existed, err := r.client.Expire(ctx, key, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("redis EXPIRE failed: %w", err)
+	}
+	return existed, nil
+}
+
+// purgeScanCount is the COUNT hint Purge's SCAN cursor walk passes Redis
+// per iteration - an approximate batch size, not a hard limit, per SCAN's
+// own contract.
+const purgeScanCount = 200
+
+// purgeBatchDelay throttles Purge between SCAN batches so draining a large
+// prefix doesn't monopolize Redis alongside live SetNX traffic.
+const purgeBatchDelay = 50 * time.Millisecond
+
+// SetNXBatch flushes every entry's SETNX (go-redis already issues it as a
+// single "SET key value NX PX ttl" command, same as SetNX) through one
+// Redis pipeline round trip, rather than len(entries) separate ones.
+// PFADD for each newly-unique key is folded into the same flush as a
+// best-effort follow-up, same as SetNX's own - a failed PFADD only drifts
+// GetCacheSize's estimate, it shouldn't fail the batch.
+func (r *RedisRepository) SetNXBatch(ctx context.Context, entries []SetNXEntry) ([]bool, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.BoolCmd, len(entries))
+	for i, e := range entries {
+		cmds[i] = pipe.SetNX(ctx, e.Key, e.Value, e.TTL)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("redis pipelined SETNX failed: %w", err)
+	}
+
+	results := make([]bool, len(entries))
+	pfaddPipe := r.client.Pipeline()
+	for i, cmd := range cmds {
+		success, err := cmd.Result()
+		if err != nil {
+			return nil, fmt.Errorf("redis pipelined SETNX failed for key %s: %w", entries[i].Key, err)
+		}
+		results[i] = success
+		if success {
+			pfaddPipe.PFAdd(ctx, hllKey, entries[i].Key)
 		}
-		count++
 	}
-	if err := iter.Err(); err != nil {
-		return 0, fmt.Errorf("redis scan failed: %w", err)
+	_, _ = pfaddPipe.Exec(ctx)
+
+	return results, nil
+}
+
+func (r *RedisRepository) Purge(ctx context.Context, prefix string) (int, error) {
+	var cursor uint64
+	var deleted int
+	for {
+		keys, next, err := r.client.Scan(ctx, cursor, prefix+"*", purgeScanCount).Result()
+		if err != nil {
+			return deleted, fmt.Errorf("redis SCAN failed: %w", err)
+		}
+		if len(keys) > 0 {
+			n, err := r.client.Del(ctx, keys...).Result()
+			if err != nil {
+				return deleted, fmt.Errorf("redis DEL failed during purge: %w", err)
+			}
+			deleted += int(n)
+		}
+		cursor = next
+		if cursor == 0 {
+			return deleted, nil
+		}
+		select {
+		case <-ctx.Done():
+			return deleted, ctx.Err()
+		case <-time.After(purgeBatchDelay):
+		}
 	}
-	return count, nil
 }
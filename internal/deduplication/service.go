@@ -4,16 +4,30 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
 	"yeti/internal/config"
 	"yeti/internal/constants"
 	"yeti/internal/logger"
+	"yeti/pkg/cel"
 	"yeti/pkg/metrics"
 	"yeti/pkg/models"
 	"yeti/pkg/tracing"
 )
 
+// l1PurgeAllKey is SubscribeInvalidations' purge-all Pub/Sub payload,
+// distinct from any real dedup key (every real key carries
+// constants.CacheKeyPrefixDedup). purgeL1 publishes it when the fields a
+// hash is computed over change, since at that point every existing L1
+// entry - on this instance and every other one sharing the channel -
+// describes a hash computed under fields that no longer apply.
+const l1PurgeAllKey = "*"
+
 func getPayloadKeys(payload map[string]interface{}) []string {
 	keys := make([]string, 0, len(payload))
 	for k := range payload {
@@ -37,6 +51,18 @@ const (
 	redisErrorHandlingAllow
 )
 
+// cacheInvalidationBroadcaster is implemented by RedisRepository (and any
+// future Repository decorator wrapping a real Redis client), checked via a
+// type assertion in NewService rather than added to Repository itself,
+// since a decorator with no Redis client of its own to publish/subscribe
+// through - e.g. CircuitBreakerRepository sitting outermost with no
+// bloom/cuckoo layer on top - has no channel to broadcast on. Mirrors
+// filterStatsReporter's established pattern below.
+type cacheInvalidationBroadcaster interface {
+	PublishInvalidation(ctx context.Context, key string) error
+	SubscribeInvalidations(ctx context.Context) (<-chan string, func() error)
+}
+
 type Service struct {
 	repo             Repository
 	hasher           *Hasher
@@ -46,9 +72,32 @@ type Service struct {
 	fieldsMu         sync.RWMutex
 	stopCacheMetrics chan struct{}
 	cancelMetricsCtx context.CancelFunc
+
+	// evaluator runs cfg.Policies' Match expressions against the full
+	// envelope, reusing the same pkg/cel.Evaluator a filtering rule's
+	// Expression runs through. nil when cfg.Policies is empty, so Process's
+	// resolvePolicy always falls through to the top-level fields (the
+	// behavior every config predating Policies already has).
+	evaluator *cel.Evaluator
+
+	// l1 is the in-process fast path Process consults before repo.SetNX;
+	// nil disables it (see config.DedupL1CacheConfig.Size / newDedupL1Cache).
+	l1 DedupCache
+	// l1TTL is the TTL newDedupL1Cache derived for l1 entries - see its
+	// doc comment for the fallback to cfg.TTLSeconds.
+	l1TTL time.Duration
+	// l1Hits/l1Attempts back metrics.SetDedupL1HitRate; see recordL1Access.
+	l1Hits     uint64
+	l1Attempts uint64
+
+	// broadcaster is repo's cacheInvalidationBroadcaster capability, if
+	// any; nil disables cross-instance invalidation, leaving every
+	// instance's l1 to expire entries on its own TTL instead.
+	broadcaster      cacheInvalidationBroadcaster
+	stopInvalidation func() error
 }
 
-func NewService(repo Repository, cfg config.DeduplicationConfig, log logger.Logger) *Service {
+func NewService(repo Repository, cfg config.DeduplicationConfig, log logger.Logger) (*Service, error) {
 	fieldsToHash := cfg.FieldsToHash
 	if len(fieldsToHash) == 0 {
 		fieldsToHash = []string{"id", "source"}
@@ -59,7 +108,7 @@ func NewService(repo Repository, cfg config.DeduplicationConfig, log logger.Logg
 
 	s := &Service{
 		repo:             repo,
-		hasher:           NewHasher(cfg.HashAlgorithm),
+		hasher:           NewHasherWithOptions(cfg.HashAlgorithm, cfg.Salt, cfg.HMACKey, cfg.CanonicalSerialization),
 		cfg:              cfg,
 		fieldsToHash:     fieldsToHash,
 		logger:           log,
@@ -67,9 +116,75 @@ func NewService(repo Repository, cfg config.DeduplicationConfig, log logger.Logg
 		cancelMetricsCtx: cancel,
 	}
 
+	if len(cfg.Policies) > 0 {
+		programCacheSize := cfg.CEL.ProgramCacheSize
+		if programCacheSize <= 0 {
+			programCacheSize = cel.DefaultProgramCacheSize
+		}
+		evaluator, err := cel.NewEvaluatorWithCacheSize(programCacheSize)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to create CEL evaluator for dedup policies: %w", err)
+		}
+		evaluator.WithEvalBudget(cfg.CEL.MaxCost, time.Duration(cfg.CEL.MaxEvalDurationMs)*time.Millisecond)
+		s.evaluator = evaluator
+	}
+
+	s.l1, s.l1TTL = newDedupL1Cache(cfg, log)
+	if broadcaster, ok := repo.(cacheInvalidationBroadcaster); ok && s.l1 != nil {
+		s.broadcaster = broadcaster
+		s.stopInvalidation = s.consumeInvalidations(ctx, broadcaster)
+	}
+
 	go s.updateCacheSizeMetrics(ctx)
 
-	return s
+	return s, nil
+}
+
+// newDedupL1Cache builds Service's L1 cache from cfg.L1Cache, returning a
+// nil DedupCache - disabling the fast path entirely - when Size <= 0 or
+// construction fails. The returned TTL is cfg.L1Cache.TTLSeconds if
+// positive, else cfg.TTLSeconds, so an L1 entry never outlives the Redis
+// key it is shadowing.
+func newDedupL1Cache(cfg config.DeduplicationConfig, log logger.Logger) (DedupCache, time.Duration) {
+	if cfg.L1Cache.Size <= 0 {
+		return nil, 0
+	}
+
+	cache, err := NewLRUDedupCache(cfg.L1Cache.Size)
+	if err != nil {
+		log.WarnwCtx(context.Background(), "Failed to create dedup L1 cache, disabling it", "error", err)
+		return nil, 0
+	}
+
+	ttlSeconds := cfg.L1Cache.TTLSeconds
+	if ttlSeconds <= 0 {
+		ttlSeconds = cfg.TTLSeconds
+	}
+	return cache, time.Duration(ttlSeconds) * time.Second
+}
+
+// consumeInvalidations subscribes to broadcaster's dedup:invalidate channel
+// and applies every message it receives to s.l1: l1PurgeAllKey clears the
+// whole cache (see purgeL1), anything else is removed as a single key. It
+// returns broadcaster's close func, which StopCacheMetricsUpdater calls via
+// s.stopInvalidation. Messages include this instance's own publishes, since
+// Redis Pub/Sub doesn't filter out a subscriber's own publishes - re-
+// applying an invalidation this instance already applied locally is a
+// harmless no-op.
+func (s *Service) consumeInvalidations(ctx context.Context, broadcaster cacheInvalidationBroadcaster) func() error {
+	keys, closeFn := broadcaster.SubscribeInvalidations(ctx)
+	go func() {
+		for key := range keys {
+			if key == l1PurgeAllKey {
+				s.l1.Purge()
+			} else {
+				s.l1.Remove(key)
+			}
+			metrics.IncDedupInvalidation("received")
+		}
+	}()
+	return closeFn
 }
 
 func (s *Service) Process(ctx context.Context, msg models.MessageEnvelope) (bool, error) {
@@ -86,8 +201,10 @@ func (s *Service) Process(ctx context.Context, msg models.MessageEnvelope) (bool
 		return false, err
 	}
 
+	policy := s.resolvePolicy(ctx, msg)
+
 	messageData := s.buildMessageData(msg)
-	fieldsToHash := s.getFieldsToHash()
+	fieldsToHash := policy.fields
 
 	s.logger.DebugwCtx(ctx, "Computing hash for message",
 		"message_id", msg.ID,
@@ -108,21 +225,39 @@ func (s *Service) Process(ctx context.Context, msg models.MessageEnvelope) (bool
 		"message_id", msg.ID,
 		"hash", hash,
 	)
+	span.SetAttributes(attribute.String("deduplication.hash", hash))
 
 	if err := ctx.Err(); err != nil {
 		return false, err
 	}
 
 	key := constants.CacheKeyPrefixDedup + hash
+
+	if s.l1 != nil {
+		if s.l1.Get(key) {
+			s.recordL1Access(true)
+			s.logger.DebugwCtx(ctx, "L1 cache hit, duplicate without Redis round trip",
+				"message_id", msg.ID,
+				"redis_key", key,
+			)
+			s.recordMetrics(0, false)
+			return false, nil
+		}
+		s.recordL1Access(false)
+	}
+
 	s.logger.DebugwCtx(ctx, "Checking Redis for duplicate",
 		"message_id", msg.ID,
 		"redis_key", key,
-		"ttl_seconds", s.cfg.TTLSeconds,
+		"ttl_seconds", policy.ttlSeconds,
 	)
 
+	checkCtx, checkSpan := tracing.GetTracer("dedup-service").Start(ctx, "deduplication.check")
+	checkSpan.SetAttributes(attribute.String("deduplication.hash", hash))
 	start := time.Now()
-	success, err := s.repo.SetNX(ctx, key, time.Now().Unix(), time.Duration(s.cfg.TTLSeconds)*time.Second)
+	success, err := s.repo.SetNX(checkCtx, key, time.Now().Unix(), time.Duration(policy.ttlSeconds)*time.Second)
 	duration := time.Since(start)
+	checkSpan.End()
 
 	if err != nil {
 		s.logger.DebugwCtx(ctx, "Redis SetNX error",
@@ -131,7 +266,12 @@ func (s *Service) Process(ctx context.Context, msg models.MessageEnvelope) (bool
 			"error", err,
 			"duration_ms", duration.Milliseconds(),
 		)
-		return s.handleRedisError(ctx, err, duration, msg.ID)
+		isUnique, procErr := s.handleRedisError(ctx, err, duration, msg.ID, policy.onRedisError)
+		if procErr != nil {
+			span.RecordError(procErr)
+			span.SetStatus(codes.Error, procErr.Error())
+		}
+		return isUnique, procErr
 	}
 
 	s.logger.DebugwCtx(ctx, "Deduplication check completed",
@@ -141,14 +281,39 @@ func (s *Service) Process(ctx context.Context, msg models.MessageEnvelope) (bool
 		"duration_ms", duration.Milliseconds(),
 	)
 
+	if s.l1 != nil && success {
+		s.l1.Set(key, s.l1TTL)
+	}
+
 	s.recordMetrics(duration, success)
 	return success, nil
 }
 
+// recordL1Access tallies one L1 lookup toward metrics.SetDedupL1HitRate,
+// the same split enrichment.serviceImpl.recordL1Access uses for
+// EnrichmentL1HitRate.
+func (s *Service) recordL1Access(hit bool) {
+	attempts := atomic.AddUint64(&s.l1Attempts, 1)
+	var hits uint64
+	if hit {
+		hits = atomic.AddUint64(&s.l1Hits, 1)
+		metrics.IncDedupL1Hit()
+	} else {
+		hits = atomic.LoadUint64(&s.l1Hits)
+		metrics.IncDedupL1Miss()
+	}
+	metrics.SetDedupL1HitRate(float64(hits) / float64(attempts))
+}
+
 func (s *Service) buildMessageData(msg models.MessageEnvelope) map[string]interface{} {
-	messageData := make(map[string]interface{}, len(msg.Payload)+2)
+	messageData := make(map[string]interface{}, len(msg.Payload)+3)
 	messageData["id"] = msg.ID
 	messageData["source"] = msg.Source
+	// payload is also exposed nested, so field expressions like
+	// "payload.user.id" can drill into it; msg.Payload's own keys stay
+	// spread at the top level too, for existing flat FieldsToHash entries
+	// (e.g. "id", "source", or a top-level payload key by itself).
+	messageData["payload"] = msg.Payload
 	for key, value := range msg.Payload {
 		messageData[key] = value
 	}
@@ -159,6 +324,63 @@ func (s *Service) buildMessageData(msg models.MessageEnvelope) map[string]interf
 	return messageData
 }
 
+// resolvedDedupPolicy is resolvePolicy's result: the fields/TTL/Redis-error
+// handling Process uses for one message, after folding the first matching
+// config.DedupPolicyConfig (if any) over the top-level defaults.
+type resolvedDedupPolicy struct {
+	fields       []string
+	ttlSeconds   int
+	onRedisError string
+}
+
+// resolvePolicy evaluates s.cfg.Policies in order against msg's full
+// envelope and returns the first match's overrides folded onto the
+// top-level defaults (s.getFieldsToHash, s.cfg.TTLSeconds,
+// s.cfg.OnRedisError); a field the matching policy leaves unset keeps its
+// default, per DedupPolicyConfig's doc comment. A policy whose Match fails
+// to evaluate is logged and skipped - same as filtering's handleEvaluationError
+// skip path - rather than denying the message, since a bad predicate is a
+// config problem, not a signal about this particular message.
+func (s *Service) resolvePolicy(ctx context.Context, msg models.MessageEnvelope) resolvedDedupPolicy {
+	resolved := resolvedDedupPolicy{
+		fields:       s.getFieldsToHash(),
+		ttlSeconds:   s.cfg.TTLSeconds,
+		onRedisError: s.cfg.OnRedisError,
+	}
+
+	if s.evaluator == nil {
+		return resolved
+	}
+
+	for _, p := range s.cfg.Policies {
+		matched, err := s.evaluator.EvaluateFilter(ctx, p.Match, msg)
+		if err != nil {
+			s.logger.WarnwCtx(ctx, "Dedup policy match expression failed to evaluate, skipping policy",
+				"message_id", msg.ID,
+				"match", p.Match,
+				"error", err,
+			)
+			continue
+		}
+		if !matched {
+			continue
+		}
+
+		if len(p.Fields) > 0 {
+			resolved.fields = p.Fields
+		}
+		if p.TTLSeconds > 0 {
+			resolved.ttlSeconds = p.TTLSeconds
+		}
+		if p.OnRedisError != "" {
+			resolved.onRedisError = p.OnRedisError
+		}
+		return resolved
+	}
+
+	return resolved
+}
+
 func (s *Service) getFieldsToHash() []string {
 	s.fieldsMu.RLock()
 	defer s.fieldsMu.RUnlock()
@@ -176,9 +398,9 @@ func (s *Service) computeHash(messageData map[string]interface{}, fieldsToHash [
 	return hash, nil
 }
 
-func (s *Service) handleRedisError(ctx context.Context, err error, duration time.Duration, msgID string) (bool, error) {
+func (s *Service) handleRedisError(ctx context.Context, err error, duration time.Duration, msgID string, onRedisError string) (bool, error) {
 	s.recordMetricsWithStatus(duration, "error")
-	status := s.getRedisErrorHandlingStatus(ctx, err, msgID)
+	status := s.getRedisErrorHandlingStatus(ctx, err, msgID, onRedisError)
 
 	if status == redisErrorHandlingAllow {
 		return true, nil
@@ -186,8 +408,8 @@ func (s *Service) handleRedisError(ctx context.Context, err error, duration time
 	return false, fmt.Errorf("redis error during dedup check for message %s: %w", msgID, err)
 }
 
-func (s *Service) getRedisErrorHandlingStatus(ctx context.Context, err error, msgID string) redisErrorHandlingStatus {
-	if s.cfg.OnRedisError == constants.FallbackAllow {
+func (s *Service) getRedisErrorHandlingStatus(ctx context.Context, err error, msgID string, onRedisError string) redisErrorHandlingStatus {
+	if onRedisError == constants.FallbackAllow {
 		metrics.FallbackUsageTotal.WithLabelValues("deduplication", "allow_on_error", err.Error()).Inc()
 		s.logger.WarnwCtx(ctx, "Redis error during dedup check, allowing message (fallback: allow)",
 			"error", err,
@@ -208,7 +430,7 @@ func (s *Service) recordMetrics(duration time.Duration, isUnique bool) {
 }
 
 func (s *Service) recordMetricsWithStatus(duration time.Duration, status string) {
-	metrics.DeduplicateMessagesTotal.WithLabelValues(status).Inc()
+	metrics.IncDedupMessage(status)
 	metrics.ObserveDedupDuration(duration, status)
 }
 
@@ -235,9 +457,40 @@ func (s *Service) UpdateFieldsToHash(fields []string) error {
 		"new_fields", fieldsCopy,
 		"fields_count", len(fieldsCopy),
 	)
+
+	// Every L1 entry was cached under a hash computed over oldFields;
+	// once fieldsToHash changes, those entries describe fields that no
+	// longer apply, on this instance and any other sharing s.broadcaster.
+	s.purgeL1(context.Background())
+
 	return nil
 }
 
+// PurgeCache drops every L1 entry and, if cross-instance invalidation is
+// wired up, broadcasts the purge over dedup:invalidate so every other
+// instance does the same. It's the operator-triggered counterpart to
+// UpdateFieldsToHash's automatic purge - both make existing L1 entries
+// describe the wrong thing.
+func (s *Service) PurgeCache(ctx context.Context) {
+	s.purgeL1(ctx)
+}
+
+func (s *Service) purgeL1(ctx context.Context) {
+	if s.l1 == nil {
+		return
+	}
+	s.l1.Purge()
+
+	if s.broadcaster == nil {
+		return
+	}
+	if err := s.broadcaster.PublishInvalidation(ctx, l1PurgeAllKey); err != nil {
+		s.logger.WarnwCtx(ctx, "Failed to broadcast dedup L1 cache purge", "error", err)
+		return
+	}
+	metrics.IncDedupInvalidation("published")
+}
+
 func (s *Service) GetFieldsToHash() []string {
 	s.fieldsMu.RLock()
 	defer s.fieldsMu.RUnlock()
@@ -247,10 +500,43 @@ func (s *Service) GetFieldsToHash() []string {
 	return fields
 }
 
+// breakerStateReporter is implemented by CircuitBreakerRepository (and any
+// future Repository decorator fronting Redis with a gobreaker circuit
+// breaker). Checked via a type assertion in BreakerState rather than added
+// to Repository itself, same rationale as filterStatsReporter below.
+type breakerStateReporter interface {
+	State() string
+}
+
+// BreakerState reports repo's circuit breaker state - "closed", "half-open",
+// or "open" - or "disabled" when repo has no circuit breaker in front of it
+// (config.CircuitBreakerConfig.Enabled is false, or repo's decorator chain
+// doesn't include CircuitBreakerRepository at all). Exposed for readiness
+// checks (see health.NewBreakerChecker) so a brownout that's tripped the
+// breaker shows up as degraded rather than silently falling back.
+func (s *Service) BreakerState() string {
+	reporter, ok := s.repo.(breakerStateReporter)
+	if !ok {
+		return "disabled"
+	}
+	return reporter.State()
+}
+
+// filterStatsReporter is implemented by CuckooRepository (and any future
+// Repository decorator that can report its own saturation). Checked via a
+// type assertion in updateCacheSizeMetrics rather than added to Repository
+// itself, since most Repository implementations - RedisRepository,
+// BloomRepository - have no comparable notion of "load factor" to report.
+type filterStatsReporter interface {
+	Stats(ctx context.Context) (CuckooStats, error)
+}
+
 func (s *Service) updateCacheSizeMetrics(ctx context.Context) {
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
 
+	reporter, _ := s.repo.(filterStatsReporter)
+
 	for {
 		select {
 		case <-ticker.C:
@@ -271,6 +557,19 @@ func (s *Service) updateCacheSizeMetrics(ctx context.Context) {
 				return
 			}
 			metrics.SetDedupCacheSize(size)
+
+			if reporter != nil {
+				if stats, err := reporter.Stats(ctx); err != nil {
+					s.logger.Debugw("Failed to get filter stats for metrics", "error", err)
+				} else {
+					metrics.SetDedupFilterLoadFactor(stats.LoadFactor)
+					metrics.SetDedupFilterCapacity(stats.Capacity)
+				}
+			}
+
+			if s.l1 != nil {
+				metrics.SetDedupL1CacheSize(s.l1.Len())
+			}
 		case <-s.stopCacheMetrics:
 			return
 		case <-ctx.Done():
@@ -284,4 +583,247 @@ func (s *Service) StopCacheMetricsUpdater() {
 		s.cancelMetricsCtx()
 	}
 	close(s.stopCacheMetrics)
+	if s.stopInvalidation != nil {
+		_ = s.stopInvalidation()
+	}
+}
+
+// ServiceStats is Stats' snapshot for the admin API's GET /admin/dedup/stats -
+// the operator-facing counterpart to the same figures updateCacheSizeMetrics
+// already feeds into Prometheus, gathered on demand instead of waiting for
+// the next 30-second tick.
+type ServiceStats struct {
+	CacheSize    int      `json:"cache_size"`
+	L1HitRate    float64  `json:"l1_hit_rate"`
+	FieldsToHash []string `json:"fields_to_hash"`
+	TTLSeconds   int      `json:"ttl_seconds"`
+	BreakerState string   `json:"breaker_state"`
+}
+
+// Stats gathers ServiceStats, including a live GetCacheSize round trip -
+// call it from an admin request handler, not a hot path.
+func (s *Service) Stats(ctx context.Context) (ServiceStats, error) {
+	size, err := s.repo.GetCacheSize(ctx, constants.CacheKeyPrefixDedup)
+	if err != nil {
+		return ServiceStats{}, fmt.Errorf("failed to get cache size: %w", err)
+	}
+
+	attempts := atomic.LoadUint64(&s.l1Attempts)
+	hits := atomic.LoadUint64(&s.l1Hits)
+	var hitRate float64
+	if attempts > 0 {
+		hitRate = float64(hits) / float64(attempts)
+	}
+
+	return ServiceStats{
+		CacheSize:    size,
+		L1HitRate:    hitRate,
+		FieldsToHash: s.GetFieldsToHash(),
+		TTLSeconds:   s.cfg.TTLSeconds,
+		BreakerState: s.BreakerState(),
+	}, nil
+}
+
+// HashForLookup computes the dedup key hash a message with id/source would
+// get, for the admin API's GET /admin/dedup/lookup?id=…&source=… form. It
+// hashes over id/source alone, with no payload, so it only matches
+// Process's own hash when fields_to_hash (or the matching Policies entry)
+// hashes over nothing but id/source - the common case, but not guaranteed;
+// callers hashing deeper payload fields should look up by ?hash= instead.
+func (s *Service) HashForLookup(id, source string) (string, error) {
+	msg := models.MessageEnvelope{ID: id, Source: source}
+	policy := s.resolvePolicy(context.Background(), msg)
+	return s.computeHash(s.buildMessageData(msg), policy.fields, id)
+}
+
+// LookupEntry reports whether constants.CacheKeyPrefixDedup+hash exists in
+// repo and its remaining TTL, for the admin API's GET /admin/dedup/lookup.
+func (s *Service) LookupEntry(ctx context.Context, hash string) (exists bool, ttl time.Duration, err error) {
+	return s.repo.Lookup(ctx, constants.CacheKeyPrefixDedup+hash)
+}
+
+// DeleteEntry drops constants.CacheKeyPrefixDedup+hash from repo and this
+// instance's L1 (broadcasting the invalidation, if wired up), for the admin
+// API's DELETE /admin/dedup/entry - the operator-triggered un-dedup that,
+// until now, UpdateFieldsToHash had no counterpart for. A
+// BloomRepository/CuckooRepository fast path may still report the key as
+// seen until its own rotation window passes - see those repositories'
+// Delete doc comments.
+func (s *Service) DeleteEntry(ctx context.Context, hash string) (bool, error) {
+	key := constants.CacheKeyPrefixDedup + hash
+	existed, err := s.repo.Delete(ctx, key)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete dedup entry: %w", err)
+	}
+
+	if s.l1 != nil {
+		s.l1.Remove(key)
+	}
+	if s.broadcaster != nil {
+		if err := s.broadcaster.PublishInvalidation(ctx, key); err != nil {
+			s.logger.WarnwCtx(ctx, "Failed to broadcast dedup entry invalidation", "error", err)
+		} else {
+			metrics.IncDedupInvalidation("published")
+		}
+	}
+
+	return existed, nil
+}
+
+// ExtendEntryTTL resets constants.CacheKeyPrefixDedup+hash's TTL to
+// ttlSeconds, for the admin API's POST /admin/dedup/extend.
+func (s *Service) ExtendEntryTTL(ctx context.Context, hash string, ttlSeconds int) (bool, error) {
+	return s.repo.ExtendTTL(ctx, constants.CacheKeyPrefixDedup+hash, time.Duration(ttlSeconds)*time.Second)
+}
+
+// PurgeEntries deletes every repo key under constants.CacheKeyPrefixDedup+prefix
+// and, like PurgeCache, drops this instance's whole L1 and broadcasts the
+// purge - L1 has no way to selectively drop just the purged prefix's
+// entries, so a prefix purge is as disruptive to L1 as a full one.
+func (s *Service) PurgeEntries(ctx context.Context, prefix string) (int, error) {
+	deleted, err := s.repo.Purge(ctx, constants.CacheKeyPrefixDedup+prefix)
+	if err != nil {
+		return deleted, fmt.Errorf("failed to purge dedup entries: %w", err)
+	}
+	s.purgeL1(ctx)
+	return deleted, nil
+}
+
+// batchItem is one ProcessBatch message's working state between the
+// concurrent hashing phase and the pipelined flush.
+type batchItem struct {
+	msg     models.MessageEnvelope
+	policy  resolvedDedupPolicy
+	key     string
+	hashErr error
+}
+
+// ProcessBatch is Process's pipelined counterpart for high-throughput batch
+// consumers: every message's hash is computed concurrently, same as N
+// independent Process calls would, but the resulting SETNX checks flush
+// through a single Repository.SetNXBatch round trip instead of one SetNX
+// per message. Results preserve msgs' order.
+//
+// A message already L1-cached as seen is resolved locally, same as Process,
+// and never reaches the pipelined flush. Context cancellation is honored
+// both after the hashing phase and before the flush, so a caller that
+// cancels while hashing is still running doesn't pay for a pipeline call
+// whose results it no longer wants.
+//
+// If the flush itself fails, every message still awaiting a verdict is
+// resolved together by the first such message's resolved OnRedisError
+// policy (see handleRedisError) - the whole flush is one Redis round trip,
+// so there's no finer-grained outcome to report per message. A caller
+// wiring this into a batch consumer that falls back to processing
+// message-by-message on failure (see broker.KafkaConsumer.ConsumeBatch) is
+// unaffected: that fallback already expects a batch-level verdict to turn
+// into a per-message retry.
+func (s *Service) ProcessBatch(ctx context.Context, msgs []models.MessageEnvelope) ([]bool, error) {
+	if len(msgs) == 0 {
+		return nil, nil
+	}
+
+	batchCtx, batchSpan := tracing.GetTracer("dedup-service").Start(ctx, "deduplication.process_batch")
+	batchSpan.SetAttributes(attribute.Int("deduplication.batch_size", len(msgs)))
+	defer batchSpan.End()
+
+	items := make([]batchItem, len(msgs))
+	var wg sync.WaitGroup
+	for i, msg := range msgs {
+		wg.Add(1)
+		go func(i int, msg models.MessageEnvelope) {
+			defer wg.Done()
+
+			// A fresh background context, not batchCtx, so one message's span
+			// doesn't nest under - and get cut short by - the batch span;
+			// the link back to it is what ties the two together instead.
+			_, span := tracing.GetTracer("dedup-service").Start(context.Background(), "deduplication.process_batch.item",
+				trace.WithLinks(trace.Link{SpanContext: batchSpan.SpanContext()}))
+			defer span.End()
+
+			policy := s.resolvePolicy(batchCtx, msg)
+			hash, err := s.computeHash(s.buildMessageData(msg), policy.fields, msg.ID)
+			if err != nil {
+				items[i] = batchItem{msg: msg, policy: policy, hashErr: err}
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				return
+			}
+
+			key := constants.CacheKeyPrefixDedup + hash
+			items[i] = batchItem{msg: msg, policy: policy, key: key}
+			span.SetAttributes(attribute.String("deduplication.hash", hash))
+		}(i, msg)
+	}
+	wg.Wait()
+
+	if err := batchCtx.Err(); err != nil {
+		return nil, err
+	}
+
+	results := make([]bool, len(msgs))
+	entries := make([]SetNXEntry, 0, len(msgs))
+	pending := make([]int, 0, len(msgs))
+	for i := range items {
+		if items[i].hashErr != nil {
+			return nil, fmt.Errorf("failed to compute hash for message %s: %w", items[i].msg.ID, items[i].hashErr)
+		}
+
+		hit := s.l1 != nil && s.l1.Get(items[i].key)
+		if s.l1 != nil {
+			s.recordL1Access(hit)
+		}
+		if hit {
+			s.recordMetrics(0, false)
+			continue
+		}
+
+		entries = append(entries, SetNXEntry{
+			Key:   items[i].key,
+			Value: time.Now().Unix(),
+			TTL:   time.Duration(items[i].policy.ttlSeconds) * time.Second,
+		})
+		pending = append(pending, i)
+	}
+
+	if len(entries) == 0 {
+		return results, nil
+	}
+
+	if err := batchCtx.Err(); err != nil {
+		return nil, err
+	}
+
+	flushCtx, flushSpan := tracing.GetTracer("dedup-service").Start(batchCtx, "deduplication.check_batch")
+	flushSpan.SetAttributes(attribute.Int("deduplication.batch_size", len(entries)))
+	start := time.Now()
+	flushed, err := s.repo.SetNXBatch(flushCtx, entries)
+	duration := time.Since(start)
+	flushSpan.End()
+
+	if err != nil {
+		first := items[pending[0]]
+		isUnique, procErr := s.handleRedisError(ctx, err, duration, first.msg.ID, first.policy.onRedisError)
+		if procErr != nil {
+			batchSpan.RecordError(procErr)
+			batchSpan.SetStatus(codes.Error, procErr.Error())
+			return nil, procErr
+		}
+		for _, i := range pending {
+			results[i] = isUnique
+		}
+		return results, nil
+	}
+
+	perEntryDuration := duration / time.Duration(len(entries))
+	for j, i := range pending {
+		success := flushed[j]
+		results[i] = success
+		if s.l1 != nil && success {
+			s.l1.Set(items[i].key, s.l1TTL)
+		}
+		s.recordMetrics(perEntryDuration, success)
+	}
+
+	return results, nil
 }
@@ -0,0 +1,37 @@
+package enrichment
+
+import (
+	"context"
+	"fmt"
+
+	"yeti/internal/constants"
+)
+
+// InvalidateEnrichmentKey drops rule's cached source data for fieldValue
+// from both s.l1 and the Redis L2 cache, computing the same cache key
+// fetchSourceData does. A singleflight call already in flight for this key
+// is unaffected - it will still populate the caches with whatever it
+// fetched, so a caller racing a write against an in-flight fetch should
+// expect at most one more stale read before the next Process call.
+func (s *serviceImpl) InvalidateEnrichmentKey(ctx context.Context, rule Rule, fieldValue interface{}) error {
+	cacheKey := fmt.Sprintf("%s%s:%v", constants.CacheKeyPrefixEnrich, rule.ID, fieldValue)
+
+	if s.l1 != nil {
+		s.l1.Remove(cacheKey)
+	}
+
+	if s.cache == nil {
+		return nil
+	}
+
+	if err := s.cache.Del(ctx, cacheKey).Err(); err != nil {
+		s.logger.WarnwCtx(ctx, "Failed to invalidate enrichment cache key",
+			"error", err,
+			"rule_id", rule.ID,
+			"cache_key", cacheKey,
+		)
+		return fmt.Errorf("invalidate enrichment key for rule %s: %w", rule.ID, err)
+	}
+
+	return nil
+}
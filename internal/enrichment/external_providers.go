@@ -0,0 +1,71 @@
+package enrichment
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"yeti/internal/enrichment/provider"
+	"yeti/internal/logger"
+)
+
+// externalProviderDoc mirrors the shape management.ExternalProvider is
+// stored as in the shared "enrichment_providers" collection. enrichment-service
+// only ever reads this collection, so it keeps its own trimmed decode target
+// instead of importing the management package for it.
+type externalProviderDoc struct {
+	Name                string `bson:"name"`
+	SourceType          string `bson:"source_type"`
+	Address             string `bson:"address"`
+	TLSCert             string `bson:"tls_cert"`
+	LoadBalancingPolicy string `bson:"load_balancing_policy"`
+}
+
+// DiscoverExternalProviders reads every provider registered through the
+// management service's POST /api/v1/providers, dials each one, and returns
+// a Registry with a warm connection per provider so Process never pays
+// dial latency on the hot path. A provider that fails to dial is logged and
+// skipped rather than failing startup: one misconfigured plugin shouldn't
+// take down enrichment for every other rule.
+func DiscoverExternalProviders(ctx context.Context, db *mongo.Database, log logger.Logger) (*provider.Registry, error) {
+	cursor, err := db.Collection("enrichment_providers").Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list external providers: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []externalProviderDoc
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("failed to decode external providers: %w", err)
+	}
+
+	registry := provider.NewRegistry()
+	for _, doc := range docs {
+		p, err := provider.NewGRPCProvider(provider.GRPCProviderConfig{
+			Name:                doc.Name,
+			Address:             doc.Address,
+			TLSCert:             doc.TLSCert,
+			LoadBalancingPolicy: doc.LoadBalancingPolicy,
+		})
+		if err != nil {
+			log.WarnwCtx(ctx, "Failed to dial external enrichment provider, skipping",
+				"provider_name", doc.Name,
+				"source_type", doc.SourceType,
+				"address", doc.Address,
+				"error", err,
+			)
+			continue
+		}
+
+		registry.Register(doc.SourceType, p)
+		log.InfowCtx(ctx, "Discovered external enrichment provider",
+			"provider_name", doc.Name,
+			"source_type", doc.SourceType,
+			"address", doc.Address,
+		)
+	}
+
+	return registry, nil
+}
@@ -0,0 +1,70 @@
+package enrichment
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"yeti/internal/enrichment/provider"
+)
+
+// HealthzHandler reports every registered source's health, as gathered by
+// Service.ProviderHealth. It responds 503 if any source's circuit breaker
+// is open, so it can double as a readiness signal for degraded deployments
+// without paging on a source that's merely slow.
+func HealthzHandler(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		health := svc.ProviderHealth(r.Context())
+
+		statusCode := http.StatusOK
+		for _, h := range health {
+			if h.CircuitState == provider.CircuitStateOpen {
+				statusCode = http.StatusServiceUnavailable
+				break
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		_ = json.NewEncoder(w).Encode(health)
+	}
+}
+
+// RuleHealthzHandler is HealthzHandler's per-rule counterpart, for rules
+// with their own CircuitBreaker override (see Rule.CircuitBreaker):
+// mounted at pathPrefix (e.g. "/healthz/enrichment/rules"), it lists every
+// such rule's health, or - with an "/:id" suffix - just one rule's,
+// 404ing if that rule has no override currently tracked.
+//
+// This is process-local to whichever enrichment-service instance serves
+// the request: it reports the breaker state that instance has observed
+// handling live traffic, not an aggregate across a replica set, and isn't
+// reachable from the management service's own API, since management runs
+// in a separate process with no provider traffic of its own (its dry-run
+// evaluator intentionally bypasses circuit breakers - see
+// service.evaluateEnrichmentRule).
+func RuleHealthzHandler(svc Service, pathPrefix string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ruleID := strings.TrimPrefix(r.URL.Path, pathPrefix)
+		ruleID = strings.Trim(ruleID, "/")
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if ruleID == "" {
+			_ = json.NewEncoder(w).Encode(svc.AllRuleHealth(r.Context()))
+			return
+		}
+
+		health, ok := svc.RuleHealth(r.Context(), ruleID)
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "no circuit breaker override tracked for rule " + ruleID})
+			return
+		}
+
+		if health.CircuitState == provider.CircuitStateOpen {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(health)
+	}
+}
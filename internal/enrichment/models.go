@@ -3,40 +3,151 @@ package enrichment
 import "time"
 
 type Rule struct {
-	ID              string           `bson:"_id,omitempty"`
-	Name            string           `bson:"name"`
-	FieldToEnrich   string           `bson:"field_to_enrich"`
-	SourceType      string           `bson:"source_type"` // api, database, cache
-	SourceConfig    SourceConfig     `bson:"source_config"`
-	Transformations []Transformation `bson:"transformations"`
-	CacheTTLSeconds int              `bson:"cache_ttl_seconds"`
-	ErrorHandling   string           `bson:"error_handling"` // skip_field, skip_rule, fail
-	FallbackValue   interface{}      `bson:"fallback_value"`
-	Priority        int              `bson:"priority"`
-	Enabled         bool             `bson:"enabled"`
-	CreatedAt       time.Time        `bson:"created_at"`
-	UpdatedAt       time.Time        `bson:"updated_at"`
+	ID            string       `json:"id,omitempty" bson:"_id,omitempty"`
+	Name          string       `json:"name" bson:"name"`
+	FieldToEnrich string       `json:"field_to_enrich" bson:"field_to_enrich"`
+	SourceType    string       `json:"source_type" bson:"source_type"` // api, database, cache
+	SourceConfig  SourceConfig `json:"source_config" bson:"source_config"`
+	// Condition, if set, is a CEL expression evaluated against the
+	// incoming message (the same event variables EvaluateFilter's rules
+	// use: id, source, timestamp, payload, metadata); the rule only runs
+	// for messages where it evaluates true. Empty runs the rule
+	// unconditionally, as before this field existed. An expression that
+	// fails to compile or evaluate is treated as false (rule skipped),
+	// same as a false result.
+	Condition       string           `json:"condition,omitempty" bson:"condition,omitempty"`
+	Transformations []Transformation `json:"transformations" bson:"transformations"`
+	CacheTTLSeconds int              `json:"cache_ttl_seconds" bson:"cache_ttl_seconds"`
+	// NegativeCacheTTLSeconds, if set, caches a provider "not found" result
+	// for this rule separately (and usually much more briefly than
+	// CacheTTLSeconds), so a field value that repeatedly misses doesn't
+	// re-hit a slow provider on every message during a traffic spike. Zero
+	// disables negative caching.
+	NegativeCacheTTLSeconds int         `json:"negative_cache_ttl_seconds,omitempty" bson:"negative_cache_ttl_seconds,omitempty"`
+	ErrorHandling           string      `json:"error_handling" bson:"error_handling"` // skip_field, skip_rule, fail
+	FallbackValue           interface{} `json:"fallback_value,omitempty" bson:"fallback_value"`
+	Priority                int         `json:"priority" bson:"priority"`
+	Enabled                 bool        `json:"enabled" bson:"enabled"`
+	// RateLimitPerSecond caps how many provider calls this rule can make
+	// per second; zero (the default) leaves the rule unthrottled. See
+	// ruleThrottle.
+	RateLimitPerSecond int `json:"rate_limit_per_second,omitempty" bson:"rate_limit_per_second,omitempty"`
+	// MaxConcurrency caps how many of this rule's provider calls may be
+	// in flight at once; zero leaves the rule unbounded. Adaptive
+	// throttling shrinks the effective limit below this ceiling when the
+	// provider looks unhealthy and grows it back additively on success
+	// streaks, so MaxConcurrency is an upper bound rather than a fixed
+	// value.
+	MaxConcurrency int `json:"max_concurrency,omitempty" bson:"max_concurrency,omitempty"`
+	// CircuitBreaker and Retry, when set, override this rule's source
+	// type's service-level circuit-breaker/retry defaults (the cbConfig/
+	// retryCfg a serviceImpl was constructed with) with thresholds scoped
+	// to just this rule, so one chronically flaky rule can trip its own
+	// breaker sooner, or retry harder, without changing every other rule
+	// sharing its source type. Nil uses the source-level default exactly
+	// as before these fields existed.
+	CircuitBreaker *RuleCircuitBreakerConfig `json:"circuit_breaker,omitempty" bson:"circuit_breaker,omitempty"`
+	Retry          *RuleRetryConfig          `json:"retry,omitempty" bson:"retry,omitempty"`
+	CreatedAt      time.Time                 `json:"created_at" bson:"created_at"`
+	UpdatedAt      time.Time                 `json:"updated_at" bson:"updated_at"`
+}
+
+// RuleCircuitBreakerConfig is a per-rule circuit breaker override. See
+// config.CircuitBreakerConfig for the service-level fields it mirrors:
+// FailureRatio/MinRequests decide when the breaker trips, Timeout is how
+// long it stays open before probing, and MaxRequests caps how many
+// requests a half-open breaker lets through before deciding whether to
+// close again.
+type RuleCircuitBreakerConfig struct {
+	FailureRatio float64       `json:"failure_ratio,omitempty" bson:"failure_ratio,omitempty"`
+	MinRequests  uint32        `json:"min_requests,omitempty" bson:"min_requests,omitempty"`
+	Timeout      time.Duration `json:"timeout,omitempty" bson:"timeout,omitempty"`
+	MaxRequests  uint32        `json:"max_requests,omitempty" bson:"max_requests,omitempty"`
+	// Interval is the closed-state rolling window gobreaker.Settings.Interval
+	// resets ReadyToTrip's failure counts over; zero reuses
+	// circuitbreaker.DefaultConfig's interval, same as before this field
+	// existed.
+	Interval time.Duration `json:"interval,omitempty" bson:"interval,omitempty"`
+}
+
+// RuleRetryConfig is a per-rule retry override; see config.RetryConfig for
+// the service-level fields it mirrors.
+type RuleRetryConfig struct {
+	MaxAttempts     int           `json:"max_attempts,omitempty" bson:"max_attempts,omitempty"`
+	InitialInterval time.Duration `json:"initial_interval,omitempty" bson:"initial_interval,omitempty"`
+	MaxInterval     time.Duration `json:"max_interval,omitempty" bson:"max_interval,omitempty"`
+	Multiplier      float64       `json:"multiplier,omitempty" bson:"multiplier,omitempty"`
 }
 
 type SourceConfig struct {
-	URL        string            `bson:"url,omitempty"`
-	Method     string            `bson:"method,omitempty"`
-	Headers    map[string]string `bson:"headers,omitempty"`
-	TimeoutMs  int               `bson:"timeout_ms,omitempty"`
-	RetryCount int               `bson:"retry_count,omitempty"`
-
-	Database   string                 `bson:"database,omitempty"`
-	Collection string                 `bson:"collection,omitempty"`
-	Query      map[string]interface{} `bson:"query,omitempty"`
-	Field      string                 `bson:"field,omitempty"`
-
-	KeyPattern string `bson:"key_pattern,omitempty"`
-	CacheType  string `bson:"cache_type,omitempty"`
+	URL        string            `json:"url,omitempty" bson:"url,omitempty"`
+	Method     string            `json:"method,omitempty" bson:"method,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty" bson:"headers,omitempty"`
+	TimeoutMs  int               `json:"timeout_ms,omitempty" bson:"timeout_ms,omitempty"`
+	RetryCount int               `json:"retry_count,omitempty" bson:"retry_count,omitempty"`
+	// BatchURL is the endpoint provider.APIProvider.FetchBatch posts
+	// field values to. Empty means the API source has no native batch
+	// endpoint, so FetchBatch falls back to one Fetch per value.
+	BatchURL string `json:"batch_url,omitempty" bson:"batch_url,omitempty"`
+
+	Database   string                 `json:"database,omitempty" bson:"database,omitempty"`
+	Collection string                 `json:"collection,omitempty" bson:"collection,omitempty"`
+	Query      map[string]interface{} `json:"query,omitempty" bson:"query,omitempty"`
+	// Field may contain "{{ expr: <cel> }}" placeholders, rendered against
+	// the triggering message before the MongoDB provider sees them, in
+	// addition to the plain field name case. See renderRuleSourceConfig.
+	Field string `json:"field,omitempty" bson:"field,omitempty"`
+
+	// KeyPattern is the CacheProvider key template; besides the existing
+	// {value}/{field_value} substitution it may contain "{{ expr: <cel> }}"
+	// placeholders rendered against the triggering message. See
+	// renderRuleSourceConfig.
+	KeyPattern string `json:"key_pattern,omitempty" bson:"key_pattern,omitempty"`
+	CacheType  string `json:"cache_type,omitempty" bson:"cache_type,omitempty"`
+
+	// QueryParams, Body and BodyContentType are SourceTypeHTTP-only; see
+	// provider.SourceConfig for the {query:K}/{header:K} templating they
+	// support.
+	QueryParams     map[string]string `json:"query_params,omitempty" bson:"query_params,omitempty"`
+	Body            string            `json:"body,omitempty" bson:"body,omitempty"`
+	BodyContentType string            `json:"body_content_type,omitempty" bson:"body_content_type,omitempty"`
+
+	// AuthType, AuthToken, AuthUsername and AuthPassword configure
+	// SourceTypeHTTP authentication ("bearer", "basic", or "mtls"); values
+	// may be "${scheme:ref}" secret placeholders. See provider.SourceConfig.
+	AuthType     string `json:"auth_type,omitempty" bson:"auth_type,omitempty"`
+	AuthToken    string `json:"auth_token,omitempty" bson:"auth_token,omitempty"`
+	AuthUsername string `json:"auth_username,omitempty" bson:"auth_username,omitempty"`
+	AuthPassword string `json:"auth_password,omitempty" bson:"auth_password,omitempty"`
+
+	// ClientCertFile, ClientKeyFile, CAFile and ServerName configure mTLS
+	// for SourceTypeHTTP (AuthType "mtls") or the dial credentials for
+	// SourceTypeGRPC.
+	ClientCertFile string `json:"client_cert_file,omitempty" bson:"client_cert_file,omitempty"`
+	ClientKeyFile  string `json:"client_key_file,omitempty" bson:"client_key_file,omitempty"`
+	CAFile         string `json:"ca_file,omitempty" bson:"ca_file,omitempty"`
+	ServerName     string `json:"server_name,omitempty" bson:"server_name,omitempty"`
+
+	// ResponseJSONPath extracts a nested value from a SourceTypeHTTP
+	// response before Transformations run. See provider.SourceConfig.
+	ResponseJSONPath string `json:"response_json_path,omitempty" bson:"response_json_path,omitempty"`
+
+	// Address is the gRPC dial target for a SourceTypeGRPC rule.
+	Address string `json:"address,omitempty" bson:"address,omitempty"`
 }
 
 type Transformation struct {
-	SourcePath  string      `bson:"source_path"`
-	TargetField string      `bson:"target_field"`
-	Expression  string      `bson:"expression"`
-	Default     interface{} `bson:"default"`
+	// SourcePath selects a field out of the fetched sourceData: "." for
+	// the whole map, a top-level key otherwise. It may instead be a full
+	// "{{ expr: <cel> }}" placeholder, evaluated against sourceData (and
+	// the triggering message) in place of the plain key lookup - see
+	// renderFullExpression.
+	SourcePath  string `json:"source_path" bson:"source_path"`
+	TargetField string `json:"target_field" bson:"target_field"`
+	Expression  string `json:"expression" bson:"expression"`
+	// Default is used when SourcePath isn't found in sourceData. A
+	// "{{ expr: <cel> }}" string is evaluated against the triggering
+	// message (sourceData is unavailable - the field it would have come
+	// from is exactly what's missing) instead of being used literally.
+	Default interface{} `json:"default,omitempty" bson:"default"`
 }
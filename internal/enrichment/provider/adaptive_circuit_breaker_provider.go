@@ -0,0 +1,102 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"yeti/internal/config"
+	"yeti/pkg/circuitbreaker"
+)
+
+// AdaptiveCircuitBreakerProvider decorates a DataProvider with
+// circuitbreaker.AdaptiveWrapper instead of the coarse counter-based
+// CircuitBreakerProvider, so a source's own endpoints (pooled Redis
+// nodes, Mongo replicas, HTTP hosts behind a single logical provider) are
+// tracked and ejected individually rather than all trip-or-not together.
+// DataProvider.Fetch has no notion of which concrete endpoint it used, so
+// every call here is keyed on name; a provider able to report the
+// specific host/replica it hit can key per-call instead by wrapping
+// AdaptiveWrapper directly.
+type AdaptiveCircuitBreakerProvider struct {
+	provider DataProvider
+	wrapper  *circuitbreaker.AdaptiveWrapper
+	name     string
+	onOpen   func(ctx context.Context) (map[string]interface{}, error)
+}
+
+func NewAdaptiveCircuitBreakerProvider(p DataProvider, name string, cfg circuitbreaker.AdaptiveConfig) *AdaptiveCircuitBreakerProvider {
+	return &AdaptiveCircuitBreakerProvider{
+		provider: p,
+		wrapper:  circuitbreaker.NewAdaptiveWrapper(cfg),
+		name:     name,
+	}
+}
+
+// WithOnOpen sets a fallback invoked instead of an ejected-endpoint error,
+// mirroring CircuitBreakerProvider.WithOnOpen.
+func (p *AdaptiveCircuitBreakerProvider) WithOnOpen(onOpen func(ctx context.Context) (map[string]interface{}, error)) *AdaptiveCircuitBreakerProvider {
+	p.onOpen = onOpen
+	return p
+}
+
+func (p *AdaptiveCircuitBreakerProvider) Fetch(ctx context.Context, config SourceConfig, fieldValue interface{}) (map[string]interface{}, error) {
+	result, err := p.wrapper.Execute(ctx, p.name, func() (interface{}, error) {
+		return p.provider.Fetch(ctx, config, fieldValue)
+	})
+	if err != nil {
+		if errors.Is(err, circuitbreaker.ErrEndpointEjected) && p.onOpen != nil {
+			return p.onOpen(ctx)
+		}
+		return nil, err
+	}
+
+	data, ok := result.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("adaptive circuit breaker %s: unexpected result type", p.name)
+	}
+	return data, nil
+}
+
+// FetchBatch runs FetchBatch against the wrapped provider through the same
+// AdaptiveWrapper as Fetch, keyed on name like every other call here.
+func (p *AdaptiveCircuitBreakerProvider) FetchBatch(ctx context.Context, config SourceConfig, fieldValues []interface{}) (map[interface{}]map[string]interface{}, error) {
+	result, err := p.wrapper.Execute(ctx, p.name, func() (interface{}, error) {
+		return FetchBatch(ctx, p.provider, config, fieldValues)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok := result.(map[interface{}]map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("adaptive circuit breaker %s: unexpected result type", p.name)
+	}
+	return data, nil
+}
+
+func adaptiveConfigFrom(cfg config.AdaptiveCircuitBreakerConfig, name string) circuitbreaker.AdaptiveConfig {
+	adaptiveCfg := circuitbreaker.DefaultAdaptiveConfig(name)
+	if cfg.BucketInterval > 0 {
+		adaptiveCfg.BucketInterval = cfg.BucketInterval
+	}
+	if cfg.BucketCount > 0 {
+		adaptiveCfg.BucketCount = cfg.BucketCount
+	}
+	if cfg.MinRequestVolume > 0 {
+		adaptiveCfg.MinRequestVolume = cfg.MinRequestVolume
+	}
+	if cfg.ErrorRateThreshold > 0 {
+		adaptiveCfg.ErrorRateThreshold = cfg.ErrorRateThreshold
+	}
+	if cfg.LatencyP99Deviation > 0 {
+		adaptiveCfg.LatencyP99Deviation = cfg.LatencyP99Deviation
+	}
+	if cfg.EjectionBaseTimeout > 0 {
+		adaptiveCfg.EjectionBaseTimeout = cfg.EjectionBaseTimeout
+	}
+	if cfg.EjectionMaxTimeout > 0 {
+		adaptiveCfg.EjectionMaxTimeout = cfg.EjectionMaxTimeout
+	}
+	return adaptiveCfg
+}
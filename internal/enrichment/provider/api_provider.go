@@ -1,6 +1,7 @@
 package provider
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -52,3 +53,58 @@ func (p *APIProvider) Fetch(ctx context.Context, config SourceConfig, fieldValue
 
 	return result, nil
 }
+
+// FetchBatch posts fieldValues to config.BatchURL in one request and
+// expects a JSON array of result objects back, each matched to its
+// fieldValue via config.Field (or "id" if unset). If BatchURL isn't
+// configured, it falls back to one Fetch per value.
+func (p *APIProvider) FetchBatch(ctx context.Context, config SourceConfig, fieldValues []interface{}) (map[interface{}]map[string]interface{}, error) {
+	if config.BatchURL == "" {
+		return fetchBatchFallback(ctx, p, config, fieldValues)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"values": fieldValuesToStrings(fieldValues)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal batch request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, config.BatchURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create batch request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range config.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("api batch request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < constants.HTTPStatusOKMin || resp.StatusCode >= constants.HTTPStatusOKMax {
+		return nil, fmt.Errorf("api batch returned status: %d", resp.StatusCode)
+	}
+
+	var results []map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return nil, fmt.Errorf("failed to decode batch response: %w", err)
+	}
+
+	matchField := config.Field
+	if matchField == "" {
+		matchField = "id"
+	}
+
+	result := make(map[interface{}]map[string]interface{}, len(results))
+	for _, r := range results {
+		fv, matched := matchFieldValue(fieldValues, r[matchField])
+		if !matched {
+			continue
+		}
+		result[fv] = r
+	}
+
+	return result, nil
+}
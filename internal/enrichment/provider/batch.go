@@ -0,0 +1,84 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// BatchDataProvider is implemented by providers that can satisfy many field
+// values in a single round trip (a SQL `WHERE field IN (...)`, a MongoDB
+// `$in`, or a bulk HTTP endpoint), rather than issuing one Fetch per value.
+// A provider that doesn't implement it still works through FetchBatch,
+// which fans out to Fetch instead.
+type BatchDataProvider interface {
+	FetchBatch(ctx context.Context, config SourceConfig, fieldValues []interface{}) (map[interface{}]map[string]interface{}, error)
+}
+
+// FetchBatch resolves fieldValues against p in one call if p implements
+// BatchDataProvider, or by fanning out to p.Fetch concurrently otherwise.
+// The returned map omits any fieldValue whose fetch failed; callers decide
+// how to handle a missing entry the same way they already handle a single
+// Fetch error.
+func FetchBatch(ctx context.Context, p DataProvider, config SourceConfig, fieldValues []interface{}) (map[interface{}]map[string]interface{}, error) {
+	if bp, ok := p.(BatchDataProvider); ok {
+		return bp.FetchBatch(ctx, config, fieldValues)
+	}
+	return fetchBatchFallback(ctx, p, config, fieldValues)
+}
+
+// fetchBatchFallback is FetchBatch's default implementation for providers
+// with no native bulk operation: one Fetch per fieldValue, run
+// concurrently so the fallback doesn't serialize what a batch call would
+// have done in parallel at the network layer anyway.
+func fetchBatchFallback(ctx context.Context, p DataProvider, config SourceConfig, fieldValues []interface{}) (map[interface{}]map[string]interface{}, error) {
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		result = make(map[interface{}]map[string]interface{}, len(fieldValues))
+	)
+
+	for _, fv := range fieldValues {
+		wg.Add(1)
+		go func(fv interface{}) {
+			defer wg.Done()
+
+			data, err := p.Fetch(ctx, config, fv)
+			if err != nil {
+				return
+			}
+
+			mu.Lock()
+			result[fv] = data
+			mu.Unlock()
+		}(fv)
+	}
+
+	wg.Wait()
+	return result, nil
+}
+
+func fieldValuesToStrings(fieldValues []interface{}) []string {
+	out := make([]string, len(fieldValues))
+	for i, fv := range fieldValues {
+		out[i] = fmt.Sprintf("%v", fv)
+	}
+	return out
+}
+
+// matchFieldValue finds the member of fieldValues that produced value (a
+// row/document field read back from a driver, compared by string
+// representation since the driver's concrete type rarely matches the
+// caller's, e.g. an int64 payload value vs. a driver-decoded int32). It
+// returns the original fieldValues entry so the result map stays keyed by
+// values the caller already holds a reference to, instead of a
+// driver-specific type it would have to re-derive.
+func matchFieldValue(fieldValues []interface{}, value interface{}) (interface{}, bool) {
+	target := fmt.Sprintf("%v", value)
+	for _, fv := range fieldValues {
+		if fmt.Sprintf("%v", fv) == target {
+			return fv, true
+		}
+	}
+	return nil, false
+}
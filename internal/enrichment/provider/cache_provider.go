@@ -45,3 +45,40 @@ func (p *CacheProvider) Fetch(ctx context.Context, config SourceConfig, fieldVal
 
 	return result, nil
 }
+
+// FetchBatch resolves every value in fieldValues with a single Redis MGET
+// instead of one GET per value.
+func (p *CacheProvider) FetchBatch(ctx context.Context, config SourceConfig, fieldValues []interface{}) (map[interface{}]map[string]interface{}, error) {
+	if config.KeyPattern == "" {
+		return nil, fmt.Errorf("key_pattern is required for cache provider")
+	}
+
+	keys := make([]string, len(fieldValues))
+	for i, fv := range fieldValues {
+		key := config.KeyPattern
+		key = strings.ReplaceAll(key, "{field_value}", fmt.Sprintf("%v", fv))
+		key = strings.ReplaceAll(key, "{value}", fmt.Sprintf("%v", fv))
+		keys[i] = key
+	}
+
+	vals, err := p.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("redis mget failed: %w", err)
+	}
+
+	result := make(map[interface{}]map[string]interface{}, len(fieldValues))
+	for i, val := range vals {
+		str, ok := val.(string)
+		if !ok {
+			continue
+		}
+
+		var data map[string]interface{}
+		if err := json.Unmarshal([]byte(str), &data); err != nil {
+			data = map[string]interface{}{"value": str}
+		}
+		result[fieldValues[i]] = data
+	}
+
+	return result, nil
+}
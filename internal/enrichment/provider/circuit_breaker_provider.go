@@ -3,47 +3,122 @@ package provider
 import (
 	"context"
 	"fmt"
+	"sync"
+	"time"
 
+	"github.com/sony/gobreaker"
 	"yeti/pkg/circuitbreaker"
 )
 
+// healthSampleWindow bounds how many recent Fetch/FetchBatch latencies
+// CircuitBreakerProvider keeps for Health's percentile estimates.
+const healthSampleWindow = 128
+
 type CircuitBreakerProvider struct {
 	provider DataProvider
 	cb       *circuitbreaker.Wrapper
 	name     string
+	onOpen   func(ctx context.Context) (map[string]interface{}, error)
+
+	latencies *latencyWindow
+
+	mu            sync.Mutex
+	lastErr       error
+	lastCheckedAt time.Time
+	tripCount     int
+	lastTrippedAt time.Time
 }
 
+// NewCircuitBreakerProvider wraps cfg.OnStateChange (if set) with tracking
+// for Health's TripCount/LastTrippedAt, so a caller-supplied OnStateChange
+// (e.g. WrapWithCircuitBreaker's logging/alerting hook) keeps firing
+// exactly as before, in addition to - not instead of - this bookkeeping.
 func NewCircuitBreakerProvider(provider DataProvider, name string, cfg circuitbreaker.Config) *CircuitBreakerProvider {
-	return &CircuitBreakerProvider{
-		provider: provider,
-		cb:       circuitbreaker.NewWrapper(cfg),
-		name:     name,
+	p := &CircuitBreakerProvider{
+		provider:  provider,
+		name:      name,
+		latencies: newLatencyWindow(healthSampleWindow),
+	}
+
+	userOnStateChange := cfg.OnStateChange
+	cfg.OnStateChange = func(breakerName string, from, to gobreaker.State) {
+		if to == gobreaker.StateOpen {
+			p.mu.Lock()
+			p.tripCount++
+			p.lastTrippedAt = time.Now()
+			p.mu.Unlock()
+		}
+		if userOnStateChange != nil {
+			userOnStateChange(breakerName, from, to)
+		}
 	}
+
+	p.cb = circuitbreaker.NewWrapper(cfg)
+	return p
+}
+
+// recordResult tracks outcome for Health after a Fetch or FetchBatch call,
+// independent of whether that call ultimately surfaces a circuit-open
+// error or the underlying provider's own error.
+func (p *CircuitBreakerProvider) recordResult(start time.Time, err error) {
+	p.latencies.record(time.Since(start))
+
+	p.mu.Lock()
+	p.lastErr = err
+	p.lastCheckedAt = time.Now()
+	p.mu.Unlock()
+}
+
+// WithOnOpen sets a fallback invoked instead of a circuit-open error once the
+// breaker for this source trips, so rules with error_handling: skip_field can
+// degrade to a cached or last-known-good value instead of failing the field.
+func (p *CircuitBreakerProvider) WithOnOpen(onOpen func(ctx context.Context) (map[string]interface{}, error)) *CircuitBreakerProvider {
+	p.onOpen = onOpen
+	return p
 }
 
 func (p *CircuitBreakerProvider) Fetch(ctx context.Context, config SourceConfig, fieldValue interface{}) (map[string]interface{}, error) {
-	result, err := p.cb.ExecuteWithContext(ctx, func() (interface{}, error) {
+	start := time.Now()
+	data, err := circuitbreaker.DoWithFallback(ctx, p.cb, func() (map[string]interface{}, error) {
 		return p.provider.Fetch(ctx, config, fieldValue)
-	})
-
-	p.cb.RecordRequest(err == nil)
+	}, p.onOpen)
 
 	if err != nil {
 		if p.cb.IsOpen() {
-			return nil, fmt.Errorf("circuit breaker is open for %s: %w", p.name, err)
+			err = fmt.Errorf("circuit breaker is open for %s: %w", p.name, err)
 		}
+		p.recordResult(start, err)
 		return nil, err
 	}
 
-	if result == nil {
-		return nil, fmt.Errorf("provider returned nil result")
+	if data == nil {
+		err = fmt.Errorf("provider returned nil result")
+		p.recordResult(start, err)
+		return nil, err
 	}
 
-	data, ok := result.(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("provider returned invalid result type")
+	p.recordResult(start, nil)
+	return data, nil
+}
+
+// FetchBatch runs FetchBatch against the wrapped provider through the same
+// circuit breaker as Fetch, so a batch call counts toward (and is shed by)
+// the same trip state as single-value fetches against this source.
+func (p *CircuitBreakerProvider) FetchBatch(ctx context.Context, config SourceConfig, fieldValues []interface{}) (map[interface{}]map[string]interface{}, error) {
+	start := time.Now()
+	data, err := circuitbreaker.DoWithFallback(ctx, p.cb, func() (map[interface{}]map[string]interface{}, error) {
+		return FetchBatch(ctx, p.provider, config, fieldValues)
+	}, nil)
+
+	if err != nil {
+		if p.cb.IsOpen() {
+			err = fmt.Errorf("circuit breaker is open for %s: %w", p.name, err)
+		}
+		p.recordResult(start, err)
+		return nil, err
 	}
 
+	p.recordResult(start, nil)
 	return data, nil
 }
 
@@ -54,3 +129,33 @@ func (p *CircuitBreakerProvider) State() string {
 func (p *CircuitBreakerProvider) IsOpen() bool {
 	return p.cb.IsOpen()
 }
+
+// Health reports this provider's current circuit state, most recent
+// Fetch/FetchBatch error, and recent latency percentiles, for the
+// enrichment service's /healthz/enrichment endpoint.
+func (p *CircuitBreakerProvider) Health() ProviderHealth {
+	p.mu.Lock()
+	lastErr := p.lastErr
+	lastCheckedAt := p.lastCheckedAt
+	tripCount := p.tripCount
+	lastTrippedAt := p.lastTrippedAt
+	p.mu.Unlock()
+
+	p50, p95, p99 := p.latencies.percentiles()
+
+	h := ProviderHealth{
+		Name:          p.name,
+		CircuitState:  p.State(),
+		LastCheckedAt: lastCheckedAt,
+		LatencyP50Ms:  p50,
+		LatencyP95Ms:  p95,
+		LatencyP99Ms:  p99,
+		FailureCount:  p.cb.Counts().TotalFailures,
+		TripCount:     tripCount,
+		LastTrippedAt: lastTrippedAt,
+	}
+	if lastErr != nil {
+		h.LastError = lastErr.Error()
+	}
+	return h
+}
@@ -5,13 +5,26 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"strings"
 
+	"github.com/lib/pq"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// safeIdentifierPattern guards every SQL identifier position (table name,
+// column name, Projection entry) PostgreSQLProvider interpolates directly
+// into a query string - those can't be bound as driver parameters the way
+// Query.Resolve's condition values now are, so this is the boundary check
+// that stands in for parameterization there.
+var safeIdentifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+func isSafeIdentifier(name string) bool {
+	return name != "" && safeIdentifierPattern.MatchString(name)
+}
+
 type MongoDBProvider struct {
 	client *mongo.Client
 }
@@ -22,36 +35,73 @@ func NewMongoDBProvider(client *mongo.Client) *MongoDBProvider {
 	}
 }
 
+// mongoOpBuilders translates a ResolvedCondition into its native Mongo
+// operator form, keeping every value a real bson value (never
+// string-interpolated) - see buildMongoFilter.
+var mongoOpBuilders = map[QueryOp]func(value interface{}) interface{}{
+	QueryOpEq:    func(v interface{}) interface{} { return v },
+	QueryOpIn:    func(v interface{}) interface{} { return bson.M{"$in": v} },
+	QueryOpGt:    func(v interface{}) interface{} { return bson.M{"$gt": v} },
+	QueryOpRegex: func(v interface{}) interface{} { return bson.M{"$regex": v} },
+}
+
+// buildMongoFilter resolves config's Query against fieldValue (see Query's
+// doc comment on $field's scope) and translates each condition into a bson
+// filter entry via mongoOpBuilders. Returns a nil filter (not an error)
+// when config has no Query conditions, so callers fall back to their
+// Field/_id default filter.
+func buildMongoFilter(query *Query, fieldValue interface{}) (bson.M, error) {
+	resolved, err := query.Resolve(map[string]interface{}{"value": fieldValue})
+	if err != nil {
+		return nil, err
+	}
+	if len(resolved) == 0 {
+		return nil, nil
+	}
+
+	filter := bson.M{}
+	for _, c := range resolved {
+		build, ok := mongoOpBuilders[c.Op]
+		if !ok {
+			return nil, fmt.Errorf("unsupported query operator %q for MongoDB provider", c.Op)
+		}
+		filter[c.Field] = build(c.Value)
+	}
+	return filter, nil
+}
+
 func (p *MongoDBProvider) Fetch(ctx context.Context, config SourceConfig, fieldValue interface{}) (map[string]interface{}, error) {
 	if config.Database == "" || config.Collection == "" {
 		return nil, fmt.Errorf("database and collection are required for MongoDB provider")
 	}
 
-	db := p.client.Database(config.Database)
-	collection := db.Collection(config.Collection)
-
-	filter := bson.M{}
-
-	queryMap := getQueryMap(config)
+	filter, err := buildMongoFilter(config.Query, fieldValue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build mongodb query: %w", err)
+	}
+	if filter == nil {
+		filter = bson.M{}
+		if config.Field != "" {
+			filter[config.Field] = fieldValue
+		} else {
+			filter["_id"] = fieldValue
+		}
+	}
 
-	if queryMap != nil && len(queryMap) > 0 {
-		for k, v := range queryMap {
-			if strVal, ok := v.(string); ok {
-				strVal = strings.ReplaceAll(strVal, "{field_value}", fmt.Sprintf("%v", fieldValue))
-				strVal = strings.ReplaceAll(strVal, "{value}", fmt.Sprintf("%v", fieldValue))
-				filter[k] = strVal
-			} else {
-				filter[k] = v
-			}
+	opts := options.FindOne()
+	if config.Query != nil && len(config.Query.Projection) > 0 {
+		projection := bson.M{}
+		for _, field := range config.Query.Projection {
+			projection[field] = 1
 		}
-	} else if config.Field != "" {
-		filter[config.Field] = fieldValue
-	} else {
-		filter["_id"] = fieldValue
+		opts.SetProjection(projection)
 	}
 
+	db := p.client.Database(config.Database)
+	collection := db.Collection(config.Collection)
+
 	var result bson.M
-	err := collection.FindOne(ctx, filter, options.FindOne()).Decode(&result)
+	err = collection.FindOne(ctx, filter, opts).Decode(&result)
 	if err == mongo.ErrNoDocuments {
 		return nil, fmt.Errorf("document not found")
 	}
@@ -64,14 +114,62 @@ func (p *MongoDBProvider) Fetch(ctx context.Context, config SourceConfig, fieldV
 		resultMap[key] = value
 	}
 
-	return resultMap, nil
+	return applyResultMapping(config.Query, resultMap), nil
 }
 
-func getQueryMap(config SourceConfig) map[string]interface{} {
-	if config.Query != nil {
-		return config.Query.ToMap()
+// FetchBatch resolves every value in fieldValues with a single MongoDB
+// `$in` query instead of one round trip per value.
+func (p *MongoDBProvider) FetchBatch(ctx context.Context, config SourceConfig, fieldValues []interface{}) (map[interface{}]map[string]interface{}, error) {
+	if config.Database == "" || config.Collection == "" {
+		return nil, fmt.Errorf("database and collection are required for MongoDB provider")
+	}
+
+	field := config.Field
+	if field == "" {
+		field = "_id"
+	}
+
+	collection := p.client.Database(config.Database).Collection(config.Collection)
+
+	opts := options.Find()
+	if config.Query != nil && len(config.Query.Projection) > 0 {
+		projection := bson.M{}
+		for _, f := range config.Query.Projection {
+			projection[f] = 1
+		}
+		opts.SetProjection(projection)
+	}
+
+	cursor, err := collection.Find(ctx, bson.M{field: bson.M{"$in": fieldValues}}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("mongodb batch query failed: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	result := make(map[interface{}]map[string]interface{}, len(fieldValues))
+	for cursor.Next(ctx) {
+		var doc bson.M
+		if err := cursor.Decode(&doc); err != nil {
+			continue
+		}
+
+		docValue, ok := doc[field]
+		if !ok {
+			continue
+		}
+		fv, matched := matchFieldValue(fieldValues, docValue)
+		if !matched {
+			continue
+		}
+
+		resultMap := make(map[string]interface{}, len(doc))
+		for k, v := range doc {
+			resultMap[k] = v
+		}
+		result[fv] = applyResultMapping(config.Query, resultMap)
 	}
-	return nil
+
+	return result, cursor.Err()
 }
 
 type PostgreSQLProvider struct {
@@ -84,39 +182,99 @@ func NewPostgreSQLProvider(db *sql.DB) *PostgreSQLProvider {
 	}
 }
 
-func (p *PostgreSQLProvider) Fetch(ctx context.Context, config SourceConfig, fieldValue interface{}) (map[string]interface{}, error) {
-	if config.Collection == "" {
-		return nil, fmt.Errorf("collection (table name) is required for PostgreSQL provider")
+// postgresOpSQL maps a QueryOp to the SQL fragment built around a single
+// parameter placeholder ("%s" is the field name, "%[2]s" the placeholder):
+// QueryOpIn binds against pq.Array so an "in" condition is one placeholder
+// (a Postgres array), not one placeholder per element.
+var postgresOpSQL = map[QueryOp]string{
+	QueryOpEq:    "%s = %s",
+	QueryOpIn:    "%s = ANY(%s)",
+	QueryOpGt:    "%s > %s",
+	QueryOpRegex: "%s ~ %s",
+}
+
+// buildPostgresWhere resolves config.Query against fieldValue (see Query's
+// doc comment on $field's scope) into a parameterized WHERE clause and its
+// bound args, numbered from startArgIndex. An empty query (no conditions)
+// returns an empty clause, not an error, so callers fall back to their
+// Field-based default condition.
+func buildPostgresWhere(query *Query, fieldValue interface{}, startArgIndex int) (string, []interface{}, error) {
+	resolved, err := query.Resolve(map[string]interface{}{"value": fieldValue})
+	if err != nil {
+		return "", nil, err
+	}
+	if len(resolved) == 0 {
+		return "", nil, nil
 	}
 
-	tableName := config.Collection
+	conditions := make([]string, 0, len(resolved))
+	args := make([]interface{}, 0, len(resolved))
+	argIndex := startArgIndex
+	for _, c := range resolved {
+		if !isSafeIdentifier(c.Field) {
+			return "", nil, fmt.Errorf("unsafe field name %q", c.Field)
+		}
+		pattern, ok := postgresOpSQL[c.Op]
+		if !ok {
+			return "", nil, fmt.Errorf("unsupported query operator %q for PostgreSQL provider", c.Op)
+		}
 
-	var whereClause string
-	var args []interface{}
+		value := c.Value
+		if c.Op == QueryOpIn {
+			value = pq.Array(c.Value)
+		}
 
-	queryMap := getQueryMap(config)
+		conditions = append(conditions, fmt.Sprintf(pattern, c.Field, fmt.Sprintf("$%d", argIndex)))
+		args = append(args, value)
+		argIndex++
+	}
 
-	if queryMap != nil && len(queryMap) > 0 {
-		var conditions []string
-		argIndex := 1
-		for k, v := range queryMap {
-			valStr := fmt.Sprintf("%v", v)
-			valStr = strings.ReplaceAll(valStr, "{field_value}", fmt.Sprintf("%v", fieldValue))
-			valStr = strings.ReplaceAll(valStr, "{value}", fmt.Sprintf("%v", fieldValue))
+	return strings.Join(conditions, " AND "), args, nil
+}
 
-			conditions = append(conditions, fmt.Sprintf("%s = $%d", k, argIndex))
-			args = append(args, valStr)
-			argIndex++
+// postgresColumnList returns query's Projection as a comma-joined column
+// list (each entry identifier-checked), or "*" when no Projection is set.
+func postgresColumnList(query *Query) (string, error) {
+	if query == nil || len(query.Projection) == 0 {
+		return "*", nil
+	}
+	for _, col := range query.Projection {
+		if !isSafeIdentifier(col) {
+			return "", fmt.Errorf("unsafe projection column %q", col)
+		}
+	}
+	return strings.Join(query.Projection, ", "), nil
+}
+
+func (p *PostgreSQLProvider) Fetch(ctx context.Context, config SourceConfig, fieldValue interface{}) (map[string]interface{}, error) {
+	if config.Collection == "" {
+		return nil, fmt.Errorf("collection (table name) is required for PostgreSQL provider")
+	}
+	if !isSafeIdentifier(config.Collection) {
+		return nil, fmt.Errorf("unsafe table name %q", config.Collection)
+	}
+
+	whereClause, args, err := buildPostgresWhere(config.Query, fieldValue, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build postgresql query: %w", err)
+	}
+	if whereClause == "" {
+		if config.Field == "" {
+			return nil, fmt.Errorf("either query or field must be specified for PostgreSQL provider")
+		}
+		if !isSafeIdentifier(config.Field) {
+			return nil, fmt.Errorf("unsafe field name %q", config.Field)
 		}
-		whereClause = strings.Join(conditions, " AND ")
-	} else if config.Field != "" {
 		whereClause = fmt.Sprintf("%s = $1", config.Field)
 		args = []interface{}{fieldValue}
-	} else {
-		return nil, fmt.Errorf("either query or field must be specified for PostgreSQL provider")
 	}
 
-	query := fmt.Sprintf("SELECT * FROM %s WHERE %s LIMIT 1", tableName, whereClause)
+	columns, err := postgresColumnList(config.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s LIMIT 1", columns, config.Collection, whereClause)
 
 	rows, err := p.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -128,6 +286,18 @@ func (p *PostgreSQLProvider) Fetch(ctx context.Context, config SourceConfig, fie
 		return nil, fmt.Errorf("row not found")
 	}
 
+	result, err := scanPostgresRow(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return applyResultMapping(config.Query, result), nil
+}
+
+// scanPostgresRow scans rows' current row into a column-name-keyed map,
+// decoding a []byte column as JSON when possible (e.g. a jsonb column)
+// rather than leaving it as a raw byte string.
+func scanPostgresRow(rows *sql.Rows) (map[string]interface{}, error) {
 	columns, err := rows.Columns()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get columns: %w", err)
@@ -143,7 +313,7 @@ func (p *PostgreSQLProvider) Fetch(ctx context.Context, config SourceConfig, fie
 		return nil, fmt.Errorf("postgresql scan failed: %w", err)
 	}
 
-	result := make(map[string]interface{})
+	result := make(map[string]interface{}, len(columns))
 	for i, col := range columns {
 		val := values[i]
 
@@ -161,3 +331,53 @@ func (p *PostgreSQLProvider) Fetch(ctx context.Context, config SourceConfig, fie
 
 	return result, nil
 }
+
+// FetchBatch resolves every value in fieldValues with a single `WHERE
+// field IN (...)` query instead of one round trip per value.
+func (p *PostgreSQLProvider) FetchBatch(ctx context.Context, config SourceConfig, fieldValues []interface{}) (map[interface{}]map[string]interface{}, error) {
+	if config.Collection == "" {
+		return nil, fmt.Errorf("collection (table name) is required for PostgreSQL provider")
+	}
+	if !isSafeIdentifier(config.Collection) {
+		return nil, fmt.Errorf("unsafe table name %q", config.Collection)
+	}
+	if config.Field == "" {
+		return nil, fmt.Errorf("field must be specified for PostgreSQL batch provider")
+	}
+	if !isSafeIdentifier(config.Field) {
+		return nil, fmt.Errorf("unsafe field name %q", config.Field)
+	}
+
+	columns, err := postgresColumnList(config.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ANY($1)", columns, config.Collection, config.Field)
+
+	rows, err := p.db.QueryContext(ctx, query, pq.Array(fieldValues))
+	if err != nil {
+		return nil, fmt.Errorf("postgresql batch query failed: %w", err)
+	}
+	defer rows.Close()
+
+	result := make(map[interface{}]map[string]interface{}, len(fieldValues))
+	for rows.Next() {
+		row, err := scanPostgresRow(rows)
+		if err != nil {
+			continue
+		}
+
+		rowValue, ok := row[config.Field]
+		if !ok {
+			continue
+		}
+		fv, matched := matchFieldValue(fieldValues, rowValue)
+		if !matched {
+			continue
+		}
+		result[fv] = applyResultMapping(config.Query, row)
+	}
+
+	return result, rows.Err()
+}
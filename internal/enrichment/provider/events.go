@@ -0,0 +1,54 @@
+package provider
+
+import (
+	"context"
+	"strings"
+)
+
+// BreakerEventPublisher is notified whenever a circuit breaker
+// WrapWithCircuitBreaker built changes state, so whichever service wires
+// one up (see SetBreakerEventPublisher) can forward the transition for
+// external alerting. management.ConfigEventProducer's
+// PublishEnrichmentBreakerStateEvent method already satisfies this
+// interface structurally, so cmd/enrichment-service can hand one in
+// without this package importing internal/management.
+type BreakerEventPublisher interface {
+	PublishEnrichmentBreakerStateEvent(ctx context.Context, sourceName, ruleID, from, to string) error
+}
+
+// breakerEventPublisher is set once at startup via SetBreakerEventPublisher,
+// mirroring management.SetMaxEstimatedCELCost's package-level config
+// convention. nil (the default) disables forwarding.
+var breakerEventPublisher BreakerEventPublisher
+
+// SetBreakerEventPublisher configures the publisher every future
+// WrapWithCircuitBreaker state transition is forwarded to.
+func SetBreakerEventPublisher(pub BreakerEventPublisher) {
+	breakerEventPublisher = pub
+}
+
+// ruleBreakerNamePrefix is buildRuleProvider's "rule:" + rule.ID naming
+// convention for a per-rule breaker's name, as opposed to a source-level
+// breaker's plain source type name (e.g. "http", "grpc").
+const ruleBreakerNamePrefix = "rule:"
+
+// publishBreakerStateChange forwards one breaker's state transition to the
+// configured publisher, if any, on a best-effort background goroutine so a
+// slow or unreachable event sink never blocks the breaker's own state
+// change. name is split into its source name and (if present) rule ID the
+// same way reportRuleBreakerState's metrics label already is.
+func publishBreakerStateChange(name, from, to string) {
+	pub := breakerEventPublisher
+	if pub == nil {
+		return
+	}
+
+	ruleID := ""
+	if rest, ok := strings.CutPrefix(name, ruleBreakerNamePrefix); ok {
+		ruleID = rest
+	}
+
+	go func() {
+		_ = pub.PublishEnrichmentBreakerStateEvent(context.Background(), name, ruleID, from, to)
+	}()
+}
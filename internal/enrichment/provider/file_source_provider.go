@@ -0,0 +1,167 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileSourceProvider is SourceTypeFile's DataProvider: like
+// GRPCSourceProvider, a rule picks its target per rule (FilePath) rather
+// than at construction time, so FileSourceProvider keeps one fileTable warm
+// per distinct path and reloads it lazily whenever the file's mtime
+// advances, instead of re-reading it on every Fetch.
+type FileSourceProvider struct {
+	mu    sync.Mutex
+	files map[string]*fileTable
+}
+
+func NewFileSourceProvider() *FileSourceProvider {
+	return &FileSourceProvider{files: make(map[string]*fileTable)}
+}
+
+// fileTable is one FilePath's decoded record set, keyed by lookup key.
+type fileTable struct {
+	mu      sync.RWMutex
+	modTime time.Time
+	records map[string]map[string]interface{}
+}
+
+func (p *FileSourceProvider) Fetch(ctx context.Context, cfg SourceConfig, fieldValue interface{}) (map[string]interface{}, error) {
+	if cfg.FilePath == "" {
+		return nil, fmt.Errorf("source_config.file_path is required for file source type")
+	}
+
+	table, err := p.tableFor(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("file source provider: %w", err)
+	}
+
+	key := fmt.Sprintf("%v", fieldValue)
+	table.mu.RLock()
+	defer table.mu.RUnlock()
+	record, ok := table.records[key]
+	if !ok {
+		return nil, fmt.Errorf("file source: no record for key %q in %s", key, cfg.FilePath)
+	}
+	return record, nil
+}
+
+func (p *FileSourceProvider) tableFor(cfg SourceConfig) (*fileTable, error) {
+	p.mu.Lock()
+	t, ok := p.files[cfg.FilePath]
+	if !ok {
+		t = &fileTable{}
+		p.files[cfg.FilePath] = t
+	}
+	p.mu.Unlock()
+
+	if err := t.reloadIfChanged(cfg); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// reloadIfChanged re-decodes cfg.FilePath if its mtime has moved past what's
+// currently loaded, so a file edited on disk is picked up without a service
+// restart - the "mtime-based reload" the chunk10-3 request asked for.
+func (t *fileTable) reloadIfChanged(cfg SourceConfig) error {
+	info, err := os.Stat(cfg.FilePath)
+	if err != nil {
+		return fmt.Errorf("stat %s: %w", cfg.FilePath, err)
+	}
+
+	t.mu.RLock()
+	unchanged := t.modTime.Equal(info.ModTime())
+	t.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	records, err := loadFileRecords(cfg.FilePath, cfg.FileFormat, cfg.KeyField)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	t.records = records
+	t.modTime = info.ModTime()
+	t.mu.Unlock()
+	return nil
+}
+
+// loadFileRecords decodes path per format ("csv" or "json"; "yaml" is
+// rejected - see the doc comment on FileSourceProvider's package for why)
+// into a table keyed by lookup key.
+func loadFileRecords(path, format, keyField string) (map[string]map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+
+	switch format {
+	case "", "json":
+		return loadJSONRecords(data)
+	case "csv":
+		return loadCSVRecords(data, keyField)
+	case "yaml":
+		return nil, fmt.Errorf("file source format %q is not supported in this build: yaml decoding would need a dependency (gopkg.in/yaml.v3) this repo doesn't otherwise carry; use csv or json instead", format)
+	default:
+		return nil, fmt.Errorf("unsupported file source format %q: must be \"csv\" or \"json\"", format)
+	}
+}
+
+// loadJSONRecords expects data to decode to a top-level object keyed by
+// lookup key, each value itself an object - i.e. the file already has the
+// table's shape, unlike loadCSVRecords which has to build it from rows.
+func loadJSONRecords(data []byte) (map[string]map[string]interface{}, error) {
+	var records map[string]map[string]interface{}
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("decode json file source: %w", err)
+	}
+	return records, nil
+}
+
+func loadCSVRecords(data []byte, keyField string) (map[string]map[string]interface{}, error) {
+	if keyField == "" {
+		return nil, fmt.Errorf("source_config.key_field is required for csv file source")
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("decode csv file source: %w", err)
+	}
+	if len(rows) == 0 {
+		return map[string]map[string]interface{}{}, nil
+	}
+
+	header := rows[0]
+	keyCol := -1
+	for i, col := range header {
+		if col == keyField {
+			keyCol = i
+			break
+		}
+	}
+	if keyCol == -1 {
+		return nil, fmt.Errorf("csv file source: key_field %q not found in header", keyField)
+	}
+
+	records := make(map[string]map[string]interface{}, len(rows)-1)
+	for _, row := range rows[1:] {
+		record := make(map[string]interface{}, len(header))
+		for i, col := range header {
+			if i < len(row) {
+				record[col] = row[i]
+			}
+		}
+		records[row[keyCol]] = record
+	}
+	return records, nil
+}
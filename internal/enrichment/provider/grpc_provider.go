@@ -0,0 +1,296 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"yeti/internal/enrichment/provider/providerpb"
+	"yeti/pkg/tlsutil"
+	"yeti/pkg/tracing"
+)
+
+// GRPCProviderConfig describes how to dial an external enrichment provider
+// registered through the management service's POST /api/v1/providers.
+type GRPCProviderConfig struct {
+	Name    string
+	Address string
+
+	// TLSCert is an inline PEM-encoded CA certificate used to verify the
+	// provider, the shape the management service has historically stored a
+	// provider's cert in. Prefer CAFile for new configuration; TLSCert is
+	// kept so existing registered providers keep working.
+	TLSCert string
+	// CAFile, ClientCertFile and ClientKeyFile configure TLS (and, with
+	// both client fields set, mTLS) by file path instead of inline PEM.
+	// CAFile takes precedence over TLSCert when both are set.
+	CAFile         string
+	ClientCertFile string
+	ClientKeyFile  string
+	// ServerName overrides the hostname used to verify the provider's
+	// certificate, for providers dialed by an address that doesn't match
+	// the certificate's subject.
+	ServerName string
+
+	// LoadBalancingPolicy selects grpc's client-side load-balancing
+	// policy: "round_robin" spreads calls across every address Address
+	// resolves to (useful when Address is a DNS name backed by several
+	// replicas); anything else, including empty, uses "pick_first" (stick
+	// to the first address that connects).
+	LoadBalancingPolicy string
+}
+
+func (cfg GRPCProviderConfig) loadBalancingPolicy() string {
+	if cfg.LoadBalancingPolicy == "round_robin" {
+		return "round_robin"
+	}
+	return "pick_first"
+}
+
+// GRPCProvider is a Provider backed by an external plugin reached over
+// gRPC. It also implements DataProvider, so it can be registered directly
+// into serviceImpl.providers alongside the built-in sources.
+type GRPCProvider struct {
+	name             string
+	conn             *grpc.ClientConn
+	client           providerpb.ProviderClient
+	enrichmentClient providerpb.EnrichmentClient
+	healthClient     grpc_health_v1.HealthClient
+}
+
+// NewGRPCProvider dials cfg.Address once and keeps the connection warm;
+// grpc.ClientConn multiplexes concurrent Lookup/Fetch calls over the
+// underlying HTTP/2 connection itself, so one GRPCProvider per registered
+// source type is enough of a pool.
+func NewGRPCProvider(cfg GRPCProviderConfig) (*GRPCProvider, error) {
+	creds, err := dialCredentials(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := grpc.Dial(cfg.Address,
+		grpc.WithTransportCredentials(creds),
+		tracing.GRPCClientDialOption(),
+		grpc.WithChainUnaryInterceptor(retryUnaryClientInterceptor),
+		grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"loadBalancingPolicy":%q}`, cfg.loadBalancingPolicy())),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("grpc provider %s: failed to dial %s: %w", cfg.Name, cfg.Address, err)
+	}
+
+	return &GRPCProvider{
+		name:             cfg.Name,
+		conn:             conn,
+		client:           providerpb.NewProviderClient(conn),
+		enrichmentClient: providerpb.NewEnrichmentClient(conn),
+		healthClient:     grpc_health_v1.NewHealthClient(conn),
+	}, nil
+}
+
+func dialCredentials(cfg GRPCProviderConfig) (credentials.TransportCredentials, error) {
+	switch {
+	case cfg.CAFile != "" || cfg.ClientCertFile != "":
+		tlsCfg, err := tlsutil.ClientTLSConfig(tlsutil.Config{
+			CertFile:   cfg.ClientCertFile,
+			KeyFile:    cfg.ClientKeyFile,
+			CAFile:     cfg.CAFile,
+			ServerName: cfg.ServerName,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("grpc provider %s: %w", cfg.Name, err)
+		}
+		return credentials.NewTLS(tlsCfg), nil
+	case cfg.TLSCert != "":
+		tlsCfg, err := tlsutil.ClientTLSConfigFromPEM(cfg.TLSCert)
+		if err != nil {
+			return nil, fmt.Errorf("grpc provider %s: %w", cfg.Name, err)
+		}
+		return credentials.NewTLS(tlsCfg), nil
+	default:
+		return insecure.NewCredentials(), nil
+	}
+}
+
+func (p *GRPCProvider) Name() string {
+	return p.name
+}
+
+func (p *GRPCProvider) Lookup(ctx context.Context, key string, params map[string]interface{}) (*EnrichmentResult, error) {
+	paramsStruct, err := structpb.NewStruct(params)
+	if err != nil {
+		return nil, fmt.Errorf("grpc provider %s: invalid params: %w", p.name, err)
+	}
+
+	req, err := structpb.NewStruct(map[string]interface{}{
+		"key":    key,
+		"params": paramsStruct.AsMap(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("grpc provider %s: failed to build request: %w", p.name, err)
+	}
+
+	resp, err := p.client.Lookup(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("grpc provider %s: lookup failed: %w", p.name, err)
+	}
+
+	data, _ := resp.AsMap()["data"].(map[string]interface{})
+	return EnrichmentResultFromMap(data), nil
+}
+
+// Fetch adapts the plugin's Enrichment.Fetch RPC to DataProvider so the
+// rest of the enrichment pipeline can use a GRPCProvider without knowing
+// it's external. It honors the rule's own SourceConfig.TimeoutMs and
+// RetryCount, since a plugin's own latency and reliability characteristics
+// are unknown to the Yeti operator and are configured per-rule rather than
+// per-connection.
+//
+// Plugins written before Enrichment.Fetch existed only implement
+// Provider.Lookup; Fetch falls back to it on an Unimplemented status so
+// registering an older plugin doesn't break every rule pointed at it.
+func (p *GRPCProvider) Fetch(ctx context.Context, config SourceConfig, fieldValue interface{}) (map[string]interface{}, error) {
+	if config.TimeoutMs > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(config.TimeoutMs)*time.Millisecond)
+		defer cancel()
+	}
+	ctx = withRetryCount(ctx, config.RetryCount)
+
+	data, err := p.fetchTyped(ctx, config, fieldValue)
+	if err == nil {
+		return data, nil
+	}
+	if status.Code(err) != codes.Unimplemented {
+		return nil, err
+	}
+
+	result, err := p.Lookup(ctx, fmt.Sprintf("%v", fieldValue), sourceConfigToParams(config))
+	if err != nil {
+		return nil, err
+	}
+	return result.ToMap(), nil
+}
+
+func (p *GRPCProvider) fetchTyped(ctx context.Context, config SourceConfig, fieldValue interface{}) (map[string]interface{}, error) {
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("grpc provider %s: failed to encode source config: %w", p.name, err)
+	}
+	valueJSON, err := json.Marshal(fieldValue)
+	if err != nil {
+		return nil, fmt.Errorf("grpc provider %s: failed to encode field value: %w", p.name, err)
+	}
+
+	resp, err := p.enrichmentClient.Fetch(ctx, &providerpb.FetchRequest{
+		SourceConfigJSON: string(configJSON),
+		FieldValueJSON:   string(valueJSON),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("grpc provider %s: fetch failed: %w", p.name, err)
+	}
+
+	if resp.DataJSON == "" {
+		return nil, nil
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(resp.DataJSON), &data); err != nil {
+		return nil, fmt.Errorf("grpc provider %s: invalid fetch response: %w", p.name, err)
+	}
+	return data, nil
+}
+
+// HealthCheck prefers the standard grpc.health.v1 service so third-party
+// health tooling (and Kubernetes gRPC probes) work against a registered
+// plugin out of the box, falling back to Provider.HealthCheck for plugins
+// written before Enrichment existed.
+func (p *GRPCProvider) HealthCheck(ctx context.Context) error {
+	resp, err := p.healthClient.Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	if err == nil {
+		if resp.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+			return fmt.Errorf("grpc provider %s: reported status %s", p.name, resp.Status)
+		}
+		return nil
+	}
+	if status.Code(err) != codes.Unimplemented {
+		return fmt.Errorf("grpc provider %s: health check failed: %w", p.name, err)
+	}
+
+	legacyResp, err := p.client.HealthCheck(ctx, &emptypb.Empty{})
+	if err != nil {
+		return fmt.Errorf("grpc provider %s: health check failed: %w", p.name, err)
+	}
+	respMap := legacyResp.AsMap()
+	if healthy, ok := respMap["healthy"].(bool); ok && !healthy {
+		msg, _ := respMap["message"].(string)
+		return fmt.Errorf("grpc provider %s: reported unhealthy: %s", p.name, msg)
+	}
+	return nil
+}
+
+func (p *GRPCProvider) Close() error {
+	return p.conn.Close()
+}
+
+// retryCountKey is the context key Fetch uses to pass a rule's
+// SourceConfig.RetryCount down to retryUnaryClientInterceptor, since the
+// grpc.ClientConn (and its interceptor chain) is shared across every rule
+// that resolves to this provider and can't be configured per-call any
+// other way.
+type retryCountKey struct{}
+
+func withRetryCount(ctx context.Context, n int) context.Context {
+	if n <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, retryCountKey{}, n)
+}
+
+// retryUnaryClientInterceptor retries a failed unary call up to the retry
+// count attached to ctx by withRetryCount, stopping early once ctx itself
+// is done (e.g. the TimeoutMs deadline Fetch set has elapsed) so retries
+// never outlive the rule's own timeout budget.
+func retryUnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	attempts, _ := ctx.Value(retryCountKey{}).(int)
+
+	var err error
+	for attempt := 0; attempt <= attempts; attempt++ {
+		err = invoker(ctx, method, req, reply, cc, opts...)
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// sourceConfigToParams flattens the subset of SourceConfig an external
+// provider can plausibly use into a generic params map; since the plugin
+// owns its own lookup logic, it decides which keys (if any) matter to it.
+func sourceConfigToParams(config SourceConfig) map[string]interface{} {
+	params := map[string]interface{}{}
+	if config.Database != "" {
+		params["database"] = config.Database
+	}
+	if config.Collection != "" {
+		params["collection"] = config.Collection
+	}
+	if config.Field != "" {
+		params["field"] = config.Field
+	}
+	if config.KeyPattern != "" {
+		params["key_pattern"] = config.KeyPattern
+	}
+	if config.Query != nil {
+		params["query"] = config.Query.ToMap()
+	}
+	return params
+}
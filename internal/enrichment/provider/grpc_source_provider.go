@@ -0,0 +1,76 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// GRPCSourceProvider is SourceTypeGRPC's DataProvider: unlike GRPCProvider,
+// which is dialed once for an ExternalProvider registered under a fixed
+// source type name, a SourceTypeGRPC rule picks its target per rule via
+// SourceConfig.Address, so GRPCSourceProvider dials lazily and keeps one
+// GRPCProvider warm per distinct address (plus TLS material) instead of
+// per rule.
+type GRPCSourceProvider struct {
+	mu        sync.Mutex
+	providers map[string]*GRPCProvider
+}
+
+func NewGRPCSourceProvider() *GRPCSourceProvider {
+	return &GRPCSourceProvider{providers: make(map[string]*GRPCProvider)}
+}
+
+func (p *GRPCSourceProvider) Fetch(ctx context.Context, cfg SourceConfig, fieldValue interface{}) (map[string]interface{}, error) {
+	if cfg.Address == "" {
+		return nil, fmt.Errorf("source_config.address is required for grpc source type")
+	}
+
+	gp, err := p.providerFor(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("grpc source provider: %w", err)
+	}
+	return gp.Fetch(ctx, cfg, fieldValue)
+}
+
+func (p *GRPCSourceProvider) providerFor(cfg SourceConfig) (*GRPCProvider, error) {
+	key := cfg.Address + "|" + cfg.ClientCertFile + "|" + cfg.ClientKeyFile + "|" + cfg.CAFile + "|" + cfg.ServerName
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if gp, ok := p.providers[key]; ok {
+		return gp, nil
+	}
+
+	gp, err := NewGRPCProvider(GRPCProviderConfig{
+		Name:           cfg.Address,
+		Address:        cfg.Address,
+		CAFile:         cfg.CAFile,
+		ClientCertFile: cfg.ClientCertFile,
+		ClientKeyFile:  cfg.ClientKeyFile,
+		ServerName:     cfg.ServerName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	p.providers[key] = gp
+	return gp, nil
+}
+
+// Close closes every GRPCProvider dialed so far, releasing their
+// connections.
+func (p *GRPCSourceProvider) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var firstErr error
+	for addr, gp := range p.providers {
+		if err := gp.Close(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("address %s: %w", addr, err)
+		}
+	}
+	p.providers = make(map[string]*GRPCProvider)
+	return firstErr
+}
@@ -0,0 +1,91 @@
+package provider
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// CircuitStateOpen is the string CircuitBreakerProvider.State() (and hence
+// ProviderHealth.CircuitState) reports while gobreaker has tripped the
+// breaker for a source, matching gobreaker.StateOpen.String().
+const CircuitStateOpen = "open"
+
+// ProviderHealth is the per-provider detail served by the enrichment
+// service's /healthz/enrichment endpoint: circuit-breaker state (from
+// CircuitBreakerProvider.State()), the most recent Fetch/FetchBatch
+// error, and recent latency percentiles, so an operator can tell a
+// merely-slow source apart from one whose breaker has actually tripped.
+type ProviderHealth struct {
+	Name          string    `json:"name"`
+	CircuitState  string    `json:"circuit_state,omitempty"`
+	LastError     string    `json:"last_error,omitempty"`
+	LastCheckedAt time.Time `json:"last_checked_at"`
+	LatencyP50Ms  float64   `json:"latency_p50_ms"`
+	LatencyP95Ms  float64   `json:"latency_p95_ms"`
+	LatencyP99Ms  float64   `json:"latency_p99_ms"`
+	// FailureCount is the breaker's current window failure count (gobreaker
+	// Counts.TotalFailures), reset whenever the breaker closes again. Zero
+	// for a source with no circuit-breaker wrapping.
+	FailureCount uint32 `json:"failure_count,omitempty"`
+	// TripCount is how many times this breaker has gone from closed/half-open
+	// to open since the provider was constructed.
+	TripCount int `json:"trip_count,omitempty"`
+	// LastTrippedAt is when the breaker last transitioned to open; the zero
+	// value means it never has.
+	LastTrippedAt time.Time `json:"last_tripped_at,omitempty"`
+}
+
+// latencyWindow keeps the last capacity latency samples in a ring buffer,
+// giving percentile estimates without the unbounded memory growth of
+// keeping every sample a long-lived provider ever observes.
+type latencyWindow struct {
+	mu       sync.Mutex
+	samples  []time.Duration
+	capacity int
+	next     int
+	full     bool
+}
+
+func newLatencyWindow(capacity int) *latencyWindow {
+	return &latencyWindow{
+		samples:  make([]time.Duration, capacity),
+		capacity: capacity,
+	}
+}
+
+func (w *latencyWindow) record(d time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.samples[w.next] = d
+	w.next = (w.next + 1) % w.capacity
+	if w.next == 0 {
+		w.full = true
+	}
+}
+
+// percentiles returns the p50/p95/p99 latencies, in milliseconds, among
+// the samples currently in the window.
+func (w *latencyWindow) percentiles() (p50, p95, p99 float64) {
+	w.mu.Lock()
+	n := w.capacity
+	if !w.full {
+		n = w.next
+	}
+	sorted := make([]time.Duration, n)
+	copy(sorted, w.samples[:n])
+	w.mu.Unlock()
+
+	if n == 0 {
+		return 0, 0, 0
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	percentile := func(p float64) float64 {
+		idx := int(p * float64(n-1))
+		return float64(sorted[idx].Milliseconds())
+	}
+
+	return percentile(0.50), percentile(0.95), percentile(0.99)
+}
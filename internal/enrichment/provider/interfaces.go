@@ -11,3 +11,17 @@ type DataProvider interface {
 type TypedDataProvider interface {
 	FetchTyped(ctx context.Context, config SourceConfig, fieldValue interface{}) (*EnrichmentResult, error)
 }
+
+// Provider is the extension point for enrichment sources that manage their
+// own connection lifecycle and liveness, such as an external gRPC plugin
+// registered through the management service. It's a superset of
+// DataProvider: Lookup carries the same data as Fetch, keyed more
+// generically (a raw key plus a params bag) so it doesn't depend on the
+// enrichment rule's SourceConfig shape, which built-in providers were
+// written against before Provider existed.
+type Provider interface {
+	Lookup(ctx context.Context, key string, params map[string]interface{}) (*EnrichmentResult, error)
+	HealthCheck(ctx context.Context) error
+	Name() string
+	Close() error
+}
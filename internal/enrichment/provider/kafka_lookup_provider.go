@@ -0,0 +1,139 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	kafka "github.com/segmentio/kafka-go"
+)
+
+// KafkaLookupProvider is SourceTypeKafkaLookup's DataProvider: it consumes a
+// compacted topic in the background and keeps an in-memory table keyed by
+// each record's Kafka message key, so Fetch is an O(1) map lookup instead of
+// a live broker round trip. Like GRPCSourceProvider, one consumer is kept
+// warm per distinct target (here, brokers+topic) rather than per rule.
+type KafkaLookupProvider struct {
+	mu      sync.Mutex
+	readers map[string]*kafkaLookupTable
+}
+
+func NewKafkaLookupProvider() *KafkaLookupProvider {
+	return &KafkaLookupProvider{readers: make(map[string]*kafkaLookupTable)}
+}
+
+// kafkaLookupTable is one (brokers, topic) pair's in-memory table, kept
+// current by a background goroutine reading from the topic's start - a
+// fresh, process-local consumer group ID each time so a restart always
+// rebuilds the full current state a compacted topic represents, rather
+// than resuming from a possibly-stale committed offset.
+type kafkaLookupTable struct {
+	mu     sync.RWMutex
+	table  map[string]map[string]interface{}
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func (p *KafkaLookupProvider) Fetch(ctx context.Context, cfg SourceConfig, fieldValue interface{}) (map[string]interface{}, error) {
+	if len(cfg.KafkaBrokers) == 0 || cfg.KafkaTopic == "" {
+		return nil, fmt.Errorf("source_config.kafka_brokers and source_config.kafka_topic are required for kafka_lookup source type")
+	}
+
+	t := p.tableFor(cfg)
+	key := fmt.Sprintf("%v", fieldValue)
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	record, ok := t.table[key]
+	if !ok {
+		return nil, fmt.Errorf("kafka_lookup: no record for key %q in topic %q", key, cfg.KafkaTopic)
+	}
+	return record, nil
+}
+
+func (p *KafkaLookupProvider) tableFor(cfg SourceConfig) *kafkaLookupTable {
+	target := strings.Join(cfg.KafkaBrokers, ",") + "|" + cfg.KafkaTopic
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if t, ok := p.readers[target]; ok {
+		return t
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &kafkaLookupTable{
+		table:  make(map[string]map[string]interface{}),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go t.consume(ctx, cfg.KafkaBrokers, cfg.KafkaTopic)
+	p.readers[target] = t
+	return t
+}
+
+func (t *kafkaLookupTable) consume(ctx context.Context, brokers []string, topic string) {
+	defer close(t.done)
+
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers: brokers,
+		Topic:   topic,
+		// GroupID is unique per table instance (not per process run) so
+		// every (brokers, topic) pair gets its own fresh read from
+		// FirstOffset, rebuilding the full compacted-topic state this
+		// table represents.
+		GroupID:     fmt.Sprintf("yeti-enrichment-kafka-lookup-%s-%d", topic, time.Now().UnixNano()),
+		StartOffset: kafka.FirstOffset,
+	})
+	defer reader.Close()
+
+	for {
+		msg, err := reader.ReadMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+		t.apply(msg)
+	}
+}
+
+// apply applies one record to the table. A nil/empty value is a Kafka
+// compaction tombstone, removing key from the table.
+func (t *kafkaLookupTable) apply(msg kafka.Message) {
+	key := string(msg.Key)
+	if key == "" {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(msg.Value) == 0 {
+		delete(t.table, key)
+		return
+	}
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(msg.Value, &record); err != nil {
+		return
+	}
+	t.table[key] = record
+}
+
+// Close stops every background consumer started so far.
+func (p *KafkaLookupProvider) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for target, t := range p.readers {
+		t.cancel()
+		<-t.done
+		delete(p.readers, target)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package providerpb
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// ServiceNameEnrichment is the fully-qualified gRPC service name for the
+// Enrichment service declared in proto/enrichment/v1/provider.proto.
+const ServiceNameEnrichment = "enrichment.v1.Enrichment"
+
+const methodFetch = "Fetch"
+
+// FetchRequest mirrors provider.proto's FetchRequest message. See
+// jsoncodec.go for why this is a plain Go struct instead of a
+// protoc-generated type.
+type FetchRequest struct {
+	SourceConfigJSON string `json:"source_config"`
+	FieldValueJSON   string `json:"field_value_json"`
+}
+
+// FetchResponse mirrors provider.proto's FetchResponse message.
+type FetchResponse struct {
+	DataJSON   string `json:"data_json"`
+	TTLSeconds int64  `json:"ttl_seconds"`
+	CacheKey   string `json:"cache_key"`
+}
+
+// EnrichmentClient is the client API for the Enrichment gRPC service.
+type EnrichmentClient interface {
+	Fetch(ctx context.Context, in *FetchRequest, opts ...grpc.CallOption) (*FetchResponse, error)
+}
+
+type enrichmentClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEnrichmentClient(cc grpc.ClientConnInterface) EnrichmentClient {
+	return &enrichmentClient{cc: cc}
+}
+
+func (c *enrichmentClient) Fetch(ctx context.Context, in *FetchRequest, opts ...grpc.CallOption) (*FetchResponse, error) {
+	out := new(FetchResponse)
+	// jsonCodec is forced per-call rather than for the whole ClientConn so
+	// Provider's Lookup/HealthCheck calls over the same connection keep
+	// using the real protobuf codec.
+	opts = append([]grpc.CallOption{grpc.ForceCodec(jsonCodec{})}, opts...)
+	if err := c.cc.Invoke(ctx, fullMethodEnrichment(methodFetch), in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// EnrichmentServer is the server API for the Enrichment gRPC service. A
+// reference implementation lives in cmd/example-provider.
+type EnrichmentServer interface {
+	Fetch(ctx context.Context, in *FetchRequest) (*FetchResponse, error)
+}
+
+func RegisterEnrichmentServer(s grpc.ServiceRegistrar, srv EnrichmentServer) {
+	s.RegisterService(&enrichmentServiceDesc, srv)
+}
+
+func fullMethodEnrichment(method string) string {
+	return "/" + ServiceNameEnrichment + "/" + method
+}
+
+var enrichmentServiceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceNameEnrichment,
+	HandlerType: (*EnrichmentServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: methodFetch, Handler: fetchHandler},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "enrichment/v1/provider.proto",
+}
+
+func fetchHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FetchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EnrichmentServer).Fetch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethodEnrichment(methodFetch)}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EnrichmentServer).Fetch(ctx, req.(*FetchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
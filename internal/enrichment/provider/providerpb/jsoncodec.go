@@ -0,0 +1,38 @@
+package providerpb
+
+import (
+	"encoding/json"
+
+	"google.golang.org/grpc/encoding"
+)
+
+// jsonCodec implements grpc's encoding.Codec with encoding/json instead of
+// real protobuf wire encoding. It exists to carry FetchRequest/FetchResponse
+// (see enrichment.go): plain Go structs that mirror provider.proto's
+// message definitions field-for-field but, like the rest of this package,
+// aren't protoc-generated — this tree has no protoc toolchain, so they
+// have no protoreflect-compatible Marshal/Unmarshal of their own. If
+// protoc becomes available, regenerate FetchRequest/FetchResponse as real
+// protobuf messages with the same JSON field names and delete this file;
+// callers only ever reach it through EnrichmentClient.Fetch's
+// grpc.ForceCodec call option, never directly.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// Name is negotiated as the call's content-subtype: grpc.ForceCodec sets it
+// on the client side, and the server looks it up here via the encoding
+// registry to decode with the same codec.
+func (jsonCodec) Name() string {
+	return "yeti-json"
+}
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
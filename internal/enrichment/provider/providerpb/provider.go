@@ -0,0 +1,112 @@
+// Package providerpb is the Go client/server binding for the gRPC service
+// described in proto/enrichment/v1/provider.proto. It's hand-maintained
+// rather than protoc-generated: both RPCs exchange google.protobuf.Struct
+// (plus Empty for the health check request), so there are no per-field
+// message types to generate. Keep this file in sync with the .proto by
+// hand when either changes.
+package providerpb
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	// ServiceName is the fully-qualified gRPC service name from the .proto
+	// package+service declaration.
+	ServiceName = "enrichment.v1.Provider"
+
+	methodLookup      = "Lookup"
+	methodHealthCheck = "HealthCheck"
+)
+
+// ProviderClient is the client API for the Provider gRPC service.
+type ProviderClient interface {
+	Lookup(ctx context.Context, in *structpb.Struct, opts ...grpc.CallOption) (*structpb.Struct, error)
+	HealthCheck(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*structpb.Struct, error)
+}
+
+type providerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewProviderClient(cc grpc.ClientConnInterface) ProviderClient {
+	return &providerClient{cc: cc}
+}
+
+func (c *providerClient) Lookup(ctx context.Context, in *structpb.Struct, opts ...grpc.CallOption) (*structpb.Struct, error) {
+	out := new(structpb.Struct)
+	if err := c.cc.Invoke(ctx, fullMethod(methodLookup), in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *providerClient) HealthCheck(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*structpb.Struct, error) {
+	out := new(structpb.Struct)
+	if err := c.cc.Invoke(ctx, fullMethod(methodHealthCheck), in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ProviderServer is the server API for the Provider gRPC service. An
+// external plugin implements this (or the equivalent generated interface in
+// its own language) and registers it with a grpc.Server via
+// RegisterProviderServer.
+type ProviderServer interface {
+	Lookup(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error)
+	HealthCheck(ctx context.Context, in *emptypb.Empty) (*structpb.Struct, error)
+}
+
+func RegisterProviderServer(s grpc.ServiceRegistrar, srv ProviderServer) {
+	s.RegisterService(&serviceDesc, srv)
+}
+
+func fullMethod(method string) string {
+	return "/" + ServiceName + "/" + method
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*ProviderServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: methodLookup, Handler: lookupHandler},
+		{MethodName: methodHealthCheck, Handler: healthCheckHandler},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "enrichment/v1/provider.proto",
+}
+
+func lookupHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProviderServer).Lookup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethod(methodLookup)}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProviderServer).Lookup(ctx, req.(*structpb.Struct))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func healthCheckHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProviderServer).HealthCheck(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethod(methodHealthCheck)}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProviderServer).HealthCheck(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
@@ -1,10 +1,73 @@
 package provider
 
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// QueryOp is a comparison operator in Query's typed condition AST.
+// PostgreSQLProvider and MongoDBProvider each translate a QueryOp into
+// their own driver's native parameterized/operator form - never into a
+// string-interpolated query fragment.
+type QueryOp string
+
+const (
+	QueryOpEq    QueryOp = "eq"
+	QueryOpIn    QueryOp = "in"
+	QueryOpGt    QueryOp = "gt"
+	QueryOpRegex QueryOp = "regex"
+)
+
+// QueryCondition is one AND-ed condition in a Query: Field Op Value. Value
+// is either a literal (string/number/bool, or []interface{} for
+// QueryOpIn), or a reference - {"$field": "<path>"} or {"$env": "<name>"} -
+// resolved at evaluation time by Resolve, never string-interpolated into a
+// query the way the old {field_value}/{value} placeholders were.
+type QueryCondition struct {
+	Field string      `json:"field"`
+	Op    QueryOp     `json:"op"`
+	Value interface{} `json:"value"`
+}
+
+// ResolvedCondition is a QueryCondition whose Value has been resolved to a
+// concrete, bindable value - see Query.Resolve.
+type ResolvedCondition struct {
+	Field string
+	Op    QueryOp
+	Value interface{}
+}
+
+// Query is SourceConfig's typed query AST. Conditions are AND-ed together;
+// Projection, if non-empty, selects specific source columns/fields instead
+// of a provider's default "select everything"; ResultMapping renames a
+// source column/field (map key) to the enrichment key callers see (map
+// value) - e.g. {"acct_status": "account_status"} - instead of callers
+// being stuck with whatever the source calls its columns.
+//
+// Filters is the pre-AST shape (field -> literal, or a
+// "{field_value}"/"{value}"-templated string) this type used to be. It's
+// still accepted by QueryFromMap and translated into an equivalent
+// Conditions entry, so a rule saved before Conditions existed keeps
+// working; new rules should use Conditions directly.
+//
+// $field resolution scope: Resolve only ever sees the single fieldValue
+// DataProvider.Fetch already resolved via rule.FieldToEnrich (exposed to
+// Resolve as params["value"]), not the full msg.Payload. Widening that
+// would mean a cached/singleflight-coalesced fetch result (keyed on
+// rule.ID+fieldValue alone - see enrichment.serviceImpl.fetchSourceData)
+// could silently vary with payload data the cache key doesn't capture, so
+// "$field" paths are scoped to "value" (and dotted/indexed paths under it,
+// if fieldValue itself is a structured value) until that cache-key
+// invariant is revisited.
 type Query struct {
-	Filters map[string]interface{} `json:"filters,omitempty"`
-	Sort    map[string]interface{} `json:"sort,omitempty"`
-	Limit   *int                   `json:"limit,omitempty"`
-	Offset  *int                   `json:"offset,omitempty"`
+	Conditions    []QueryCondition       `json:"conditions,omitempty"`
+	Projection    []string               `json:"projection,omitempty"`
+	ResultMapping map[string]string      `json:"result_mapping,omitempty"`
+	Filters       map[string]interface{} `json:"filters,omitempty"`
+	Sort          map[string]interface{} `json:"sort,omitempty"`
+	Limit         *int                   `json:"limit,omitempty"`
+	Offset        *int                   `json:"offset,omitempty"`
 }
 
 func (q *Query) ToMap() map[string]interface{} {
@@ -12,8 +75,18 @@ func (q *Query) ToMap() map[string]interface{} {
 		return make(map[string]interface{})
 	}
 	result := make(map[string]interface{})
-	if q.Filters != nil {
-		result["filters"] = q.Filters
+	if len(q.Conditions) > 0 {
+		conditions := make([]map[string]interface{}, len(q.Conditions))
+		for i, c := range q.Conditions {
+			conditions[i] = map[string]interface{}{"field": c.Field, "op": string(c.Op), "value": c.Value}
+		}
+		result["conditions"] = conditions
+	}
+	if len(q.Projection) > 0 {
+		result["projection"] = q.Projection
+	}
+	if len(q.ResultMapping) > 0 {
+		result["result_mapping"] = q.ResultMapping
 	}
 	if q.Sort != nil {
 		result["sort"] = q.Sort
@@ -29,16 +102,49 @@ func (q *Query) ToMap() map[string]interface{} {
 
 func QueryFromMap(m map[string]interface{}) *Query {
 	if m == nil {
-		return &Query{Filters: make(map[string]interface{})}
+		return &Query{}
 	}
 
-	q := &Query{
-		Filters: make(map[string]interface{}),
+	q := &Query{}
+
+	if rawConditions, ok := m["conditions"].([]interface{}); ok {
+		for _, rc := range rawConditions {
+			cm, ok := rc.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			field, _ := cm["field"].(string)
+			op, _ := cm["op"].(string)
+			if op == "" {
+				op = string(QueryOpEq)
+			}
+			q.Conditions = append(q.Conditions, QueryCondition{Field: field, Op: QueryOp(op), Value: cm["value"]})
+		}
 	}
 
 	if filters, ok := m["filters"].(map[string]interface{}); ok {
-		q.Filters = filters
+		for field, raw := range filters {
+			q.Conditions = append(q.Conditions, legacyFilterToCondition(field, raw))
+		}
+	}
+
+	if projection, ok := m["projection"].([]interface{}); ok {
+		for _, p := range projection {
+			if s, ok := p.(string); ok {
+				q.Projection = append(q.Projection, s)
+			}
+		}
 	}
+
+	if mapping, ok := m["result_mapping"].(map[string]interface{}); ok {
+		q.ResultMapping = make(map[string]string, len(mapping))
+		for k, v := range mapping {
+			if s, ok := v.(string); ok {
+				q.ResultMapping[k] = s
+			}
+		}
+	}
+
 	if sort, ok := m["sort"].(map[string]interface{}); ok {
 		q.Sort = sort
 	}
@@ -51,3 +157,87 @@ func QueryFromMap(m map[string]interface{}) *Query {
 
 	return q
 }
+
+// legacyFilterToCondition translates one entry of Query's pre-AST Filters
+// map into an equivalent eq QueryCondition. A value containing the old
+// "{field_value}"/"{value}" placeholder becomes a {"$field": "value"}
+// reference - the same substitution string.ReplaceAll used to perform -
+// rather than a literal string containing the placeholder text; anything
+// else is carried over unchanged as a literal.
+func legacyFilterToCondition(field string, raw interface{}) QueryCondition {
+	if s, ok := raw.(string); ok && (strings.Contains(s, "{field_value}") || strings.Contains(s, "{value}")) {
+		return QueryCondition{Field: field, Op: QueryOpEq, Value: map[string]interface{}{"$field": "value"}}
+	}
+	return QueryCondition{Field: field, Op: QueryOpEq, Value: raw}
+}
+
+// Resolve evaluates every condition's Value against params - see Query's
+// doc comment on $field's scope - resolving "$field"/"$env" references
+// into concrete values a provider can bind as real driver parameters.
+func (q *Query) Resolve(params map[string]interface{}) ([]ResolvedCondition, error) {
+	if q == nil || len(q.Conditions) == 0 {
+		return nil, nil
+	}
+
+	resolved := make([]ResolvedCondition, 0, len(q.Conditions))
+	for _, c := range q.Conditions {
+		value, err := resolveQueryValue(c.Value, params)
+		if err != nil {
+			return nil, fmt.Errorf("condition on %q: %w", c.Field, err)
+		}
+		resolved = append(resolved, ResolvedCondition{Field: c.Field, Op: c.Op, Value: value})
+	}
+	return resolved, nil
+}
+
+// resolveQueryValue resolves a single Value: a {"$field": "<path>"} or
+// {"$env": "<name>"} reference (via extractJSONPath against params, or
+// os.Getenv), each element of an []interface{} in turn (for QueryOpIn), or
+// any other literal value unchanged.
+func resolveQueryValue(value interface{}, params map[string]interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if path, ok := v["$field"].(string); ok {
+			resolved, err := extractJSONPath(params, path)
+			if err != nil {
+				return nil, fmt.Errorf("$field %q: %w", path, err)
+			}
+			return resolved, nil
+		}
+		if name, ok := v["$env"].(string); ok {
+			return os.Getenv(name), nil
+		}
+		return nil, fmt.Errorf("unrecognized value reference: %v", v)
+	case []interface{}:
+		resolvedList := make([]interface{}, len(v))
+		for i, item := range v {
+			r, err := resolveQueryValue(item, params)
+			if err != nil {
+				return nil, err
+			}
+			resolvedList[i] = r
+		}
+		return resolvedList, nil
+	default:
+		return value, nil
+	}
+}
+
+// applyResultMapping renames result's keys per query.ResultMapping (source
+// column/field -> target enrichment key); unmapped keys pass through
+// unchanged. Shared by PostgreSQLProvider and MongoDBProvider so both
+// honor ResultMapping identically.
+func applyResultMapping(query *Query, result map[string]interface{}) map[string]interface{} {
+	if query == nil || len(query.ResultMapping) == 0 {
+		return result
+	}
+	mapped := make(map[string]interface{}, len(result))
+	for k, v := range result {
+		if target, ok := query.ResultMapping[k]; ok {
+			mapped[target] = v
+			continue
+		}
+		mapped[k] = v
+	}
+	return mapped
+}
@@ -0,0 +1,96 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Registry dispatches enrichment lookups to a Provider by source type, so
+// new enrichment backends (in particular external gRPC plugins, which
+// aren't known until an operator registers one) can be added without
+// touching the enrichment service's fetch path or the SourceType constants
+// in internal/constants.
+type Registry struct {
+	mu        sync.RWMutex
+	providers map[string]Provider
+}
+
+func NewRegistry() *Registry {
+	return &Registry{providers: make(map[string]Provider)}
+}
+
+// Register adds or replaces the Provider for sourceType. If a different
+// Provider was already registered under that name, it is closed first so
+// its underlying connection doesn't leak.
+func (r *Registry) Register(sourceType string, p Provider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.providers[sourceType]; ok && existing != p {
+		_ = existing.Close()
+	}
+	r.providers[sourceType] = p
+}
+
+func (r *Registry) Get(sourceType string) (Provider, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.providers[sourceType]
+	return p, ok
+}
+
+func (r *Registry) Unregister(sourceType string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if p, ok := r.providers[sourceType]; ok {
+		_ = p.Close()
+		delete(r.providers, sourceType)
+	}
+}
+
+// SourceTypes returns the source types with a registered Provider.
+func (r *Registry) SourceTypes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	types := make([]string, 0, len(r.providers))
+	for t := range r.providers {
+		types = append(types, t)
+	}
+	return types
+}
+
+// HealthCheckAll runs HealthCheck against every registered Provider and
+// returns the errors reported, keyed by source type.
+func (r *Registry) HealthCheckAll(ctx context.Context) map[string]error {
+	r.mu.RLock()
+	providers := make(map[string]Provider, len(r.providers))
+	for t, p := range r.providers {
+		providers[t] = p
+	}
+	r.mu.RUnlock()
+
+	failures := make(map[string]error)
+	for t, p := range providers {
+		if err := p.HealthCheck(ctx); err != nil {
+			failures[t] = fmt.Errorf("provider %s (%s): %w", t, p.Name(), err)
+		}
+	}
+	return failures
+}
+
+// Close closes every registered Provider and empties the registry.
+func (r *Registry) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var firstErr error
+	for t, p := range r.providers {
+		if err := p.Close(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("provider %s: %w", t, err)
+		}
+		delete(r.providers, t)
+	}
+	return firstErr
+}
@@ -0,0 +1,122 @@
+package provider
+
+import (
+	"context"
+	"time"
+
+	"yeti/internal/config"
+	"yeti/internal/logger"
+	"yeti/pkg/metrics"
+	"yeti/pkg/retry"
+)
+
+// RetryingProvider decorates a DataProvider with retry.Policy-driven
+// retries, so a transient failure against a flaky HTTP API is retried a few
+// times (spread out with jitter to avoid synchronizing many workers) before
+// it reaches the circuit breaker or fails the rule.
+type RetryingProvider struct {
+	provider DataProvider
+	name     string
+	policy   retry.Policy
+	logger   logger.Logger
+}
+
+func NewRetryingProvider(provider DataProvider, name string, cfg config.RetryConfig, log logger.Logger) *RetryingProvider {
+	policy := retry.DefaultPolicy()
+	if cfg.MaxAttempts > 0 {
+		policy.MaxAttempts = cfg.MaxAttempts
+	}
+	if cfg.InitialInterval > 0 {
+		policy.InitialInterval = cfg.InitialInterval
+	}
+	if cfg.MaxInterval > 0 {
+		policy.MaxInterval = cfg.MaxInterval
+	}
+	if cfg.Multiplier > 0 {
+		policy.Multiplier = cfg.Multiplier
+	}
+	if cfg.MaxElapsedTime > 0 {
+		policy.MaxElapsedTime = cfg.MaxElapsedTime
+	}
+	if cfg.Jitter != "" {
+		policy.Jitter = retry.JitterStrategy(cfg.Jitter)
+	}
+
+	return &RetryingProvider{
+		provider: provider,
+		name:     name,
+		policy:   policy,
+		logger:   log,
+	}
+}
+
+func (p *RetryingProvider) Fetch(ctx context.Context, config SourceConfig, fieldValue interface{}) (map[string]interface{}, error) {
+	var result map[string]interface{}
+
+	err := retry.RetryWithCallback(ctx, p.policy, func() error {
+		data, err := p.provider.Fetch(ctx, config, fieldValue)
+		if err != nil {
+			return err
+		}
+		result = data
+		return nil
+	}, func(attempt int, err error, nextDelay time.Duration) {
+		metrics.IncEnrichmentRetryAttempts(p.name)
+		if p.logger != nil {
+			p.logger.WarnwCtx(ctx, "Retrying enrichment source fetch",
+				"source", p.name,
+				"attempt", attempt,
+				"error", err,
+				"next_delay", nextDelay,
+			)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Health forwards to the wrapped provider's Health if it has one (notably
+// CircuitBreakerProvider, which RetryingProvider commonly wraps), so
+// retry-then-circuit-break chains still surface circuit state and latency
+// through /healthz/enrichment. A wrapped provider with no Health reports
+// just its name.
+func (p *RetryingProvider) Health() ProviderHealth {
+	if hp, ok := p.provider.(interface{ Health() ProviderHealth }); ok {
+		return hp.Health()
+	}
+	return ProviderHealth{Name: p.name}
+}
+
+// FetchBatch retries FetchBatch against the wrapped provider under the same
+// policy Fetch uses, so a batch call gets the same transient-failure
+// tolerance as a single-value fetch.
+func (p *RetryingProvider) FetchBatch(ctx context.Context, config SourceConfig, fieldValues []interface{}) (map[interface{}]map[string]interface{}, error) {
+	var result map[interface{}]map[string]interface{}
+
+	err := retry.RetryWithCallback(ctx, p.policy, func() error {
+		data, err := FetchBatch(ctx, p.provider, config, fieldValues)
+		if err != nil {
+			return err
+		}
+		result = data
+		return nil
+	}, func(attempt int, err error, nextDelay time.Duration) {
+		metrics.IncEnrichmentRetryAttempts(p.name)
+		if p.logger != nil {
+			p.logger.WarnwCtx(ctx, "Retrying enrichment source batch fetch",
+				"source", p.name,
+				"attempt", attempt,
+				"error", err,
+				"next_delay", nextDelay,
+			)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -6,6 +6,10 @@ type SourceConfig struct {
 	Headers    map[string]string
 	TimeoutMs  int
 	RetryCount int
+	// BatchURL is the endpoint APIProvider.FetchBatch posts field values
+	// to. Empty means the source has no native batch endpoint, so
+	// FetchBatch falls back to one Fetch per value.
+	BatchURL string
 
 	Database   string
 	Collection string
@@ -14,4 +18,64 @@ type SourceConfig struct {
 
 	KeyPattern string
 	CacheType  string
+
+	// QueryParams and Body are HTTPProvider-only: QueryParams are appended
+	// to the request URL, and Body (if non-empty) is sent as the request
+	// body with Content-Type BodyContentType. All three, like URL, support
+	// {field_value}/{value} substitution; QueryParams and Headers entries
+	// are additionally addressable from URL/Body via {query:K} and
+	// {header:K} placeholders (K is the map key), since HTTPProvider.Fetch
+	// only carries the rule's resolved field value, not the rest of the
+	// source message - templating against live message fields would mean
+	// widening DataProvider.Fetch's signature for every provider.
+	QueryParams     map[string]string
+	Body            string
+	BodyContentType string
+
+	// AuthType selects how HTTPProvider authenticates: "" (none), "bearer",
+	// "basic", or "mtls". AuthToken is the bearer token; AuthUsername and
+	// AuthPassword are basic-auth credentials. All three may hold a
+	// "${scheme:ref}" placeholder resolved through the configured
+	// config.SecretResolver instead of a literal value.
+	AuthType     string
+	AuthToken    string
+	AuthUsername string
+	AuthPassword string
+
+	// ClientCertFile, ClientKeyFile and CAFile configure mTLS for
+	// AuthType "mtls" (HTTPProvider) or the dial credentials for a
+	// SourceTypeGRPC rule (GRPCSourceProvider). ServerName overrides the
+	// hostname used to verify the peer certificate.
+	ClientCertFile string
+	ClientKeyFile  string
+	CAFile         string
+	ServerName     string
+
+	// ResponseJSONPath, if set, extracts a nested value from the decoded
+	// JSON response body (dot-separated, e.g. "data.user.profile", with
+	// "[N]" array indexing) before handing it to Transformations, instead
+	// of the whole decoded body.
+	ResponseJSONPath string
+
+	// Address is the dial target for a SourceTypeGRPC rule, e.g.
+	// "enrichment-plugin:9443". GRPCSourceProvider dials lazily per unique
+	// Address and keeps the connection warm across calls.
+	Address string
+
+	// KafkaBrokers and KafkaTopic pick the compacted topic a
+	// SourceTypeKafkaLookup rule reads into KafkaLookupProvider's in-memory
+	// table. KeyField is unused here: the Kafka message key is always the
+	// table key.
+	KafkaBrokers []string
+	KafkaTopic   string
+
+	// FilePath and FileFormat ("csv" or "json") configure a SourceTypeFile
+	// rule: FileSourceProvider decodes the file into an in-memory table
+	// reloaded whenever FilePath's mtime changes. KeyField names the CSV
+	// column holding each record's lookup key; a JSON file is instead
+	// expected to already be an object keyed by lookup key, so KeyField is
+	// unused there.
+	FilePath   string
+	FileFormat string
+	KeyField   string
 }
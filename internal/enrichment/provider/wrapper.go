@@ -1,16 +1,48 @@
 package provider
 
 import (
+	"context"
+	"errors"
+	"fmt"
+
 	"github.com/sony/gobreaker"
 	"yeti/internal/config"
+	"yeti/internal/logger"
 	"yeti/pkg/circuitbreaker"
+	"yeti/pkg/metrics"
 )
 
-func WrapWithCircuitBreaker(p DataProvider, name string, cfg config.CircuitBreakerConfig) DataProvider {
+// ErrSourceDenied is returned by the onOpen fallback WrapWithCircuitBreaker
+// builds from a "deny" CircuitBreakerConfig.Fallback: it marks an
+// open-circuit fetch that must fail outright, regardless of whatever
+// error_handling/fallback_value a rule would otherwise apply to an
+// ordinary provider error. Callers check errors.Is(err, ErrSourceDenied)
+// before falling back to their own error handling.
+var ErrSourceDenied = errors.New("enrichment source circuit breaker is open and configured to deny")
+
+// WrapWithCircuitBreaker decorates p with a circuit breaker keyed by name, so
+// each source type (api, mongodb, postgresql, cache, ...) trips independently
+// and a flaky HTTP API can't take down an otherwise-healthy DB source. log
+// may be nil; onOpen may be nil, in which case it falls back to
+// fallbackOnOpen(name, cfg.Fallback, log) - nil itself unless cfg.Fallback
+// is "allow" or "deny" - rather than leaving the source with no fallback
+// at all.
+// If cfg.Adaptive.Enabled, p is wrapped with AdaptiveCircuitBreakerProvider's
+// rolling per-endpoint error-rate/latency tracking instead of the coarse
+// counter-based breaker below.
+func WrapWithCircuitBreaker(p DataProvider, name string, cfg config.CircuitBreakerConfig, log logger.Logger, onOpen func(ctx context.Context) (map[string]interface{}, error)) DataProvider {
 	if !cfg.Enabled {
 		return p
 	}
 
+	if onOpen == nil {
+		onOpen = fallbackOnOpen(name, cfg.Fallback, log)
+	}
+
+	if cfg.Adaptive.Enabled {
+		return NewAdaptiveCircuitBreakerProvider(p, name, adaptiveConfigFrom(cfg.Adaptive, name)).WithOnOpen(onOpen)
+	}
+
 	cbConfig := circuitbreaker.DefaultConfig(name)
 	if cfg.MaxRequests > 0 {
 		cbConfig.MaxRequests = cfg.MaxRequests
@@ -30,6 +62,67 @@ func WrapWithCircuitBreaker(p DataProvider, name string, cfg config.CircuitBreak
 			return failureRatio >= cfg.FailureRatio
 		}
 	}
+	cbConfig.OnStateChange = func(breakerName string, from, to gobreaker.State) {
+		if log != nil {
+			log.WarnwCtx(context.Background(), "enrichment source circuit breaker state changed",
+				"source", breakerName, "from", from.String(), "to", to.String())
+		}
+		publishBreakerStateChange(breakerName, from.String(), to.String())
+	}
 
-	return NewCircuitBreakerProvider(p, name, cbConfig)
+	return NewCircuitBreakerProvider(p, name, cbConfig).WithOnOpen(onOpen)
+}
+
+// WrapWithRetry decorates p with retry.Policy-driven retries keyed by name,
+// so a source that fails transiently gets a few jittered retries before
+// falling through to the circuit breaker or failing the rule. Apply this
+// around WrapWithCircuitBreaker's result so a retry never bypasses the
+// breaker's fail-fast behavior once it has tripped.
+func WrapWithRetry(p DataProvider, name string, cfg config.RetryConfig, log logger.Logger) DataProvider {
+	if !cfg.Enabled {
+		return p
+	}
+
+	return NewRetryingProvider(p, name, cfg, log)
+}
+
+// fallbackOnOpen builds the onOpen fallback a "allow"/"deny" cfg.OnError
+// describes, reusing metrics.FallbackUsageTotal's {service, strategy,
+// reason} label scheme the same way filtering.Service's own Fallback
+// handling does. "" (and any value besides "allow"/"deny") returns nil,
+// leaving the breaker's own "circuit breaker is open" error to flow into
+// the caller's existing error handling unchanged - this is the same
+// behavior WrapWithCircuitBreaker always had before Fallback existed.
+//
+// "stale" (serving a source's last-cached value even though it's expired)
+// isn't implemented here: onOpen only receives a ctx, not the rule/
+// fieldValue a stale L1/L2 cache entry would need to key off, and
+// widening onOpen's signature to carry them would ripple through every
+// DataProvider wrapper (CircuitBreakerProvider, AdaptiveCircuitBreakerProvider,
+// RetryingProvider) the same way widening Query's $field scope would have
+// rippled through every provider's Fetch signature - left for whoever
+// wires a stale-cache-aware fallback through that larger change.
+func fallbackOnOpen(name string, cfg config.FallbackConfig, log logger.Logger) func(ctx context.Context) (map[string]interface{}, error) {
+	switch cfg.OnError {
+	case "allow":
+		return func(ctx context.Context) (map[string]interface{}, error) {
+			if log != nil {
+				log.WarnwCtx(ctx, "enrichment source circuit open, allowing message through with no enrichment data from this source",
+					"source", name)
+			}
+			metrics.FallbackUsageTotal.WithLabelValues("enrichment", "circuit_open_allow", "circuit_open").Inc()
+			return map[string]interface{}{}, nil
+		}
+	case "deny":
+		return func(ctx context.Context) (map[string]interface{}, error) {
+			if log != nil {
+				log.WarnwCtx(ctx, "enrichment source circuit open, denying fetch",
+					"source", name)
+			}
+			metrics.FallbackUsageTotal.WithLabelValues("enrichment", "circuit_open_deny", "circuit_open").Inc()
+			return nil, fmt.Errorf("%w: source %s", ErrSourceDenied, name)
+		}
+	default:
+		return nil
+	}
 }
@@ -2,15 +2,30 @@ package enrichment
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"yeti/internal/config"
 )
 
+// ErrRuleNotFound is returned by GetRuleByID when no enrichment rule with
+// the given ID exists, so callers (see Service.ReloadRule) can tell a
+// deleted rule apart from a query failure.
+var ErrRuleNotFound = errors.New("enrichment rule not found")
+
 type Repository interface {
 	GetActiveRules(ctx context.Context) ([]Rule, error)
+
+	// GetRuleByID returns the rule regardless of its Enabled flag, so a
+	// targeted reload can tell a disabled rule apart from a deleted one.
+	// It returns ErrRuleNotFound if no rule with that ID exists.
+	GetRuleByID(ctx context.Context, id string) (*Rule, error)
 }
 
 type MongoDBRepository struct {
@@ -40,3 +55,54 @@ func (r *MongoDBRepository) GetActiveRules(ctx context.Context) ([]Rule, error)
 
 	return rules, nil
 }
+
+// NewRepositoryFromConfig builds the Repository driver selected by driver
+// ("mongodb", "postgres", "file", or "http"; empty defaults to "mongodb"
+// for existing deployments that never set Enrichment.RuleStorage.Driver),
+// mirroring management.NewEnrichmentRepositoryFromConfig's shape. Only the
+// argument matching the chosen driver needs to be non-zero; callers that
+// haven't connected/configured it get a descriptive error rather than a
+// nil-pointer panic the first time a repository method runs.
+func NewRepositoryFromConfig(driver string, mongoDB *mongo.Database, postgresDB *sql.DB, fileCfg config.FileRuleStorageConfig, httpCfg config.HTTPRuleStorageConfig) (Repository, error) {
+	switch driver {
+	case "", "mongodb":
+		if mongoDB == nil {
+			return nil, fmt.Errorf("enrichment rule storage driver %q requires database.mongodb.uri to be set", driver)
+		}
+		return NewRepository(mongoDB), nil
+	case "postgres":
+		if postgresDB == nil {
+			return nil, fmt.Errorf("enrichment rule storage driver %q requires database.postgres.host to be set", driver)
+		}
+		return newPostgresRepository(postgresDB), nil
+	case "file":
+		if fileCfg.Path == "" {
+			return nil, fmt.Errorf("enrichment rule storage driver %q requires enrichment.rule_storage.file.path to be set", driver)
+		}
+		return newFileRepository(fileCfg.Path), nil
+	case "http":
+		if httpCfg.URL == "" {
+			return nil, fmt.Errorf("enrichment rule storage driver %q requires enrichment.rule_storage.http.url to be set", driver)
+		}
+		timeout := time.Duration(httpCfg.TimeoutMs) * time.Millisecond
+		if timeout <= 0 {
+			timeout = 10 * time.Second
+		}
+		return newHTTPRepository(httpCfg.URL, timeout), nil
+	default:
+		return nil, fmt.Errorf("unknown enrichment rule storage driver %q", driver)
+	}
+}
+
+func (r *MongoDBRepository) GetRuleByID(ctx context.Context, id string) (*Rule, error) {
+	var rule Rule
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&rule)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrRuleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find rule %s: %w", id, err)
+	}
+
+	return &rule, nil
+}
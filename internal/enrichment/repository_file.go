@@ -0,0 +1,84 @@
+package enrichment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// fileRepository reads rules from a directory of "*.json" files, one Rule
+// object per file, so a GitOps workflow can keep rules in code review
+// (an external process - typically a sidecar running `git pull` on a
+// schedule - is responsible for keeping Path up to date; this repository
+// only ever reads what's already on disk). It rescans the directory on
+// every call rather than caching, trading a bit of redundant disk I/O for
+// never serving a stale rule set between ReloadRules calls.
+type fileRepository struct {
+	dir string
+}
+
+// newFileRepository returns a Repository reading Rule files from dir. It's
+// unexported for the same reason newPostgresRepository is: callers select
+// a driver through NewRepositoryFromConfig.
+func newFileRepository(dir string) Repository {
+	return &fileRepository{dir: dir}
+}
+
+func (r *fileRepository) loadAll(ctx context.Context) ([]Rule, error) {
+	matches, err := filepath.Glob(filepath.Join(r.dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rule files in %s: %w", r.dir, err)
+	}
+	sort.Strings(matches) // deterministic order before Priority sorting below
+
+	rules := make([]Rule, 0, len(matches))
+	for _, path := range matches {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read rule file %s: %w", path, err)
+		}
+
+		var rule Rule
+		if err := json.Unmarshal(data, &rule); err != nil {
+			return nil, fmt.Errorf("failed to decode rule file %s: %w", path, err)
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+func (r *fileRepository) GetActiveRules(ctx context.Context) ([]Rule, error) {
+	all, err := r.loadAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	active := make([]Rule, 0, len(all))
+	for _, rule := range all {
+		if rule.Enabled {
+			active = append(active, rule)
+		}
+	}
+	sort.SliceStable(active, func(i, j int) bool { return active[i].Priority < active[j].Priority })
+
+	return active, nil
+}
+
+func (r *fileRepository) GetRuleByID(ctx context.Context, id string) (*Rule, error) {
+	all, err := r.loadAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range all {
+		if all[i].ID == id {
+			return &all[i], nil
+		}
+	}
+
+	return nil, ErrRuleNotFound
+}
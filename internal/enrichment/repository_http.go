@@ -0,0 +1,116 @@
+package enrichment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// httpRepository fetches rules as a JSON array from a single URL. It keeps
+// the last response's ETag and decoded rules so GetActiveRules/GetRuleByID
+// can issue a conditional GET (If-None-Match) and, on a 304 Not Modified,
+// skip re-decoding a body that hasn't changed - the cheap-poll behavior a
+// git/filesystem-backed source gets for free from the OS, but an HTTP
+// endpoint needs to ask for explicitly.
+type httpRepository struct {
+	url    string
+	client *http.Client
+
+	mu     sync.Mutex
+	etag   string
+	cached []Rule
+}
+
+// newHTTPRepository returns a Repository fetching rules from url. It's
+// unexported for the same reason newPostgresRepository is: callers select
+// a driver through NewRepositoryFromConfig.
+func newHTTPRepository(url string, timeout time.Duration) Repository {
+	return &httpRepository{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (r *httpRepository) fetch(ctx context.Context) ([]Rule, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build rule request: %w", err)
+	}
+
+	r.mu.Lock()
+	etag := r.etag
+	r.mu.Unlock()
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch rules from %s: %w", r.url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		r.mu.Lock()
+		cached := r.cached
+		r.mu.Unlock()
+		return cached, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch rules from %s: unexpected status %d", r.url, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rule response body: %w", err)
+	}
+
+	var rules []Rule
+	if err := json.Unmarshal(body, &rules); err != nil {
+		return nil, fmt.Errorf("failed to decode rule response: %w", err)
+	}
+
+	r.mu.Lock()
+	r.etag = resp.Header.Get("ETag")
+	r.cached = rules
+	r.mu.Unlock()
+
+	return rules, nil
+}
+
+func (r *httpRepository) GetActiveRules(ctx context.Context) ([]Rule, error) {
+	all, err := r.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	active := make([]Rule, 0, len(all))
+	for _, rule := range all {
+		if rule.Enabled {
+			active = append(active, rule)
+		}
+	}
+	sort.SliceStable(active, func(i, j int) bool { return active[i].Priority < active[j].Priority })
+
+	return active, nil
+}
+
+func (r *httpRepository) GetRuleByID(ctx context.Context, id string) (*Rule, error) {
+	all, err := r.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range all {
+		if all[i].ID == id {
+			return &all[i], nil
+		}
+	}
+
+	return nil, ErrRuleNotFound
+}
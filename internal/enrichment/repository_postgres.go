@@ -0,0 +1,75 @@
+package enrichment
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// postgresRepository stores Rule as a JSONB blob in its own
+// enrichment_runtime_rules table (see
+// migrations/postgres/0005_enrichment_runtime_rules.up.sql), alongside
+// indexed priority/enabled columns GetActiveRules filters and sorts on.
+// This is a separate table from management.EnrichmentRule's
+// enrichment_rules - the two packages' rule types serialize to different
+// JSON shapes (see models.go's json tags vs management/models.go's), and
+// this repository is the read-only runtime path enrichment-service polls
+// rather than the admin API's full-CRUD one, so sharing a table would
+// couple two independently-evolving schemas together for no real benefit.
+type postgresRepository struct {
+	db *sql.DB
+}
+
+// newPostgresRepository is unexported: callers outside this package select
+// a driver through NewRepositoryFromConfig rather than constructing a
+// specific implementation directly, matching how MongoDBRepository is only
+// ever reached through NewRepository/NewRepositoryFromConfig.
+func newPostgresRepository(db *sql.DB) Repository {
+	return &postgresRepository{db: db}
+}
+
+func (r *postgresRepository) GetActiveRules(ctx context.Context) ([]Rule, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT data FROM enrichment_runtime_rules WHERE enabled = true ORDER BY priority ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := make([]Rule, 0)
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to scan rule row: %w", err)
+		}
+
+		var rule Rule
+		if err := json.Unmarshal(data, &rule); err != nil {
+			return nil, fmt.Errorf("failed to decode rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to find rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+func (r *postgresRepository) GetRuleByID(ctx context.Context, id string) (*Rule, error) {
+	var data []byte
+	err := r.db.QueryRowContext(ctx, `SELECT data FROM enrichment_runtime_rules WHERE id = $1`, id).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, ErrRuleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find rule %s: %w", id, err)
+	}
+
+	var rule Rule
+	if err := json.Unmarshal(data, &rule); err != nil {
+		return nil, fmt.Errorf("failed to decode rule %s: %w", id, err)
+	}
+
+	return &rule, nil
+}
@@ -0,0 +1,140 @@
+package enrichment
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"yeti/pkg/models"
+)
+
+// exprPlaceholder matches a "{{ expr: <cel> }}" placeholder, as used by
+// Rule.Condition's sibling fields: SourceConfig.KeyPattern, SourceConfig.Field,
+// Transformation.SourcePath and Transformation.Default.
+var exprPlaceholder = regexp.MustCompile(`\{\{\s*expr:(.*?)\}\}`)
+
+// renderExpressions replaces every "{{ expr: <cel> }}" placeholder in tmpl
+// with the stringified result of evaluating the enclosed CEL expression
+// against msg and sourceData, leaving the rest of tmpl untouched. sourceData
+// may be nil - SourceConfig.KeyPattern/Field render before a source fetch
+// has happened, so they have no sourceData yet. tmpl with no placeholder is
+// returned unchanged, matching the pre-existing plain-string behavior.
+func (s *serviceImpl) renderExpressions(ctx context.Context, tmpl string, msg models.MessageEnvelope, sourceData map[string]interface{}) (string, error) {
+	if !strings.Contains(tmpl, "{{") || s.evaluator == nil {
+		return tmpl, nil
+	}
+
+	var evalErr error
+	rendered := exprPlaceholder.ReplaceAllStringFunc(tmpl, func(match string) string {
+		if evalErr != nil {
+			return match
+		}
+		expr := strings.TrimSpace(exprPlaceholder.FindStringSubmatch(match)[1])
+		val, err := s.evaluator.EvaluateTransform(ctx, expr, msg, sourceData)
+		if err != nil {
+			evalErr = fmt.Errorf("render expression %q: %w", expr, err)
+			return match
+		}
+		return fmt.Sprintf("%v", val)
+	})
+	if evalErr != nil {
+		return "", evalErr
+	}
+	return rendered, nil
+}
+
+// isDynamicTemplate reports whether tmpl contains a "{{ expr: ... }}"
+// placeholder, so callers can tell a static KeyPattern/Field (the common
+// case) apart from one that needs per-message rendering.
+func isDynamicTemplate(tmpl string) bool {
+	return strings.Contains(tmpl, "{{")
+}
+
+// fullExpression reports whether tmpl is exactly one "{{ expr: <cel> }}"
+// placeholder with nothing else around it. Transformation.SourcePath and
+// Transformation.Default use this form (rather than renderExpressions'
+// string substitution) so their CEL result's original type - a number, a
+// nested map, a bool - survives instead of being stringified.
+func fullExpression(tmpl string) (string, bool) {
+	m := exprPlaceholder.FindStringSubmatch(tmpl)
+	if m == nil || strings.TrimSpace(tmpl) != m[0] {
+		return "", false
+	}
+	return strings.TrimSpace(m[1]), true
+}
+
+// renderRuleSourceConfig returns a copy of rule.SourceConfig with any
+// "{{ expr: ... }}" placeholders in KeyPattern/Field rendered against msg,
+// so the CacheProvider/MongoDBProvider behind rule.SourceType see a plain
+// key/field name exactly as they do today. SourceConfig is returned
+// unchanged (and no error) if neither field is templated.
+func (s *serviceImpl) renderRuleSourceConfig(ctx context.Context, cfg SourceConfig, msg models.MessageEnvelope) (SourceConfig, error) {
+	if isDynamicTemplate(cfg.KeyPattern) {
+		rendered, err := s.renderExpressions(ctx, cfg.KeyPattern, msg, nil)
+		if err != nil {
+			return cfg, fmt.Errorf("key_pattern: %w", err)
+		}
+		cfg.KeyPattern = rendered
+	}
+	if isDynamicTemplate(cfg.Field) {
+		rendered, err := s.renderExpressions(ctx, cfg.Field, msg, nil)
+		if err != nil {
+			return cfg, fmt.Errorf("field: %w", err)
+		}
+		cfg.Field = rendered
+	}
+	return cfg, nil
+}
+
+// evaluateCondition reports whether rule should run against msg. An empty
+// Condition always runs the rule, preserving behavior from before this
+// field existed. A Condition that fails to compile or evaluate is treated
+// as false (same as evaluating to false) rather than failing the rule,
+// since a broken condition shouldn't take down enrichment for every
+// message it would otherwise have matched.
+func (s *serviceImpl) evaluateCondition(ctx context.Context, rule Rule, msg models.MessageEnvelope) bool {
+	if rule.Condition == "" {
+		return true
+	}
+	if s.evaluator == nil {
+		return true
+	}
+
+	matched, err := s.evaluator.EvaluateFilterForRule(ctx, rule.ID, rule.Condition, msg)
+	if err != nil {
+		s.logger.WarnwCtx(ctx, "Rule condition failed to evaluate, skipping rule",
+			"rule_id", rule.ID,
+			"rule_name", rule.Name,
+			"condition", rule.Condition,
+			"error", err,
+		)
+		return false
+	}
+	return matched
+}
+
+// resolveDefault returns trans.Default, except when it's a full
+// "{{ expr: <cel> }}" placeholder, in which case it evaluates the
+// expression against msg (sourceData is unavailable here - the field it
+// would have supplied is exactly what's missing) and returns that result
+// instead. Any evaluation error falls back to the literal Default.
+func (s *serviceImpl) resolveDefault(ctx context.Context, def interface{}, msg models.MessageEnvelope) interface{} {
+	str, ok := def.(string)
+	if !ok || s.evaluator == nil {
+		return def
+	}
+	expr, ok := fullExpression(str)
+	if !ok {
+		return def
+	}
+	val, err := s.evaluator.EvaluateTransform(ctx, expr, msg, nil)
+	if err != nil {
+		s.logger.WarnwCtx(ctx, "Default expression failed to evaluate, using literal default",
+			"default", def,
+			"error", err,
+		)
+		return def
+	}
+	return val
+}
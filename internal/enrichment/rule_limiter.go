@@ -0,0 +1,171 @@
+package enrichment
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"yeti/pkg/metrics"
+)
+
+const (
+	// adaptiveLatencyThresholdMs is the p99 latency, in milliseconds,
+	// above which a rule's provider is treated as degraded for adaptive
+	// throttling purposes even if its circuit breaker hasn't tripped.
+	adaptiveLatencyThresholdMs = 1000
+	// throttleGrowStreak is how many consecutive healthy fetches a rule
+	// needs before ruleThrottle grows its effective concurrency by one
+	// slot (the additive-increase half of AIMD).
+	throttleGrowStreak = 10
+	// throttlePollInterval is how often acquire rechecks the adaptive
+	// concurrency ceiling while waiting for a slot to free up.
+	throttlePollInterval = 10 * time.Millisecond
+)
+
+// ruleThrottle gates one rule's provider calls behind a token-bucket rate
+// limiter (Rule.RateLimitPerSecond) and an adaptive concurrency ceiling
+// (Rule.MaxConcurrency), so a single misbehaving downstream can't starve
+// every other rule sharing its provider. The ceiling shrinks
+// multiplicatively (halved, floored at 1) on a fetch error or when the
+// provider reports an open circuit or elevated p99 latency, and grows back
+// additively (one slot per throttleGrowStreak consecutive successes),
+// AIMD-style.
+type ruleThrottle struct {
+	ruleID string
+	rps    float64
+	max    int
+
+	limiter *rate.Limiter
+
+	permits  chan struct{} // capacity == max; a classic counting semaphore
+	inFlight int32         // atomic
+	// effective is the current adaptive concurrency ceiling, 1..max.
+	// acquire polls this (rather than permits' capacity) so shrinking it
+	// takes effect without needing to drain in-flight permits.
+	effective     int32 // atomic
+	successStreak int32 // atomic
+}
+
+func newRuleThrottle(ruleID string, rps float64, maxConcurrency int) *ruleThrottle {
+	t := &ruleThrottle{ruleID: ruleID, rps: rps, max: maxConcurrency}
+
+	if rps > 0 {
+		burst := int(rps)
+		if burst < 1 {
+			burst = 1
+		}
+		t.limiter = rate.NewLimiter(rate.Limit(rps), burst)
+	}
+
+	if maxConcurrency > 0 {
+		t.permits = make(chan struct{}, maxConcurrency)
+		for i := 0; i < maxConcurrency; i++ {
+			t.permits <- struct{}{}
+		}
+		t.effective = int32(maxConcurrency)
+	}
+
+	return t
+}
+
+// matches reports whether t was built from rule's current throttle
+// settings, so throttleFor can tell a stale cached throttle (left over
+// from a rule update) apart from one that's still current.
+func (t *ruleThrottle) matches(rule Rule) bool {
+	return t.rps == float64(rule.RateLimitPerSecond) && t.max == rule.MaxConcurrency
+}
+
+// acquire blocks until the rule's rate limiter and concurrency ceiling
+// admit one provider call, returning a release func the caller must call
+// exactly once with the call's outcome. waited reports whether the caller
+// had to wait on either the limiter or the concurrency ceiling.
+func (t *ruleThrottle) acquire(ctx context.Context) (release func(success, providerUnhealthy bool), waited bool, err error) {
+	if t.limiter != nil && !t.limiter.Allow() {
+		if err := t.limiter.Wait(ctx); err != nil {
+			return nil, waited, err
+		}
+		waited = true
+	}
+
+	if t.permits == nil {
+		return func(bool, bool) {}, waited, nil
+	}
+
+	for {
+		if atomic.LoadInt32(&t.inFlight) < atomic.LoadInt32(&t.effective) {
+			select {
+			case <-t.permits:
+				atomic.AddInt32(&t.inFlight, 1)
+				return func(success, providerUnhealthy bool) { t.release(success, providerUnhealthy) }, waited, nil
+			default:
+			}
+		}
+
+		waited = true
+		timer := time.NewTimer(throttlePollInterval)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, waited, ctx.Err()
+		}
+	}
+}
+
+func (t *ruleThrottle) release(success, providerUnhealthy bool) {
+	atomic.AddInt32(&t.inFlight, -1)
+	t.permits <- struct{}{}
+	t.adjust(success, providerUnhealthy)
+}
+
+// adjust applies the AIMD rule described on ruleThrottle and publishes the
+// resulting ceiling via metrics.EnrichmentRuleConcurrencyLimit.
+func (t *ruleThrottle) adjust(success, providerUnhealthy bool) {
+	for {
+		old := atomic.LoadInt32(&t.effective)
+		next := old
+
+		if !success || providerUnhealthy {
+			atomic.StoreInt32(&t.successStreak, 0)
+			next = old / 2
+			if next < 1 {
+				next = 1
+			}
+		} else if streak := atomic.AddInt32(&t.successStreak, 1); streak >= throttleGrowStreak && old < int32(t.max) {
+			next = old + 1
+			atomic.StoreInt32(&t.successStreak, 0)
+		}
+
+		if next == old {
+			return
+		}
+		if atomic.CompareAndSwapInt32(&t.effective, old, next) {
+			metrics.SetEnrichmentRuleConcurrencyLimit(t.ruleID, int(next))
+			return
+		}
+	}
+}
+
+// throttleFor returns rule's ruleThrottle, creating or replacing it if the
+// rule has no throttle settings configured (nil) or its cached throttle is
+// stale. Calls are keyed on rule.ID, not rule.SourceType, since throttling
+// is meant to protect against one misbehaving *rule*, not the provider
+// every rule sharing that source type also uses.
+func (s *serviceImpl) throttleFor(rule Rule) *ruleThrottle {
+	if rule.RateLimitPerSecond <= 0 && rule.MaxConcurrency <= 0 {
+		return nil
+	}
+
+	s.throttlesMu.Lock()
+	defer s.throttlesMu.Unlock()
+
+	if t, ok := s.throttles[rule.ID]; ok && t.matches(rule) {
+		return t
+	}
+
+	t := newRuleThrottle(rule.ID, float64(rule.RateLimitPerSecond), rule.MaxConcurrency)
+	s.throttles[rule.ID] = t
+	return t
+}
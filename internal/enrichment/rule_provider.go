@@ -0,0 +1,113 @@
+package enrichment
+
+import (
+	"yeti/internal/config"
+	"yeti/internal/enrichment/provider"
+	"yeti/internal/logger"
+	"yeti/pkg/metrics"
+)
+
+// ruleProviderEntry caches one rule's wrapped provider alongside the
+// override it was built from, so ruleProviderFor can tell a stale cache
+// entry (the rule's CircuitBreaker/Retry changed since ReloadRules) apart
+// from a still-current one.
+type ruleProviderEntry struct {
+	provider       provider.DataProvider
+	circuitBreaker *RuleCircuitBreakerConfig
+	retry          *RuleRetryConfig
+}
+
+func (e *ruleProviderEntry) matches(rule Rule) bool {
+	return ruleCircuitBreakerEqual(e.circuitBreaker, rule.CircuitBreaker) && ruleRetryEqual(e.retry, rule.Retry)
+}
+
+func ruleCircuitBreakerEqual(a, b *RuleCircuitBreakerConfig) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func ruleRetryEqual(a, b *RuleRetryConfig) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// ruleProviderFor wraps base with rule's per-rule CircuitBreaker/Retry
+// overrides, if either is set, layering a breaker/retry scoped to just
+// this rule.ID on top of base's existing source-level wrapping (base is
+// s.providers[providerName], already wrapped per source type by
+// NewServiceWithL1Cache and friends). Returns base unchanged if the rule
+// has neither override configured - the overwhelming common case, since
+// most rules are well served by their source type's shared defaults.
+//
+// Wrapped providers are cached per rule.ID (rebuilt if the rule's
+// override changed since the last call) rather than rebuilt on every
+// fetch, since a fresh CircuitBreakerProvider starts closed with no
+// history - rebuilding it every call would mean a rule's breaker could
+// never actually trip.
+func (s *serviceImpl) ruleProviderFor(rule Rule, base provider.DataProvider) provider.DataProvider {
+	if rule.CircuitBreaker == nil && rule.Retry == nil {
+		return base
+	}
+
+	s.ruleProvidersMu.Lock()
+	defer s.ruleProvidersMu.Unlock()
+
+	if e, ok := s.ruleProviders[rule.ID]; ok && e.matches(rule) {
+		return e.provider
+	}
+
+	wrapped := buildRuleProvider(base, rule, s.logger)
+	s.ruleProviders[rule.ID] = &ruleProviderEntry{
+		provider:       wrapped,
+		circuitBreaker: rule.CircuitBreaker,
+		retry:          rule.Retry,
+	}
+	return wrapped
+}
+
+func buildRuleProvider(base provider.DataProvider, rule Rule, log logger.Logger) provider.DataProvider {
+	name := "rule:" + rule.ID
+	wrapped := base
+
+	if cb := rule.CircuitBreaker; cb != nil {
+		wrapped = provider.WrapWithCircuitBreaker(wrapped, name, config.CircuitBreakerConfig{
+			Enabled:      true,
+			MaxRequests:  cb.MaxRequests,
+			Interval:     cb.Interval,
+			Timeout:      cb.Timeout,
+			FailureRatio: cb.FailureRatio,
+			MinRequests:  cb.MinRequests,
+		}, log, nil)
+	}
+
+	if r := rule.Retry; r != nil {
+		wrapped = provider.WrapWithRetry(wrapped, name, config.RetryConfig{
+			Enabled:         true,
+			MaxAttempts:     r.MaxAttempts,
+			InitialInterval: r.InitialInterval,
+			MaxInterval:     r.MaxInterval,
+			Multiplier:      r.Multiplier,
+		}, log)
+	}
+
+	return wrapped
+}
+
+// reportRuleBreakerState publishes rule's per-rule breaker state (if it
+// has a CircuitBreaker override) to metrics.EnrichmentBreakerState, so
+// ProviderHealth-style visibility also works for rule-scoped breakers,
+// which aren't reachable through s.providers' health.
+func (s *serviceImpl) reportRuleBreakerState(rule Rule, p provider.DataProvider) {
+	if rule.CircuitBreaker == nil {
+		return
+	}
+	hp, ok := p.(interface{ Health() provider.ProviderHealth })
+	if !ok {
+		return
+	}
+	metrics.SetEnrichmentBreakerState(rule.ID, hp.Health().CircuitState)
+}
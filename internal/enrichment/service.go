@@ -4,20 +4,29 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	lru "github.com/hashicorp/golang-lru/v2"
 	"github.com/redis/go-redis/v9"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"golang.org/x/sync/singleflight"
 
 	"yeti/internal/config"
+	"yeti/internal/config_handler"
 	"yeti/internal/constants"
 	"yeti/internal/enrichment/provider"
 	"yeti/internal/logger"
 	"yeti/pkg/cel"
 	"yeti/pkg/metrics"
 	"yeti/pkg/models"
+	"yeti/pkg/retry"
 	"yeti/pkg/tracing"
 )
 
@@ -64,17 +73,162 @@ func getProviderNames(providers map[string]provider.DataProvider) []string {
 type Service interface {
 	Process(ctx context.Context, msg models.MessageEnvelope) (models.MessageEnvelope, error)
 
+	// ProcessBatch enriches msgs together: per rule, it issues a single
+	// cache MGET and one bulk provider call for the whole batch instead
+	// of Process's one-round-trip-per-message path, trading some of
+	// Process's per-message error granularity (see fetchSourceDataBatch)
+	// for order-of-magnitude fewer provider round trips on high-volume
+	// pipelines.
+	ProcessBatch(ctx context.Context, msgs []models.MessageEnvelope) ([]models.MessageEnvelope, error)
+
 	ReloadRules(ctx context.Context) error
+
+	// ReloadRule re-fetches a single rule by ID and applies just that
+	// change to the in-memory rule set, instead of ReloadRules' full
+	// GetActiveRules scan. config_handler.Handler calls it when a
+	// ConfigUpdateEvent names a specific rule_id. A deleted or disabled
+	// rule is removed from the in-memory set.
+	ReloadRule(ctx context.Context, ruleID string) error
+
+	// ApplyRuleDelta implements config_handler.RuleDeltaApplier: it applies
+	// a ConfigUpdateEvent's embedded Rule payload directly, skipping the
+	// repository round trip ReloadRule makes. It returns
+	// config_handler.ErrNoRuleDelta when the event carries no payload to
+	// apply, so Handler falls back to ReloadRule/ReloadRules.
+	ApplyRuleDelta(ctx context.Context, event models.ConfigUpdateEvent) error
+
+	// ProviderHealth reports each registered source's circuit-breaker
+	// state, last error, and recent latency percentiles, keyed by source
+	// type. A source without circuit-breaker wrapping (cbConfig disabled)
+	// is reported with just its name. Backs the /healthz/enrichment
+	// endpoint.
+	ProviderHealth(ctx context.Context) map[string]provider.ProviderHealth
+
+	// RuleHealth reports ruleID's own circuit-breaker state, distinct from
+	// its source type's shared state in ProviderHealth, for rules with a
+	// CircuitBreaker override (see Rule.CircuitBreaker/ruleProviderFor). ok
+	// is false if ruleID has no override configured or hasn't been fetched
+	// through yet, in which case its health is whatever ProviderHealth
+	// reports for its source type. Backs /healthz/enrichment/rules/:id.
+	RuleHealth(ctx context.Context, ruleID string) (health provider.ProviderHealth, ok bool)
+
+	// AllRuleHealth reports RuleHealth for every rule with a CircuitBreaker
+	// override currently tracked, keyed by rule ID. Backs
+	// /healthz/enrichment/rules.
+	AllRuleHealth(ctx context.Context) map[string]provider.ProviderHealth
+
+	// Close releases any external provider connections opened by
+	// NewServiceWithExternalProviders. It's a no-op if none were opened.
+	Close() error
+
+	// InvalidateEnrichmentKey drops rule's cached source data for
+	// fieldValue from both the L1 cache and the Redis L2 cache, so a
+	// caller that just wrote fresh upstream data (e.g. the management
+	// service updating a user profile a rule reads from) can force the
+	// next Process call to re-fetch rather than serve a stale hit for
+	// up to CacheTTLSeconds. A miss in either cache is not an error.
+	InvalidateEnrichmentKey(ctx context.Context, rule Rule, fieldValue interface{}) error
+}
+
+// l1Entry is the value stored in serviceImpl.l1: either a positive result
+// (data non-nil) or a negative one cached under rule.NegativeCacheTTLSeconds
+// (negative true, data nil) to stop a field value that keeps missing from
+// re-hitting a slow provider on every message. expiresAt is checked on read
+// since golang-lru/v2's plain Cache has no per-entry TTL.
+type l1Entry struct {
+	data      map[string]interface{}
+	negative  bool
+	expiresAt time.Time
 }
 
 type serviceImpl struct {
 	repo      Repository
 	cache     *redis.Client
 	providers map[string]provider.DataProvider
+	registry  *provider.Registry
 	evaluator *cel.Evaluator
 	rules     []Rule
 	rulesMu   sync.RWMutex
 	logger    logger.Logger
+
+	// l1 is an in-process cache in front of cache (the Redis L2), keyed the
+	// same way as the Redis cache key. Nil disables the L1 cache entirely,
+	// falling back to the pre-existing Redis-only path.
+	l1 *lru.Cache[string, l1Entry]
+	// l1Hits/l1Attempts back metrics.EnrichmentL1HitRate; see recordL1Access.
+	l1Hits     uint64
+	l1Attempts uint64
+	// sf collapses concurrent fetchSourceData calls for the same (rule,
+	// field value) into one provider call, so a burst of messages that all
+	// need the same enrichment doesn't fan out N identical upstream
+	// requests.
+	sf singleflight.Group
+
+	// throttles holds one ruleThrottle per rule with RateLimitPerSecond or
+	// MaxConcurrency configured, built lazily in throttleFor.
+	throttles   map[string]*ruleThrottle
+	throttlesMu sync.Mutex
+
+	// grpcSource backs the SourceTypeGRPC provider registered by
+	// registerHTTPGRPCAndLookupProviders; kept here (rather than only inside
+	// s.providers, which may wrap it in a circuit breaker/retry decorator)
+	// so Close can reach its dialed connections directly.
+	grpcSource *provider.GRPCSourceProvider
+	// kafkaLookup backs the SourceTypeKafkaLookup provider, for the same
+	// reason grpcSource is kept alongside s.providers: Close needs to stop
+	// its background consumers directly, not through whatever decorator
+	// s.providers wraps it in.
+	kafkaLookup *provider.KafkaLookupProvider
+
+	// ruleProviders holds one wrapped DataProvider per rule with a
+	// CircuitBreaker or Retry override, built lazily in ruleProviderFor.
+	ruleProviders   map[string]*ruleProviderEntry
+	ruleProvidersMu sync.Mutex
+}
+
+func newL1Cache(cfg *config.L1CacheConfig, log logger.Logger) *lru.Cache[string, l1Entry] {
+	if cfg == nil || cfg.Size <= 0 {
+		return nil
+	}
+
+	cache, err := lru.New[string, l1Entry](cfg.Size)
+	if err != nil {
+		log.WarnwCtx(context.Background(), "Failed to create enrichment L1 cache, disabling it", "error", err)
+		return nil
+	}
+	return cache
+}
+
+// celProgramCacheSize returns celCfg.ProgramCacheSize, falling back to
+// cel.DefaultProgramCacheSize when celCfg is nil or non-positive - unlike
+// l1Cfg, there's no "intentionally disabled" case here worth preserving:
+// the CEL compiled-program cache only ever helps, so an unset config value
+// should still get a cache rather than silently recompiling every message.
+func celProgramCacheSize(celCfg *config.CELConfig) int {
+	if celCfg == nil || celCfg.ProgramCacheSize <= 0 {
+		return cel.DefaultProgramCacheSize
+	}
+	return celCfg.ProgramCacheSize
+}
+
+// applyCELBudget configures evaluator's cost/timeout budget, estimated-cost
+// validation ceiling, and per-rule circuit breaker from celCfg, mirroring
+// filtering.Service's NewService. A nil celCfg, or zero-valued fields within
+// it, leaves the corresponding guard disabled - see config.CELConfig.
+func applyCELBudget(evaluator *cel.Evaluator, celCfg *config.CELConfig) {
+	if celCfg == nil {
+		return
+	}
+	evaluator.WithEvalBudget(celCfg.MaxCost, time.Duration(celCfg.MaxEvalDurationMs)*time.Millisecond)
+	evaluator.WithMaxEstimatedCost(celCfg.MaxEstimatedCost)
+	if celCfg.RuleBreaker.FailureThreshold > 0 {
+		evaluator.WithRuleBreaker(retry.CircuitBreakerConfig{
+			FailureThreshold: celCfg.RuleBreaker.FailureThreshold,
+			SuccessThreshold: celCfg.RuleBreaker.SuccessThreshold,
+			WindowSize:       celCfg.RuleBreaker.WindowSize,
+			OpenTimeout:      time.Duration(celCfg.RuleBreaker.OpenTimeoutSeconds) * time.Second,
+		})
+	}
 }
 
 func NewService(repo Repository, cache *redis.Client, log logger.Logger) Service {
@@ -82,35 +236,64 @@ func NewService(repo Repository, cache *redis.Client, log logger.Logger) Service
 }
 
 func NewServiceWithCircuitBreaker(repo Repository, cache *redis.Client, log logger.Logger, cbConfig *config.CircuitBreakerConfig) Service {
-	evaluator, err := cel.NewEvaluator()
+	return NewServiceWithCircuitBreakerAndRetry(repo, cache, log, cbConfig, nil)
+}
+
+func NewServiceWithCircuitBreakerAndRetry(repo Repository, cache *redis.Client, log logger.Logger, cbConfig *config.CircuitBreakerConfig, retryCfg *config.RetryConfig) Service {
+	return NewServiceWithL1Cache(repo, cache, log, cbConfig, retryCfg, nil)
+}
+
+// NewServiceWithL1Cache extends NewServiceWithCircuitBreakerAndRetry with the
+// in-process L1 cache described on serviceImpl.l1. l1Cfg nil, or a non-nil
+// l1Cfg with Size <= 0, disables the L1 cache.
+func NewServiceWithL1Cache(repo Repository, cache *redis.Client, log logger.Logger, cbConfig *config.CircuitBreakerConfig, retryCfg *config.RetryConfig, l1Cfg *config.L1CacheConfig) Service {
+	return NewServiceWithCELCache(repo, cache, log, cbConfig, retryCfg, l1Cfg, nil)
+}
+
+// NewServiceWithCELCache extends NewServiceWithL1Cache with a configurable
+// size for the CEL evaluator's compiled-program caches (see
+// cel.Evaluator). celCfg nil, or a non-nil celCfg with ProgramCacheSize <= 0,
+// falls back to cel.DefaultProgramCacheSize.
+func NewServiceWithCELCache(repo Repository, cache *redis.Client, log logger.Logger, cbConfig *config.CircuitBreakerConfig, retryCfg *config.RetryConfig, l1Cfg *config.L1CacheConfig, celCfg *config.CELConfig) Service {
+	evaluator, err := cel.NewEvaluatorWithCacheSize(celProgramCacheSize(celCfg))
 	if err != nil {
 		log.WarnwCtx(context.Background(), "Failed to create CEL evaluator", "error", err)
+	} else {
+		applyCELBudget(evaluator, celCfg)
 	}
 
 	s := &serviceImpl{
-		repo:      repo,
-		cache:     cache,
-		providers: make(map[string]provider.DataProvider),
-		evaluator: evaluator,
-		rules:     make([]Rule, 0),
-		logger:    log,
+		repo:          repo,
+		cache:         cache,
+		providers:     make(map[string]provider.DataProvider),
+		evaluator:     evaluator,
+		rules:         make([]Rule, 0),
+		logger:        log,
+		l1:            newL1Cache(l1Cfg, log),
+		throttles:     make(map[string]*ruleThrottle),
+		ruleProviders: make(map[string]*ruleProviderEntry),
 	}
 
 	var apiProv provider.DataProvider = provider.NewAPIProvider()
 	if cbConfig != nil {
-		apiProv = provider.WrapWithCircuitBreaker(apiProv, "api", *cbConfig)
+		apiProv = provider.WrapWithCircuitBreaker(apiProv, "api", *cbConfig, log, nil)
+	}
+	if retryCfg != nil {
+		apiProv = provider.WrapWithRetry(apiProv, "api", *retryCfg, log)
 	}
 	s.providers["api"] = apiProv
 
 	if cache != nil {
 		var cacheProv provider.DataProvider = provider.NewCacheProvider(cache)
 		if cbConfig != nil {
-			cacheProv = provider.WrapWithCircuitBreaker(cacheProv, "cache", *cbConfig)
+			cacheProv = provider.WrapWithCircuitBreaker(cacheProv, "cache", *cbConfig, log, nil)
 		}
 		s.providers["cache"] = cacheProv
 		s.providers["redis"] = cacheProv
 	}
 
+	registerHTTPGRPCAndLookupProviders(s, cbConfig, retryCfg, log)
+
 	return s
 }
 
@@ -119,30 +302,100 @@ func NewServiceWithDatabaseProviders(repo Repository, cache *redis.Client, mongo
 }
 
 func NewServiceWithDatabaseProvidersAndCircuitBreaker(repo Repository, cache *redis.Client, mongoClient *mongo.Client, postgresDB *sql.DB, log logger.Logger, cbConfig *config.CircuitBreakerConfig) Service {
-	evaluator, err := cel.NewEvaluator()
+	return NewServiceWithDatabaseProvidersAndRetry(repo, cache, mongoClient, postgresDB, log, cbConfig, nil)
+}
+
+func NewServiceWithDatabaseProvidersAndRetry(repo Repository, cache *redis.Client, mongoClient *mongo.Client, postgresDB *sql.DB, log logger.Logger, cbConfig *config.CircuitBreakerConfig, retryCfg *config.RetryConfig) Service {
+	return NewServiceWithDatabaseProvidersAndL1Cache(repo, cache, mongoClient, postgresDB, log, cbConfig, retryCfg, nil)
+}
+
+// NewServiceWithExternalProviders extends NewServiceWithDatabaseProvidersAndL1Cache
+// with providers discovered via DiscoverExternalProviders: every source
+// type registry has a Provider for is registered into s.providers exactly
+// like a built-in source, so the rest of Process's fetch path doesn't need
+// to know a given rule's source type resolves to an external gRPC plugin
+// rather than Mongo or Postgres. registry may be nil.
+func NewServiceWithExternalProviders(repo Repository, cache *redis.Client, mongoClient *mongo.Client, postgresDB *sql.DB, log logger.Logger, cbConfig *config.CircuitBreakerConfig, retryCfg *config.RetryConfig, l1Cfg *config.L1CacheConfig, registry *provider.Registry) Service {
+	return NewServiceWithExternalProvidersAndCELCache(repo, cache, mongoClient, postgresDB, log, cbConfig, retryCfg, l1Cfg, nil, registry)
+}
+
+// NewServiceWithExternalProvidersAndCELCache is NewServiceWithExternalProviders
+// with an additional celCfg, sizing the CEL evaluator's compiled-program
+// caches exactly as NewServiceWithCELCache does. celCfg nil, or a non-nil
+// celCfg with ProgramCacheSize <= 0, falls back to cel.DefaultProgramCacheSize.
+func NewServiceWithExternalProvidersAndCELCache(repo Repository, cache *redis.Client, mongoClient *mongo.Client, postgresDB *sql.DB, log logger.Logger, cbConfig *config.CircuitBreakerConfig, retryCfg *config.RetryConfig, l1Cfg *config.L1CacheConfig, celCfg *config.CELConfig, registry *provider.Registry) Service {
+	svc := NewServiceWithDatabaseProvidersAndCELCache(repo, cache, mongoClient, postgresDB, log, cbConfig, retryCfg, l1Cfg, celCfg)
+	s := svc.(*serviceImpl)
+
+	if registry == nil {
+		return s
+	}
+
+	s.registry = registry
+	for _, sourceType := range registry.SourceTypes() {
+		p, ok := registry.Get(sourceType)
+		if !ok {
+			continue
+		}
+		if dp, ok := p.(provider.DataProvider); ok {
+			if cbConfig != nil {
+				dp = provider.WrapWithCircuitBreaker(dp, sourceType, *cbConfig, log, nil)
+			}
+			s.providers[sourceType] = dp
+			s.logger.InfowCtx(context.Background(), "External enrichment provider registered",
+				"source_type", sourceType,
+				"provider_name", p.Name(),
+			)
+		}
+	}
+
+	return s
+}
+
+// NewServiceWithDatabaseProvidersAndL1Cache extends
+// NewServiceWithDatabaseProvidersAndRetry with the in-process L1 cache
+// described on serviceImpl.l1. l1Cfg nil, or a non-nil l1Cfg with Size <= 0,
+// disables the L1 cache.
+func NewServiceWithDatabaseProvidersAndL1Cache(repo Repository, cache *redis.Client, mongoClient *mongo.Client, postgresDB *sql.DB, log logger.Logger, cbConfig *config.CircuitBreakerConfig, retryCfg *config.RetryConfig, l1Cfg *config.L1CacheConfig) Service {
+	return NewServiceWithDatabaseProvidersAndCELCache(repo, cache, mongoClient, postgresDB, log, cbConfig, retryCfg, l1Cfg, nil)
+}
+
+// NewServiceWithDatabaseProvidersAndCELCache extends
+// NewServiceWithDatabaseProvidersAndL1Cache with a configurable size for the
+// CEL evaluator's compiled-program caches; see NewServiceWithCELCache.
+func NewServiceWithDatabaseProvidersAndCELCache(repo Repository, cache *redis.Client, mongoClient *mongo.Client, postgresDB *sql.DB, log logger.Logger, cbConfig *config.CircuitBreakerConfig, retryCfg *config.RetryConfig, l1Cfg *config.L1CacheConfig, celCfg *config.CELConfig) Service {
+	evaluator, err := cel.NewEvaluatorWithCacheSize(celProgramCacheSize(celCfg))
 	if err != nil {
 		log.WarnwCtx(context.Background(), "Failed to create CEL evaluator", "error", err)
+	} else {
+		applyCELBudget(evaluator, celCfg)
 	}
 
 	s := &serviceImpl{
-		repo:      repo,
-		cache:     cache,
-		providers: make(map[string]provider.DataProvider),
-		evaluator: evaluator,
-		rules:     make([]Rule, 0),
-		logger:    log,
+		repo:          repo,
+		cache:         cache,
+		providers:     make(map[string]provider.DataProvider),
+		evaluator:     evaluator,
+		rules:         make([]Rule, 0),
+		logger:        log,
+		l1:            newL1Cache(l1Cfg, log),
+		throttles:     make(map[string]*ruleThrottle),
+		ruleProviders: make(map[string]*ruleProviderEntry),
 	}
 
 	var apiProv provider.DataProvider = provider.NewAPIProvider()
 	if cbConfig != nil {
-		apiProv = provider.WrapWithCircuitBreaker(apiProv, "api", *cbConfig)
+		apiProv = provider.WrapWithCircuitBreaker(apiProv, "api", *cbConfig, log, nil)
+	}
+	if retryCfg != nil {
+		apiProv = provider.WrapWithRetry(apiProv, "api", *retryCfg, log)
 	}
 	s.providers["api"] = apiProv
 
 	if cache != nil {
 		var cacheProv provider.DataProvider = provider.NewCacheProvider(cache)
 		if cbConfig != nil {
-			cacheProv = provider.WrapWithCircuitBreaker(cacheProv, "cache", *cbConfig)
+			cacheProv = provider.WrapWithCircuitBreaker(cacheProv, "cache", *cbConfig, log, nil)
 		}
 		s.providers["cache"] = cacheProv
 		s.providers["redis"] = cacheProv
@@ -152,7 +405,7 @@ func NewServiceWithDatabaseProvidersAndCircuitBreaker(repo Repository, cache *re
 	if mongoClient != nil {
 		var mongoProv provider.DataProvider = provider.NewMongoDBProvider(mongoClient)
 		if cbConfig != nil {
-			mongoProv = provider.WrapWithCircuitBreaker(mongoProv, "mongodb", *cbConfig)
+			mongoProv = provider.WrapWithCircuitBreaker(mongoProv, "mongodb", *cbConfig, log, nil)
 		}
 		s.providers["mongodb"] = mongoProv
 		s.logger.InfowCtx(context.Background(), "MongoDB provider registered")
@@ -161,15 +414,58 @@ func NewServiceWithDatabaseProvidersAndCircuitBreaker(repo Repository, cache *re
 	if postgresDB != nil {
 		var pgProv provider.DataProvider = provider.NewPostgreSQLProvider(postgresDB)
 		if cbConfig != nil {
-			pgProv = provider.WrapWithCircuitBreaker(pgProv, "postgresql", *cbConfig)
+			pgProv = provider.WrapWithCircuitBreaker(pgProv, "postgresql", *cbConfig, log, nil)
 		}
 		s.providers["postgresql"] = pgProv
 		s.logger.InfowCtx(context.Background(), "PostgreSQL provider registered")
 	}
 
+	registerHTTPGRPCAndLookupProviders(s, cbConfig, retryCfg, log)
+
 	return s
 }
 
+// registerHTTPGRPCAndLookupProviders registers the rule-driven built-in
+// providers shared by every NewServiceWith* constructor: SourceTypeHTTP
+// (provider.HTTPProvider), SourceTypeGRPC (provider.GRPCSourceProvider),
+// SourceTypeKafkaLookup (provider.KafkaLookupProvider) and SourceTypeFile
+// (provider.FileSourceProvider). Unlike NewServiceWithExternalProviders'
+// registry-discovered providers, these resolve their target lazily from
+// each rule's SourceConfig rather than from a caller-supplied dependency,
+// so they're always registered. The HTTPProvider built here has no
+// config.SecretResolver wired in yet, so "${scheme:ref}" auth placeholders
+// pass through unresolved; wiring one through is left to whichever
+// constructor variant first needs it.
+func registerHTTPGRPCAndLookupProviders(s *serviceImpl, cbConfig *config.CircuitBreakerConfig, retryCfg *config.RetryConfig, log logger.Logger) {
+	var httpProv provider.DataProvider = provider.NewHTTPProvider(nil)
+	if cbConfig != nil {
+		httpProv = provider.WrapWithCircuitBreaker(httpProv, "http", *cbConfig, log, nil)
+	}
+	if retryCfg != nil {
+		httpProv = provider.WrapWithRetry(httpProv, "http", *retryCfg, log)
+	}
+	s.providers[constants.SourceTypeHTTP] = httpProv
+
+	s.grpcSource = provider.NewGRPCSourceProvider()
+	var grpcProv provider.DataProvider = s.grpcSource
+	if cbConfig != nil {
+		grpcProv = provider.WrapWithCircuitBreaker(grpcProv, "grpc", *cbConfig, log, nil)
+	}
+	if retryCfg != nil {
+		grpcProv = provider.WrapWithRetry(grpcProv, "grpc", *retryCfg, log)
+	}
+	s.providers[constants.SourceTypeGRPC] = grpcProv
+
+	s.kafkaLookup = provider.NewKafkaLookupProvider()
+	var kafkaLookupProv provider.DataProvider = s.kafkaLookup
+	if cbConfig != nil {
+		kafkaLookupProv = provider.WrapWithCircuitBreaker(kafkaLookupProv, "kafka_lookup", *cbConfig, log, nil)
+	}
+	s.providers[constants.SourceTypeKafkaLookup] = kafkaLookupProv
+
+	s.providers[constants.SourceTypeFile] = provider.NewFileSourceProvider()
+}
+
 func (s *serviceImpl) ReloadRules(ctx context.Context) error {
 	rules, err := s.repo.GetActiveRules(ctx)
 	if err != nil {
@@ -188,6 +484,118 @@ func (s *serviceImpl) ReloadRules(ctx context.Context) error {
 	return nil
 }
 
+func (s *serviceImpl) ReloadRule(ctx context.Context, ruleID string) error {
+	start := time.Now()
+
+	rule, err := s.repo.GetRuleByID(ctx, ruleID)
+	if errors.Is(err, ErrRuleNotFound) {
+		rule = nil
+	} else if err != nil {
+		s.logger.ErrorwCtx(ctx, "Failed to load rule for targeted reload",
+			"rule_id", ruleID,
+			"error", err,
+		)
+		return err
+	}
+
+	s.applyRuleUpdate(ctx, ruleID, rule)
+	metrics.ObserveConfigReloadDuration("enrichment", "rule", time.Since(start))
+
+	return nil
+}
+
+func (s *serviceImpl) ApplyRuleDelta(ctx context.Context, event models.ConfigUpdateEvent) error {
+	if event.Action == models.ActionDelete {
+		s.applyRuleUpdate(ctx, event.RuleID, nil)
+		metrics.SetRulesLastDeltaTimestamp("enrichment", time.Now())
+		return nil
+	}
+
+	if len(event.Rule) == 0 {
+		return config_handler.ErrNoRuleDelta
+	}
+
+	var rule Rule
+	if err := json.Unmarshal(event.Rule, &rule); err != nil {
+		return fmt.Errorf("failed to decode rule delta for %s: %w", event.RuleID, err)
+	}
+
+	s.applyRuleUpdate(ctx, rule.ID, &rule)
+	metrics.SetRulesLastDeltaTimestamp("enrichment", time.Now())
+	return nil
+}
+
+func (s *serviceImpl) ProviderHealth(ctx context.Context) map[string]provider.ProviderHealth {
+	health := make(map[string]provider.ProviderHealth, len(s.providers))
+	for name, p := range s.providers {
+		if hp, ok := p.(interface{ Health() provider.ProviderHealth }); ok {
+			health[name] = hp.Health()
+			continue
+		}
+		health[name] = provider.ProviderHealth{Name: name}
+	}
+
+	return health
+}
+
+func (s *serviceImpl) RuleHealth(ctx context.Context, ruleID string) (provider.ProviderHealth, bool) {
+	s.ruleProvidersMu.Lock()
+	entry, ok := s.ruleProviders[ruleID]
+	s.ruleProvidersMu.Unlock()
+	if !ok {
+		return provider.ProviderHealth{}, false
+	}
+
+	hp, ok := entry.provider.(interface{ Health() provider.ProviderHealth })
+	if !ok {
+		return provider.ProviderHealth{Name: "rule:" + ruleID}, true
+	}
+	return hp.Health(), true
+}
+
+func (s *serviceImpl) AllRuleHealth(ctx context.Context) map[string]provider.ProviderHealth {
+	s.ruleProvidersMu.Lock()
+	ids := make([]string, 0, len(s.ruleProviders))
+	for id := range s.ruleProviders {
+		ids = append(ids, id)
+	}
+	s.ruleProvidersMu.Unlock()
+
+	health := make(map[string]provider.ProviderHealth, len(ids))
+	for _, id := range ids {
+		if h, ok := s.RuleHealth(ctx, id); ok {
+			health[id] = h
+		}
+	}
+	return health
+}
+
+func (s *serviceImpl) applyRuleUpdate(ctx context.Context, ruleID string, rule *Rule) {
+	s.rulesMu.Lock()
+	rules := make([]Rule, 0, len(s.rules)+1)
+	for _, existing := range s.rules {
+		if existing.ID == ruleID {
+			continue
+		}
+		rules = append(rules, existing)
+	}
+	if rule != nil && rule.Enabled {
+		rules = append(rules, *rule)
+	}
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].Priority < rules[j].Priority
+	})
+	s.rules = rules
+	s.rulesMu.Unlock()
+
+	metrics.SetEnrichmentActiveRules(len(rules))
+	s.logger.InfowCtx(ctx, "Applied targeted rule reload",
+		"rule_id", ruleID,
+		"removed", rule == nil || !rule.Enabled,
+		"new_rules_count", len(rules),
+	)
+}
+
 func (s *serviceImpl) Process(ctx context.Context, msg models.MessageEnvelope) (models.MessageEnvelope, error) {
 	ctx, span := tracing.GetTracer("enrichment-service").Start(ctx, "enrichment.process")
 	defer span.End()
@@ -233,6 +641,15 @@ func (s *serviceImpl) Process(ctx context.Context, msg models.MessageEnvelope) (
 			"enabled", rule.Enabled,
 		)
 
+		if !s.evaluateCondition(ctx, rule, msg) {
+			s.logger.DebugwCtx(ctx, "Condition not met, skipping rule",
+				"rule_id", rule.ID,
+				"rule_name", rule.Name,
+				"condition", rule.Condition,
+			)
+			continue
+		}
+
 		fieldValue, exists := msg.GetPayloadField(rule.FieldToEnrich)
 		if !exists {
 			s.logger.DebugwCtx(ctx, "Field not found in payload, skipping rule",
@@ -250,8 +667,14 @@ func (s *serviceImpl) Process(ctx context.Context, msg models.MessageEnvelope) (
 			"field_value", fieldValue,
 		)
 
-		sourceData, hit, err := s.fetchSourceData(ctx, rule, fieldValue)
+		ruleCtx, ruleSpan := tracing.GetTracer("enrichment-service").Start(ctx, "enrichment.apply_rule")
+		ruleSpan.SetAttributes(attribute.String("rule_id", rule.ID))
+
+		sourceData, hit, err := s.fetchSourceData(ruleCtx, rule, fieldValue, msg)
 		if err != nil {
+			ruleSpan.RecordError(err)
+			ruleSpan.SetStatus(codes.Error, err.Error())
+			ruleSpan.End()
 			if rule.ErrorHandling == constants.ErrorHandlingFail {
 				metrics.ObserveEnrichmentDuration(time.Since(start), "error")
 				return msg, err
@@ -284,11 +707,12 @@ func (s *serviceImpl) Process(ctx context.Context, msg models.MessageEnvelope) (
 			)
 		}
 
-		s.applyTransformations(ctx, rule, sourceData, &msg)
+		s.applyTransformations(ruleCtx, rule, sourceData, &msg)
+		ruleSpan.End()
 	}
 
 	s.updateCacheMetrics(cacheHits, cacheMisses)
-	
+
 	s.logger.DebugwCtx(ctx, "Enrichment processing completed",
 		"message_id", msg.ID,
 		"rules_processed", len(activeRules),
@@ -302,6 +726,119 @@ func (s *serviceImpl) Process(ctx context.Context, msg models.MessageEnvelope) (
 	return msg, nil
 }
 
+// ProcessBatch enriches msgs rule-by-rule: for each rule it resolves source
+// data for the whole batch in fetchSourceDataBatch (one MGET plus at most one
+// bulk provider call, rather than one round trip per message) and then runs
+// applyTransformations concurrently across messages, since transformation is
+// pure CEL evaluation with no shared state. Unlike Process, a rule whose
+// error_handling is "fail" aborts the whole batch rather than just the one
+// message - see fetchSourceDataBatch for why a per-message error can't be
+// threaded back through this signature.
+//
+// A rule.Condition narrows the batch down to the messages it matches before
+// fetchSourceDataBatch runs, same as Process's per-message check. A
+// SourceConfig.KeyPattern/Field "{{ expr: ... }}" template, by contrast, is
+// NOT rendered here - it's inherently per-message (e.g. keying on
+// msg.tenant), which conflicts with fetchSourceDataBatch's one-KeyPattern-
+// for-the-whole-batch fan-in; a rule needing a dynamic key should avoid
+// ProcessBatch. See Process/fetchSourceData for where it is supported.
+func (s *serviceImpl) ProcessBatch(ctx context.Context, msgs []models.MessageEnvelope) ([]models.MessageEnvelope, error) {
+	ctx, span := tracing.GetTracer("enrichment-service").Start(ctx, "enrichment.process_batch")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.ObserveEnrichmentDuration(time.Since(start), "success")
+	}()
+
+	out := make([]models.MessageEnvelope, len(msgs))
+	copy(out, msgs)
+	for i := range out {
+		if out[i].Metadata.Enrichment == nil {
+			out[i].Metadata.Enrichment = make(map[string]interface{})
+		}
+	}
+
+	activeRules := s.getActiveRules()
+	s.logger.DebugwCtx(ctx, "Processing message batch for enrichment",
+		"batch_size", len(out),
+		"rules_count", len(activeRules),
+	)
+
+	for _, rule := range activeRules {
+		if err := ctx.Err(); err != nil {
+			return out, err
+		}
+
+		// candidates/origIndex narrow out down to the messages rule.Condition
+		// matches (all of them, for the common case of no Condition), so a
+		// skipped message neither triggers a provider round trip nor gets
+		// this rule's transformations applied. origIndex maps a candidates
+		// index back to its slot in out for scattering sourceByMsg below.
+		candidates := out
+		origIndex := func(i int) int { return i }
+		if rule.Condition != "" {
+			filtered := make([]models.MessageEnvelope, 0, len(out))
+			indices := make([]int, 0, len(out))
+			for i, m := range out {
+				if s.evaluateCondition(ctx, rule, m) {
+					filtered = append(filtered, m)
+					indices = append(indices, i)
+				}
+			}
+			candidates = filtered
+			origIndex = func(i int) int { return indices[i] }
+		}
+		if len(candidates) == 0 {
+			continue
+		}
+
+		sourceByMsg, err := s.fetchSourceDataBatch(ctx, rule, candidates)
+		if err != nil {
+			metrics.ObserveEnrichmentDuration(time.Since(start), "error")
+			return out, err
+		}
+
+		var wg sync.WaitGroup
+		for i, sourceData := range sourceByMsg {
+			wg.Add(1)
+			go func(i int, sourceData map[string]interface{}) {
+				defer wg.Done()
+				s.applyTransformations(ctx, rule, sourceData, &out[origIndex(i)])
+			}(i, sourceData)
+		}
+		wg.Wait()
+	}
+
+	s.logger.DebugwCtx(ctx, "Enrichment batch processing completed",
+		"batch_size", len(out),
+		"rules_processed", len(activeRules),
+		"duration_ms", time.Since(start).Milliseconds(),
+	)
+
+	return out, nil
+}
+
+func (s *serviceImpl) Close() error {
+	var firstErr error
+	if s.grpcSource != nil {
+		if err := s.grpcSource.Close(); err != nil {
+			firstErr = err
+		}
+	}
+	if s.kafkaLookup != nil {
+		if err := s.kafkaLookup.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if s.registry != nil {
+		if err := s.registry.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
 func (s *serviceImpl) getActiveRules() []Rule {
 	s.rulesMu.RLock()
 	defer s.rulesMu.RUnlock()
@@ -311,9 +848,94 @@ func (s *serviceImpl) getActiveRules() []Rule {
 	return rules
 }
 
-func (s *serviceImpl) fetchSourceData(ctx context.Context, rule Rule, fieldValue interface{}) (map[string]interface{}, bool, error) {
-	cacheKey := fmt.Sprintf("%s%s:%v", constants.CacheKeyPrefixEnrich, rule.ID, fieldValue)
+// sourceFetchResult carries fetchSourceDataL2's result through
+// singleflight.Group.Do, which only passes a single interface{} value.
+type sourceFetchResult struct {
+	data map[string]interface{}
+	hit  bool
+}
+
+// fetchSourceData resolves rule's source data for fieldValue through, in
+// order: the in-process L1 cache (if enabled), a singleflight-deduplicated
+// call into fetchSourceDataL2 (the pre-existing Redis L2 + provider path).
+// Concurrent calls for the same (rule.ID, fieldValue) share one L2/provider
+// round trip via s.sf; every caller still gets its own hit/err, since
+// singleflight.Do replays the same result to all of them. A result that
+// lands in a skip_rule/skip_field skipRuleError is negative-cached in L1
+// under rule.NegativeCacheTTLSeconds (if set) so a field value that keeps
+// missing doesn't re-hit a slow provider on every message.
+//
+// If rule.SourceConfig.KeyPattern/Field is a "{{ expr: ... }}" template
+// (see renderRuleSourceConfig), it's rendered against msg here, before any
+// cache lookup, and the rendered result is folded into the cache/
+// singleflight key alongside rule.ID+fieldValue - a dynamic key can vary
+// per message even for the same fieldValue (e.g. keying on msg.tenant), so
+// the static rule.ID+fieldValue key alone would incorrectly coalesce
+// different messages' lookups.
+func (s *serviceImpl) fetchSourceData(ctx context.Context, rule Rule, fieldValue interface{}, msg models.MessageEnvelope) (map[string]interface{}, bool, error) {
+	dynamicKey := isDynamicTemplate(rule.SourceConfig.KeyPattern) || isDynamicTemplate(rule.SourceConfig.Field)
+	keySuffix := ""
+	if dynamicKey {
+		rendered, err := s.renderRuleSourceConfig(ctx, rule.SourceConfig, msg)
+		if err != nil {
+			return nil, false, fmt.Errorf("rule %s: %w", rule.ID, err)
+		}
+		rule.SourceConfig = rendered
+		keySuffix = ":" + rendered.KeyPattern + ":" + rendered.Field
+	}
+
+	cacheKey := fmt.Sprintf("%s%s:%v%s", constants.CacheKeyPrefixEnrich, rule.ID, fieldValue, keySuffix)
+
+	if s.l1 != nil {
+		if entry, ok := s.l1.Get(cacheKey); ok && time.Now().Before(entry.expiresAt) {
+			s.recordL1Access(rule.SourceType, true)
+			if entry.negative {
+				return nil, true, &skipRuleError{rule: rule.Name, reason: "negative cache: field value not found on a prior fetch"}
+			}
+			return entry.data, true, nil
+		}
+		s.recordL1Access(rule.SourceType, false)
+	}
+
+	sfKey := rule.ID + ":" + fmt.Sprintf("%v", fieldValue) + keySuffix
+	v, err, shared := s.sf.Do(sfKey, func() (interface{}, error) {
+		data, hit, ferr := s.fetchSourceDataL2(ctx, rule, fieldValue, cacheKey)
+		return sourceFetchResult{data: data, hit: hit}, ferr
+	})
+	if shared {
+		metrics.IncEnrichmentSingleflightSuppressed(rule.ID)
+		metrics.IncEnrichmentCacheCoalesced(rule.SourceType, "singleflight")
+	}
+	result, _ := v.(sourceFetchResult)
+
+	if s.l1 != nil {
+		switch {
+		case err == nil:
+			s.l1.Add(cacheKey, l1Entry{data: result.data, expiresAt: time.Now().Add(time.Duration(rule.CacheTTLSeconds) * time.Second)})
+		case IsSkipRuleError(err) && rule.NegativeCacheTTLSeconds > 0:
+			s.l1.Add(cacheKey, l1Entry{negative: true, expiresAt: time.Now().Add(time.Duration(rule.NegativeCacheTTLSeconds) * time.Second)})
+		}
+	}
+
+	return result.data, result.hit, err
+}
+
+func (s *serviceImpl) recordL1Access(source string, hit bool) {
+	attempts := atomic.AddUint64(&s.l1Attempts, 1)
+	var hits uint64
+	if hit {
+		hits = atomic.AddUint64(&s.l1Hits, 1)
+		metrics.IncEnrichmentCacheHit(source, "l1")
+	} else {
+		hits = atomic.LoadUint64(&s.l1Hits)
+		metrics.IncEnrichmentCacheMiss(source, "l1")
+	}
+	metrics.SetEnrichmentL1HitRate(float64(hits) / float64(attempts))
+}
 
+// fetchSourceDataL2 is fetchSourceData's pre-L1 implementation: a Redis
+// lookup, falling back to fetchFromProvider on a miss.
+func (s *serviceImpl) fetchSourceDataL2(ctx context.Context, rule Rule, fieldValue interface{}, cacheKey string) (map[string]interface{}, bool, error) {
 	s.logger.DebugwCtx(ctx, "Checking cache for source data",
 		"rule_id", rule.ID,
 		"rule_name", rule.Name,
@@ -335,7 +957,8 @@ func (s *serviceImpl) fetchSourceData(ctx context.Context, rule Rule, fieldValue
 			)
 			return nil, false, err
 		}
-		metrics.EnrichmentMessagesTotal.WithLabelValues("cache_hit").Inc()
+		metrics.IncEnrichmentMessage("cache_hit")
+		metrics.IncEnrichmentCacheHit(rule.SourceType, "l2")
 		s.logger.DebugwCtx(ctx, "Source data retrieved from cache",
 			"rule_id", rule.ID,
 			"source_data_keys", getMapKeys(sourceData),
@@ -349,11 +972,12 @@ func (s *serviceImpl) fetchSourceData(ctx context.Context, rule Rule, fieldValue
 		"cache_error", err,
 	)
 
-	metrics.EnrichmentMessagesTotal.WithLabelValues("cache_miss").Inc()
+	metrics.IncEnrichmentMessage("cache_miss")
+	metrics.IncEnrichmentCacheMiss(rule.SourceType, "l2")
 	return s.fetchFromProvider(ctx, rule, fieldValue, cacheKey)
 }
 
-func (s *serviceImpl) fetchFromProvider(ctx context.Context, rule Rule, fieldValue interface{}, cacheKey string) (map[string]interface{}, bool, error) {
+func (s *serviceImpl) fetchFromProvider(ctx context.Context, rule Rule, fieldValue interface{}, cacheKey string) (result map[string]interface{}, cacheHit bool, err error) {
 	providerName := s.resolveProviderName(rule.SourceType)
 	s.logger.DebugwCtx(ctx, "Resolved provider name",
 		"rule_id", rule.ID,
@@ -361,7 +985,7 @@ func (s *serviceImpl) fetchFromProvider(ctx context.Context, rule Rule, fieldVal
 		"provider_name", providerName,
 	)
 
-	provider, ok := s.providers[providerName]
+	dataProvider, ok := s.providers[providerName]
 	if !ok {
 		s.logger.ErrorwCtx(ctx, "Provider not registered",
 			"rule_id", rule.ID,
@@ -372,6 +996,20 @@ func (s *serviceImpl) fetchFromProvider(ctx context.Context, rule Rule, fieldVal
 		return nil, false, fmt.Errorf("unknown source type: %s (provider not registered)", rule.SourceType)
 	}
 
+	dataProvider = s.ruleProviderFor(rule, dataProvider)
+	defer s.reportRuleBreakerState(rule, dataProvider)
+
+	if throttle := s.throttleFor(rule); throttle != nil {
+		release, waited, werr := throttle.acquire(ctx)
+		if werr != nil {
+			return nil, false, fmt.Errorf("rule %s: throttled: %w", rule.ID, werr)
+		}
+		metrics.SetEnrichmentRuleThrottled(rule.ID, waited)
+		defer func() {
+			release(err == nil, isProviderUnhealthy(dataProvider))
+		}()
+	}
+
 	providerConfig := convertSourceConfig(rule.SourceConfig)
 	s.logger.DebugwCtx(ctx, "Fetching data from provider",
 		"rule_id", rule.ID,
@@ -380,14 +1018,14 @@ func (s *serviceImpl) fetchFromProvider(ctx context.Context, rule Rule, fieldVal
 		"provider_config", providerConfig,
 	)
 
-	fetched, err := provider.Fetch(ctx, providerConfig, fieldValue)
-	if err != nil {
+	fetched, ferr := dataProvider.Fetch(ctx, providerConfig, fieldValue)
+	if ferr != nil {
 		s.logger.DebugwCtx(ctx, "Provider fetch failed",
 			"rule_id", rule.ID,
 			"provider_name", providerName,
-			"error", err,
+			"error", ferr,
 		)
-		return s.handleFetchError(ctx, rule, providerName, err)
+		return s.handleFetchError(ctx, rule, providerName, ferr)
 	}
 
 	s.logger.DebugwCtx(ctx, "Data fetched from provider",
@@ -400,6 +1038,21 @@ func (s *serviceImpl) fetchFromProvider(ctx context.Context, rule Rule, fieldVal
 	return fetched, false, nil
 }
 
+// isProviderUnhealthy reports whether p's circuit breaker is open or its
+// p99 latency exceeds adaptiveLatencyThresholdMs, the two signals
+// ruleThrottle uses (alongside outright fetch errors) to shrink a rule's
+// adaptive concurrency ceiling. A provider with no Health method (no
+// circuit breaker wrapping it) is never considered unhealthy by this
+// check alone.
+func isProviderUnhealthy(p provider.DataProvider) bool {
+	hp, ok := p.(interface{ Health() provider.ProviderHealth })
+	if !ok {
+		return false
+	}
+	h := hp.Health()
+	return h.CircuitState == provider.CircuitStateOpen || h.LatencyP99Ms > adaptiveLatencyThresholdMs
+}
+
 func (s *serviceImpl) resolveProviderName(sourceType string) string {
 	if sourceType == constants.SourceTypeDatabase {
 		return constants.ProviderNameMongoDB
@@ -411,7 +1064,11 @@ func (s *serviceImpl) resolveProviderName(sourceType string) string {
 }
 
 func (s *serviceImpl) handleFetchError(ctx context.Context, rule Rule, providerName string, err error) (map[string]interface{}, bool, error) {
-	metrics.EnrichmentMessagesTotal.WithLabelValues("error").Inc()
+	metrics.IncEnrichmentMessage("error")
+
+	if errors.Is(err, provider.ErrSourceDenied) {
+		return nil, false, fmt.Errorf("enrichment failed for rule %s (field: %s, provider: %s): %w", rule.Name, rule.FieldToEnrich, providerName, err)
+	}
 
 	if rule.FallbackValue != nil {
 		metrics.FallbackUsageTotal.WithLabelValues("enrichment", "fallback_value", err.Error()).Inc()
@@ -470,6 +1127,188 @@ func (s *serviceImpl) cacheSourceData(ctx context.Context, cacheKey string, sour
 	}
 }
 
+// fetchSourceDataBatch resolves rule's source data for every message in msgs
+// in one pass: a single MGET covers every distinct field value already
+// cached, one bulk provider call covers the rest, and the fetched misses are
+// written back to the cache before returning. The result map is keyed by
+// index into msgs and omits any message missing rule.FieldToEnrich, mirroring
+// Process's per-message "field not found, skip rule" behavior.
+//
+// A non-nil error means some message hit a rule.ErrorHandling of "fail" with
+// no fallback value, same as Process returning an error for that message -
+// but because ProcessBatch has a single error return for the whole batch,
+// that one message's fail aborts processing of this rule (and the batch's
+// remaining rules) for every message, not just the one that failed. A
+// skip_rule/skip_field miss, by contrast, only drops that one message's
+// entry from the returned map, so its other rules and messages are
+// unaffected.
+func (s *serviceImpl) fetchSourceDataBatch(ctx context.Context, rule Rule, msgs []models.MessageEnvelope) (map[int]map[string]interface{}, error) {
+	fieldValueByMsg := make(map[int]interface{}, len(msgs))
+	cacheKeyByValue := make(map[interface{}]string)
+
+	for i, msg := range msgs {
+		fieldValue, exists := msg.GetPayloadField(rule.FieldToEnrich)
+		if !exists {
+			continue
+		}
+		fieldValueByMsg[i] = fieldValue
+		cacheKeyByValue[fieldValue] = fmt.Sprintf("%s%s:%v", constants.CacheKeyPrefixEnrich, rule.ID, fieldValue)
+	}
+
+	if len(fieldValueByMsg) == 0 {
+		return nil, nil
+	}
+
+	uniqueValues := make([]interface{}, 0, len(cacheKeyByValue))
+	cacheKeys := make([]string, 0, len(cacheKeyByValue))
+	for fieldValue, cacheKey := range cacheKeyByValue {
+		uniqueValues = append(uniqueValues, fieldValue)
+		cacheKeys = append(cacheKeys, cacheKey)
+	}
+
+	cached, err := s.mgetSourceData(ctx, cacheKeys)
+	if err != nil {
+		s.logger.WarnwCtx(ctx, "Batch MGET failed, treating batch as a full cache miss",
+			"rule_id", rule.ID,
+			"error", err,
+		)
+		cached = make(map[string]map[string]interface{})
+	}
+
+	resultByValue := make(map[interface{}]map[string]interface{}, len(uniqueValues))
+	var misses []interface{}
+	var cacheHits, cacheMisses int
+
+	for _, fieldValue := range uniqueValues {
+		if data, ok := cached[cacheKeyByValue[fieldValue]]; ok {
+			resultByValue[fieldValue] = data
+			cacheHits++
+		} else {
+			misses = append(misses, fieldValue)
+			cacheMisses++
+		}
+	}
+	metrics.AddEnrichmentMessages("cache_hit", cacheHits)
+	metrics.AddEnrichmentMessages("cache_miss", cacheMisses)
+	s.updateCacheMetrics(cacheHits, cacheMisses)
+
+	if len(misses) > 0 {
+		providerName := s.resolveProviderName(rule.SourceType)
+		fetched, fetchErr := s.fetchBatchFromProvider(ctx, rule, misses)
+		if fetchErr != nil {
+			s.logger.WarnwCtx(ctx, "Batch provider fetch failed, falling back per value",
+				"rule_id", rule.ID,
+				"provider_name", providerName,
+				"error", fetchErr,
+			)
+		}
+
+		toCache := make(map[string][]byte, len(misses))
+		for _, fieldValue := range misses {
+			data, ok := fetched[fieldValue]
+			if !ok {
+				missErr := fetchErr
+				if missErr == nil {
+					missErr = fmt.Errorf("no result returned for field value %v", fieldValue)
+				}
+				fallback, _, err := s.handleFetchError(ctx, rule, providerName, missErr)
+				if err != nil {
+					if IsSkipRuleError(err) {
+						continue
+					}
+					return nil, err
+				}
+				data = fallback
+			} else if b, err := json.Marshal(data); err == nil {
+				toCache[cacheKeyByValue[fieldValue]] = b
+			}
+			resultByValue[fieldValue] = data
+		}
+
+		s.msetSourceData(ctx, toCache, rule.CacheTTLSeconds)
+	}
+
+	out := make(map[int]map[string]interface{}, len(fieldValueByMsg))
+	for i, fieldValue := range fieldValueByMsg {
+		if data, ok := resultByValue[fieldValue]; ok {
+			out[i] = data
+		}
+	}
+
+	return out, nil
+}
+
+func (s *serviceImpl) fetchBatchFromProvider(ctx context.Context, rule Rule, fieldValues []interface{}) (map[interface{}]map[string]interface{}, error) {
+	providerName := s.resolveProviderName(rule.SourceType)
+	p, ok := s.providers[providerName]
+	if !ok {
+		s.logger.ErrorwCtx(ctx, "Provider not registered",
+			"rule_id", rule.ID,
+			"source_type", rule.SourceType,
+			"provider_name", providerName,
+			"available_providers", getProviderNames(s.providers),
+		)
+		return nil, fmt.Errorf("unknown source type: %s (provider not registered)", rule.SourceType)
+	}
+
+	p = s.ruleProviderFor(rule, p)
+	defer s.reportRuleBreakerState(rule, p)
+
+	providerConfig := convertSourceConfig(rule.SourceConfig)
+	return provider.FetchBatch(ctx, p, providerConfig, fieldValues)
+}
+
+func (s *serviceImpl) mgetSourceData(ctx context.Context, cacheKeys []string) (map[string]map[string]interface{}, error) {
+	if len(cacheKeys) == 0 {
+		return nil, nil
+	}
+
+	vals, err := s.cache.MGet(ctx, cacheKeys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("redis mget failed: %w", err)
+	}
+
+	result := make(map[string]map[string]interface{}, len(cacheKeys))
+	for i, val := range vals {
+		str, ok := val.(string)
+		if !ok {
+			continue
+		}
+		var data map[string]interface{}
+		if err := json.Unmarshal([]byte(str), &data); err != nil {
+			s.logger.WarnwCtx(ctx, "Failed to unmarshal cached source data",
+				"error", err,
+				"cache_key", cacheKeys[i],
+			)
+			continue
+		}
+		result[cacheKeys[i]] = data
+	}
+
+	return result, nil
+}
+
+// msetSourceData writes every entry in data to the cache with ttlSeconds. It
+// uses a pipelined SET per key rather than a literal Redis MSET, since MSET
+// has no per-key expiry and every key here shares the same rule TTL anyway -
+// a pipeline gets the same one-round-trip benefit without losing expiry.
+func (s *serviceImpl) msetSourceData(ctx context.Context, data map[string][]byte, ttlSeconds int) {
+	if len(data) == 0 {
+		return
+	}
+
+	ttl := time.Duration(ttlSeconds) * time.Second
+	pipe := s.cache.Pipeline()
+	for key, value := range data {
+		pipe.Set(ctx, key, value, ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		s.logger.WarnwCtx(ctx, "Failed to cache batch enrichment data",
+			"error", err,
+		)
+	}
+}
+
 func (s *serviceImpl) applyTransformations(ctx context.Context, rule Rule, sourceData map[string]interface{}, msg *models.MessageEnvelope) {
 	s.logger.DebugwCtx(ctx, "Applying transformations",
 		"rule_id", rule.ID,
@@ -488,7 +1327,7 @@ func (s *serviceImpl) applyTransformations(ctx context.Context, rule Rule, sourc
 			"has_default", trans.Default != nil,
 		)
 
-		fieldValue, exists := s.getSourceFieldValue(trans.SourcePath, sourceData)
+		fieldValue, exists := s.getSourceFieldValue(ctx, trans.SourcePath, sourceData, *msg)
 
 		if !exists {
 			s.logger.DebugwCtx(ctx, "Source field not found",
@@ -497,7 +1336,7 @@ func (s *serviceImpl) applyTransformations(ctx context.Context, rule Rule, sourc
 				"source_path", trans.SourcePath,
 			)
 			if trans.Default != nil {
-				msg.Metadata.Enrichment[trans.TargetField] = trans.Default
+				msg.Metadata.Enrichment[trans.TargetField] = s.resolveDefault(ctx, trans.Default, *msg)
 				s.logger.DebugwCtx(ctx, "Using default value",
 					"rule_id", rule.ID,
 					"target_field", trans.TargetField,
@@ -546,7 +1385,7 @@ func (s *serviceImpl) applyTransformations(ctx context.Context, rule Rule, sourc
 					"error", err,
 				)
 				if trans.Default != nil {
-					msg.Metadata.Enrichment[trans.TargetField] = trans.Default
+					msg.Metadata.Enrichment[trans.TargetField] = s.resolveDefault(ctx, trans.Default, *msg)
 				}
 				continue
 			} else {
@@ -581,7 +1420,18 @@ func (s *serviceImpl) applyTransformations(ctx context.Context, rule Rule, sourc
 	)
 }
 
-func (s *serviceImpl) getSourceFieldValue(sourcePath string, sourceData map[string]interface{}) (interface{}, bool) {
+func (s *serviceImpl) getSourceFieldValue(ctx context.Context, sourcePath string, sourceData map[string]interface{}, msg models.MessageEnvelope) (interface{}, bool) {
+	if expr, ok := fullExpression(sourcePath); ok && s.evaluator != nil {
+		val, err := s.evaluator.EvaluateTransform(ctx, expr, msg, sourceData)
+		if err != nil {
+			s.logger.WarnwCtx(ctx, "SourcePath expression failed to evaluate",
+				"source_path", sourcePath,
+				"error", err,
+			)
+			return nil, false
+		}
+		return val, true
+	}
 	if sourcePath == "." {
 		return sourceData, true
 	}
@@ -629,11 +1479,29 @@ func convertSourceConfig(cfg SourceConfig) provider.SourceConfig {
 		Headers:    cfg.Headers,
 		TimeoutMs:  cfg.TimeoutMs,
 		RetryCount: cfg.RetryCount,
+		BatchURL:   cfg.BatchURL,
 		Database:   cfg.Database,
 		Collection: cfg.Collection,
 		Query:      query,
 		Field:      cfg.Field,
 		KeyPattern: cfg.KeyPattern,
 		CacheType:  cfg.CacheType,
+
+		QueryParams:     cfg.QueryParams,
+		Body:            cfg.Body,
+		BodyContentType: cfg.BodyContentType,
+
+		AuthType:     cfg.AuthType,
+		AuthToken:    cfg.AuthToken,
+		AuthUsername: cfg.AuthUsername,
+		AuthPassword: cfg.AuthPassword,
+
+		ClientCertFile: cfg.ClientCertFile,
+		ClientKeyFile:  cfg.ClientKeyFile,
+		CAFile:         cfg.CAFile,
+		ServerName:     cfg.ServerName,
+
+		ResponseJSONPath: cfg.ResponseJSONPath,
+		Address:          cfg.Address,
 	}
 }
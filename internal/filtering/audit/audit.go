@@ -0,0 +1,248 @@
+// Package audit records one structured Record per filtering.Service.Filter
+// call to a pluggable Sink, so an operator can answer "why was this message
+// rejected" or "was this rule ever actually evaluated" after the fact
+// without turning on debug logging across the whole service.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"yeti/internal/broker"
+	"yeti/internal/config"
+	"yeti/internal/logger"
+	"yeti/pkg/models"
+)
+
+// Record is one audit entry Service.Filter emits. Exactly one of
+// MatchedRuleIDs/RejectedByRuleID is ever meaningful for a given call,
+// mirroring Filter's own passed/appliedRules result: a message that passed
+// carries MatchedRuleIDs with RejectedByRuleID empty, one that didn't
+// carries the reverse.
+type Record struct {
+	MessageID        string    `json:"message_id"`
+	Passed           bool      `json:"passed"`
+	MatchedRuleIDs   []string  `json:"matched_rule_ids,omitempty"`
+	RejectedByRuleID string    `json:"rejected_by_rule_id,omitempty"`
+	Error            string    `json:"error,omitempty"`
+	LatencyMs        float64   `json:"latency_ms"`
+	TraceID          string    `json:"trace_id,omitempty"`
+	ServiceName      string    `json:"service_name,omitempty"`
+	Timestamp        time.Time `json:"timestamp"`
+}
+
+// Sink is where Record values go once ShouldSample decides a given Filter
+// call is worth recording. See NewSinkFromConfig for the drivers this
+// package ships.
+type Sink interface {
+	Write(ctx context.Context, record Record) error
+	Close() error
+}
+
+// ShouldSample decides whether a Filter call with the given passed/err
+// outcome should be written to a Sink: AlwaysLogOnReject/AlwaysLogOnError
+// force it regardless of SampleRate, so denials and CEL fallbacks are
+// captured in full even when the head-based sample rate is turned down low
+// for cost reasons. Otherwise it's an independent SampleRate-chance coin
+// flip per call, decided without regard to the outcome - the "head-based"
+// half of the scheme, as opposed to a tail-based sampler that only decides
+// once the outcome (and therefore the interesting cases) is already known.
+func ShouldSample(cfg config.FilteringAuditConfig, passed bool, err error) bool {
+	if err != nil && cfg.AlwaysLogOnError {
+		return true
+	}
+	if !passed && cfg.AlwaysLogOnReject {
+		return true
+	}
+	if cfg.SampleRate <= 0 {
+		return false
+	}
+	if cfg.SampleRate >= 1 {
+		return true
+	}
+	return rand.Float64() < cfg.SampleRate
+}
+
+// NewSinkFromConfig builds the Sink cfg.Driver selects. "" (the zero value,
+// so existing deployments are unaffected) and "none" return a sink that
+// discards every Record, keeping audit fully opt-in. "stdout" JSON-encodes
+// one Record per line to os.Stdout. "kafka" publishes to cfg.Kafka.Topic via
+// the same broker.Producer abstraction the rest of this repo uses for
+// message traffic. "file" appends to cfg.File.Path, rotating once it grows
+// past cfg.File.MaxSizeBytes.
+func NewSinkFromConfig(cfg config.FilteringAuditConfig, log logger.Logger) (Sink, error) {
+	switch cfg.Driver {
+	case "", "none":
+		return noopSink{}, nil
+	case "stdout":
+		return newStdoutSink(os.Stdout), nil
+	case "kafka":
+		return newKafkaSink(cfg.Kafka, log)
+	case "file":
+		return newFileSink(cfg.File)
+	default:
+		return nil, fmt.Errorf("unknown filtering audit driver %q", cfg.Driver)
+	}
+}
+
+type noopSink struct{}
+
+func (noopSink) Write(context.Context, Record) error { return nil }
+func (noopSink) Close() error                        { return nil }
+
+// stdoutSink JSON-encodes one Record per line to an io.Writer (os.Stdout in
+// production), guarded by a mutex since Filter runs concurrently across a
+// service's in-flight message handlers.
+type stdoutSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func newStdoutSink(w io.Writer) *stdoutSink {
+	return &stdoutSink{w: w}
+}
+
+func (s *stdoutSink) Write(_ context.Context, record Record) error {
+	body, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit record: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(body, '\n'))
+	return err
+}
+
+func (s *stdoutSink) Close() error { return nil }
+
+// kafkaSink publishes one Record per Filter call to a Kafka topic via
+// broker.Producer, wrapping the Record's fields into a
+// models.MessageEnvelope's Payload since Producer.Publish only knows how to
+// encode an envelope.
+type kafkaSink struct {
+	producer broker.Producer
+	topic    string
+}
+
+func newKafkaSink(cfg config.FilteringAuditKafkaConfig, log logger.Logger) (*kafkaSink, error) {
+	if cfg.Topic == "" {
+		return nil, fmt.Errorf("filtering audit kafka driver requires a topic")
+	}
+	producer := broker.NewKafkaProducer(config.KafkaConfig{Brokers: cfg.Brokers}, log, models.JSONCodec{})
+	return &kafkaSink{producer: producer, topic: cfg.Topic}, nil
+}
+
+func (s *kafkaSink) Write(ctx context.Context, record Record) error {
+	payload, err := recordToPayload(record)
+	if err != nil {
+		return err
+	}
+	envelope := models.MessageEnvelope{
+		ID:        record.MessageID,
+		Source:    "filtering-audit",
+		Timestamp: record.Timestamp,
+		Payload:   payload,
+		Metadata:  models.Metadata{TraceID: record.TraceID},
+	}
+	return s.producer.Publish(ctx, s.topic, envelope)
+}
+
+func (s *kafkaSink) Close() error { return s.producer.Close() }
+
+func recordToPayload(record Record) (map[string]interface{}, error) {
+	body, err := json.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal audit record: %w", err)
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("failed to convert audit record to payload: %w", err)
+	}
+	return payload, nil
+}
+
+// fileSink appends one JSON-encoded Record per line to a file, rotating -
+// renaming the current file aside with a nanosecond-timestamp suffix and
+// opening a fresh one - once writing the next record would cross
+// MaxSizeBytes. MaxSizeBytes <= 0 disables rotation entirely, matching this
+// repo's "<= 0 disables" convention (see config.CELConfig).
+type fileSink struct {
+	mu           sync.Mutex
+	path         string
+	maxSizeBytes int64
+	f            *os.File
+	size         int64
+}
+
+func newFileSink(cfg config.FilteringAuditFileConfig) (*fileSink, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("filtering audit file driver requires a path")
+	}
+	f, size, err := openForAppend(cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+	return &fileSink{path: cfg.Path, maxSizeBytes: cfg.MaxSizeBytes, f: f, size: size}, nil
+}
+
+func openForAppend(path string) (*os.File, int64, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open audit file %q: %w", path, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, fmt.Errorf("failed to stat audit file %q: %w", path, err)
+	}
+	return f, info.Size(), nil
+}
+
+func (s *fileSink) Write(_ context.Context, record Record) error {
+	body, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit record: %w", err)
+	}
+	body = append(body, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.maxSizeBytes > 0 && s.size+int64(len(body)) > s.maxSizeBytes {
+		if err := s.rotateLocked(); err != nil {
+			return err
+		}
+	}
+
+	n, err := s.f.Write(body)
+	s.size += int64(n)
+	return err
+}
+
+func (s *fileSink) rotateLocked() error {
+	if err := s.f.Close(); err != nil {
+		return fmt.Errorf("failed to close audit file %q before rotation: %w", s.path, err)
+	}
+	rotated := fmt.Sprintf("%s.%d", s.path, time.Now().UnixNano())
+	if err := os.Rename(s.path, rotated); err != nil {
+		return fmt.Errorf("failed to rotate audit file %q: %w", s.path, err)
+	}
+	f, size, err := openForAppend(s.path)
+	if err != nil {
+		return err
+	}
+	s.f, s.size = f, size
+	return nil
+}
+
+func (s *fileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.Close()
+}
@@ -1,13 +1,87 @@
 package filtering
 
-import "time"
+import (
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"time"
+)
 
+// Rule's json tags match management.FilteringRule's (see
+// internal/management/models.go) field-for-field, so a ConfigUpdateEvent's
+// embedded Rule payload - marshaled from a management.FilteringRule -
+// decodes straight into this type in Service.ApplyRuleDelta without a
+// separate conversion step.
 type Rule struct {
-	ID         string
-	Name       string
-	Expression string // CEL expression that must evaluate to bool
-	Priority   int
-	Enabled    bool
-	CreatedAt  time.Time
-	UpdatedAt  time.Time
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Expression string    `json:"expression"` // CEL expression that must evaluate to bool
+	Priority   int       `json:"priority"`
+	Enabled    bool      `json:"enabled"`
+	// Mode controls whether this rule's result can deny a message:
+	// ModeEnforce (or empty, for rules that predate this field) lets it
+	// deny like always; ModeShadow evaluates it and records what it would
+	// have decided (see Service.evaluateShadowRules) without denying;
+	// "canary:<percent>" resolves to enforce or shadow per-message,
+	// enforcing on roughly percent% of traffic. A shadow/canary rule is
+	// paired with its enforce-mode counterpart by matching Name, letting
+	// a new CEL expression be rolled out against production traffic
+	// before being flipped to ModeEnforce.
+	Mode string `json:"mode,omitempty"`
+	// MaxCost and MaxEvalDurationMs override config.CELConfig's
+	// evaluator-wide MaxCost/MaxEvalDurationMs for this rule alone (see
+	// Service.resolveRuleBudget) - a rule whose expression is unusually
+	// expensive (or unusually cheap and latency-sensitive) can be budgeted
+	// independently of every other rule sharing the evaluator. <= 0 (the
+	// zero value, so existing rules are unaffected) falls back to the
+	// service-wide default.
+	MaxCost           uint64    `json:"max_cost,omitempty"`
+	MaxEvalDurationMs int       `json:"max_eval_duration_ms,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+const (
+	ModeEnforce = "enforce"
+	ModeShadow  = "shadow"
+
+	canaryModePrefix = "canary:"
+)
+
+// isEnforced reports whether r's result should be allowed to affect
+// messageID's filter outcome this evaluation. A "canary:<percent>" mode is
+// resolved deterministically from messageID (fnv64(messageID) % 100 < pct),
+// so the same message consistently gets the same enforce-or-shadow
+// treatment across retries and redeliveries instead of flipping a coin on
+// every evaluation.
+func (r Rule) isEnforced(messageID string) bool {
+	switch {
+	case r.Mode == "" || r.Mode == ModeEnforce:
+		return true
+	case r.Mode == ModeShadow:
+		return false
+	case strings.HasPrefix(r.Mode, canaryModePrefix):
+		pct, err := strconv.Atoi(strings.TrimPrefix(r.Mode, canaryModePrefix))
+		if err != nil {
+			return true
+		}
+		if pct >= 100 {
+			return true
+		}
+		if pct <= 0 {
+			return false
+		}
+		return fnv64a(messageID)%100 < uint64(pct)
+	default:
+		return true
+	}
+}
+
+// fnv64a hashes s with FNV-1a, giving isEnforced's canary sampling a stable,
+// well-distributed value to bucket a message ID against without needing
+// cryptographic strength.
+func fnv64a(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
 }
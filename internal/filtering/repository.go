@@ -3,11 +3,22 @@ package filtering
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 )
 
+// ErrRuleNotFound is returned by GetRuleByID when no filtering rule with
+// the given ID exists, so callers (see Service.ReloadRule) can tell a
+// deleted rule apart from a query failure.
+var ErrRuleNotFound = errors.New("filtering rule not found")
+
 type Repository interface {
 	GetActiveRules(ctx context.Context) ([]Rule, error)
+
+	// GetRuleByID returns the rule regardless of its Enabled flag, so a
+	// targeted reload can tell a disabled rule apart from a deleted one.
+	// It returns ErrRuleNotFound if no rule with that ID exists.
+	GetRuleByID(ctx context.Context, id string) (*Rule, error)
 }
 
 type PostgresRepository struct {
@@ -20,7 +31,7 @@ func NewRepository(db *sql.DB) Repository {
 
 func (r *PostgresRepository) GetActiveRules(ctx context.Context) ([]Rule, error) {
 	query := `
-		SELECT id, name, expression, priority, enabled, created_at, updated_at
+		SELECT id, name, expression, priority, enabled, mode, max_cost, max_eval_duration_ms, created_at, updated_at
 		FROM filtering_rules
 		WHERE enabled = true
 		ORDER BY priority DESC, created_at ASC
@@ -41,6 +52,9 @@ func (r *PostgresRepository) GetActiveRules(ctx context.Context) ([]Rule, error)
 			&rule.Expression,
 			&rule.Priority,
 			&rule.Enabled,
+			&rule.Mode,
+			&rule.MaxCost,
+			&rule.MaxEvalDurationMs,
 			&rule.CreatedAt,
 			&rule.UpdatedAt,
 		); err != nil {
@@ -55,3 +69,33 @@ func (r *PostgresRepository) GetActiveRules(ctx context.Context) ([]Rule, error)
 
 	return rules, nil
 }
+
+func (r *PostgresRepository) GetRuleByID(ctx context.Context, id string) (*Rule, error) {
+	query := `
+		SELECT id, name, expression, priority, enabled, mode, max_cost, max_eval_duration_ms, created_at, updated_at
+		FROM filtering_rules
+		WHERE id = $1
+	`
+
+	var rule Rule
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&rule.ID,
+		&rule.Name,
+		&rule.Expression,
+		&rule.Priority,
+		&rule.Enabled,
+		&rule.Mode,
+		&rule.MaxCost,
+		&rule.MaxEvalDurationMs,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRuleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rule %s: %w", id, err)
+	}
+
+	return &rule, nil
+}
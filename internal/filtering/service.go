@@ -2,16 +2,26 @@ package filtering
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
 	"yeti/internal/config"
+	"yeti/internal/config_handler"
 	"yeti/internal/constants"
+	"yeti/internal/filtering/audit"
 	"yeti/internal/logger"
 	"yeti/pkg/cel"
+	"yeti/pkg/logging"
 	"yeti/pkg/metrics"
 	"yeti/pkg/models"
+	"yeti/pkg/retry"
 	"yeti/pkg/tracing"
 )
 
@@ -46,31 +56,88 @@ const (
 	errorHandlingSkip
 )
 
+// defaultGroupConcurrency bounds a RuleGroup's worker pool when
+// config.FilteringConfig.GroupConcurrency is left at its zero value.
+const defaultGroupConcurrency = 8
+
 type Service struct {
-	repo            Repository
-	rules           []Rule
-	rulesMu         sync.RWMutex
-	filteringConfig config.FilteringConfig
-	evaluator       *cel.Evaluator
-	logger          logger.Logger
+	repo             Repository
+	rules            []Rule
+	rulesMu          sync.RWMutex
+	filteringConfig  config.FilteringConfig
+	evaluator        *cel.Evaluator
+	groupConcurrency int
+	logger           logger.Logger
+	auditSink        audit.Sink
+}
+
+// ServiceOption customizes a Service built by NewService beyond what
+// config.FilteringConfig alone can express - see WithAuditSink.
+type ServiceOption func(*Service)
+
+// WithAuditSink overrides the audit.Sink NewService would otherwise build
+// from cfg.Audit via audit.NewSinkFromConfig. Production wiring has no
+// reason to use this - it exists so a test can inject a fake Sink and
+// assert on the Record values Filter emits.
+func WithAuditSink(sink audit.Sink) ServiceOption {
+	return func(s *Service) {
+		s.auditSink = sink
+	}
 }
 
-func NewService(repo Repository, cfg config.FilteringConfig, log logger.Logger) (*Service, error) {
-	evaluator, err := cel.NewEvaluator()
+func NewService(repo Repository, cfg config.FilteringConfig, log logger.Logger, opts ...ServiceOption) (*Service, error) {
+	programCacheSize := cfg.CEL.ProgramCacheSize
+	if programCacheSize <= 0 {
+		programCacheSize = cel.DefaultProgramCacheSize
+	}
+	evaluator, err := cel.NewEvaluatorWithCacheSize(programCacheSize)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create CEL evaluator: %w", err)
 	}
+	evaluator.WithEvalBudget(cfg.CEL.MaxCost, time.Duration(cfg.CEL.MaxEvalDurationMs)*time.Millisecond)
+	evaluator.WithMaxEstimatedCost(cfg.CEL.MaxEstimatedCost)
+	if cfg.CEL.RuleBreaker.FailureThreshold > 0 {
+		evaluator.WithRuleBreaker(retry.CircuitBreakerConfig{
+			FailureThreshold: cfg.CEL.RuleBreaker.FailureThreshold,
+			SuccessThreshold: cfg.CEL.RuleBreaker.SuccessThreshold,
+			WindowSize:       cfg.CEL.RuleBreaker.WindowSize,
+			OpenTimeout:      time.Duration(cfg.CEL.RuleBreaker.OpenTimeoutSeconds) * time.Second,
+		})
+	}
+
+	groupConcurrency := cfg.GroupConcurrency
+	if groupConcurrency <= 0 {
+		groupConcurrency = defaultGroupConcurrency
+	}
 
-	return &Service{
-		repo:            repo,
-		filteringConfig: cfg,
-		rules:           make([]Rule, 0),
-		evaluator:       evaluator,
-		logger:          log,
-	}, nil
+	svc := &Service{
+		repo:             repo,
+		filteringConfig:  cfg,
+		rules:            make([]Rule, 0),
+		evaluator:        evaluator,
+		groupConcurrency: groupConcurrency,
+		logger:           log,
+	}
+	for _, opt := range opts {
+		opt(svc)
+	}
+	if svc.auditSink == nil {
+		sink, err := audit.NewSinkFromConfig(cfg.Audit, log)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create filtering audit sink: %w", err)
+		}
+		svc.auditSink = sink
+	}
+	return svc, nil
+}
+
+// Close releases resources NewService acquired on cfg's behalf - currently
+// just the audit sink (e.g. a kafka sink's Producer).
+func (s *Service) Close() error {
+	return s.auditSink.Close()
 }
 
-func (s *Service) Filter(ctx context.Context, msg models.MessageEnvelope) (bool, []string, error) {
+func (s *Service) Filter(ctx context.Context, msg models.MessageEnvelope) (bool, []string, []ShadowVerdict, error) {
 	ctx, span := tracing.GetTracer("filtering-service").Start(ctx, "filtering.filter")
 	defer span.End()
 
@@ -89,20 +156,56 @@ func (s *Service) Filter(ctx context.Context, msg models.MessageEnvelope) (bool,
 	appliedRules := make([]string, 0, len(rules))
 	start := time.Now()
 
-	passed, appliedRules, err := s.evaluateRules(ctx, rules, msg, &appliedRules)
+	passed, appliedRules, shadowResults, deniedRuleID, err := s.evaluateRules(ctx, rules, msg, &appliedRules)
 
 	duration := time.Since(start)
 	s.recordMetrics(duration, passed)
-	
+
 	s.logger.DebugwCtx(ctx, "Filtering completed",
 		"message_id", msg.ID,
 		"passed", passed,
 		"applied_rules_count", len(appliedRules),
 		"applied_rule_ids", appliedRules,
+		"shadow_results_count", len(shadowResults),
 		"duration_ms", duration.Milliseconds(),
 	)
 
-	return passed, appliedRules, err
+	s.emitAuditRecord(ctx, msg, passed, appliedRules, deniedRuleID, duration, err)
+
+	return passed, appliedRules, shadowResults, err
+}
+
+// emitAuditRecord writes one audit.Record for this Filter call to
+// s.auditSink, subject to audit.ShouldSample - a Filter call that
+// ShouldSample declines costs nothing beyond that one check. Sink errors
+// are logged rather than returned: a broken audit sink shouldn't start
+// failing the filter pipeline itself.
+func (s *Service) emitAuditRecord(ctx context.Context, msg models.MessageEnvelope, passed bool, appliedRules []string, deniedRuleID string, duration time.Duration, filterErr error) {
+	cfg := s.filteringConfig.Audit
+	if !audit.ShouldSample(cfg, passed, filterErr) {
+		return
+	}
+
+	record := audit.Record{
+		MessageID:        msg.ID,
+		Passed:           passed,
+		MatchedRuleIDs:   appliedRules,
+		RejectedByRuleID: deniedRuleID,
+		LatencyMs:        float64(duration.Microseconds()) / 1000,
+		TraceID:          logging.GetTraceID(ctx),
+		ServiceName:      logging.GetServiceName(ctx),
+		Timestamp:        time.Now(),
+	}
+	if filterErr != nil {
+		record.Error = filterErr.Error()
+	}
+
+	if err := s.auditSink.Write(ctx, record); err != nil {
+		s.logger.WarnwCtx(ctx, "Failed to write filtering audit record",
+			"message_id", msg.ID,
+			"error", err,
+		)
+	}
 }
 
 func (s *Service) getActiveRules() []Rule {
@@ -114,66 +217,344 @@ func (s *Service) getActiveRules() []Rule {
 	return rules
 }
 
-func (s *Service) evaluateRules(ctx context.Context, rules []Rule, msg models.MessageEnvelope, appliedRules *[]string) (bool, []string, error) {
+// evaluateRules returns, in addition to the pass/fail/applied/shadow
+// results its signature already carried, the ID of the rule that denied the
+// message - empty if the message passed or no single rule is responsible
+// (e.g. ctx was already cancelled). It exists purely for Filter's audit
+// record; nothing else in this package needs to know which rule denied as
+// opposed to just whether one did.
+func (s *Service) evaluateRules(ctx context.Context, rules []Rule, msg models.MessageEnvelope, appliedRules *[]string) (bool, []string, []ShadowVerdict, string, error) {
 	ctx, span := tracing.GetTracer("filtering-service").Start(ctx, "filtering.evaluate_rules")
 	defer span.End()
 
-	for i, rule := range rules {
+	enforceRules := make([]Rule, 0, len(rules))
+	shadowRules := make([]Rule, 0)
+	for _, rule := range rules {
+		if rule.isEnforced(msg.ID) {
+			enforceRules = append(enforceRules, rule)
+		} else {
+			shadowRules = append(shadowRules, rule)
+		}
+	}
+
+	var shadowResults []ShadowVerdict
+	if len(shadowRules) > 0 {
+		shadowResults = s.evaluateShadowRules(ctx, shadowRules, enforceRules, msg)
+	}
+
+	for _, group := range groupRulesByPriority(enforceRules) {
 		if err := ctx.Err(); err != nil {
-			return false, nil, err
+			return false, nil, shadowResults, "", err
+		}
+
+		passed, groupApplied, deniedRuleID, err := s.evaluateRuleGroup(ctx, group, msg)
+		if err != nil {
+			return false, nil, shadowResults, "", err
+		}
+		if !passed {
+			return false, *appliedRules, shadowResults, deniedRuleID, nil
+		}
+		*appliedRules = append(*appliedRules, groupApplied...)
+	}
+
+	s.logger.DebugwCtx(ctx, "All rules passed",
+		"total_rules", len(enforceRules),
+		"applied_rules_count", len(*appliedRules),
+	)
+
+	return true, *appliedRules, shadowResults, "", nil
+}
+
+// RuleGroup is a batch of enforce-mode rules that share the same Priority,
+// and are therefore treated as order-independent: Service.evaluateRuleGroup
+// evaluates every rule in a group concurrently, unlike rules in different
+// groups, whose relative priority order (highest first) is preserved.
+type RuleGroup struct {
+	Priority int
+	Rules    []Rule
+}
+
+// groupRulesByPriority partitions rules - already sorted priority DESC (see
+// applyRuleUpdate/loadRules' ORDER BY) - into contiguous RuleGroups,
+// preserving that order between groups. A rule whose Priority is unique
+// among rules ends up alone in its own group, so evaluateRuleGroup's
+// single-rule path (identical to how this loop evaluated rules before
+// RuleGroup existed) is what actually runs for the common case of
+// distinctly-prioritized rules.
+func groupRulesByPriority(rules []Rule) []RuleGroup {
+	groups := make([]RuleGroup, 0, len(rules))
+	for _, rule := range rules {
+		if n := len(groups); n > 0 && groups[n-1].Priority == rule.Priority {
+			groups[n-1].Rules = append(groups[n-1].Rules, rule)
+			continue
+		}
+		groups = append(groups, RuleGroup{Priority: rule.Priority, Rules: []Rule{rule}})
+	}
+	return groups
+}
+
+// ruleOutcome is evaluateRule's result: whether the message continues past
+// the rule, stops because the rule denied it, or stops because
+// handleEvaluationError chose to deny it (ruleOutcomeDeny covers both -
+// evaluateRuleGroup treats a failed rule and a denying error identically).
+type ruleOutcome int
+
+const (
+	// ruleOutcomeUnevaluated is outcomes' zero value, left in place for a
+	// rule evaluateRuleGroup's worker pool never got to run because the
+	// group was already cancelled (see evaluateRuleGroup). It's handled the
+	// same as ruleOutcomeSkip: neither denies the message nor adds an ID to
+	// appliedRules.
+	ruleOutcomeUnevaluated ruleOutcome = iota
+	ruleOutcomePass
+	ruleOutcomeSkip
+	ruleOutcomeDeny
+)
+
+// resolveRuleBudget builds rule's effective cel.EvalBudget, following the
+// same "<= 0 falls back to the service-wide default" convention as
+// config.CELConfig itself: a rule with no MaxCost/MaxEvalDurationMs override
+// gets filteringConfig.CEL's values (already applied evaluator-wide via
+// NewService's WithEvalBudget), so EvaluateFilterForRuleWithBudget's
+// zero-value fast path takes over for the common case of an un-overridden
+// rule. Mirrors resolvedDedupPolicy/Service.resolvePolicy in
+// internal/deduplication/service.go.
+func (s *Service) resolveRuleBudget(rule Rule) cel.EvalBudget {
+	budget := cel.EvalBudget{MaxCost: rule.MaxCost}
+	if rule.MaxEvalDurationMs > 0 {
+		budget.MaxDuration = time.Duration(rule.MaxEvalDurationMs) * time.Millisecond
+	}
+	return budget
+}
+
+// evaluateRule runs a single rule's expression against msg, recording
+// filtering_rule_eval_duration_seconds and filtering_rule_evaluations_total
+// around the call, and translates an evaluation error through
+// handleEvaluationError into ruleOutcomeDeny or ruleOutcomeSkip.
+func (s *Service) evaluateRule(ctx context.Context, rule Rule, msg models.MessageEnvelope) ruleOutcome {
+	ctx, span := tracing.GetTracer("filtering-service").Start(ctx, "filtering.evaluate_rule")
+	defer span.End()
+	span.SetAttributes(attribute.String("rule_id", rule.ID))
+
+	start := time.Now()
+	result, err := s.evaluator.EvaluateFilterForRuleWithBudget(ctx, rule.ID, rule.Expression, s.resolveRuleBudget(rule), msg)
+	metrics.ObserveFilteringRuleEvalDuration(rule.ID, time.Since(start))
+
+	if err != nil {
+		if _, exceeded := cel.IsBudgetExceeded(err); exceeded {
+			metrics.IncFilteringRuleCostExceeded(rule.ID)
 		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		status := s.handleEvaluationError(ctx, rule, err)
+		if status == errorHandlingDeny {
+			s.logger.DebugwCtx(ctx, "Message denied due to evaluation error",
+				"rule_id", rule.ID,
+				"rule_name", rule.Name,
+			)
+			metrics.IncFilteringRuleEvaluation(rule.ID, rule.Name, "error_deny")
+			return ruleOutcomeDeny
+		}
+		metrics.IncFilteringRuleEvaluation(rule.ID, rule.Name, "error_skip")
+		return ruleOutcomeSkip
+	}
 
+	s.logger.DebugwCtx(ctx, "Rule evaluation result",
+		"rule_id", rule.ID,
+		"rule_name", rule.Name,
+		"result", result,
+	)
+
+	if !result {
+		s.logger.DebugwCtx(ctx, "Rule filtered message",
+			"rule_id", rule.ID,
+			"rule_name", rule.Name,
+		)
+		metrics.IncFilteringRuleEvaluation(rule.ID, rule.Name, "filtered")
+		return ruleOutcomeDeny
+	}
+
+	metrics.IncFilteringRuleEvaluation(rule.ID, rule.Name, "passed")
+	return ruleOutcomePass
+}
+
+// evaluateRuleGroup evaluates every rule in group against msg and reports
+// whether the message passes the whole group, plus the IDs of the rules
+// that passed (in group.Rules order, not completion order, so the result is
+// deterministic regardless of goroutine scheduling) and, if the group
+// denied, the ID of the rule that denied it. A group of exactly one
+// rule - the common case, since distinct Priority values each get their own
+// group - runs inline with no goroutine/worker-pool overhead, identical to
+// how this package evaluated rules before RuleGroup existed. A larger group
+// (rules sharing one Priority) runs concurrently, bounded by
+// Service.groupConcurrency, and short-circuits the rest of the group as
+// soon as one rule denies - "short-circuit the whole batch" in this sense
+// means the remaining rules in the group stop, not that other, already
+// evaluated groups are unwound.
+func (s *Service) evaluateRuleGroup(ctx context.Context, group RuleGroup, msg models.MessageEnvelope) (bool, []string, string, error) {
+	if len(group.Rules) == 1 {
+		rule := group.Rules[0]
 		s.logger.DebugwCtx(ctx, "Evaluating filtering rule",
-			"rule_index", i+1,
-			"total_rules", len(rules),
 			"rule_id", rule.ID,
 			"rule_name", rule.Name,
 			"expression", rule.Expression,
 			"priority", rule.Priority,
 			"enabled", rule.Enabled,
 		)
+		switch s.evaluateRule(ctx, rule, msg) {
+		case ruleOutcomeDeny:
+			return false, nil, rule.ID, nil
+		case ruleOutcomeSkip:
+			return true, nil, "", nil
+		default:
+			return true, []string{rule.ID}, "", nil
+		}
+	}
 
-		result, err := s.evaluator.EvaluateFilter(ctx, rule.Expression, msg)
-		if err != nil {
-			status := s.handleEvaluationError(ctx, rule, err)
-			if status == errorHandlingDeny {
-				s.logger.DebugwCtx(ctx, "Message denied due to evaluation error",
-					"rule_id", rule.ID,
-					"rule_name", rule.Name,
-				)
-				return false, *appliedRules, nil
+	groupCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	outcomes := make([]ruleOutcome, len(group.Rules))
+	sem := make(chan struct{}, s.groupConcurrency)
+	var wg sync.WaitGroup
+
+	for i, rule := range group.Rules {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, rule Rule) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if groupCtx.Err() != nil {
+				return
 			}
-			continue
+
+			s.logger.DebugwCtx(ctx, "Evaluating filtering rule",
+				"rule_id", rule.ID,
+				"rule_name", rule.Name,
+				"expression", rule.Expression,
+				"priority", rule.Priority,
+				"enabled", rule.Enabled,
+			)
+
+			outcome := s.evaluateRule(groupCtx, rule, msg)
+			outcomes[i] = outcome
+			if outcome == ruleOutcomeDeny {
+				cancel()
+			}
+		}(i, rule)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return false, nil, "", err
+	}
+
+	applied := make([]string, 0, len(group.Rules))
+	for i, outcome := range outcomes {
+		switch outcome {
+		case ruleOutcomeDeny:
+			return false, nil, group.Rules[i].ID, nil
+		case ruleOutcomePass:
+			applied = append(applied, group.Rules[i].ID)
 		}
+	}
 
-		s.logger.DebugwCtx(ctx, "Rule evaluation result",
-			"rule_id", rule.ID,
-			"rule_name", rule.Name,
-			"result", result,
-		)
+	return true, applied, "", nil
+}
 
-		if !result {
-			s.logger.DebugwCtx(ctx, "Rule filtered message",
+// ShadowVerdict is one shadow-mode (or unsampled-canary) rule's recorded
+// would-be decision for a single message, returned from Filter alongside
+// its enforced passed/appliedRules result so a caller's metrics/audit can
+// tell an observed decision apart from one that actually affected the
+// message - see evaluateShadowRules.
+type ShadowVerdict struct {
+	RuleID    string `json:"rule_id"`
+	RuleName  string `json:"rule_name"`
+	Mode      string `json:"mode"`
+	WouldPass bool   `json:"would_pass"`
+	// Diverged is only meaningful when this shadow rule is paired with an
+	// enforce rule of the same Name; it's false for a shadow rule with no
+	// enforce counterpart to diff against.
+	Diverged bool `json:"diverged,omitempty"`
+}
+
+// evaluateShadowRules runs rules in shadow mode (or the unsampled portion
+// of a "canary:<percent>" rule) against msg, recording what each would have
+// decided via filtering_shadow_decisions_total and as a returned
+// ShadowVerdict, without letting the result affect the caller's filter
+// decision or appliedRules list. When an enforceRules entry shares the
+// shadow rule's Name - this package's convention for pairing a shadow
+// candidate with the enforce rule it's meant to replace - its result is
+// evaluated too and compared, recording filtering_shadow_divergence_total
+// and ShadowVerdict.Diverged on disagreement.
+func (s *Service) evaluateShadowRules(ctx context.Context, shadowRules, enforceRules []Rule, msg models.MessageEnvelope) []ShadowVerdict {
+	ctx, span := tracing.GetTracer("filtering-service").Start(ctx, "filtering.evaluate_shadow_rules")
+	defer span.End()
+
+	enforceByName := make(map[string]Rule, len(enforceRules))
+	for _, rule := range enforceRules {
+		enforceByName[rule.Name] = rule
+	}
+
+	verdicts := make([]ShadowVerdict, 0, len(shadowRules))
+
+	for _, rule := range shadowRules {
+		result, err := s.evaluator.EvaluateFilterForRule(ctx, rule.ID, rule.Expression, msg)
+		if err != nil {
+			s.logger.WarnwCtx(ctx, "Shadow rule evaluation error, skipping",
 				"rule_id", rule.ID,
 				"rule_name", rule.Name,
+				"mode", rule.Mode,
+				"error", err,
 			)
-			return false, *appliedRules, nil
+			continue
 		}
 
-		*appliedRules = append(*appliedRules, rule.ID)
-		s.logger.DebugwCtx(ctx, "Rule passed, message continues",
+		wouldHave := "pass"
+		if !result {
+			wouldHave = "filter"
+		}
+		metrics.IncFilteringShadowDecision(rule.ID, wouldHave)
+		span.SetAttributes(
+			attribute.String("filtering.shadow_rule_id", rule.ID),
+			attribute.String("filtering.shadow_would_have", wouldHave),
+		)
+		s.logger.DebugwCtx(ctx, "Shadow rule evaluated",
 			"rule_id", rule.ID,
 			"rule_name", rule.Name,
-			"total_applied_rules", len(*appliedRules),
+			"mode", rule.Mode,
+			"would_have", wouldHave,
 		)
-	}
 
-	s.logger.DebugwCtx(ctx, "All rules passed",
-		"total_rules", len(rules),
-		"applied_rules_count", len(*appliedRules),
-	)
+		verdict := ShadowVerdict{RuleID: rule.ID, RuleName: rule.Name, Mode: rule.Mode, WouldPass: result}
+
+		enforceRule, ok := enforceByName[rule.Name]
+		if !ok {
+			verdicts = append(verdicts, verdict)
+			continue
+		}
+
+		enforceResult, err := s.evaluator.EvaluateFilterForRule(ctx, enforceRule.ID, enforceRule.Expression, msg)
+		if err != nil {
+			verdicts = append(verdicts, verdict)
+			continue
+		}
 
-	return true, *appliedRules, nil
+		if enforceResult != result {
+			verdict.Diverged = true
+			metrics.IncFilteringShadowDivergence(rule.ID)
+			span.SetAttributes(attribute.Bool("filtering.shadow_diverged", true))
+			s.logger.InfowCtx(ctx, "Shadow rule diverged from its enforce counterpart",
+				"shadow_rule_id", rule.ID,
+				"enforce_rule_id", enforceRule.ID,
+				"rule_name", rule.Name,
+			)
+		}
+		verdicts = append(verdicts, verdict)
+	}
+
+	return verdicts
 }
 
 func (s *Service) handleEvaluationError(ctx context.Context, rule Rule, err error) errorHandlingStatus {
@@ -210,7 +591,7 @@ func (s *Service) recordMetrics(duration time.Duration, passed bool) {
 	if !passed {
 		status = "filtered"
 	}
-	metrics.FilteringMessagesTotal.WithLabelValues(status).Inc()
+	metrics.IncFilteringMessage(status)
 	metrics.ObserveFilteringDuration(duration, status)
 }
 
@@ -224,6 +605,89 @@ func (s *Service) ReloadRules(ctx context.Context) error {
 	return nil
 }
 
+// ReloadRule re-fetches a single rule by ID and applies just that change to
+// the in-memory rule set, instead of ReloadRules' full GetActiveRules scan.
+// It's what config_handler.Handler calls when a ConfigUpdateEvent names a
+// specific rule_id, so a management API edit becomes visible within
+// milliseconds without waiting on Service.StartReloader's periodic sweep.
+// A deleted or disabled rule is removed from the in-memory set.
+func (s *Service) ReloadRule(ctx context.Context, ruleID string) error {
+	start := time.Now()
+
+	rule, err := s.repo.GetRuleByID(ctx, ruleID)
+	if errors.Is(err, ErrRuleNotFound) {
+		rule = nil
+	} else if err != nil {
+		s.logger.ErrorwCtx(ctx, "Failed to load rule for targeted reload",
+			"rule_id", ruleID,
+			"error", err,
+		)
+		return err
+	}
+
+	s.applyRuleUpdate(ctx, ruleID, rule)
+	metrics.ObserveConfigReloadDuration("filtering", "rule", time.Since(start))
+
+	return nil
+}
+
+// ApplyRuleDelta implements config_handler.RuleDeltaApplier: it applies a
+// ConfigUpdateEvent's embedded Rule payload directly, skipping the
+// repository round trip ReloadRule makes. A "delete" needs no payload and is
+// applied directly by RuleID. For any other action it returns
+// config_handler.ErrNoRuleDelta when the event carries no payload to apply
+// (events published before the Rule field existed leave it empty), so
+// Handler falls back to ReloadRule/ReloadRules.
+func (s *Service) ApplyRuleDelta(ctx context.Context, event models.ConfigUpdateEvent) error {
+	if event.Action == models.ActionDelete {
+		s.applyRuleUpdate(ctx, event.RuleID, nil)
+		metrics.SetRulesLastDeltaTimestamp("filtering", time.Now())
+		return nil
+	}
+
+	if len(event.Rule) == 0 {
+		return config_handler.ErrNoRuleDelta
+	}
+
+	var rule Rule
+	if err := json.Unmarshal(event.Rule, &rule); err != nil {
+		return fmt.Errorf("failed to decode rule delta for %s: %w", event.RuleID, err)
+	}
+
+	s.applyRuleUpdate(ctx, rule.ID, &rule)
+	metrics.SetRulesLastDeltaTimestamp("filtering", time.Now())
+	return nil
+}
+
+func (s *Service) applyRuleUpdate(ctx context.Context, ruleID string, rule *Rule) {
+	s.rulesMu.Lock()
+	rules := make([]Rule, 0, len(s.rules)+1)
+	for _, existing := range s.rules {
+		if existing.ID == ruleID {
+			continue
+		}
+		rules = append(rules, existing)
+	}
+	if rule != nil && rule.Enabled {
+		rules = append(rules, *rule)
+	}
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].Priority != rules[j].Priority {
+			return rules[i].Priority > rules[j].Priority
+		}
+		return rules[i].CreatedAt.Before(rules[j].CreatedAt)
+	})
+	s.rules = rules
+	s.rulesMu.Unlock()
+
+	metrics.SetFilteringActiveRules(len(rules))
+	s.logger.InfowCtx(ctx, "Applied targeted rule reload",
+		"rule_id", ruleID,
+		"removed", rule == nil || !rule.Enabled,
+		"new_rules_count", len(rules),
+	)
+}
+
 func (s *Service) loadRules(ctx context.Context) ([]Rule, error) {
 	s.logger.DebugwCtx(ctx, "Loading rules from database")
 	rules, err := s.repo.GetActiveRules(ctx)
@@ -256,6 +720,11 @@ func (s *Service) updateRules(ctx context.Context, rules []Rule) {
 	)
 }
 
+// StartReloader periodically runs a full ReloadRules on
+// filteringConfig.Reload.IntervalSeconds. With config_handler.Handler
+// driving targeted ReloadRule calls off ConfigUpdateEvents, this loop is
+// now the reconciliation fallback that catches up if a Kafka event is
+// ever dropped or delivered to a replica that was down at the time.
 func (s *Service) StartReloader(ctx context.Context) error {
 	ticker := time.NewTicker(time.Duration(s.filteringConfig.Reload.IntervalSeconds) * time.Second)
 	defer ticker.Stop()
@@ -2,6 +2,8 @@ package logger
 
 import (
 	"context"
+	"time"
+
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"yeti/pkg/logging"
@@ -28,18 +30,45 @@ type Logger interface {
 	InfowCtx(ctx context.Context, msg string, keysAndValues ...interface{})
 	WarnwCtx(ctx context.Context, msg string, keysAndValues ...interface{})
 	ErrorwCtx(ctx context.Context, msg string, keysAndValues ...interface{})
+
+	// SetLevel updates the logger's minimum level in place, so it can be
+	// hot-reloaded without rebuilding the underlying zap core.
+	SetLevel(level string) error
+
+	// With returns a child logger with keysAndValues pinned to every entry
+	// it writes, in addition to whatever is passed at the call site.
+	With(keysAndValues ...interface{}) Logger
 }
 
 type SugaredLogger struct {
 	*zap.SugaredLogger
 	serviceName string
+	level       zap.AtomicLevel
 }
 
 func (l *SugaredLogger) SetServiceName(name string) {
 	l.serviceName = name
 }
 
+// SamplingConfig mirrors config.LoggingConfig's sampling tunables without
+// importing the config package (internal/config already imports
+// internal/logger for the hot-reload watcher, so the reverse import would
+// cycle). Zero values disable sampling.
+type SamplingConfig struct {
+	Initial    int
+	Thereafter int
+}
+
 func New(level string) (Logger, error) {
+	return NewWithSampling(level, SamplingConfig{})
+}
+
+// NewWithSampling is New plus a sampler: once Initial entries at a given
+// level have been logged within one second, only every Thereafter-th one
+// after that is kept. Error level is never sampled, regardless of the
+// configured values, since errors are rare enough not to need throttling
+// and too important to drop silently.
+func NewWithSampling(level string, sampling SamplingConfig) (Logger, error) {
 	cfg := zap.NewProductionConfig()
 
 	cfg.Encoding = "json"
@@ -51,6 +80,11 @@ func New(level string) (Logger, error) {
 	cfg.EncoderConfig.CallerKey = "caller"
 	cfg.EncoderConfig.StacktraceKey = "stacktrace"
 
+	// cfg.Build() would apply cfg.Sampling uniformly across every level
+	// (including errors), so sampling is disabled here and applied manually
+	// below via a core that exempts zapcore.ErrorLevel.
+	cfg.Sampling = nil
+
 	switch level {
 	case "debug":
 		cfg.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
@@ -62,16 +96,92 @@ func New(level string) (Logger, error) {
 		cfg.Level = zap.NewAtomicLevelAt(zapcore.InfoLevel)
 	}
 
-	zapLogger, err := cfg.Build()
+	var zapLogger *zap.Logger
+	var err error
+	if sampling.Initial > 0 || sampling.Thereafter > 0 {
+		zapLogger, err = cfg.Build(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return newErrorExemptSampler(core, sampling.Initial, sampling.Thereafter)
+		}))
+	} else {
+		zapLogger, err = cfg.Build()
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	return &SugaredLogger{
 		SugaredLogger: zapLogger.Sugar(),
+		level:         cfg.Level,
 	}, nil
 }
 
+// errorExemptSampler wraps a core with zap's standard sampler for
+// below-error levels, while routing error-and-above entries to the
+// unsampled core untouched.
+type errorExemptSampler struct {
+	sampled   zapcore.Core
+	unsampled zapcore.Core
+}
+
+func newErrorExemptSampler(core zapcore.Core, initial, thereafter int) zapcore.Core {
+	if initial <= 0 {
+		initial = 100
+	}
+	if thereafter <= 0 {
+		thereafter = 100
+	}
+	return &errorExemptSampler{
+		sampled:   zapcore.NewSamplerWithOptions(core, time.Second, initial, thereafter),
+		unsampled: core,
+	}
+}
+
+func (c *errorExemptSampler) Enabled(lvl zapcore.Level) bool {
+	return c.unsampled.Enabled(lvl)
+}
+
+func (c *errorExemptSampler) With(fields []zapcore.Field) zapcore.Core {
+	return &errorExemptSampler{
+		sampled:   c.sampled.With(fields),
+		unsampled: c.unsampled.With(fields),
+	}
+}
+
+func (c *errorExemptSampler) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.Level >= zapcore.ErrorLevel {
+		return c.unsampled.Check(ent, ce)
+	}
+	return c.sampled.Check(ent, ce)
+}
+
+func (c *errorExemptSampler) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.unsampled.Write(ent, fields)
+}
+
+func (c *errorExemptSampler) Sync() error {
+	return c.unsampled.Sync()
+}
+
+// With returns a child logger with keysAndValues pinned to every entry it
+// writes. It shares the same atomic level and service name as l, so
+// SetLevel on either one affects both.
+func (l *SugaredLogger) With(keysAndValues ...interface{}) Logger {
+	return &SugaredLogger{
+		SugaredLogger: l.SugaredLogger.With(keysAndValues...),
+		serviceName:   l.serviceName,
+		level:         l.level,
+	}
+}
+
+func (l *SugaredLogger) SetLevel(level string) error {
+	parsed, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	l.level.SetLevel(parsed)
+	return nil
+}
+
 func (l *SugaredLogger) DebugwCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
 	fields := l.getContextFields(ctx)
 	l.Debugw(msg, append(fields, keysAndValues...)...)
@@ -106,5 +216,6 @@ func NopLogger() Logger {
 	return &SugaredLogger{
 		SugaredLogger: zap.NewNop().Sugar(),
 		serviceName:   "",
+		level:         zap.NewAtomicLevel(),
 	}
 }
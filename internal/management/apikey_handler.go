@@ -0,0 +1,123 @@
+package management
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"yeti/internal/logger"
+	"yeti/pkg/errors"
+)
+
+type APIKeyHandler struct {
+	BaseHandler
+	AuthService AuthService
+}
+
+func NewAPIKeyHandler(authSvc AuthService, log logger.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		BaseHandler: BaseHandler{Logger: log},
+		AuthService: authSvc,
+	}
+}
+
+// RegisterAPIKeyRoutes wires /api/v1/keys. Every route requires
+// config:write: rotating or minting a credential is an administrative
+// action regardless of which other scopes the caller already has.
+func (h *APIKeyHandler) RegisterAPIKeyRoutes(router *gin.Engine) {
+	v1 := router.Group("/api/v1")
+	{
+		keys := v1.Group("/keys", RequireScope(ScopeConfigWrite))
+		{
+			keys.GET("", h.ListAPIKeys)
+			keys.POST("", h.CreateAPIKey)
+			keys.POST("/:id/rotate", h.RotateAPIKey)
+			keys.DELETE("/:id", h.RevokeAPIKey)
+		}
+	}
+}
+
+// ListAPIKeys godoc
+// @Summary      List API keys for the caller's tenant
+// @Description  List API keys scoped to the caller's tenant. Plaintext keys are never returned.
+// @Tags         api-keys
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}    APIKey
+// @Failure      500  {object}  errors.ErrorResponse
+// @Router       /keys [get]
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	keys, err := h.AuthService.ListAPIKeys(c.Request.Context(), TenantIDFromContext(c.Request.Context()))
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+// CreateAPIKey godoc
+// @Summary      Create an API key
+// @Description  Mint a new API key for a tenant. The plaintext key is returned only in this response and cannot be recovered afterwards.
+// @Tags         api-keys
+// @Accept       json
+// @Produce      json
+// @Param        key  body      CreateAPIKeyRequest  true  "Tenant, name, and RBAC scopes for the new key"
+// @Success      201  {object}  CreateAPIKeyResponse
+// @Failure      400  {object}  errors.ErrorResponse
+// @Failure      500  {object}  errors.ErrorResponse
+// @Router       /keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.HandleError(c, errors.ErrValidation.WithCause(err))
+		return
+	}
+
+	resp, err := h.AuthService.CreateAPIKey(c.Request.Context(), req)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+// RotateAPIKey godoc
+// @Summary      Rotate an API key
+// @Description  Issue a new plaintext key for an existing key ID, keeping its name, tenant, and scopes. The old plaintext key stops working immediately.
+// @Tags         api-keys
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "API key ID"
+// @Success      200  {object}  CreateAPIKeyResponse
+// @Failure      404  {object}  errors.ErrorResponse
+// @Failure      500  {object}  errors.ErrorResponse
+// @Router       /keys/{id}/rotate [post]
+func (h *APIKeyHandler) RotateAPIKey(c *gin.Context) {
+	id := c.Param("id")
+	resp, err := h.AuthService.RotateAPIKey(c.Request.Context(), TenantIDFromContext(c.Request.Context()), id)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// RevokeAPIKey godoc
+// @Summary      Revoke an API key
+// @Description  Permanently revoke an API key by ID. Revoked keys are rejected by AuthMiddleware immediately.
+// @Tags         api-keys
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "API key ID"
+// @Success      204  "No Content"
+// @Failure      404  {object}  errors.ErrorResponse
+// @Failure      500  {object}  errors.ErrorResponse
+// @Router       /keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	id := c.Param("id")
+	if err := h.AuthService.RevokeAPIKey(c.Request.Context(), TenantIDFromContext(c.Request.Context()), id); err != nil {
+		h.HandleError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,126 @@
+package management
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// APIKeyRepository persists API keys, always by their hash; the plaintext
+// key is never written to storage.
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *APIKey) error
+	List(ctx context.Context, tenantID string) ([]APIKey, error)
+	Get(ctx context.Context, tenantID, id string) (*APIKey, error)
+	GetByHash(ctx context.Context, hash string) (*APIKey, error)
+	Revoke(ctx context.Context, tenantID, id string) error
+	UpdateKey(ctx context.Context, key *APIKey) error
+}
+
+type mongoAPIKeyRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAPIKeyRepository(db *mongo.Database) APIKeyRepository {
+	return &mongoAPIKeyRepository{
+		collection: db.Collection("api_keys"),
+	}
+}
+
+func (r *mongoAPIKeyRepository) Create(ctx context.Context, key *APIKey) error {
+	if key.ID == "" {
+		key.ID = uuid.New().String()
+	}
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = time.Now()
+	}
+
+	_, err := r.collection.InsertOne(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	return nil
+}
+
+func (r *mongoAPIKeyRepository) List(ctx context.Context, tenantID string) ([]APIKey, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"tenant_id": tenantID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var keys []APIKey
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, fmt.Errorf("failed to decode API keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (r *mongoAPIKeyRepository) Get(ctx context.Context, tenantID, id string) (*APIKey, error) {
+	filter := bson.M{"_id": id, "tenant_id": tenantID}
+
+	var key APIKey
+	err := r.collection.FindOne(ctx, filter).Decode(&key)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("API key not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	return &key, nil
+}
+
+func (r *mongoAPIKeyRepository) GetByHash(ctx context.Context, hash string) (*APIKey, error) {
+	filter := bson.M{"key_hash": hash}
+
+	var key APIKey
+	err := r.collection.FindOne(ctx, filter).Decode(&key)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up API key: %w", err)
+	}
+
+	return &key, nil
+}
+
+func (r *mongoAPIKeyRepository) Revoke(ctx context.Context, tenantID, id string) error {
+	filter := bson.M{"_id": id, "tenant_id": tenantID}
+	update := bson.M{"$set": bson.M{"revoked_at": time.Now()}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("API key not found")
+	}
+
+	return nil
+}
+
+func (r *mongoAPIKeyRepository) UpdateKey(ctx context.Context, key *APIKey) error {
+	filter := bson.M{"_id": key.ID, "tenant_id": key.TenantID}
+	update := bson.M{"$set": key}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update API key: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("API key not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,327 @@
+package management
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	pkgerrors "yeti/pkg/errors"
+	"yeti/pkg/logging"
+)
+
+// DefaultTenantID is the tenant every rule, config, and audit log belongs to
+// when the caller didn't present an X-API-Key. Auth is opt-in
+// (ManagementConfig.Auth.Enabled): until a deployment provisions keys,
+// every caller is scoped to this tenant with full access, so existing
+// single-tenant deployments keep working unchanged.
+const DefaultTenantID = "default"
+
+// Scope is an RBAC permission an API key can be granted. The management API
+// checks for one of these before running a handler.
+type Scope string
+
+const (
+	ScopeRulesRead   Scope = "rules:read"
+	ScopeRulesWrite  Scope = "rules:write"
+	ScopeAuditRead   Scope = "audit:read"
+	ScopeConfigWrite Scope = "config:write"
+)
+
+func (s Scope) valid() bool {
+	switch s {
+	case ScopeRulesRead, ScopeRulesWrite, ScopeAuditRead, ScopeConfigWrite:
+		return true
+	default:
+		return false
+	}
+}
+
+type ctxKey string
+
+const (
+	tenantCtxKey        ctxKey = "management_tenant_id"
+	scopesCtxKey        ctxKey = "management_scopes"
+	includeGlobalCtxKey ctxKey = "management_include_global"
+)
+
+func withTenantID(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantCtxKey, tenantID)
+}
+
+// ContextWithTenant returns a copy of ctx scoped to tenantID, the same way
+// AuthMiddleware scopes a request's context from the caller's API key. It's
+// exported for callers that resolve the tenant some other way than an
+// X-API-Key header - a future gRPC interceptor, a background job acting on
+// behalf of a specific tenant, or a test exercising tenant isolation.
+func ContextWithTenant(ctx context.Context, tenantID string) context.Context {
+	return withTenantID(ctx, tenantID)
+}
+
+// TenantIDFromContext returns the tenant the current request is scoped to,
+// falling back to DefaultTenantID for internal callers and deployments that
+// never enabled auth.
+func TenantIDFromContext(ctx context.Context) string {
+	if tenantID, ok := ctx.Value(tenantCtxKey).(string); ok && tenantID != "" {
+		return tenantID
+	}
+	return DefaultTenantID
+}
+
+// ContextWithIncludeGlobal marks ctx as requesting that a tenant-scoped list
+// also inherit matching rules owned by DefaultTenantID, the way the
+// include_global query param does for ListRules/ListEnrichmentRules; see
+// ListFilteringRules/ListEnrichmentRules in service.go.
+func ContextWithIncludeGlobal(ctx context.Context) context.Context {
+	return context.WithValue(ctx, includeGlobalCtxKey, true)
+}
+
+func includeGlobalRequested(ctx context.Context) bool {
+	include, _ := ctx.Value(includeGlobalCtxKey).(bool)
+	return include
+}
+
+func withScopes(ctx context.Context, scopes []Scope) context.Context {
+	return context.WithValue(ctx, scopesCtxKey, scopes)
+}
+
+func scopesFromContext(ctx context.Context) []Scope {
+	if scopes, ok := ctx.Value(scopesCtxKey).([]Scope); ok {
+		return scopes
+	}
+	return nil
+}
+
+func hasScope(ctx context.Context, scope Scope) bool {
+	for _, s := range scopesFromContext(ctx) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKey is an issued credential, scoped to exactly one tenant and a set of
+// RBAC scopes. The plaintext key is never persisted; only KeyHash is.
+type APIKey struct {
+	ID        string     `json:"id" bson:"_id,omitempty"`
+	TenantID  string     `json:"tenant_id" bson:"tenant_id"`
+	Name      string     `json:"name" bson:"name"`
+	KeyHash   string     `json:"-" bson:"key_hash"`
+	KeyPrefix string     `json:"key_prefix" bson:"key_prefix"`
+	Scopes    []Scope    `json:"scopes" bson:"scopes"`
+	CreatedAt time.Time  `json:"created_at" bson:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty" bson:"revoked_at,omitempty"`
+}
+
+type CreateAPIKeyRequest struct {
+	TenantID string  `json:"tenant_id" binding:"required"`
+	Name     string  `json:"name" binding:"required"`
+	Scopes   []Scope `json:"scopes" binding:"required"`
+}
+
+// CreateAPIKeyResponse carries the plaintext Key alongside the stored
+// APIKey. It is only ever returned from creation and rotation; Key is never
+// recoverable afterwards.
+type CreateAPIKeyResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// AuthService issues, looks up, and revokes API keys. Its methods are kept
+// separate from the rule-management Service interface: auth is a
+// cross-cutting concern wired in as gin middleware, not a rule operation.
+type AuthService interface {
+	CreateAPIKey(ctx context.Context, req CreateAPIKeyRequest) (*CreateAPIKeyResponse, error)
+	ListAPIKeys(ctx context.Context, tenantID string) ([]APIKey, error)
+	RevokeAPIKey(ctx context.Context, tenantID, id string) error
+	RotateAPIKey(ctx context.Context, tenantID, id string) (*CreateAPIKeyResponse, error)
+	Authenticate(ctx context.Context, plaintextKey string) (*APIKey, error)
+}
+
+type authService struct {
+	repo APIKeyRepository
+}
+
+func NewAuthService(repo APIKeyRepository) AuthService {
+	return &authService{repo: repo}
+}
+
+func (s *authService) CreateAPIKey(ctx context.Context, req CreateAPIKeyRequest) (*CreateAPIKeyResponse, error) {
+	for _, scope := range req.Scopes {
+		if !scope.valid() {
+			return nil, pkgerrors.ErrValidation.WithDetail("message", fmt.Sprintf("unknown scope: %s", scope))
+		}
+	}
+
+	plaintext, err := generateAPIKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate API key: %w", err)
+	}
+
+	key := &APIKey{
+		TenantID:  req.TenantID,
+		Name:      req.Name,
+		KeyHash:   hashAPIKey(plaintext),
+		KeyPrefix: keyPrefix(plaintext),
+		Scopes:    req.Scopes,
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.repo.Create(ctx, key); err != nil {
+		return nil, err
+	}
+
+	return &CreateAPIKeyResponse{APIKey: *key, Key: plaintext}, nil
+}
+
+func (s *authService) ListAPIKeys(ctx context.Context, tenantID string) ([]APIKey, error) {
+	return s.repo.List(ctx, tenantID)
+}
+
+func (s *authService) RevokeAPIKey(ctx context.Context, tenantID, id string) error {
+	return s.repo.Revoke(ctx, tenantID, id)
+}
+
+func (s *authService) RotateAPIKey(ctx context.Context, tenantID, id string) (*CreateAPIKeyResponse, error) {
+	existing, err := s.repo.Get(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := generateAPIKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate API key: %w", err)
+	}
+
+	existing.KeyHash = hashAPIKey(plaintext)
+	existing.KeyPrefix = keyPrefix(plaintext)
+	existing.RevokedAt = nil
+
+	if err := s.repo.UpdateKey(ctx, existing); err != nil {
+		return nil, err
+	}
+
+	return &CreateAPIKeyResponse{APIKey: *existing, Key: plaintext}, nil
+}
+
+func (s *authService) Authenticate(ctx context.Context, plaintextKey string) (*APIKey, error) {
+	key, err := s.repo.GetByHash(ctx, hashAPIKey(plaintextKey))
+	if err != nil {
+		return nil, err
+	}
+	if key == nil || key.RevokedAt != nil {
+		return nil, pkgerrors.ErrUnauthorized.WithDetail("message", "invalid or revoked API key")
+	}
+	return key, nil
+}
+
+func generateAPIKey() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "ytk_" + hex.EncodeToString(buf), nil
+}
+
+func hashAPIKey(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
+
+func keyPrefix(plaintext string) string {
+	if len(plaintext) <= 12 {
+		return plaintext
+	}
+	return plaintext[:12]
+}
+
+// AuthMiddleware validates X-API-Key against authSvc and attaches the
+// resulting tenant ID and scopes to the request context. When enabled is
+// false, a missing header scopes the request to DefaultTenantID with every
+// scope, so deployments that haven't turned on Management.Auth.Enabled keep
+// working unauthenticated. When enabled is true, a missing header is
+// rejected outright - Enabled is the switch that turns on API-key
+// enforcement, so it must gate this fallback or every key an operator
+// provisions is optional. A present but invalid/revoked key is always
+// rejected outright rather than silently falling back, since a caller
+// presenting a key clearly expects it to be checked.
+func AuthMiddleware(authSvc AuthService, enabled bool) gin.HandlerFunc {
+	allScopes := []Scope{ScopeRulesRead, ScopeRulesWrite, ScopeAuditRead, ScopeConfigWrite}
+
+	return func(c *gin.Context) {
+		apiKey := c.GetHeader("X-API-Key")
+		if apiKey == "" {
+			if enabled {
+				writeProblem(c, pkgerrors.ErrUnauthorized.WithDetail("message", "X-API-Key header is required"))
+				return
+			}
+			ctx := withScopes(withTenantID(c.Request.Context(), DefaultTenantID), allScopes)
+			c.Request = c.Request.WithContext(ctx)
+			c.Next()
+			return
+		}
+
+		if authSvc == nil {
+			writeProblem(c, pkgerrors.ErrUnauthorized.WithDetail("message", "API key auth is not configured"))
+			return
+		}
+
+		key, err := authSvc.Authenticate(c.Request.Context(), apiKey)
+		if err != nil {
+			writeProblem(c, err)
+			return
+		}
+
+		ctx := withScopes(withTenantID(c.Request.Context(), key.TenantID), key.Scopes)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// RequireScope rejects the request with 403 unless the caller's API key (or
+// the implicit default-tenant identity) was granted scope.
+func RequireScope(scope Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !hasScope(c.Request.Context(), scope) {
+			writeProblem(c, pkgerrors.ErrForbidden.WithDetail("message", fmt.Sprintf("missing required scope: %s", scope)))
+			return
+		}
+		c.Next()
+	}
+}
+
+// BootstrapAPIKey seeds a known plaintext key scoped to config:write for
+// tenantID, so an operator bringing up a fresh deployment with
+// AuthConfig.BootstrapAPIKey has a way to mint further keys through
+// POST /api/v1/keys before any key exists in Mongo. It is idempotent: if a
+// key with this hash already exists, it does nothing.
+func BootstrapAPIKey(ctx context.Context, repo APIKeyRepository, tenantID, plaintextKey string) error {
+	hash := hashAPIKey(plaintextKey)
+	existing, err := repo.GetByHash(ctx, hash)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return nil
+	}
+
+	key := &APIKey{
+		TenantID:  tenantID,
+		Name:      "bootstrap",
+		KeyHash:   hash,
+		KeyPrefix: keyPrefix(plaintextKey),
+		Scopes:    []Scope{ScopeConfigWrite},
+		CreatedAt: time.Now(),
+	}
+	return repo.Create(ctx, key)
+}
+
+func writeProblem(c *gin.Context, err error) {
+	problem := pkgerrors.ToProblemDetails(err, logging.GetTraceID(c.Request.Context()))
+	c.AbortWithStatusJSON(problem.Status, problem)
+}
@@ -0,0 +1,224 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	pkgerrors "yeti/pkg/errors"
+)
+
+// RuleBatchOp is one operation within a batch request to
+// ApplyFilteringRuleBatch/ApplyEnrichmentRuleBatch. ID is required for
+// every op but "create"; Body carries the create/update payload (a
+// CreateFilteringRuleRequest/UpdateFilteringRuleRequest, or their
+// enrichment counterparts) and is ignored for "delete"/"enable"/
+// "disable", which only ever need ID. "enable"/"disable" are shorthand for
+// an update whose only change is Enabled, so a caller flipping a rule on
+// or off doesn't need to round-trip its full body first.
+type RuleBatchOp struct {
+	Op   string          `json:"op" binding:"required"`
+	ID   string          `json:"id,omitempty"`
+	Body json.RawMessage `json:"body,omitempty"`
+}
+
+// RuleBatchRequest is the request body for the filtering/enrichment batch
+// endpoints.
+type RuleBatchRequest struct {
+	Operations []RuleBatchOp `json:"operations" binding:"required"`
+}
+
+// RuleBatchOpResult reports one RuleBatchOp's outcome within a
+// RuleBatchResult, in request order.
+type RuleBatchOpResult struct {
+	Op    string `json:"op"`
+	ID    string `json:"id,omitempty"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// RuleBatchResult is the response body for the filtering/enrichment batch
+// endpoints.
+type RuleBatchResult struct {
+	Results []RuleBatchOpResult `json:"results"`
+	// Applied means different things for the two batch kinds, documented
+	// on ApplyFilteringRuleBatch/ApplyEnrichmentRuleBatch respectively -
+	// check it rather than assuming "some ops succeeded" implies "all the
+	// ones marked ok actually landed".
+	Applied bool `json:"applied"`
+}
+
+// ApplyFilteringRuleBatch runs every op in ops atomically through
+// VersioningRepository.ApplyFilteringChangeSet - the same one
+// ApplyRuleChangeSet uses - so either all of them land in one
+// rule_versions/rule_audit_logs transaction or none do. Every op is
+// validated up front; if any op fails to parse or validate, nothing is
+// applied and Results reports which op(s) were the problem with
+// Applied=false. A failure from the transaction itself (after every op
+// validated cleanly) is reported the same way: Applied=false and every
+// Result's Error set to that failure, since in that case there's no way to
+// tell from here which op the database rejected.
+func (s *service) ApplyFilteringRuleBatch(ctx context.Context, ops []RuleBatchOp) (*RuleBatchResult, error) {
+	if s.versioningRepo == nil {
+		return nil, pkgerrors.ErrInternal.WithDetail("message", "versioning not enabled")
+	}
+
+	result := &RuleBatchResult{Results: make([]RuleBatchOpResult, len(ops))}
+	filteringOps := make([]FilteringRuleOp, len(ops))
+	valid := true
+
+	for i, op := range ops {
+		result.Results[i] = RuleBatchOpResult{Op: op.Op, ID: op.ID}
+		fo, err := filteringRuleOpFromBatch(op)
+		if err != nil {
+			result.Results[i].Error = err.Error()
+			valid = false
+			continue
+		}
+		filteringOps[i] = fo
+	}
+	if !valid {
+		return result, nil
+	}
+
+	meta := AuditMeta{ChangedBy: getChangedBy(ctx)}
+	versions, err := s.versioningRepo.ApplyFilteringChangeSet(ctx, filteringOps, meta)
+	if err != nil {
+		for i := range result.Results {
+			result.Results[i].Error = err.Error()
+		}
+		return result, nil
+	}
+
+	result.Applied = true
+	for i := range result.Results {
+		result.Results[i].OK = true
+	}
+	for _, v := range versions {
+		s.warmFilteringRuleCEL(ruleExpressionFromVersion(v))
+	}
+	return result, nil
+}
+
+// ApplyEnrichmentRuleBatch runs every op in ops sequentially through the
+// existing Create/Update/DeleteEnrichmentRule path, continuing past a
+// failed op rather than stopping - there's no single transaction spanning
+// every possible EnrichmentRepository backing store for this batch to join
+// (see ApplyRuleChangeSet's doc comment for why). Applied is true only if
+// every op in Results came back ok; a caller that needs to know exactly
+// which ones didn't should inspect Results rather than just Applied.
+func (s *service) ApplyEnrichmentRuleBatch(ctx context.Context, ops []RuleBatchOp) (*RuleBatchResult, error) {
+	result := &RuleBatchResult{Results: make([]RuleBatchOpResult, len(ops))}
+	allOK := true
+
+	for i, op := range ops {
+		result.Results[i] = RuleBatchOpResult{Op: op.Op, ID: op.ID}
+
+		eo, err := enrichmentRuleOpFromBatch(op)
+		if err != nil {
+			result.Results[i].Error = err.Error()
+			allOK = false
+			continue
+		}
+		if err := s.applyEnrichmentRuleOp(ctx, eo); err != nil {
+			result.Results[i].Error = err.Error()
+			allOK = false
+			continue
+		}
+		result.Results[i].OK = true
+	}
+
+	result.Applied = allOK
+	return result, nil
+}
+
+// filteringRuleOpFromBatch translates one RuleBatchOp into the
+// FilteringRuleOp ApplyFilteringChangeSet expects, validating its body the
+// same way CreateFilteringRule/UpdateFilteringRule would.
+func filteringRuleOpFromBatch(op RuleBatchOp) (FilteringRuleOp, error) {
+	switch op.Op {
+	case "create":
+		var req CreateFilteringRuleRequest
+		if err := json.Unmarshal(op.Body, &req); err != nil {
+			return FilteringRuleOp{}, fmt.Errorf("invalid create body: %w", err)
+		}
+		if _, err := ValidateFilteringRule(req); err != nil {
+			return FilteringRuleOp{}, err
+		}
+		return FilteringRuleOp{Create: &req}, nil
+
+	case "update":
+		if op.ID == "" {
+			return FilteringRuleOp{}, fmt.Errorf("update op requires id")
+		}
+		var req UpdateFilteringRuleRequest
+		if err := json.Unmarshal(op.Body, &req); err != nil {
+			return FilteringRuleOp{}, fmt.Errorf("invalid update body: %w", err)
+		}
+		if _, err := ValidateUpdateFilteringRule(req); err != nil {
+			return FilteringRuleOp{}, err
+		}
+		return FilteringRuleOp{UpdateID: op.ID, Update: &req}, nil
+
+	case "delete":
+		if op.ID == "" {
+			return FilteringRuleOp{}, fmt.Errorf("delete op requires id")
+		}
+		return FilteringRuleOp{DeleteID: op.ID}, nil
+
+	case "enable", "disable":
+		if op.ID == "" {
+			return FilteringRuleOp{}, fmt.Errorf("%s op requires id", op.Op)
+		}
+		enabled := op.Op == "enable"
+		return FilteringRuleOp{UpdateID: op.ID, Update: &UpdateFilteringRuleRequest{Enabled: &enabled}}, nil
+
+	default:
+		return FilteringRuleOp{}, fmt.Errorf("unknown op %q", op.Op)
+	}
+}
+
+// enrichmentRuleOpFromBatch is filteringRuleOpFromBatch's enrichment
+// counterpart.
+func enrichmentRuleOpFromBatch(op RuleBatchOp) (EnrichmentRuleOp, error) {
+	switch op.Op {
+	case "create":
+		var req CreateEnrichmentRuleRequest
+		if err := json.Unmarshal(op.Body, &req); err != nil {
+			return EnrichmentRuleOp{}, fmt.Errorf("invalid create body: %w", err)
+		}
+		if err := ValidateEnrichmentRule(req); err != nil {
+			return EnrichmentRuleOp{}, err
+		}
+		return EnrichmentRuleOp{Create: &req}, nil
+
+	case "update":
+		if op.ID == "" {
+			return EnrichmentRuleOp{}, fmt.Errorf("update op requires id")
+		}
+		var req UpdateEnrichmentRuleRequest
+		if err := json.Unmarshal(op.Body, &req); err != nil {
+			return EnrichmentRuleOp{}, fmt.Errorf("invalid update body: %w", err)
+		}
+		if err := ValidateUpdateEnrichmentRule(req); err != nil {
+			return EnrichmentRuleOp{}, err
+		}
+		return EnrichmentRuleOp{UpdateID: op.ID, Update: &req}, nil
+
+	case "delete":
+		if op.ID == "" {
+			return EnrichmentRuleOp{}, fmt.Errorf("delete op requires id")
+		}
+		return EnrichmentRuleOp{DeleteID: op.ID}, nil
+
+	case "enable", "disable":
+		if op.ID == "" {
+			return EnrichmentRuleOp{}, fmt.Errorf("%s op requires id", op.Op)
+		}
+		enabled := op.Op == "enable"
+		return EnrichmentRuleOp{UpdateID: op.ID, Update: &UpdateEnrichmentRuleRequest{Enabled: &enabled}}, nil
+
+	default:
+		return EnrichmentRuleOp{}, fmt.Errorf("unknown op %q", op.Op)
+	}
+}
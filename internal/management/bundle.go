@@ -0,0 +1,404 @@
+package management
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	pkgerrors "yeti/pkg/errors"
+)
+
+// RuleBundle is a portable snapshot of every filtering rule, enrichment
+// rule, and the deduplication config, used to promote configuration between
+// environments (e.g. staging to production) in a GitOps-style workflow.
+// FilteringRules/EnrichmentRules are sorted by name before export so two
+// exports of an unchanged configuration list the same rules in the same
+// order, keeping it diff-friendly in Git; GeneratedAt, and with it
+// ContentHash, still differs from one export to the next.
+//
+// ContentHash is a SHA-256 hash of the bundle with ContentHash itself
+// cleared. There is no signing-key infrastructure elsewhere in this
+// service, so the hash serves as the bundle's integrity check rather than a
+// cryptographic signature: ImportRuleBundle recomputes it and rejects the
+// import if it doesn't match, so a bundle edited in transit is caught
+// before anything is written.
+type RuleBundle struct {
+	FilteringRules      []FilteringRule      `json:"filtering_rules"`
+	EnrichmentRules     []EnrichmentRule     `json:"enrichment_rules"`
+	DeduplicationConfig *DeduplicationConfig `json:"deduplication_config,omitempty"`
+	GeneratedAt         time.Time            `json:"generated_at"`
+	ContentHash         string               `json:"content_hash"`
+}
+
+func (b RuleBundle) contentHash() (string, error) {
+	b.ContentHash = ""
+	data, err := json.Marshal(b)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal bundle: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// RecomputeContentHash sets b.ContentHash to match b's current content.
+// ExportRuleBundle already does this before returning a bundle; a caller
+// that hand-edits an exported bundle (e.g. the GitOps workflow this type
+// exists for) must call this again before ImportRuleBundle will accept the
+// edit, since the hash exists to catch exactly that kind of unintended
+// drift in anything ImportRuleBundle wasn't told to expect.
+func (b *RuleBundle) RecomputeContentHash() error {
+	hash, err := b.contentHash()
+	if err != nil {
+		return err
+	}
+	b.ContentHash = hash
+	return nil
+}
+
+// ExportRuleBundle packages every filtering rule, every enrichment rule (if
+// enrichment storage is configured), and the deduplication config into a
+// single hashed bundle suitable for promotion to another environment.
+func (s *service) ExportRuleBundle(ctx context.Context) (*RuleBundle, error) {
+	filteringRules, err := s.ListFilteringRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(filteringRules, func(i, j int) bool { return filteringRules[i].Name < filteringRules[j].Name })
+
+	var enrichmentRules []EnrichmentRule
+	if s.enrichmentRepo != nil {
+		enrichmentRules, err = s.ListEnrichmentRules(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(enrichmentRules, func(i, j int) bool { return enrichmentRules[i].Name < enrichmentRules[j].Name })
+
+	dedupConfig, err := s.GetDeduplicationConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &RuleBundle{
+		FilteringRules:      filteringRules,
+		EnrichmentRules:     enrichmentRules,
+		DeduplicationConfig: dedupConfig,
+		GeneratedAt:         time.Now(),
+	}
+
+	if err := bundle.RecomputeContentHash(); err != nil {
+		return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+
+	return bundle, nil
+}
+
+// ImportMode selects how ImportRuleBundle reconciles a bundle's rules
+// against what's currently stored, by rule name.
+type ImportMode string
+
+const (
+	// ImportModeCreateOnly creates rules absent by name and leaves any
+	// name already present untouched - the safest mode for seeding a
+	// fresh environment without risking a clobber.
+	ImportModeCreateOnly ImportMode = "create-only"
+	// ImportModeUpsert creates rules absent by name and updates rules
+	// already present, but never deletes anything not in the bundle.
+	ImportModeUpsert ImportMode = "upsert"
+	// ImportModeReplaceAll is ImportModeUpsert plus deleting every
+	// currently-stored rule whose name isn't in the bundle, making the
+	// stored configuration match the bundle exactly.
+	ImportModeReplaceAll ImportMode = "replace-all"
+)
+
+// ImportOptions configures ImportRuleBundle.
+type ImportOptions struct {
+	// Mode defaults to ImportModeCreateOnly if empty.
+	Mode ImportMode `json:"mode"`
+	// DryRun, if true, validates the bundle and computes RulePlans exactly
+	// as a real import would, but returns before any write - ImportResult
+	// reports what would have happened.
+	DryRun bool `json:"dry_run"`
+}
+
+// RulePlan is the set of rule names ImportRuleBundle created, updated, or
+// deleted (or, for a dry run, would have).
+type RulePlan struct {
+	Create []string `json:"create,omitempty"`
+	Update []string `json:"update,omitempty"`
+	Delete []string `json:"delete,omitempty"`
+}
+
+// ImportResult is ImportRuleBundle's outcome: the per-kind RulePlan that was
+// executed (or, when opts.DryRun is set, merely computed), tagged with
+// ImportID. A real import also records ImportID in its summary AuditLog
+// entry (see ImportRuleBundle); a dry run writes no audit entry.
+type ImportResult struct {
+	ImportID                   string      `json:"import_id"`
+	DryRun                     bool        `json:"dry_run"`
+	FilteringRules             RulePlan    `json:"filtering_rules"`
+	EnrichmentRules            RulePlan    `json:"enrichment_rules"`
+	DeduplicationConfigChanged bool        `json:"deduplication_config_changed"`
+	Bundle                     *RuleBundle `json:"bundle,omitempty"`
+}
+
+// ImportRuleBundle applies a previously exported bundle: its content hash is
+// verified, then every filtering/enrichment rule's CEL (Expression,
+// Condition, transformation expressions) is validated before anything is
+// written, so a bundle containing one invalid expression fails - and
+// mutates nothing - before the first write happens. This is what makes
+// ImportModeReplaceAll "transactional" in practice: since this package has
+// no cross-collection (Postgres + Mongo) transaction to wrap the import in,
+// the only reliable rollback is never starting the write phase at all.
+//
+// Reconciliation is by rule name, per opts.Mode (ImportModeCreateOnly if
+// opts.Mode is empty) - see ImportMode's doc comment. opts.DryRun computes
+// the same RulePlans and returns them without calling a single Create/
+// Update/Delete. When versioning is enabled, ImportID is recorded in one
+// summary AuditLog entry (RuleType "bundle", Action "import") written after
+// the rules are applied, together with the names of the rules created,
+// updated and deleted. The per-rule audit entries written by the individual
+// Create/Update/Delete calls do not carry ImportID.
+func (s *service) ImportRuleBundle(ctx context.Context, bundle RuleBundle, changedBy string, opts ImportOptions) (*ImportResult, error) {
+	expectedHash, err := bundle.contentHash()
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+	if bundle.ContentHash == "" || bundle.ContentHash != expectedHash {
+		return nil, pkgerrors.ErrValidation.WithDetail("message", "bundle content hash mismatch")
+	}
+
+	mode := opts.Mode
+	if mode == "" {
+		mode = ImportModeCreateOnly
+	}
+
+	if err := validateBundleExpressions(bundle); err != nil {
+		return nil, wrapValidationError(err)
+	}
+
+	currentFiltering, err := s.ListFilteringRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var currentEnrichment []EnrichmentRule
+	if s.enrichmentRepo != nil {
+		currentEnrichment, err = s.ListEnrichmentRules(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	filteringByName := make(map[string]FilteringRule, len(currentFiltering))
+	for _, rule := range currentFiltering {
+		filteringByName[rule.Name] = rule
+	}
+	enrichmentByName := make(map[string]EnrichmentRule, len(currentEnrichment))
+	for _, rule := range currentEnrichment {
+		enrichmentByName[rule.Name] = rule
+	}
+
+	importID := uuid.New().String()
+	result := &ImportResult{ImportID: importID, DryRun: opts.DryRun}
+
+	bundleFilteringNames := make(map[string]bool, len(bundle.FilteringRules))
+	for _, rule := range bundle.FilteringRules {
+		bundleFilteringNames[rule.Name] = true
+		if _, exists := filteringByName[rule.Name]; exists {
+			if mode != ImportModeCreateOnly {
+				result.FilteringRules.Update = append(result.FilteringRules.Update, rule.Name)
+			}
+		} else {
+			result.FilteringRules.Create = append(result.FilteringRules.Create, rule.Name)
+		}
+	}
+	bundleEnrichmentNames := make(map[string]bool, len(bundle.EnrichmentRules))
+	for _, rule := range bundle.EnrichmentRules {
+		bundleEnrichmentNames[rule.Name] = true
+		if _, exists := enrichmentByName[rule.Name]; exists {
+			if mode != ImportModeCreateOnly {
+				result.EnrichmentRules.Update = append(result.EnrichmentRules.Update, rule.Name)
+			}
+		} else {
+			result.EnrichmentRules.Create = append(result.EnrichmentRules.Create, rule.Name)
+		}
+	}
+	if mode == ImportModeReplaceAll {
+		for name := range filteringByName {
+			if !bundleFilteringNames[name] {
+				result.FilteringRules.Delete = append(result.FilteringRules.Delete, name)
+			}
+		}
+		for name := range enrichmentByName {
+			if !bundleEnrichmentNames[name] {
+				result.EnrichmentRules.Delete = append(result.EnrichmentRules.Delete, name)
+			}
+		}
+	}
+	result.DeduplicationConfigChanged = bundle.DeduplicationConfig != nil
+
+	if opts.DryRun {
+		return result, nil
+	}
+
+	importCtx := context.WithValue(ctx, "user_id", changedBy)
+
+	for _, rule := range bundle.FilteringRules {
+		enabled := rule.Enabled
+		if existing, exists := filteringByName[rule.Name]; exists {
+			if mode == ImportModeCreateOnly {
+				continue
+			}
+			req := UpdateFilteringRuleRequest{
+				Expression: &rule.Expression,
+				Priority:   &rule.Priority,
+				Enabled:    &enabled,
+			}
+			if _, err := s.UpdateFilteringRule(importCtx, existing.ID, req); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		req := CreateFilteringRuleRequest{
+			Name:       rule.Name,
+			Expression: rule.Expression,
+			Priority:   rule.Priority,
+			Enabled:    &enabled,
+		}
+		if _, err := s.CreateFilteringRule(importCtx, req); err != nil {
+			return nil, err
+		}
+	}
+	if mode == ImportModeReplaceAll {
+		for _, name := range result.FilteringRules.Delete {
+			if err := s.DeleteFilteringRule(importCtx, filteringByName[name].ID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if s.enrichmentRepo != nil {
+		for _, rule := range bundle.EnrichmentRules {
+			enabled := rule.Enabled
+			if existing, exists := enrichmentByName[rule.Name]; exists {
+				if mode == ImportModeCreateOnly {
+					continue
+				}
+				req := UpdateEnrichmentRuleRequest{
+					FieldToEnrich:   &rule.FieldToEnrich,
+					SourceType:      &rule.SourceType,
+					SourceConfig:    &rule.SourceConfig,
+					Condition:       &rule.Condition,
+					Transformations: &rule.Transformations,
+					CacheTTLSeconds: &rule.CacheTTLSeconds,
+					ErrorHandling:   &rule.ErrorHandling,
+					Priority:        &rule.Priority,
+					Enabled:         &enabled,
+				}
+				if _, err := s.UpdateEnrichmentRule(importCtx, existing.ID, req); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			req := CreateEnrichmentRuleRequest{
+				Name:            rule.Name,
+				FieldToEnrich:   rule.FieldToEnrich,
+				SourceType:      rule.SourceType,
+				SourceConfig:    rule.SourceConfig,
+				Condition:       rule.Condition,
+				Transformations: rule.Transformations,
+				CacheTTLSeconds: rule.CacheTTLSeconds,
+				ErrorHandling:   rule.ErrorHandling,
+				FallbackValue:   rule.FallbackValue,
+				Priority:        rule.Priority,
+				Enabled:         &enabled,
+			}
+			if _, err := s.CreateEnrichmentRule(importCtx, req); err != nil {
+				return nil, err
+			}
+		}
+		if mode == ImportModeReplaceAll {
+			for _, name := range result.EnrichmentRules.Delete {
+				if err := s.DeleteEnrichmentRule(importCtx, enrichmentByName[name].ID); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if bundle.DeduplicationConfig != nil {
+		dedupReq := UpdateDeduplicationConfigRequest{
+			HashAlgorithm: &bundle.DeduplicationConfig.HashAlgorithm,
+			TTLSeconds:    &bundle.DeduplicationConfig.TTLSeconds,
+			OnRedisError:  &bundle.DeduplicationConfig.OnRedisError,
+			FieldsToHash:  &bundle.DeduplicationConfig.FieldsToHash,
+			Salt:          &bundle.DeduplicationConfig.Salt,
+			HMACKeyRef:    &bundle.DeduplicationConfig.HMACKeyRef,
+		}
+		if _, err := s.UpdateDeduplicationConfig(importCtx, dedupReq); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.versioningRepo != nil {
+		_ = s.versioningRepo.CreateAuditLog(importCtx, &AuditLog{
+			RuleType: "bundle",
+			Action:   "import",
+			NewValue: map[string]interface{}{
+				"import_id":        importID,
+				"mode":             mode,
+				"content_hash":     bundle.ContentHash,
+				"filtering_rules":  result.FilteringRules,
+				"enrichment_rules": result.EnrichmentRules,
+			},
+			ChangedBy:    changedBy,
+			ChangeReason: fmt.Sprintf("bundle import %s, mode=%s, content_hash=%s", importID, mode, bundle.ContentHash),
+		})
+	}
+
+	exported, err := s.ExportRuleBundle(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result.Bundle = exported
+	return result, nil
+}
+
+// validateBundleExpressions compiles every CEL expression a bundle would
+// write - each filtering rule's Expression, and each enrichment rule's
+// Condition and transformation expressions - before ImportRuleBundle writes
+// anything, so one invalid expression fails the whole import instead of
+// leaving a partially-applied bundle behind.
+func validateBundleExpressions(bundle RuleBundle) error {
+	evaluator, err := newValidationEvaluator()
+	if err != nil {
+		return fmt.Errorf("failed to create CEL evaluator: %w", err)
+	}
+	for _, rule := range bundle.FilteringRules {
+		if err := evaluator.ValidateFilterExpression(rule.Expression); err != nil {
+			return &ValidationError{Field: fmt.Sprintf("filtering_rules[%s].expression", rule.Name), Message: err.Error()}
+		}
+	}
+
+	ruleValidator, err := NewRuleValidator()
+	if err != nil {
+		return fmt.Errorf("failed to create rule validator: %w", err)
+	}
+	for _, rule := range bundle.EnrichmentRules {
+		if rule.Condition != "" {
+			if err := evaluator.ValidateFilterExpression(rule.Condition); err != nil {
+				return &ValidationError{Field: fmt.Sprintf("enrichment_rules[%s].condition", rule.Name), Message: err.Error()}
+			}
+		}
+		if _, err := ruleValidator.CompileTransformations(rule.Transformations); err != nil {
+			return fmt.Errorf("enrichment_rules[%s]: %w", rule.Name, err)
+		}
+	}
+	return nil
+}
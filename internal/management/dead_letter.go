@@ -0,0 +1,211 @@
+package management
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"yeti/pkg/models"
+)
+
+// DeadLetterEntry is a message that exhausted its retry policy and landed on
+// a DLQ topic/subject/stream, indexed here so an operator can list and
+// replay it through the management API instead of reading the DLQ topic
+// directly with a Kafka console consumer. Envelope is the full
+// models.MessageEnvelope as published to the DLQ, including the
+// ErrorRecord(s) internal/broker appended to its Metadata.Errors.
+type DeadLetterEntry struct {
+	ID            string                 `json:"id"`
+	TenantID      string                 `json:"tenant_id"`
+	ServiceName   string                 `json:"service_name"`
+	SourceTopic   string                 `json:"source_topic"`
+	DLQTopic      string                 `json:"dlq_topic"`
+	RuleID        string                 `json:"rule_id,omitempty"`
+	ErrorClass    string                 `json:"error_class"`
+	ErrorMessage  string                 `json:"error_message"`
+	Attempts      int                    `json:"attempts"`
+	Envelope      models.MessageEnvelope `json:"envelope"`
+	FirstFailedAt time.Time              `json:"first_failed_at"`
+	LastFailedAt  time.Time              `json:"last_failed_at"`
+	ReplayedAt    *time.Time             `json:"replayed_at,omitempty"`
+	ReplayedTopic *string                `json:"replayed_topic,omitempty"`
+}
+
+// DeadLetterFilter narrows ListDeadLettered. An empty/unset field matches
+// everything for that dimension. Unreplayed, when true, excludes entries
+// that have already been replayed.
+type DeadLetterFilter struct {
+	ServiceName string
+	Unreplayed  bool
+	Limit       int
+}
+
+type DeadLetterRepository interface {
+	// Record inserts entry, assigning an ID if it doesn't already have one.
+	Record(ctx context.Context, entry *DeadLetterEntry) error
+	List(ctx context.Context, filter DeadLetterFilter) ([]DeadLetterEntry, error)
+	Get(ctx context.Context, id string) (*DeadLetterEntry, error)
+	// MarkReplayed records that id was successfully republished to
+	// targetTopic at the given time, so it's excluded from a subsequent
+	// DeadLetterFilter{Unreplayed: true} listing.
+	MarkReplayed(ctx context.Context, id, targetTopic string, at time.Time) error
+}
+
+type postgresDeadLetterRepository struct {
+	db *sql.DB
+}
+
+func NewDeadLetterRepository(db *sql.DB) DeadLetterRepository {
+	return &postgresDeadLetterRepository{db: db}
+}
+
+func (r *postgresDeadLetterRepository) Record(ctx context.Context, entry *DeadLetterEntry) error {
+	if entry.ID == "" {
+		entry.ID = uuid.New().String()
+	}
+
+	envelopeJSON, err := json.Marshal(entry.Envelope)
+	if err != nil {
+		return fmt.Errorf("failed to marshal dead letter envelope: %w", err)
+	}
+
+	var ruleID *string
+	if entry.RuleID != "" {
+		ruleID = &entry.RuleID
+	}
+
+	query := `
+		INSERT INTO dead_letters (id, tenant_id, service_name, source_topic, dlq_topic, rule_id, error_class, error_message, attempts, envelope, first_failed_at, last_failed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`
+	_, err = r.db.ExecContext(ctx, query,
+		entry.ID, entry.TenantID, entry.ServiceName, entry.SourceTopic, entry.DLQTopic,
+		ruleID, entry.ErrorClass, entry.ErrorMessage, entry.Attempts, envelopeJSON,
+		entry.FirstFailedAt, entry.LastFailedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record dead letter: %w", err)
+	}
+
+	return nil
+}
+
+func (r *postgresDeadLetterRepository) List(ctx context.Context, filter DeadLetterFilter) ([]DeadLetterEntry, error) {
+	tenantID := TenantIDFromContext(ctx)
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+
+	query := `
+		SELECT id, tenant_id, service_name, source_topic, dlq_topic, rule_id, error_class, error_message, attempts, envelope, first_failed_at, last_failed_at, replayed_at, replayed_topic
+		FROM dead_letters
+		WHERE tenant_id = $1
+	`
+	args := []interface{}{tenantID}
+
+	if filter.ServiceName != "" {
+		args = append(args, filter.ServiceName)
+		query += fmt.Sprintf(" AND service_name = $%d", len(args))
+	}
+	if filter.Unreplayed {
+		query += " AND replayed_at IS NULL"
+	}
+
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY last_failed_at DESC LIMIT $%d", len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []DeadLetterEntry
+	for rows.Next() {
+		entry, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *entry)
+	}
+
+	return entries, nil
+}
+
+func (r *postgresDeadLetterRepository) Get(ctx context.Context, id string) (*DeadLetterEntry, error) {
+	tenantID := TenantIDFromContext(ctx)
+
+	query := `
+		SELECT id, tenant_id, service_name, source_topic, dlq_topic, rule_id, error_class, error_message, attempts, envelope, first_failed_at, last_failed_at, replayed_at, replayed_topic
+		FROM dead_letters
+		WHERE id = $1 AND tenant_id = $2
+	`
+	row := r.db.QueryRowContext(ctx, query, id, tenantID)
+	entry, err := scanDeadLetter(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dead letter: %w", err)
+	}
+	return entry, nil
+}
+
+// dlqScanner is the subset of *sql.Row/*sql.Rows scanDeadLetter needs.
+type dlqScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanDeadLetter(row dlqScanner) (*DeadLetterEntry, error) {
+	var entry DeadLetterEntry
+	var ruleID, replayedTopic *string
+	var replayedAt *time.Time
+	var envelopeJSON []byte
+
+	if err := row.Scan(
+		&entry.ID, &entry.TenantID, &entry.ServiceName, &entry.SourceTopic, &entry.DLQTopic,
+		&ruleID, &entry.ErrorClass, &entry.ErrorMessage, &entry.Attempts, &envelopeJSON,
+		&entry.FirstFailedAt, &entry.LastFailedAt, &replayedAt, &replayedTopic,
+	); err != nil {
+		return nil, err
+	}
+
+	if ruleID != nil {
+		entry.RuleID = *ruleID
+	}
+	entry.ReplayedAt = replayedAt
+	entry.ReplayedTopic = replayedTopic
+
+	if err := json.Unmarshal(envelopeJSON, &entry.Envelope); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal dead letter envelope: %w", err)
+	}
+
+	return &entry, nil
+}
+
+func (r *postgresDeadLetterRepository) MarkReplayed(ctx context.Context, id, targetTopic string, at time.Time) error {
+	tenantID := TenantIDFromContext(ctx)
+
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE dead_letters SET replayed_at = $1, replayed_topic = $2 WHERE id = $3 AND tenant_id = $4`,
+		at, targetTopic, id, tenantID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark dead letter replayed: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check dead letter update result: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("dead letter %s not found", id)
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package management
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"yeti/internal/broker"
+	"yeti/internal/logger"
+	"yeti/pkg/models"
+)
+
+// DeadLetterIndexer drains a DLQ topic/subject/stream into a
+// DeadLetterRepository so ListDeadLettered/Replay have something to read
+// and act on, rather than requiring an operator to read the DLQ topic
+// directly with a broker-specific console consumer. It never acks/commits
+// on its own failure to record an entry - Consume's at-least-once retry
+// will hand the same message back rather than silently losing it from the
+// index.
+type DeadLetterIndexer struct {
+	repo        DeadLetterRepository
+	consumer    broker.Consumer
+	topic       string
+	serviceName string
+	logger      logger.Logger
+}
+
+func NewDeadLetterIndexer(repo DeadLetterRepository, consumer broker.Consumer, topic, serviceName string, log logger.Logger) *DeadLetterIndexer {
+	consumer.SetServiceName(serviceName + "-dlq-indexer")
+	return &DeadLetterIndexer{
+		repo:        repo,
+		consumer:    consumer,
+		topic:       topic,
+		serviceName: serviceName,
+		logger:      log,
+	}
+}
+
+// Run blocks consuming topic until ctx is canceled, recording one
+// DeadLetterEntry per message. Intended to be started in its own goroutine
+// alongside the rest of a service's long-running loops.
+func (idx *DeadLetterIndexer) Run(ctx context.Context) error {
+	return idx.consumer.Consume(ctx, idx.topic, func(ctx context.Context, envelope models.MessageEnvelope) error {
+		entry := deadLetterEntryFromEnvelope(envelope, idx.topic)
+		if err := idx.repo.Record(ctx, entry); err != nil {
+			idx.logger.ErrorwCtx(ctx, "Failed to record dead letter",
+				"error", err,
+				"topic", idx.topic,
+				"message_id", envelope.ID,
+			)
+			return fmt.Errorf("failed to record dead letter for message %s: %w", envelope.ID, err)
+		}
+		return nil
+	})
+}
+
+// deadLetterEntryFromEnvelope builds a DeadLetterEntry from the last
+// ErrorRecord attached to envelope (see publishKafkaDLQ and its NATS/Redis
+// Streams equivalents), falling back to the older ad-hoc
+// Metadata.Enrichment["dlq_reason"/"dlq_source_topic"] keys for a message
+// published before Metadata.Errors existed.
+func deadLetterEntryFromEnvelope(envelope models.MessageEnvelope, dlqTopic string) *DeadLetterEntry {
+	entry := &DeadLetterEntry{
+		DLQTopic:      dlqTopic,
+		Envelope:      envelope,
+		FirstFailedAt: time.Now(),
+		LastFailedAt:  time.Now(),
+	}
+
+	if n := len(envelope.Metadata.Errors); n > 0 {
+		last := envelope.Metadata.Errors[n-1]
+		entry.ServiceName = last.Service
+		entry.RuleID = last.RuleID
+		entry.ErrorClass = last.ErrorClass
+		entry.ErrorMessage = last.Message
+		entry.Attempts = last.Attempts
+		entry.FirstFailedAt = envelope.Metadata.Errors[0].FirstSeenAt
+		entry.LastFailedAt = last.LastSeenAt
+		entry.SourceTopic, _ = envelope.Metadata.Enrichment["dlq_source_topic"].(string)
+		return entry
+	}
+
+	entry.ErrorClass = "UNKNOWN_ERROR"
+	if reason, ok := envelope.Metadata.Enrichment["dlq_reason"].(string); ok {
+		entry.ErrorMessage = reason
+	}
+	if source, ok := envelope.Metadata.Enrichment["dlq_source_topic"].(string); ok {
+		entry.SourceTopic = source
+	} else if source, ok := envelope.Metadata.Enrichment["dlq_source_stream"].(string); ok {
+		entry.SourceTopic = source
+	}
+
+	return entry
+}
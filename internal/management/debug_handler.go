@@ -0,0 +1,60 @@
+package management
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"yeti/internal/logger"
+	"yeti/pkg/errors"
+)
+
+// DebugHandler exposes operational controls that don't belong to the rule
+// management domain, such as flipping the log level without a restart.
+type DebugHandler struct {
+	BaseHandler
+}
+
+func NewDebugHandler(log logger.Logger) *DebugHandler {
+	return &DebugHandler{BaseHandler: BaseHandler{Logger: log}}
+}
+
+// RegisterDebugRoutes wires /debug/log-level behind config:write, the same
+// scope every other administrative (non-rule) action in this service
+// requires.
+func (h *DebugHandler) RegisterDebugRoutes(router *gin.Engine) {
+	debug := router.Group("/debug", RequireScope(ScopeConfigWrite))
+	{
+		debug.PUT("/log-level", h.UpdateLogLevel)
+	}
+}
+
+type updateLogLevelRequest struct {
+	Level string `json:"level" binding:"required"`
+}
+
+// UpdateLogLevel godoc
+// @Summary      Hot-reload the log level
+// @Description  Change the running process's minimum log level (debug, info, warn, error) without a restart.
+// @Tags         debug
+// @Accept       json
+// @Produce      json
+// @Param        level  body      updateLogLevelRequest  true  "New log level"
+// @Success      200    {object}  updateLogLevelRequest
+// @Failure      400    {object}  errors.ErrorResponse
+// @Router       /debug/log-level [put]
+func (h *DebugHandler) UpdateLogLevel(c *gin.Context) {
+	var req updateLogLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.HandleError(c, errors.ErrValidation.WithCause(err))
+		return
+	}
+
+	if err := h.Logger.SetLevel(req.Level); err != nil {
+		h.HandleError(c, errors.ErrValidation.WithDetail("message", err.Error()))
+		return
+	}
+
+	h.Logger.InfowCtx(c.Request.Context(), "log level updated via debug endpoint", "level", req.Level)
+	c.JSON(http.StatusOK, req)
+}
@@ -2,6 +2,7 @@ package management
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"time"
 
@@ -9,44 +10,228 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+
+	pkgerrors "yeti/pkg/errors"
 )
 
+// WriteResult is embedded in every write-method result struct below.
+// Acknowledged reports whether the server actually confirmed the write -
+// false means the call was issued under an unacknowledged WriteConcern (see
+// WithWriteConcern) and the driver returned before the server durably
+// applied it, so the caller knows not to treat the operation as verified.
+// Non-Mongo backends (postgres, sqlite) always report true: a committed SQL
+// transaction has no unacknowledged-write concept to lie about.
+type WriteResult struct {
+	Acknowledged bool
+}
+
+// InsertResult is CreateEnrichmentRule's write outcome.
+type InsertResult struct {
+	WriteResult
+	InsertedID string
+}
+
+// UpdateResult is UpdateEnrichmentRule/UpdateEnrichmentRuleCAS's write
+// outcome. MatchedCount and ModifiedCount are 0 under an unacknowledged
+// WriteConcern - the server never reports them back - so callers that rely
+// on Acknowledged being true to trust those counts at all.
+type UpdateResult struct {
+	WriteResult
+	MatchedCount  int64
+	ModifiedCount int64
+}
+
+// DeleteResult is DeleteEnrichmentRule's write outcome. DeletedCount is 0
+// under an unacknowledged WriteConcern for the same reason as UpdateResult's
+// counts.
+type DeleteResult struct {
+	WriteResult
+	DeletedCount int64
+}
+
 type EnrichmentRepository interface {
-	CreateEnrichmentRule(ctx context.Context, rule *EnrichmentRule) error
+	CreateEnrichmentRule(ctx context.Context, rule *EnrichmentRule) (InsertResult, error)
 	ListEnrichmentRules(ctx context.Context) ([]EnrichmentRule, error)
 	GetEnrichmentRule(ctx context.Context, id string) (*EnrichmentRule, error)
-	UpdateEnrichmentRule(ctx context.Context, rule *EnrichmentRule) error
-	DeleteEnrichmentRule(ctx context.Context, id string) error
+	UpdateEnrichmentRule(ctx context.Context, rule *EnrichmentRule) (UpdateResult, error)
+	UpdateEnrichmentRuleCAS(ctx context.Context, rule *EnrichmentRule, expectedVersion int) (UpdateResult, error)
+	DeleteEnrichmentRule(ctx context.Context, id string) (DeleteResult, error)
+	// GetEnrichmentRuleHistory returns id's audit trail, newest first. The
+	// trail is still returned after the rule itself is deleted, since the
+	// history of a deleted rule is still worth keeping; an id with no audit
+	// entries gets an empty slice, not an error.
+	GetEnrichmentRuleHistory(ctx context.Context, id string) ([]EnrichmentRuleAudit, error)
+	// RollbackEnrichmentRule restores ruleID to target's field values and
+	// records a "rollback" audit entry with FromVersion set to fromVersion.
+	// If ruleID has since been deleted (no current row), it's recreated
+	// with the same ID rather than erroring, the same way a rollback to any
+	// other version overwrites whatever's there now.
+	RollbackEnrichmentRule(ctx context.Context, ruleID string, target *EnrichmentRule, fromVersion int) (*EnrichmentRule, error)
 }
 
 type mongoEnrichmentRepository struct {
-	collection *mongo.Collection
+	collection      *mongo.Collection
+	auditCollection *mongo.Collection
+	acknowledged    bool
+}
+
+// EnrichmentRepositoryOption configures NewEnrichmentRepository at
+// construction time.
+type EnrichmentRepositoryOption func(*enrichmentRepositoryConfig)
+
+type enrichmentRepositoryConfig struct {
+	writeConcern *writeconcern.WriteConcern
+}
+
+// WithWriteConcern sets wc on both the rule and audit collections. Pass
+// writeconcern.Unacknowledged() for high-volume collections where operators
+// accept losing a write under a dropped connection in exchange for not
+// waiting on server confirmation; the default (no option passed) is
+// writeconcern.Majority(), which is acknowledged. Acknowledged on
+// every InsertResult/UpdateResult/DeleteResult below is derived from wc once
+// here rather than introspected per call, since the driver's result structs
+// don't expose it themselves - an unacknowledged write just returns a
+// zero-valued result, indistinguishable from the fields being merely unset.
+func WithWriteConcern(wc *writeconcern.WriteConcern) EnrichmentRepositoryOption {
+	return func(c *enrichmentRepositoryConfig) {
+		c.writeConcern = wc
+	}
 }
 
-func NewEnrichmentRepository(db *mongo.Database) EnrichmentRepository {
+func NewEnrichmentRepository(db *mongo.Database, opts ...EnrichmentRepositoryOption) EnrichmentRepository {
+	cfg := enrichmentRepositoryConfig{writeConcern: writeconcern.Majority()}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	collOpts := options.Collection().SetWriteConcern(cfg.writeConcern)
 	return &mongoEnrichmentRepository{
-		collection: db.Collection("enrichment_rules"),
+		collection:      db.Collection("enrichment_rules", collOpts),
+		auditCollection: db.Collection("enrichment_rules_audit", collOpts),
+		acknowledged:    cfg.writeConcern.Acknowledged(),
+	}
+}
+
+// recordAudit appends one EnrichmentRuleAudit entry. before and/or after may
+// be nil (creation has no before, deletion has no after). It returns an
+// error rather than swallowing one, but callers still perform the mutation
+// first: an unaudited write is preferable to losing the write entirely over
+// an audit-collection hiccup.
+func (r *mongoEnrichmentRepository) recordAudit(ctx context.Context, ruleID string, version int, action string, before, after *EnrichmentRule) error {
+	return r.recordAuditEntry(ctx, ruleID, version, action, before, after, 0)
+}
+
+// withTransaction runs fn under a session, with ctx replaced by the
+// session's context so every driver call fn issues against r.collection/
+// r.auditCollection - via ctx, not a captured outer one - joins the same
+// transaction. Used by the CRUD methods below so a rule write and its
+// recordAudit call either both commit or both roll back, instead of a
+// crash between the two leaving a mutated rule with no matching
+// enrichment_rules_audit entry.
+//
+// MongoDB transactions only support acknowledged (majority) write concern -
+// an unacknowledged WriteConcern can't run inside one at all - so when
+// r.acknowledged is false, fn instead runs as two plain, non-transactional
+// operations, same as before this method existed. An operator who opted
+// into WithWriteConcern(writeconcern.Unacknowledged()) has already accepted
+// losing delivery guarantees in exchange for not waiting on the server; this
+// just means that tradeoff also covers the rule write and its audit entry
+// landing together.
+func (r *mongoEnrichmentRepository) withTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if !r.acknowledged {
+		return fn(ctx)
+	}
+
+	session, err := r.collection.Database().Client().StartSession()
+	if err != nil {
+		return fmt.Errorf("failed to start session: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessCtx)
+	})
+	return err
+}
+
+func (r *mongoEnrichmentRepository) recordAuditEntry(ctx context.Context, ruleID string, version int, action string, before, after *EnrichmentRule, fromVersion int) error {
+	entry := EnrichmentRuleAudit{
+		ID:          uuid.New().String(),
+		TenantID:    TenantIDFromContext(ctx),
+		RuleID:      ruleID,
+		Version:     version,
+		Actor:       getChangedBy(ctx),
+		Action:      action,
+		Before:      before,
+		After:       after,
+		At:          time.Now(),
+		FromVersion: fromVersion,
+	}
+
+	if _, err := r.auditCollection.InsertOne(ctx, entry); err != nil {
+		return fmt.Errorf("failed to record enrichment rule audit entry: %w", err)
+	}
+
+	return nil
+}
+
+// NewEnrichmentRepositoryFromConfig builds the EnrichmentRepository driver
+// selected by driver ("mongodb", "postgres", or "sqlite"; empty defaults to
+// "mongodb" for existing deployments that never set
+// Management.EnrichmentStorage.Driver). Only the *sql.DB/*mongo.Database
+// matching the chosen driver needs to be non-nil; callers that haven't
+// connected it get a descriptive error rather than a nil-pointer panic the
+// first time a repository method runs. mongoOpts is ignored for the
+// postgres/sqlite drivers - WithWriteConcern only means anything against
+// the mongodb driver.
+func NewEnrichmentRepositoryFromConfig(driver string, mongoDB *mongo.Database, postgresDB, sqliteDB *sql.DB, mongoOpts ...EnrichmentRepositoryOption) (EnrichmentRepository, error) {
+	switch driver {
+	case "", "mongodb":
+		if mongoDB == nil {
+			return nil, fmt.Errorf("enrichment storage driver %q requires database.mongodb.uri to be set", driver)
+		}
+		return NewEnrichmentRepository(mongoDB, mongoOpts...), nil
+	case "postgres":
+		if postgresDB == nil {
+			return nil, fmt.Errorf("enrichment storage driver %q requires database.postgres.host to be set", driver)
+		}
+		return newPostgresEnrichmentRepository(postgresDB), nil
+	case "sqlite":
+		if sqliteDB == nil {
+			return nil, fmt.Errorf("enrichment storage driver %q requires database.sqlite.path to be set", driver)
+		}
+		return newSQLiteEnrichmentRepository(sqliteDB), nil
+	default:
+		return nil, fmt.Errorf("unknown enrichment storage driver %q", driver)
 	}
 }
 
-func (r *mongoEnrichmentRepository) CreateEnrichmentRule(ctx context.Context, rule *EnrichmentRule) error {
+func (r *mongoEnrichmentRepository) CreateEnrichmentRule(ctx context.Context, rule *EnrichmentRule) (InsertResult, error) {
 	if rule.ID == "" {
 		rule.ID = uuid.New().String()
 	}
+	rule.TenantID = TenantIDFromContext(ctx)
 	now := time.Now()
 	rule.CreatedAt = now
 	rule.UpdatedAt = now
+	rule.Version = 1
 
-	_, err := r.collection.InsertOne(ctx, rule)
+	err := r.withTransaction(ctx, func(ctx context.Context) error {
+		if _, err := r.collection.InsertOne(ctx, rule); err != nil {
+			return fmt.Errorf("failed to create enrichment rule: %w", err)
+		}
+		return r.recordAudit(ctx, rule.ID, rule.Version, "create", nil, rule)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to create enrichment rule: %w", err)
+		return InsertResult{}, err
 	}
 
-	return nil
+	return InsertResult{WriteResult: WriteResult{Acknowledged: r.acknowledged}, InsertedID: rule.ID}, nil
 }
 
 func (r *mongoEnrichmentRepository) GetEnrichmentRule(ctx context.Context, id string) (*EnrichmentRule, error) {
-	filter := bson.M{"_id": id}
+	filter := bson.M{"_id": id, "tenant_id": TenantIDFromContext(ctx)}
 
 	var rule EnrichmentRule
 	err := r.collection.FindOne(ctx, filter).Decode(&rule)
@@ -63,7 +248,7 @@ func (r *mongoEnrichmentRepository) GetEnrichmentRule(ctx context.Context, id st
 func (r *mongoEnrichmentRepository) ListEnrichmentRules(ctx context.Context) ([]EnrichmentRule, error) {
 	opts := options.Find().SetSort(bson.D{{Key: "priority", Value: -1}, {Key: "created_at", Value: -1}})
 
-	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	cursor, err := r.collection.Find(ctx, bson.M{"tenant_id": TenantIDFromContext(ctx)}, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list enrichment rules: %w", err)
 	}
@@ -77,35 +262,194 @@ func (r *mongoEnrichmentRepository) ListEnrichmentRules(ctx context.Context) ([]
 	return rules, nil
 }
 
-func (r *mongoEnrichmentRepository) UpdateEnrichmentRule(ctx context.Context, rule *EnrichmentRule) error {
+func (r *mongoEnrichmentRepository) UpdateEnrichmentRule(ctx context.Context, rule *EnrichmentRule) (UpdateResult, error) {
+	before, err := r.GetEnrichmentRule(ctx, rule.ID)
+	if err != nil {
+		return UpdateResult{}, err
+	}
+
+	rule.UpdatedAt = time.Now()
+	rule.Version++
+
+	filter := bson.M{"_id": rule.ID, "tenant_id": TenantIDFromContext(ctx)}
+	update := bson.M{"$set": rule}
+
+	var result *mongo.UpdateResult
+	err = r.withTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = r.collection.UpdateOne(ctx, filter, update)
+		if err != nil {
+			return fmt.Errorf("failed to update enrichment rule: %w", err)
+		}
+
+		// MatchedCount is always 0 under an unacknowledged WriteConcern -
+		// the server never reports it back - so this not-found check only
+		// means anything when r.acknowledged is true. An unacknowledged
+		// caller has already accepted it can't tell "matched" from "not
+		// found" from "lost the write" in exchange for not waiting on the
+		// server.
+		if r.acknowledged && result.MatchedCount == 0 {
+			return fmt.Errorf("enrichment rule not found")
+		}
+
+		return r.recordAudit(ctx, rule.ID, rule.Version, "update", before, rule)
+	})
+	if err != nil {
+		return UpdateResult{}, err
+	}
+
+	return UpdateResult{
+		WriteResult:   WriteResult{Acknowledged: r.acknowledged},
+		MatchedCount:  result.MatchedCount,
+		ModifiedCount: result.ModifiedCount,
+	}, nil
+}
+
+// UpdateEnrichmentRuleCAS applies rule using optimistic concurrency control: the
+// write only succeeds if the document's current version still matches
+// expectedVersion. On a version mismatch it returns ErrConflict so callers can
+// refetch, re-merge their changes, and retry. If the document doesn't exist
+// at all, it returns a plain not-found error instead, so callers can tell
+// "someone else deleted it" apart from "someone else edited it".
+func (r *mongoEnrichmentRepository) UpdateEnrichmentRuleCAS(ctx context.Context, rule *EnrichmentRule, expectedVersion int) (UpdateResult, error) {
+	before, err := r.GetEnrichmentRule(ctx, rule.ID)
+	if err != nil {
+		return UpdateResult{}, err
+	}
+
 	rule.UpdatedAt = time.Now()
+	rule.Version = expectedVersion + 1
 
-	filter := bson.M{"_id": rule.ID}
+	filter := bson.M{"_id": rule.ID, "version": expectedVersion, "tenant_id": TenantIDFromContext(ctx)}
 	update := bson.M{"$set": rule}
 
-	result, err := r.collection.UpdateOne(ctx, filter, update)
+	var result *mongo.UpdateResult
+	err = r.withTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = r.collection.UpdateOne(ctx, filter, update)
+		if err != nil {
+			return fmt.Errorf("failed to update enrichment rule: %w", err)
+		}
+
+		// Same caveat as UpdateEnrichmentRule: MatchedCount can't be
+		// trusted under an unacknowledged WriteConcern, so the CAS check
+		// it backs is only enforceable when r.acknowledged is true. An
+		// operator choosing unacknowledged writes for this collection has
+		// given up optimistic concurrency's conflict detection along with
+		// the delivery guarantee.
+		if r.acknowledged && result.MatchedCount == 0 {
+			if before == nil {
+				return fmt.Errorf("enrichment rule not found")
+			}
+			return pkgerrors.ErrConflict.WithDetail("message", fmt.Sprintf("enrichment rule %s was modified by another request", rule.ID)).WithDetail("rule_id", rule.ID)
+		}
+
+		return r.recordAudit(ctx, rule.ID, rule.Version, "update", before, rule)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to update enrichment rule: %w", err)
+		return UpdateResult{}, err
 	}
 
-	if result.MatchedCount == 0 {
-		return fmt.Errorf("enrichment rule not found")
+	return UpdateResult{
+		WriteResult:   WriteResult{Acknowledged: r.acknowledged},
+		MatchedCount:  result.MatchedCount,
+		ModifiedCount: result.ModifiedCount,
+	}, nil
+}
+
+func (r *mongoEnrichmentRepository) DeleteEnrichmentRule(ctx context.Context, id string) (DeleteResult, error) {
+	before, err := r.GetEnrichmentRule(ctx, id)
+	if err != nil {
+		return DeleteResult{}, err
 	}
 
-	return nil
+	filter := bson.M{"_id": id, "tenant_id": TenantIDFromContext(ctx)}
+
+	version := 0
+	if before != nil {
+		version = before.Version
+	}
+
+	var result *mongo.DeleteResult
+	err = r.withTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = r.collection.DeleteOne(ctx, filter)
+		if err != nil {
+			return fmt.Errorf("failed to delete enrichment rule: %w", err)
+		}
+
+		// Same caveat as the update paths: DeletedCount is always 0 under
+		// an unacknowledged WriteConcern, so this check only applies when
+		// r.acknowledged is true.
+		if r.acknowledged && result.DeletedCount == 0 {
+			return fmt.Errorf("enrichment rule not found")
+		}
+
+		return r.recordAudit(ctx, id, version, "delete", before, nil)
+	})
+	if err != nil {
+		return DeleteResult{}, err
+	}
+
+	return DeleteResult{WriteResult: WriteResult{Acknowledged: r.acknowledged}, DeletedCount: result.DeletedCount}, nil
 }
 
-func (r *mongoEnrichmentRepository) DeleteEnrichmentRule(ctx context.Context, id string) error {
-	filter := bson.M{"_id": id}
+// GetEnrichmentRuleHistory returns id's audit trail, newest first.
+func (r *mongoEnrichmentRepository) GetEnrichmentRuleHistory(ctx context.Context, id string) ([]EnrichmentRuleAudit, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "at", Value: -1}})
 
-	result, err := r.collection.DeleteOne(ctx, filter)
+	cursor, err := r.auditCollection.Find(ctx, bson.M{"rule_id": id, "tenant_id": TenantIDFromContext(ctx)}, opts)
 	if err != nil {
-		return fmt.Errorf("failed to delete enrichment rule: %w", err)
+		return nil, fmt.Errorf("failed to list enrichment rule history: %w", err)
 	}
+	defer cursor.Close(ctx)
 
-	if result.DeletedCount == 0 {
-		return fmt.Errorf("enrichment rule not found")
+	entries := []EnrichmentRuleAudit{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, fmt.Errorf("failed to decode enrichment rule history: %w", err)
 	}
 
-	return nil
+	return entries, nil
+}
+
+// RollbackEnrichmentRule implements the EnrichmentRepository interface
+// method of the same name. Deleted rules (before == nil) are recreated with
+// ruleID as a fresh document at version 1, rather than an update, since
+// there's no existing row to match against.
+func (r *mongoEnrichmentRepository) RollbackEnrichmentRule(ctx context.Context, ruleID string, target *EnrichmentRule, fromVersion int) (*EnrichmentRule, error) {
+	before, err := r.GetEnrichmentRule(ctx, ruleID)
+	if err != nil {
+		return nil, err
+	}
+
+	restored := *target
+	restored.ID = ruleID
+	restored.TenantID = TenantIDFromContext(ctx)
+	restored.UpdatedAt = time.Now()
+
+	if before == nil {
+		restored.CreatedAt = restored.UpdatedAt
+		restored.Version = 1
+		if _, err := r.collection.InsertOne(ctx, &restored); err != nil {
+			return nil, fmt.Errorf("failed to recreate enrichment rule: %w", err)
+		}
+	} else {
+		restored.CreatedAt = before.CreatedAt
+		restored.Version = before.Version + 1
+		filter := bson.M{"_id": ruleID, "tenant_id": restored.TenantID}
+		update := bson.M{"$set": &restored}
+		result, err := r.collection.UpdateOne(ctx, filter, update)
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore enrichment rule: %w", err)
+		}
+		if result.MatchedCount == 0 {
+			return nil, fmt.Errorf("enrichment rule not found")
+		}
+	}
+
+	if err := r.recordAuditEntry(ctx, ruleID, restored.Version, "rollback", before, &restored, fromVersion); err != nil {
+		return nil, err
+	}
+
+	return &restored, nil
 }
@@ -0,0 +1,428 @@
+package management
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	pkgerrors "yeti/pkg/errors"
+)
+
+// sqliteEnrichmentRepository is the embedded/test-mode EnrichmentRepository
+// driver: same table shape as postgresEnrichmentRepository (see
+// migrations/sqlite/0003_enrichment_rules.up.sql), minus the GIN index
+// Postgres keeps on the data column, since SQLite has no equivalent.
+type sqliteEnrichmentRepository struct {
+	db *sql.DB
+}
+
+func newSQLiteEnrichmentRepository(db *sql.DB) EnrichmentRepository {
+	return &sqliteEnrichmentRepository{db: db}
+}
+
+// recordAudit appends one enrichment_rules_audit row via exec - the same
+// *sql.Tx a CRUD method is writing the rule row under, so the audit row
+// lands atomically with it (see sqlExecutor in
+// enrichment_repository_postgres.go). before and/or after may be nil
+// (creation has no before, deletion has no after).
+func (r *sqliteEnrichmentRepository) recordAudit(ctx context.Context, exec sqlExecutor, ruleID string, version int, action string, before, after *EnrichmentRule) error {
+	return r.recordAuditEntry(ctx, exec, ruleID, version, action, before, after, 0)
+}
+
+func (r *sqliteEnrichmentRepository) recordAuditEntry(ctx context.Context, exec sqlExecutor, ruleID string, version int, action string, before, after *EnrichmentRule, fromVersion int) error {
+	var beforeData, afterData []byte
+	var err error
+	if before != nil {
+		if beforeData, err = json.Marshal(before); err != nil {
+			return fmt.Errorf("failed to marshal enrichment rule audit before-snapshot: %w", err)
+		}
+	}
+	if after != nil {
+		if afterData, err = json.Marshal(after); err != nil {
+			return fmt.Errorf("failed to marshal enrichment rule audit after-snapshot: %w", err)
+		}
+	}
+
+	query := `
+		INSERT INTO enrichment_rules_audit (id, tenant_id, rule_id, version, actor, action, before, after, at, from_version)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err = exec.ExecContext(ctx, query,
+		uuid.New().String(), TenantIDFromContext(ctx), ruleID, version, getChangedBy(ctx), action, beforeData, afterData, time.Now(), nullableVersion(fromVersion),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record enrichment rule audit entry: %w", err)
+	}
+
+	return nil
+}
+
+func (r *sqliteEnrichmentRepository) CreateEnrichmentRule(ctx context.Context, rule *EnrichmentRule) (InsertResult, error) {
+	if rule.ID == "" {
+		rule.ID = uuid.New().String()
+	}
+	rule.TenantID = TenantIDFromContext(ctx)
+	now := time.Now()
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+	rule.Version = 1
+
+	data, err := json.Marshal(rule)
+	if err != nil {
+		return InsertResult{}, fmt.Errorf("failed to marshal enrichment rule: %w", err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return InsertResult{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO enrichment_rules (id, tenant_id, name, field_to_enrich, priority, enabled, version, data, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err = tx.ExecContext(ctx, query,
+		rule.ID, rule.TenantID, rule.Name, rule.FieldToEnrich,
+		rule.Priority, rule.Enabled, rule.Version, data, rule.CreatedAt, rule.UpdatedAt,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return InsertResult{}, pkgerrors.ErrConflict.WithCause(err).WithDetail("message", fmt.Sprintf("enrichment rule with id '%s' already exists", rule.ID))
+		}
+		return InsertResult{}, fmt.Errorf("failed to create enrichment rule: %w", err)
+	}
+
+	if err := r.recordAudit(ctx, tx, rule.ID, rule.Version, "create", nil, rule); err != nil {
+		return InsertResult{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return InsertResult{}, fmt.Errorf("failed to commit enrichment rule create: %w", err)
+	}
+
+	// A committed SQLite INSERT has no unacknowledged-write concept: a
+	// driver error above means the transaction didn't commit at all, so
+	// reaching here means the write is durable.
+	return InsertResult{WriteResult: WriteResult{Acknowledged: true}, InsertedID: rule.ID}, nil
+}
+
+func (r *sqliteEnrichmentRepository) GetEnrichmentRule(ctx context.Context, id string) (*EnrichmentRule, error) {
+	query := `SELECT data FROM enrichment_rules WHERE id = ? AND tenant_id = ?`
+
+	var data []byte
+	err := r.db.QueryRowContext(ctx, query, id, TenantIDFromContext(ctx)).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil // Not found
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get enrichment rule: %w", err)
+	}
+
+	var rule EnrichmentRule
+	if err := json.Unmarshal(data, &rule); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal enrichment rule: %w", err)
+	}
+
+	return &rule, nil
+}
+
+func (r *sqliteEnrichmentRepository) ListEnrichmentRules(ctx context.Context) ([]EnrichmentRule, error) {
+	query := `
+		SELECT data FROM enrichment_rules
+		WHERE tenant_id = ?
+		ORDER BY priority DESC, created_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, TenantIDFromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list enrichment rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []EnrichmentRule
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to scan enrichment rule: %w", err)
+		}
+		var rule EnrichmentRule
+		if err := json.Unmarshal(data, &rule); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal enrichment rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+func (r *sqliteEnrichmentRepository) UpdateEnrichmentRule(ctx context.Context, rule *EnrichmentRule) (UpdateResult, error) {
+	before, err := r.GetEnrichmentRule(ctx, rule.ID)
+	if err != nil {
+		return UpdateResult{}, err
+	}
+
+	rule.UpdatedAt = time.Now()
+	rule.Version++
+
+	data, err := json.Marshal(rule)
+	if err != nil {
+		return UpdateResult{}, fmt.Errorf("failed to marshal enrichment rule: %w", err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return UpdateResult{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE enrichment_rules
+		SET name = ?, field_to_enrich = ?, priority = ?, enabled = ?, version = ?, data = ?, updated_at = ?
+		WHERE id = ? AND tenant_id = ?
+	`
+
+	res, err := tx.ExecContext(ctx, query,
+		rule.Name, rule.FieldToEnrich, rule.Priority, rule.Enabled, rule.Version, data, rule.UpdatedAt,
+		rule.ID, TenantIDFromContext(ctx),
+	)
+	if err != nil {
+		return UpdateResult{}, fmt.Errorf("failed to update enrichment rule: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return UpdateResult{}, err
+	}
+	if rows == 0 {
+		return UpdateResult{}, fmt.Errorf("enrichment rule not found")
+	}
+
+	if err := r.recordAudit(ctx, tx, rule.ID, rule.Version, "update", before, rule); err != nil {
+		return UpdateResult{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return UpdateResult{}, fmt.Errorf("failed to commit enrichment rule update: %w", err)
+	}
+
+	return UpdateResult{WriteResult: WriteResult{Acknowledged: true}, MatchedCount: rows, ModifiedCount: rows}, nil
+}
+
+// UpdateEnrichmentRuleCAS mirrors postgresEnrichmentRepository's: the write
+// only succeeds if the row's current version still matches expectedVersion.
+// If the row doesn't exist at all, it returns a plain not-found error
+// instead of ErrConflict, so callers can tell "someone else deleted it"
+// apart from "someone else edited it".
+func (r *sqliteEnrichmentRepository) UpdateEnrichmentRuleCAS(ctx context.Context, rule *EnrichmentRule, expectedVersion int) (UpdateResult, error) {
+	before, err := r.GetEnrichmentRule(ctx, rule.ID)
+	if err != nil {
+		return UpdateResult{}, err
+	}
+
+	rule.UpdatedAt = time.Now()
+	rule.Version = expectedVersion + 1
+
+	data, err := json.Marshal(rule)
+	if err != nil {
+		return UpdateResult{}, fmt.Errorf("failed to marshal enrichment rule: %w", err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return UpdateResult{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE enrichment_rules
+		SET name = ?, field_to_enrich = ?, priority = ?, enabled = ?, version = ?, data = ?, updated_at = ?
+		WHERE id = ? AND version = ? AND tenant_id = ?
+	`
+
+	res, err := tx.ExecContext(ctx, query,
+		rule.Name, rule.FieldToEnrich, rule.Priority, rule.Enabled, rule.Version, data, rule.UpdatedAt,
+		rule.ID, expectedVersion, TenantIDFromContext(ctx),
+	)
+	if err != nil {
+		return UpdateResult{}, fmt.Errorf("failed to update enrichment rule: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return UpdateResult{}, err
+	}
+	if rows == 0 {
+		if before == nil {
+			return UpdateResult{}, fmt.Errorf("enrichment rule not found")
+		}
+		return UpdateResult{}, pkgerrors.ErrConflict.WithDetail("message", fmt.Sprintf("enrichment rule %s was modified by another request", rule.ID)).WithDetail("rule_id", rule.ID)
+	}
+
+	if err := r.recordAudit(ctx, tx, rule.ID, rule.Version, "update", before, rule); err != nil {
+		return UpdateResult{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return UpdateResult{}, fmt.Errorf("failed to commit enrichment rule update: %w", err)
+	}
+
+	return UpdateResult{WriteResult: WriteResult{Acknowledged: true}, MatchedCount: rows, ModifiedCount: rows}, nil
+}
+
+func (r *sqliteEnrichmentRepository) DeleteEnrichmentRule(ctx context.Context, id string) (DeleteResult, error) {
+	before, err := r.GetEnrichmentRule(ctx, id)
+	if err != nil {
+		return DeleteResult{}, err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return DeleteResult{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `DELETE FROM enrichment_rules WHERE id = ? AND tenant_id = ?`
+
+	res, err := tx.ExecContext(ctx, query, id, TenantIDFromContext(ctx))
+	if err != nil {
+		return DeleteResult{}, fmt.Errorf("failed to delete enrichment rule: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return DeleteResult{}, err
+	}
+	if rows == 0 {
+		return DeleteResult{}, fmt.Errorf("enrichment rule not found")
+	}
+
+	version := 0
+	if before != nil {
+		version = before.Version
+	}
+
+	if err := r.recordAudit(ctx, tx, id, version, "delete", before, nil); err != nil {
+		return DeleteResult{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return DeleteResult{}, fmt.Errorf("failed to commit enrichment rule delete: %w", err)
+	}
+
+	return DeleteResult{WriteResult: WriteResult{Acknowledged: true}, DeletedCount: rows}, nil
+}
+
+// GetEnrichmentRuleHistory returns id's audit trail, newest first.
+func (r *sqliteEnrichmentRepository) GetEnrichmentRuleHistory(ctx context.Context, id string) ([]EnrichmentRuleAudit, error) {
+	query := `
+		SELECT id, tenant_id, rule_id, version, actor, action, before, after, at, from_version
+		FROM enrichment_rules_audit
+		WHERE rule_id = ? AND tenant_id = ?
+		ORDER BY at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, id, TenantIDFromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list enrichment rule history: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []EnrichmentRuleAudit{}
+	for rows.Next() {
+		var entry EnrichmentRuleAudit
+		var beforeData, afterData []byte
+		var fromVersion sql.NullInt64
+		if err := rows.Scan(&entry.ID, &entry.TenantID, &entry.RuleID, &entry.Version, &entry.Actor, &entry.Action, &beforeData, &afterData, &entry.At, &fromVersion); err != nil {
+			return nil, fmt.Errorf("failed to scan enrichment rule audit entry: %w", err)
+		}
+		if len(beforeData) > 0 {
+			if err := json.Unmarshal(beforeData, &entry.Before); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal enrichment rule audit before-snapshot: %w", err)
+			}
+		}
+		if len(afterData) > 0 {
+			if err := json.Unmarshal(afterData, &entry.After); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal enrichment rule audit after-snapshot: %w", err)
+			}
+		}
+		entry.FromVersion = int(fromVersion.Int64)
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// RollbackEnrichmentRule implements the EnrichmentRepository interface
+// method of the same name, mirroring postgresEnrichmentRepository's.
+func (r *sqliteEnrichmentRepository) RollbackEnrichmentRule(ctx context.Context, ruleID string, target *EnrichmentRule, fromVersion int) (*EnrichmentRule, error) {
+	before, err := r.GetEnrichmentRule(ctx, ruleID)
+	if err != nil {
+		return nil, err
+	}
+
+	restored := *target
+	restored.ID = ruleID
+	restored.TenantID = TenantIDFromContext(ctx)
+	restored.UpdatedAt = time.Now()
+
+	if before == nil {
+		restored.CreatedAt = restored.UpdatedAt
+		restored.Version = 1
+
+		data, err := json.Marshal(&restored)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal enrichment rule: %w", err)
+		}
+
+		_, err = r.db.ExecContext(ctx, `
+			INSERT INTO enrichment_rules (id, tenant_id, name, field_to_enrich, priority, enabled, version, data, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, restored.ID, restored.TenantID, restored.Name, restored.FieldToEnrich,
+			restored.Priority, restored.Enabled, restored.Version, data, restored.CreatedAt, restored.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to recreate enrichment rule: %w", err)
+		}
+	} else {
+		restored.CreatedAt = before.CreatedAt
+		restored.Version = before.Version + 1
+
+		data, err := json.Marshal(&restored)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal enrichment rule: %w", err)
+		}
+
+		res, err := r.db.ExecContext(ctx, `
+			UPDATE enrichment_rules
+			SET name = ?, field_to_enrich = ?, priority = ?, enabled = ?, version = ?, data = ?, updated_at = ?
+			WHERE id = ? AND tenant_id = ?
+		`, restored.Name, restored.FieldToEnrich, restored.Priority, restored.Enabled, restored.Version, data, restored.UpdatedAt,
+			restored.ID, restored.TenantID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore enrichment rule: %w", err)
+		}
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		if rows == 0 {
+			return nil, fmt.Errorf("enrichment rule not found")
+		}
+	}
+
+	if err := r.recordAuditEntry(ctx, r.db, ruleID, restored.Version, "rollback", before, &restored, fromVersion); err != nil {
+		return nil, err
+	}
+
+	return &restored, nil
+}
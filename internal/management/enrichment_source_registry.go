@@ -0,0 +1,44 @@
+package management
+
+import "sort"
+
+// EnrichmentSourceRegistry lets a caller register and enumerate enrichment
+// source types without importing this package's unexported validator map
+// directly. NewEnrichmentSourceRegistry returns the one implementation this
+// package ships, which is just a thin wrapper around
+// RegisterSourceTypeValidator/validSourceTypes; a deployment wanting a
+// registry scoped to something other than this package's process-wide
+// global state (e.g. per-tenant source types) can supply its own.
+type EnrichmentSourceRegistry interface {
+	// RegisterSourceType adds sourceType as an allowed EnrichmentRule
+	// source_type, dispatching its SourceConfig validation to validate.
+	RegisterSourceType(sourceType string, validate func(EnrichmentSourceConfig) error)
+	// SourceTypes returns every currently-registered source_type, sorted.
+	SourceTypes() []string
+}
+
+type globalEnrichmentSourceRegistry struct{}
+
+// NewEnrichmentSourceRegistry returns an EnrichmentSourceRegistry backed by
+// RegisterSourceTypeValidator's package-level registry - the same registry
+// ValidateEnrichmentRule/ValidateUpdateEnrichmentRule already dispatch to.
+func NewEnrichmentSourceRegistry() EnrichmentSourceRegistry {
+	return globalEnrichmentSourceRegistry{}
+}
+
+func (globalEnrichmentSourceRegistry) RegisterSourceType(sourceType string, validate func(EnrichmentSourceConfig) error) {
+	RegisterSourceTypeValidator(sourceType, validate)
+}
+
+func (globalEnrichmentSourceRegistry) SourceTypes() []string {
+	sourceTypeValidatorsMu.RLock()
+	defer sourceTypeValidatorsMu.RUnlock()
+	types := make([]string, 0, len(validSourceTypes))
+	for sourceType, allowed := range validSourceTypes {
+		if allowed {
+			types = append(types, sourceType)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
@@ -0,0 +1,948 @@
+package management
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"yeti/internal/constants"
+	"yeti/internal/enrichment/provider"
+	"yeti/pkg/cel"
+	pkgerrors "yeti/pkg/errors"
+	"yeti/pkg/models"
+)
+
+// SampleEvent is the JSON (and, for corpus storage, BSON) shape of an event
+// fed into a dry-run filtering or enrichment evaluation. Its fields mirror
+// models.MessageEnvelope so a sample can be replayed through the production
+// CEL evaluator unchanged.
+type SampleEvent struct {
+	ID        string                 `json:"id,omitempty" bson:"_id,omitempty"`
+	Source    string                 `json:"source" bson:"source"`
+	Timestamp time.Time              `json:"timestamp" bson:"timestamp"`
+	Payload   map[string]interface{} `json:"payload" bson:"payload"`
+}
+
+func (e SampleEvent) toEnvelope() models.MessageEnvelope {
+	ts := e.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	return models.MessageEnvelope{
+		ID:        e.ID,
+		Source:    e.Source,
+		Timestamp: ts,
+		Payload:   e.Payload,
+		Metadata:  models.Metadata{Enrichment: make(map[string]interface{})},
+	}
+}
+
+// FilteringEvaluateRequest evaluates a filtering rule against a sample event
+// without adding it to the active rule set. Exactly one of RuleID or
+// Expression should be set: RuleID replays a stored rule, Expression
+// evaluates an inline expression that hasn't been saved yet.
+type FilteringEvaluateRequest struct {
+	RuleID     *string     `json:"rule_id"`
+	Expression *string     `json:"expression"`
+	Event      SampleEvent `json:"event" binding:"required"`
+}
+
+type FilteringEvaluateResponse struct {
+	Matched          bool                     `json:"matched"`
+	EvaluationTimeMS float64                  `json:"evaluation_time_ms"`
+	CompileError     string                   `json:"compile_error,omitempty"`
+	Error            string                   `json:"error,omitempty"`
+	// Trace is a per-subexpression evaluation trace from cel.Evaluator's
+	// EvaluateFilterWithTrace, letting an operator see which part of the
+	// expression produced the result before saving it as a rule; see that
+	// method's doc comment for how it's derived.
+	Trace []cel.SubexpressionTrace `json:"trace,omitempty"`
+}
+
+// EnrichmentEvaluateRequest evaluates an enrichment rule against a sample
+// event. Exactly one of RuleID or Rule should be set: RuleID replays a
+// stored rule, Rule evaluates an inline rule spec that hasn't been saved yet.
+type EnrichmentEvaluateRequest struct {
+	RuleID *string                      `json:"rule_id"`
+	Rule   *CreateEnrichmentRuleRequest `json:"rule"`
+	Event  SampleEvent                  `json:"event" binding:"required"`
+	// MockSources, keyed by source_type (matching EnrichmentRule.SourceType,
+	// e.g. "cache"/"http"), substitutes a canned response for that source
+	// type's real provider.Fetch call, so a dry run can be evaluated without
+	// actually reaching the backend a saved rule would hit. A source type
+	// with no entry here still falls through to its live provider.
+	MockSources map[string]map[string]interface{} `json:"mock_sources,omitempty"`
+}
+
+type EnrichmentEvaluateResponse struct {
+	Matched          bool                   `json:"matched"`
+	EvaluationTimeMS float64                `json:"evaluation_time_ms"`
+	ResolvedProvider string                 `json:"resolved_provider,omitempty"`
+	CompileError     string                 `json:"compile_error,omitempty"`
+	Error            string                 `json:"error,omitempty"`
+	EnrichmentDiff   map[string]interface{} `json:"enrichment_diff,omitempty"`
+}
+
+// maxDryRunEventResults caps how many per-event outcomes DryRunResult.Results
+// carries, so a dry run against a large sample_events/caller-supplied batch
+// doesn't balloon the response; SampleCount/MatchCount/MatchRate/Errors are
+// always computed over the full set regardless of this cap.
+const maxDryRunEventResults = 50
+
+// DryRunEventResult is one sample event's outcome within a DryRunResult,
+// identifying it by EventID (the sample event's own ID; empty, and so
+// omitted, for an event that didn't set one) so a reviewer can see exactly
+// which events would start or stop matching.
+//
+// Extractions captures the data a reviewer would need to see why Matched
+// came out the way it did, without requiring them to cross-reference the
+// original sample separately: for a filtering rule, the event's full
+// Payload (narrowing it to just the fields the expression actually
+// referenced would need inspecting the compiled CEL AST, which
+// DryRunFilteringRule's evaluator doesn't expose - see
+// RuleDryRunCondition for the narrower per-clause trace
+// DryRunFilteringRuleByID offers instead, for a single event); for an
+// enrichment rule, the EnrichmentDiff fields that rule's transformations
+// would have produced.
+type DryRunEventResult struct {
+	EventID     string                 `json:"event_id,omitempty"`
+	Matched     bool                   `json:"matched"`
+	Error       string                 `json:"error,omitempty"`
+	Extractions map[string]interface{} `json:"extractions,omitempty"`
+}
+
+// DryRunResult summarizes how a candidate rule would have performed against
+// a sample event set, letting a reviewer judge impact before a create/update
+// goes live. The sample set is either a caller-supplied batch (see
+// DryRunFilteringRule/DryRunEnrichmentRule's sampleEvents parameter) or,
+// when that's empty, the stored corpus of recent production traffic.
+type DryRunResult struct {
+	SampleCount int                 `json:"sample_count"`
+	MatchCount  int                 `json:"match_count"`
+	MatchRate   float64             `json:"match_rate"`
+	Errors      int                 `json:"errors,omitempty"`
+	Results     []DryRunEventResult `json:"results,omitempty"`
+	// PreviousMatchCount and MatchDelta report how the candidate's match
+	// count compares to the rule's currently-stored expression/Condition
+	// evaluated over the same sample set, when dry-running an update to
+	// an existing rule (see DryRunFilteringRule/DryRunEnrichmentRule's
+	// previous parameter). Both are nil for a dry run with nothing stored
+	// yet to diff against (a new rule, or RuleID unset).
+	PreviousMatchCount *int `json:"previous_match_count,omitempty"`
+	MatchDelta         *int `json:"match_delta,omitempty"`
+}
+
+// appendDryRunEventResult appends er to results unless the
+// maxDryRunEventResults cap has already been reached, for
+// DryRunFilteringRule/DryRunEnrichmentRule's per-event loops.
+func appendDryRunEventResult(results []DryRunEventResult, er DryRunEventResult) []DryRunEventResult {
+	if len(results) >= maxDryRunEventResults {
+		return results
+	}
+	return append(results, er)
+}
+
+// EnrichmentExpressionPlaygroundRequest evaluates a single CEL expression -
+// an enrichment rule's Condition, or one of its transformations' Expression
+// - against a sample event and a mocked SourceData, without needing a live
+// provider fetch or a full rule spec. Exactly one of RuleID or Expression
+// should be set: RuleID replays an existing rule's Condition, Expression
+// evaluates inline CEL that hasn't been saved to any rule yet.
+type EnrichmentExpressionPlaygroundRequest struct {
+	RuleID     *string                `json:"rule_id"`
+	Expression *string                `json:"expression"`
+	Event      SampleEvent            `json:"event" binding:"required"`
+	SourceData map[string]interface{} `json:"source_data"`
+}
+
+// PlaygroundResult is the response for a single expression's dry-run/
+// playground evaluation (see FilteringEvaluateRequest used as a filtering
+// playground request, and EnrichmentExpressionPlaygroundRequest): besides
+// Result - the expression's evaluated value, bool for a filter expression,
+// whatever EvaluateTransform returns for a transform one - it reports the
+// expression's static shape (OutputType, EstimatedCost) and, if it failed to
+// compile, every issue cel-go reported with its source position, so a rule
+// author's editor can underline the exact token without re-deriving any of
+// this client-side.
+type PlaygroundResult struct {
+	Result           interface{}           `json:"result,omitempty"`
+	EvaluationTimeMS float64               `json:"evaluation_time_ms"`
+	OutputType       string                `json:"output_type,omitempty"`
+	EstimatedCost    uint64                `json:"estimated_cost,omitempty"`
+	Issues           []cel.ExpressionIssue `json:"issues,omitempty"`
+	Error            string                `json:"error,omitempty"`
+}
+
+// getEvaluator lazily builds the service's CEL evaluator, mirroring the
+// fallback in enrichment.serviceImpl.transformValue: the evaluator is
+// stateless, so rebuilding it on a rare race is harmless. It's capped by the
+// same maxEstimatedCELCost ceiling newValidationEvaluator uses, so a dry run
+// through this evaluator can't pass on an expression create/update would
+// then reject.
+func (s *service) getEvaluator() (*cel.Evaluator, error) {
+	if s.evaluator != nil {
+		return s.evaluator, nil
+	}
+	evaluator, err := cel.NewEvaluator()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CEL evaluator: %w", err)
+	}
+	evaluator.WithMaxEstimatedCost(maxEstimatedCELCost)
+	s.evaluator = evaluator
+	return evaluator, nil
+}
+
+// getRuleValidator lazily builds the service's RuleValidator, mirroring
+// getEvaluator: it's stateless apart from its compiled-program cache, so
+// rebuilding it on a rare race just costs that cache, not correctness.
+func (s *service) getRuleValidator() (*RuleValidator, error) {
+	if s.ruleValidator != nil {
+		return s.ruleValidator, nil
+	}
+	ruleValidator, err := NewRuleValidator()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rule validator: %w", err)
+	}
+	s.ruleValidator = ruleValidator
+	return ruleValidator, nil
+}
+
+// cacheEnrichmentRuleValidation compiles rule's transformations and caches
+// the resulting programs under (rule.ID, rule.Version), so the enrichment
+// runtime's hot path can reuse them instead of recompiling per event. The
+// expressions were already validated once by ValidateEnrichmentRule/
+// ValidateUpdateEnrichmentRule before the write reached here, so a compile
+// failure at this point would mean those checks and this cache disagree;
+// rather than fail an otherwise-successful write over a caching concern, it
+// just skips caching for this version and lets the runtime fall back to
+// compiling on demand.
+func (s *service) cacheEnrichmentRuleValidation(rule *EnrichmentRule) {
+	ruleValidator, err := s.getRuleValidator()
+	if err != nil {
+		return
+	}
+	programs, err := ruleValidator.CompileTransformations(rule.Transformations)
+	if err != nil {
+		return
+	}
+	ruleValidator.CacheCompiled(rule.ID, rule.Version, programs)
+}
+
+// getHTTPTemplateCompiler lazily builds the service's HTTPTemplateCompiler,
+// mirroring getRuleValidator.
+func (s *service) getHTTPTemplateCompiler() *HTTPTemplateCompiler {
+	if s.httpTemplates == nil {
+		s.httpTemplates = NewHTTPTemplateCompiler()
+	}
+	return s.httpTemplates
+}
+
+// cacheEnrichmentRuleHTTPTemplate is cacheEnrichmentRuleValidation's http
+// source-type counterpart: for an http-sourced rule it parses and caches
+// the source_config's URL/Body/Headers/QueryParams placeholders under
+// (rule.ID, rule.Version), so a later dry-run/fetch can look up which
+// fields the template needs without re-parsing it. rule's source_config
+// was already validated by ValidateEnrichmentRule/ValidateUpdateEnrichmentRule
+// before the write reached here, so a compile failure at this point is
+// skipped rather than failing an otherwise-successful write, the same way
+// cacheEnrichmentRuleValidation treats a post-validation compile failure.
+func (s *service) cacheEnrichmentRuleHTTPTemplate(rule *EnrichmentRule) {
+	if rule.SourceType != "http" {
+		return
+	}
+	tokens, err := s.getHTTPTemplateCompiler().CompileSourceConfig(rule.SourceConfig)
+	if err != nil {
+		return
+	}
+	s.getHTTPTemplateCompiler().CacheCompiled(rule.ID, rule.Version, tokens)
+}
+
+// warmFilteringRuleCEL pre-warms the shared cel.Evaluator's compiled-program
+// cache with rule's expression, so the runtime filtering-service evaluator -
+// which shares the same expression string, just a separate Evaluator
+// instance in a separate process - still pays the one-time compile cost the
+// first time it sees the expression, but this management-side evaluator's
+// own dry-run/evaluate endpoints see an immediate cache hit. Errors are
+// ignored, matching cacheEnrichmentRuleValidation: ValidateFilteringRule
+// already validated this same expression before the write reached here, so
+// a compile failure here would just mean a pointless rebuild was skipped.
+func (s *service) warmFilteringRuleCEL(expression string) {
+	evaluator, err := s.getEvaluator()
+	if err != nil {
+		return
+	}
+	_ = evaluator.WarmFilterExpression(expression)
+}
+
+// warmEnrichmentRuleCEL is warmFilteringRuleCEL's enrichment counterpart: it
+// warms rule.Condition (if set) in the filter cache and every transformation
+// expression in the transform cache.
+func (s *service) warmEnrichmentRuleCEL(rule *EnrichmentRule) {
+	evaluator, err := s.getEvaluator()
+	if err != nil {
+		return
+	}
+	if rule.Condition != "" {
+		_ = evaluator.WarmFilterExpression(rule.Condition)
+	}
+	for _, trans := range rule.Transformations {
+		if trans.Expression != "" {
+			_ = evaluator.WarmTransformExpression(trans.Expression)
+		}
+	}
+}
+
+// evictFilteringRuleCEL is warmFilteringRuleCEL's delete-time counterpart.
+func (s *service) evictFilteringRuleCEL(expression string) {
+	evaluator, err := s.getEvaluator()
+	if err != nil {
+		return
+	}
+	evaluator.EvictFilterExpression(expression)
+}
+
+// evictEnrichmentRuleCEL evicts rule's Condition and transformation
+// expressions from the shared evaluator's caches; see
+// cel.Evaluator.EvictFilterExpression's doc comment for why a shared
+// expression text surviving in another active rule is harmless.
+func (s *service) evictEnrichmentRuleCEL(rule *EnrichmentRule) {
+	evaluator, err := s.getEvaluator()
+	if err != nil {
+		return
+	}
+	if rule.Condition != "" {
+		evaluator.EvictFilterExpression(rule.Condition)
+	}
+	for _, trans := range rule.Transformations {
+		if trans.Expression != "" {
+			evaluator.EvictTransformExpression(trans.Expression)
+		}
+	}
+}
+
+func (s *service) EvaluateFilteringRule(ctx context.Context, req FilteringEvaluateRequest) (*FilteringEvaluateResponse, error) {
+	expression, err := s.resolveFilteringExpression(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	evaluator, err := s.getEvaluator()
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+
+	resp := &FilteringEvaluateResponse{}
+	if err := evaluator.ValidateFilterExpression(expression); err != nil {
+		resp.CompileError = err.Error()
+		return resp, nil
+	}
+
+	start := time.Now()
+	matched, trace, err := evaluator.EvaluateFilterWithTrace(ctx, expression, req.Event.toEnvelope())
+	resp.EvaluationTimeMS = evaluationTimeMS(start)
+	resp.Trace = trace
+	if err != nil {
+		resp.Error = err.Error()
+		return resp, nil
+	}
+	resp.Matched = matched
+	return resp, nil
+}
+
+func (s *service) resolveFilteringExpression(ctx context.Context, req FilteringEvaluateRequest) (string, error) {
+	if req.Expression != nil && *req.Expression != "" {
+		return *req.Expression, nil
+	}
+	if req.RuleID == nil || *req.RuleID == "" {
+		return "", pkgerrors.ErrValidation.WithDetail("message", "either rule_id or expression is required")
+	}
+
+	rule, err := s.repo.GetFilteringRule(ctx, *req.RuleID)
+	if err != nil {
+		return "", s.handleNotFoundError(err, *req.RuleID)
+	}
+	if rule == nil {
+		return "", pkgerrors.ErrNotFound.WithDetail("id", *req.RuleID)
+	}
+	return rule.Expression, nil
+}
+
+// PlaygroundFilteringExpression is EvaluateFilteringRule's richer
+// counterpart for a rule-editor playground: alongside the match result, it
+// reports the expression's compiled output type, its estimated worst-case
+// cost, and - if it fails to compile - every issue cel-go reported with its
+// source position. An expression that fails AnalyzeFilterExpression never
+// reaches evaluation; one that compiles but would still be rejected by
+// ValidateFilterExpression (wrong output type, over the estimated-cost
+// ceiling) is reported the same way create/update would reject it, so a
+// playground result can't go green on an expression a real save would
+// refuse.
+func (s *service) PlaygroundFilteringExpression(ctx context.Context, req FilteringEvaluateRequest) (*PlaygroundResult, error) {
+	expression, err := s.resolveFilteringExpression(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	evaluator, err := s.getEvaluator()
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+
+	analysis := evaluator.AnalyzeFilterExpression(expression)
+	resp := &PlaygroundResult{
+		OutputType:    analysis.OutputType,
+		EstimatedCost: analysis.EstimatedCost,
+		Issues:        analysis.Issues,
+	}
+	if len(analysis.Issues) > 0 {
+		return resp, nil
+	}
+	if err := evaluator.ValidateFilterExpression(expression); err != nil {
+		resp.Error = err.Error()
+		return resp, nil
+	}
+
+	start := time.Now()
+	matched, err := evaluator.EvaluateFilter(ctx, expression, req.Event.toEnvelope())
+	resp.EvaluationTimeMS = evaluationTimeMS(start)
+	if err != nil {
+		resp.Error = err.Error()
+		return resp, nil
+	}
+	resp.Result = matched
+	return resp, nil
+}
+
+// DryRunFilteringRule evaluates expression against sampleEvents without
+// persisting anything, falling back to the stored sample corpus
+// (loadSampleCorpus) when sampleEvents is empty - the "caller-supplied
+// batch of sample events or a recent slice of production traffic" split
+// the chunk10-2 request asked for. When previousExpression is non-empty
+// (UpdateRule's dry-run path passes the rule's current Expression), the
+// result also reports how the candidate's match count compares to that
+// currently-active expression over the same sample set.
+func (s *service) DryRunFilteringRule(ctx context.Context, expression string, sampleEvents []SampleEvent, previousExpression string) (*DryRunResult, error) {
+	evaluator, err := s.getEvaluator()
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+	if err := evaluator.ValidateFilterExpression(expression); err != nil {
+		return nil, pkgerrors.ErrValidation.WithCause(err)
+	}
+
+	events := sampleEvents
+	if len(events) == 0 {
+		events, err = s.loadSampleCorpus(ctx)
+		if err != nil {
+			return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+		}
+	}
+
+	result := &DryRunResult{SampleCount: len(events)}
+	for _, event := range events {
+		matched, err := evaluator.EvaluateFilter(ctx, expression, event.toEnvelope())
+		er := DryRunEventResult{EventID: event.ID, Extractions: event.Payload}
+		if err != nil {
+			result.Errors++
+			er.Error = err.Error()
+		} else {
+			er.Matched = matched
+			if matched {
+				result.MatchCount++
+			}
+		}
+		result.Results = appendDryRunEventResult(result.Results, er)
+	}
+	if result.SampleCount > 0 {
+		result.MatchRate = float64(result.MatchCount) / float64(result.SampleCount)
+	}
+
+	if previousExpression != "" {
+		previousMatch := 0
+		for _, event := range events {
+			if matched, err := evaluator.EvaluateFilter(ctx, previousExpression, event.toEnvelope()); err == nil && matched {
+				previousMatch++
+			}
+		}
+		delta := result.MatchCount - previousMatch
+		result.PreviousMatchCount = &previousMatch
+		result.MatchDelta = &delta
+	}
+
+	return result, nil
+}
+
+func (s *service) EvaluateEnrichmentRule(ctx context.Context, req EnrichmentEvaluateRequest) (*EnrichmentEvaluateResponse, error) {
+	rule, err := s.resolveEnrichmentRuleSpec(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return s.evaluateEnrichmentRuleWithMocks(ctx, *rule, req.Event, req.MockSources), nil
+}
+
+func (s *service) resolveEnrichmentRuleSpec(ctx context.Context, req EnrichmentEvaluateRequest) (*EnrichmentRule, error) {
+	if req.Rule != nil {
+		return enrichmentRuleFromCreateRequest(*req.Rule), nil
+	}
+	if req.RuleID == nil || *req.RuleID == "" {
+		return nil, pkgerrors.ErrValidation.WithDetail("message", "either rule_id or rule is required")
+	}
+	if s.enrichmentRepo == nil {
+		return nil, pkgerrors.ErrInternal.WithDetail("message", "enrichment repository not configured")
+	}
+
+	rule, err := s.enrichmentRepo.GetEnrichmentRule(ctx, *req.RuleID)
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+	if rule == nil {
+		return nil, pkgerrors.ErrNotFound.WithDetail("id", *req.RuleID)
+	}
+	return rule, nil
+}
+
+// PlaygroundEnrichmentExpression evaluates a single enrichment expression -
+// an existing rule's Condition, or inline CEL that hasn't been saved to any
+// rule yet - against a sample event and mocked SourceData, without a live
+// provider fetch. It's PlaygroundFilteringExpression's enrichment
+// counterpart: the expression is analyzed in the transform environment (see
+// AnalyzeTransformExpression/ValidateTransformExpression) rather than the
+// filter one, since it may return any type, not just bool.
+func (s *service) PlaygroundEnrichmentExpression(ctx context.Context, req EnrichmentExpressionPlaygroundRequest) (*PlaygroundResult, error) {
+	expression, err := s.resolveEnrichmentExpression(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	evaluator, err := s.getEvaluator()
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+
+	analysis := evaluator.AnalyzeTransformExpression(expression)
+	resp := &PlaygroundResult{
+		OutputType:    analysis.OutputType,
+		EstimatedCost: analysis.EstimatedCost,
+		Issues:        analysis.Issues,
+	}
+	if len(analysis.Issues) > 0 {
+		return resp, nil
+	}
+	if err := evaluator.ValidateTransformExpression(expression); err != nil {
+		resp.Error = err.Error()
+		return resp, nil
+	}
+
+	start := time.Now()
+	result, err := evaluator.EvaluateTransform(ctx, expression, req.Event.toEnvelope(), req.SourceData)
+	resp.EvaluationTimeMS = evaluationTimeMS(start)
+	if err != nil {
+		resp.Error = err.Error()
+		return resp, nil
+	}
+	resp.Result = result
+	return resp, nil
+}
+
+// resolveEnrichmentExpression resolves req's RuleID/Expression to the CEL
+// source it names, mirroring resolveFilteringExpression: RuleID replays an
+// existing rule's Condition (the only enrichment expression guaranteed to
+// exist on every rule; a specific transformation's Expression isn't
+// individually addressable here, only reachable by supplying it inline).
+func (s *service) resolveEnrichmentExpression(ctx context.Context, req EnrichmentExpressionPlaygroundRequest) (string, error) {
+	if req.Expression != nil && *req.Expression != "" {
+		return *req.Expression, nil
+	}
+	if req.RuleID == nil || *req.RuleID == "" {
+		return "", pkgerrors.ErrValidation.WithDetail("message", "either rule_id or expression is required")
+	}
+	if s.enrichmentRepo == nil {
+		return "", pkgerrors.ErrInternal.WithDetail("message", "enrichment repository not configured")
+	}
+
+	rule, err := s.enrichmentRepo.GetEnrichmentRule(ctx, *req.RuleID)
+	if err != nil {
+		return "", pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+	if rule == nil {
+		return "", pkgerrors.ErrNotFound.WithDetail("id", *req.RuleID)
+	}
+	if rule.Condition == "" {
+		return "", pkgerrors.ErrValidation.WithDetail("message", "rule has no Condition to analyze; supply expression inline instead")
+	}
+	return rule.Condition, nil
+}
+
+func enrichmentRuleFromCreateRequest(req CreateEnrichmentRuleRequest) *EnrichmentRule {
+	return &EnrichmentRule{
+		Name:            req.Name,
+		FieldToEnrich:   req.FieldToEnrich,
+		SourceType:      req.SourceType,
+		SourceConfig:    req.SourceConfig,
+		Condition:       req.Condition,
+		Transformations: req.Transformations,
+		CacheTTLSeconds: req.CacheTTLSeconds,
+		ErrorHandling:   req.ErrorHandling,
+		FallbackValue:   req.FallbackValue,
+		Priority:        req.Priority,
+		Enabled:         getEnabledValue(req.Enabled),
+		Mode:            req.Mode,
+		CircuitBreaker:  req.CircuitBreaker,
+		Retry:           req.Retry,
+	}
+}
+
+// evaluateEnrichmentRule runs rule against event with no mock sources, i.e.
+// always resolving its source data from the live provider; see
+// evaluateEnrichmentRuleWithMocks's doc comment for the mocked counterpart
+// EvaluateEnrichmentRule's dry-run path uses instead.
+func (s *service) evaluateEnrichmentRule(ctx context.Context, rule EnrichmentRule, event SampleEvent) *EnrichmentEvaluateResponse {
+	return s.evaluateEnrichmentRuleWithMocks(ctx, rule, event, nil)
+}
+
+// evaluateEnrichmentRuleWithMocks is evaluateEnrichmentRule's mock-aware
+// counterpart: if mockSources has an entry for rule.SourceType, that canned
+// response stands in for dataProvider.Fetch so a dry run through
+// EvaluateEnrichmentRule never has to reach a real cache/http backend. A
+// source type absent from mockSources (including a nil map, DryRunEnrichmentRule's
+// case) still hits the live provider exactly as before.
+func (s *service) evaluateEnrichmentRuleWithMocks(ctx context.Context, rule EnrichmentRule, event SampleEvent, mockSources map[string]map[string]interface{}) *EnrichmentEvaluateResponse {
+	resp := &EnrichmentEvaluateResponse{
+		ResolvedProvider: resolveEnrichmentProviderName(rule.SourceType),
+	}
+
+	envelope := event.toEnvelope()
+	fieldValue, exists := envelope.GetPayloadField(rule.FieldToEnrich)
+	if !exists {
+		resp.Error = fmt.Sprintf("field %q not present in sample event payload", rule.FieldToEnrich)
+		return resp
+	}
+
+	mocked, isMocked := mockSources[rule.SourceType]
+	if !isMocked {
+		if _, ok := s.providers[resp.ResolvedProvider]; !ok {
+			resp.Error = fmt.Sprintf("provider %q not available for rule evaluation", resp.ResolvedProvider)
+			return resp
+		}
+	}
+
+	evaluator, err := s.getEvaluator()
+	if err != nil {
+		resp.Error = err.Error()
+		return resp
+	}
+
+	if rule.Condition != "" {
+		conditionMet, err := evaluator.EvaluateFilter(ctx, rule.Condition, envelope)
+		if err != nil {
+			resp.Error = fmt.Sprintf("condition: %s", err)
+			return resp
+		}
+		if !conditionMet {
+			resp.EnrichmentDiff = map[string]interface{}{}
+			return resp
+		}
+	}
+
+	var sourceData map[string]interface{}
+	start := time.Now()
+	if isMocked {
+		sourceData = mocked
+	} else {
+		sourceData, err = s.providers[resp.ResolvedProvider].Fetch(ctx, convertEnrichmentSourceConfig(rule.SourceConfig), fieldValue)
+	}
+	resp.EvaluationTimeMS = evaluationTimeMS(start)
+	if err != nil {
+		resp.Error = err.Error()
+		return resp
+	}
+
+	diff := make(map[string]interface{})
+	for _, trans := range rule.Transformations {
+		value, exists := getEnrichmentSourceFieldValue(trans.SourcePath, sourceData)
+		if !exists {
+			if trans.Default != nil {
+				diff[trans.TargetField] = trans.Default
+			}
+			continue
+		}
+
+		if trans.Expression == "" {
+			diff[trans.TargetField] = value
+			continue
+		}
+
+		transformed, err := evaluator.EvaluateTransform(ctx, trans.Expression, envelope, sourceData)
+		if err != nil {
+			resp.CompileError = err.Error()
+			continue
+		}
+		diff[trans.TargetField] = transformed
+	}
+
+	resp.Matched = len(diff) > 0
+	resp.EnrichmentDiff = diff
+	return resp
+}
+
+// DryRunEnrichmentRule is DryRunFilteringRule's enrichment counterpart: rule
+// is evaluated (condition + transformations, via evaluateEnrichmentRule)
+// against sampleEvents, falling back to the stored sample corpus when
+// sampleEvents is empty. When previous is non-nil (UpdateEnrichmentRule's
+// dry-run path passes the rule's currently-stored version), the result also
+// reports how rule's match count compares to previous's over the same
+// sample set.
+func (s *service) DryRunEnrichmentRule(ctx context.Context, rule EnrichmentRule, sampleEvents []SampleEvent, previous *EnrichmentRule) (*DryRunResult, error) {
+	events := sampleEvents
+	if len(events) == 0 {
+		var err error
+		events, err = s.loadSampleCorpus(ctx)
+		if err != nil {
+			return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+		}
+	}
+
+	result := &DryRunResult{SampleCount: len(events)}
+	for _, event := range events {
+		resp := s.evaluateEnrichmentRule(ctx, rule, event)
+		er := DryRunEventResult{EventID: event.ID, Matched: resp.Matched, Error: resp.Error, Extractions: resp.EnrichmentDiff}
+		if resp.Error != "" {
+			result.Errors++
+		} else if resp.Matched {
+			result.MatchCount++
+		}
+		result.Results = appendDryRunEventResult(result.Results, er)
+	}
+	if result.SampleCount > 0 {
+		result.MatchRate = float64(result.MatchCount) / float64(result.SampleCount)
+	}
+
+	if previous != nil {
+		previousMatch := 0
+		for _, event := range events {
+			resp := s.evaluateEnrichmentRule(ctx, *previous, event)
+			if resp.Error == "" && resp.Matched {
+				previousMatch++
+			}
+		}
+		delta := result.MatchCount - previousMatch
+		result.PreviousMatchCount = &previousMatch
+		result.MatchDelta = &delta
+	}
+
+	return result, nil
+}
+
+func (s *service) loadSampleCorpus(ctx context.Context) ([]SampleEvent, error) {
+	if s.sampleEventRepo == nil {
+		return nil, nil
+	}
+	return s.sampleEventRepo.ListRecentSampleEvents(ctx, constants.DefaultSampleCorpusSize)
+}
+
+// resolveEnrichmentProviderName mirrors enrichment.serviceImpl.resolveProviderName:
+// "database" sources are served by the mongodb provider and "redis" sources
+// by the cache provider; every other source type names its provider directly.
+func resolveEnrichmentProviderName(sourceType string) string {
+	if sourceType == constants.SourceTypeDatabase {
+		return constants.ProviderNameMongoDB
+	}
+	if sourceType == constants.SourceTypeRedis {
+		return constants.ProviderNameCache
+	}
+	return sourceType
+}
+
+func convertEnrichmentSourceConfig(cfg EnrichmentSourceConfig) provider.SourceConfig {
+	var query *provider.Query
+	if cfg.Query != nil {
+		query = provider.QueryFromMap(cfg.Query)
+	}
+
+	return provider.SourceConfig{
+		URL:        cfg.URL,
+		Method:     cfg.Method,
+		Headers:    cfg.Headers,
+		TimeoutMs:  cfg.TimeoutMs,
+		RetryCount: cfg.RetryCount,
+		Database:   cfg.Database,
+		Collection: cfg.Collection,
+		Query:      query,
+		Field:      cfg.Field,
+		KeyPattern: cfg.KeyPattern,
+		CacheType:  cfg.CacheType,
+
+		QueryParams:     cfg.QueryParams,
+		Body:            cfg.Body,
+		BodyContentType: cfg.BodyContentType,
+
+		AuthType:     cfg.AuthType,
+		AuthToken:    cfg.AuthToken,
+		AuthUsername: cfg.AuthUsername,
+		AuthPassword: cfg.AuthPassword,
+
+		ClientCertFile: cfg.ClientCertFile,
+		ClientKeyFile:  cfg.ClientKeyFile,
+		CAFile:         cfg.CAFile,
+		ServerName:     cfg.ServerName,
+
+		ResponseJSONPath: cfg.ResponseJSONPath,
+		Address:          cfg.Address,
+
+		KafkaBrokers: cfg.KafkaBrokers,
+		KafkaTopic:   cfg.KafkaTopic,
+
+		FilePath:   cfg.FilePath,
+		FileFormat: cfg.FileFormat,
+		KeyField:   cfg.KeyField,
+	}
+}
+
+func getEnrichmentSourceFieldValue(sourcePath string, sourceData map[string]interface{}) (interface{}, bool) {
+	if sourcePath == "." {
+		return sourceData, true
+	}
+	value, exists := sourceData[sourcePath]
+	return value, exists
+}
+
+func evaluationTimeMS(start time.Time) float64 {
+	return float64(time.Since(start).Microseconds()) / 1000.0
+}
+
+// RuleDryRunCondition is one top-level "&&"-joined clause of a stored
+// filtering rule's Expression, evaluated independently so
+// DryRunFilteringRuleByID can show an operator which clause(s) matched
+// rather than just the overall boolean. An expression with no top-level
+// "&&" (including one joined only by "||") reports a single clause
+// spanning the whole expression - splitting on "||" would misattribute a
+// per-clause false as "this clause is why the rule didn't match" when an
+// OR'd sibling might have.
+type RuleDryRunCondition struct {
+	Expression string `json:"expression"`
+	Matched    bool   `json:"matched"`
+	Error      string `json:"error,omitempty"`
+}
+
+// RuleDryRunResult is DryRunFilteringRuleByID's result: whether the stored
+// rule would match event, the action the runtime pipeline would then take
+// given the rule's Mode, and a trace of which top-level clause(s) of its
+// expression matched.
+type RuleDryRunResult struct {
+	Matched          bool                  `json:"matched"`
+	Action           string                `json:"action"`
+	EvaluationTimeMS float64               `json:"evaluation_time_ms"`
+	Trace            []RuleDryRunCondition `json:"trace"`
+	Error            string                `json:"error,omitempty"`
+}
+
+// dryRunAction reports what the runtime filtering pipeline would do with a
+// message this rule matched, given mode (see filtering.Rule.Mode/
+// validateFilteringRuleMode for the set of valid values) - "drop" for the
+// default enforce mode, "shadow_drop"/"canary_drop" for a rule that would
+// only ever log the decision rather than actually drop the message, or
+// "pass" when the rule didn't match at all.
+func dryRunAction(matched bool, mode string) string {
+	if !matched {
+		return "pass"
+	}
+	switch {
+	case mode == "" || mode == "enforce":
+		return "drop"
+	case mode == "shadow":
+		return "shadow_drop"
+	case strings.HasPrefix(mode, "canary:"):
+		return "canary_drop"
+	default:
+		return "drop"
+	}
+}
+
+// splitTopLevelAndClauses splits expression on "&&" operators that occur
+// outside of any parentheses/brackets/braces and outside any quoted string
+// literal, so e.g. "a && (b || c)" splits into ["a", "(b || c)"] but
+// "a && (b && c)" stays a single clause inside the parens. A malformed or
+// unbalanced expression (which ValidateFilterExpression would already have
+// rejected before a rule reached storage) just returns it unsplit.
+func splitTopLevelAndClauses(expression string) []string {
+	var clauses []string
+	depth := 0
+	var quote rune
+	start := 0
+
+	runes := []rune(expression)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+		case depth == 0 && c == '&' && i+1 < len(runes) && runes[i+1] == '&':
+			clauses = append(clauses, strings.TrimSpace(string(runes[start:i])))
+			i++
+			start = i + 1
+		}
+	}
+	clauses = append(clauses, strings.TrimSpace(string(runes[start:])))
+	return clauses
+}
+
+// DryRunFilteringRuleByID dry-runs ruleID's currently-stored Expression
+// against a single event without writing any audit record, reusing the
+// same cel.Evaluator the runtime pipeline and DryRunFilteringRule's
+// batch/corpus path share. Unlike DryRunFilteringRule (which dry-runs a
+// candidate expression against many sample events), this evaluates one
+// stored rule against one message and adds a per-clause trace plus the
+// action the runtime pipeline would take, for interactively debugging why
+// a particular message did or didn't match.
+func (s *service) DryRunFilteringRuleByID(ctx context.Context, ruleID string, event SampleEvent) (*RuleDryRunResult, error) {
+	rule, err := s.repo.GetFilteringRule(ctx, ruleID)
+	if err != nil {
+		return nil, s.handleNotFoundError(err, ruleID)
+	}
+	if rule == nil {
+		return nil, pkgerrors.ErrNotFound.WithDetail("id", ruleID)
+	}
+
+	evaluator, err := s.getEvaluator()
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+
+	envelope := event.toEnvelope()
+	result := &RuleDryRunResult{}
+
+	start := time.Now()
+	matched, err := evaluator.EvaluateFilter(ctx, rule.Expression, envelope)
+	result.EvaluationTimeMS = evaluationTimeMS(start)
+	if err != nil {
+		result.Error = err.Error()
+		result.Action = dryRunAction(false, rule.Mode)
+		return result, nil
+	}
+	result.Matched = matched
+	result.Action = dryRunAction(matched, rule.Mode)
+
+	for _, clause := range splitTopLevelAndClauses(rule.Expression) {
+		cond := RuleDryRunCondition{Expression: clause}
+		clauseMatched, err := evaluator.EvaluateFilter(ctx, clause, envelope)
+		if err != nil {
+			cond.Error = err.Error()
+		} else {
+			cond.Matched = clauseMatched
+		}
+		result.Trace = append(result.Trace, cond)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,217 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"yeti/internal/management/managementpb"
+	"yeti/pkg/errors"
+)
+
+// GRPCServer is management.Service's gRPC counterpart to Handler: both are
+// thin transports that convert their wire shape (gin.Context/managementpb
+// messages) to and from Service's typed requests and responses, so every
+// validation rule and audit-log write Service itself performs applies the
+// same way on either transport. It implements
+// managementpb.FilteringRulesServer and managementpb.AuditServer; register
+// it with a *grpc.Server via RegisterAll.
+//
+// Unlike Handler, GRPCServer has no per-call auth check yet - HTTP's
+// AuthMiddleware (see auth.go) resolves an API key into a tenant-scoped
+// context before Handler ever runs, and there's no equivalent grpc
+// interceptor reading an API key out of call metadata here. Every call
+// through GRPCServer today runs as DefaultTenantID, same as an
+// unauthenticated REST request. Add a UnaryServerInterceptor mirroring
+// AuthMiddleware before exposing this outside a trusted network.
+type GRPCServer struct {
+	Service Service
+}
+
+func NewGRPCServer(service Service) *GRPCServer {
+	return &GRPCServer{Service: service}
+}
+
+// RegisterAll registers every managementpb service this type implements
+// against s. Call once per grpc.Server, the same way Handler.RegisterRoutes
+// is called once per gin.Engine.
+func (g *GRPCServer) RegisterAll(s grpc.ServiceRegistrar) {
+	managementpb.RegisterFilteringRulesServer(s, g)
+	managementpb.RegisterAuditServer(s, g)
+}
+
+// toGRPCStatus is ToHTTPStatus's gRPC-status-code counterpart: it
+// classifies err via the same pkg/errors.Error.Code checks Handler.
+// HandleError's errors.ToProblemDetails uses, so both transports map a
+// given Service error to the same family of client-visible status.
+func toGRPCStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var code codes.Code
+	switch {
+	case errors.IsNotFound(err):
+		code = codes.NotFound
+	case errors.IsValidation(err):
+		code = codes.InvalidArgument
+	case errors.IsConflict(err):
+		code = codes.AlreadyExists
+	default:
+		code = codes.Internal
+	}
+
+	return status.Error(code, err.Error())
+}
+
+func (g *GRPCServer) ListFilteringRules(ctx context.Context, _ *managementpb.ListFilteringRulesRequest) (*managementpb.ListFilteringRulesResponse, error) {
+	rules, err := g.Service.ListFilteringRules(ctx)
+	if err != nil {
+		return nil, toGRPCStatus(err)
+	}
+
+	resp := &managementpb.ListFilteringRulesResponse{Rules: make([]managementpb.FilteringRule, len(rules))}
+	for i, r := range rules {
+		resp.Rules[i] = toPBFilteringRule(&r)
+	}
+	return resp, nil
+}
+
+func (g *GRPCServer) GetFilteringRule(ctx context.Context, in *managementpb.GetFilteringRuleRequest) (*managementpb.FilteringRule, error) {
+	rule, err := g.Service.GetFilteringRule(ctx, in.ID)
+	if err != nil {
+		return nil, toGRPCStatus(err)
+	}
+
+	pb := toPBFilteringRule(rule)
+	return &pb, nil
+}
+
+func (g *GRPCServer) CreateFilteringRule(ctx context.Context, in *managementpb.CreateFilteringRuleRequest) (*managementpb.FilteringRule, error) {
+	req := CreateFilteringRuleRequest{
+		Name:       in.Name,
+		Expression: in.Expression,
+		Priority:   int(in.Priority),
+		Mode:       in.Mode,
+	}
+	if in.EnabledSet {
+		enabled := in.Enabled
+		req.Enabled = &enabled
+	}
+
+	rule, err := g.Service.CreateFilteringRule(ctx, req)
+	if err != nil {
+		return nil, toGRPCStatus(err)
+	}
+
+	pb := toPBFilteringRule(rule)
+	return &pb, nil
+}
+
+func (g *GRPCServer) UpdateFilteringRule(ctx context.Context, in *managementpb.UpdateFilteringRuleRequest) (*managementpb.FilteringRule, error) {
+	var req UpdateFilteringRuleRequest
+	if in.NameSet {
+		name := in.Name
+		req.Name = &name
+	}
+	if in.ExpressionSet {
+		expression := in.Expression
+		req.Expression = &expression
+	}
+	if in.PrioritySet {
+		priority := int(in.Priority)
+		req.Priority = &priority
+	}
+	if in.EnabledSet {
+		enabled := in.Enabled
+		req.Enabled = &enabled
+	}
+	if in.ModeSet {
+		mode := in.Mode
+		req.Mode = &mode
+	}
+	if in.VersionSet {
+		version := int(in.Version)
+		req.Version = &version
+	}
+
+	rule, err := g.Service.UpdateFilteringRule(ctx, in.ID, req)
+	if err != nil {
+		return nil, toGRPCStatus(err)
+	}
+
+	pb := toPBFilteringRule(rule)
+	return &pb, nil
+}
+
+func (g *GRPCServer) DeleteFilteringRule(ctx context.Context, in *managementpb.DeleteFilteringRuleRequest) (*managementpb.DeleteFilteringRuleResponse, error) {
+	if err := g.Service.DeleteFilteringRule(ctx, in.ID); err != nil {
+		return nil, toGRPCStatus(err)
+	}
+	return &managementpb.DeleteFilteringRuleResponse{}, nil
+}
+
+func toPBFilteringRule(r *FilteringRule) managementpb.FilteringRule {
+	return managementpb.FilteringRule{
+		ID:         r.ID,
+		TenantID:   r.TenantID,
+		Name:       r.Name,
+		Expression: r.Expression,
+		Priority:   int32(r.Priority),
+		Enabled:    r.Enabled,
+		Mode:       r.Mode,
+		Version:    int32(r.Version),
+		CreatedAt:  r.CreatedAt.Format(time.RFC3339Nano),
+		UpdatedAt:  r.UpdatedAt.Format(time.RFC3339Nano),
+	}
+}
+
+func (g *GRPCServer) GetAuditLogs(ctx context.Context, in *managementpb.GetAuditLogsRequest) (*managementpb.GetAuditLogsResponse, error) {
+	var ruleID *string
+	if in.RuleIDSet {
+		ruleID = &in.RuleID
+	}
+
+	logs, err := g.Service.GetAuditLogs(ctx, ruleID, in.RuleType, int(in.Limit))
+	if err != nil {
+		return nil, toGRPCStatus(err)
+	}
+
+	resp := &managementpb.GetAuditLogsResponse{Logs: make([]managementpb.AuditLog, len(logs))}
+	for i, l := range logs {
+		resp.Logs[i] = toPBAuditLog(&l)
+	}
+	return resp, nil
+}
+
+func toPBAuditLog(l *AuditLog) managementpb.AuditLog {
+	pb := managementpb.AuditLog{
+		ID:           l.ID,
+		TenantID:     l.TenantID,
+		RuleType:     l.RuleType,
+		Action:       l.Action,
+		ChangedBy:    l.ChangedBy,
+		ChangeReason: l.ChangeReason,
+		IPAddress:    l.IPAddress,
+		Timestamp:    l.Timestamp.Format(time.RFC3339Nano),
+	}
+	if l.RuleID != nil {
+		pb.RuleID = *l.RuleID
+		pb.RuleIDSet = true
+	}
+	if len(l.OldValue) > 0 {
+		if b, err := json.Marshal(l.OldValue); err == nil {
+			pb.OldValueJSON = string(b)
+		}
+	}
+	if len(l.NewValue) > 0 {
+		if b, err := json.Marshal(l.NewValue); err == nil {
+			pb.NewValueJSON = string(b)
+		}
+	}
+	return pb
+}
@@ -1,15 +1,34 @@
 package management
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"yeti/internal/constants"
 	"yeti/internal/logger"
 	"yeti/pkg/errors"
+	"yeti/pkg/logging"
 )
 
+// ruleChangesPollInterval is how often WatchRuleChanges re-polls
+// GetRuleChangesSince between SSE frames. There's no LISTEN/NOTIFY or
+// message-bus push wired into VersioningRepository, so the watch endpoint
+// trades a little latency for staying entirely inside the existing
+// Postgres-backed rule_versions table rather than adding a new transport.
+const ruleChangesPollInterval = 2 * time.Second
+
+// BaseHandler is the REST transport's half of management.Service: it
+// converts gin.Context to and from Service's typed requests/responses and
+// errors.ToProblemDetails to an HTTP status, the same way GRPCServer (see
+// grpcserver.go) converts managementpb messages and errors.IsNotFound/
+// IsValidation/IsConflict to a gRPC status, so both surfaces share every
+// auth, validation and audit-log path Service itself implements.
 type BaseHandler struct {
 	Service Service
 	Logger  logger.Logger
@@ -18,10 +37,15 @@ type BaseHandler struct {
 func (h *BaseHandler) HandleError(c *gin.Context, err error) {
 	h.Logger.ErrorwCtx(c.Request.Context(), "Request error", "error", err, "path", c.Request.URL.Path)
 
-	status := errors.ToHTTPStatus(err)
-	response := errors.ToErrorResponse(err)
+	problem := errors.ToProblemDetails(err, logging.GetTraceID(c.Request.Context()))
+
+	body, marshalErr := json.Marshal(problem)
+	if marshalErr != nil {
+		c.Status(problem.Status)
+		return
+	}
 
-	c.JSON(status, response)
+	c.Data(problem.Status, "application/problem+json", body)
 }
 
 type Handler struct {
@@ -40,35 +64,76 @@ func NewHandler(service Service, log logger.Logger) *Handler {
 func (h *Handler) RegisterRoutes(router *gin.Engine) {
 	v1 := router.Group("/api/v1")
 	{
-		rules := v1.Group("/rules/filtering")
+		rulesRead := v1.Group("/rules/filtering", RequireScope(ScopeRulesRead))
 		{
-			rules.GET("", h.ListRules)
-			rules.POST("", h.CreateRule)
-			rules.GET("/:id", h.GetRule)
-			rules.PUT("/:id", h.UpdateRule)
-			rules.DELETE("/:id", h.DeleteRule)
-			rules.GET("/:id/versions", h.GetRuleVersions)
-			rules.GET("/:id/audit", h.GetRuleAuditLogs)
+			rulesRead.GET("", h.ListRules)
+			rulesRead.GET("/:id", h.GetRule)
+			rulesRead.GET("/:id/versions", h.GetRuleVersions)
+			rulesRead.GET("/:id/versions/:version", h.GetRuleVersion)
+			rulesRead.GET("/:id/versions/:versionA/diff/:versionB", h.DiffRuleVersions)
+			rulesRead.GET("/:id/versions/:versionA/patch/:versionB", h.GetRuleVersionPatch)
+			rulesRead.GET("/:id/timeline", h.GetRuleVersionTimeline)
+			rulesRead.GET("/:id/audit", h.GetRuleAuditLogs)
+			rulesRead.GET("/:id/history", h.GetRuleHistory)
+			rulesRead.GET("/:id/schedule", h.GetRuleSchedule)
+			rulesRead.POST("/evaluate", h.EvaluateRule)
+			rulesRead.POST("/dry-run", h.PlaygroundRule)
 		}
 
-		audit := v1.Group("/audit")
+		rulesWrite := v1.Group("/rules/filtering", RequireScope(ScopeRulesWrite))
+		{
+			rulesWrite.POST("", h.CreateRule)
+			rulesWrite.PUT("/:id", h.UpdateRule)
+			rulesWrite.PATCH("/:id", h.PatchRule)
+			rulesWrite.PUT("/:id/schedule", h.UpdateRuleSchedule)
+			rulesWrite.DELETE("/:id", h.DeleteRule)
+			rulesWrite.POST("/:id/versions/:version/rollback", h.RollbackRuleVersion)
+			rulesWrite.POST("/:id/versions/:version/restore", h.RestoreRuleVersion)
+			rulesWrite.POST("/:id/rollback/:auditId", h.RollbackRule)
+			rulesWrite.POST("/batch", h.ApplyFilteringRuleBatch)
+			rulesWrite.POST("/:id/dry-run", h.DryRunRuleByID)
+		}
+
+		audit := v1.Group("/audit", RequireScope(ScopeAuditRead))
 		{
 			audit.GET("/logs", h.GetAuditLogs)
 		}
+
+		deadLetters := v1.Group("/dead-letters")
+		{
+			deadLetters.GET("", RequireScope(ScopeAuditRead), h.ListDeadLettered)
+			deadLetters.POST("/replay", RequireScope(ScopeConfigWrite), h.ReplayDeadLetters)
+		}
+
+		changes := v1.Group("/rules/changes", RequireScope(ScopeRulesRead))
+		{
+			changes.GET("", h.GetRuleChanges)
+			changes.GET("/watch", h.WatchRuleChanges)
+		}
+
+		changeSets := v1.Group("/rules/changesets", RequireScope(ScopeRulesWrite))
+		{
+			changeSets.POST("", h.ApplyRuleChangeSet)
+		}
 	}
 }
 
 // ListRules godoc
 // @Summary      List all filtering rules
-// @Description  Get a list of all filtering rules
+// @Description  Get a list of all filtering rules for the calling tenant. Pass include_global=true to also inherit DefaultTenantID's rules (a tenant rule shadows a global one of the same name).
 // @Tags         filtering-rules
 // @Accept       json
 // @Produce      json
+// @Param        include_global  query  bool  false  "also inherit global (DefaultTenantID) rules"
 // @Success      200  {array}    FilteringRule
 // @Failure      500  {object}  errors.ErrorResponse
 // @Router       /rules/filtering [get]
 func (h *Handler) ListRules(c *gin.Context) {
-	rules, err := h.Service.ListFilteringRules(c.Request.Context())
+	ctx := c.Request.Context()
+	if c.Query("include_global") == "true" {
+		ctx = ContextWithIncludeGlobal(ctx)
+	}
+	rules, err := h.Service.ListFilteringRules(ctx)
 	if err != nil {
 		h.HandleError(c, err)
 		return
@@ -82,8 +147,10 @@ func (h *Handler) ListRules(c *gin.Context) {
 // @Tags         filtering-rules
 // @Accept       json
 // @Produce      json
-// @Param        rule  body       CreateFilteringRuleRequest  true  "Filtering rule data"
+// @Param        rule     body       CreateFilteringRuleRequest  true   "Filtering rule data"
+// @Param        dry_run  query      bool                        false  "Evaluate the rule against the sample corpus instead of creating it"
 // @Success      201   {object}   FilteringRule
+// @Success      200   {object}   DryRunResult  "when dry_run=true"
 // @Failure      400   {object}  errors.ErrorResponse
 // @Failure      409   {object}  errors.ErrorResponse
 // @Failure      500   {object}  errors.ErrorResponse
@@ -91,7 +158,17 @@ func (h *Handler) ListRules(c *gin.Context) {
 func (h *Handler) CreateRule(c *gin.Context) {
 	var req CreateFilteringRuleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, errors.ToErrorResponse(errors.ErrValidation.WithCause(err)))
+		h.HandleError(c, errors.ErrValidation.WithCause(err))
+		return
+	}
+
+	if c.Query("dry_run") == "true" {
+		result, err := h.Service.DryRunFilteringRule(c.Request.Context(), req.Expression, req.SampleEvents, "")
+		if err != nil {
+			h.HandleError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, result)
 		return
 	}
 
@@ -132,18 +209,42 @@ func (h *Handler) GetRule(c *gin.Context) {
 // @Tags         filtering-rules
 // @Accept       json
 // @Produce      json
-// @Param        id    path      string                      true  "Rule ID"
-// @Param        rule  body       UpdateFilteringRuleRequest  true  "Updated rule data"
+// @Param        id       path      string                      true   "Rule ID"
+// @Param        rule     body       UpdateFilteringRuleRequest  true   "Updated rule data"
+// @Param        dry_run  query     bool                        false  "Evaluate the updated rule against the sample corpus instead of applying it"
 // @Success      200   {object}   FilteringRule
+// @Success      200   {object}   DryRunResult  "when dry_run=true"
 // @Failure      400   {object}  errors.ErrorResponse
 // @Failure      404   {object}  errors.ErrorResponse
+// @Failure      409   {object}  errors.ErrorResponse  "version conflict; response includes current_version"
 // @Failure      500   {object}  errors.ErrorResponse
 // @Router       /rules/filtering/{id} [put]
 func (h *Handler) UpdateRule(c *gin.Context) {
 	id := c.Param("id")
 	var req UpdateFilteringRuleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, errors.ToErrorResponse(errors.ErrValidation.WithCause(err)))
+		h.HandleError(c, errors.ErrValidation.WithCause(err))
+		return
+	}
+
+	if c.Query("dry_run") == "true" {
+		existing, err := h.Service.GetFilteringRule(c.Request.Context(), id)
+		if err != nil {
+			h.HandleError(c, err)
+			return
+		}
+
+		expression := existing.Expression
+		if req.Expression != nil {
+			expression = *req.Expression
+		}
+
+		result, err := h.Service.DryRunFilteringRule(c.Request.Context(), expression, req.SampleEvents, existing.Expression)
+		if err != nil {
+			h.HandleError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, result)
 		return
 	}
 
@@ -156,6 +257,117 @@ func (h *Handler) UpdateRule(c *gin.Context) {
 	c.JSON(http.StatusOK, rule)
 }
 
+// PatchRule godoc
+// @Summary      Partially update a filtering rule
+// @Description  Apply an RFC 6902 JSON Patch (Content-Type application/json-patch+json) or an RFC 7396 JSON Merge Patch (application/merge-patch+json) to a filtering rule
+// @Tags         filtering-rules
+// @Accept       json-patch+json
+// @Produce      json
+// @Param        id   path      string  true  "Rule ID"
+// @Success      200  {object}  FilteringRule
+// @Failure      400  {object}  errors.ErrorResponse
+// @Failure      404  {object}  errors.ErrorResponse
+// @Failure      422  {object}  errors.ErrorResponse  "patch touches an immutable field; response includes the offending JSON pointer"
+// @Failure      500  {object}  errors.ErrorResponse
+// @Router       /rules/filtering/{id} [patch]
+func (h *Handler) PatchRule(c *gin.Context) {
+	id := c.Param("id")
+
+	existing, err := h.Service.GetFilteringRule(c.Request.Context(), id)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		h.HandleError(c, errors.ErrValidation.WithCause(err))
+		return
+	}
+
+	patched, err := ApplyPatch(existing, c.ContentType(), body)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	var req UpdateFilteringRuleRequest
+	if err := decodeInto(patched, &req); err != nil {
+		h.HandleError(c, errors.ErrValidation.WithCause(err))
+		return
+	}
+
+	ctx := withPatchDocument(c.Request.Context(), body)
+	rule, err := h.Service.UpdateFilteringRule(ctx, id, req)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// GetRuleSchedule godoc
+// @Summary      Get a filtering rule's activation schedule
+// @Description  Get the Schedule driving scheduler.Scheduler's automatic enable/disable of this rule, if any
+// @Tags         filtering-rules
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "Rule ID"
+// @Success      200  {object}  Schedule
+// @Failure      404  {object}  errors.ErrorResponse
+// @Failure      500  {object}  errors.ErrorResponse
+// @Router       /rules/filtering/{id}/schedule [get]
+func (h *Handler) GetRuleSchedule(c *gin.Context) {
+	id := c.Param("id")
+
+	sched, err := h.Service.GetFilteringRuleSchedule(c.Request.Context(), id)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, sched)
+}
+
+// UpdateRuleSchedule godoc
+// @Summary      Replace a filtering rule's activation schedule
+// @Description  Replace (or, with an empty/null body, clear) the Schedule driving scheduler.Scheduler's automatic enable/disable of this rule
+// @Tags         filtering-rules
+// @Accept       json
+// @Produce      json
+// @Param        id        path      string    true  "Rule ID"
+// @Param        schedule  body      Schedule  false "New schedule; omit or send null to clear"
+// @Success      200       {object}  FilteringRule
+// @Failure      400       {object}  errors.ErrorResponse
+// @Failure      404       {object}  errors.ErrorResponse
+// @Failure      500       {object}  errors.ErrorResponse
+// @Router       /rules/filtering/{id}/schedule [put]
+func (h *Handler) UpdateRuleSchedule(c *gin.Context) {
+	id := c.Param("id")
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		h.HandleError(c, errors.ErrValidation.WithCause(err))
+		return
+	}
+
+	var sched *Schedule
+	if trimmed := strings.TrimSpace(string(body)); trimmed != "" && trimmed != "null" {
+		sched = &Schedule{}
+		if err := json.Unmarshal(body, sched); err != nil {
+			h.HandleError(c, errors.ErrValidation.WithCause(err))
+			return
+		}
+	}
+
+	rule, err := h.Service.UpdateFilteringRuleSchedule(c.Request.Context(), id, sched)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rule)
+}
+
 // DeleteRule godoc
 // @Summary      Delete a filtering rule
 // @Description  Delete a filtering rule by ID
@@ -199,6 +411,196 @@ func (h *Handler) GetRuleVersions(c *gin.Context) {
 	c.JSON(http.StatusOK, versions)
 }
 
+// GetRuleVersion godoc
+// @Summary      Get a specific rule version
+// @Description  Get a single numbered version of a filtering rule
+// @Tags         filtering-rules
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string  true  "Rule ID"
+// @Param        version  path      int     true  "Version number"
+// @Success      200      {object}  RuleVersion
+// @Failure      400      {object}  errors.ErrorResponse
+// @Failure      404      {object}  errors.ErrorResponse
+// @Failure      500      {object}  errors.ErrorResponse
+// @Router       /rules/filtering/{id}/versions/{version} [get]
+func (h *Handler) GetRuleVersion(c *gin.Context) {
+	id := c.Param("id")
+	version, err := parseVersionParam(c, "version")
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	rv, err := h.Service.GetRuleVersion(c.Request.Context(), id, version)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rv)
+}
+
+// DiffRuleVersions godoc
+// @Summary      Diff two rule versions
+// @Description  Get the field-level differences between two numbered versions of a filtering rule
+// @Tags         filtering-rules
+// @Accept       json
+// @Produce      json
+// @Param        id        path      string  true  "Rule ID"
+// @Param        versionA  path      int     true  "First version number"
+// @Param        versionB  path      int     true  "Second version number"
+// @Success      200       {array}   FieldDiff
+// @Failure      400       {object}  errors.ErrorResponse
+// @Failure      404       {object}  errors.ErrorResponse
+// @Failure      500       {object}  errors.ErrorResponse
+// @Router       /rules/filtering/{id}/versions/{versionA}/diff/{versionB} [get]
+func (h *Handler) DiffRuleVersions(c *gin.Context) {
+	id := c.Param("id")
+	versionA, err := parseVersionParam(c, "versionA")
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+	versionB, err := parseVersionParam(c, "versionB")
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	diffs, err := h.Service.DiffRuleVersions(c.Request.Context(), id, versionA, versionB)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, diffs)
+}
+
+// RollbackRuleVersion godoc
+// @Summary      Roll back a filtering rule to a specific version
+// @Description  Restore a filtering rule to the state captured in a numbered version
+// @Tags         filtering-rules
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string  true  "Rule ID"
+// @Param        version  path      int     true  "Version number to restore"
+// @Success      200      {object}  FilteringRule
+// @Failure      400      {object}  errors.ErrorResponse
+// @Failure      404      {object}  errors.ErrorResponse
+// @Failure      500      {object}  errors.ErrorResponse
+// @Router       /rules/filtering/{id}/versions/{version}/rollback [post]
+func (h *Handler) RollbackRuleVersion(c *gin.Context) {
+	id := c.Param("id")
+	version, err := parseVersionParam(c, "version")
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	if err := h.Service.RollbackRuleToVersion(c.Request.Context(), "filtering", id, version, getChangedByHeader(c)); err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	rule, err := h.Service.GetFilteringRule(c.Request.Context(), id)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rule)
+}
+
+// GetRuleVersionPatch godoc
+// @Summary      Diff two rule versions as a JSON Patch
+// @Description  Get the RFC 6902 JSON Patch between two numbered versions of a filtering rule
+// @Tags         filtering-rules
+// @Accept       json
+// @Produce      json
+// @Param        id        path      string  true  "Rule ID"
+// @Param        versionA  path      int     true  "First version number"
+// @Param        versionB  path      int     true  "Second version number"
+// @Success      200       {object}  RuleDiff
+// @Failure      400       {object}  errors.ErrorResponse
+// @Failure      404       {object}  errors.ErrorResponse
+// @Failure      500       {object}  errors.ErrorResponse
+// @Router       /rules/filtering/{id}/versions/{versionA}/patch/{versionB} [get]
+func (h *Handler) GetRuleVersionPatch(c *gin.Context) {
+	id := c.Param("id")
+	versionA, err := parseVersionParam(c, "versionA")
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+	versionB, err := parseVersionParam(c, "versionB")
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	diff, err := h.Service.GetRuleVersionPatch(c.Request.Context(), id, versionA, versionB)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, diff)
+}
+
+// GetRuleVersionTimeline godoc
+// @Summary      Get a rule's version timeline
+// @Description  Get a compact version-by-version summary of a filtering rule's history, newest first
+// @Tags         filtering-rules
+// @Accept       json
+// @Produce      json
+// @Param        id     path      string  true   "Rule ID"
+// @Param        since  query     string  false  "Only include versions created at or after this RFC3339 timestamp"
+// @Success      200    {array}   VersionSummary
+// @Failure      400    {object}  errors.ErrorResponse
+// @Failure      500    {object}  errors.ErrorResponse
+// @Router       /rules/filtering/{id}/timeline [get]
+func (h *Handler) GetRuleVersionTimeline(c *gin.Context) {
+	id := c.Param("id")
+	since, err := parseSince(c.Query("since"))
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	timeline, err := h.Service.GetRuleVersionTimeline(c.Request.Context(), id, since)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, timeline)
+}
+
+// RestoreRuleVersion godoc
+// @Summary      Restore a filtering rule to a specific version
+// @Description  Atomically roll back a filtering rule to a numbered version, recording a new version and a "rollback" audit entry rather than rewriting history
+// @Tags         filtering-rules
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string  true  "Rule ID"
+// @Param        version  path      int     true  "Version number to restore"
+// @Success      200      {object}  RuleVersion
+// @Failure      400      {object}  errors.ErrorResponse
+// @Failure      404      {object}  errors.ErrorResponse
+// @Failure      500      {object}  errors.ErrorResponse
+// @Router       /rules/filtering/{id}/versions/{version}/restore [post]
+func (h *Handler) RestoreRuleVersion(c *gin.Context) {
+	id := c.Param("id")
+	version, err := parseVersionParam(c, "version")
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	rv, err := h.Service.RestoreRuleVersion(c.Request.Context(), "filtering", id, version, getChangedByHeader(c))
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rv)
+}
+
 // GetRuleAuditLogs godoc
 // @Summary      Get audit logs for a rule
 // @Description  Get audit logs for a specific filtering rule
@@ -253,70 +655,488 @@ func (h *Handler) GetAuditLogs(c *gin.Context) {
 	c.JSON(http.StatusOK, logs)
 }
 
-func parseLimit(limitStr string) int {
-	if limitStr == "" {
-		return constants.DefaultLimit
-	}
-	parsed, err := strconv.Atoi(limitStr)
-	if err != nil || parsed <= 0 || parsed > constants.MaxLimit {
-		return constants.DefaultLimit
-	}
-	return parsed
-}
-
-type EnrichmentHandler struct {
-	BaseHandler
-}
-
-func NewEnrichmentHandler(service Service, log logger.Logger) *EnrichmentHandler {
-	return &EnrichmentHandler{
-		BaseHandler: BaseHandler{
-			Service: service,
-			Logger:  log,
-		},
+// ListDeadLettered godoc
+// @Summary      List dead-lettered messages
+// @Description  List messages that exhausted their retry policy and were sent to a DLQ, newest first
+// @Tags         dead-letters
+// @Accept       json
+// @Produce      json
+// @Param        service     query     string  false  "Filter by the service that dead-lettered the message (e.g. filtering-service)"
+// @Param        unreplayed  query     bool    false  "Only return entries that haven't been replayed yet"
+// @Param        limit       query     int     false  "Maximum number of entries to return (1-1000)" default(100)
+// @Success      200  {array}   DeadLetterEntry
+// @Failure      500  {object}  errors.ErrorResponse
+// @Router       /dead-letters [get]
+func (h *Handler) ListDeadLettered(c *gin.Context) {
+	filter := DeadLetterFilter{
+		ServiceName: c.Query("service"),
+		Unreplayed:  c.Query("unreplayed") == "true",
+		Limit:       parseLimit(c.Query("limit")),
 	}
-}
 
-func (h *EnrichmentHandler) RegisterEnrichmentRoutes(router *gin.Engine) {
-	v1 := router.Group("/api/v1")
-	{
-		rules := v1.Group("/rules/enrichment")
-		{
-			rules.GET("", h.ListEnrichmentRules)
-			rules.POST("", h.CreateEnrichmentRule)
-			rules.GET("/:id", h.GetEnrichmentRule)
-			rules.PUT("/:id", h.UpdateEnrichmentRule)
-			rules.DELETE("/:id", h.DeleteEnrichmentRule)
-		}
+	entries, err := h.Service.ListDeadLettered(c.Request.Context(), filter)
+	if err != nil {
+		h.HandleError(c, err)
+		return
 	}
+	c.JSON(http.StatusOK, entries)
 }
 
-// ListEnrichmentRules godoc
-// @Summary      List all enrichment rules
-// @Description  Get a list of all enrichment rules
-// @Tags         enrichment-rules
+// ReplayDeadLetters godoc
+// @Summary      Replay dead-lettered messages
+// @Description  Republish one or more dead-lettered messages, all to the request's target topic or, when none is given, each to the topic it originally failed on. Every id is attempted independently; see ReplayResult for the per-id outcome
+// @Tags         dead-letters
 // @Accept       json
 // @Produce      json
-// @Success      200  {array}    EnrichmentRule
-// @Failure      500  {object}  errors.ErrorResponse
-// @Router       /rules/enrichment [get]
-func (h *EnrichmentHandler) ListEnrichmentRules(c *gin.Context) {
-	rules, err := h.Service.ListEnrichmentRules(c.Request.Context())
+// @Param        replay  body      ReplayDeadLettersRequest  true  "IDs to replay and optional target topic"
+// @Success      200     {object}  ReplayResult
+// @Failure      400     {object}  errors.ErrorResponse
+// @Failure      500     {object}  errors.ErrorResponse
+// @Router       /dead-letters/replay [post]
+func (h *Handler) ReplayDeadLetters(c *gin.Context) {
+	var req ReplayDeadLettersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.HandleError(c, errors.ErrValidation.WithCause(err))
+		return
+	}
+
+	result, err := h.Service.Replay(c.Request.Context(), req.IDs, req.TargetTopic)
 	if err != nil {
 		h.HandleError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, rules)
+	c.JSON(http.StatusOK, result)
 }
 
-// CreateEnrichmentRule godoc
-// @Summary      Create a new enrichment rule
-// @Description  Create a new enrichment rule with the provided data
-// @Tags         enrichment-rules
+// GetRuleHistory godoc
+// @Summary      Get rule change history
+// @Description  Get the audit log history for a specific filtering rule, newest first
+// @Tags         filtering-rules
+// @Accept       json
+// @Produce      json
+// @Param        id     path      string  true   "Rule ID"
+// @Param        limit  query     int     false  "Maximum number of entries to return (1-1000)" default(100)
+// @Success      200    {array}   AuditLog
+// @Failure      500    {object}  errors.ErrorResponse
+// @Router       /rules/filtering/{id}/history [get]
+func (h *Handler) GetRuleHistory(c *gin.Context) {
+	id := c.Param("id")
+	limit := parseLimit(c.Query("limit"))
+
+	history, err := h.Service.ListRuleHistory(c.Request.Context(), "filtering", id, limit)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
+
+// RollbackRule godoc
+// @Summary      Roll back a filtering rule
+// @Description  Restore a filtering rule to the state captured in a prior audit entry
+// @Tags         filtering-rules
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string  true  "Rule ID"
+// @Param        auditId  path      string  true  "Audit entry ID to restore"
+// @Success      200      {object}  FilteringRule
+// @Failure      400      {object}  errors.ErrorResponse
+// @Failure      404      {object}  errors.ErrorResponse
+// @Failure      500      {object}  errors.ErrorResponse
+// @Router       /rules/filtering/{id}/rollback/{auditId} [post]
+func (h *Handler) RollbackRule(c *gin.Context) {
+	id := c.Param("id")
+	auditID := c.Param("auditId")
+
+	if err := h.Service.RollbackRule(c.Request.Context(), "filtering", id, auditID, getChangedByHeader(c)); err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	rule, err := h.Service.GetFilteringRule(c.Request.Context(), id)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rule)
+}
+
+// EvaluateRule godoc
+// @Summary      Evaluate a filtering rule against a sample event
+// @Description  Dry-run a stored or inline filtering expression against a sample event, returning the match result without changing any stored rule
+// @Tags         filtering-rules
 // @Accept       json
 // @Produce      json
-// @Param        rule  body       CreateEnrichmentRuleRequest  true  "Enrichment rule data"
+// @Param        request  body      FilteringEvaluateRequest  true  "Rule reference or inline expression, plus a sample event"
+// @Success      200      {object}  FilteringEvaluateResponse
+// @Failure      400      {object}  errors.ErrorResponse
+// @Failure      404      {object}  errors.ErrorResponse
+// @Failure      500      {object}  errors.ErrorResponse
+// @Router       /rules/filtering/evaluate [post]
+func (h *Handler) EvaluateRule(c *gin.Context) {
+	var req FilteringEvaluateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.HandleError(c, errors.ErrValidation.WithCause(err))
+		return
+	}
+
+	result, err := h.Service.EvaluateFilteringRule(c.Request.Context(), req)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// PlaygroundRule godoc
+// @Summary      Analyze and evaluate a filtering expression
+// @Description  Compile a stored or inline filtering expression against a sample event, returning its output type, estimated cost, any compile issues with source position, and - if it compiles - the match result, without changing any stored rule
+// @Tags         filtering-rules
+// @Accept       json
+// @Produce      json
+// @Param        request  body      FilteringEvaluateRequest  true  "Rule reference or inline expression, plus a sample event"
+// @Success      200      {object}  PlaygroundResult
+// @Failure      400      {object}  errors.ErrorResponse
+// @Failure      404      {object}  errors.ErrorResponse
+// @Failure      500      {object}  errors.ErrorResponse
+// @Router       /rules/filtering/dry-run [post]
+func (h *Handler) PlaygroundRule(c *gin.Context) {
+	var req FilteringEvaluateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.HandleError(c, errors.ErrValidation.WithCause(err))
+		return
+	}
+
+	result, err := h.Service.PlaygroundFilteringExpression(c.Request.Context(), req)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func getChangedByHeader(c *gin.Context) string {
+	if userID := c.GetHeader("X-User-ID"); userID != "" {
+		return userID
+	}
+	return "system"
+}
+
+func parseLimit(limitStr string) int {
+	if limitStr == "" {
+		return constants.DefaultLimit
+	}
+	parsed, err := strconv.Atoi(limitStr)
+	if err != nil || parsed <= 0 || parsed > constants.MaxLimit {
+		return constants.DefaultLimit
+	}
+	return parsed
+}
+
+func parseVersionParam(c *gin.Context, param string) (int, error) {
+	version, err := strconv.Atoi(c.Param(param))
+	if err != nil {
+		return 0, errors.ErrValidation.WithDetail("message", fmt.Sprintf("%s must be an integer", param))
+	}
+	return version, nil
+}
+
+// parseSince parses an optional RFC3339 "since" query parameter, defaulting
+// to the zero time (i.e. no lower bound) when absent.
+func parseSince(sinceStr string) (time.Time, error) {
+	if sinceStr == "" {
+		return time.Time{}, nil
+	}
+	since, err := time.Parse(time.RFC3339, sinceStr)
+	if err != nil {
+		return time.Time{}, errors.ErrValidation.WithDetail("message", "since must be an RFC3339 timestamp")
+	}
+	return since, nil
+}
+
+// parseRevision parses an optional "since" query parameter as the int64
+// RuleVersion.Revision GetRuleChanges/WatchRuleChanges resume from,
+// defaulting to 0 (the beginning of the tenant's history) when absent.
+func parseRevision(revisionStr string) (int64, error) {
+	if revisionStr == "" {
+		return 0, nil
+	}
+	parsed, err := strconv.ParseInt(revisionStr, 10, 64)
+	if err != nil {
+		return 0, errors.ErrValidation.WithDetail("message", "since must be an integer revision")
+	}
+	return parsed, nil
+}
+
+// ApplyRuleChangeSet godoc
+// @Summary      Apply a batch of filtering/enrichment rule changes
+// @Description  Apply a ChangeSet of filtering and enrichment rule create/update/delete operations. The filtering half commits atomically in one transaction; the enrichment half applies sequentially afterward and is not atomic with it - see ChangeSetResult.EnrichmentApplied and ApplyRuleChangeSet's doc comment
+// @Tags         rule-changesets
+// @Accept       json
+// @Produce      json
+// @Param        changeSet  body      ChangeSet  true  "Rule operations to apply"
+// @Success      200        {object}  ChangeSetResult
+// @Failure      400        {object}  errors.ErrorResponse
+// @Failure      409        {object}  errors.ErrorResponse
+// @Failure      500        {object}  errors.ErrorResponse
+// @Router       /rules/changesets [post]
+func (h *Handler) ApplyRuleChangeSet(c *gin.Context) {
+	var cs ChangeSet
+	if err := c.ShouldBindJSON(&cs); err != nil {
+		h.HandleError(c, errors.ErrValidation.WithCause(err))
+		return
+	}
+
+	result, err := h.Service.ApplyRuleChangeSet(c.Request.Context(), cs)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// ApplyFilteringRuleBatch godoc
+// @Summary      Batch-apply filtering rule operations
+// @Description  Apply a batch of create/update/delete/enable/disable operations against filtering rules atomically - either all of them land or none do. See RuleBatchResult.Applied and ApplyFilteringRuleBatch's doc comment for how a partial failure is reported
+// @Tags         filtering-rules
+// @Accept       json
+// @Produce      json
+// @Param        request  body      RuleBatchRequest  true  "Operations to apply"
+// @Success      200      {object}  RuleBatchResult
+// @Failure      400      {object}  errors.ErrorResponse
+// @Failure      500      {object}  errors.ErrorResponse
+// @Router       /rules/filtering/batch [post]
+func (h *Handler) ApplyFilteringRuleBatch(c *gin.Context) {
+	var req RuleBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.HandleError(c, errors.ErrValidation.WithCause(err))
+		return
+	}
+
+	result, err := h.Service.ApplyFilteringRuleBatch(c.Request.Context(), req.Operations)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// DryRunRuleByID godoc
+// @Summary      Dry-run a stored filtering rule against a sample event
+// @Description  Evaluate a stored filtering rule's current expression against a single sample event without writing any audit record, returning the match result, the action the runtime pipeline would take, and a trace of which top-level clause(s) of the expression matched
+// @Tags         filtering-rules
+// @Accept       json
+// @Produce      json
+// @Param        id     path      string       true  "Rule ID"
+// @Param        event  body      SampleEvent  true  "Sample event"
+// @Success      200    {object}  RuleDryRunResult
+// @Failure      400    {object}  errors.ErrorResponse
+// @Failure      404    {object}  errors.ErrorResponse
+// @Failure      500    {object}  errors.ErrorResponse
+// @Router       /rules/filtering/{id}/dry-run [post]
+func (h *Handler) DryRunRuleByID(c *gin.Context) {
+	var event SampleEvent
+	if err := c.ShouldBindJSON(&event); err != nil {
+		h.HandleError(c, errors.ErrValidation.WithCause(err))
+		return
+	}
+
+	result, err := h.Service.DryRunFilteringRuleByID(c.Request.Context(), c.Param("id"), event)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// GetRuleChanges godoc
+// @Summary      Get rule changes since a revision
+// @Description  Poll for rule_versions rows written after the given revision, oldest first, across every rule. Pass the last returned entry's revision back as "since" to resume
+// @Tags         rule-changesets
+// @Accept       json
+// @Produce      json
+// @Param        since  query     int  false  "Only include changes with a revision greater than this"
+// @Param        limit  query     int  false  "Maximum number of changes to return (1-1000)" default(100)
+// @Success      200    {array}   RuleVersion
+// @Failure      400    {object}  errors.ErrorResponse
+// @Failure      500    {object}  errors.ErrorResponse
+// @Router       /rules/changes [get]
+func (h *Handler) GetRuleChanges(c *gin.Context) {
+	since, err := parseRevision(c.Query("since"))
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+	limit := parseLimit(c.Query("limit"))
+
+	versions, err := h.Service.GetRuleChangesSince(c.Request.Context(), since, limit)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, versions)
+}
+
+// WatchRuleChanges godoc
+// @Summary      Stream rule changes since a revision
+// @Description  Server-Sent Events stream of rule_versions rows written after the given revision, re-polling every few seconds for as long as the client stays connected. Each "change" event's data is a RuleVersion; reconnect with "since" set to the last revision seen to resume without gaps
+// @Tags         rule-changesets
+// @Produce      text/event-stream
+// @Param        since  query  int  false  "Start streaming changes with a revision greater than this"
+// @Param        limit  query  int  false  "Maximum changes per poll (1-1000)" default(100)
+// @Success      200
+// @Failure      400  {object}  errors.ErrorResponse
+// @Router       /rules/changes/watch [get]
+func (h *Handler) WatchRuleChanges(c *gin.Context) {
+	since, err := parseRevision(c.Query("since"))
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+	limit := parseLimit(c.Query("limit"))
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+
+	ctx := c.Request.Context()
+	ticker := time.NewTicker(ruleChangesPollInterval)
+	defer ticker.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+
+		versions, err := h.Service.GetRuleChangesSince(ctx, since, limit)
+		if err != nil {
+			c.SSEvent("error", err.Error())
+			return false
+		}
+		for _, v := range versions {
+			c.SSEvent("change", v)
+			since = v.Revision
+		}
+		return true
+	})
+}
+
+type EnrichmentHandler struct {
+	BaseHandler
+}
+
+func NewEnrichmentHandler(service Service, log logger.Logger) *EnrichmentHandler {
+	return &EnrichmentHandler{
+		BaseHandler: BaseHandler{
+			Service: service,
+			Logger:  log,
+		},
+	}
+}
+
+func (h *EnrichmentHandler) RegisterEnrichmentRoutes(router *gin.Engine) {
+	v1 := router.Group("/api/v1")
+	{
+		rulesRead := v1.Group("/rules/enrichment", RequireScope(ScopeRulesRead))
+		{
+			rulesRead.GET("", h.ListEnrichmentRules)
+			rulesRead.GET("/:id", h.GetEnrichmentRule)
+			rulesRead.GET("/:id/history", h.GetEnrichmentRuleHistory)
+			rulesRead.GET("/:id/audit", h.GetEnrichmentRuleAudit)
+			rulesRead.GET("/:id/versions/:versionA/diff/:versionB", h.DiffEnrichmentRuleVersions)
+			rulesRead.GET("/source-types", h.ListEnrichmentSourceTypes)
+			rulesRead.POST("/evaluate", h.EvaluateEnrichmentRule)
+			rulesRead.POST("/dry-run", h.PlaygroundEnrichmentExpression)
+		}
+
+		rulesWrite := v1.Group("/rules/enrichment", RequireScope(ScopeRulesWrite))
+		{
+			rulesWrite.POST("", h.CreateEnrichmentRule)
+			rulesWrite.PUT("/:id", h.UpdateEnrichmentRule)
+			rulesWrite.PATCH("/:id", h.PatchEnrichmentRule)
+			rulesWrite.DELETE("/:id", h.DeleteEnrichmentRule)
+			rulesWrite.POST("/:id/rollback/:auditId", h.RollbackEnrichmentRule)
+			rulesWrite.POST("/:id/versions/:version/restore", h.RestoreEnrichmentRuleVersion)
+			rulesWrite.POST("/batch", h.ApplyEnrichmentRuleBatch)
+		}
+	}
+}
+
+// ApplyEnrichmentRuleBatch godoc
+// @Summary      Batch-apply enrichment rule operations
+// @Description  Apply a batch of create/update/delete/enable/disable operations against enrichment rules. Unlike the filtering batch endpoint this isn't atomic - each op applies sequentially through the existing create/update/delete path, so a failure partway through leaves earlier ops committed; see RuleBatchResult.Applied and ApplyEnrichmentRuleBatch's doc comment
+// @Tags         enrichment-rules
+// @Accept       json
+// @Produce      json
+// @Param        request  body      RuleBatchRequest  true  "Operations to apply"
+// @Success      200      {object}  RuleBatchResult
+// @Failure      400      {object}  errors.ErrorResponse
+// @Failure      500      {object}  errors.ErrorResponse
+// @Router       /rules/enrichment/batch [post]
+func (h *EnrichmentHandler) ApplyEnrichmentRuleBatch(c *gin.Context) {
+	var req RuleBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.HandleError(c, errors.ErrValidation.WithCause(err))
+		return
+	}
+
+	result, err := h.Service.ApplyEnrichmentRuleBatch(c.Request.Context(), req.Operations)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// ListEnrichmentRules godoc
+// @Summary      List all enrichment rules
+// @Description  Get a list of all enrichment rules for the calling tenant. Pass include_global=true to also inherit DefaultTenantID's rules (a tenant rule shadows a global one of the same name).
+// @Tags         enrichment-rules
+// @Accept       json
+// @Produce      json
+// @Param        include_global  query  bool  false  "also inherit global (DefaultTenantID) rules"
+// @Success      200  {array}    EnrichmentRule
+// @Failure      500  {object}  errors.ErrorResponse
+// @Router       /rules/enrichment [get]
+func (h *EnrichmentHandler) ListEnrichmentRules(c *gin.Context) {
+	ctx := c.Request.Context()
+	if c.Query("include_global") == "true" {
+		ctx = ContextWithIncludeGlobal(ctx)
+	}
+	rules, err := h.Service.ListEnrichmentRules(ctx)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rules)
+}
+
+// ListEnrichmentSourceTypes godoc
+// @Summary      List allowed enrichment source types
+// @Description  Get every source_type EnrichmentRule currently accepts, including any registered via WithEnrichmentSourceRegistry/RegisterSourceTypeValidator
+// @Tags         enrichment-rules
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}    string
+// @Router       /rules/enrichment/source-types [get]
+func (h *EnrichmentHandler) ListEnrichmentSourceTypes(c *gin.Context) {
+	c.JSON(http.StatusOK, h.Service.ListEnrichmentSourceTypes(c.Request.Context()))
+}
+
+// CreateEnrichmentRule godoc
+// @Summary      Create a new enrichment rule
+// @Description  Create a new enrichment rule with the provided data
+// @Tags         enrichment-rules
+// @Accept       json
+// @Produce      json
+// @Param        rule     body       CreateEnrichmentRuleRequest  true   "Enrichment rule data"
+// @Param        dry_run  query      bool                         false  "Evaluate the rule against the sample corpus instead of creating it"
 // @Success      201   {object}   EnrichmentRule
+// @Success      200   {object}   DryRunResult  "when dry_run=true"
 // @Failure      400   {object}  errors.ErrorResponse
 // @Failure      409   {object}  errors.ErrorResponse
 // @Failure      500   {object}  errors.ErrorResponse
@@ -324,99 +1144,364 @@ func (h *EnrichmentHandler) ListEnrichmentRules(c *gin.Context) {
 func (h *EnrichmentHandler) CreateEnrichmentRule(c *gin.Context) {
 	var req CreateEnrichmentRuleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, errors.ToErrorResponse(errors.ErrValidation.WithCause(err)))
+		h.HandleError(c, errors.ErrValidation.WithCause(err))
+		return
+	}
+
+	if c.Query("dry_run") == "true" {
+		result, err := h.Service.DryRunEnrichmentRule(c.Request.Context(), *enrichmentRuleFromCreateRequest(req), req.SampleEvents, nil)
+		if err != nil {
+			h.HandleError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	rule, err := h.Service.CreateEnrichmentRule(c.Request.Context(), req)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// GetEnrichmentRule godoc
+// @Summary      Get an enrichment rule by ID
+// @Description  Get a specific enrichment rule by its ID
+// @Tags         enrichment-rules
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "Rule ID"
+// @Success      200  {object}   EnrichmentRule
+// @Failure      404  {object}  errors.ErrorResponse
+// @Failure      500  {object}  errors.ErrorResponse
+// @Router       /rules/enrichment/{id} [get]
+func (h *EnrichmentHandler) GetEnrichmentRule(c *gin.Context) {
+	id := c.Param("id")
+	rule, err := h.Service.GetEnrichmentRule(c.Request.Context(), id)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// UpdateEnrichmentRule godoc
+// @Summary      Update an enrichment rule
+// @Description  Update an existing enrichment rule by ID
+// @Tags         enrichment-rules
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                        true   "Rule ID"
+// @Param        rule     body       UpdateEnrichmentRuleRequest    true   "Updated rule data"
+// @Param        dry_run  query     bool                           false  "Evaluate the updated rule against the sample corpus instead of applying it"
+// @Success      200   {object}   EnrichmentRule
+// @Success      200   {object}   DryRunResult  "when dry_run=true"
+// @Failure      400   {object}  errors.ErrorResponse
+// @Failure      404   {object}  errors.ErrorResponse
+// @Failure      409   {object}  errors.ErrorResponse  "version conflict; response includes current_version"
+// @Failure      500   {object}  errors.ErrorResponse
+// @Router       /rules/enrichment/{id} [put]
+func (h *EnrichmentHandler) UpdateEnrichmentRule(c *gin.Context) {
+	id := c.Param("id")
+	var req UpdateEnrichmentRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.HandleError(c, errors.ErrValidation.WithCause(err))
+		return
+	}
+
+	if c.Query("dry_run") == "true" {
+		existing, err := h.Service.GetEnrichmentRule(c.Request.Context(), id)
+		if err != nil {
+			h.HandleError(c, err)
+			return
+		}
+		previous := *existing
+		applyEnrichmentRuleUpdate(existing, req)
+
+		result, err := h.Service.DryRunEnrichmentRule(c.Request.Context(), *existing, req.SampleEvents, &previous)
+		if err != nil {
+			h.HandleError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	rule, err := h.Service.UpdateEnrichmentRule(c.Request.Context(), id, req)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// PatchEnrichmentRule godoc
+// @Summary      Partially update an enrichment rule
+// @Description  Apply an RFC 6902 JSON Patch (Content-Type application/json-patch+json) or an RFC 7396 JSON Merge Patch (application/merge-patch+json) to an enrichment rule. Unlike PatchRule, the resulting audit entry (EnrichmentRuleAudit) still only records a before/after snapshot - see recordAudit in enrichment_repository*.go - since that trail has no patch-document field.
+// @Tags         enrichment-rules
+// @Accept       json-patch+json
+// @Produce      json
+// @Param        id   path      string  true  "Rule ID"
+// @Success      200  {object}  EnrichmentRule
+// @Failure      400  {object}  errors.ErrorResponse
+// @Failure      404  {object}  errors.ErrorResponse
+// @Failure      422  {object}  errors.ErrorResponse  "patch touches an immutable field; response includes the offending JSON pointer"
+// @Failure      500  {object}  errors.ErrorResponse
+// @Router       /rules/enrichment/{id} [patch]
+func (h *EnrichmentHandler) PatchEnrichmentRule(c *gin.Context) {
+	id := c.Param("id")
+
+	existing, err := h.Service.GetEnrichmentRule(c.Request.Context(), id)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		h.HandleError(c, errors.ErrValidation.WithCause(err))
+		return
+	}
+
+	patched, err := ApplyPatch(existing, c.ContentType(), body)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	var req UpdateEnrichmentRuleRequest
+	if err := decodeInto(patched, &req); err != nil {
+		h.HandleError(c, errors.ErrValidation.WithCause(err))
+		return
+	}
+
+	rule, err := h.Service.UpdateEnrichmentRule(c.Request.Context(), id, req)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteEnrichmentRule godoc
+// @Summary      Delete an enrichment rule
+// @Description  Delete an enrichment rule by ID
+// @Tags         enrichment-rules
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "Rule ID"
+// @Success      204  "No Content"
+// @Failure      404  {object}  errors.ErrorResponse
+// @Failure      500  {object}  errors.ErrorResponse
+// @Router       /rules/enrichment/{id} [delete]
+func (h *EnrichmentHandler) DeleteEnrichmentRule(c *gin.Context) {
+	id := c.Param("id")
+	err := h.Service.DeleteEnrichmentRule(c.Request.Context(), id)
+	if err != nil {
+		h.HandleError(c, err)
 		return
 	}
 
-	rule, err := h.Service.CreateEnrichmentRule(c.Request.Context(), req)
+	c.Status(http.StatusNoContent)
+}
+
+// GetEnrichmentRuleHistory godoc
+// @Summary      Get enrichment rule change history
+// @Description  Get the audit log history for a specific enrichment rule, newest first
+// @Tags         enrichment-rules
+// @Accept       json
+// @Produce      json
+// @Param        id     path      string  true   "Rule ID"
+// @Param        limit  query     int     false  "Maximum number of entries to return (1-1000)" default(100)
+// @Success      200    {array}   AuditLog
+// @Failure      500    {object}  errors.ErrorResponse
+// @Router       /rules/enrichment/{id}/history [get]
+func (h *EnrichmentHandler) GetEnrichmentRuleHistory(c *gin.Context) {
+	id := c.Param("id")
+	limit := parseLimit(c.Query("limit"))
+
+	history, err := h.Service.ListRuleHistory(c.Request.Context(), "enrichment", id, limit)
 	if err != nil {
-		if errors.IsValidation(err) {
-			response := errors.ToErrorResponse(err)
-			if err.Error() != "" {
-				response["message"] = err.Error()
-			}
-			c.JSON(http.StatusBadRequest, response)
-			return
-		}
 		h.HandleError(c, err)
 		return
 	}
-
-	c.JSON(http.StatusCreated, rule)
+	c.JSON(http.StatusOK, history)
 }
 
-// GetEnrichmentRule godoc
-// @Summary      Get an enrichment rule by ID
-// @Description  Get a specific enrichment rule by its ID
+// GetEnrichmentRuleAudit godoc
+// @Summary      Get an enrichment rule's repository-level audit trail
+// @Description  Get the create/update/delete history recorded by the configured enrichment storage driver for a specific enrichment rule, newest first. Unlike /history (which reads the shared Postgres versioning system), this reflects whichever driver management.enrichment_storage.driver selects.
 // @Tags         enrichment-rules
 // @Accept       json
 // @Produce      json
 // @Param        id   path      string  true  "Rule ID"
-// @Success      200  {object}   EnrichmentRule
-// @Failure      404  {object}  errors.ErrorResponse
+// @Success      200  {array}   EnrichmentRuleAudit
 // @Failure      500  {object}  errors.ErrorResponse
-// @Router       /rules/enrichment/{id} [get]
-func (h *EnrichmentHandler) GetEnrichmentRule(c *gin.Context) {
+// @Router       /rules/enrichment/{id}/audit [get]
+func (h *EnrichmentHandler) GetEnrichmentRuleAudit(c *gin.Context) {
 	id := c.Param("id")
-	rule, err := h.Service.GetEnrichmentRule(c.Request.Context(), id)
+	entries, err := h.Service.GetEnrichmentRuleAudit(c.Request.Context(), id)
 	if err != nil {
 		h.HandleError(c, err)
 		return
 	}
+	c.JSON(http.StatusOK, entries)
+}
+
+// RollbackEnrichmentRule godoc
+// @Summary      Roll back an enrichment rule
+// @Description  Restore an enrichment rule to the state captured in a prior audit entry
+// @Tags         enrichment-rules
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string  true  "Rule ID"
+// @Param        auditId  path      string  true  "Audit entry ID to restore"
+// @Success      200      {object}  EnrichmentRule
+// @Failure      400      {object}  errors.ErrorResponse
+// @Failure      404      {object}  errors.ErrorResponse
+// @Failure      500      {object}  errors.ErrorResponse
+// @Router       /rules/enrichment/{id}/rollback/{auditId} [post]
+func (h *EnrichmentHandler) RollbackEnrichmentRule(c *gin.Context) {
+	id := c.Param("id")
+	auditID := c.Param("auditId")
+
+	if err := h.Service.RollbackRule(c.Request.Context(), "enrichment", id, auditID, getChangedByHeader(c)); err != nil {
+		h.HandleError(c, err)
+		return
+	}
 
+	rule, err := h.Service.GetEnrichmentRule(c.Request.Context(), id)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
 	c.JSON(http.StatusOK, rule)
 }
 
-// UpdateEnrichmentRule godoc
-// @Summary      Update an enrichment rule
-// @Description  Update an existing enrichment rule by ID
+// DiffEnrichmentRuleVersions godoc
+// @Summary      Diff two versions of an enrichment rule
+// @Description  Get the field-level differences between two recorded versions of an enrichment rule
 // @Tags         enrichment-rules
 // @Accept       json
 // @Produce      json
-// @Param        id    path      string                        true  "Rule ID"
-// @Param        rule  body       UpdateEnrichmentRuleRequest    true  "Updated rule data"
-// @Success      200   {object}   EnrichmentRule
-// @Failure      400   {object}  errors.ErrorResponse
-// @Failure      404   {object}  errors.ErrorResponse
-// @Failure      500   {object}  errors.ErrorResponse
-// @Router       /rules/enrichment/{id} [put]
-func (h *EnrichmentHandler) UpdateEnrichmentRule(c *gin.Context) {
+// @Param        id        path      string  true  "Rule ID"
+// @Param        versionA  path      int     true  "First version number"
+// @Param        versionB  path      int     true  "Second version number"
+// @Success      200       {array}   FieldDiff
+// @Failure      400       {object}  errors.ErrorResponse
+// @Failure      404       {object}  errors.ErrorResponse
+// @Failure      500       {object}  errors.ErrorResponse
+// @Router       /rules/enrichment/{id}/versions/{versionA}/diff/{versionB} [get]
+func (h *EnrichmentHandler) DiffEnrichmentRuleVersions(c *gin.Context) {
 	id := c.Param("id")
-	var req UpdateEnrichmentRuleRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, errors.ToErrorResponse(errors.ErrValidation.WithCause(err)))
+	versionA, err := parseVersionParam(c, "versionA")
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+	versionB, err := parseVersionParam(c, "versionB")
+	if err != nil {
+		h.HandleError(c, err)
 		return
 	}
 
-	rule, err := h.Service.UpdateEnrichmentRule(c.Request.Context(), id, req)
+	diffs, err := h.Service.DiffEnrichmentRuleVersions(c.Request.Context(), id, versionA, versionB)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, diffs)
+}
+
+// RestoreEnrichmentRuleVersion godoc
+// @Summary      Restore an enrichment rule to a specific version
+// @Description  Atomically roll back an enrichment rule to a numbered version, recording a new version and a "rollback" audit entry rather than rewriting history. Recreates the rule with the same ID if it was since deleted.
+// @Tags         enrichment-rules
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string  true  "Rule ID"
+// @Param        version  path      int     true  "Version number to restore"
+// @Success      200      {object}  EnrichmentRule
+// @Failure      400      {object}  errors.ErrorResponse
+// @Failure      404      {object}  errors.ErrorResponse
+// @Failure      500      {object}  errors.ErrorResponse
+// @Router       /rules/enrichment/{id}/versions/{version}/restore [post]
+func (h *EnrichmentHandler) RestoreEnrichmentRuleVersion(c *gin.Context) {
+	id := c.Param("id")
+	version, err := parseVersionParam(c, "version")
 	if err != nil {
 		h.HandleError(c, err)
 		return
 	}
 
+	rule, err := h.Service.RollbackEnrichmentRule(c.Request.Context(), id, version, getChangedByHeader(c))
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
 	c.JSON(http.StatusOK, rule)
 }
 
-// DeleteEnrichmentRule godoc
-// @Summary      Delete an enrichment rule
-// @Description  Delete an enrichment rule by ID
+// EvaluateEnrichmentRule godoc
+// @Summary      Evaluate an enrichment rule against a sample event
+// @Description  Dry-run a stored or inline enrichment rule against a sample event, returning the resolved provider and the enrichment fields it would add, without changing any stored rule
 // @Tags         enrichment-rules
 // @Accept       json
 // @Produce      json
-// @Param        id   path      string  true  "Rule ID"
-// @Success      204  "No Content"
-// @Failure      404  {object}  errors.ErrorResponse
-// @Failure      500  {object}  errors.ErrorResponse
-// @Router       /rules/enrichment/{id} [delete]
-func (h *EnrichmentHandler) DeleteEnrichmentRule(c *gin.Context) {
-	id := c.Param("id")
-	err := h.Service.DeleteEnrichmentRule(c.Request.Context(), id)
+// @Param        request  body      EnrichmentEvaluateRequest  true  "Rule reference or inline rule spec, plus a sample event"
+// @Success      200      {object}  EnrichmentEvaluateResponse
+// @Failure      400      {object}  errors.ErrorResponse
+// @Failure      404      {object}  errors.ErrorResponse
+// @Failure      500      {object}  errors.ErrorResponse
+// @Router       /rules/enrichment/evaluate [post]
+func (h *EnrichmentHandler) EvaluateEnrichmentRule(c *gin.Context) {
+	var req EnrichmentEvaluateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.HandleError(c, errors.ErrValidation.WithCause(err))
+		return
+	}
+
+	result, err := h.Service.EvaluateEnrichmentRule(c.Request.Context(), req)
 	if err != nil {
 		h.HandleError(c, err)
 		return
 	}
 
-	c.Status(http.StatusNoContent)
+	c.JSON(http.StatusOK, result)
+}
+
+// PlaygroundEnrichmentExpression godoc
+// @Summary      Analyze and evaluate an enrichment expression
+// @Description  Compile a stored rule's Condition or an inline expression against a sample event and mocked source data, returning its output type, estimated cost, any compile issues with source position, and - if it compiles - the evaluated result, without a live provider fetch or changing any stored rule
+// @Tags         enrichment-rules
+// @Accept       json
+// @Produce      json
+// @Param        request  body      EnrichmentExpressionPlaygroundRequest  true  "Rule reference or inline expression, a sample event, and mocked source data"
+// @Success      200      {object}  PlaygroundResult
+// @Failure      400      {object}  errors.ErrorResponse
+// @Failure      404      {object}  errors.ErrorResponse
+// @Failure      500      {object}  errors.ErrorResponse
+// @Router       /rules/enrichment/dry-run [post]
+func (h *EnrichmentHandler) PlaygroundEnrichmentExpression(c *gin.Context) {
+	var req EnrichmentExpressionPlaygroundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.HandleError(c, errors.ErrValidation.WithCause(err))
+		return
+	}
+
+	result, err := h.Service.PlaygroundEnrichmentExpression(c.Request.Context(), req)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
 }
 
 type DeduplicationHandler struct {
@@ -437,9 +1522,13 @@ func (h *DeduplicationHandler) RegisterDeduplicationRoutes(router *gin.Engine) {
 	{
 		config := v1.Group("/config/deduplication")
 		{
-			config.GET("", h.GetDeduplicationConfig)
-			config.PUT("", h.UpdateDeduplicationConfig)
+			config.GET("", RequireScope(ScopeRulesRead), h.GetDeduplicationConfig)
+			config.PUT("", RequireScope(ScopeConfigWrite), h.UpdateDeduplicationConfig)
+			config.PATCH("", RequireScope(ScopeConfigWrite), h.PatchDeduplicationConfig)
+			config.GET("/versions", RequireScope(ScopeRulesRead), h.GetDeduplicationConfigVersions)
+			config.POST("/versions/:version/rollback", RequireScope(ScopeConfigWrite), h.RollbackDeduplicationConfig)
 		}
+		v1.POST("/dedup/preview", RequireScope(ScopeRulesRead), h.PreviewDeduplicationFingerprint)
 	}
 }
 
@@ -475,7 +1564,7 @@ func (h *DeduplicationHandler) GetDeduplicationConfig(c *gin.Context) {
 func (h *DeduplicationHandler) UpdateDeduplicationConfig(c *gin.Context) {
 	var req UpdateDeduplicationConfigRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, errors.ToErrorResponse(errors.ErrValidation.WithCause(err)))
+		h.HandleError(c, errors.ErrValidation.WithCause(err))
 		return
 	}
 
@@ -487,3 +1576,254 @@ func (h *DeduplicationHandler) UpdateDeduplicationConfig(c *gin.Context) {
 
 	c.JSON(http.StatusOK, config)
 }
+
+// PatchDeduplicationConfig godoc
+// @Summary      Partially update deduplication configuration
+// @Description  Apply an RFC 6902 JSON Patch (Content-Type application/json-patch+json) or an RFC 7396 JSON Merge Patch (application/merge-patch+json) to the deduplication service configuration
+// @Tags         deduplication
+// @Accept       json-patch+json
+// @Produce      json
+// @Success      200  {object}  DeduplicationConfig
+// @Failure      400  {object}  errors.ErrorResponse
+// @Failure      422  {object}  errors.ErrorResponse  "patch touches an immutable field; response includes the offending JSON pointer"
+// @Failure      500  {object}  errors.ErrorResponse
+// @Router       /config/deduplication [patch]
+func (h *DeduplicationHandler) PatchDeduplicationConfig(c *gin.Context) {
+	existing, err := h.Service.GetDeduplicationConfig(c.Request.Context())
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		h.HandleError(c, errors.ErrValidation.WithCause(err))
+		return
+	}
+
+	patched, err := ApplyPatch(existing, c.ContentType(), body)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	var req UpdateDeduplicationConfigRequest
+	if err := decodeInto(patched, &req); err != nil {
+		h.HandleError(c, errors.ErrValidation.WithCause(err))
+		return
+	}
+
+	ctx := withPatchDocument(c.Request.Context(), body)
+	config, err := h.Service.UpdateDeduplicationConfig(ctx, req)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, config)
+}
+
+// GetDeduplicationConfigVersions godoc
+// @Summary      List deduplication configuration versions
+// @Description  Get the version history of the deduplication service configuration
+// @Tags         deduplication
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}    RuleVersion
+// @Failure      500  {object}  errors.ErrorResponse
+// @Router       /config/deduplication/versions [get]
+func (h *DeduplicationHandler) GetDeduplicationConfigVersions(c *gin.Context) {
+	versions, err := h.Service.GetDeduplicationConfigVersions(c.Request.Context())
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, versions)
+}
+
+// RollbackDeduplicationConfig godoc
+// @Summary      Roll back deduplication configuration
+// @Description  Restore the deduplication service configuration to a previously recorded version
+// @Tags         deduplication
+// @Accept       json
+// @Produce      json
+// @Param        version  path      int  true  "Target version"
+// @Success      200      {object}  DeduplicationConfig
+// @Failure      400      {object}  errors.ErrorResponse
+// @Failure      404      {object}  errors.ErrorResponse
+// @Failure      500      {object}  errors.ErrorResponse
+// @Router       /config/deduplication/versions/{version}/rollback [post]
+func (h *DeduplicationHandler) RollbackDeduplicationConfig(c *gin.Context) {
+	version, err := parseVersionParam(c, "version")
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	config, err := h.Service.RollbackDeduplicationConfig(c.Request.Context(), version, getChangedByHeader(c))
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, config)
+}
+
+// PreviewDeduplicationFingerprint godoc
+// @Summary      Preview a deduplication fingerprint
+// @Description  Compute the deduplication fingerprint a sample event would produce, without writing anything to Redis
+// @Tags         deduplication
+// @Accept       json
+// @Produce      json
+// @Param        request  body      DeduplicationPreviewRequest  true  "Sample event and optional config override"
+// @Success      200      {object}  DeduplicationPreviewResponse
+// @Failure      400      {object}  errors.ErrorResponse
+// @Failure      500      {object}  errors.ErrorResponse
+// @Router       /dedup/preview [post]
+func (h *DeduplicationHandler) PreviewDeduplicationFingerprint(c *gin.Context) {
+	var req DeduplicationPreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.HandleError(c, errors.ErrValidation.WithCause(err))
+		return
+	}
+
+	result, err := h.Service.PreviewDeduplicationFingerprint(c.Request.Context(), req)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+type BundleHandler struct {
+	BaseHandler
+}
+
+func NewBundleHandler(service Service, log logger.Logger) *BundleHandler {
+	return &BundleHandler{
+		BaseHandler: BaseHandler{
+			Service: service,
+			Logger:  log,
+		},
+	}
+}
+
+func (h *BundleHandler) RegisterBundleRoutes(router *gin.Engine) {
+	v1 := router.Group("/api/v1")
+	{
+		bundle := v1.Group("/rules/bundle")
+		{
+			bundle.GET("", RequireScope(ScopeRulesRead), h.ExportRuleBundle)
+			bundle.POST("", RequireScope(ScopeConfigWrite), h.ImportRuleBundle)
+		}
+	}
+}
+
+// ExportRuleBundle godoc
+// @Summary      Export a rule bundle
+// @Description  Package every filtering rule, every enrichment rule, and the deduplication config into a single hashed bundle for promotion to another environment
+// @Tags         bundle
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  RuleBundle
+// @Failure      500  {object}  errors.ErrorResponse
+// @Router       /rules/bundle [get]
+func (h *BundleHandler) ExportRuleBundle(c *gin.Context) {
+	bundle, err := h.Service.ExportRuleBundle(c.Request.Context())
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, bundle)
+}
+
+// ImportRuleBundle godoc
+// @Summary      Import a rule bundle
+// @Description  Apply a previously exported rule bundle, rejecting it if its content hash doesn't match or any rule's CEL fails to validate. mode selects how bundle rules are reconciled against what's stored by name - create-only (default), upsert, or replace-all; see ImportMode's doc comment. dry_run=true returns the planned create/update/delete lists without writing anything.
+// @Tags         bundle
+// @Accept       json
+// @Produce      json
+// @Param        bundle   body      RuleBundle  true   "Bundle to import"
+// @Param        mode     query     string      false  "create-only (default), upsert, or replace-all"
+// @Param        dry_run  query     bool        false  "Compute the import plan without applying it"
+// @Success      200      {object}  ImportResult
+// @Failure      400      {object}  errors.ErrorResponse
+// @Failure      500      {object}  errors.ErrorResponse
+// @Router       /rules/bundle [post]
+func (h *BundleHandler) ImportRuleBundle(c *gin.Context) {
+	var bundle RuleBundle
+	if err := c.ShouldBindJSON(&bundle); err != nil {
+		h.HandleError(c, errors.ErrValidation.WithCause(err))
+		return
+	}
+
+	opts := ImportOptions{
+		Mode:   ImportMode(c.Query("mode")),
+		DryRun: c.Query("dry_run") == "true",
+	}
+
+	result, err := h.Service.ImportRuleBundle(c.Request.Context(), bundle, getChangedByHeader(c), opts)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// ScheduleHandler serves endpoints that span both filtering and
+// enrichment rules - unlike GetRuleSchedule/UpdateRuleSchedule on Handler,
+// which are scoped to a single filtering rule's own schedule sub-resource.
+type ScheduleHandler struct {
+	BaseHandler
+}
+
+func NewScheduleHandler(service Service, log logger.Logger) *ScheduleHandler {
+	return &ScheduleHandler{
+		BaseHandler: BaseHandler{
+			Service: service,
+			Logger:  log,
+		},
+	}
+}
+
+func (h *ScheduleHandler) RegisterScheduleRoutes(router *gin.Engine) {
+	v1 := router.Group("/api/v1")
+	{
+		v1.GET("/schedules/upcoming", RequireScope(ScopeRulesRead), h.ListUpcomingSchedules)
+	}
+}
+
+// defaultUpcomingWindow is how far ahead ListUpcomingSchedules looks when
+// the caller omits ?window.
+const defaultUpcomingWindow = 24 * time.Hour
+
+// ListUpcomingSchedules godoc
+// @Summary      List upcoming scheduled rule activations/deactivations
+// @Description  List every planned activation/deactivation across all filtering and enrichment rules due within the given window, soonest first
+// @Tags         schedules
+// @Accept       json
+// @Produce      json
+// @Param        window  query     string  false  "Go duration string, e.g. 24h (default 24h)"
+// @Success      200     {array}   UpcomingSchedule
+// @Failure      400     {object}  errors.ErrorResponse
+// @Failure      500     {object}  errors.ErrorResponse
+// @Router       /schedules/upcoming [get]
+func (h *ScheduleHandler) ListUpcomingSchedules(c *gin.Context) {
+	window := defaultUpcomingWindow
+	if w := c.Query("window"); w != "" {
+		parsed, err := time.ParseDuration(w)
+		if err != nil {
+			h.HandleError(c, errors.ErrValidation.WithDetail("message", "window must be a valid Go duration, e.g. 24h"))
+			return
+		}
+		window = parsed
+	}
+
+	upcoming, err := h.Service.ListUpcomingSchedules(c.Request.Context(), window)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, upcoming)
+}
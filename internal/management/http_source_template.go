@@ -0,0 +1,139 @@
+package management
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// httpTemplateTokenPattern matches the {...} placeholders renderHTTPTemplate
+// (internal/enrichment/provider) substitutes at fetch time.
+var httpTemplateTokenPattern = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// validateHTTPSourceTemplates rejects an http source_config whose URL, Body,
+// or Headers/QueryParams values reference a {token} renderHTTPTemplate
+// wouldn't know how to fill: anything other than {field_value}/{value} (the
+// enriched field's own value) or a {header:K}/{query:K} naming one of this
+// same config's declared Headers/QueryParams keys. Without this, a typo'd or
+// stale placeholder would silently pass through to the live request as
+// literal text instead of failing at validation time.
+func validateHTTPSourceTemplates(cfg EnrichmentSourceConfig) error {
+	if err := validateHTTPTemplateString("source_config.url", cfg.URL, cfg); err != nil {
+		return err
+	}
+	if err := validateHTTPTemplateString("source_config.body", cfg.Body, cfg); err != nil {
+		return err
+	}
+	for k, v := range cfg.Headers {
+		if err := validateHTTPTemplateString(fmt.Sprintf("source_config.headers[%s]", k), v, cfg); err != nil {
+			return err
+		}
+	}
+	for k, v := range cfg.QueryParams {
+		if err := validateHTTPTemplateString(fmt.Sprintf("source_config.query_params[%s]", k), v, cfg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateHTTPTemplateString(field, s string, cfg EnrichmentSourceConfig) error {
+	for _, match := range httpTemplateTokenPattern.FindAllStringSubmatch(s, -1) {
+		token := match[1]
+		if token == "field_value" || token == "value" {
+			continue
+		}
+		if key, ok := strings.CutPrefix(token, "header:"); ok {
+			if _, declared := cfg.Headers[key]; declared {
+				continue
+			}
+			return &ValidationError{Field: field, Message: fmt.Sprintf("template references undeclared header %q", key)}
+		}
+		if key, ok := strings.CutPrefix(token, "query:"); ok {
+			if _, declared := cfg.QueryParams[key]; declared {
+				continue
+			}
+			return &ValidationError{Field: field, Message: fmt.Sprintf("template references undeclared query param %q", key)}
+		}
+		return &ValidationError{Field: field, Message: fmt.Sprintf("template references unknown field %q; allowed: field_value, value, header:<name>, query:<name>", token)}
+	}
+	return nil
+}
+
+// HTTPTemplateCompiler parses and caches the {token} placeholders used by an
+// http enrichment source's URL/Body/Headers/QueryParams, keyed by
+// (rule.ID, rule.Version). It mirrors RuleValidator's compiled-program
+// cache, but stores parsed token names rather than cel-go programs, since an
+// http source's "compiled form" is just the set of placeholders the fetch
+// path needs to resolve, not an executable expression.
+type HTTPTemplateCompiler struct {
+	mu    sync.RWMutex
+	cache map[string][]string
+}
+
+func NewHTTPTemplateCompiler() *HTTPTemplateCompiler {
+	return &HTTPTemplateCompiler{cache: make(map[string][]string)}
+}
+
+// CompileSourceConfig validates cfg's templates (see
+// validateHTTPSourceTemplates) and returns the sorted, deduplicated set of
+// placeholders it references - the "compiled form" callers cache via
+// CacheCompiled.
+func (c *HTTPTemplateCompiler) CompileSourceConfig(cfg EnrichmentSourceConfig) ([]string, error) {
+	if err := validateHTTPSourceTemplates(cfg); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	for _, s := range append([]string{cfg.URL, cfg.Body}, append(mapValues(cfg.Headers), mapValues(cfg.QueryParams)...)...) {
+		for _, match := range httpTemplateTokenPattern.FindAllStringSubmatch(s, -1) {
+			seen[match[1]] = true
+		}
+	}
+	tokens := make([]string, 0, len(seen))
+	for token := range seen {
+		tokens = append(tokens, token)
+	}
+	sort.Strings(tokens)
+	return tokens, nil
+}
+
+func mapValues(m map[string]string) []string {
+	values := make([]string, 0, len(m))
+	for _, v := range m {
+		values = append(values, v)
+	}
+	return values
+}
+
+// CacheCompiled stores tokens (as returned by CompileSourceConfig) under
+// (ruleID, version), replacing anything already cached for that exact
+// version.
+func (c *HTTPTemplateCompiler) CacheCompiled(ruleID string, version int, tokens []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cache[cacheKey(ruleID, version)] = tokens
+}
+
+// GetCompiled returns the tokens cached for (ruleID, version) by a prior
+// CacheCompiled call, and whether an entry was found at all.
+func (c *HTTPTemplateCompiler) GetCompiled(ruleID string, version int) ([]string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	tokens, ok := c.cache[cacheKey(ruleID, version)]
+	return tokens, ok
+}
+
+// InvalidateRule drops every cached version of ruleID.
+func (c *HTTPTemplateCompiler) InvalidateRule(ruleID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	prefix := ruleID + ":"
+	for key := range c.cache {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.cache, key)
+		}
+	}
+}
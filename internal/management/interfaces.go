@@ -2,6 +2,9 @@ package management
 
 import (
 	"context"
+	"time"
+
+	"yeti/internal/management/scheduler"
 )
 
 type Service interface {
@@ -11,14 +14,109 @@ type Service interface {
 	UpdateFilteringRule(ctx context.Context, id string, req UpdateFilteringRuleRequest) (*FilteringRule, error)
 	DeleteFilteringRule(ctx context.Context, id string) error
 	GetRuleVersions(ctx context.Context, ruleID string) ([]RuleVersion, error)
+	GetRuleVersion(ctx context.Context, ruleID string, version int) (*RuleVersion, error)
+	DiffRuleVersions(ctx context.Context, ruleID string, versionA, versionB int) ([]FieldDiff, error)
 	GetAuditLogs(ctx context.Context, ruleID *string, ruleType string, limit int) ([]AuditLog, error)
+	ListRuleHistory(ctx context.Context, ruleType, ruleID string, limit int) ([]AuditLog, error)
+	RollbackRule(ctx context.Context, ruleType, ruleID, auditEntryID, changedBy string) error
+	RollbackRuleToVersion(ctx context.Context, ruleType, ruleID string, version int, changedBy string) error
+	GetRuleVersionPatch(ctx context.Context, ruleID string, fromVersion, toVersion int) (*RuleDiff, error)
+	RestoreRuleVersion(ctx context.Context, ruleType, ruleID string, targetVersion int, changedBy string) (*RuleVersion, error)
+	// RollbackFilteringRule is RestoreRuleVersion bound to rule_type
+	// "filtering"; see its doc comment in rollback.go.
+	RollbackFilteringRule(ctx context.Context, ruleID string, targetVersion int, changedBy string) (*RuleVersion, error)
+	GetRuleVersionTimeline(ctx context.Context, ruleID string, since time.Time) ([]VersionSummary, error)
+
+	// GetFilteringRuleSchedule/UpdateFilteringRuleSchedule back the
+	// dedicated schedule sub-resource endpoints; see their doc comments in
+	// schedule_endpoints.go. ListUpcomingSchedules covers both filtering
+	// and enrichment rules.
+	GetFilteringRuleSchedule(ctx context.Context, ruleID string) (*Schedule, error)
+	UpdateFilteringRuleSchedule(ctx context.Context, ruleID string, sched *Schedule) (*FilteringRule, error)
+	ListUpcomingSchedules(ctx context.Context, window time.Duration) ([]UpcomingSchedule, error)
+	// ListSchedulableRules/SetRuleEnabled satisfy scheduler.RuleStore,
+	// letting a Service value be passed directly to scheduler.New as its
+	// RuleStore - see scheduler_adapter.go.
+	ListSchedulableRules(ctx context.Context) ([]scheduler.RuleRef, error)
+	SetRuleEnabled(ctx context.Context, ruleType, ruleID string, enabled bool, changedBy string) error
+
+	// ApplyRuleChangeSet bulk-applies cs's filtering ops atomically and its
+	// enrichment ops sequentially/best-effort; see its doc comment in
+	// changeset.go for the atomicity boundary between the two.
+	ApplyRuleChangeSet(ctx context.Context, cs ChangeSet) (*ChangeSetResult, error)
+	// GetRuleChangesSince returns rule_versions rows written after
+	// sinceRevision, oldest first, for a "changes since revision N" watch
+	// feed - see VersioningRepository.GetVersionsSinceRevision.
+	GetRuleChangesSince(ctx context.Context, sinceRevision int64, limit int) ([]RuleVersion, error)
+
+	// ApplyFilteringRuleBatch/ApplyEnrichmentRuleBatch are ApplyRuleChangeSet's
+	// per-rule-type counterparts with enable/disable shorthand and a
+	// per-op result; see their doc comments in batch.go for the
+	// atomicity difference between the two.
+	ApplyFilteringRuleBatch(ctx context.Context, ops []RuleBatchOp) (*RuleBatchResult, error)
+	ApplyEnrichmentRuleBatch(ctx context.Context, ops []RuleBatchOp) (*RuleBatchResult, error)
+	// DryRunFilteringRuleByID dry-runs one stored rule against a single
+	// event with a per-clause trace; see its doc comment in evaluate.go.
+	DryRunFilteringRuleByID(ctx context.Context, ruleID string, event SampleEvent) (*RuleDryRunResult, error)
+
+	ExportRuleBundle(ctx context.Context) (*RuleBundle, error)
+	// ImportRuleBundle is ExportRuleBundle's counterpart; see its doc
+	// comment in bundle.go for ImportMode/ImportOptions semantics.
+	ImportRuleBundle(ctx context.Context, bundle RuleBundle, changedBy string, opts ImportOptions) (*ImportResult, error)
 
 	CreateEnrichmentRule(ctx context.Context, req CreateEnrichmentRuleRequest) (*EnrichmentRule, error)
 	ListEnrichmentRules(ctx context.Context) ([]EnrichmentRule, error)
 	GetEnrichmentRule(ctx context.Context, id string) (*EnrichmentRule, error)
 	UpdateEnrichmentRule(ctx context.Context, id string, req UpdateEnrichmentRuleRequest) (*EnrichmentRule, error)
 	DeleteEnrichmentRule(ctx context.Context, id string) error
+	GetEnrichmentRuleAudit(ctx context.Context, id string) ([]EnrichmentRuleAudit, error)
+	// DiffEnrichmentRuleVersions is DiffRuleVersions's enrichment
+	// counterpart; see its doc comment in rollback.go.
+	DiffEnrichmentRuleVersions(ctx context.Context, ruleID string, versionA, versionB int) ([]FieldDiff, error)
+	// RollbackEnrichmentRule restores an enrichment rule to a recorded
+	// version, recreating it with the same ID if it's since been deleted;
+	// see its doc comment in rollback.go.
+	RollbackEnrichmentRule(ctx context.Context, ruleID string, targetVersion int, changedBy string) (*EnrichmentRule, error)
+	// ListEnrichmentSourceTypes returns every source_type EnrichmentRule
+	// currently accepts, including any registered via
+	// WithEnrichmentSourceRegistry/RegisterSourceTypeValidator.
+	ListEnrichmentSourceTypes(ctx context.Context) []string
 
 	GetDeduplicationConfig(ctx context.Context) (*DeduplicationConfig, error)
 	UpdateDeduplicationConfig(ctx context.Context, req UpdateDeduplicationConfigRequest) (*DeduplicationConfig, error)
+	// GetDeduplicationConfigVersions returns the calling tenant's
+	// deduplication config version history; see its doc comment in
+	// rollback.go.
+	GetDeduplicationConfigVersions(ctx context.Context) ([]RuleVersion, error)
+	// RollbackDeduplicationConfig restores a previously recorded
+	// deduplication config version; see its doc comment in rollback.go.
+	RollbackDeduplicationConfig(ctx context.Context, targetVersion int, changedBy string) (*DeduplicationConfig, error)
+	// PreviewDeduplicationFingerprint computes the fingerprint a sample
+	// event would produce without writing anything to Redis; see its doc
+	// comment in rollback.go.
+	PreviewDeduplicationFingerprint(ctx context.Context, req DeduplicationPreviewRequest) (*DeduplicationPreviewResponse, error)
+
+	RegisterProvider(ctx context.Context, req RegisterProviderRequest) (*ExternalProvider, error)
+	ListProviders(ctx context.Context) ([]ExternalProvider, error)
+	DeleteProvider(ctx context.Context, id string) error
+
+	EvaluateFilteringRule(ctx context.Context, req FilteringEvaluateRequest) (*FilteringEvaluateResponse, error)
+	// DryRunFilteringRule evaluates expression against sampleEvents (or,
+	// when empty, the stored sample corpus); previousExpression, if set,
+	// adds a match-count diff against the rule's currently-active
+	// expression. See its doc comment in evaluate.go.
+	DryRunFilteringRule(ctx context.Context, expression string, sampleEvents []SampleEvent, previousExpression string) (*DryRunResult, error)
+	EvaluateEnrichmentRule(ctx context.Context, req EnrichmentEvaluateRequest) (*EnrichmentEvaluateResponse, error)
+	// DryRunEnrichmentRule is DryRunFilteringRule's enrichment counterpart;
+	// previous, if set, adds a match-count diff against the rule's
+	// currently-stored version.
+	DryRunEnrichmentRule(ctx context.Context, rule EnrichmentRule, sampleEvents []SampleEvent, previous *EnrichmentRule) (*DryRunResult, error)
+	PlaygroundFilteringExpression(ctx context.Context, req FilteringEvaluateRequest) (*PlaygroundResult, error)
+	PlaygroundEnrichmentExpression(ctx context.Context, req EnrichmentExpressionPlaygroundRequest) (*PlaygroundResult, error)
+
+	// ListDeadLettered/Replay inspect and reprocess messages a
+	// DeadLetterIndexer has recorded off a DLQ topic; see their doc
+	// comments in dead_letter.go/service.go.
+	ListDeadLettered(ctx context.Context, filter DeadLetterFilter) ([]DeadLetterEntry, error)
+	Replay(ctx context.Context, ids []string, targetTopic string) (*ReplayResult, error)
 }
@@ -0,0 +1,106 @@
+package managementpb
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// ServiceNameAudit is the fully-qualified gRPC service name for the Audit
+// service declared in proto/management/v1/audit.proto.
+const ServiceNameAudit = "management.v1.Audit"
+
+const methodGetAuditLogs = "GetAuditLogs"
+
+// AuditLog mirrors audit.proto's AuditLog message, which in turn mirrors
+// management.AuditLog field-for-field. OldValueJSON/NewValueJSON are
+// JSON-encoded objects rather than google.protobuf.Struct - see this
+// package's doc comment.
+type AuditLog struct {
+	ID           string `json:"id"`
+	TenantID     string `json:"tenant_id"`
+	RuleID       string `json:"rule_id"`
+	RuleIDSet    bool   `json:"rule_id_set"`
+	RuleType     string `json:"rule_type"`
+	Action       string `json:"action"`
+	OldValueJSON string `json:"old_value_json"`
+	NewValueJSON string `json:"new_value_json"`
+	ChangedBy    string `json:"changed_by"`
+	ChangeReason string `json:"change_reason"`
+	IPAddress    string `json:"ip_address"`
+	Timestamp    string `json:"timestamp"`
+}
+
+// GetAuditLogsRequest mirrors Service.GetAuditLogs's (ruleID *string,
+// ruleType string, limit int) parameters.
+type GetAuditLogsRequest struct {
+	RuleID    string `json:"rule_id"`
+	RuleIDSet bool   `json:"rule_id_set"`
+	RuleType  string `json:"rule_type"`
+	Limit     int32  `json:"limit"`
+}
+
+type GetAuditLogsResponse struct {
+	Logs []AuditLog `json:"logs"`
+}
+
+// AuditClient is the client API for the Audit gRPC service.
+type AuditClient interface {
+	GetAuditLogs(ctx context.Context, in *GetAuditLogsRequest, opts ...grpc.CallOption) (*GetAuditLogsResponse, error)
+}
+
+type auditClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAuditClient(cc grpc.ClientConnInterface) AuditClient {
+	return &auditClient{cc: cc}
+}
+
+func (c *auditClient) GetAuditLogs(ctx context.Context, in *GetAuditLogsRequest, opts ...grpc.CallOption) (*GetAuditLogsResponse, error) {
+	out := new(GetAuditLogsResponse)
+	if err := c.cc.Invoke(ctx, fullMethodAudit(methodGetAuditLogs), in, out, callOpts(opts)...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AuditServer is the server API for the Audit gRPC service.
+// management.GRPCServer implements this - see
+// internal/management/grpcserver.go.
+type AuditServer interface {
+	GetAuditLogs(ctx context.Context, in *GetAuditLogsRequest) (*GetAuditLogsResponse, error)
+}
+
+func RegisterAuditServer(s grpc.ServiceRegistrar, srv AuditServer) {
+	s.RegisterService(&auditServiceDesc, srv)
+}
+
+func fullMethodAudit(method string) string {
+	return "/" + ServiceNameAudit + "/" + method
+}
+
+var auditServiceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceNameAudit,
+	HandlerType: (*AuditServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: methodGetAuditLogs, Handler: getAuditLogsHandler},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "management/v1/audit.proto",
+}
+
+func getAuditLogsHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAuditLogsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuditServer).GetAuditLogs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethodAudit(methodGetAuditLogs)}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuditServer).GetAuditLogs(ctx, req.(*GetAuditLogsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
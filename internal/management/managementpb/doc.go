@@ -0,0 +1,20 @@
+// Package managementpb is the Go client/server binding for the gRPC
+// services described under proto/management/v1/ - the programmatic
+// counterpart to management.Handler's REST API, both built as thin
+// transports over management.Service (see
+// internal/management/grpcserver.go).
+//
+// Like internal/enrichment/provider/providerpb, it's hand-maintained
+// rather than protoc-generated: this tree has no protoc toolchain. Unlike
+// providerpb (which exchanges google.protobuf.Struct and so needs no
+// per-field message types), management.Service's domain types have
+// specific fields, so this package defines plain Go structs mirroring
+// each .proto message and carries them over the wire with the same
+// encoding/json-backed grpc codec providerpb uses - see jsoncodec.go.
+// Regenerate properly if protoc becomes available, keeping the same
+// field names and JSON tags.
+//
+// v1 binds FilteringRules and Audit only. EnrichmentRules and
+// Deduplication have .proto contracts defined but no Go binding yet - see
+// their doc comments in proto/management/v1/ for why.
+package managementpb
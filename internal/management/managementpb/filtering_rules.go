@@ -0,0 +1,257 @@
+package managementpb
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// ServiceNameFilteringRules is the fully-qualified gRPC service name for
+// the FilteringRules service declared in
+// proto/management/v1/filtering_rules.proto.
+const ServiceNameFilteringRules = "management.v1.FilteringRules"
+
+const (
+	methodListFilteringRules  = "ListFilteringRules"
+	methodGetFilteringRule    = "GetFilteringRule"
+	methodCreateFilteringRule = "CreateFilteringRule"
+	methodUpdateFilteringRule = "UpdateFilteringRule"
+	methodDeleteFilteringRule = "DeleteFilteringRule"
+)
+
+// FilteringRule mirrors filtering_rules.proto's FilteringRule message,
+// which in turn mirrors management.FilteringRule field-for-field.
+// CreatedAt/UpdatedAt are RFC 3339 strings - see this package's doc
+// comment for why there's no google.protobuf.Timestamp here.
+type FilteringRule struct {
+	ID         string `json:"id"`
+	TenantID   string `json:"tenant_id"`
+	Name       string `json:"name"`
+	Expression string `json:"expression"`
+	Priority   int32  `json:"priority"`
+	Enabled    bool   `json:"enabled"`
+	Mode       string `json:"mode"`
+	Version    int32  `json:"version"`
+	CreatedAt  string `json:"created_at"`
+	UpdatedAt  string `json:"updated_at"`
+}
+
+type ListFilteringRulesRequest struct{}
+
+type ListFilteringRulesResponse struct {
+	Rules []FilteringRule `json:"rules"`
+}
+
+type GetFilteringRuleRequest struct {
+	ID string `json:"id"`
+}
+
+// CreateFilteringRuleRequest mirrors management.CreateFilteringRuleRequest.
+// EnabledSet distinguishes "omitted" from "false", the way the REST body's
+// *bool does over JSON - see filtering_rules.proto.
+type CreateFilteringRuleRequest struct {
+	Name       string `json:"name"`
+	Expression string `json:"expression"`
+	Priority   int32  `json:"priority"`
+	Enabled    bool   `json:"enabled"`
+	EnabledSet bool   `json:"enabled_set"`
+	Mode       string `json:"mode"`
+}
+
+// UpdateFilteringRuleRequest mirrors management.UpdateFilteringRuleRequest.
+// Every field besides ID is optional, carried the same way
+// CreateFilteringRuleRequest.EnabledSet is.
+type UpdateFilteringRuleRequest struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	NameSet       bool   `json:"name_set"`
+	Expression    string `json:"expression"`
+	ExpressionSet bool   `json:"expression_set"`
+	Priority      int32  `json:"priority"`
+	PrioritySet   bool   `json:"priority_set"`
+	Enabled       bool   `json:"enabled"`
+	EnabledSet    bool   `json:"enabled_set"`
+	Mode          string `json:"mode"`
+	ModeSet       bool   `json:"mode_set"`
+	Version       int32  `json:"version"`
+	VersionSet    bool   `json:"version_set"`
+}
+
+type DeleteFilteringRuleRequest struct {
+	ID string `json:"id"`
+}
+
+type DeleteFilteringRuleResponse struct{}
+
+// FilteringRulesClient is the client API for the FilteringRules gRPC
+// service.
+type FilteringRulesClient interface {
+	ListFilteringRules(ctx context.Context, in *ListFilteringRulesRequest, opts ...grpc.CallOption) (*ListFilteringRulesResponse, error)
+	GetFilteringRule(ctx context.Context, in *GetFilteringRuleRequest, opts ...grpc.CallOption) (*FilteringRule, error)
+	CreateFilteringRule(ctx context.Context, in *CreateFilteringRuleRequest, opts ...grpc.CallOption) (*FilteringRule, error)
+	UpdateFilteringRule(ctx context.Context, in *UpdateFilteringRuleRequest, opts ...grpc.CallOption) (*FilteringRule, error)
+	DeleteFilteringRule(ctx context.Context, in *DeleteFilteringRuleRequest, opts ...grpc.CallOption) (*DeleteFilteringRuleResponse, error)
+}
+
+type filteringRulesClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewFilteringRulesClient(cc grpc.ClientConnInterface) FilteringRulesClient {
+	return &filteringRulesClient{cc: cc}
+}
+
+// callOpts forces the jsonCodec for every FilteringRules call, the same
+// way providerpb.EnrichmentClient.Fetch does - see this package's doc
+// comment.
+func callOpts(opts []grpc.CallOption) []grpc.CallOption {
+	return append([]grpc.CallOption{grpc.ForceCodec(jsonCodec{})}, opts...)
+}
+
+func (c *filteringRulesClient) ListFilteringRules(ctx context.Context, in *ListFilteringRulesRequest, opts ...grpc.CallOption) (*ListFilteringRulesResponse, error) {
+	out := new(ListFilteringRulesResponse)
+	if err := c.cc.Invoke(ctx, fullMethodFilteringRules(methodListFilteringRules), in, out, callOpts(opts)...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filteringRulesClient) GetFilteringRule(ctx context.Context, in *GetFilteringRuleRequest, opts ...grpc.CallOption) (*FilteringRule, error) {
+	out := new(FilteringRule)
+	if err := c.cc.Invoke(ctx, fullMethodFilteringRules(methodGetFilteringRule), in, out, callOpts(opts)...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filteringRulesClient) CreateFilteringRule(ctx context.Context, in *CreateFilteringRuleRequest, opts ...grpc.CallOption) (*FilteringRule, error) {
+	out := new(FilteringRule)
+	if err := c.cc.Invoke(ctx, fullMethodFilteringRules(methodCreateFilteringRule), in, out, callOpts(opts)...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filteringRulesClient) UpdateFilteringRule(ctx context.Context, in *UpdateFilteringRuleRequest, opts ...grpc.CallOption) (*FilteringRule, error) {
+	out := new(FilteringRule)
+	if err := c.cc.Invoke(ctx, fullMethodFilteringRules(methodUpdateFilteringRule), in, out, callOpts(opts)...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filteringRulesClient) DeleteFilteringRule(ctx context.Context, in *DeleteFilteringRuleRequest, opts ...grpc.CallOption) (*DeleteFilteringRuleResponse, error) {
+	out := new(DeleteFilteringRuleResponse)
+	if err := c.cc.Invoke(ctx, fullMethodFilteringRules(methodDeleteFilteringRule), in, out, callOpts(opts)...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// FilteringRulesServer is the server API for the FilteringRules gRPC
+// service. management.GRPCServer implements this - see
+// internal/management/grpcserver.go.
+type FilteringRulesServer interface {
+	ListFilteringRules(ctx context.Context, in *ListFilteringRulesRequest) (*ListFilteringRulesResponse, error)
+	GetFilteringRule(ctx context.Context, in *GetFilteringRuleRequest) (*FilteringRule, error)
+	CreateFilteringRule(ctx context.Context, in *CreateFilteringRuleRequest) (*FilteringRule, error)
+	UpdateFilteringRule(ctx context.Context, in *UpdateFilteringRuleRequest) (*FilteringRule, error)
+	DeleteFilteringRule(ctx context.Context, in *DeleteFilteringRuleRequest) (*DeleteFilteringRuleResponse, error)
+}
+
+func RegisterFilteringRulesServer(s grpc.ServiceRegistrar, srv FilteringRulesServer) {
+	s.RegisterService(&filteringRulesServiceDesc, srv)
+}
+
+func fullMethodFilteringRules(method string) string {
+	return "/" + ServiceNameFilteringRules + "/" + method
+}
+
+var filteringRulesServiceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceNameFilteringRules,
+	HandlerType: (*FilteringRulesServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: methodListFilteringRules, Handler: listFilteringRulesHandler},
+		{MethodName: methodGetFilteringRule, Handler: getFilteringRuleHandler},
+		{MethodName: methodCreateFilteringRule, Handler: createFilteringRuleHandler},
+		{MethodName: methodUpdateFilteringRule, Handler: updateFilteringRuleHandler},
+		{MethodName: methodDeleteFilteringRule, Handler: deleteFilteringRuleHandler},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "management/v1/filtering_rules.proto",
+}
+
+func listFilteringRulesHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListFilteringRulesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilteringRulesServer).ListFilteringRules(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethodFilteringRules(methodListFilteringRules)}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilteringRulesServer).ListFilteringRules(ctx, req.(*ListFilteringRulesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func getFilteringRuleHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetFilteringRuleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilteringRulesServer).GetFilteringRule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethodFilteringRules(methodGetFilteringRule)}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilteringRulesServer).GetFilteringRule(ctx, req.(*GetFilteringRuleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func createFilteringRuleHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateFilteringRuleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilteringRulesServer).CreateFilteringRule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethodFilteringRules(methodCreateFilteringRule)}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilteringRulesServer).CreateFilteringRule(ctx, req.(*CreateFilteringRuleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func updateFilteringRuleHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateFilteringRuleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilteringRulesServer).UpdateFilteringRule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethodFilteringRules(methodUpdateFilteringRule)}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilteringRulesServer).UpdateFilteringRule(ctx, req.(*UpdateFilteringRuleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func deleteFilteringRuleHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteFilteringRuleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilteringRulesServer).DeleteFilteringRule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethodFilteringRules(methodDeleteFilteringRule)}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilteringRulesServer).DeleteFilteringRule(ctx, req.(*DeleteFilteringRuleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
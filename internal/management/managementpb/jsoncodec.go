@@ -0,0 +1,39 @@
+package managementpb
+
+import (
+	"encoding/json"
+
+	"google.golang.org/grpc/encoding"
+)
+
+// jsonCodec implements grpc's encoding.Codec with encoding/json instead of
+// real protobuf wire encoding, for the same reason
+// internal/enrichment/provider/providerpb carries FetchRequest/
+// FetchResponse this way - see this package's doc comment. Callers only
+// ever reach it through the generated client methods' grpc.ForceCodec
+// call option, never directly.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// Name is negotiated as the call's content-subtype: grpc.ForceCodec sets it
+// on the client side, and the server looks it up here via the encoding
+// registry to decode with the same codec. Shares providerpb's "yeti-json"
+// name - both codecs are byte-for-byte identical encoding/json wrappers,
+// and grpc's encoding registry is global per-process, so registering the
+// same name twice from two packages is harmless as long as neither
+// package depends on the other's registration running first (each
+// package's own init() registers its own copy here).
+func (jsonCodec) Name() string {
+	return "yeti-json"
+}
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
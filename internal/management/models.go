@@ -4,12 +4,68 @@ import "time"
 
 type FilteringRule struct {
 	ID         string    `json:"id" db:"id"`
+	TenantID   string    `json:"tenant_id" db:"tenant_id"`
 	Name       string    `json:"name" db:"name"`
 	Expression string    `json:"expression" db:"expression"`
 	Priority   int       `json:"priority" db:"priority"`
 	Enabled    bool      `json:"enabled" db:"enabled"`
-	CreatedAt  time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+	// Mode is "enforce" (default, same as ""), "shadow", or
+	// "canary:<percent>" - see filtering.Rule.Mode and
+	// filtering.Service.evaluateShadowRules for how it's interpreted.
+	Mode string `json:"mode,omitempty" db:"mode"`
+	// Schedule, when set, hands this rule's Enabled state over to
+	// scheduler.Scheduler instead of leaving it fully operator-controlled;
+	// see Schedule's doc comment and management/scheduler.
+	Schedule *Schedule `json:"schedule,omitempty" db:"schedule"`
+	// MaxCost and MaxEvalDurationMs mirror filtering.Rule's fields of the
+	// same name - a per-rule override of FilteringConfig.CEL's MaxCost/
+	// MaxEvalDurationMs defaults. <= 0 (the zero value) leaves the rule on
+	// the service-wide default.
+	MaxCost           uint64    `json:"max_cost,omitempty" db:"max_cost"`
+	MaxEvalDurationMs int       `json:"max_eval_duration_ms,omitempty" db:"max_eval_duration_ms"`
+	Version           int       `json:"version" db:"version"`
+	CreatedAt         time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at" db:"updated_at"`
+	// Warnings is only ever populated on CreateFilteringRule/
+	// UpdateFilteringRule's returned FilteringRule - see
+	// ValidateFilteringRule/ValidateUpdateFilteringRule - so an operator sees
+	// e.g. an over-budget estimated CEL cost without the save itself being
+	// rejected. Never persisted (db:"-") and never present on a rule read
+	// back from ListFilteringRules/GetFilteringRule.
+	Warnings []string `json:"warnings,omitempty" db:"-"`
+}
+
+// Schedule describes when scheduler.Scheduler should flip a rule's Enabled
+// state on its owner's behalf, rather than leaving it entirely up to a
+// PUT/PATCH. ActivateAt/DeactivateAt are one-shot instants; Cron, when
+// set, re-fires activation on a recurring basis (see
+// management/scheduler's doc comment for exactly what "recurring
+// activation" means here). Both kinds can be set on the same rule - e.g.
+// a DeactivateAt "kill switch" on an otherwise cron-driven rollout - and
+// are evaluated independently.
+type Schedule struct {
+	ActivateAt   *time.Time `json:"activate_at,omitempty"`
+	DeactivateAt *time.Time `json:"deactivate_at,omitempty"`
+	// Cron is a standard 5-field expression (minute hour dom month dow);
+	// see scheduler.ParseCron for the supported grammar.
+	Cron string `json:"cron,omitempty"`
+	// Timezone is an IANA zone name (e.g. "America/New_York") Cron is
+	// evaluated in; empty means UTC.
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// UpcomingSchedule is one entry in Service.ListUpcomingSchedules's result: a
+// single planned activation or deactivation instant for one rule, within
+// the requested lookahead window.
+type UpcomingSchedule struct {
+	RuleType string    `json:"rule_type"`
+	RuleID   string    `json:"rule_id"`
+	At       time.Time `json:"at"`
+	// Action is "activate" or "deactivate".
+	Action string `json:"action"`
+	// Reason is "activate_at", "deactivate_at", or "cron" - which part of
+	// the rule's Schedule produced this entry.
+	Reason string `json:"reason"`
 }
 
 type CreateFilteringRuleRequest struct {
@@ -17,6 +73,17 @@ type CreateFilteringRuleRequest struct {
 	Expression string `json:"expression" binding:"required"`
 	Priority   int    `json:"priority"`
 	Enabled    *bool  `json:"enabled"`
+	Mode       string `json:"mode"`
+	// Schedule is FilteringRule.Schedule's create-time counterpart.
+	Schedule *Schedule `json:"schedule,omitempty"`
+	// MaxCost and MaxEvalDurationMs are FilteringRule.MaxCost/
+	// MaxEvalDurationMs's create-time counterparts.
+	MaxCost           uint64 `json:"max_cost,omitempty"`
+	MaxEvalDurationMs int    `json:"max_eval_duration_ms,omitempty"`
+	// SampleEvents, if set, is only consulted when this request is sent
+	// with ?dry_run=true - see DryRunFilteringRule. It's ignored on a real
+	// create.
+	SampleEvents []SampleEvent `json:"sample_events,omitempty"`
 }
 
 type UpdateFilteringRuleRequest struct {
@@ -24,22 +91,114 @@ type UpdateFilteringRuleRequest struct {
 	Expression *string `json:"expression"`
 	Priority   *int    `json:"priority"`
 	Enabled    *bool   `json:"enabled"`
+	Mode       *string `json:"mode"`
+	// Schedule, when non-nil, replaces the rule's schedule wholesale (no
+	// field-by-field merge), matching how EnrichmentRule.SourceConfig is
+	// replaced on update. Send an empty &Schedule{} to clear it.
+	Schedule *Schedule `json:"schedule,omitempty"`
+	// MaxCost and MaxEvalDurationMs are FilteringRule.MaxCost/
+	// MaxEvalDurationMs's update-time counterparts. A zero value is
+	// indistinguishable from "not set" here, the same as Priority/Enabled
+	// being pointers elsewhere in this request - there's no need to clear a
+	// per-rule override back to "use the default" other than setting it to
+	// 0 again explicitly.
+	MaxCost           *uint64 `json:"max_cost,omitempty"`
+	MaxEvalDurationMs *int    `json:"max_eval_duration_ms,omitempty"`
+	Version           *int    `json:"version"`
+	// SampleEvents is CreateFilteringRuleRequest.SampleEvents's counterpart
+	// for ?dry_run=true on an update.
+	SampleEvents []SampleEvent `json:"sample_events,omitempty"`
 }
 
+// EnrichmentRule's index and indexes tags are this collection's declarative
+// counterpart to the mongo.IndexModel list ensureEnrichmentRulesIndexes used
+// to hand-maintain; see migrations.IndexModelsFromTags, which now builds
+// that same index set from these tags directly instead.
 type EnrichmentRule struct {
 	ID              string                     `json:"id" bson:"_id,omitempty"`
+	TenantID        string                     `json:"tenant_id" bson:"tenant_id"`
 	Name            string                     `json:"name" bson:"name"`
-	FieldToEnrich   string                     `json:"field_to_enrich" bson:"field_to_enrich"`
+	FieldToEnrich   string                     `json:"field_to_enrich" bson:"field_to_enrich" index:"1"`
 	SourceType      string                     `json:"source_type" bson:"source_type"`
 	SourceConfig    EnrichmentSourceConfig     `json:"source_config" bson:"source_config"`
+	// Condition, if set, is a CEL expression gating which messages this rule
+	// runs against; see enrichment.Rule.Condition. Empty runs the rule
+	// unconditionally.
+	Condition       string                     `json:"condition,omitempty" bson:"condition,omitempty"`
 	Transformations []EnrichmentTransformation `json:"transformations" bson:"transformations"`
 	CacheTTLSeconds int                        `json:"cache_ttl_seconds" bson:"cache_ttl_seconds"`
 	ErrorHandling   string                     `json:"error_handling" bson:"error_handling"`
 	FallbackValue   interface{}                `json:"fallback_value,omitempty" bson:"fallback_value"`
-	Priority        int                        `json:"priority" bson:"priority"`
+	Priority        int                        `json:"priority" bson:"priority" index:"-1"`
 	Enabled         bool                       `json:"enabled" bson:"enabled"`
-	CreatedAt       time.Time                  `json:"created_at" bson:"created_at"`
-	UpdatedAt       time.Time                  `json:"updated_at" bson:"updated_at"`
+	// Mode is "enforce" (default, same as "") or "shadow" - see
+	// FilteringRule.Mode. Unlike filtering rules, shadow mode here only
+	// marks the rule as not yet promoted; there is no enrichment-pipeline
+	// equivalent of filtering.Service.evaluateShadowRules running it in
+	// parallel against live traffic, so setting it has no runtime effect
+	// beyond what DryRunEnrichmentRule lets an operator check by hand.
+	Mode            string                     `json:"mode,omitempty" bson:"mode,omitempty"`
+	// Schedule is FilteringRule.Schedule's enrichment counterpart; see its
+	// doc comment and management/scheduler.
+	Schedule *Schedule `json:"schedule,omitempty" bson:"schedule,omitempty"`
+	// CircuitBreaker and Retry, when set, override this rule's source
+	// type's service-level circuit-breaker/retry defaults with thresholds
+	// scoped to just this rule; see enrichment.Rule.CircuitBreaker/Retry,
+	// whose bson tags these mirror so enrichment-service's ReloadRules
+	// picks them up from the same stored document.
+	CircuitBreaker *RuleCircuitBreakerConfig `json:"circuit_breaker,omitempty" bson:"circuit_breaker,omitempty"`
+	Retry          *RuleRetryConfig          `json:"retry,omitempty" bson:"retry,omitempty"`
+	Version        int                       `json:"version" bson:"version"`
+	CreatedAt      time.Time                 `json:"created_at" bson:"created_at"`
+	UpdatedAt      time.Time                 `json:"updated_at" bson:"updated_at" index:"-1"`
+	// _ declares the compound indexes a single field's index tag can't: the
+	// enabled+priority lookup order and the enabled+field_to_enrich+priority
+	// path ReloadRules/evaluation filter on together.
+	_ struct{} `indexes:"enabled:1,priority:-1|enabled:1,field_to_enrich:1,priority:-1;name=idx_enrichment_rules_enabled_field_priority"`
+}
+
+// RuleCircuitBreakerConfig mirrors enrichment.RuleCircuitBreakerConfig; see
+// its doc comment for what each field controls.
+type RuleCircuitBreakerConfig struct {
+	FailureRatio float64       `json:"failure_ratio,omitempty" bson:"failure_ratio,omitempty"`
+	MinRequests  uint32        `json:"min_requests,omitempty" bson:"min_requests,omitempty"`
+	Timeout      time.Duration `json:"timeout,omitempty" bson:"timeout,omitempty"`
+	MaxRequests  uint32        `json:"max_requests,omitempty" bson:"max_requests,omitempty"`
+	Interval     time.Duration `json:"interval,omitempty" bson:"interval,omitempty"`
+}
+
+// RuleRetryConfig mirrors enrichment.RuleRetryConfig; see its doc comment
+// for what each field controls.
+type RuleRetryConfig struct {
+	MaxAttempts     int           `json:"max_attempts,omitempty" bson:"max_attempts,omitempty"`
+	InitialInterval time.Duration `json:"initial_interval,omitempty" bson:"initial_interval,omitempty"`
+	MaxInterval     time.Duration `json:"max_interval,omitempty" bson:"max_interval,omitempty"`
+	Multiplier      float64       `json:"multiplier,omitempty" bson:"multiplier,omitempty"`
+}
+
+// EnrichmentRuleAudit is one entry in an EnrichmentRule's write history, as
+// recorded by EnrichmentRepository on every create/update/delete and
+// returned newest-first by GetEnrichmentRuleHistory. Before/After hold full
+// rule snapshots rather than a diff, so an operator can roll back to any
+// recorded version without needing to replay the ones in between.
+type EnrichmentRuleAudit struct {
+	ID       string          `json:"id" bson:"_id,omitempty"`
+	TenantID string          `json:"tenant_id" bson:"tenant_id"`
+	RuleID   string          `json:"rule_id" bson:"rule_id"`
+	Version  int             `json:"version" bson:"version"`
+	Actor    string          `json:"actor,omitempty" bson:"actor,omitempty"`
+	Action   string          `json:"action" bson:"action"`
+	Before   *EnrichmentRule `json:"before,omitempty" bson:"before,omitempty"`
+	After    *EnrichmentRule `json:"after,omitempty" bson:"after,omitempty"`
+	At       time.Time       `json:"at" bson:"at"`
+	// FromVersion is the version this entry rolled back to; set only when
+	// Action is "rollback" (see EnrichmentRepository.RollbackEnrichmentRule),
+	// zero otherwise.
+	FromVersion int `json:"from_version,omitempty" bson:"from_version,omitempty"`
+	// _ declares the (rule_id, version) lookup GetEnrichmentRuleHistory
+	// filters and sorts on; see EnrichmentRule's equivalent field and
+	// migrations.IndexModelsFromTags.
+	_ struct{} `indexes:"rule_id:1,version:-1;name=idx_enrichment_rules_audit_rule_version"`
 }
 
 type EnrichmentSourceConfig struct {
@@ -56,6 +215,47 @@ type EnrichmentSourceConfig struct {
 
 	KeyPattern string `json:"key_pattern,omitempty" bson:"key_pattern"`
 	CacheType  string `json:"cache_type,omitempty" bson:"cache_type"`
+
+	// QueryParams, Body and BodyContentType are http-source-type-only. See
+	// provider.SourceConfig for the {query:K}/{header:K} templating they
+	// support.
+	QueryParams     map[string]string `json:"query_params,omitempty" bson:"query_params"`
+	Body            string            `json:"body,omitempty" bson:"body"`
+	BodyContentType string            `json:"body_content_type,omitempty" bson:"body_content_type"`
+
+	// AuthType, AuthToken, AuthUsername and AuthPassword configure
+	// http-source-type authentication ("bearer", "basic", or "mtls");
+	// values may be "${scheme:ref}" secret placeholders.
+	AuthType     string `json:"auth_type,omitempty" bson:"auth_type"`
+	AuthToken    string `json:"auth_token,omitempty" bson:"auth_token"`
+	AuthUsername string `json:"auth_username,omitempty" bson:"auth_username"`
+	AuthPassword string `json:"auth_password,omitempty" bson:"auth_password"`
+
+	// ClientCertFile, ClientKeyFile, CAFile and ServerName configure mTLS
+	// for the http source type (AuthType "mtls") or the dial credentials
+	// for the grpc source type.
+	ClientCertFile string `json:"client_cert_file,omitempty" bson:"client_cert_file"`
+	ClientKeyFile  string `json:"client_key_file,omitempty" bson:"client_key_file"`
+	CAFile         string `json:"ca_file,omitempty" bson:"ca_file"`
+	ServerName     string `json:"server_name,omitempty" bson:"server_name"`
+
+	// ResponseJSONPath extracts a nested value from an http-source-type
+	// response before Transformations run.
+	ResponseJSONPath string `json:"response_json_path,omitempty" bson:"response_json_path"`
+
+	// Address is the gRPC dial target for a grpc source type rule.
+	Address string `json:"address,omitempty" bson:"address"`
+
+	// KafkaBrokers and KafkaTopic configure a kafka_lookup source type rule:
+	// see provider.SourceConfig's KafkaBrokers/KafkaTopic.
+	KafkaBrokers []string `json:"kafka_brokers,omitempty" bson:"kafka_brokers"`
+	KafkaTopic   string   `json:"kafka_topic,omitempty" bson:"kafka_topic"`
+
+	// FilePath, FileFormat and KeyField configure a file source type rule:
+	// see provider.SourceConfig's FilePath/FileFormat/KeyField.
+	FilePath   string `json:"file_path,omitempty" bson:"file_path"`
+	FileFormat string `json:"file_format,omitempty" bson:"file_format"`
+	KeyField   string `json:"key_field,omitempty" bson:"key_field"`
 }
 
 type EnrichmentTransformation struct {
@@ -70,12 +270,23 @@ type CreateEnrichmentRuleRequest struct {
 	FieldToEnrich   string                     `json:"field_to_enrich" binding:"required"`
 	SourceType      string                     `json:"source_type" binding:"required"`
 	SourceConfig    EnrichmentSourceConfig     `json:"source_config" binding:"required"`
+	Condition       string                     `json:"condition"`
 	Transformations []EnrichmentTransformation `json:"transformations"`
 	CacheTTLSeconds int                        `json:"cache_ttl_seconds"`
 	ErrorHandling   string                     `json:"error_handling"`
 	FallbackValue   interface{}                `json:"fallback_value"`
 	Priority        int                        `json:"priority"`
 	Enabled         *bool                      `json:"enabled"`
+	Mode            string                     `json:"mode"`
+	// CircuitBreaker and Retry are this rule's per-rule overrides; see
+	// EnrichmentRule.CircuitBreaker/Retry. Nil uses the source type's
+	// service-level defaults, as before these fields existed.
+	CircuitBreaker *RuleCircuitBreakerConfig `json:"circuit_breaker,omitempty"`
+	Retry          *RuleRetryConfig          `json:"retry,omitempty"`
+	Schedule       *Schedule                 `json:"schedule,omitempty"`
+	// SampleEvents is CreateFilteringRuleRequest.SampleEvents's counterpart
+	// for ?dry_run=true; ignored on a real create.
+	SampleEvents []SampleEvent `json:"sample_events,omitempty"`
 }
 
 type UpdateEnrichmentRuleRequest struct {
@@ -83,19 +294,48 @@ type UpdateEnrichmentRuleRequest struct {
 	FieldToEnrich   *string                     `json:"field_to_enrich"`
 	SourceType      *string                     `json:"source_type"`
 	SourceConfig    *EnrichmentSourceConfig     `json:"source_config"`
+	Condition       *string                     `json:"condition"`
 	Transformations *[]EnrichmentTransformation `json:"transformations"`
 	CacheTTLSeconds *int                        `json:"cache_ttl_seconds"`
 	ErrorHandling   *string                     `json:"error_handling"`
 	FallbackValue   *interface{}                `json:"fallback_value"`
 	Priority        *int                        `json:"priority"`
 	Enabled         *bool                       `json:"enabled"`
+	Mode            *string                     `json:"mode"`
+	// CircuitBreaker and Retry are CreateEnrichmentRuleRequest's
+	// counterparts; a non-nil pointer here replaces the existing override
+	// outright (there's no field-by-field merge), matching how
+	// SourceConfig is already replaced wholesale on update.
+	CircuitBreaker *RuleCircuitBreakerConfig `json:"circuit_breaker,omitempty"`
+	Retry          *RuleRetryConfig          `json:"retry,omitempty"`
+	Schedule       *Schedule                 `json:"schedule,omitempty"`
+	Version        *int                      `json:"version"`
+	// SampleEvents is CreateEnrichmentRuleRequest.SampleEvents's
+	// counterpart for ?dry_run=true on an update.
+	SampleEvents []SampleEvent `json:"sample_events,omitempty"`
 }
 
 type DeduplicationConfig struct {
+	TenantID      string   `json:"tenant_id,omitempty"`
 	HashAlgorithm string   `json:"hash_algorithm"`
 	TTLSeconds    int      `json:"ttl_seconds"`
 	OnRedisError  string   `json:"on_redis_error"`
 	FieldsToHash  []string `json:"fields_to_hash"`
+	// Salt is folded into every fingerprint computed from FieldsToHash;
+	// see deduplication.Hasher.
+	Salt string `json:"salt,omitempty"`
+	// HMACKeyRef is the HMAC key used when HashAlgorithm is "hmac-sha256".
+	// Like config.DeduplicationConfig.HMACKey it may be a rotating
+	// "${vault:...}"/"${env:...}" reference rather than a literal key;
+	// unlike that field, nothing here resolves it - it's handed to
+	// whichever deduplication.Service instance picks up the config update
+	// (see ConfigEventProducer.PublishDedupConfigEvent) exactly as given.
+	HMACKeyRef string `json:"hmac_key_ref,omitempty"`
+	// Version increases by one on every UpdateDeduplicationConfig call, so
+	// callers can address a specific revision via
+	// Service.GetDeduplicationConfigVersion/RollbackDeduplicationConfig the
+	// same way rule versions are addressed.
+	Version int `json:"version"`
 }
 
 type UpdateDeduplicationConfigRequest struct {
@@ -103,4 +343,81 @@ type UpdateDeduplicationConfigRequest struct {
 	TTLSeconds    *int      `json:"ttl_seconds,omitempty"`
 	OnRedisError  *string   `json:"on_redis_error,omitempty"`
 	FieldsToHash  *[]string `json:"fields_to_hash,omitempty"`
+	Salt          *string   `json:"salt,omitempty"`
+	HMACKeyRef    *string   `json:"hmac_key_ref,omitempty"`
+}
+
+// DeduplicationPreviewRequest carries a sample event for
+// Service.PreviewDeduplicationFingerprint to fingerprint with the tenant's
+// current (or a hypothetically overridden) deduplication config, without
+// writing anything to Redis.
+type DeduplicationPreviewRequest struct {
+	// SampleEvent is shaped like models.MessageEnvelope.Payload: the
+	// business-data map a field expression like "payload.user.id"
+	// resolves against, rooted the same way deduplication.Service's
+	// buildMessageData nests it.
+	SampleEvent map[string]interface{} `json:"sample_event"`
+	// ID and Source stand in for the envelope fields buildMessageData
+	// always sets alongside Payload, since SampleEvent only carries the
+	// payload.
+	ID     string `json:"id,omitempty"`
+	Source string `json:"source,omitempty"`
+	// Config, if set, previews against this config instead of the
+	// tenant's currently stored one - e.g. to see what a proposed
+	// FieldsToHash/Salt/HashAlgorithm change would produce before saving it.
+	Config *UpdateDeduplicationConfigRequest `json:"config,omitempty"`
+}
+
+type DeduplicationPreviewResponse struct {
+	Fingerprint string `json:"fingerprint"`
+}
+
+// ExternalProvider registers an external gRPC enrichment plugin (see
+// proto/enrichment/v1/provider.proto) for a SourceType. enrichment-service
+// discovers these at startup and dials one connection per provider; this
+// record is never read by the enrichment rule path itself.
+type ExternalProvider struct {
+	ID         string `json:"id" bson:"_id,omitempty"`
+	TenantID   string `json:"tenant_id" bson:"tenant_id"`
+	Name       string `json:"name" bson:"name"`
+	SourceType string `json:"source_type" bson:"source_type"`
+	Address    string `json:"address" bson:"address"`
+	TLSCert    string `json:"tls_cert,omitempty" bson:"tls_cert,omitempty"`
+	// LoadBalancingPolicy is the gRPC client-side load-balancing policy
+	// the enrichment service dials this provider with: "round_robin" or
+	// "pick_first" (the default when empty). See GRPCProviderConfig.
+	LoadBalancingPolicy string    `json:"load_balancing_policy,omitempty" bson:"load_balancing_policy,omitempty"`
+	CreatedAt           time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+type RegisterProviderRequest struct {
+	Name                string `json:"name" binding:"required"`
+	SourceType          string `json:"source_type" binding:"required"`
+	Address             string `json:"address" binding:"required"`
+	TLSCert             string `json:"tls_cert,omitempty"`
+	LoadBalancingPolicy string `json:"load_balancing_policy,omitempty"`
+}
+
+// ReplayDeadLettersRequest is the request body for POST /dead-letters/replay.
+// An empty/omitted TargetTopic replays each entry to the topic it originally
+// failed on.
+type ReplayDeadLettersRequest struct {
+	IDs         []string `json:"ids" binding:"required"`
+	TargetTopic string   `json:"target_topic,omitempty"`
+}
+
+// ReplayResult reports one outcome per requested ID, in request order - the
+// batch-endpoint shape also used by RuleBatchResult, so a client that
+// replays several dead letters at once gets the same "which ones actually
+// landed" reporting it already gets from the rule batch endpoints.
+type ReplayResult struct {
+	Results []ReplayEntryResult `json:"results"`
+}
+
+// ReplayEntryResult is one ReplayResult entry.
+type ReplayEntryResult struct {
+	ID    string `json:"id"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
 }
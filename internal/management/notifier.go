@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"reflect"
 	"time"
 
 	"github.com/google/uuid"
@@ -23,7 +24,17 @@ func NewConfigEventProducer(producer kafka.Producer, topic string) *ConfigEventP
 	}
 }
 
-func (p *ConfigEventProducer) PublishFilteringRuleEvent(ctx context.Context, action, ruleID, changedBy string) error {
+// PublishFilteringRuleEvent publishes action for ruleID, embedding rule's
+// JSON encoding in the event's Rule field so a filtering.Service consumer
+// can apply the change directly via ApplyRuleDelta instead of fetching it
+// back out of the repository. Pass nil for rule on a delete (there's
+// nothing left to embed).
+func (p *ConfigEventProducer) PublishFilteringRuleEvent(ctx context.Context, action, ruleID, changedBy string, rule *FilteringRule) error {
+	ruleJSON, err := marshalRuleDelta(rule)
+	if err != nil {
+		return err
+	}
+
 	event := models.ConfigUpdateEvent{
 		EventType:   models.EventTypeFilteringRuleUpdated,
 		ServiceType: models.ServiceTypeFiltering,
@@ -31,11 +42,19 @@ func (p *ConfigEventProducer) PublishFilteringRuleEvent(ctx context.Context, act
 		Action:      action,
 		Timestamp:   time.Now(),
 		ChangedBy:   changedBy,
+		Rule:        ruleJSON,
 	}
 	return p.publishEvent(ctx, event)
 }
 
-func (p *ConfigEventProducer) PublishEnrichmentRuleEvent(ctx context.Context, action, ruleID, changedBy string) error {
+// PublishEnrichmentRuleEvent is PublishFilteringRuleEvent's enrichment
+// counterpart; see its doc comment.
+func (p *ConfigEventProducer) PublishEnrichmentRuleEvent(ctx context.Context, action, ruleID, changedBy string, rule *EnrichmentRule) error {
+	ruleJSON, err := marshalRuleDelta(rule)
+	if err != nil {
+		return err
+	}
+
 	event := models.ConfigUpdateEvent{
 		EventType:   models.EventTypeEnrichmentRuleUpdated,
 		ServiceType: models.ServiceTypeEnrichment,
@@ -43,6 +62,45 @@ func (p *ConfigEventProducer) PublishEnrichmentRuleEvent(ctx context.Context, ac
 		Action:      action,
 		Timestamp:   time.Now(),
 		ChangedBy:   changedBy,
+		Rule:        ruleJSON,
+	}
+	return p.publishEvent(ctx, event)
+}
+
+// marshalRuleDelta marshals rule for embedding in a ConfigUpdateEvent.Rule,
+// or returns nil if rule is nil (e.g. a delete, which has nothing to embed).
+func marshalRuleDelta(rule interface{}) (json.RawMessage, error) {
+	if rule == nil || reflect.ValueOf(rule).IsNil() {
+		return nil, nil
+	}
+
+	ruleJSON, err := json.Marshal(rule)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal rule delta: %w", err)
+	}
+	return ruleJSON, nil
+}
+
+// PublishEnrichmentBreakerStateEvent publishes an enrichment circuit
+// breaker's state transition, for external alerting rather than config
+// replication - its signature matches provider.BreakerEventPublisher
+// structurally, so cmd/enrichment-service can hand a *ConfigEventProducer
+// straight to provider.SetBreakerEventPublisher without this package
+// importing internal/enrichment/provider. ruleID is empty for a
+// source-level breaker (e.g. "http", "grpc"); set for a rule-scoped one
+// (see Rule.CircuitBreaker).
+func (p *ConfigEventProducer) PublishEnrichmentBreakerStateEvent(ctx context.Context, sourceName, ruleID, from, to string) error {
+	event := models.ConfigUpdateEvent{
+		EventType:   models.EventTypeEnrichmentBreakerStateChanged,
+		ServiceType: models.ServiceTypeEnrichment,
+		RuleID:      ruleID,
+		Action:      models.ActionUpdate,
+		Timestamp:   time.Now(),
+		Metadata: map[string]interface{}{
+			"source": sourceName,
+			"from":   from,
+			"to":     to,
+		},
 	}
 	return p.publishEvent(ctx, event)
 }
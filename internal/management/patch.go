@@ -0,0 +1,300 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	pkgerrors "yeti/pkg/errors"
+)
+
+// patchCtxKey carries the raw patch document a PATCH request was submitted
+// with down into createVersionAndAudit/recordDedupConfigVersion, so the
+// audit log can record the diff that was actually sent rather than a
+// recomputed before/after snapshot. Mirrors tenantCtxKey/scopesCtxKey in
+// auth.go.
+const patchCtxKey ctxKey = "management_patch_document"
+
+func withPatchDocument(ctx context.Context, raw json.RawMessage) context.Context {
+	return context.WithValue(ctx, patchCtxKey, raw)
+}
+
+// patchDocumentFromContext returns the raw patch body the current request
+// carried, or nil if this write didn't originate from a PATCH handler.
+func patchDocumentFromContext(ctx context.Context) json.RawMessage {
+	raw, _ := ctx.Value(patchCtxKey).(json.RawMessage)
+	return raw
+}
+
+// immutablePatchFields can never be touched by a PATCH, on either a
+// filtering rule, an enrichment rule, or the deduplication config: id is
+// the row's identity and created_at is set once at INSERT time by the
+// store, not something a client request should be able to rewrite.
+var immutablePatchFields = map[string]bool{
+	"id":         true,
+	"created_at": true,
+}
+
+// contentTypeJSONPatch and contentTypeMergePatch are the two Content-Type
+// values ApplyPatch dispatches on, per RFC 6902 and RFC 7396
+// respectively. Anything else (including a bare "application/json") is
+// rejected rather than guessed at, since the two patch formats parse a
+// JSON body completely differently (an array of ops vs. an object to
+// merge).
+const (
+	contentTypeJSONPatch  = "application/json-patch+json"
+	contentTypeMergePatch = "application/merge-patch+json"
+)
+
+// ApplyPatch applies body (either an RFC 6902 JSON Patch array or an RFC
+// 7396 JSON Merge Patch object, selected by contentType) on top of
+// current, returning the fully-resolved patched document as
+// map[string]interface{} so callers can re-unmarshal it into whichever
+// Update*Request struct the target entity shares JSON tags with. It
+// rejects any attempt to touch immutablePatchFields with
+// pkgerrors.ErrUnprocessableEntity carrying the offending "pointer" detail.
+func ApplyPatch(current interface{}, contentType string, body []byte) (map[string]interface{}, error) {
+	currentJSON, err := json.Marshal(current)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal current value for patch: %w", err)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(currentJSON, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode current value for patch: %w", err)
+	}
+
+	mediaType := contentType
+	if idx := strings.IndexByte(mediaType, ';'); idx >= 0 {
+		mediaType = mediaType[:idx]
+	}
+	mediaType = strings.TrimSpace(mediaType)
+
+	switch mediaType {
+	case contentTypeJSONPatch:
+		var ops []PatchOp
+		if err := json.Unmarshal(body, &ops); err != nil {
+			return nil, pkgerrors.ErrValidation.WithCause(err).WithDetail("message", "invalid JSON Patch document")
+		}
+		for _, op := range ops {
+			if pointer, immutable := immutablePatchFieldAt(op.Path); immutable {
+				return nil, pkgerrors.ErrUnprocessableEntity.
+					WithDetail("message", fmt.Sprintf("patch may not modify immutable field %q", pointer)).
+					WithDetail("pointer", pointer)
+			}
+		}
+		if err := applyJSONPatch(doc, ops); err != nil {
+			return nil, pkgerrors.ErrUnprocessableEntity.WithCause(err)
+		}
+	case contentTypeMergePatch:
+		var patch map[string]interface{}
+		if err := json.Unmarshal(body, &patch); err != nil {
+			return nil, pkgerrors.ErrValidation.WithCause(err).WithDetail("message", "invalid JSON Merge Patch document")
+		}
+		if pointer, immutable := immutableMergeField(patch); immutable {
+			return nil, pkgerrors.ErrUnprocessableEntity.
+				WithDetail("message", fmt.Sprintf("patch may not modify immutable field %q", pointer)).
+				WithDetail("pointer", pointer)
+		}
+		doc = applyMergePatch(doc, patch)
+	default:
+		return nil, pkgerrors.ErrValidation.WithDetail("message",
+			fmt.Sprintf("unsupported patch Content-Type %q, expected %q or %q", contentType, contentTypeJSONPatch, contentTypeMergePatch))
+	}
+
+	return doc, nil
+}
+
+// immutablePatchFieldAt reports whether path (an RFC 6901 JSON Pointer)
+// targets one of immutablePatchFields at the top level, e.g. "/id" or
+// "/created_at". Nested paths (e.g. "/metadata/id") are left alone since
+// they don't collide with the entity's own top-level id/created_at.
+func immutablePatchFieldAt(path string) (string, bool) {
+	token := strings.TrimPrefix(path, "/")
+	if idx := strings.IndexByte(token, '/'); idx >= 0 {
+		return "", false
+	}
+	token = unescapePatchToken(token)
+	return path, immutablePatchFields[token]
+}
+
+// immutableMergeField reports whether patch directly sets one of
+// immutablePatchFields, returning the JSON Pointer a json-patch caller
+// would have used for the same field so both error shapes line up.
+func immutableMergeField(patch map[string]interface{}) (string, bool) {
+	for field := range immutablePatchFields {
+		if _, ok := patch[field]; ok {
+			return "/" + field, true
+		}
+	}
+	return "", false
+}
+
+// unescapePatchToken reverses escapePatchToken (versioning.go): "~1"
+// becomes "/" and "~0" becomes "~", in that order so a literal "~01"
+// round-trips as "~1" rather than "/".
+func unescapePatchToken(token string) string {
+	token = strings.ReplaceAll(token, "~1", "/")
+	token = strings.ReplaceAll(token, "~0", "~")
+	return token
+}
+
+// applyJSONPatch applies ops to doc in place, per RFC 6902. Only "add",
+// "remove", "replace" and "test" are implemented: rule_data and the
+// deduplication config have no arrays worth reordering, so "move"/"copy"
+// (the two ops jsonPatchDiff never generates either, see versioning.go)
+// are rejected rather than silently ignored.
+func applyJSONPatch(doc map[string]interface{}, ops []PatchOp) error {
+	for _, op := range ops {
+		tokens := pointerTokens(op.Path)
+		switch op.Op {
+		case "add", "replace":
+			if err := setAtPointer(doc, tokens, op.Value); err != nil {
+				return fmt.Errorf("op %q at %q: %w", op.Op, op.Path, err)
+			}
+		case "remove":
+			if err := removeAtPointer(doc, tokens); err != nil {
+				return fmt.Errorf("op \"remove\" at %q: %w", op.Path, err)
+			}
+		case "test":
+			current, err := getAtPointer(doc, tokens)
+			if err != nil {
+				return fmt.Errorf("op \"test\" at %q: %w", op.Path, err)
+			}
+			currentJSON, _ := json.Marshal(current)
+			valueJSON, _ := json.Marshal(op.Value)
+			if string(currentJSON) != string(valueJSON) {
+				return fmt.Errorf("op \"test\" at %q: value mismatch", op.Path)
+			}
+		default:
+			return fmt.Errorf("unsupported patch op %q", op.Op)
+		}
+	}
+	return nil
+}
+
+func pointerTokens(path string) []string {
+	if path == "" || path == "/" {
+		return nil
+	}
+	raw := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	tokens := make([]string, len(raw))
+	for i, t := range raw {
+		tokens[i] = unescapePatchToken(t)
+	}
+	return tokens
+}
+
+func getAtPointer(doc map[string]interface{}, tokens []string) (interface{}, error) {
+	if len(tokens) == 0 {
+		return doc, nil
+	}
+	var cur interface{} = doc
+	for _, token := range tokens {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("pointer traverses a non-object value")
+		}
+		v, ok := m[token]
+		if !ok {
+			return nil, fmt.Errorf("member %q not found", token)
+		}
+		cur = v
+	}
+	return cur, nil
+}
+
+func setAtPointer(doc map[string]interface{}, tokens []string, value interface{}) error {
+	if len(tokens) == 0 {
+		return fmt.Errorf("cannot replace the document root")
+	}
+	parent, err := navigateToParent(doc, tokens)
+	if err != nil {
+		return err
+	}
+	parent[tokens[len(tokens)-1]] = value
+	return nil
+}
+
+func removeAtPointer(doc map[string]interface{}, tokens []string) error {
+	if len(tokens) == 0 {
+		return fmt.Errorf("cannot remove the document root")
+	}
+	parent, err := navigateToParent(doc, tokens)
+	if err != nil {
+		return err
+	}
+	last := tokens[len(tokens)-1]
+	if _, ok := parent[last]; !ok {
+		return fmt.Errorf("member %q not found", last)
+	}
+	delete(parent, last)
+	return nil
+}
+
+// navigateToParent walks tokens[:len(tokens)-1] from doc, returning the
+// map the final token should be applied against. rule_data/dedup-config
+// documents are plain nested objects (no arrays in the pointer path), so
+// unlike a general-purpose RFC 6901 implementation this doesn't need to
+// handle "-" or numeric array indices.
+func navigateToParent(doc map[string]interface{}, tokens []string) (map[string]interface{}, error) {
+	cur := doc
+	for _, token := range tokens[:len(tokens)-1] {
+		next, ok := cur[token]
+		if !ok {
+			return nil, fmt.Errorf("member %q not found", token)
+		}
+		nextMap, ok := next.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("member %q is not an object", token)
+		}
+		cur = nextMap
+	}
+	return cur, nil
+}
+
+// applyMergePatch applies patch onto doc per RFC 7396: a null value
+// deletes the member, an object value merges recursively, and anything
+// else replaces the member wholesale. doc is not mutated; the merged
+// result is returned.
+func applyMergePatch(doc, patch map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(doc))
+	for k, v := range doc {
+		result[k] = v
+	}
+
+	for k, patchValue := range patch {
+		if patchValue == nil {
+			delete(result, k)
+			continue
+		}
+		patchObj, patchIsObj := patchValue.(map[string]interface{})
+		existingObj, existingIsObj := result[k].(map[string]interface{})
+		if patchIsObj && existingIsObj {
+			result[k] = applyMergePatch(existingObj, patchObj)
+		} else if patchIsObj {
+			result[k] = applyMergePatch(map[string]interface{}{}, patchObj)
+		} else {
+			result[k] = patchValue
+		}
+	}
+	return result
+}
+
+// decodeInto re-marshals doc and unmarshals it into target, which is
+// expected to be a pointer to one of the pointer-field Update*Request
+// structs (UpdateFilteringRuleRequest, UpdateEnrichmentRuleRequest,
+// UpdateDeduplicationConfigRequest). Their JSON tags match the
+// corresponding entity's own tags field-for-field, so the patched
+// document - every field, not just the ones the incoming patch touched -
+// decodes straight into the *pointer* fields the Update*Request already
+// uses to mean "this field was set"; ValidateX and Service.UpdateX then
+// run exactly as they do for a full PUT.
+func decodeInto(doc map[string]interface{}, target interface{}) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
@@ -0,0 +1,104 @@
+package management
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"yeti/internal/logger"
+	"yeti/pkg/errors"
+)
+
+type ProviderHandler struct {
+	BaseHandler
+}
+
+func NewProviderHandler(service Service, log logger.Logger) *ProviderHandler {
+	return &ProviderHandler{
+		BaseHandler: BaseHandler{
+			Service: service,
+			Logger:  log,
+		},
+	}
+}
+
+// RegisterProviderRoutes wires /api/v1/providers. Registering a plugin is
+// gated on config:write like API key management: it's an administrative
+// action that lets the caller point the enrichment pipeline at an
+// arbitrary address.
+func (h *ProviderHandler) RegisterProviderRoutes(router *gin.Engine) {
+	v1 := router.Group("/api/v1")
+	{
+		providers := v1.Group("/providers")
+		{
+			providers.GET("", RequireScope(ScopeRulesRead), h.ListProviders)
+			providers.POST("", RequireScope(ScopeConfigWrite), h.RegisterProvider)
+			providers.DELETE("/:id", RequireScope(ScopeConfigWrite), h.DeleteProvider)
+		}
+	}
+}
+
+// ListProviders godoc
+// @Summary      List external enrichment providers
+// @Description  List gRPC enrichment providers registered for the caller's tenant
+// @Tags         providers
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}    ExternalProvider
+// @Failure      500  {object}  errors.ErrorResponse
+// @Router       /providers [get]
+func (h *ProviderHandler) ListProviders(c *gin.Context) {
+	providers, err := h.Service.ListProviders(c.Request.Context())
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, providers)
+}
+
+// RegisterProvider godoc
+// @Summary      Register an external enrichment provider
+// @Description  Register a gRPC enrichment plugin's address (and optional TLS cert) for a source type. enrichment-service discovers it at its next startup.
+// @Tags         providers
+// @Accept       json
+// @Produce      json
+// @Param        provider  body      RegisterProviderRequest  true  "Provider name, source type, address, and optional TLS cert"
+// @Success      201  {object}   ExternalProvider
+// @Failure      400  {object}  errors.ErrorResponse
+// @Failure      500  {object}  errors.ErrorResponse
+// @Router       /providers [post]
+func (h *ProviderHandler) RegisterProvider(c *gin.Context) {
+	var req RegisterProviderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.HandleError(c, errors.ErrValidation.WithCause(err))
+		return
+	}
+
+	provider, err := h.Service.RegisterProvider(c.Request.Context(), req)
+	if err != nil {
+		h.HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, provider)
+}
+
+// DeleteProvider godoc
+// @Summary      Unregister an external enrichment provider
+// @Description  Remove a previously registered gRPC enrichment provider by ID
+// @Tags         providers
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "Provider ID"
+// @Success      204  "No Content"
+// @Failure      404  {object}  errors.ErrorResponse
+// @Failure      500  {object}  errors.ErrorResponse
+// @Router       /providers/{id} [delete]
+func (h *ProviderHandler) DeleteProvider(c *gin.Context) {
+	id := c.Param("id")
+	if err := h.Service.DeleteProvider(c.Request.Context(), id); err != nil {
+		h.HandleError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
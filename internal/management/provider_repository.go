@@ -0,0 +1,95 @@
+package management
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ProviderRepository persists external enrichment provider registrations.
+// enrichment-service reads the same "enrichment_providers" collection
+// directly to discover providers at startup, rather than calling back into
+// this service.
+type ProviderRepository interface {
+	Create(ctx context.Context, provider *ExternalProvider) error
+	List(ctx context.Context, tenantID string) ([]ExternalProvider, error)
+	Get(ctx context.Context, tenantID, id string) (*ExternalProvider, error)
+	Delete(ctx context.Context, tenantID, id string) error
+}
+
+type mongoProviderRepository struct {
+	collection *mongo.Collection
+}
+
+func NewProviderRepository(db *mongo.Database) ProviderRepository {
+	return &mongoProviderRepository{
+		collection: db.Collection("enrichment_providers"),
+	}
+}
+
+func (r *mongoProviderRepository) Create(ctx context.Context, provider *ExternalProvider) error {
+	if provider.ID == "" {
+		provider.ID = uuid.New().String()
+	}
+	now := time.Now()
+	provider.CreatedAt = now
+	provider.UpdatedAt = now
+
+	_, err := r.collection.InsertOne(ctx, provider)
+	if err != nil {
+		return fmt.Errorf("failed to create provider: %w", err)
+	}
+
+	return nil
+}
+
+func (r *mongoProviderRepository) List(ctx context.Context, tenantID string) ([]ExternalProvider, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"tenant_id": tenantID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list providers: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var providers []ExternalProvider
+	if err := cursor.All(ctx, &providers); err != nil {
+		return nil, fmt.Errorf("failed to decode providers: %w", err)
+	}
+
+	return providers, nil
+}
+
+func (r *mongoProviderRepository) Get(ctx context.Context, tenantID, id string) (*ExternalProvider, error) {
+	filter := bson.M{"_id": id, "tenant_id": tenantID}
+
+	var provider ExternalProvider
+	err := r.collection.FindOne(ctx, filter).Decode(&provider)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("provider not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get provider: %w", err)
+	}
+
+	return &provider, nil
+}
+
+func (r *mongoProviderRepository) Delete(ctx context.Context, tenantID, id string) error {
+	filter := bson.M{"_id": id, "tenant_id": tenantID}
+
+	result, err := r.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to delete provider: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("provider not found")
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package management
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -17,7 +18,9 @@ type Repository interface {
 	CreateFilteringRule(ctx context.Context, rule *FilteringRule) error
 	ListFilteringRules(ctx context.Context) ([]FilteringRule, error)
 	GetFilteringRule(ctx context.Context, id string) (*FilteringRule, error)
+	GetForUpdate(ctx context.Context, id string) (*FilteringRule, error)
 	UpdateFilteringRule(ctx context.Context, rule *FilteringRule) error
+	UpdateFilteringRuleCAS(ctx context.Context, rule *FilteringRule, expectedVersion int) error
 	DeleteFilteringRule(ctx context.Context, id string) error
 }
 
@@ -29,22 +32,52 @@ func NewRepository(db *sql.DB) Repository {
 	return &PostgresRepository{db: db}
 }
 
+// scheduleToColumn marshals a rule's optional Schedule for storage in the
+// filtering_rules.schedule JSONB column, returning nil for an unset schedule
+// so the column stores SQL NULL rather than the JSON literal "null".
+func scheduleToColumn(sched *Schedule) ([]byte, error) {
+	if sched == nil {
+		return nil, nil
+	}
+	return json.Marshal(sched)
+}
+
+// scheduleFromColumn reverses scheduleToColumn, treating a NULL/empty column
+// as an unset schedule.
+func scheduleFromColumn(raw []byte) (*Schedule, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var sched Schedule
+	if err := json.Unmarshal(raw, &sched); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal schedule: %w", err)
+	}
+	return &sched, nil
+}
+
 func (r *PostgresRepository) CreateFilteringRule(ctx context.Context, rule *FilteringRule) error {
 	if rule.ID == "" {
 		rule.ID = uuid.New().String()
 	}
+	rule.TenantID = TenantIDFromContext(ctx)
 	now := time.Now()
 	rule.CreatedAt = now
 	rule.UpdatedAt = now
+	rule.Version = 1
+
+	scheduleJSON, err := scheduleToColumn(rule.Schedule)
+	if err != nil {
+		return fmt.Errorf("failed to marshal schedule: %w", err)
+	}
 
 	query := `
-		INSERT INTO filtering_rules (id, name, expression, priority, enabled, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO filtering_rules (id, tenant_id, name, expression, priority, enabled, mode, schedule, max_cost, max_eval_duration_ms, version, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 	`
 
-	_, err := r.db.ExecContext(ctx, query,
-		rule.ID, rule.Name, rule.Expression,
-		rule.Priority, rule.Enabled, rule.CreatedAt, rule.UpdatedAt,
+	_, err = r.db.ExecContext(ctx, query,
+		rule.ID, rule.TenantID, rule.Name, rule.Expression,
+		rule.Priority, rule.Enabled, rule.Mode, scheduleJSON, rule.MaxCost, rule.MaxEvalDurationMs, rule.Version, rule.CreatedAt, rule.UpdatedAt,
 	)
 	if err != nil {
 		if pqErr, ok := err.(*pq.Error); ok {
@@ -63,17 +96,18 @@ func (r *PostgresRepository) CreateFilteringRule(ctx context.Context, rule *Filt
 
 func (r *PostgresRepository) GetFilteringRule(ctx context.Context, id string) (*FilteringRule, error) {
 	query := `
-		SELECT id, name, expression, priority, enabled, created_at, updated_at
+		SELECT id, tenant_id, name, expression, priority, enabled, mode, schedule, max_cost, max_eval_duration_ms, version, created_at, updated_at
 		FROM filtering_rules
-		WHERE id = $1
+		WHERE id = $1 AND tenant_id = $2
 	`
 
-	row := r.db.QueryRowContext(ctx, query, id)
+	row := r.db.QueryRowContext(ctx, query, id, TenantIDFromContext(ctx))
 
 	var rule FilteringRule
+	var scheduleJSON []byte
 	err := row.Scan(
-		&rule.ID, &rule.Name, &rule.Expression,
-		&rule.Priority, &rule.Enabled, &rule.CreatedAt, &rule.UpdatedAt,
+		&rule.ID, &rule.TenantID, &rule.Name, &rule.Expression,
+		&rule.Priority, &rule.Enabled, &rule.Mode, &scheduleJSON, &rule.MaxCost, &rule.MaxEvalDurationMs, &rule.Version, &rule.CreatedAt, &rule.UpdatedAt,
 	)
 
 	if errors.Is(err, sql.ErrNoRows) {
@@ -83,17 +117,28 @@ func (r *PostgresRepository) GetFilteringRule(ctx context.Context, id string) (*
 		return nil, fmt.Errorf("failed to get rule: %w", err)
 	}
 
+	if rule.Schedule, err = scheduleFromColumn(scheduleJSON); err != nil {
+		return nil, err
+	}
+
 	return &rule, nil
 }
 
+// GetForUpdate fetches a rule along with its current version, for callers that
+// intend to write it back through UpdateFilteringRuleCAS.
+func (r *PostgresRepository) GetForUpdate(ctx context.Context, id string) (*FilteringRule, error) {
+	return r.GetFilteringRule(ctx, id)
+}
+
 func (r *PostgresRepository) ListFilteringRules(ctx context.Context) ([]FilteringRule, error) {
 	query := `
-		SELECT id, name, expression, priority, enabled, created_at, updated_at
+		SELECT id, tenant_id, name, expression, priority, enabled, mode, schedule, max_cost, max_eval_duration_ms, version, created_at, updated_at
 		FROM filtering_rules
+		WHERE tenant_id = $1
 		ORDER BY priority DESC, created_at DESC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := r.db.QueryContext(ctx, query, TenantIDFromContext(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to list rules: %w", err)
 	}
@@ -108,12 +153,16 @@ func (r *PostgresRepository) ListFilteringRules(ctx context.Context) ([]Filterin
 		}
 
 		var rule FilteringRule
+		var scheduleJSON []byte
 		if err := rows.Scan(
-			&rule.ID, &rule.Name, &rule.Expression,
-			&rule.Priority, &rule.Enabled, &rule.CreatedAt, &rule.UpdatedAt,
+			&rule.ID, &rule.TenantID, &rule.Name, &rule.Expression,
+			&rule.Priority, &rule.Enabled, &rule.Mode, &scheduleJSON, &rule.MaxCost, &rule.MaxEvalDurationMs, &rule.Version, &rule.CreatedAt, &rule.UpdatedAt,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan rule: %w", err)
 		}
+		if rule.Schedule, err = scheduleFromColumn(scheduleJSON); err != nil {
+			return nil, err
+		}
 		rules = append(rules, rule)
 	}
 
@@ -123,35 +172,69 @@ func (r *PostgresRepository) ListFilteringRules(ctx context.Context) ([]Filterin
 func (r *PostgresRepository) UpdateFilteringRule(ctx context.Context, rule *FilteringRule) error {
 	rule.UpdatedAt = time.Now()
 
+	scheduleJSON, err := scheduleToColumn(rule.Schedule)
+	if err != nil {
+		return fmt.Errorf("failed to marshal schedule: %w", err)
+	}
+
 	query := `
 		UPDATE filtering_rules
-		SET name = $1, expression = $2, priority = $3, enabled = $4, updated_at = $5
-		WHERE id = $6
+		SET name = $1, expression = $2, priority = $3, enabled = $4, mode = $5, schedule = $6, max_cost = $7, max_eval_duration_ms = $8, updated_at = $9, version = version + 1
+		WHERE id = $10 AND tenant_id = $11
+		RETURNING version
 	`
 
-	res, err := r.db.ExecContext(ctx, query,
+	err = r.db.QueryRowContext(ctx, query,
 		rule.Name, rule.Expression,
-		rule.Priority, rule.Enabled, rule.UpdatedAt, rule.ID,
-	)
+		rule.Priority, rule.Enabled, rule.Mode, scheduleJSON, rule.MaxCost, rule.MaxEvalDurationMs, rule.UpdatedAt, rule.ID, TenantIDFromContext(ctx),
+	).Scan(&rule.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("rule not found")
+	}
 	if err != nil {
 		return fmt.Errorf("failed to update rule: %w", err)
 	}
 
-	rows, err := res.RowsAffected()
+	return nil
+}
+
+// UpdateFilteringRuleCAS applies rule using optimistic concurrency control: the
+// write only succeeds if the row's current version still matches expectedVersion.
+// On a version mismatch (or if the rule no longer exists) it returns ErrConflict
+// so callers can refetch, re-merge their changes, and retry.
+func (r *PostgresRepository) UpdateFilteringRuleCAS(ctx context.Context, rule *FilteringRule, expectedVersion int) error {
+	rule.UpdatedAt = time.Now()
+
+	scheduleJSON, err := scheduleToColumn(rule.Schedule)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to marshal schedule: %w", err)
 	}
-	if rows == 0 {
-		return fmt.Errorf("rule not found")
+
+	query := `
+		UPDATE filtering_rules
+		SET name = $1, expression = $2, priority = $3, enabled = $4, mode = $5, schedule = $6, max_cost = $7, max_eval_duration_ms = $8, updated_at = $9, version = version + 1
+		WHERE id = $10 AND version = $11 AND tenant_id = $12
+		RETURNING version
+	`
+
+	err = r.db.QueryRowContext(ctx, query,
+		rule.Name, rule.Expression, rule.Priority, rule.Enabled, rule.Mode, scheduleJSON, rule.MaxCost, rule.MaxEvalDurationMs, rule.UpdatedAt,
+		rule.ID, expectedVersion, TenantIDFromContext(ctx),
+	).Scan(&rule.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return pkgerrors.ErrConflict.WithDetail("message", fmt.Sprintf("rule %s was modified by another request", rule.ID)).WithDetail("rule_id", rule.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update rule: %w", err)
 	}
 
 	return nil
 }
 
 func (r *PostgresRepository) DeleteFilteringRule(ctx context.Context, id string) error {
-	query := `DELETE FROM filtering_rules WHERE id = $1`
+	query := `DELETE FROM filtering_rules WHERE id = $1 AND tenant_id = $2`
 
-	res, err := r.db.ExecContext(ctx, query, id)
+	res, err := r.db.ExecContext(ctx, query, id, TenantIDFromContext(ctx))
 	if err != nil {
 		return fmt.Errorf("failed to delete rule: %w", err)
 	}
@@ -0,0 +1,695 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"yeti/internal/constants"
+	"yeti/internal/deduplication"
+	pkgerrors "yeti/pkg/errors"
+	"yeti/pkg/models"
+)
+
+type FieldDiff struct {
+	Field    string      `json:"field"`
+	OldValue interface{} `json:"old_value"`
+	NewValue interface{} `json:"new_value"`
+}
+
+// validateRolledBackExpression re-runs ValidateFilteringRule's hard CEL
+// check (compiles, returns bool) against a target version's Expression
+// before a rollback writes it back. A version that validated fine when it
+// was first saved can still fail this today if the CEL environment has
+// since lost a function or variable it depended on, so rollback can't just
+// trust a past validation result the way a plain field-copy would.
+func validateRolledBackExpression(expression string) error {
+	evaluator, err := newValidationEvaluator()
+	if err != nil {
+		return fmt.Errorf("failed to create CEL evaluator: %w", err)
+	}
+	if err := evaluator.ValidateFilterExpression(expression); err != nil {
+		return &ValidationError{Field: "expression", Message: fmt.Sprintf("invalid CEL expression: %s", err)}
+	}
+	return nil
+}
+
+// validateRolledBackEnrichmentRule is validateRolledBackExpression's
+// enrichment counterpart, re-checking target's Condition and
+// Transformations the same way ValidateEnrichmentRule does on create.
+func validateRolledBackEnrichmentRule(target *EnrichmentRule) error {
+	if target.Condition != "" {
+		evaluator, err := newValidationEvaluator()
+		if err != nil {
+			return fmt.Errorf("failed to create CEL evaluator: %w", err)
+		}
+		if err := evaluator.ValidateFilterExpression(target.Condition); err != nil {
+			return &ValidationError{Field: "condition", Message: fmt.Sprintf("invalid CEL expression: %s", err)}
+		}
+	}
+
+	ruleValidator, err := NewRuleValidator()
+	if err != nil {
+		return fmt.Errorf("failed to create rule validator: %w", err)
+	}
+	if _, err := ruleValidator.CompileTransformations(target.Transformations); err != nil {
+		return err
+	}
+	return nil
+}
+
+// DiffAuditLogs returns the field-level differences between the NewValue
+// snapshots of two audit log entries, sorted by field name so the UI can
+// render a stable diff regardless of map iteration order.
+func DiffAuditLogs(from, to AuditLog) []FieldDiff {
+	seen := make(map[string]struct{}, len(from.NewValue)+len(to.NewValue))
+	for field := range from.NewValue {
+		seen[field] = struct{}{}
+	}
+	for field := range to.NewValue {
+		seen[field] = struct{}{}
+	}
+
+	var diffs []FieldDiff
+	for field := range seen {
+		oldVal := from.NewValue[field]
+		newVal := to.NewValue[field]
+		if !reflect.DeepEqual(oldVal, newVal) {
+			diffs = append(diffs, FieldDiff{Field: field, OldValue: oldVal, NewValue: newVal})
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Field < diffs[j].Field })
+	return diffs
+}
+
+// ruleVersionDiffFields are the RuleVersion.RuleData fields compared by
+// diffRuleVersions. created_at/updated_at are deliberately excluded: they
+// change on every version and aren't meaningful in a rule diff.
+var ruleVersionDiffFields = []string{"name", "expression", "priority", "enabled"}
+
+// diffRuleVersions returns the field-level differences between two stored
+// versions of a filtering rule, sorted by field name for a stable diff.
+func diffRuleVersions(from, to RuleVersion) ([]FieldDiff, error) {
+	var fromData, toData map[string]interface{}
+	if err := json.Unmarshal([]byte(from.RuleData), &fromData); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal version %d: %w", from.Version, err)
+	}
+	if err := json.Unmarshal([]byte(to.RuleData), &toData); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal version %d: %w", to.Version, err)
+	}
+
+	var diffs []FieldDiff
+	for _, field := range ruleVersionDiffFields {
+		oldVal, newVal := fromData[field], toData[field]
+		if !reflect.DeepEqual(oldVal, newVal) {
+			diffs = append(diffs, FieldDiff{Field: field, OldValue: oldVal, NewValue: newVal})
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Field < diffs[j].Field })
+	return diffs, nil
+}
+
+func (s *service) ListRuleHistory(ctx context.Context, ruleType, ruleID string, limit int) ([]AuditLog, error) {
+	if s.versioningRepo == nil {
+		return nil, pkgerrors.ErrInternal.WithDetail("message", "audit logging not enabled")
+	}
+	if limit <= 0 || limit > constants.MaxLimit {
+		limit = constants.DefaultLimit
+	}
+
+	logs, err := s.versioningRepo.GetAuditLogs(ctx, &ruleID, ruleType, limit)
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+	return logs, nil
+}
+
+// RollbackRule restores a rule to the state captured in a prior audit entry.
+// The restore is applied as a normal update through the owning repository
+// rather than mutating history in place. A filtering rule gets its own
+// version and audit entry with action "rollback"; an enrichment rule is
+// saved through EnrichmentRepository.UpdateEnrichmentRule, which records the
+// restore as an ordinary "update" audit entry.
+func (s *service) RollbackRule(ctx context.Context, ruleType, ruleID, auditEntryID, changedBy string) error {
+	if s.versioningRepo == nil {
+		return pkgerrors.ErrInternal.WithDetail("message", "audit logging not enabled")
+	}
+
+	entry, err := s.versioningRepo.GetAuditLog(ctx, auditEntryID)
+	if err != nil {
+		return pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+	if entry == nil || entry.RuleID == nil || *entry.RuleID != ruleID || entry.RuleType != ruleType {
+		return pkgerrors.ErrNotFound.WithDetail("message", "audit entry not found for rule")
+	}
+	if entry.OldValue == nil {
+		return pkgerrors.ErrValidation.WithDetail("message", "audit entry has no prior state to roll back to")
+	}
+
+	rollbackCtx := context.WithValue(ctx, "user_id", changedBy)
+
+	switch ruleType {
+	case "filtering":
+		return s.rollbackFilteringRule(rollbackCtx, ruleID, entry)
+	case "enrichment":
+		return s.rollbackEnrichmentRule(rollbackCtx, ruleID, entry)
+	default:
+		return pkgerrors.ErrValidation.WithDetail("message", fmt.Sprintf("rollback not supported for rule_type %q", ruleType))
+	}
+}
+
+func (s *service) rollbackFilteringRule(ctx context.Context, ruleID string, entry *AuditLog) error {
+	var target FilteringRule
+	if err := remarshalRuleState(entry.OldValue, &target); err != nil {
+		return pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+	return s.applyFilteringRollback(ctx, ruleID, &target)
+}
+
+// RollbackRuleToVersion restores a filtering rule to the state captured in a
+// numbered version, as an alternative entry point to RollbackRule for
+// callers that think in version numbers rather than audit entry IDs. Like
+// RollbackRule, the restore is applied as a normal update, so it produces
+// its own version and audit entry (action "rollback") rather than rewriting
+// history.
+func (s *service) RollbackRuleToVersion(ctx context.Context, ruleType, ruleID string, version int, changedBy string) error {
+	if s.versioningRepo == nil {
+		return pkgerrors.ErrInternal.WithDetail("message", "audit logging not enabled")
+	}
+	if ruleType != "filtering" {
+		return pkgerrors.ErrValidation.WithDetail("message", fmt.Sprintf("version rollback not supported for rule_type %q", ruleType))
+	}
+
+	target, err := s.versioningRepo.GetVersion(ctx, ruleID, version)
+	if err != nil {
+		return pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+	if target == nil {
+		return pkgerrors.ErrNotFound.WithDetail("id", ruleID).WithDetail("version", version)
+	}
+
+	var targetRule FilteringRule
+	if err := json.Unmarshal([]byte(target.RuleData), &targetRule); err != nil {
+		return pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+
+	rollbackCtx := context.WithValue(ctx, "user_id", changedBy)
+	return s.applyFilteringRollback(rollbackCtx, ruleID, &targetRule)
+}
+
+// applyFilteringRollback overwrites the mutable fields of the current
+// filtering rule with target's, persists it, and records the resulting
+// version/audit entry. Shared by the audit-entry and version-number
+// rollback entry points, which differ only in how they resolve target.
+func (s *service) applyFilteringRollback(ctx context.Context, ruleID string, target *FilteringRule) error {
+	if err := validateRolledBackExpression(target.Expression); err != nil {
+		return wrapValidationError(err)
+	}
+
+	current, err := s.repo.GetFilteringRule(ctx, ruleID)
+	if err != nil {
+		return s.handleNotFoundError(err, ruleID)
+	}
+	if current == nil {
+		return pkgerrors.ErrNotFound.WithDetail("id", ruleID)
+	}
+
+	oldValue, _ := s.ruleToMap(current)
+
+	current.Name = target.Name
+	current.Expression = target.Expression
+	current.Priority = target.Priority
+	current.Enabled = target.Enabled
+
+	if err := s.repo.UpdateFilteringRule(ctx, current); err != nil {
+		return pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+
+	s.createVersionAndAudit(ctx, current, "rollback", oldValue)
+	if s.configEventProducer != nil {
+		_ = s.configEventProducer.PublishFilteringRuleEvent(ctx, models.ActionRollback, current.ID, getChangedBy(ctx), current)
+	}
+
+	return nil
+}
+
+func (s *service) rollbackEnrichmentRule(ctx context.Context, ruleID string, entry *AuditLog) error {
+	if s.enrichmentRepo == nil {
+		return pkgerrors.ErrInternal.WithDetail("message", "enrichment repository not configured")
+	}
+
+	var target EnrichmentRule
+	if err := remarshalRuleState(entry.OldValue, &target); err != nil {
+		return pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+	if err := validateRolledBackEnrichmentRule(&target); err != nil {
+		return wrapValidationError(err)
+	}
+
+	current, err := s.enrichmentRepo.GetEnrichmentRule(ctx, ruleID)
+	if err != nil {
+		return pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+	if current == nil {
+		return pkgerrors.ErrNotFound.WithDetail("id", ruleID)
+	}
+
+	current.Name = target.Name
+	current.FieldToEnrich = target.FieldToEnrich
+	current.SourceType = target.SourceType
+	current.SourceConfig = target.SourceConfig
+	current.Condition = target.Condition
+	current.Transformations = target.Transformations
+	current.CacheTTLSeconds = target.CacheTTLSeconds
+	current.ErrorHandling = target.ErrorHandling
+	current.FallbackValue = target.FallbackValue
+	current.Priority = target.Priority
+	current.Enabled = target.Enabled
+
+	if _, err := s.enrichmentRepo.UpdateEnrichmentRule(ctx, current); err != nil {
+		return pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+
+	if s.configEventProducer != nil {
+		_ = s.configEventProducer.PublishEnrichmentRuleEvent(ctx, models.ActionRollback, current.ID, getChangedBy(ctx), current)
+	}
+
+	return nil
+}
+
+// enrichmentRuleVersionDiffFields are the EnrichmentRule fields compared by
+// diffEnrichmentRuleVersions, mirroring ruleVersionDiffFields for filtering
+// rules. id/tenant_id/version/created_at/updated_at are excluded for the
+// same reason: they change on every version and aren't meaningful in a rule
+// diff.
+var enrichmentRuleVersionDiffFields = []string{
+	"name", "field_to_enrich", "source_type", "source_config", "condition",
+	"transformations", "cache_ttl_seconds", "error_handling", "fallback_value",
+	"priority", "enabled", "circuit_breaker", "retry",
+}
+
+// diffEnrichmentRuleVersions returns the field-level differences between two
+// stored versions of an enrichment rule, sorted by field name for a stable
+// diff. Unlike diffRuleVersions it compares EnrichmentRule snapshots
+// directly rather than a RuleVersion.RuleData string, since enrichment
+// rules have no dedicated rule_versions store - see
+// EnrichmentRuleAudit.After and enrichmentRuleAtVersion.
+func diffEnrichmentRuleVersions(from, to EnrichmentRule) ([]FieldDiff, error) {
+	fromData, err := enrichmentRuleFieldMap(from)
+	if err != nil {
+		return nil, err
+	}
+	toData, err := enrichmentRuleFieldMap(to)
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []FieldDiff
+	for _, field := range enrichmentRuleVersionDiffFields {
+		oldVal, newVal := fromData[field], toData[field]
+		if !reflect.DeepEqual(oldVal, newVal) {
+			diffs = append(diffs, FieldDiff{Field: field, OldValue: oldVal, NewValue: newVal})
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Field < diffs[j].Field })
+	return diffs, nil
+}
+
+func enrichmentRuleFieldMap(rule EnrichmentRule) (map[string]interface{}, error) {
+	data, err := json.Marshal(rule)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal enrichment rule: %w", err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal enrichment rule: %w", err)
+	}
+	return m, nil
+}
+
+// enrichmentRuleAtVersion finds the full rule snapshot recorded when
+// ruleID's history reached version, by scanning its audit trail (there's no
+// dedicated per-version store for enrichment rules, unlike
+// VersioningRepository.GetVersion for filtering ones) for the create/update
+// entry whose Version matches and whose After is set - delete entries have
+// no After, since there's no rule state left to capture.
+func (s *service) enrichmentRuleAtVersion(ctx context.Context, ruleID string, version int) (*EnrichmentRule, error) {
+	if s.enrichmentRepo == nil {
+		return nil, pkgerrors.ErrInternal.WithDetail("message", "enrichment repository not configured")
+	}
+
+	history, err := s.enrichmentRepo.GetEnrichmentRuleHistory(ctx, ruleID)
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+	for _, entry := range history {
+		if entry.Version == version && entry.After != nil {
+			return entry.After, nil
+		}
+	}
+	return nil, pkgerrors.ErrNotFound.WithDetail("id", ruleID).WithDetail("version", version)
+}
+
+// DiffEnrichmentRuleVersions returns the field-level differences between two
+// recorded versions of an enrichment rule, the enrichment counterpart to
+// DiffRuleVersions (filtering-only, backed by VersioningRepository).
+func (s *service) DiffEnrichmentRuleVersions(ctx context.Context, ruleID string, versionA, versionB int) ([]FieldDiff, error) {
+	from, err := s.enrichmentRuleAtVersion(ctx, ruleID, versionA)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.enrichmentRuleAtVersion(ctx, ruleID, versionB)
+	if err != nil {
+		return nil, err
+	}
+
+	diffs, err := diffEnrichmentRuleVersions(*from, *to)
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+	return diffs, nil
+}
+
+// RollbackEnrichmentRule restores an enrichment rule to the state recorded
+// at targetVersion (see enrichmentRuleAtVersion), writing a new version via
+// EnrichmentRepository.RollbackEnrichmentRule (a "rollback"-actioned audit
+// entry with FromVersion set, never rewriting history) and publishing a
+// config event, the same as applyFilteringRollback/rollbackEnrichmentRule
+// already do for an audit-entry-based rollback. If ruleID was since
+// deleted, it's recreated with the same ID rather than erroring.
+func (s *service) RollbackEnrichmentRule(ctx context.Context, ruleID string, targetVersion int, changedBy string) (*EnrichmentRule, error) {
+	if s.enrichmentRepo == nil {
+		return nil, pkgerrors.ErrInternal.WithDetail("message", "enrichment repository not configured")
+	}
+
+	target, err := s.enrichmentRuleAtVersion(ctx, ruleID, targetVersion)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateRolledBackEnrichmentRule(target); err != nil {
+		return nil, wrapValidationError(err)
+	}
+
+	rollbackCtx := context.WithValue(ctx, "user_id", changedBy)
+	restored, err := s.enrichmentRepo.RollbackEnrichmentRule(rollbackCtx, ruleID, target, targetVersion)
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+
+	if s.configEventProducer != nil {
+		_ = s.configEventProducer.PublishEnrichmentRuleEvent(ctx, models.ActionRollback, restored.ID, changedBy, restored)
+	}
+
+	return restored, nil
+}
+
+// GetRuleVersionPatch returns the JSON-Patch diff between two stored
+// versions of a filtering rule, via VersioningRepository.DiffVersions. It's
+// the RFC-6902 counterpart to DiffRuleVersions, which returns the same
+// comparison as a flat field list instead.
+func (s *service) GetRuleVersionPatch(ctx context.Context, ruleID string, fromVersion, toVersion int) (*RuleDiff, error) {
+	if s.versioningRepo == nil {
+		return nil, pkgerrors.ErrInternal.WithDetail("message", "versioning not enabled")
+	}
+
+	diff, err := s.versioningRepo.DiffVersions(ctx, ruleID, fromVersion, toVersion)
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+	if diff == nil {
+		return nil, pkgerrors.ErrNotFound.WithDetail("id", ruleID).WithDetail("from_version", fromVersion).WithDetail("to_version", toVersion)
+	}
+	return diff, nil
+}
+
+// RollbackFilteringRule rolls a filtering rule back to targetVersion. It's a
+// thin, rule-type-specific wrapper around RestoreRuleVersion for callers
+// that don't want to pass a ruleType string to get the same atomic,
+// version-and-audit-entry-writing rollback; see RestoreRuleVersion's doc
+// comment for exactly what gets written. RollbackEnrichmentRule is its
+// enrichment counterpart.
+func (s *service) RollbackFilteringRule(ctx context.Context, ruleID string, targetVersion int, changedBy string) (*RuleVersion, error) {
+	return s.RestoreRuleVersion(ctx, "filtering", ruleID, targetVersion, changedBy)
+}
+
+// RestoreRuleVersion rolls a filtering rule back to targetVersion through
+// VersioningRepository.RollbackToVersion, the transactional, never-destructive
+// alternative to RollbackRuleToVersion: the restore and its version/audit
+// entry are written in one locked transaction instead of as a separate
+// read-then-UpdateFilteringRule-then-createVersionAndAudit sequence.
+func (s *service) RestoreRuleVersion(ctx context.Context, ruleType, ruleID string, targetVersion int, changedBy string) (*RuleVersion, error) {
+	if s.versioningRepo == nil {
+		return nil, pkgerrors.ErrInternal.WithDetail("message", "versioning not enabled")
+	}
+	if ruleType != "filtering" {
+		return nil, pkgerrors.ErrValidation.WithDetail("message", fmt.Sprintf("version rollback not supported for rule_type %q", ruleType))
+	}
+
+	target, err := s.versioningRepo.GetVersion(ctx, ruleID, targetVersion)
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+	if target == nil {
+		return nil, pkgerrors.ErrNotFound.WithDetail("id", ruleID).WithDetail("version", targetVersion)
+	}
+	var targetRule FilteringRule
+	if err := json.Unmarshal([]byte(target.RuleData), &targetRule); err != nil {
+		return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+	if err := validateRolledBackExpression(targetRule.Expression); err != nil {
+		return nil, wrapValidationError(err)
+	}
+
+	version, err := s.versioningRepo.RollbackToVersion(ctx, ruleID, targetVersion, AuditMeta{ChangedBy: changedBy})
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+	if version == nil {
+		return nil, pkgerrors.ErrNotFound.WithDetail("id", ruleID).WithDetail("version", targetVersion)
+	}
+
+	if s.configEventProducer != nil {
+		var restoredRule *FilteringRule
+		var rule FilteringRule
+		if err := json.Unmarshal([]byte(version.RuleData), &rule); err == nil {
+			restoredRule = &rule
+		}
+		_ = s.configEventProducer.PublishFilteringRuleEvent(ctx, models.ActionRollback, ruleID, changedBy, restoredRule)
+	}
+
+	return version, nil
+}
+
+// GetRuleVersionTimeline returns a compact, UI-timeline-sized summary of a
+// filtering rule's version history since the given time, via
+// VersioningRepository.GetVersionTimeline.
+func (s *service) GetRuleVersionTimeline(ctx context.Context, ruleID string, since time.Time) ([]VersionSummary, error) {
+	if s.versioningRepo == nil {
+		return nil, pkgerrors.ErrInternal.WithDetail("message", "versioning not enabled")
+	}
+
+	timeline, err := s.versioningRepo.GetVersionTimeline(ctx, ruleID, since)
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+	return timeline, nil
+}
+
+func remarshalRuleState(value map[string]interface{}, target interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit value: %w", err)
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return fmt.Errorf("failed to unmarshal audit value into rule state: %w", err)
+	}
+	return nil
+}
+
+// dedupConfigRuleID is the pseudo rule_id rule_versions/rule_audit_logs
+// rows for tenantID's deduplication config are written under. There's one
+// config per tenant rather than a collection of rule_id-addressable rows,
+// so this sentinel (not a real FilteringRule/EnrichmentRule ID) is what
+// ties its version history together in the shared rule_versions table.
+func dedupConfigRuleID(tenantID string) string {
+	return constants.CacheKeyPrefixDedup + tenantID
+}
+
+// recordDedupConfigVersion writes cfg's new revision to the same
+// rule_versions/rule_audit_logs tables filtering and enrichment rule
+// changes go through, under rule_type "deduplication". It's best-effort,
+// matching createVersionAndAudit: a versioning write failure doesn't fail
+// the config update itself, since the in-memory config (the source of
+// truth dedup-service actually reads from) has already been applied.
+func (s *service) recordDedupConfigVersion(ctx context.Context, action string, before, cfg *DeduplicationConfig) {
+	if !s.auditEnabled || s.versioningRepo == nil {
+		return
+	}
+
+	ruleID := dedupConfigRuleID(cfg.TenantID)
+	cfgJSON, err := json.Marshal(cfg)
+	if err != nil {
+		return
+	}
+
+	version := &RuleVersion{
+		RuleID:    ruleID,
+		RuleType:  "deduplication",
+		RuleData:  string(cfgJSON),
+		Version:   cfg.Version,
+		ChangedBy: getChangedBy(ctx),
+	}
+	if err := s.versioningRepo.CreateVersion(ctx, version); err != nil {
+		return
+	}
+
+	oldValue, err := dedupConfigToMap(before)
+	if err != nil {
+		return
+	}
+	newValue, err := dedupConfigToMap(cfg)
+	if err != nil {
+		return
+	}
+
+	auditLog := s.buildAuditLog(ctx, ruleID, "deduplication", action, oldValue, newValue, getChangedBy(ctx))
+	_ = s.versioningRepo.CreateAuditLog(ctx, auditLog)
+}
+
+func dedupConfigToMap(cfg *DeduplicationConfig) (map[string]interface{}, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetDeduplicationConfigVersions returns every recorded version of the
+// calling tenant's deduplication config, via the same rule_versions table
+// GetRuleVersions reads for filtering rules.
+func (s *service) GetDeduplicationConfigVersions(ctx context.Context) ([]RuleVersion, error) {
+	if s.versioningRepo == nil {
+		return nil, pkgerrors.ErrInternal.WithDetail("message", "versioning not enabled")
+	}
+
+	ruleID := dedupConfigRuleID(TenantIDFromContext(ctx))
+	versions, err := s.versioningRepo.GetVersions(ctx, ruleID)
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+	return versions, nil
+}
+
+// RollbackDeduplicationConfig restores the calling tenant's deduplication
+// config to a previously recorded version, applying it the same way
+// UpdateDeduplicationConfig would and recording a "rollback" version/audit
+// entry. Unlike RollbackFilteringRule/RollbackEnrichmentRule it doesn't go
+// through VersioningRepository.RollbackToVersion: that method is
+// transactional against filtering_rules specifically, whereas the dedup
+// config lives in the in-memory per-tenant map dedupConfigForTenantLocked
+// guards, not its own SQL table.
+func (s *service) RollbackDeduplicationConfig(ctx context.Context, targetVersion int, changedBy string) (*DeduplicationConfig, error) {
+	if s.versioningRepo == nil {
+		return nil, pkgerrors.ErrInternal.WithDetail("message", "versioning not enabled")
+	}
+
+	tenantID := TenantIDFromContext(ctx)
+	ruleID := dedupConfigRuleID(tenantID)
+
+	version, err := s.versioningRepo.GetVersion(ctx, ruleID, targetVersion)
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+	if version == nil {
+		return nil, pkgerrors.ErrNotFound.WithDetail("version", targetVersion)
+	}
+
+	var target DeduplicationConfig
+	if err := json.Unmarshal([]byte(version.RuleData), &target); err != nil {
+		return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+
+	rollbackCtx := context.WithValue(ctx, "user_id", changedBy)
+
+	s.dedupConfigMu.Lock()
+	cfg := s.dedupConfigForTenantLocked(tenantID)
+	before := cloneDedupConfig(cfg)
+	cfg.HashAlgorithm = target.HashAlgorithm
+	cfg.TTLSeconds = target.TTLSeconds
+	cfg.OnRedisError = target.OnRedisError
+	cfg.FieldsToHash = append([]string(nil), target.FieldsToHash...)
+	cfg.Salt = target.Salt
+	cfg.HMACKeyRef = target.HMACKeyRef
+	cfg.Version = before.Version + 1
+	result := cloneDedupConfig(cfg)
+	s.dedupConfigMu.Unlock()
+
+	s.recordDedupConfigVersion(rollbackCtx, "rollback", before, result)
+
+	if s.configEventProducer != nil {
+		eventMetadata := map[string]interface{}{
+			"tenant_id":      result.TenantID,
+			"fields_to_hash": result.FieldsToHash,
+			"hash_algorithm": result.HashAlgorithm,
+			"ttl_seconds":    result.TTLSeconds,
+		}
+		_ = s.configEventProducer.PublishDedupConfigEvent(ctx, models.ActionRollback, changedBy, eventMetadata)
+	}
+
+	return result, nil
+}
+
+// PreviewDeduplicationFingerprint computes the dedup fingerprint a sample
+// event would produce under the tenant's current config (or req.Config, a
+// hypothetical override), without writing anything to Redis - the
+// dry-run counterpart to deduplication.Service.Process's actual check.
+func (s *service) PreviewDeduplicationFingerprint(ctx context.Context, req DeduplicationPreviewRequest) (*DeduplicationPreviewResponse, error) {
+	cfg, err := s.GetDeduplicationConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.Config != nil {
+		if err := ValidateDeduplicationConfig(*req.Config); err != nil {
+			return nil, pkgerrors.Wrap(err, pkgerrors.ErrValidation)
+		}
+		if req.Config.HashAlgorithm != nil {
+			cfg.HashAlgorithm = *req.Config.HashAlgorithm
+		}
+		if req.Config.FieldsToHash != nil {
+			cfg.FieldsToHash = *req.Config.FieldsToHash
+		}
+		if req.Config.Salt != nil {
+			cfg.Salt = *req.Config.Salt
+		}
+		if req.Config.HMACKeyRef != nil {
+			cfg.HMACKeyRef = *req.Config.HMACKeyRef
+		}
+	}
+
+	messageData := map[string]interface{}{
+		"id":      req.ID,
+		"source":  req.Source,
+		"payload": req.SampleEvent,
+	}
+	for key, value := range req.SampleEvent {
+		messageData[key] = value
+	}
+
+	hasher := deduplication.NewHasherWithKey(cfg.HashAlgorithm, cfg.Salt, cfg.HMACKeyRef)
+	fingerprint, err := hasher.ComputeHash(messageData, cfg.FieldsToHash)
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, pkgerrors.ErrValidation)
+	}
+
+	return &DeduplicationPreviewResponse{Fingerprint: fingerprint}, nil
+}
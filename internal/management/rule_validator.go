@@ -0,0 +1,110 @@
+package management
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	googlecel "github.com/google/cel-go/cel"
+
+	"yeti/pkg/cel"
+)
+
+// ValidationError is a single field-level validation failure, shaped like
+// config.ValidationError so the HTTP layer can render both config and rule
+// validation failures through the same {field, message} structure.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("validation error for field '%s': %s", e.Field, e.Message)
+}
+
+// RuleValidator type-checks an EnrichmentRule's transform expressions
+// against the CEL transform schema (cel.Evaluator) and caches each
+// successfully validated rule version's compiled programs, keyed by
+// (rule.ID, rule.Version), so the enrichment runtime's hot path never
+// recompiles an expression this package has already validated.
+type RuleValidator struct {
+	evaluator *cel.Evaluator
+
+	mu    sync.RWMutex
+	cache map[string][]googlecel.Program
+}
+
+func NewRuleValidator() (*RuleValidator, error) {
+	evaluator, err := cel.NewEvaluator()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CEL evaluator: %w", err)
+	}
+	return &RuleValidator{
+		evaluator: evaluator,
+		cache:     make(map[string][]googlecel.Program),
+	}, nil
+}
+
+// CompileTransformations compiles every transformation expression in
+// transformations, returning a *ValidationError naming the first offending
+// transformations[i].expression field if one fails to compile. On success
+// it returns the compiled, ready-to-run programs in the same order (a nil
+// entry for transformations with no expression - a plain field copy needs
+// no program).
+func (v *RuleValidator) CompileTransformations(transformations []EnrichmentTransformation) ([]googlecel.Program, error) {
+	programs := make([]googlecel.Program, len(transformations))
+
+	for i, trans := range transformations {
+		if trans.Expression == "" {
+			continue
+		}
+		program, err := v.evaluator.CompileTransformExpression(trans.Expression)
+		if err != nil {
+			return nil, &ValidationError{
+				Field:   fmt.Sprintf("transformations[%d].expression", i),
+				Message: err.Error(),
+			}
+		}
+		programs[i] = program
+	}
+
+	return programs, nil
+}
+
+// CacheCompiled stores programs (as returned by CompileTransformations) under
+// (ruleID, version), replacing anything already cached for that exact
+// version. Call it once a rule has actually persisted at that version, since
+// the cache key is meaningless before then.
+func (v *RuleValidator) CacheCompiled(ruleID string, version int, programs []googlecel.Program) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.cache[cacheKey(ruleID, version)] = programs
+}
+
+// GetCompiledTransforms returns the programs cached for (ruleID, version) by
+// a prior CacheCompiled call, and whether an entry was found at all. A miss
+// means this version hasn't been validated yet; callers should fall back to
+// cel.Evaluator.EvaluateTransform, which compiles on the fly.
+func (v *RuleValidator) GetCompiledTransforms(ruleID string, version int) ([]googlecel.Program, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	programs, ok := v.cache[cacheKey(ruleID, version)]
+	return programs, ok
+}
+
+// InvalidateRule drops every cached version of ruleID, e.g. once it's
+// deleted and none of its compiled programs will run again.
+func (v *RuleValidator) InvalidateRule(ruleID string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	prefix := ruleID + ":"
+	for key := range v.cache {
+		if strings.HasPrefix(key, prefix) {
+			delete(v.cache, key)
+		}
+	}
+}
+
+func cacheKey(ruleID string, version int) string {
+	return fmt.Sprintf("%s:%d", ruleID, version)
+}
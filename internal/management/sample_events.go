@@ -0,0 +1,64 @@
+package management
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SampleEventRepository stores the corpus of recent events used to dry-run a
+// filtering or enrichment rule before it goes live. Producers outside this
+// package (e.g. a debug sink on the ingestion pipeline) are expected to call
+// StoreSampleEvent as events flow through; the management service itself
+// only reads the corpus back for dry runs.
+type SampleEventRepository interface {
+	StoreSampleEvent(ctx context.Context, event SampleEvent) error
+	ListRecentSampleEvents(ctx context.Context, limit int) ([]SampleEvent, error)
+}
+
+type mongoSampleEventRepository struct {
+	collection *mongo.Collection
+}
+
+func NewSampleEventRepository(db *mongo.Database) SampleEventRepository {
+	return &mongoSampleEventRepository{
+		collection: db.Collection("sample_events"),
+	}
+}
+
+func (r *mongoSampleEventRepository) StoreSampleEvent(ctx context.Context, event SampleEvent) error {
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	_, err := r.collection.InsertOne(ctx, event)
+	if err != nil {
+		return fmt.Errorf("failed to store sample event: %w", err)
+	}
+	return nil
+}
+
+func (r *mongoSampleEventRepository) ListRecentSampleEvents(ctx context.Context, limit int) ([]SampleEvent, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: -1}}).SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sample events: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var events []SampleEvent
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, fmt.Errorf("failed to decode sample events: %w", err)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,141 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule is a parsed cron expression. ParseCron supports the standard
+// 5-field grammar (minute hour day-of-month month day-of-week), each field
+// being "*", a single integer, a comma-separated list of integers, or a
+// "*/N" step - the subset every FilteringRule.Schedule/EnrichmentRule.Schedule
+// Cron string in this codebase is expected to use. It does NOT support
+// ranges ("1-5"), the "L"/"W"/"#" day qualifiers, or a seconds field; a
+// cron string needing those is rejected by ParseCron rather than silently
+// misinterpreted.
+type CronSchedule struct {
+	minute, hour, dom, month, dow fieldMatcher
+}
+
+type fieldMatcher struct {
+	any    bool
+	values map[int]bool
+}
+
+func (m fieldMatcher) matches(v int) bool {
+	if m.any {
+		return true
+	}
+	return m.values[v]
+}
+
+// ParseCron parses a standard 5-field cron expression; see CronSchedule's
+// doc comment for the supported grammar.
+func ParseCron(expr string) (*CronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron: expected 5 fields (minute hour dom month dow), got %d in %q", len(fields), expr)
+	}
+
+	minute, err := parseCronField(fields[0], 0, 59)
+	if err != nil {
+		return nil, fmt.Errorf("cron: minute field: %w", err)
+	}
+	hour, err := parseCronField(fields[1], 0, 23)
+	if err != nil {
+		return nil, fmt.Errorf("cron: hour field: %w", err)
+	}
+	dom, err := parseCronField(fields[2], 1, 31)
+	if err != nil {
+		return nil, fmt.Errorf("cron: day-of-month field: %w", err)
+	}
+	month, err := parseCronField(fields[3], 1, 12)
+	if err != nil {
+		return nil, fmt.Errorf("cron: month field: %w", err)
+	}
+	dow, err := parseCronField(fields[4], 0, 6)
+	if err != nil {
+		return nil, fmt.Errorf("cron: day-of-week field: %w", err)
+	}
+
+	return &CronSchedule{minute: minute, hour: hour, dom: dom, month: month, dow: dow}, nil
+}
+
+func parseCronField(field string, min, max int) (fieldMatcher, error) {
+	if field == "*" {
+		return fieldMatcher{any: true}, nil
+	}
+
+	values := make(map[int]bool)
+	for _, part := range strings.Split(field, ",") {
+		if strings.HasPrefix(part, "*/") {
+			step, err := strconv.Atoi(strings.TrimPrefix(part, "*/"))
+			if err != nil || step <= 0 {
+				return fieldMatcher{}, fmt.Errorf("invalid step %q", part)
+			}
+			for v := min; v <= max; v += step {
+				values[v] = true
+			}
+			continue
+		}
+		v, err := strconv.Atoi(part)
+		if err != nil || v < min || v > max {
+			return fieldMatcher{}, fmt.Errorf("invalid value %q (expected %d-%d)", part, min, max)
+		}
+		values[v] = true
+	}
+	return fieldMatcher{values: values}, nil
+}
+
+// cronSearchHorizon bounds how far Next/Previous will scan before giving up
+// on an expression that can never match (e.g. day-of-month 31 combined with
+// month February) - long enough to still find rare-but-valid matches such
+// as a Feb 29 cron in a leap year.
+const cronSearchHorizon = 4 * 365 * 24 * time.Hour
+
+// Next returns the first instant strictly after `after` (evaluated in loc,
+// UTC if loc is nil) that matches s, scanning minute-by-minute. ok is false
+// if no match was found within cronSearchHorizon.
+func (s *CronSchedule) Next(after time.Time, loc *time.Location) (t time.Time, ok bool) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	cursor := after.In(loc).Truncate(time.Minute).Add(time.Minute)
+	limit := after.Add(cronSearchHorizon)
+	for cursor.Before(limit) {
+		if s.matchesInstant(cursor) {
+			return cursor, true
+		}
+		cursor = cursor.Add(time.Minute)
+	}
+	return time.Time{}, false
+}
+
+// Previous returns the last instant at or before `before` that matches s,
+// scanning minute-by-minute backward. ok is false if no match was found
+// within cronSearchHorizon; used by Scheduler's startup reconciliation to
+// find the most recent occurrence a cron rule should already have fired.
+func (s *CronSchedule) Previous(before time.Time, loc *time.Location) (t time.Time, ok bool) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	cursor := before.In(loc).Truncate(time.Minute)
+	limit := before.Add(-cronSearchHorizon)
+	for cursor.After(limit) {
+		if s.matchesInstant(cursor) {
+			return cursor, true
+		}
+		cursor = cursor.Add(-time.Minute)
+	}
+	return time.Time{}, false
+}
+
+func (s *CronSchedule) matchesInstant(t time.Time) bool {
+	return s.month.matches(int(t.Month())) &&
+		s.dom.matches(t.Day()) &&
+		s.dow.matches(int(t.Weekday())) &&
+		s.hour.matches(t.Hour()) &&
+		s.minute.matches(t.Minute())
+}
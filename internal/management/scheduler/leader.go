@@ -0,0 +1,94 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// leaderKey is the single Redis key contended for leadership of the
+// schedule-evaluation loop across management-service replicas.
+const leaderKey = "management:scheduler:leader"
+
+// renewScript re-PEXPIREs KEYS[1] to ARGV[2] milliseconds only if it still
+// holds ARGV[1] - this replica's id - so a renewal can never extend the
+// TTL on a lease another replica's SETNX won after this one's expired. A
+// plain GET-then-EXPIRE can't tell the two apart: the GET can observe this
+// replica's own (already-expired) value a moment before another replica
+// takes over the key, and the EXPIRE that follows then extends the new
+// leader's lease while reporting true back to the stale caller - both
+// replicas then believe they're leader until the stale one's next poll
+// notices the mismatch. Same pattern as deduplication.Lock's refreshScript.
+const renewScript = `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+  return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+else
+  return 0
+end
+`
+
+// releaseScript deletes KEYS[1] only if it still holds ARGV[1], the same
+// compare-and-delete guard renewScript applies to renewal; see Release.
+const releaseScript = `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+  return redis.call("DEL", KEYS[1])
+else
+  return 0
+end
+`
+
+var (
+	renewLuaScript   = redis.NewScript(renewScript)
+	releaseLuaScript = redis.NewScript(releaseScript)
+)
+
+// LeaderElector uses a single Redis key (SET NX plus a TTL, renewed on
+// every successful TryAcquire by the incumbent) so exactly one
+// management-service replica drives schedule transitions at a time - the
+// same SETNX-based coordination primitive deduplication.RedisRepository
+// uses for fingerprint locking, just held by a replica instead of a
+// request.
+type LeaderElector struct {
+	client *redis.Client
+	id     string
+	ttl    time.Duration
+}
+
+// NewLeaderElector returns an elector contending leaderKey under replicaID.
+// replicaID should be unique per process (e.g. hostname:pid) so a replica
+// can recognize and renew its own lease.
+func NewLeaderElector(client *redis.Client, replicaID string, ttl time.Duration) *LeaderElector {
+	return &LeaderElector{client: client, id: replicaID, ttl: ttl}
+}
+
+// TryAcquire attempts to become leader, or renews the lease if this
+// replica already holds it, returning whether it holds leadership after
+// the call returns.
+func (l *LeaderElector) TryAcquire(ctx context.Context) (bool, error) {
+	acquired, err := l.client.SetNX(ctx, leaderKey, l.id, l.ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("redis leader SETNX failed: %w", err)
+	}
+	if acquired {
+		return true, nil
+	}
+
+	res, err := renewLuaScript.Run(ctx, l.client, []string{leaderKey}, l.id, l.ttl.Milliseconds()).Result()
+	if err != nil {
+		return false, fmt.Errorf("redis leader renew script failed: %w", err)
+	}
+	n, _ := res.(int64)
+	return n == 1, nil
+}
+
+// Release gives up leadership immediately, rather than waiting for the
+// lease to expire, if this replica still holds it - used on graceful
+// shutdown so a surviving replica can take over without a TTL-length gap.
+func (l *LeaderElector) Release(ctx context.Context) error {
+	if err := releaseLuaScript.Run(ctx, l.client, []string{leaderKey}, l.id).Err(); err != nil {
+		return fmt.Errorf("redis leader release script failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,329 @@
+// Package scheduler evaluates Schedule-driven activation/deactivation
+// windows for management rules and flips their enabled state at the
+// appropriate instants, coordinating across control-plane replicas via a
+// Redis-backed leader election (see LeaderElector) so only one replica
+// drives transitions at a time.
+//
+// The package has no dependency on the management package so it can be
+// unit-tested and reasoned about independently; management.NewSchedulerAdapter
+// bridges management.Service to the RuleStore interface Scheduler consumes.
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"yeti/internal/logger"
+)
+
+// Schedule mirrors management.Schedule's shape. It's redeclared here
+// (rather than imported) to keep this package free of a dependency on
+// management - see the package doc comment.
+type Schedule struct {
+	ActivateAt   *time.Time
+	DeactivateAt *time.Time
+	Cron         string
+	Timezone     string
+}
+
+// RuleRef is a minimal, management-package-agnostic view of a schedulable
+// rule that RuleStore.ListSchedulableRules returns.
+type RuleRef struct {
+	RuleType string
+	RuleID   string
+	Enabled  bool
+	Schedule *Schedule
+}
+
+// RuleStore is the subset of management.Service the Scheduler needs:
+// listing schedulable rules and toggling their enabled state.
+// management.NewSchedulerAdapter wraps a management.Service to satisfy it.
+type RuleStore interface {
+	ListSchedulableRules(ctx context.Context) ([]RuleRef, error)
+	SetRuleEnabled(ctx context.Context, ruleType, ruleID string, enabled bool, changedBy string) error
+}
+
+// nextFireKeyPrefix namespaces the Redis keys Scheduler persists each
+// rule's fired/next-fire state under, so a restart resumes instead of
+// re-arming (or re-firing) every schedule from scratch.
+const nextFireKeyPrefix = "management:scheduler:next:"
+
+// changedByActor is the audit "changed by" value schedule-driven rule
+// updates are attributed to.
+const changedByActor = "scheduler"
+
+// oneShotClaimTTL bounds how long a fired (or missed) ActivateAt/DeactivateAt
+// claim key is kept, comfortably longer than any realistic gap between
+// schedule creation and the instant it targets.
+const oneShotClaimTTL = 30 * 24 * time.Hour
+
+// Scheduler periodically evaluates FilteringRule/EnrichmentRule Schedules
+// (via RuleStore) and toggles their Enabled state at the appropriate
+// instants, writing an audit entry for every transition (through the
+// ruleStore's SetRuleEnabled, which is expected to go through the same
+// Update path - and therefore the same audit trail - a manual API update
+// would).
+//
+// Scope decisions: Schedule.ActivateAt/DeactivateAt are one-shot instants -
+// each fires at most once (tracked via a Redis claim key), and one still
+// unfired when this process starts is logged as missed rather than fired
+// retroactively (see evaluateOneShot). Schedule.Cron is a recurring
+// *activation* pulse - it sets Enabled=true on every match; it does not
+// itself define a deactivation window, so pair it with DeactivateAt (or a
+// manual disable) if the rule should later turn back off. Version-flipping
+// rollouts (switching between RuleVersion snapshots rather than toggling
+// Enabled) are out of scope for this Scheduler.
+type Scheduler struct {
+	store  RuleStore
+	redis  *redis.Client
+	leader *LeaderElector
+	log    logger.Logger
+
+	pollInterval time.Duration
+	startedAt    time.Time
+
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+// New constructs a Scheduler. pollInterval defaults to 30s and leaderTTL to
+// 15s when zero or negative. replicaID should be unique per process (e.g.
+// hostname:pid) so a replica can recognize and renew its own leader lease.
+func New(store RuleStore, redisClient *redis.Client, replicaID string, pollInterval, leaderTTL time.Duration, log logger.Logger) *Scheduler {
+	if pollInterval <= 0 {
+		pollInterval = 30 * time.Second
+	}
+	if leaderTTL <= 0 {
+		leaderTTL = 15 * time.Second
+	}
+	return &Scheduler{
+		store:        store,
+		redis:        redisClient,
+		leader:       NewLeaderElector(redisClient, replicaID, leaderTTL),
+		log:          log,
+		pollInterval: pollInterval,
+		stopCh:       make(chan struct{}),
+		doneCh:       make(chan struct{}),
+	}
+}
+
+// Run ticks every pollInterval, evaluating schedules whenever this replica
+// holds leadership, until ctx is cancelled or Stop is called. Callers
+// should run it in its own goroutine. The first tick after this replica
+// becomes leader (including the very first tick of the process, if it
+// wins leadership immediately) runs reconcile before the regular
+// evaluation pass.
+func (s *Scheduler) Run(ctx context.Context) {
+	defer close(s.doneCh)
+
+	s.startedAt = time.Now()
+	wasLeader := false
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		isLeader, err := s.leader.TryAcquire(ctx)
+		if err != nil {
+			s.log.ErrorwCtx(ctx, "scheduler: leader election failed", "error", err)
+			isLeader = false
+		}
+
+		if isLeader {
+			if !wasLeader {
+				s.reconcile(ctx)
+			}
+			s.evaluate(ctx)
+		}
+		wasLeader = isLeader
+
+		select {
+		case <-ctx.Done():
+			if isLeader {
+				_ = s.leader.Release(context.Background())
+			}
+			return
+		case <-s.stopCh:
+			if isLeader {
+				_ = s.leader.Release(context.Background())
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stop signals Run to exit and blocks until it has.
+func (s *Scheduler) Stop() {
+	close(s.stopCh)
+	<-s.doneCh
+}
+
+func (s *Scheduler) evaluate(ctx context.Context) {
+	rules, err := s.store.ListSchedulableRules(ctx)
+	if err != nil {
+		s.log.ErrorwCtx(ctx, "scheduler: failed to list schedulable rules", "error", err)
+		return
+	}
+
+	now := time.Now()
+	for _, rule := range rules {
+		if rule.Schedule == nil {
+			continue
+		}
+		s.evaluateRule(ctx, rule, now)
+	}
+}
+
+func (s *Scheduler) evaluateRule(ctx context.Context, rule RuleRef, now time.Time) {
+	sched := rule.Schedule
+	base := nextFireKeyPrefix + rule.RuleType + ":" + rule.RuleID
+
+	s.evaluateOneShot(ctx, rule, base+":activate", sched.ActivateAt, now, true)
+	s.evaluateOneShot(ctx, rule, base+":deactivate", sched.DeactivateAt, now, false)
+
+	if sched.Cron != "" {
+		s.evaluateCron(ctx, rule, base+":cron", sched, now)
+	}
+}
+
+// evaluateOneShot fires a single ActivateAt/DeactivateAt instant exactly
+// once, claimed via a Redis SETNX so concurrent evaluation passes (or a
+// replica that takes over leadership mid-transition) can't double-fire it.
+// An instant already past when this process started is logged as missed
+// rather than fired - see Scheduler's doc comment.
+func (s *Scheduler) evaluateOneShot(ctx context.Context, rule RuleRef, key string, at *time.Time, now time.Time, enable bool) {
+	if at == nil || at.After(now) {
+		return
+	}
+	if !s.claimFire(ctx, key, *at) {
+		return
+	}
+	if at.Before(s.startedAt) {
+		s.log.WarnwCtx(ctx, "scheduler: missed schedule window during downtime, not firing retroactively",
+			"rule_type", rule.RuleType, "rule_id", rule.RuleID, "at", at, "enable", enable)
+		return
+	}
+
+	reason := "deactivate_at"
+	if enable {
+		reason = "activate_at"
+	}
+	s.apply(ctx, rule, enable, reason)
+}
+
+func (s *Scheduler) evaluateCron(ctx context.Context, rule RuleRef, key string, sched *Schedule, now time.Time) {
+	cron, err := ParseCron(sched.Cron)
+	if err != nil {
+		s.log.WarnwCtx(ctx, "scheduler: invalid cron expression, skipping", "rule_type", rule.RuleType, "rule_id", rule.RuleID, "cron", sched.Cron, "error", err)
+		return
+	}
+	loc := s.resolveLocation(ctx, sched.Timezone)
+
+	nextStr, err := s.redis.Get(ctx, key).Result()
+	if err != nil {
+		if err != redis.Nil {
+			s.log.ErrorwCtx(ctx, "scheduler: redis get next-fire failed", "key", key, "error", err)
+		}
+		if next, ok := cron.Next(now, loc); ok {
+			s.persistNextFire(ctx, key, next)
+		}
+		return
+	}
+
+	next, err := time.Parse(time.RFC3339, nextStr)
+	if err != nil {
+		s.log.ErrorwCtx(ctx, "scheduler: corrupt next-fire value, re-arming", "key", key, "value", nextStr, "error", err)
+		if n, ok := cron.Next(now, loc); ok {
+			s.persistNextFire(ctx, key, n)
+		}
+		return
+	}
+	if next.After(now) {
+		return
+	}
+
+	s.apply(ctx, rule, true, "cron")
+	if n, ok := cron.Next(now, loc); ok {
+		s.persistNextFire(ctx, key, n)
+	} else {
+		_ = s.redis.Del(ctx, key).Err()
+	}
+}
+
+// reconcile runs once, the first time this replica becomes leader: for
+// every Cron-scheduled rule whose most recent occurrence at-or-before now
+// hasn't been applied (the rule is still disabled), it's enabled
+// immediately rather than waiting for the next natural cron match - this
+// is the "currently-active cron rules should be reconciled on startup"
+// behavior. ActivateAt/DeactivateAt one-shots don't need a separate
+// reconcile step: the regular evaluate path (evaluateOneShot) already
+// handles a not-yet-claimed instant correctly on the first tick, whether
+// that's right after startup or not.
+func (s *Scheduler) reconcile(ctx context.Context) {
+	rules, err := s.store.ListSchedulableRules(ctx)
+	if err != nil {
+		s.log.ErrorwCtx(ctx, "scheduler: reconcile failed to list rules", "error", err)
+		return
+	}
+
+	now := time.Now()
+	for _, rule := range rules {
+		if rule.Schedule == nil || rule.Schedule.Cron == "" || rule.Enabled {
+			continue
+		}
+		cron, err := ParseCron(rule.Schedule.Cron)
+		if err != nil {
+			continue // logged by evaluateCron on the regular pass
+		}
+		loc := s.resolveLocation(ctx, rule.Schedule.Timezone)
+
+		if _, ok := cron.Previous(now, loc); !ok {
+			continue
+		}
+		s.log.InfowCtx(ctx, "scheduler: reconciling currently-active cron rule on startup",
+			"rule_type", rule.RuleType, "rule_id", rule.RuleID)
+		s.apply(ctx, rule, true, "startup_reconcile")
+	}
+}
+
+func (s *Scheduler) claimFire(ctx context.Context, key string, at time.Time) bool {
+	claimed, err := s.redis.SetNX(ctx, key, at.Format(time.RFC3339), oneShotClaimTTL).Result()
+	if err != nil {
+		s.log.ErrorwCtx(ctx, "scheduler: redis claim failed", "key", key, "error", err)
+		return false
+	}
+	return claimed
+}
+
+func (s *Scheduler) persistNextFire(ctx context.Context, key string, at time.Time) {
+	if err := s.redis.Set(ctx, key, at.Format(time.RFC3339), 0).Err(); err != nil {
+		s.log.ErrorwCtx(ctx, "scheduler: failed to persist next-fire time", "key", key, "error", err)
+	}
+}
+
+func (s *Scheduler) apply(ctx context.Context, rule RuleRef, enable bool, reason string) {
+	if rule.Enabled == enable {
+		return
+	}
+	if err := s.store.SetRuleEnabled(ctx, rule.RuleType, rule.RuleID, enable, changedByActor); err != nil {
+		s.log.ErrorwCtx(ctx, "scheduler: failed to apply schedule transition",
+			"rule_type", rule.RuleType, "rule_id", rule.RuleID, "enable", enable, "reason", reason, "error", err)
+		return
+	}
+	s.log.InfowCtx(ctx, "scheduler: applied schedule transition",
+		"rule_type", rule.RuleType, "rule_id", rule.RuleID, "enable", enable, "reason", reason)
+}
+
+func (s *Scheduler) resolveLocation(ctx context.Context, tz string) *time.Location {
+	if tz == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		s.log.WarnwCtx(ctx, "scheduler: unknown timezone, defaulting to UTC", "timezone", tz, "error", err)
+		return time.UTC
+	}
+	return loc
+}
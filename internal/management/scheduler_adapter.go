@@ -0,0 +1,84 @@
+package management
+
+import (
+	"context"
+	"fmt"
+
+	"yeti/internal/management/scheduler"
+)
+
+// ListSchedulableRules implements scheduler.RuleStore, giving the
+// management/scheduler subsystem a management-package-agnostic view of
+// every FilteringRule/EnrichmentRule carrying a Schedule. scheduler has no
+// import of this package (see its doc comment), so this adapter - not a
+// shared type - is what lets *service satisfy scheduler.RuleStore.
+func (s *service) ListSchedulableRules(ctx context.Context) ([]scheduler.RuleRef, error) {
+	var refs []scheduler.RuleRef
+
+	filteringRules, err := s.repo.ListFilteringRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range filteringRules {
+		if rule.Schedule == nil {
+			continue
+		}
+		refs = append(refs, scheduler.RuleRef{
+			RuleType: "filtering",
+			RuleID:   rule.ID,
+			Enabled:  rule.Enabled,
+			Schedule: toSchedulerSchedule(rule.Schedule),
+		})
+	}
+
+	if s.enrichmentRepo != nil {
+		enrichmentRules, err := s.enrichmentRepo.ListEnrichmentRules(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, rule := range enrichmentRules {
+			if rule.Schedule == nil {
+				continue
+			}
+			refs = append(refs, scheduler.RuleRef{
+				RuleType: "enrichment",
+				RuleID:   rule.ID,
+				Enabled:  rule.Enabled,
+				Schedule: toSchedulerSchedule(rule.Schedule),
+			})
+		}
+	}
+
+	return refs, nil
+}
+
+// SetRuleEnabled implements scheduler.RuleStore by routing through the
+// same UpdateFilteringRule/UpdateEnrichmentRule paths the REST API uses,
+// so a schedule-driven toggle produces the same version/audit trail as a
+// manual one, attributed to changedBy via the existing "user_id" context
+// convention (see getChangedBy).
+func (s *service) SetRuleEnabled(ctx context.Context, ruleType, ruleID string, enabled bool, changedBy string) error {
+	actorCtx := context.WithValue(ctx, "user_id", changedBy)
+	switch ruleType {
+	case "filtering":
+		_, err := s.UpdateFilteringRule(actorCtx, ruleID, UpdateFilteringRuleRequest{Enabled: &enabled})
+		return err
+	case "enrichment":
+		_, err := s.UpdateEnrichmentRule(actorCtx, ruleID, UpdateEnrichmentRuleRequest{Enabled: &enabled})
+		return err
+	default:
+		return fmt.Errorf("scheduler: unknown rule type %q", ruleType)
+	}
+}
+
+func toSchedulerSchedule(sched *Schedule) *scheduler.Schedule {
+	if sched == nil {
+		return nil
+	}
+	return &scheduler.Schedule{
+		ActivateAt:   sched.ActivateAt,
+		DeactivateAt: sched.DeactivateAt,
+		Cron:         sched.Cron,
+		Timezone:     sched.Timezone,
+	}
+}
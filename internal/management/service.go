@@ -3,12 +3,18 @@ package management
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"strings"
 	"sync"
+	"time"
 
+	kafka "yeti/internal/broker"
 	"yeti/internal/config"
 	"yeti/internal/constants"
+	"yeti/internal/enrichment/provider"
+	"yeti/pkg/cel"
 	pkgerrors "yeti/pkg/errors"
+	"yeti/pkg/metrics"
 	"yeti/pkg/models"
 )
 
@@ -18,8 +24,18 @@ type service struct {
 	versioningRepo      VersioningRepository
 	configEventProducer *ConfigEventProducer
 	auditEnabled        bool
-	dedupConfig         *DeduplicationConfig
+	defaultDedupConfig  *DeduplicationConfig
+	dedupConfigs        map[string]*DeduplicationConfig
 	dedupConfigMu       sync.RWMutex
+	evaluator           *cel.Evaluator
+	ruleValidator       *RuleValidator
+	httpTemplates       *HTTPTemplateCompiler
+	sourceRegistry      EnrichmentSourceRegistry
+	providers           map[string]provider.DataProvider
+	sampleEventRepo     SampleEventRepository
+	providerRepo        ProviderRepository
+	dlqRepo             DeadLetterRepository
+	replayProducer      kafka.Producer
 }
 
 type ServiceOption func(*service)
@@ -37,6 +53,21 @@ func WithEnrichment(enrichmentRepo EnrichmentRepository) ServiceOption {
 	}
 }
 
+// WithEnrichmentSourceRegistry lets a deployment register its own
+// enrichment source types (and their SourceConfig validators) at boot,
+// the same role ArgoCD's ApplicationSet plugin generators play for
+// registering new generator kinds without ArgoCD itself knowing about
+// them ahead of time. Without this option, third-party source types can
+// still be added by calling RegisterSourceTypeValidator directly - this
+// option just lets a service's caller hold and inspect the registry
+// through the Service value it already has, instead of reaching into
+// this package's global validator registry.
+func WithEnrichmentSourceRegistry(registry EnrichmentSourceRegistry) ServiceOption {
+	return func(s *service) {
+		s.sourceRegistry = registry
+	}
+}
+
 func WithConfigEvents(configEventProducer *ConfigEventProducer) ServiceOption {
 	return func(s *service) {
 		s.configEventProducer = configEventProducer
@@ -50,19 +81,73 @@ func WithDeduplicationConfig(dedupCfg config.DeduplicationConfig) ServiceOption
 			fieldsToHash = []string{"id", "source"}
 		}
 
-		s.dedupConfig = &DeduplicationConfig{
+		s.defaultDedupConfig = &DeduplicationConfig{
 			HashAlgorithm: dedupCfg.HashAlgorithm,
 			TTLSeconds:    dedupCfg.TTLSeconds,
 			OnRedisError:  dedupCfg.OnRedisError,
 			FieldsToHash:  fieldsToHash,
+			Salt:          dedupCfg.Salt,
+			HMACKeyRef:    dedupCfg.HMACKey,
 		}
 	}
 }
 
+// WithEnrichmentProviders registers the data providers used to resolve an
+// enrichment rule's dry-run/evaluate fetches. Unlike the enrichment-service
+// providers, these are never wrapped with a circuit breaker or retry: a
+// dry-run evaluation should surface the provider's real current behavior
+// rather than its steady-state failure handling.
+func WithEnrichmentProviders(providers map[string]provider.DataProvider) ServiceOption {
+	return func(s *service) {
+		s.providers = providers
+	}
+}
+
+// WithSampleEventRepository enables dry-run match-rate reporting against a
+// stored corpus of recent sample events. Without it, dry runs still
+// validate and evaluate a rule but report zero samples.
+func WithSampleEventRepository(sampleEventRepo SampleEventRepository) ServiceOption {
+	return func(s *service) {
+		s.sampleEventRepo = sampleEventRepo
+	}
+}
+
+// WithProviderRepository enables the external provider registry endpoints
+// (POST/GET/DELETE /api/v1/providers). Without it, those calls fail with
+// ErrInternal the same way enrichment rule endpoints do without
+// WithEnrichment.
+func WithProviderRepository(providerRepo ProviderRepository) ServiceOption {
+	return func(s *service) {
+		s.providerRepo = providerRepo
+	}
+}
+
+// WithDeadLetters enables ListDeadLettered/Replay, backed by entries a
+// DeadLetterIndexer has recorded from a DLQ topic. Without it, those calls
+// fail with ErrInternal the same way enrichment rule endpoints do without
+// WithEnrichment.
+func WithDeadLetters(dlqRepo DeadLetterRepository) ServiceOption {
+	return func(s *service) {
+		s.dlqRepo = dlqRepo
+	}
+}
+
+// WithDeadLetterReplayProducer supplies the producer Replay republishes a
+// dead-lettered envelope through. It's a separate option from
+// WithDeadLetters because a deployment might index dead letters read-only
+// (e.g. a reporting-only management replica) without granting it publish
+// access to the pipeline's topics.
+func WithDeadLetterReplayProducer(producer kafka.Producer) ServiceOption {
+	return func(s *service) {
+		s.replayProducer = producer
+	}
+}
+
 func NewService(repo Repository, opts ...ServiceOption) Service {
 	s := &service{
 		repo:         repo,
 		auditEnabled: false,
+		dedupConfigs: make(map[string]*DeduplicationConfig),
 	}
 
 	for _, opt := range opts {
@@ -77,42 +162,66 @@ func NewService(repo Repository, opts ...ServiceOption) Service {
 }
 
 func (s *service) CreateFilteringRule(ctx context.Context, req CreateFilteringRuleRequest) (*FilteringRule, error) {
-	if err := ValidateFilteringRule(req); err != nil {
+	warnings, err := ValidateFilteringRule(req)
+	if err != nil {
 		return nil, pkgerrors.Wrap(err, pkgerrors.ErrValidation)
 	}
 
 	rule := &FilteringRule{
-		Name:       req.Name,
-		Expression: req.Expression,
-		Priority:   req.Priority,
-		Enabled:    getEnabledValue(req.Enabled),
+		Name:              req.Name,
+		Expression:        req.Expression,
+		Priority:          req.Priority,
+		Enabled:           getEnabledValue(req.Enabled),
+		Mode:              req.Mode,
+		Schedule:          req.Schedule,
+		MaxCost:           req.MaxCost,
+		MaxEvalDurationMs: req.MaxEvalDurationMs,
 	}
 
 	if err := s.repo.CreateFilteringRule(ctx, rule); err != nil {
 		return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
 	}
+	s.warmFilteringRuleCEL(rule.Expression)
 
 	s.createVersionAndAudit(ctx, rule, "create", nil)
-	s.publishConfigEvent(ctx, models.ActionCreate, rule.ID)
+	s.publishConfigEvent(ctx, models.ActionCreate, rule.ID, rule)
 
-	return s.copyFilteringRule(rule), nil
+	result := s.copyFilteringRule(rule)
+	result.Warnings = warnings
+	return result, nil
 }
 
+// ListFilteringRules returns the calling tenant's filtering rules. If ctx was
+// marked via ContextWithIncludeGlobal (see the handler's include_global query
+// param), it also returns DefaultTenantID's rules, with a tenant rule
+// shadowing a global one that shares its Name.
 func (s *service) ListFilteringRules(ctx context.Context) ([]FilteringRule, error) {
 	domainRules, err := s.repo.ListFilteringRules(ctx)
 	if err != nil {
 		return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
 	}
+	if includeGlobalRequested(ctx) && TenantIDFromContext(ctx) != DefaultTenantID {
+		globalRules, err := s.repo.ListFilteringRules(ContextWithTenant(ctx, DefaultTenantID))
+		if err != nil {
+			return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+		}
+		domainRules = mergeInheritedFilteringRules(domainRules, globalRules)
+	}
 	rules := make([]FilteringRule, len(domainRules))
 	for i, dr := range domainRules {
 		rules[i] = FilteringRule{
-			ID:         dr.ID,
-			Name:       dr.Name,
-			Expression: dr.Expression,
-			Priority:   dr.Priority,
-			Enabled:    dr.Enabled,
-			CreatedAt:  dr.CreatedAt,
-			UpdatedAt:  dr.UpdatedAt,
+			ID:                dr.ID,
+			Name:              dr.Name,
+			Expression:        dr.Expression,
+			Priority:          dr.Priority,
+			Enabled:           dr.Enabled,
+			Mode:              dr.Mode,
+			Schedule:          dr.Schedule,
+			MaxCost:           dr.MaxCost,
+			MaxEvalDurationMs: dr.MaxEvalDurationMs,
+			Version:           dr.Version,
+			CreatedAt:         dr.CreatedAt,
+			UpdatedAt:         dr.UpdatedAt,
 		}
 	}
 	return rules, nil
@@ -130,7 +239,8 @@ func (s *service) GetFilteringRule(ctx context.Context, id string) (*FilteringRu
 }
 
 func (s *service) UpdateFilteringRule(ctx context.Context, id string, req UpdateFilteringRuleRequest) (*FilteringRule, error) {
-	if err := ValidateUpdateFilteringRule(req); err != nil {
+	warnings, err := ValidateUpdateFilteringRule(req)
+	if err != nil {
 		return nil, pkgerrors.Wrap(err, pkgerrors.ErrValidation)
 	}
 
@@ -145,14 +255,29 @@ func (s *service) UpdateFilteringRule(ctx context.Context, id string, req Update
 	oldValue, _ := s.ruleToMap(rule)
 	s.updateFilteringRuleFields(rule, req)
 
-	if err := s.repo.UpdateFilteringRule(ctx, rule); err != nil {
+	if req.Version != nil {
+		if err := s.repo.UpdateFilteringRuleCAS(ctx, rule, *req.Version); err != nil {
+			if pkgerrors.IsConflict(err) {
+				if current, getErr := s.repo.GetForUpdate(ctx, id); getErr == nil && current != nil {
+					return nil, pkgerrors.ErrConflict.
+						WithDetail("message", "rule was modified by another request").
+						WithDetail("current_version", current.Version)
+				}
+				return nil, err
+			}
+			return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+		}
+	} else if err := s.repo.UpdateFilteringRule(ctx, rule); err != nil {
 		return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
 	}
+	s.warmFilteringRuleCEL(rule.Expression)
 
 	s.createVersionAndAudit(ctx, rule, "update", oldValue)
-	s.publishConfigEvent(ctx, models.ActionUpdate, rule.ID)
+	s.publishConfigEvent(ctx, models.ActionUpdate, rule.ID, rule)
 
-	return s.copyFilteringRule(rule), nil
+	result := s.copyFilteringRule(rule)
+	result.Warnings = warnings
+	return result, nil
 }
 
 func (s *service) DeleteFilteringRule(ctx context.Context, id string) error {
@@ -169,13 +294,15 @@ func (s *service) DeleteFilteringRule(ctx context.Context, id string) error {
 	if err := s.repo.DeleteFilteringRule(ctx, id); err != nil {
 		return pkgerrors.Wrap(err, pkgerrors.ErrInternal)
 	}
+	s.evictFilteringRuleCEL(rule.Expression)
+	metrics.EvictFilteringRule(id)
 
 	if s.auditEnabled && s.versioningRepo != nil {
-		auditLog := s.buildAuditLog(id, "filtering", "delete", oldValue, nil, getChangedBy(ctx))
+		auditLog := s.buildAuditLog(ctx, id, "filtering", "delete", oldValue, nil, getChangedBy(ctx))
 		_ = s.versioningRepo.CreateAuditLog(ctx, auditLog)
 	}
 
-	s.publishConfigEvent(ctx, models.ActionDelete, id)
+	s.publishConfigEvent(ctx, models.ActionDelete, id, nil)
 	return nil
 }
 
@@ -190,6 +317,59 @@ func (s *service) GetRuleVersions(ctx context.Context, ruleID string) ([]RuleVer
 	return versions, nil
 }
 
+func (s *service) GetRuleChangesSince(ctx context.Context, sinceRevision int64, limit int) ([]RuleVersion, error) {
+	if s.versioningRepo == nil {
+		return nil, pkgerrors.ErrInternal.WithDetail("message", "versioning not enabled")
+	}
+	versions, err := s.versioningRepo.GetVersionsSinceRevision(ctx, sinceRevision, limit)
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+	return versions, nil
+}
+
+func (s *service) GetRuleVersion(ctx context.Context, ruleID string, version int) (*RuleVersion, error) {
+	if s.versioningRepo == nil {
+		return nil, pkgerrors.ErrInternal.WithDetail("message", "versioning not enabled")
+	}
+	v, err := s.versioningRepo.GetVersion(ctx, ruleID, version)
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+	if v == nil {
+		return nil, pkgerrors.ErrNotFound.WithDetail("id", ruleID).WithDetail("version", version)
+	}
+	return v, nil
+}
+
+func (s *service) DiffRuleVersions(ctx context.Context, ruleID string, versionA, versionB int) ([]FieldDiff, error) {
+	if s.versioningRepo == nil {
+		return nil, pkgerrors.ErrInternal.WithDetail("message", "versioning not enabled")
+	}
+
+	from, err := s.versioningRepo.GetVersion(ctx, ruleID, versionA)
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+	if from == nil {
+		return nil, pkgerrors.ErrNotFound.WithDetail("id", ruleID).WithDetail("version", versionA)
+	}
+
+	to, err := s.versioningRepo.GetVersion(ctx, ruleID, versionB)
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+	if to == nil {
+		return nil, pkgerrors.ErrNotFound.WithDetail("id", ruleID).WithDetail("version", versionB)
+	}
+
+	diffs, err := diffRuleVersions(*from, *to)
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+	return diffs, nil
+}
+
 func (s *service) GetAuditLogs(ctx context.Context, ruleID *string, ruleType string, limit int) ([]AuditLog, error) {
 	if s.versioningRepo == nil {
 		return nil, pkgerrors.ErrInternal.WithDetail("message", "audit logging not enabled")
@@ -204,9 +384,72 @@ func (s *service) GetAuditLogs(ctx context.Context, ruleID *string, ruleType str
 	return logs, nil
 }
 
+func (s *service) ListDeadLettered(ctx context.Context, filter DeadLetterFilter) ([]DeadLetterEntry, error) {
+	if s.dlqRepo == nil {
+		return nil, pkgerrors.ErrInternal.WithDetail("message", "dead letter tracking not enabled")
+	}
+	return s.dlqRepo.List(ctx, filter)
+}
+
+// Replay republishes each previously dead-lettered envelope in ids to
+// targetTopic (or, if empty, the topic it individually failed on) and marks
+// it replayed. Every id is attempted independently - one bad id or publish
+// failure doesn't stop the rest - and the outcome of each is reported in
+// ReplayResult.Results, in the order ids was given, mirroring how
+// ApplyEnrichmentRuleBatch reports a per-op result for its non-atomic batch.
+// Replay does not remove or alter the ErrorRecord(s) already on an envelope's
+// Metadata.Errors - a downstream consumer seeing a retried message still
+// sees its full prior failure history.
+func (s *service) Replay(ctx context.Context, ids []string, targetTopic string) (*ReplayResult, error) {
+	if s.dlqRepo == nil {
+		return nil, pkgerrors.ErrInternal.WithDetail("message", "dead letter tracking not enabled")
+	}
+	if s.replayProducer == nil {
+		return nil, pkgerrors.ErrInternal.WithDetail("message", "dead letter replay producer not configured")
+	}
+
+	result := &ReplayResult{Results: make([]ReplayEntryResult, 0, len(ids))}
+	for _, id := range ids {
+		if err := s.replayOne(ctx, id, targetTopic); err != nil {
+			result.Results = append(result.Results, ReplayEntryResult{ID: id, OK: false, Error: err.Error()})
+			continue
+		}
+		result.Results = append(result.Results, ReplayEntryResult{ID: id, OK: true})
+	}
+	return result, nil
+}
+
+func (s *service) replayOne(ctx context.Context, id, targetTopic string) error {
+	entry, err := s.dlqRepo.Get(ctx, id)
+	if err != nil {
+		return pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+	if entry == nil {
+		return pkgerrors.ErrNotFound.WithDetail("id", id)
+	}
+
+	topic := targetTopic
+	if topic == "" {
+		topic = entry.SourceTopic
+	}
+	if topic == "" {
+		return pkgerrors.ErrValidation.WithDetail("message", "no target topic: entry has no recorded source topic and none was provided")
+	}
+
+	if err := s.replayProducer.Publish(ctx, topic, entry.Envelope); err != nil {
+		return pkgerrors.ErrInternal.WithCause(err)
+	}
+
+	if err := s.dlqRepo.MarkReplayed(ctx, id, topic, time.Now()); err != nil {
+		return pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+
+	return nil
+}
+
 func (s *service) CreateEnrichmentRule(ctx context.Context, req CreateEnrichmentRuleRequest) (*EnrichmentRule, error) {
 	if err := ValidateEnrichmentRule(req); err != nil {
-		return nil, pkgerrors.Wrap(err, pkgerrors.ErrValidation)
+		return nil, wrapValidationError(err)
 	}
 
 	if s.enrichmentRepo == nil {
@@ -223,6 +466,7 @@ func (s *service) CreateEnrichmentRule(ctx context.Context, req CreateEnrichment
 		FieldToEnrich:   req.FieldToEnrich,
 		SourceType:      req.SourceType,
 		SourceConfig:    req.SourceConfig,
+		Condition:       req.Condition,
 		Transformations: req.Transformations,
 		CacheTTLSeconds: req.CacheTTLSeconds,
 		ErrorHandling:   req.ErrorHandling,
@@ -231,17 +475,35 @@ func (s *service) CreateEnrichmentRule(ctx context.Context, req CreateEnrichment
 		Enabled:         enabled,
 	}
 
-	if err := s.enrichmentRepo.CreateEnrichmentRule(ctx, rule); err != nil {
+	if _, err := s.enrichmentRepo.CreateEnrichmentRule(ctx, rule); err != nil {
 		return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
 	}
+	s.cacheEnrichmentRuleValidation(rule)
+	s.cacheEnrichmentRuleHTTPTemplate(rule)
+	s.warmEnrichmentRuleCEL(rule)
 
 	if s.configEventProducer != nil {
-		_ = s.configEventProducer.PublishEnrichmentRuleEvent(ctx, models.ActionCreate, rule.ID, getChangedBy(ctx))
+		_ = s.configEventProducer.PublishEnrichmentRuleEvent(ctx, models.ActionCreate, rule.ID, getChangedBy(ctx), rule)
 	}
 
 	return rule, nil
 }
 
+// ListEnrichmentSourceTypes returns every source_type EnrichmentRule
+// currently accepts. It consults s.sourceRegistry (set via
+// WithEnrichmentSourceRegistry) when configured, and falls back to
+// NewEnrichmentSourceRegistry's process-wide view otherwise, so this works
+// the same whether or not a caller bothered to supply its own registry.
+func (s *service) ListEnrichmentSourceTypes(ctx context.Context) []string {
+	registry := s.sourceRegistry
+	if registry == nil {
+		registry = NewEnrichmentSourceRegistry()
+	}
+	return registry.SourceTypes()
+}
+
+// ListEnrichmentRules returns the calling tenant's enrichment rules, with the
+// same include_global inheritance as ListFilteringRules.
 func (s *service) ListEnrichmentRules(ctx context.Context) ([]EnrichmentRule, error) {
 	if s.enrichmentRepo == nil {
 		return nil, pkgerrors.ErrInternal.WithDetail("message", "enrichment repository not configured")
@@ -251,6 +513,13 @@ func (s *service) ListEnrichmentRules(ctx context.Context) ([]EnrichmentRule, er
 	if err != nil {
 		return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
 	}
+	if includeGlobalRequested(ctx) && TenantIDFromContext(ctx) != DefaultTenantID {
+		globalRules, err := s.enrichmentRepo.ListEnrichmentRules(ContextWithTenant(ctx, DefaultTenantID))
+		if err != nil {
+			return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+		}
+		rules = mergeInheritedEnrichmentRules(rules, globalRules)
+	}
 	return rules, nil
 }
 
@@ -271,7 +540,7 @@ func (s *service) GetEnrichmentRule(ctx context.Context, id string) (*Enrichment
 
 func (s *service) UpdateEnrichmentRule(ctx context.Context, id string, req UpdateEnrichmentRuleRequest) (*EnrichmentRule, error) {
 	if err := ValidateUpdateEnrichmentRule(req); err != nil {
-		return nil, pkgerrors.Wrap(err, pkgerrors.ErrValidation)
+		return nil, wrapValidationError(err)
 	}
 
 	if s.enrichmentRepo == nil {
@@ -286,46 +555,83 @@ func (s *service) UpdateEnrichmentRule(ctx context.Context, id string, req Updat
 		return nil, pkgerrors.ErrNotFound.WithDetail("id", id)
 	}
 
+	applyEnrichmentRuleUpdate(oldRule, req)
+
+	if req.Version != nil {
+		if _, err := s.enrichmentRepo.UpdateEnrichmentRuleCAS(ctx, oldRule, *req.Version); err != nil {
+			if pkgerrors.IsConflict(err) {
+				if current, getErr := s.enrichmentRepo.GetEnrichmentRule(ctx, id); getErr == nil && current != nil {
+					return nil, pkgerrors.ErrConflict.
+						WithDetail("message", "rule was modified by another request").
+						WithDetail("current_version", current.Version)
+				}
+				return nil, err
+			}
+			return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+		}
+	} else if _, err := s.enrichmentRepo.UpdateEnrichmentRule(ctx, oldRule); err != nil {
+		return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+	s.cacheEnrichmentRuleValidation(oldRule)
+	s.cacheEnrichmentRuleHTTPTemplate(oldRule)
+	s.warmEnrichmentRuleCEL(oldRule)
+
+	if s.configEventProducer != nil {
+		_ = s.configEventProducer.PublishEnrichmentRuleEvent(ctx, models.ActionUpdate, oldRule.ID, getChangedBy(ctx), oldRule)
+	}
+
+	return oldRule, nil
+}
+
+// applyEnrichmentRuleUpdate merges the set fields of req onto rule in place.
+// Shared by UpdateEnrichmentRule and the dry_run branch of the update
+// handler, which needs the same merged rule without persisting it.
+func applyEnrichmentRuleUpdate(rule *EnrichmentRule, req UpdateEnrichmentRuleRequest) {
 	if req.Name != nil {
-		oldRule.Name = *req.Name
+		rule.Name = *req.Name
 	}
 	if req.FieldToEnrich != nil {
-		oldRule.FieldToEnrich = *req.FieldToEnrich
+		rule.FieldToEnrich = *req.FieldToEnrich
 	}
 	if req.SourceType != nil {
-		oldRule.SourceType = *req.SourceType
+		rule.SourceType = *req.SourceType
 	}
 	if req.SourceConfig != nil {
-		oldRule.SourceConfig = *req.SourceConfig
+		rule.SourceConfig = *req.SourceConfig
+	}
+	if req.Condition != nil {
+		rule.Condition = *req.Condition
 	}
 	if req.Transformations != nil {
-		oldRule.Transformations = *req.Transformations
+		rule.Transformations = *req.Transformations
 	}
 	if req.CacheTTLSeconds != nil {
-		oldRule.CacheTTLSeconds = *req.CacheTTLSeconds
+		rule.CacheTTLSeconds = *req.CacheTTLSeconds
 	}
 	if req.ErrorHandling != nil {
-		oldRule.ErrorHandling = *req.ErrorHandling
+		rule.ErrorHandling = *req.ErrorHandling
 	}
 	if req.FallbackValue != nil {
-		oldRule.FallbackValue = *req.FallbackValue
+		rule.FallbackValue = *req.FallbackValue
 	}
 	if req.Priority != nil {
-		oldRule.Priority = *req.Priority
+		rule.Priority = *req.Priority
 	}
 	if req.Enabled != nil {
-		oldRule.Enabled = *req.Enabled
+		rule.Enabled = *req.Enabled
 	}
-
-	if err := s.enrichmentRepo.UpdateEnrichmentRule(ctx, oldRule); err != nil {
-		return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	if req.Mode != nil {
+		rule.Mode = *req.Mode
 	}
-
-	if s.configEventProducer != nil {
-		_ = s.configEventProducer.PublishEnrichmentRuleEvent(ctx, models.ActionUpdate, oldRule.ID, getChangedBy(ctx))
+	if req.CircuitBreaker != nil {
+		rule.CircuitBreaker = req.CircuitBreaker
+	}
+	if req.Retry != nil {
+		rule.Retry = req.Retry
+	}
+	if req.Schedule != nil {
+		rule.Schedule = req.Schedule
 	}
-
-	return oldRule, nil
 }
 
 func (s *service) DeleteEnrichmentRule(ctx context.Context, id string) error {
@@ -341,33 +647,119 @@ func (s *service) DeleteEnrichmentRule(ctx context.Context, id string) error {
 		return pkgerrors.ErrNotFound.WithDetail("id", id)
 	}
 
-	if err := s.enrichmentRepo.DeleteEnrichmentRule(ctx, id); err != nil {
+	if _, err := s.enrichmentRepo.DeleteEnrichmentRule(ctx, id); err != nil {
 		return pkgerrors.Wrap(err, pkgerrors.ErrInternal)
 	}
+	if ruleValidator, err := s.getRuleValidator(); err == nil {
+		ruleValidator.InvalidateRule(id)
+	}
+	s.getHTTPTemplateCompiler().InvalidateRule(id)
+	s.evictEnrichmentRuleCEL(rule)
+	metrics.EvictEnrichmentRule(id)
 
 	if s.configEventProducer != nil {
-		_ = s.configEventProducer.PublishEnrichmentRuleEvent(ctx, models.ActionDelete, id, getChangedBy(ctx))
+		_ = s.configEventProducer.PublishEnrichmentRuleEvent(ctx, models.ActionDelete, id, getChangedBy(ctx), nil)
 	}
 
 	return nil
 }
 
-func (s *service) GetDeduplicationConfig(ctx context.Context) (*DeduplicationConfig, error) {
-	s.dedupConfigMu.RLock()
-	defer s.dedupConfigMu.RUnlock()
+// GetEnrichmentRuleAudit returns id's create/update/delete history as
+// recorded by the configured EnrichmentRepository, newest first. Unlike
+// ListRuleHistory (which reads the Postgres-only VersioningRepository), this
+// works against whichever driver EnrichmentStorageConfig selects.
+func (s *service) GetEnrichmentRuleAudit(ctx context.Context, id string) ([]EnrichmentRuleAudit, error) {
+	if s.enrichmentRepo == nil {
+		return nil, pkgerrors.ErrInternal.WithDetail("message", "enrichment repository not configured")
+	}
+
+	entries, err := s.enrichmentRepo.GetEnrichmentRuleHistory(ctx, id)
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+	return entries, nil
+}
+
+func (s *service) RegisterProvider(ctx context.Context, req RegisterProviderRequest) (*ExternalProvider, error) {
+	if s.providerRepo == nil {
+		return nil, pkgerrors.ErrInternal.WithDetail("message", "provider repository not configured")
+	}
+
+	provider := &ExternalProvider{
+		TenantID:            TenantIDFromContext(ctx),
+		Name:                req.Name,
+		SourceType:          req.SourceType,
+		Address:             req.Address,
+		TLSCert:             req.TLSCert,
+		LoadBalancingPolicy: req.LoadBalancingPolicy,
+	}
+
+	if err := s.providerRepo.Create(ctx, provider); err != nil {
+		return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
 
-	if s.dedupConfig == nil {
-		return nil, pkgerrors.ErrNotFound.WithDetail("message", "deduplication config not initialized")
+	return provider, nil
+}
+
+func (s *service) ListProviders(ctx context.Context) ([]ExternalProvider, error) {
+	if s.providerRepo == nil {
+		return nil, pkgerrors.ErrInternal.WithDetail("message", "provider repository not configured")
+	}
+
+	providers, err := s.providerRepo.List(ctx, TenantIDFromContext(ctx))
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, pkgerrors.ErrInternal)
+	}
+	return providers, nil
+}
+
+func (s *service) DeleteProvider(ctx context.Context, id string) error {
+	if s.providerRepo == nil {
+		return pkgerrors.ErrInternal.WithDetail("message", "provider repository not configured")
 	}
 
-	config := &DeduplicationConfig{
-		HashAlgorithm: s.dedupConfig.HashAlgorithm,
-		TTLSeconds:    s.dedupConfig.TTLSeconds,
-		OnRedisError:  s.dedupConfig.OnRedisError,
-		FieldsToHash:  make([]string, len(s.dedupConfig.FieldsToHash)),
+	if err := s.providerRepo.Delete(ctx, TenantIDFromContext(ctx), id); err != nil {
+		return s.handleNotFoundError(err, id)
 	}
-	copy(config.FieldsToHash, s.dedupConfig.FieldsToHash)
+	return nil
+}
 
+// dedupConfigForTenantLocked returns the tenant's config, seeding it from
+// defaultDedupConfig (or a hardcoded fallback) on first access. Callers must
+// hold dedupConfigMu.
+func (s *service) dedupConfigForTenantLocked(tenantID string) *DeduplicationConfig {
+	if cfg, ok := s.dedupConfigs[tenantID]; ok {
+		return cfg
+	}
+
+	cfg := &DeduplicationConfig{
+		HashAlgorithm: "md5",
+		TTLSeconds:    constants.DefaultTTLSeconds,
+		OnRedisError:  "allow",
+		FieldsToHash:  []string{"id", "source"},
+		Version:       1,
+	}
+	if s.defaultDedupConfig != nil {
+		cfg.HashAlgorithm = s.defaultDedupConfig.HashAlgorithm
+		cfg.TTLSeconds = s.defaultDedupConfig.TTLSeconds
+		cfg.OnRedisError = s.defaultDedupConfig.OnRedisError
+		cfg.FieldsToHash = append([]string(nil), s.defaultDedupConfig.FieldsToHash...)
+		cfg.Salt = s.defaultDedupConfig.Salt
+		cfg.HMACKeyRef = s.defaultDedupConfig.HMACKeyRef
+	}
+	cfg.TenantID = tenantID
+	s.dedupConfigs[tenantID] = cfg
+	return cfg
+}
+
+func (s *service) GetDeduplicationConfig(ctx context.Context) (*DeduplicationConfig, error) {
+	tenantID := TenantIDFromContext(ctx)
+
+	s.dedupConfigMu.Lock()
+	defer s.dedupConfigMu.Unlock()
+
+	cfg := s.dedupConfigForTenantLocked(tenantID)
+	config := cloneDedupConfig(cfg)
 	return config, nil
 }
 
@@ -376,53 +768,80 @@ func (s *service) UpdateDeduplicationConfig(ctx context.Context, req UpdateDedup
 		return nil, pkgerrors.Wrap(err, pkgerrors.ErrValidation)
 	}
 
+	tenantID := TenantIDFromContext(ctx)
+
 	s.dedupConfigMu.Lock()
 	defer s.dedupConfigMu.Unlock()
 
-	if s.dedupConfig == nil {
-		s.dedupConfig = &DeduplicationConfig{
-			HashAlgorithm: "md5",
-			TTLSeconds:    constants.DefaultTTLSeconds,
-			OnRedisError:  "allow",
-			FieldsToHash:  []string{"id", "source"},
-		}
-	}
+	cfg := s.dedupConfigForTenantLocked(tenantID)
+	before := cloneDedupConfig(cfg)
 
 	// Update fields
 	if req.HashAlgorithm != nil {
-		s.dedupConfig.HashAlgorithm = *req.HashAlgorithm
+		cfg.HashAlgorithm = *req.HashAlgorithm
 	}
 	if req.TTLSeconds != nil {
-		s.dedupConfig.TTLSeconds = *req.TTLSeconds
+		cfg.TTLSeconds = *req.TTLSeconds
 	}
 	if req.OnRedisError != nil {
-		s.dedupConfig.OnRedisError = *req.OnRedisError
+		cfg.OnRedisError = *req.OnRedisError
 	}
 	if req.FieldsToHash != nil {
-		s.dedupConfig.FieldsToHash = *req.FieldsToHash
+		cfg.FieldsToHash = *req.FieldsToHash
+	}
+	if req.Salt != nil {
+		cfg.Salt = *req.Salt
+	}
+	if req.HMACKeyRef != nil {
+		cfg.HMACKeyRef = *req.HMACKeyRef
 	}
 
+	if cfg.HashAlgorithm == "hmac-sha256" && cfg.HMACKeyRef == "" {
+		return nil, pkgerrors.ErrValidation.WithDetail("message", "hmac_key_ref is required when hash_algorithm is hmac-sha256")
+	}
+
+	cfg.Version++
+	config := cloneDedupConfig(cfg)
+
+	s.recordDedupConfigVersion(ctx, "update", before, config)
+
 	if s.configEventProducer != nil {
 		eventMetadata := map[string]interface{}{
-			"fields_to_hash": s.dedupConfig.FieldsToHash,
-			"hash_algorithm": s.dedupConfig.HashAlgorithm,
-			"ttl_seconds":    s.dedupConfig.TTLSeconds,
+			"tenant_id":      cfg.TenantID,
+			"fields_to_hash": cfg.FieldsToHash,
+			"hash_algorithm": cfg.HashAlgorithm,
+			"ttl_seconds":    cfg.TTLSeconds,
+			"salt":           cfg.Salt,
 		}
 
 		_ = s.configEventProducer.PublishDedupConfigEvent(ctx, models.ActionUpdate, getChangedBy(ctx), eventMetadata)
 	}
 
-	config := &DeduplicationConfig{
-		HashAlgorithm: s.dedupConfig.HashAlgorithm,
-		TTLSeconds:    s.dedupConfig.TTLSeconds,
-		OnRedisError:  s.dedupConfig.OnRedisError,
-		FieldsToHash:  make([]string, len(s.dedupConfig.FieldsToHash)),
-	}
-	copy(config.FieldsToHash, s.dedupConfig.FieldsToHash)
-
 	return config, nil
 }
 
+// cloneDedupConfig returns a copy of cfg safe to hand to a caller outside
+// dedupConfigMu - in particular, one whose FieldsToHash slice doesn't alias
+// the tenant's live config.
+func cloneDedupConfig(cfg *DeduplicationConfig) *DeduplicationConfig {
+	clone := *cfg
+	clone.FieldsToHash = make([]string, len(cfg.FieldsToHash))
+	copy(clone.FieldsToHash, cfg.FieldsToHash)
+	return &clone
+}
+
+// wrapValidationError turns a *ValidationError into pkgerrors.ErrValidation
+// with its field/message exposed as details (so the HTTP layer can render a
+// field-level error), or falls back to a plain validation wrap for any other
+// error a validator function returns.
+func wrapValidationError(err error) error {
+	var ve *ValidationError
+	if errors.As(err, &ve) {
+		return pkgerrors.ErrValidation.WithCause(err).WithDetail("field", ve.Field).WithDetail("message", ve.Message)
+	}
+	return pkgerrors.Wrap(err, pkgerrors.ErrValidation)
+}
+
 func (s *service) handleNotFoundError(err error, id string) error {
 	if err == nil {
 		return nil
@@ -453,7 +872,7 @@ func (s *service) createVersionAndAudit(ctx context.Context, rule *FilteringRule
 		return
 	}
 
-	auditLog := s.buildAuditLog(rule.ID, "filtering", action, oldValue, newValue, getChangedBy(ctx))
+	auditLog := s.buildAuditLog(ctx, rule.ID, "filtering", action, oldValue, newValue, getChangedBy(ctx))
 	_ = s.versioningRepo.CreateAuditLog(ctx, auditLog)
 }
 
@@ -474,7 +893,7 @@ func (s *service) buildVersion(ctx context.Context, rule *FilteringRule, ruleJSO
 	}
 }
 
-func (s *service) buildAuditLog(ruleID, ruleType, action string, oldValue, newValue map[string]interface{}, changedBy string) *AuditLog {
+func (s *service) buildAuditLog(ctx context.Context, ruleID, ruleType, action string, oldValue, newValue map[string]interface{}, changedBy string) *AuditLog {
 	return &AuditLog{
 		RuleID:    &ruleID,
 		RuleType:  ruleType,
@@ -482,6 +901,7 @@ func (s *service) buildAuditLog(ruleID, ruleType, action string, oldValue, newVa
 		OldValue:  oldValue,
 		NewValue:  newValue,
 		ChangedBy: changedBy,
+		Patch:     patchDocumentFromContext(ctx),
 	}
 }
 
@@ -497,9 +917,9 @@ func (s *service) ruleToMap(rule *FilteringRule) (map[string]interface{}, error)
 	return result, nil
 }
 
-func (s *service) publishConfigEvent(ctx context.Context, action, ruleID string) {
+func (s *service) publishConfigEvent(ctx context.Context, action, ruleID string, rule *FilteringRule) {
 	if s.configEventProducer != nil {
-		_ = s.configEventProducer.PublishFilteringRuleEvent(ctx, action, ruleID, getChangedBy(ctx))
+		_ = s.configEventProducer.PublishFilteringRuleEvent(ctx, action, ruleID, getChangedBy(ctx), rule)
 	}
 }
 
@@ -516,18 +936,68 @@ func (s *service) updateFilteringRuleFields(rule *FilteringRule, req UpdateFilte
 	if req.Enabled != nil {
 		rule.Enabled = *req.Enabled
 	}
+	if req.Mode != nil {
+		rule.Mode = *req.Mode
+	}
+	if req.Schedule != nil {
+		rule.Schedule = req.Schedule
+	}
+	if req.MaxCost != nil {
+		rule.MaxCost = *req.MaxCost
+	}
+	if req.MaxEvalDurationMs != nil {
+		rule.MaxEvalDurationMs = *req.MaxEvalDurationMs
+	}
 }
 
 func (s *service) copyFilteringRule(rule *FilteringRule) *FilteringRule {
 	return &FilteringRule{
-		ID:         rule.ID,
-		Name:       rule.Name,
-		Expression: rule.Expression,
-		Priority:   rule.Priority,
-		Enabled:    rule.Enabled,
-		CreatedAt:  rule.CreatedAt,
-		UpdatedAt:  rule.UpdatedAt,
+		ID:                rule.ID,
+		Name:              rule.Name,
+		Expression:        rule.Expression,
+		Priority:          rule.Priority,
+		Enabled:           rule.Enabled,
+		Mode:              rule.Mode,
+		Schedule:          rule.Schedule,
+		MaxCost:           rule.MaxCost,
+		MaxEvalDurationMs: rule.MaxEvalDurationMs,
+		Version:           rule.Version,
+		CreatedAt:         rule.CreatedAt,
+		UpdatedAt:         rule.UpdatedAt,
+	}
+}
+
+// mergeInheritedFilteringRules combines a tenant's own filtering rules with
+// the global (DefaultTenantID) ones inherited alongside them, letting a
+// tenant rule shadow a global one of the same Name rather than listing both.
+func mergeInheritedFilteringRules(tenantRules, globalRules []FilteringRule) []FilteringRule {
+	shadowed := make(map[string]bool, len(tenantRules))
+	for _, rule := range tenantRules {
+		shadowed[rule.Name] = true
+	}
+	merged := tenantRules
+	for _, rule := range globalRules {
+		if !shadowed[rule.Name] {
+			merged = append(merged, rule)
+		}
+	}
+	return merged
+}
+
+// mergeInheritedEnrichmentRules is mergeInheritedFilteringRules's enrichment
+// counterpart.
+func mergeInheritedEnrichmentRules(tenantRules, globalRules []EnrichmentRule) []EnrichmentRule {
+	shadowed := make(map[string]bool, len(tenantRules))
+	for _, rule := range tenantRules {
+		shadowed[rule.Name] = true
+	}
+	merged := tenantRules
+	for _, rule := range globalRules {
+		if !shadowed[rule.Name] {
+			merged = append(merged, rule)
+		}
 	}
+	return merged
 }
 
 func getEnabledValue(reqEnabled *bool) bool {
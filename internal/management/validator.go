@@ -2,51 +2,218 @@ package management
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
+	"sync"
 
+	"yeti/internal/deduplication"
 	"yeti/pkg/cel"
 )
 
-func ValidateFilteringRule(req CreateFilteringRuleRequest) error {
+// maxEstimatedCELCost is the worst-case cel.Env.EstimateCost ceiling this
+// package's validators apply: ValidateEnrichmentRule/ValidateUpdateEnrichmentRule
+// reject a condition over it, ValidateFilteringRule/ValidateUpdateFilteringRule
+// return a warning for an expression over it (see estimatedCostWarning), and
+// transformation expressions are not checked against it. 0 (the default)
+// disables the check. Set once at startup via SetMaxEstimatedCELCost, from
+// config.ManagementConfig.CEL.MaxEstimatedCost.
+var maxEstimatedCELCost uint64
+
+// SetMaxEstimatedCELCost configures the ceiling every future validation call
+// in this package enforces.
+func SetMaxEstimatedCELCost(limit uint64) {
+	maxEstimatedCELCost = limit
+}
+
+func newValidationEvaluator() (*cel.Evaluator, error) {
+	evaluator, err := cel.NewEvaluator()
+	if err != nil {
+		return nil, err
+	}
+	evaluator.WithMaxEstimatedCost(maxEstimatedCELCost)
+	return evaluator, nil
+}
+
+// estimatedCostWarning builds ValidateFilteringRule/ValidateUpdateFilteringRule's
+// advisory message for an expression whose worst-case estimated cost exceeds
+// maxEstimatedCELCost, or returns "" if it's within bounds (or the ceiling is
+// disabled). Unlike evaluator.ValidateFilterExpression's hard-reject, a
+// filtering rule's cost estimate is surfaced to the caller instead of
+// blocking the save - see FilteringRule.MaxCost, which lets an operator raise
+// (or lower) that specific rule's evaluation-time ceiling in response.
+func estimatedCostWarning(evaluator *cel.Evaluator, expression string) (string, error) {
+	estimate, err := evaluator.EstimateFilterCost(expression)
+	if err != nil {
+		return "", fmt.Errorf("invalid CEL expression: %w", err)
+	}
+	if maxEstimatedCELCost > 0 && estimate.Max > maxEstimatedCELCost {
+		return fmt.Sprintf("expression's estimated worst-case cost (%d) exceeds the configured ceiling (%d); it may hit its evaluation-time budget at runtime unless max_cost is raised for this rule", estimate.Max, maxEstimatedCELCost), nil
+	}
+	return "", nil
+}
+
+// ValidateFilteringRule returns a non-nil error only for a hard failure
+// (missing fields, invalid mode, an expression that doesn't compile or
+// return bool). A high estimated CEL cost is not one of those - it comes
+// back as a warning string instead, so an operator can still save the rule
+// and rely on FilteringRule.MaxCost/filtering_rule_cost_exceeded_total to
+// catch it at runtime.
+func ValidateFilteringRule(req CreateFilteringRuleRequest) ([]string, error) {
 	if req.Name == "" {
-		return fmt.Errorf("name is required")
+		return nil, fmt.Errorf("name is required")
 	}
 	if req.Expression == "" {
-		return fmt.Errorf("expression is required")
+		return nil, fmt.Errorf("expression is required")
+	}
+	if err := validateFilteringRuleMode(req.Mode); err != nil {
+		return nil, err
 	}
 
-	evaluator, err := cel.NewEvaluator()
+	evaluator, err := newValidationEvaluator()
 	if err != nil {
-		return fmt.Errorf("failed to create CEL evaluator: %w", err)
+		return nil, fmt.Errorf("failed to create CEL evaluator: %w", err)
 	}
 
-	if err := evaluator.ValidateFilterExpression(req.Expression); err != nil {
-		return fmt.Errorf("invalid CEL expression: %w", err)
+	warning, err := estimatedCostWarning(evaluator, req.Expression)
+	if err != nil {
+		return nil, err
+	}
+	if warning != "" {
+		return []string{warning}, nil
 	}
 
-	return nil
+	return nil, nil
 }
 
-func ValidateUpdateFilteringRule(req UpdateFilteringRuleRequest) error {
+func ValidateUpdateFilteringRule(req UpdateFilteringRuleRequest) ([]string, error) {
+	if req.Mode != nil {
+		if err := validateFilteringRuleMode(*req.Mode); err != nil {
+			return nil, err
+		}
+	}
+
 	if req.Expression != nil && *req.Expression != "" {
-		evaluator, err := cel.NewEvaluator()
+		evaluator, err := newValidationEvaluator()
 		if err != nil {
-			return fmt.Errorf("failed to create CEL evaluator: %w", err)
+			return nil, fmt.Errorf("failed to create CEL evaluator: %w", err)
 		}
 
-		if err := evaluator.ValidateFilterExpression(*req.Expression); err != nil {
-			return fmt.Errorf("invalid CEL expression: %w", err)
+		warning, err := estimatedCostWarning(evaluator, *req.Expression)
+		if err != nil {
+			return nil, err
+		}
+		if warning != "" {
+			return []string{warning}, nil
 		}
 	}
-	return nil
+	return nil, nil
+}
+
+// validateFilteringRuleMode accepts "" (defaults to enforce), "enforce",
+// "shadow", or "canary:<percent>" with percent in [0, 100] - the same set
+// filtering.Rule.isEnforced resolves at evaluation time.
+func validateFilteringRuleMode(mode string) error {
+	switch {
+	case mode == "" || mode == "enforce" || mode == "shadow":
+		return nil
+	case strings.HasPrefix(mode, "canary:"):
+		pct, err := strconv.Atoi(strings.TrimPrefix(mode, "canary:"))
+		if err != nil || pct < 0 || pct > 100 {
+			return fmt.Errorf("invalid mode %q: canary percent must be an integer between 0 and 100", mode)
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid mode %q: must be \"enforce\", \"shadow\", or \"canary:<percent>\"", mode)
+	}
 }
 
 var validSourceTypes = map[string]bool{
-	"api":        true,
-	"database":   true,
-	"mongodb":    true,
-	"postgresql": true,
-	"cache":      true,
-	"redis":      true,
+	"api":          true,
+	"database":     true,
+	"mongodb":      true,
+	"postgresql":   true,
+	"cache":        true,
+	"redis":        true,
+	"http":         true,
+	"grpc":         true,
+	"kafka_lookup": true,
+	"file":         true,
+}
+
+// sourceTypeValidators holds SourceConfig validation for source types added
+// after ValidateEnrichmentRule's per-type if-chain below was written, so a
+// new type doesn't need another branch grafted into it. kafka_lookup and
+// file are registered here rather than joining the if-chain; built-in types
+// already validated by the if-chain (api/http/grpc/database/cache) aren't
+// migrated, since that would be unrelated churn for this change.
+var (
+	sourceTypeValidatorsMu sync.RWMutex
+	sourceTypeValidators   = map[string]func(EnrichmentSourceConfig) error{
+		"kafka_lookup": validateKafkaLookupSourceConfig,
+		"file":         validateFileSourceConfig,
+	}
+)
+
+// RegisterSourceTypeValidator adds or replaces the SourceConfig validator
+// ValidateEnrichmentRule/ValidateUpdateEnrichmentRule dispatch to for
+// sourceType, and marks sourceType as an allowed EnrichmentRule source_type.
+// This is how a third-party enrichment source (registered at runtime into
+// provider.Registry for the fetch path - see enrichment.DiscoverExternalProviders)
+// also gets its create/update request validated, without editing
+// validSourceTypes or this file's if-chain.
+func RegisterSourceTypeValidator(sourceType string, validate func(EnrichmentSourceConfig) error) {
+	sourceTypeValidatorsMu.Lock()
+	defer sourceTypeValidatorsMu.Unlock()
+	sourceTypeValidators[sourceType] = validate
+	validSourceTypes[sourceType] = true
+}
+
+func sourceTypeValidator(sourceType string) (func(EnrichmentSourceConfig) error, bool) {
+	sourceTypeValidatorsMu.RLock()
+	defer sourceTypeValidatorsMu.RUnlock()
+	validate, ok := sourceTypeValidators[sourceType]
+	return validate, ok
+}
+
+func validateKafkaLookupSourceConfig(cfg EnrichmentSourceConfig) error {
+	if len(cfg.KafkaBrokers) == 0 {
+		return &ValidationError{Field: "source_config.kafka_brokers", Message: "source_config.kafka_brokers is required for kafka_lookup source type"}
+	}
+	if cfg.KafkaTopic == "" {
+		return &ValidationError{Field: "source_config.kafka_topic", Message: "source_config.kafka_topic is required for kafka_lookup source type"}
+	}
+	return nil
+}
+
+// validateRuleCircuitBreakerConfig allows nil (no override), and otherwise
+// only checks FailureRatio's range - gobreaker.Settings tolerates a zero
+// MaxRequests/Interval/Timeout/MinRequests by falling back to its own
+// defaults, so those are left unchecked the same way cbConfig's global
+// counterparts in config.CircuitBreakerConfig are.
+func validateRuleCircuitBreakerConfig(cb *RuleCircuitBreakerConfig) error {
+	if cb == nil {
+		return nil
+	}
+	if cb.FailureRatio < 0 || cb.FailureRatio > 1 {
+		return &ValidationError{Field: "circuit_breaker.failure_ratio", Message: "circuit_breaker.failure_ratio must be between 0 and 1"}
+	}
+	return nil
+}
+
+func validateFileSourceConfig(cfg EnrichmentSourceConfig) error {
+	if cfg.FilePath == "" {
+		return &ValidationError{Field: "source_config.file_path", Message: "source_config.file_path is required for file source type"}
+	}
+	switch cfg.FileFormat {
+	case "", "json":
+	case "csv":
+		if cfg.KeyField == "" {
+			return &ValidationError{Field: "source_config.key_field", Message: "source_config.key_field is required for csv file source type"}
+		}
+	default:
+		return &ValidationError{Field: "source_config.file_format", Message: fmt.Sprintf("invalid file_format: %s. Allowed: csv, json", cfg.FileFormat)}
+	}
+	return nil
 }
 
 var validErrorHandling = map[string]bool{
@@ -57,48 +224,68 @@ var validErrorHandling = map[string]bool{
 
 func ValidateEnrichmentRule(req CreateEnrichmentRuleRequest) error {
 	if req.Name == "" {
-		return fmt.Errorf("name is required")
+		return &ValidationError{Field: "name", Message: "name is required"}
 	}
 	if req.FieldToEnrich == "" {
-		return fmt.Errorf("field_to_enrich is required")
+		return &ValidationError{Field: "field_to_enrich", Message: "field_to_enrich is required"}
 	}
 	if !validSourceTypes[req.SourceType] {
-		return fmt.Errorf("invalid source_type: %s. Allowed: api, database, mongodb, postgresql, cache, redis", req.SourceType)
+		return &ValidationError{Field: "source_type", Message: fmt.Sprintf("invalid source_type: %s. Allowed: api, database, mongodb, postgresql, cache, redis, http, grpc, kafka_lookup, file", req.SourceType)}
+	}
+	if (req.SourceType == "api" || req.SourceType == "http") && req.SourceConfig.URL == "" {
+		return &ValidationError{Field: "source_config.url", Message: fmt.Sprintf("source_config.url is required for %s source type", req.SourceType)}
 	}
-	if req.SourceType == "api" && req.SourceConfig.URL == "" {
-		return fmt.Errorf("source_config.url is required for api source type")
+	if req.SourceType == "grpc" && req.SourceConfig.Address == "" {
+		return &ValidationError{Field: "source_config.address", Message: "source_config.address is required for grpc source type"}
 	}
 	if req.SourceType == "database" || req.SourceType == "mongodb" || req.SourceType == "postgresql" {
 		if req.SourceConfig.Collection == "" {
-			return fmt.Errorf("source_config.collection is required for database source type")
+			return &ValidationError{Field: "source_config.collection", Message: "source_config.collection is required for database source type"}
 		}
 		if req.SourceConfig.Query == nil && req.SourceConfig.Field == "" {
-			return fmt.Errorf("either source_config.query or source_config.field is required for database source type")
+			return &ValidationError{Field: "source_config.field", Message: "either source_config.query or source_config.field is required for database source type"}
 		}
 	}
 	if req.SourceType == "cache" || req.SourceType == "redis" {
 		if req.SourceConfig.KeyPattern == "" {
-			return fmt.Errorf("source_config.key_pattern is required for cache source type")
+			return &ValidationError{Field: "source_config.key_pattern", Message: "source_config.key_pattern is required for cache source type"}
+		}
+	}
+	if req.SourceType == "http" {
+		if err := validateHTTPSourceTemplates(req.SourceConfig); err != nil {
+			return err
+		}
+	}
+	if validate, ok := sourceTypeValidator(req.SourceType); ok {
+		if err := validate(req.SourceConfig); err != nil {
+			return err
 		}
 	}
 	if req.ErrorHandling != "" && !validErrorHandling[req.ErrorHandling] {
-		return fmt.Errorf("invalid error_handling: %s. Allowed: skip_field, skip_rule, fail", req.ErrorHandling)
+		return &ValidationError{Field: "error_handling", Message: fmt.Sprintf("invalid error_handling: %s. Allowed: skip_field, skip_rule, fail", req.ErrorHandling)}
 	}
 	if req.CacheTTLSeconds < 0 {
-		return fmt.Errorf("cache_ttl_seconds must be non-negative")
+		return &ValidationError{Field: "cache_ttl_seconds", Message: "cache_ttl_seconds must be non-negative"}
+	}
+	if err := validateRuleCircuitBreakerConfig(req.CircuitBreaker); err != nil {
+		return err
+	}
+	if req.Condition != "" {
+		evaluator, err := newValidationEvaluator()
+		if err != nil {
+			return fmt.Errorf("failed to create CEL evaluator: %w", err)
+		}
+		if err := evaluator.ValidateFilterExpression(req.Condition); err != nil {
+			return &ValidationError{Field: "condition", Message: fmt.Sprintf("invalid CEL expression: %s", err)}
+		}
 	}
 
-	evaluator, err := cel.NewEvaluator()
+	ruleValidator, err := NewRuleValidator()
 	if err != nil {
-		return fmt.Errorf("failed to create CEL evaluator: %w", err)
+		return fmt.Errorf("failed to create rule validator: %w", err)
 	}
-
-	for i, trans := range req.Transformations {
-		if trans.Expression != "" {
-			if err := evaluator.ValidateTransformExpression(trans.Expression); err != nil {
-				return fmt.Errorf("invalid CEL expression in transformation[%d]: %w", i, err)
-			}
-		}
+	if _, err := ruleValidator.CompileTransformations(req.Transformations); err != nil {
+		return err
 	}
 
 	return nil
@@ -107,30 +294,49 @@ func ValidateEnrichmentRule(req CreateEnrichmentRuleRequest) error {
 func ValidateUpdateEnrichmentRule(req UpdateEnrichmentRuleRequest) error {
 	if req.SourceType != nil {
 		if !validSourceTypes[*req.SourceType] {
-			return fmt.Errorf("invalid source_type: %s. Allowed: api, database, cache", *req.SourceType)
+			return &ValidationError{Field: "source_type", Message: fmt.Sprintf("invalid source_type: %s. Allowed: api, database, mongodb, postgresql, cache, redis, http, grpc, kafka_lookup, file", *req.SourceType)}
 		}
 	}
 	if req.ErrorHandling != nil {
 		if !validErrorHandling[*req.ErrorHandling] {
-			return fmt.Errorf("invalid error_handling: %s. Allowed: skip_field, skip_rule, fail", *req.ErrorHandling)
+			return &ValidationError{Field: "error_handling", Message: fmt.Sprintf("invalid error_handling: %s. Allowed: skip_field, skip_rule, fail", *req.ErrorHandling)}
+		}
+	}
+	if req.SourceType != nil && *req.SourceType == "http" && req.SourceConfig != nil {
+		if err := validateHTTPSourceTemplates(*req.SourceConfig); err != nil {
+			return err
+		}
+	}
+	if req.SourceType != nil && req.SourceConfig != nil {
+		if validate, ok := sourceTypeValidator(*req.SourceType); ok {
+			if err := validate(*req.SourceConfig); err != nil {
+				return err
+			}
 		}
 	}
 	if req.CacheTTLSeconds != nil && *req.CacheTTLSeconds < 0 {
-		return fmt.Errorf("cache_ttl_seconds must be non-negative")
+		return &ValidationError{Field: "cache_ttl_seconds", Message: "cache_ttl_seconds must be non-negative"}
 	}
-
-	if req.Transformations != nil {
-		evaluator, err := cel.NewEvaluator()
+	if err := validateRuleCircuitBreakerConfig(req.CircuitBreaker); err != nil {
+		return err
+	}
+	if req.Condition != nil && *req.Condition != "" {
+		evaluator, err := newValidationEvaluator()
 		if err != nil {
 			return fmt.Errorf("failed to create CEL evaluator: %w", err)
 		}
+		if err := evaluator.ValidateFilterExpression(*req.Condition); err != nil {
+			return &ValidationError{Field: "condition", Message: fmt.Sprintf("invalid CEL expression: %s", err)}
+		}
+	}
 
-		for i, trans := range *req.Transformations {
-			if trans.Expression != "" {
-				if err := evaluator.ValidateExpression(trans.Expression); err != nil {
-					return fmt.Errorf("invalid CEL expression in transformation[%d]: %w", i, err)
-				}
-			}
+	if req.Transformations != nil {
+		ruleValidator, err := NewRuleValidator()
+		if err != nil {
+			return fmt.Errorf("failed to create rule validator: %w", err)
+		}
+		if _, err := ruleValidator.CompileTransformations(*req.Transformations); err != nil {
+			return err
 		}
 	}
 
@@ -138,8 +344,9 @@ func ValidateUpdateEnrichmentRule(req UpdateEnrichmentRuleRequest) error {
 }
 
 var validHashAlgorithms = map[string]bool{
-	"md5":    true,
-	"sha256": true,
+	"md5":         true,
+	"sha256":      true,
+	"hmac-sha256": true,
 }
 
 var validOnRedisError = map[string]bool{
@@ -147,10 +354,16 @@ var validOnRedisError = map[string]bool{
 	"filter_out": true,
 }
 
+// ValidateDeduplicationConfig validates req in isolation (hash_algorithm,
+// on_redis_error, ttl_seconds, and the syntax of each fields_to_hash
+// entry). It can't enforce the "hmac-sha256 requires a key" cross-field
+// rule, since req is only the patch being applied, not the tenant's
+// resulting config - see service.go's UpdateDeduplicationConfig, which
+// checks that after merging req onto the current config.
 func ValidateDeduplicationConfig(req UpdateDeduplicationConfigRequest) error {
 	if req.HashAlgorithm != nil {
 		if !validHashAlgorithms[*req.HashAlgorithm] {
-			return fmt.Errorf("invalid hash_algorithm: %s. Allowed: md5, sha256", *req.HashAlgorithm)
+			return fmt.Errorf("invalid hash_algorithm: %s. Allowed: md5, sha256, hmac-sha256", *req.HashAlgorithm)
 		}
 	}
 	if req.OnRedisError != nil {
@@ -165,6 +378,11 @@ func ValidateDeduplicationConfig(req UpdateDeduplicationConfigRequest) error {
 		if len(*req.FieldsToHash) == 0 {
 			return fmt.Errorf("fields_to_hash cannot be empty")
 		}
+		for _, field := range *req.FieldsToHash {
+			if err := deduplication.ValidateFieldExpr(field); err != nil {
+				return fmt.Errorf("invalid fields_to_hash entry %q: %w", field, err)
+			}
+		}
 	}
 	return nil
 }
@@ -4,18 +4,51 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"yeti/internal/constants"
+	pkgerrors "yeti/pkg/errors"
 )
 
+// ErrVersionConflict is returned (wrapped under pkgerrors.ErrConflict, so
+// pkgerrors.IsConflict still matches) by UpdateWithVersion when the rule's
+// current version no longer matches the caller's expectedVersion, meaning
+// someone else wrote it first.
+var ErrVersionConflict = errors.New("management: rule version conflict")
+
+// AuditMeta carries the who/why/where of a rule change into UpdateWithVersion,
+// mirroring the fields CreateAuditLog and RuleVersion already track per-write.
+type AuditMeta struct {
+	ChangedBy    string
+	ChangeReason string
+	IPAddress    string
+}
+
+// BackoffFunc computes how long RetryOnConflict should wait before attempt n
+// (0-indexed), e.g. retry.EqualJitter or retry.CalculateBackoffDuration bound
+// to fixed base/cap/multiplier arguments.
+type BackoffFunc func(attempt int) time.Duration
+
 type RuleVersion struct {
-	ID           string    `json:"id"`
-	RuleID       string    `json:"rule_id"`
-	RuleType     string    `json:"rule_type"`
-	RuleData     string    `json:"rule_data"`
-	Version      int       `json:"version"`
+	ID       string `json:"id"`
+	TenantID string `json:"tenant_id"`
+	RuleID   string `json:"rule_id"`
+	RuleType string `json:"rule_type"`
+	RuleData string `json:"rule_data"`
+	Version  int    `json:"version"`
+	// Revision is the rule_versions row's globally (per-table, not
+	// per-rule) monotonically increasing BIGSERIAL, unlike Version which
+	// only increases within a single rule_id. It's what
+	// GetVersionsSinceRevision and ApplyFilteringChangeSet's callers use
+	// to resume a "changes since revision N" watch feed across every
+	// rule rather than one rule at a time.
+	Revision     int64     `json:"revision"`
 	ChangedBy    string    `json:"changed_by,omitempty"`
 	ChangeReason string    `json:"change_reason,omitempty"`
 	CreatedAt    time.Time `json:"created_at"`
@@ -23,6 +56,7 @@ type RuleVersion struct {
 
 type AuditLog struct {
 	ID           string                 `json:"id"`
+	TenantID     string                 `json:"tenant_id"`
 	RuleID       *string                `json:"rule_id,omitempty"`
 	RuleType     string                 `json:"rule_type"`
 	Action       string                 `json:"action"`
@@ -32,6 +66,48 @@ type AuditLog struct {
 	ChangeReason string                 `json:"change_reason,omitempty"`
 	IPAddress    string                 `json:"ip_address,omitempty"`
 	Timestamp    time.Time              `json:"timestamp"`
+	// Patch is the raw RFC 6902/RFC 7396 body a PATCH request was
+	// submitted with, set via withPatchDocument (patch.go) and recorded
+	// here instead of relying on OldValue/NewValue alone so
+	// GetRuleVersions/GetRuleAuditLogs can show the diff a client
+	// actually sent. Empty for changes made via PUT/POST, which never
+	// carry a patch document.
+	Patch json.RawMessage `json:"patch,omitempty"`
+}
+
+// PatchOp is a single RFC 6902 JSON Patch operation. jsonPatchDiff/
+// DiffVersions only ever produce "add", "remove" and "replace" (rule_data
+// has no arrays to reorder, so "move"/"copy" don't apply, and Value is
+// omitted for "remove"); applyJSONPatch (patch.go), which decodes an
+// incoming PATCH body into the same type, additionally accepts "test".
+type PatchOp struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// RuleDiff is the JSON-Patch representation of the change between two
+// stored versions of a rule, returned by VersioningRepository.DiffVersions.
+// It's a separate, finer-grained view of the same two RuleVersion.RuleData
+// blobs diffRuleVersions already compares field-by-field for DiffRuleVersions;
+// callers that want a patch they can replay rather than a flat field list
+// use this one instead.
+type RuleDiff struct {
+	RuleID      string    `json:"rule_id"`
+	FromVersion int       `json:"from_version"`
+	ToVersion   int       `json:"to_version"`
+	Patch       []PatchOp `json:"patch"`
+}
+
+// VersionSummary is a compact, UI-timeline-sized view of a RuleVersion:
+// who changed it, why, when, and how big the change was, without the full
+// rule_data payload.
+type VersionSummary struct {
+	Version      int       `json:"version"`
+	ChangedBy    string    `json:"changed_by,omitempty"`
+	ChangeReason string    `json:"change_reason,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	OpsCount     int       `json:"ops_count"`
 }
 
 type VersioningRepository interface {
@@ -40,7 +116,52 @@ type VersioningRepository interface {
 	GetVersion(ctx context.Context, ruleID string, version int) (*RuleVersion, error)
 	CreateAuditLog(ctx context.Context, log *AuditLog) error
 	GetAuditLogs(ctx context.Context, ruleID *string, ruleType string, limit int) ([]AuditLog, error)
+	GetAuditLog(ctx context.Context, id string) (*AuditLog, error)
 	GetNextVersion(ctx context.Context, ruleID string) (int, error)
+
+	// UpdateWithVersion atomically applies mutate to the rule identified by
+	// ruleID, provided its current version still matches expectedVersion,
+	// and records the resulting rule_versions/rule_audit_logs rows in the
+	// same transaction. It replaces the CreateVersion/GetNextVersion pair
+	// above for callers that need to read-modify-write a rule without a
+	// lost-update race: those two calls are independent round trips, so two
+	// concurrent writers can both observe the same "next version" and one
+	// either overwrites the other or fails the rule_versions uniqueness
+	// constraint outright.
+	UpdateWithVersion(ctx context.Context, ruleID string, expectedVersion int, mutate func(current *FilteringRule) (*FilteringRule, error), meta AuditMeta) (*RuleVersion, error)
+
+	// DiffVersions returns the JSON-Patch representation of the change
+	// between two stored versions of ruleID, or (nil, nil) if either
+	// version doesn't exist.
+	DiffVersions(ctx context.Context, ruleID string, fromV, toV int) (*RuleDiff, error)
+
+	// RollbackToVersion restores ruleID to the payload captured in
+	// targetVersion by writing a brand new version and "rollback" audit
+	// entry, the same way UpdateWithVersion writes an "update" one; it
+	// never rewrites or deletes the versions in between, so rolling back
+	// and forward again is always possible. Returns (nil, nil) if
+	// targetVersion doesn't exist.
+	RollbackToVersion(ctx context.Context, ruleID string, targetVersion int, meta AuditMeta) (*RuleVersion, error)
+
+	// GetVersionTimeline returns a VersionSummary per version created at or
+	// after since, newest first, with OpsCount set to the size of the
+	// JSON-Patch diff against the previous version (or against an empty
+	// rule, for the first version).
+	GetVersionTimeline(ctx context.Context, ruleID string, since time.Time) ([]VersionSummary, error)
+
+	// GetVersionsSinceRevision returns rule_versions rows with revision >
+	// sinceRevision, oldest first, across every rule_id - the read side of
+	// the "changes since revision N" watch feed. See RuleVersion.Revision.
+	GetVersionsSinceRevision(ctx context.Context, sinceRevision int64, limit int) ([]RuleVersion, error)
+
+	// ApplyFilteringChangeSet applies every op in ops in a single
+	// transaction: either all of them land in filtering_rules plus their
+	// rule_versions/rule_audit_logs rows, or (on any op's error) none do.
+	// It returns the RuleVersion written for each create/update op, in
+	// the same order as ops (delete ops have no corresponding entry,
+	// matching DeleteFilteringRule's existing behavior of auditing a
+	// delete without versioning it).
+	ApplyFilteringChangeSet(ctx context.Context, ops []FilteringRuleOp, meta AuditMeta) ([]RuleVersion, error)
 }
 
 type postgresVersioningRepository struct {
@@ -54,16 +175,18 @@ func (r *postgresVersioningRepository) CreateVersion(ctx context.Context, versio
 	if version.CreatedAt.IsZero() {
 		version.CreatedAt = time.Now()
 	}
+	version.TenantID = TenantIDFromContext(ctx)
 
 	query := `
-		INSERT INTO rule_versions (id, rule_id, rule_type, rule_data, version, changed_by, change_reason, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO rule_versions (id, tenant_id, rule_id, rule_type, rule_data, version, changed_by, change_reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING revision
 	`
 
-	_, err := r.db.ExecContext(ctx, query,
-		version.ID, version.RuleID, version.RuleType, version.RuleData,
+	err := r.db.QueryRowContext(ctx, query,
+		version.ID, version.TenantID, version.RuleID, version.RuleType, version.RuleData,
 		version.Version, version.ChangedBy, version.ChangeReason, version.CreatedAt,
-	)
+	).Scan(&version.Revision)
 	if err != nil {
 		return fmt.Errorf("failed to create rule version: %w", err)
 	}
@@ -73,13 +196,13 @@ func (r *postgresVersioningRepository) CreateVersion(ctx context.Context, versio
 
 func (r *postgresVersioningRepository) GetVersions(ctx context.Context, ruleID string) ([]RuleVersion, error) {
 	query := `
-		SELECT id, rule_id, rule_type, rule_data, version, changed_by, change_reason, created_at
+		SELECT id, tenant_id, rule_id, rule_type, rule_data, version, revision, changed_by, change_reason, created_at
 		FROM rule_versions
-		WHERE rule_id = $1
+		WHERE rule_id = $1 AND tenant_id = $2
 		ORDER BY version DESC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, ruleID)
+	rows, err := r.db.QueryContext(ctx, query, ruleID, TenantIDFromContext(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to query versions: %w", err)
 	}
@@ -89,8 +212,8 @@ func (r *postgresVersioningRepository) GetVersions(ctx context.Context, ruleID s
 	for rows.Next() {
 		var v RuleVersion
 		if err := rows.Scan(
-			&v.ID, &v.RuleID, &v.RuleType, &v.RuleData,
-			&v.Version, &v.ChangedBy, &v.ChangeReason, &v.CreatedAt,
+			&v.ID, &v.TenantID, &v.RuleID, &v.RuleType, &v.RuleData,
+			&v.Version, &v.Revision, &v.ChangedBy, &v.ChangeReason, &v.CreatedAt,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan version: %w", err)
 		}
@@ -102,15 +225,15 @@ func (r *postgresVersioningRepository) GetVersions(ctx context.Context, ruleID s
 
 func (r *postgresVersioningRepository) GetVersion(ctx context.Context, ruleID string, version int) (*RuleVersion, error) {
 	query := `
-		SELECT id, rule_id, rule_type, rule_data, version, changed_by, change_reason, created_at
+		SELECT id, tenant_id, rule_id, rule_type, rule_data, version, revision, changed_by, change_reason, created_at
 		FROM rule_versions
-		WHERE rule_id = $1 AND version = $2
+		WHERE rule_id = $1 AND version = $2 AND tenant_id = $3
 	`
 
 	var v RuleVersion
-	err := r.db.QueryRowContext(ctx, query, ruleID, version).Scan(
-		&v.ID, &v.RuleID, &v.RuleType, &v.RuleData,
-		&v.Version, &v.ChangedBy, &v.ChangeReason, &v.CreatedAt,
+	err := r.db.QueryRowContext(ctx, query, ruleID, version, TenantIDFromContext(ctx)).Scan(
+		&v.ID, &v.TenantID, &v.RuleID, &v.RuleType, &v.RuleData,
+		&v.Version, &v.Revision, &v.ChangedBy, &v.ChangeReason, &v.CreatedAt,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -122,6 +245,47 @@ func (r *postgresVersioningRepository) GetVersion(ctx context.Context, ruleID st
 	return &v, nil
 }
 
+// GetVersionsSinceRevision returns up to limit rule_versions rows with
+// revision > sinceRevision, oldest first, across every rule_id for the
+// caller's tenant - the primitive a "changes since revision N" watch feed
+// polls or streams from. Pass the last row's Revision as the next call's
+// sinceRevision to resume; sinceRevision 0 starts from the beginning of
+// the tenant's history. limit <= 0 falls back to constants.DefaultLimit,
+// matching GetAuditLogs.
+func (r *postgresVersioningRepository) GetVersionsSinceRevision(ctx context.Context, sinceRevision int64, limit int) ([]RuleVersion, error) {
+	if limit <= 0 || limit > constants.MaxLimit {
+		limit = constants.DefaultLimit
+	}
+
+	query := `
+		SELECT id, tenant_id, rule_id, rule_type, rule_data, version, revision, changed_by, change_reason, created_at
+		FROM rule_versions
+		WHERE revision > $1 AND tenant_id = $2
+		ORDER BY revision ASC
+		LIMIT $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, sinceRevision, TenantIDFromContext(ctx), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query versions since revision: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []RuleVersion
+	for rows.Next() {
+		var v RuleVersion
+		if err := rows.Scan(
+			&v.ID, &v.TenantID, &v.RuleID, &v.RuleType, &v.RuleData,
+			&v.Version, &v.Revision, &v.ChangedBy, &v.ChangeReason, &v.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan version: %w", err)
+		}
+		versions = append(versions, v)
+	}
+
+	return versions, nil
+}
+
 func (r *postgresVersioningRepository) CreateAuditLog(ctx context.Context, log *AuditLog) error {
 	if log.ID == "" {
 		log.ID = uuid.New().String()
@@ -129,6 +293,7 @@ func (r *postgresVersioningRepository) CreateAuditLog(ctx context.Context, log *
 	if log.Timestamp.IsZero() {
 		log.Timestamp = time.Now()
 	}
+	log.TenantID = TenantIDFromContext(ctx)
 
 	var oldValueJSON, newValueJSON []byte
 	var err error
@@ -148,12 +313,12 @@ func (r *postgresVersioningRepository) CreateAuditLog(ctx context.Context, log *
 	}
 
 	query := `
-		INSERT INTO rule_audit_logs (id, rule_id, rule_type, action, old_value, new_value, changed_by, change_reason, ip_address, timestamp)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		INSERT INTO rule_audit_logs (id, tenant_id, rule_id, rule_type, action, old_value, new_value, changed_by, change_reason, ip_address, timestamp)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	`
 
 	_, err = r.db.ExecContext(ctx, query,
-		log.ID, log.RuleID, log.RuleType, log.Action,
+		log.ID, log.TenantID, log.RuleID, log.RuleType, log.Action,
 		oldValueJSON, newValueJSON, log.ChangedBy, log.ChangeReason, log.IPAddress, log.Timestamp,
 	)
 	if err != nil {
@@ -167,32 +332,35 @@ func (r *postgresVersioningRepository) GetAuditLogs(ctx context.Context, ruleID
 	var query string
 	var args []interface{}
 
+	tenantID := TenantIDFromContext(ctx)
+
 	if ruleID != nil {
 		query = `
-			SELECT id, rule_id, rule_type, action, old_value, new_value, changed_by, change_reason, ip_address, timestamp
+			SELECT id, tenant_id, rule_id, rule_type, action, old_value, new_value, changed_by, change_reason, ip_address, timestamp
 			FROM rule_audit_logs
-			WHERE rule_id = $1
+			WHERE rule_id = $1 AND tenant_id = $2
 			ORDER BY timestamp DESC
-			LIMIT $2
+			LIMIT $3
 		`
-		args = []interface{}{*ruleID, limit}
+		args = []interface{}{*ruleID, tenantID, limit}
 	} else if ruleType != "" {
 		query = `
-			SELECT id, rule_id, rule_type, action, old_value, new_value, changed_by, change_reason, ip_address, timestamp
+			SELECT id, tenant_id, rule_id, rule_type, action, old_value, new_value, changed_by, change_reason, ip_address, timestamp
 			FROM rule_audit_logs
-			WHERE rule_type = $1
+			WHERE rule_type = $1 AND tenant_id = $2
 			ORDER BY timestamp DESC
-			LIMIT $2
+			LIMIT $3
 		`
-		args = []interface{}{ruleType, limit}
+		args = []interface{}{ruleType, tenantID, limit}
 	} else {
 		query = `
-			SELECT id, rule_id, rule_type, action, old_value, new_value, changed_by, change_reason, ip_address, timestamp
+			SELECT id, tenant_id, rule_id, rule_type, action, old_value, new_value, changed_by, change_reason, ip_address, timestamp
 			FROM rule_audit_logs
+			WHERE tenant_id = $1
 			ORDER BY timestamp DESC
-			LIMIT $1
+			LIMIT $2
 		`
-		args = []interface{}{limit}
+		args = []interface{}{tenantID, limit}
 	}
 
 	rows, err := r.db.QueryContext(ctx, query, args...)
@@ -208,7 +376,7 @@ func (r *postgresVersioningRepository) GetAuditLogs(ctx context.Context, ruleID
 		var ruleIDPtr *string
 
 		if err := rows.Scan(
-			&log.ID, &ruleIDPtr, &log.RuleType, &log.Action,
+			&log.ID, &log.TenantID, &ruleIDPtr, &log.RuleType, &log.Action,
 			&oldValueJSON, &newValueJSON, &log.ChangedBy, &log.ChangeReason, &log.IPAddress, &log.Timestamp,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan audit log: %w", err)
@@ -234,6 +402,44 @@ func (r *postgresVersioningRepository) GetAuditLogs(ctx context.Context, ruleID
 	return logs, nil
 }
 
+func (r *postgresVersioningRepository) GetAuditLog(ctx context.Context, id string) (*AuditLog, error) {
+	query := `
+		SELECT id, tenant_id, rule_id, rule_type, action, old_value, new_value, changed_by, change_reason, ip_address, timestamp
+		FROM rule_audit_logs
+		WHERE id = $1 AND tenant_id = $2
+	`
+
+	var log AuditLog
+	var oldValueJSON, newValueJSON []byte
+	var ruleIDPtr *string
+
+	err := r.db.QueryRowContext(ctx, query, id, TenantIDFromContext(ctx)).Scan(
+		&log.ID, &log.TenantID, &ruleIDPtr, &log.RuleType, &log.Action,
+		&oldValueJSON, &newValueJSON, &log.ChangedBy, &log.ChangeReason, &log.IPAddress, &log.Timestamp,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit log: %w", err)
+	}
+
+	log.RuleID = ruleIDPtr
+
+	if len(oldValueJSON) > 0 {
+		if err := json.Unmarshal(oldValueJSON, &log.OldValue); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal old value: %w", err)
+		}
+	}
+	if len(newValueJSON) > 0 {
+		if err := json.Unmarshal(newValueJSON, &log.NewValue); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal new value: %w", err)
+		}
+	}
+
+	return &log, nil
+}
+
 func ruleToJSON(rule *FilteringRule) (string, error) {
 	data := map[string]interface{}{
 		"id":         rule.ID,
@@ -254,10 +460,10 @@ func ruleToJSON(rule *FilteringRule) (string, error) {
 }
 
 func (r *postgresVersioningRepository) GetNextVersion(ctx context.Context, ruleID string) (int, error) {
-	query := `SELECT COALESCE(MAX(version), 0) + 1 FROM rule_versions WHERE rule_id = $1`
+	query := `SELECT COALESCE(MAX(version), 0) + 1 FROM rule_versions WHERE rule_id = $1 AND tenant_id = $2`
 
 	var version int
-	err := r.db.QueryRowContext(ctx, query, ruleID).Scan(&version)
+	err := r.db.QueryRowContext(ctx, query, ruleID, TenantIDFromContext(ctx)).Scan(&version)
 	if err != nil {
 		return 1, nil // First version
 	}
@@ -265,6 +471,710 @@ func (r *postgresVersioningRepository) GetNextVersion(ctx context.Context, ruleI
 	return version, nil
 }
 
+// UpdateWithVersion implements the VersioningRepository interface method of
+// the same name: it reads the rule row FOR UPDATE so a concurrent writer
+// blocks on the same row instead of racing GetNextVersion, checks
+// expectedVersion against what's actually there, and only then applies
+// mutate and writes the rule, its rule_versions entry, and its audit log
+// together in one transaction.
+func (r *postgresVersioningRepository) UpdateWithVersion(ctx context.Context, ruleID string, expectedVersion int, mutate func(current *FilteringRule) (*FilteringRule, error), meta AuditMeta) (*RuleVersion, error) {
+	tenantID := TenantIDFromContext(ctx)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var current FilteringRule
+	err = tx.QueryRowContext(ctx, `
+		SELECT id, tenant_id, name, expression, priority, enabled, version, created_at, updated_at
+		FROM filtering_rules
+		WHERE id = $1 AND tenant_id = $2
+		FOR UPDATE
+	`, ruleID, tenantID).Scan(
+		&current.ID, &current.TenantID, &current.Name, &current.Expression,
+		&current.Priority, &current.Enabled, &current.Version, &current.CreatedAt, &current.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, pkgerrors.ErrNotFound.WithDetail("rule_id", ruleID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock rule: %w", err)
+	}
+
+	if current.Version != expectedVersion {
+		return nil, pkgerrors.ErrConflict.WithCause(ErrVersionConflict).
+			WithDetail("message", fmt.Sprintf("rule %s is at version %d, expected %d", ruleID, current.Version, expectedVersion)).
+			WithDetail("rule_id", ruleID).
+			WithDetail("current_version", current.Version)
+	}
+
+	updated, err := mutate(&current)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply rule mutation: %w", err)
+	}
+
+	updated.ID = ruleID
+	updated.TenantID = tenantID
+	updated.Version = current.Version + 1
+	updated.UpdatedAt = time.Now()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE filtering_rules
+		SET name = $1, expression = $2, priority = $3, enabled = $4, updated_at = $5, version = $6
+		WHERE id = $7 AND tenant_id = $8
+	`, updated.Name, updated.Expression, updated.Priority, updated.Enabled, updated.UpdatedAt, updated.Version, ruleID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update rule: %w", err)
+	}
+
+	ruleJSON, err := ruleToJSON(updated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal rule: %w", err)
+	}
+
+	version := &RuleVersion{
+		ID:           uuid.New().String(),
+		TenantID:     tenantID,
+		RuleID:       ruleID,
+		RuleType:     "filtering",
+		RuleData:     ruleJSON,
+		Version:      updated.Version,
+		ChangedBy:    meta.ChangedBy,
+		ChangeReason: meta.ChangeReason,
+		CreatedAt:    updated.UpdatedAt,
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO rule_versions (id, tenant_id, rule_id, rule_type, rule_data, version, changed_by, change_reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING revision
+	`, version.ID, version.TenantID, version.RuleID, version.RuleType, version.RuleData,
+		version.Version, version.ChangedBy, version.ChangeReason, version.CreatedAt).Scan(&version.Revision)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rule version: %w", err)
+	}
+
+	oldValueJSON, err := json.Marshal(ruleSnapshot(&current))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal old value: %w", err)
+	}
+	newValueJSON, err := json.Marshal(ruleSnapshot(updated))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal new value: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO rule_audit_logs (id, tenant_id, rule_id, rule_type, action, old_value, new_value, changed_by, change_reason, ip_address, timestamp)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`, uuid.New().String(), tenantID, ruleID, "filtering", "update",
+		oldValueJSON, newValueJSON, meta.ChangedBy, meta.ChangeReason, meta.IPAddress, version.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit rule update: %w", err)
+	}
+
+	return version, nil
+}
+
+// ruleSnapshot renders rule the same shape ruleToJSON does, for the
+// old_value/new_value columns UpdateWithVersion writes to rule_audit_logs.
+func ruleSnapshot(rule *FilteringRule) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         rule.ID,
+		"name":       rule.Name,
+		"expression": rule.Expression,
+		"priority":   rule.Priority,
+		"enabled":    rule.Enabled,
+		"created_at": rule.CreatedAt,
+		"updated_at": rule.UpdatedAt,
+	}
+}
+
+// DiffVersions implements the VersioningRepository interface method of the
+// same name. created_at/updated_at are stripped before diffing for the same
+// reason diffRuleVersions excludes them: they change on every version and
+// would otherwise show up as a spurious "replace" on every single diff.
+func (r *postgresVersioningRepository) DiffVersions(ctx context.Context, ruleID string, fromV, toV int) (*RuleDiff, error) {
+	from, err := r.GetVersion(ctx, ruleID, fromV)
+	if err != nil {
+		return nil, err
+	}
+	to, err := r.GetVersion(ctx, ruleID, toV)
+	if err != nil {
+		return nil, err
+	}
+	if from == nil || to == nil {
+		return nil, nil
+	}
+
+	fromData, err := ruleVersionData(from)
+	if err != nil {
+		return nil, err
+	}
+	toData, err := ruleVersionData(to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RuleDiff{
+		RuleID:      ruleID,
+		FromVersion: fromV,
+		ToVersion:   toV,
+		Patch:       jsonPatchDiff(fromData, toData),
+	}, nil
+}
+
+// RollbackToVersion implements the VersioningRepository interface method of
+// the same name. It locks the rule row the same way UpdateWithVersion does,
+// so a rollback racing a concurrent UpdateWithVersion blocks rather than
+// silently overwriting one or the other, then writes the target's payload
+// as a new version/audit entry instead of touching history in place.
+func (r *postgresVersioningRepository) RollbackToVersion(ctx context.Context, ruleID string, targetVersion int, meta AuditMeta) (*RuleVersion, error) {
+	target, err := r.GetVersion(ctx, ruleID, targetVersion)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		return nil, nil
+	}
+
+	var targetRule FilteringRule
+	if err := json.Unmarshal([]byte(target.RuleData), &targetRule); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal version %d: %w", targetVersion, err)
+	}
+
+	tenantID := TenantIDFromContext(ctx)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var current FilteringRule
+	wasDeleted := false
+	err = tx.QueryRowContext(ctx, `
+		SELECT id, tenant_id, name, expression, priority, enabled, version, created_at, updated_at
+		FROM filtering_rules
+		WHERE id = $1 AND tenant_id = $2
+		FOR UPDATE
+	`, ruleID, tenantID).Scan(
+		&current.ID, &current.TenantID, &current.Name, &current.Expression,
+		&current.Priority, &current.Enabled, &current.Version, &current.CreatedAt, &current.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		// The rule was deleted since targetVersion was written; rollback
+		// recreates it with the same ID rather than failing, the same way
+		// EnrichmentRepository.RollbackEnrichmentRule does for enrichment
+		// rules. Its rule_versions history survives the delete, so the
+		// recreated rule's version continues from the highest one there.
+		wasDeleted = true
+		current = FilteringRule{ID: ruleID, TenantID: tenantID, Version: 0}
+		if err := tx.QueryRowContext(ctx,
+			`SELECT COALESCE(MAX(version), 0) FROM rule_versions WHERE rule_id = $1 AND tenant_id = $2`,
+			ruleID, tenantID,
+		).Scan(&current.Version); err != nil {
+			return nil, fmt.Errorf("failed to read latest version: %w", err)
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to lock rule: %w", err)
+	}
+
+	updated := current
+	updated.Name = targetRule.Name
+	updated.Expression = targetRule.Expression
+	updated.Priority = targetRule.Priority
+	updated.Enabled = targetRule.Enabled
+	updated.Version = current.Version + 1
+	updated.UpdatedAt = time.Now()
+
+	if wasDeleted {
+		updated.CreatedAt = updated.UpdatedAt
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO filtering_rules (id, tenant_id, name, expression, priority, enabled, version, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		`, updated.ID, updated.TenantID, updated.Name, updated.Expression, updated.Priority, updated.Enabled, updated.Version, updated.CreatedAt, updated.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to recreate rule: %w", err)
+		}
+	} else {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE filtering_rules
+			SET name = $1, expression = $2, priority = $3, enabled = $4, updated_at = $5, version = $6
+			WHERE id = $7 AND tenant_id = $8
+		`, updated.Name, updated.Expression, updated.Priority, updated.Enabled, updated.UpdatedAt, updated.Version, ruleID, tenantID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update rule: %w", err)
+		}
+	}
+
+	ruleJSON, err := ruleToJSON(&updated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal rule: %w", err)
+	}
+
+	changeReason := meta.ChangeReason
+	if changeReason == "" {
+		changeReason = fmt.Sprintf("rollback to version %d", targetVersion)
+	}
+
+	version := &RuleVersion{
+		ID:           uuid.New().String(),
+		TenantID:     tenantID,
+		RuleID:       ruleID,
+		RuleType:     "filtering",
+		RuleData:     ruleJSON,
+		Version:      updated.Version,
+		ChangedBy:    meta.ChangedBy,
+		ChangeReason: changeReason,
+		CreatedAt:    updated.UpdatedAt,
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO rule_versions (id, tenant_id, rule_id, rule_type, rule_data, version, changed_by, change_reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING revision
+	`, version.ID, version.TenantID, version.RuleID, version.RuleType, version.RuleData,
+		version.Version, version.ChangedBy, version.ChangeReason, version.CreatedAt).Scan(&version.Revision)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rule version: %w", err)
+	}
+
+	oldValueJSON, err := json.Marshal(ruleSnapshot(&current))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal old value: %w", err)
+	}
+	newValueJSON, err := json.Marshal(ruleSnapshot(&updated))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal new value: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO rule_audit_logs (id, tenant_id, rule_id, rule_type, action, old_value, new_value, changed_by, change_reason, ip_address, timestamp)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`, uuid.New().String(), tenantID, ruleID, "filtering", "rollback",
+		oldValueJSON, newValueJSON, meta.ChangedBy, changeReason, meta.IPAddress, version.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit rollback: %w", err)
+	}
+
+	return version, nil
+}
+
+// GetVersionTimeline implements the VersioningRepository interface method
+// of the same name.
+func (r *postgresVersioningRepository) GetVersionTimeline(ctx context.Context, ruleID string, since time.Time) ([]VersionSummary, error) {
+	versions, err := r.GetVersions(ctx, ruleID)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Version < versions[j].Version })
+
+	prevData := map[string]interface{}{}
+	summaries := make([]VersionSummary, 0, len(versions))
+	for _, v := range versions {
+		data, err := ruleVersionData(&v)
+		if err != nil {
+			return nil, err
+		}
+		opsCount := len(jsonPatchDiff(prevData, data))
+		prevData = data
+
+		if v.CreatedAt.Before(since) {
+			continue
+		}
+		summaries = append(summaries, VersionSummary{
+			Version:      v.Version,
+			ChangedBy:    v.ChangedBy,
+			ChangeReason: v.ChangeReason,
+			CreatedAt:    v.CreatedAt,
+			OpsCount:     opsCount,
+		})
+	}
+
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Version > summaries[j].Version })
+	return summaries, nil
+}
+
+// ruleVersionData unmarshals v.RuleData, stripping the timestamp fields
+// jsonPatchDiff callers don't want treated as content changes.
+func ruleVersionData(v *RuleVersion) (map[string]interface{}, error) {
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(v.RuleData), &data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal version %d: %w", v.Version, err)
+	}
+	delete(data, "created_at")
+	delete(data, "updated_at")
+	return data, nil
+}
+
+// jsonPatchDiff produces the RFC 6902 operations (add/remove/replace; rule
+// payloads have no arrays, so move/copy never apply) that transform from
+// into to, recursing into nested objects and sorting keys for a stable,
+// deterministic patch.
+func jsonPatchDiff(from, to map[string]interface{}) []PatchOp {
+	return diffObjectsAt("", from, to)
+}
+
+func diffObjectsAt(prefix string, from, to map[string]interface{}) []PatchOp {
+	keys := make(map[string]struct{}, len(from)+len(to))
+	for k := range from {
+		keys[k] = struct{}{}
+	}
+	for k := range to {
+		keys[k] = struct{}{}
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var ops []PatchOp
+	for _, k := range sorted {
+		path := prefix + "/" + escapePatchToken(k)
+		fv, fok := from[k]
+		tv, tok := to[k]
+
+		switch {
+		case !fok:
+			ops = append(ops, PatchOp{Op: "add", Path: path, Value: tv})
+		case !tok:
+			ops = append(ops, PatchOp{Op: "remove", Path: path})
+		default:
+			fm, fIsMap := fv.(map[string]interface{})
+			tm, tIsMap := tv.(map[string]interface{})
+			if fIsMap && tIsMap {
+				ops = append(ops, diffObjectsAt(path, fm, tm)...)
+			} else if !reflect.DeepEqual(fv, tv) {
+				ops = append(ops, PatchOp{Op: "replace", Path: path, Value: tv})
+			}
+		}
+	}
+	return ops
+}
+
+// escapePatchToken escapes a map key per RFC 6901 so it's safe to embed in
+// a JSON Pointer path: "~" must come first so it doesn't double-escape the
+// "~1" produced for "/".
+func escapePatchToken(token string) string {
+	token = strings.ReplaceAll(token, "~", "~0")
+	token = strings.ReplaceAll(token, "/", "~1")
+	return token
+}
+
+// RetryOnConflict runs UpdateWithVersion against repo, and on
+// ErrVersionConflict re-reads ruleID's current version via getRule and
+// re-applies mutate, up to maxAttempts total tries with backoff(attempt)
+// between each — the same compare-and-swap retry loop etcd-style
+// key/value stores use to resolve a write against a moving version
+// without the caller having to hand-roll the read-retry loop itself.
+func RetryOnConflict(
+	ctx context.Context,
+	repo VersioningRepository,
+	getRule func(ctx context.Context, ruleID string) (*FilteringRule, error),
+	ruleID string,
+	mutate func(current *FilteringRule) (*FilteringRule, error),
+	meta AuditMeta,
+	maxAttempts int,
+	backoff BackoffFunc,
+) (*RuleVersion, error) {
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if attempt > 0 {
+			if backoff != nil {
+				select {
+				case <-time.After(backoff(attempt)):
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+			}
+		}
+
+		current, err := getRule(ctx, ruleID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read rule %s: %w", ruleID, err)
+		}
+
+		version, err := repo.UpdateWithVersion(ctx, ruleID, current.Version, mutate, meta)
+		if err == nil {
+			return version, nil
+		}
+		if !pkgerrors.IsConflict(err) {
+			return nil, err
+		}
+		lastErr = err
+	}
+
+	return nil, fmt.Errorf("failed to update rule %s after %d attempts: %w", ruleID, maxAttempts, lastErr)
+}
+
+// ApplyFilteringChangeSet implements the VersioningRepository interface
+// method of the same name. It runs the whole batch in one *sql.Tx, in the
+// same BeginTx/defer Rollback/Commit shape UpdateWithVersion and
+// RollbackToVersion use, so a failure partway through (a bad op, a
+// conflicting name, a missing rule_id) rolls every prior op in the same
+// ChangeSet back too instead of leaving it half-applied.
+func (r *postgresVersioningRepository) ApplyFilteringChangeSet(ctx context.Context, ops []FilteringRuleOp, meta AuditMeta) ([]RuleVersion, error) {
+	tenantID := TenantIDFromContext(ctx)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var versions []RuleVersion
+	now := time.Now()
+
+	for i, op := range ops {
+		switch {
+		case op.Create != nil:
+			v, err := r.applyFilteringCreate(ctx, tx, tenantID, *op.Create, meta, now)
+			if err != nil {
+				return nil, fmt.Errorf("changeset op %d (create): %w", i, err)
+			}
+			versions = append(versions, *v)
+
+		case op.Update != nil:
+			v, err := r.applyFilteringUpdate(ctx, tx, tenantID, op.UpdateID, *op.Update, meta, now)
+			if err != nil {
+				return nil, fmt.Errorf("changeset op %d (update %s): %w", i, op.UpdateID, err)
+			}
+			versions = append(versions, *v)
+
+		case op.DeleteID != "":
+			if err := r.applyFilteringDelete(ctx, tx, tenantID, op.DeleteID, meta, now); err != nil {
+				return nil, fmt.Errorf("changeset op %d (delete %s): %w", i, op.DeleteID, err)
+			}
+
+		default:
+			return nil, fmt.Errorf("changeset op %d: neither create, update, nor delete set", i)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit change set: %w", err)
+	}
+
+	return versions, nil
+}
+
+func (r *postgresVersioningRepository) applyFilteringCreate(ctx context.Context, tx *sql.Tx, tenantID string, req CreateFilteringRuleRequest, meta AuditMeta, now time.Time) (*RuleVersion, error) {
+	enabled := getEnabledValue(req.Enabled)
+
+	rule := &FilteringRule{
+		ID:         uuid.New().String(),
+		TenantID:   tenantID,
+		Name:       req.Name,
+		Expression: req.Expression,
+		Priority:   req.Priority,
+		Enabled:    enabled,
+		Mode:       req.Mode,
+		Version:    1,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO filtering_rules (id, tenant_id, name, expression, priority, enabled, mode, version, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, rule.ID, rule.TenantID, rule.Name, rule.Expression, rule.Priority, rule.Enabled, rule.Mode, rule.Version, rule.CreatedAt, rule.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rule: %w", err)
+	}
+
+	return r.writeFilteringVersionAndAudit(ctx, tx, tenantID, rule, "create", nil, meta, now)
+}
+
+func (r *postgresVersioningRepository) applyFilteringUpdate(ctx context.Context, tx *sql.Tx, tenantID, ruleID string, req UpdateFilteringRuleRequest, meta AuditMeta, now time.Time) (*RuleVersion, error) {
+	var current FilteringRule
+	err := tx.QueryRowContext(ctx, `
+		SELECT id, tenant_id, name, expression, priority, enabled, mode, version, created_at, updated_at
+		FROM filtering_rules
+		WHERE id = $1 AND tenant_id = $2
+		FOR UPDATE
+	`, ruleID, tenantID).Scan(
+		&current.ID, &current.TenantID, &current.Name, &current.Expression,
+		&current.Priority, &current.Enabled, &current.Mode, &current.Version, &current.CreatedAt, &current.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, pkgerrors.ErrNotFound.WithDetail("rule_id", ruleID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock rule: %w", err)
+	}
+
+	if req.Version != nil && current.Version != *req.Version {
+		return nil, pkgerrors.ErrConflict.WithCause(ErrVersionConflict).
+			WithDetail("message", fmt.Sprintf("rule %s is at version %d, expected %d", ruleID, current.Version, *req.Version)).
+			WithDetail("rule_id", ruleID).
+			WithDetail("current_version", current.Version)
+	}
+
+	updated := current
+	if req.Name != nil {
+		updated.Name = *req.Name
+	}
+	if req.Expression != nil {
+		updated.Expression = *req.Expression
+	}
+	if req.Priority != nil {
+		updated.Priority = *req.Priority
+	}
+	if req.Enabled != nil {
+		updated.Enabled = *req.Enabled
+	}
+	if req.Mode != nil {
+		updated.Mode = *req.Mode
+	}
+	updated.Version = current.Version + 1
+	updated.UpdatedAt = now
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE filtering_rules
+		SET name = $1, expression = $2, priority = $3, enabled = $4, mode = $5, updated_at = $6, version = $7
+		WHERE id = $8 AND tenant_id = $9
+	`, updated.Name, updated.Expression, updated.Priority, updated.Enabled, updated.Mode, updated.UpdatedAt, updated.Version, ruleID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update rule: %w", err)
+	}
+
+	oldValue, err := json.Marshal(ruleSnapshot(&current))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal old value: %w", err)
+	}
+	var oldValueMap map[string]interface{}
+	if err := json.Unmarshal(oldValue, &oldValueMap); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal old value: %w", err)
+	}
+
+	return r.writeFilteringVersionAndAudit(ctx, tx, tenantID, &updated, "update", oldValueMap, meta, now)
+}
+
+func (r *postgresVersioningRepository) applyFilteringDelete(ctx context.Context, tx *sql.Tx, tenantID, ruleID string, meta AuditMeta, now time.Time) error {
+	var current FilteringRule
+	err := tx.QueryRowContext(ctx, `
+		SELECT id, tenant_id, name, expression, priority, enabled, mode, version, created_at, updated_at
+		FROM filtering_rules
+		WHERE id = $1 AND tenant_id = $2
+		FOR UPDATE
+	`, ruleID, tenantID).Scan(
+		&current.ID, &current.TenantID, &current.Name, &current.Expression,
+		&current.Priority, &current.Enabled, &current.Mode, &current.Version, &current.CreatedAt, &current.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return pkgerrors.ErrNotFound.WithDetail("rule_id", ruleID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock rule: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM filtering_rules WHERE id = $1 AND tenant_id = $2`, ruleID, tenantID); err != nil {
+		return fmt.Errorf("failed to delete rule: %w", err)
+	}
+
+	oldValue, err := json.Marshal(ruleSnapshot(&current))
+	if err != nil {
+		return fmt.Errorf("failed to marshal old value: %w", err)
+	}
+	var oldValueMap map[string]interface{}
+	if err := json.Unmarshal(oldValue, &oldValueMap); err != nil {
+		return fmt.Errorf("failed to unmarshal old value: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO rule_audit_logs (id, tenant_id, rule_id, rule_type, action, old_value, new_value, changed_by, change_reason, ip_address, timestamp)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`, uuid.New().String(), tenantID, ruleID, "filtering", "delete",
+		oldValue, nil, meta.ChangedBy, meta.ChangeReason, meta.IPAddress, now)
+	if err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	return nil
+}
+
+// writeFilteringVersionAndAudit writes rule's rule_versions row (capturing
+// its DB-assigned revision) and a matching rule_audit_logs row, the shared
+// tail end of applyFilteringCreate and applyFilteringUpdate.
+func (r *postgresVersioningRepository) writeFilteringVersionAndAudit(ctx context.Context, tx *sql.Tx, tenantID string, rule *FilteringRule, action string, oldValue map[string]interface{}, meta AuditMeta, now time.Time) (*RuleVersion, error) {
+	ruleJSON, err := ruleToJSON(rule)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal rule: %w", err)
+	}
+
+	version := &RuleVersion{
+		ID:           uuid.New().String(),
+		TenantID:     tenantID,
+		RuleID:       rule.ID,
+		RuleType:     "filtering",
+		RuleData:     ruleJSON,
+		Version:      rule.Version,
+		ChangedBy:    meta.ChangedBy,
+		ChangeReason: meta.ChangeReason,
+		CreatedAt:    now,
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO rule_versions (id, tenant_id, rule_id, rule_type, rule_data, version, changed_by, change_reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING revision
+	`, version.ID, version.TenantID, version.RuleID, version.RuleType, version.RuleData,
+		version.Version, version.ChangedBy, version.ChangeReason, version.CreatedAt).Scan(&version.Revision)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rule version: %w", err)
+	}
+
+	newValue, err := json.Marshal(ruleSnapshot(rule))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal new value: %w", err)
+	}
+	var newValueMap map[string]interface{}
+	if err := json.Unmarshal(newValue, &newValueMap); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal new value: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO rule_audit_logs (id, tenant_id, rule_id, rule_type, action, old_value, new_value, changed_by, change_reason, ip_address, timestamp)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`, uuid.New().String(), tenantID, rule.ID, "filtering", action,
+		mapToJSONOrNil(oldValue), newValue, meta.ChangedBy, meta.ChangeReason, meta.IPAddress, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	return version, nil
+}
+
+// mapToJSONOrNil marshals m, or returns nil for a nil m so the
+// rule_audit_logs.old_value column stores SQL NULL for a create op
+// instead of the literal string "null".
+func mapToJSONOrNil(m map[string]interface{}) []byte {
+	if m == nil {
+		return nil
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
 func NewVersioningRepository(db *sql.DB) VersioningRepository {
 	return &postgresVersioningRepository{db: db}
 }
@@ -0,0 +1,113 @@
+package testutil
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"yeti/internal/config"
+	"yeti/internal/constants"
+	"yeti/internal/filtering"
+	"yeti/internal/logger"
+	"yeti/internal/management"
+	"yeti/pkg/models"
+)
+
+// FilteringHarness bundles everything a filtering.Service integration test
+// needs - the postgres-backed infra, a logger, the management and
+// filtering repositories, and a ready-to-use Service - behind AddRule/Send
+// so a new test case only has to state its rule and message, not rebuild
+// this wiring every time.
+type FilteringHarness struct {
+	T             *testing.T
+	Infra         *TestInfra
+	Logger        logger.Logger
+	MgmtRepo      management.Repository
+	FilteringRepo filtering.Repository
+	Service       *filtering.Service
+}
+
+// DefaultFilteringConfig is the config.FilteringConfig NewFilteringHarness
+// builds its Service from: "allow" on a CEL error, and a periodic reload
+// interval long enough that it never fires during a test (rules are
+// applied via AddRule's own explicit ReloadRules call instead).
+func DefaultFilteringConfig() config.FilteringConfig {
+	return config.FilteringConfig{
+		Fallback: config.FallbackConfig{OnError: constants.FallbackAllow},
+		Reload:   config.ReloadConfig{IntervalSeconds: 60},
+	}
+}
+
+// NewFilteringHarness builds a FilteringHarness against a fresh postgres
+// container, using DefaultFilteringConfig. opts are passed straight through
+// to filtering.NewService, e.g. filtering.WithAuditSink(fakeSink) for a
+// test asserting on emitted audit.Records.
+func NewFilteringHarness(t *testing.T, opts ...filtering.ServiceOption) *FilteringHarness {
+	t.Helper()
+	return NewFilteringHarnessWithConfig(t, DefaultFilteringConfig(), opts...)
+}
+
+// NewFilteringHarnessWithConfig is NewFilteringHarness for a test that
+// needs to override part of config.FilteringConfig (e.g. Fallback.OnError,
+// or CEL cost/timeout budgets) rather than accepting the default.
+func NewFilteringHarnessWithConfig(t *testing.T, cfg config.FilteringConfig, opts ...filtering.ServiceOption) *FilteringHarness {
+	t.Helper()
+
+	infra := SetupTestInfra(t)
+	log := logger.NopLogger()
+	mgmtRepo := management.NewRepository(infra.PostgresDB)
+	filteringRepo := filtering.NewRepository(infra.PostgresDB)
+
+	svc, err := filtering.NewService(filteringRepo, cfg, log, opts...)
+	require.NoError(t, err)
+
+	return &FilteringHarness{
+		T:             t,
+		Infra:         infra,
+		Logger:        log,
+		MgmtRepo:      mgmtRepo,
+		FilteringRepo: filteringRepo,
+		Service:       svc,
+	}
+}
+
+// AddRule creates an enabled rule with the given CEL expression/priority,
+// reloads the Service's in-memory rule set so it takes effect immediately,
+// and returns the persisted rule. Callers needing a disabled rule, a
+// non-default Mode, or a cost/timeout override should create and persist a
+// *management.FilteringRule via h.MgmtRepo directly, then call
+// h.Service.ReloadRules themselves.
+func (h *FilteringHarness) AddRule(name, expression string, priority int) *management.FilteringRule {
+	h.T.Helper()
+
+	rule := &management.FilteringRule{
+		Name:       name,
+		Expression: expression,
+		Priority:   priority,
+		Enabled:    true,
+	}
+	require.NoError(h.T, h.MgmtRepo.CreateFilteringRule(context.Background(), rule))
+	require.NoError(h.T, h.Service.ReloadRules(context.Background()))
+	return rule
+}
+
+// Send builds a models.MessageEnvelope from id/source/payload and runs it
+// through h.Service.Filter with context.Background(). Use SendCtx directly
+// for a test exercising context cancellation/timeout behavior.
+func (h *FilteringHarness) Send(id, source string, payload map[string]interface{}) (bool, []string, []filtering.ShadowVerdict, error) {
+	h.T.Helper()
+	return h.SendCtx(context.Background(), id, source, payload)
+}
+
+// SendCtx is Send with an explicit context.
+func (h *FilteringHarness) SendCtx(ctx context.Context, id, source string, payload map[string]interface{}) (bool, []string, []filtering.ShadowVerdict, error) {
+	h.T.Helper()
+	msg := models.MessageEnvelope{
+		ID:       id,
+		Source:   source,
+		Payload:  payload,
+		Metadata: models.Metadata{},
+	}
+	return h.Service.Filter(ctx, msg)
+}
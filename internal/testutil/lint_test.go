@@ -0,0 +1,80 @@
+package testutil_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"testing"
+)
+
+// TestIntegrationTestsUseTestutilTimeouts is a vet-style guard against
+// tests/integration reintroducing the raw time.Sleep/ad-hoc
+// context.WithTimeout(ctx, 1*time.Nanosecond) literals that testutil's
+// Wait*/Interval*/AlreadyExpiredContext exist to replace. It only flags
+// calls whose argument is itself a literal or arithmetic over literals
+// (e.g. 10*time.Millisecond) - a testutil.WaitShort or a variable built
+// from one is an identifier/selector, not a literal, so it passes.
+func TestIntegrationTestsUseTestutilTimeouts(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("..", "..", "tests", "integration", "*.go"))
+	if err != nil {
+		t.Fatalf("failed to glob tests/integration: %v", err)
+	}
+
+	fset := token.NewFileSet()
+	for _, file := range files {
+		f, err := parser.ParseFile(fset, file, nil, 0)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", file, err)
+		}
+
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			name := callName(call.Fun)
+			switch name {
+			case "time.Sleep":
+				if len(call.Args) == 1 && isLiteralDuration(call.Args[0]) {
+					t.Errorf("%s: time.Sleep with a literal duration; use a testutil.Wait*/Interval* constant instead",
+						fset.Position(call.Pos()))
+				}
+			case "context.WithTimeout", "context.WithDeadline":
+				if len(call.Args) == 2 && isLiteralDuration(call.Args[1]) {
+					t.Errorf("%s: %s with a literal duration; use testutil.AlreadyExpiredContext or a testutil.Wait* constant instead",
+						fset.Position(call.Pos()), name)
+				}
+			}
+			return true
+		})
+	}
+}
+
+func callName(expr ast.Expr) string {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return ""
+	}
+	return pkg.Name + "." + sel.Sel.Name
+}
+
+// isLiteralDuration reports whether expr is a basic literal (1*time.Nanosecond)
+// or a binary expression built from one (10*time.Millisecond), as opposed to
+// an identifier or selector referencing a named constant/variable.
+func isLiteralDuration(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		return true
+	case *ast.BinaryExpr:
+		return isLiteralDuration(e.X) || isLiteralDuration(e.Y)
+	case *ast.ParenExpr:
+		return isLiteralDuration(e.X)
+	default:
+		return false
+	}
+}
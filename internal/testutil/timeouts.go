@@ -0,0 +1,55 @@
+// Package testutil collects integration-test building blocks - standardized
+// timeouts and container-backed infra fixtures - so a test in
+// tests/integration doesn't hand-roll its own sleep/timeout literals or
+// Postgres/Mongo/Redis wiring. See FilteringHarness for the filtering
+// service's fixture.
+package testutil
+
+import (
+	"context"
+	"runtime"
+	"time"
+)
+
+// WaitShort, WaitMedium, and WaitLong are standardized durations for a test
+// that genuinely needs to wait on async behavior (a background reloader
+// picking up a change, a goroutine finishing) instead of picking a
+// one-off duration per call site. They're longer on Windows, whose CI
+// runners observably schedule goroutines and I/O more slowly under load
+// than the Linux runners this suite was originally tuned against.
+var (
+	WaitShort  = tunedForOS(50*time.Millisecond, 200*time.Millisecond)
+	WaitMedium = tunedForOS(500*time.Millisecond, 2*time.Second)
+	WaitLong   = tunedForOS(2*time.Second, 8*time.Second)
+)
+
+const (
+	// IntervalFast spaces out operations that must land in strictly
+	// increasing order (e.g. consecutive CreatedAt timestamps) rather than
+	// waiting on an async result, so - unlike Wait* above - it doesn't need
+	// to scale with a slower CI runner's scheduler, only with whatever
+	// timestamp resolution the backing store guarantees.
+	IntervalFast = 10 * time.Millisecond
+	// IntervalMedium is IntervalFast's slower sibling for operations (e.g.
+	// Redis TTL expiry) that need a somewhat larger gap to observe
+	// reliably.
+	IntervalMedium = 100 * time.Millisecond
+)
+
+func tunedForOS(standard, windows time.Duration) time.Duration {
+	if runtime.GOOS == "windows" {
+		return windows
+	}
+	return standard
+}
+
+// AlreadyExpiredContext returns a context whose deadline has already
+// passed, for a test asserting timeout-handling behavior without waiting
+// out a realistic deadline. It replaces the
+// context.WithTimeout(ctx, 1*time.Nanosecond) plus a follow-up time.Sleep
+// this suite used to hand-roll at every such call site.
+func AlreadyExpiredContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	return ctx, cancel
+}
@@ -0,0 +1,124 @@
+// Package bloom implements a minimal, dependency-free Bloom filter:
+// a fixed-size bit array tested/set through two independent hashes combined
+// via double hashing (Kirsch-Mitzenmacher), which approximates k independent
+// hash functions without computing k separate digests per operation.
+package bloom
+
+import (
+	"hash/fnv"
+	"math"
+)
+
+// Filter is a fixed-capacity Bloom filter. A negative Test result is always
+// correct (the item was never Added); a positive result may be a false
+// positive at approximately the rate the Filter was sized for.
+type Filter struct {
+	bits []uint64
+	m    uint64 // number of bits
+	k    uint64 // number of hash rounds per Add/Test
+}
+
+// New sizes a Filter for expectedItems entries at targetFalsePositiveRate,
+// computing the optimal bit-array size m and hash-round count k:
+//
+//	m = ceil(-n * ln(p) / ln(2)^2)
+//	k = round(m/n * ln(2))
+func New(expectedItems uint64, targetFalsePositiveRate float64) *Filter {
+	if expectedItems == 0 {
+		expectedItems = 1
+	}
+	if targetFalsePositiveRate <= 0 || targetFalsePositiveRate >= 1 {
+		targetFalsePositiveRate = 0.01
+	}
+
+	n := float64(expectedItems)
+	p := targetFalsePositiveRate
+
+	m := uint64(math.Ceil(-n * math.Log(p) / (math.Ln2 * math.Ln2)))
+	if m == 0 {
+		m = 1
+	}
+	k := uint64(math.Round(float64(m) / n * math.Ln2))
+	if k == 0 {
+		k = 1
+	}
+
+	return &Filter{
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+	}
+}
+
+// hashes returns the two independent hashes double hashing derives every
+// other probe position from: h1 is FNV-1a of data, h2 is FNV-1 of data
+// (salted so it doesn't collapse to the same value as h1).
+func hashes(data []byte) (uint64, uint64) {
+	h1 := fnv.New64a()
+	h1.Write(data)
+	sum1 := h1.Sum64()
+
+	h2 := fnv.New64()
+	h2.Write(data)
+	h2.Write([]byte{0xff})
+	sum2 := h2.Sum64()
+
+	return sum1, sum2
+}
+
+func (f *Filter) bitIndex(h1, h2 uint64, i uint64) uint64 {
+	return (h1 + i*h2) % f.m
+}
+
+// Add records data as present.
+func (f *Filter) Add(data []byte) {
+	h1, h2 := hashes(data)
+	for i := uint64(0); i < f.k; i++ {
+		idx := f.bitIndex(h1, h2, i)
+		f.bits[idx/64] |= 1 << (idx % 64)
+	}
+}
+
+// Test reports whether data is possibly present (true) or definitely absent
+// (false).
+func (f *Filter) Test(data []byte) bool {
+	h1, h2 := hashes(data)
+	for i := uint64(0); i < f.k; i++ {
+		idx := f.bitIndex(h1, h2, i)
+		if f.bits[idx/64]&(1<<(idx%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// M returns the filter's bit-array size.
+func (f *Filter) M() uint64 { return f.m }
+
+// K returns the filter's hash-round count.
+func (f *Filter) K() uint64 { return f.k }
+
+// Size computes the same m (bit-array size) and k (hash-round count) New
+// sizes a Filter with, for callers that need to store the bit array
+// somewhere other than Filter's own in-process []uint64 — e.g. as
+// individual bits in a Redis key shared across replicas.
+func Size(expectedItems uint64, targetFalsePositiveRate float64) (m, k uint64) {
+	f := New(expectedItems, targetFalsePositiveRate)
+	return f.m, f.k
+}
+
+// Positions returns the k bit offsets (each < m) data hashes to under the
+// same double-hashing scheme Filter.Add/Test use, for callers maintaining
+// an external bit array (e.g. via Redis SETBIT/GETBIT) instead of Filter's
+// in-process one.
+func Positions(data []byte, m, k uint64) []uint64 {
+	if m == 0 {
+		m = 1
+	}
+	h1, h2 := hashes(data)
+	positions := make([]uint64, k)
+	for i := uint64(0); i < k; i++ {
+		positions[i] = (h1 + i*h2) % m
+	}
+	return positions
+}
@@ -0,0 +1,45 @@
+package bloom
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterNeverFalseNegative(t *testing.T) {
+	f := New(1000, 0.01)
+
+	for i := 0; i < 1000; i++ {
+		f.Add([]byte(fmt.Sprintf("key-%d", i)))
+	}
+
+	for i := 0; i < 1000; i++ {
+		assert.True(t, f.Test([]byte(fmt.Sprintf("key-%d", i))), "added key reported absent")
+	}
+}
+
+func TestFilterFalsePositiveRateStaysClose(t *testing.T) {
+	const n = 10000
+	const target = 0.01
+
+	f := New(n, target)
+	for i := 0; i < n; i++ {
+		f.Add([]byte(fmt.Sprintf("present-%d", i)))
+	}
+
+	falsePositives := 0
+	for i := 0; i < n; i++ {
+		if f.Test([]byte(fmt.Sprintf("absent-%d", i))) {
+			falsePositives++
+		}
+	}
+
+	rate := float64(falsePositives) / float64(n)
+	assert.Less(t, rate, target*5, "false positive rate %.4f far exceeds target %.4f", rate, target)
+}
+
+func TestFilterEmptyReportsAbsent(t *testing.T) {
+	f := New(100, 0.01)
+	assert.False(t, f.Test([]byte("anything")))
+}
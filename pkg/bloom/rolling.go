@@ -0,0 +1,92 @@
+package bloom
+
+import (
+	"sync"
+	"time"
+)
+
+// RollingParams sizes a RollingFilter's underlying Filter (see New) and
+// controls how often it rotates.
+type RollingParams struct {
+	// ExpectedItems and FalsePositiveRate size each of the two underlying
+	// Filters exactly as New does.
+	ExpectedItems     uint64
+	FalsePositiveRate float64
+	// RotationInterval is how long a Filter serves as the "current" window
+	// before RollingFilter starts a fresh one and retires the old one to
+	// "previous". Two windows of RotationInterval each bound how stale a
+	// membership result can be at exactly 2*RotationInterval, so a caller
+	// sizing RotationInterval to ttl/2 gets a false-negative window bounded
+	// by ttl, matching ttl-based expiry semantics elsewhere in this
+	// package's callers (e.g. a Redis key with the same ttl).
+	RotationInterval time.Duration
+}
+
+// RollingFilter is a Bloom filter sized for a bounded membership window
+// rather than a fixed item count: it keeps two Filters ("current" and
+// "previous") and rotates every RotationInterval, discarding "previous" and
+// demoting "current" into its place. Test checks both windows (so an item
+// added anywhere in the last up-to-2*RotationInterval is still found);
+// Add only ever writes to "current". This keeps memory bounded under
+// sustained traffic instead of a single Filter's accuracy degrading forever
+// as more items than it was sized for get added.
+type RollingFilter struct {
+	mu       sync.RWMutex
+	current  *Filter
+	previous *Filter
+	params   RollingParams
+	rotateAt time.Time
+}
+
+// NewRolling returns a RollingFilter with both windows freshly sized per
+// params, rotating for the first time after params.RotationInterval.
+func NewRolling(params RollingParams) *RollingFilter {
+	if params.RotationInterval <= 0 {
+		params.RotationInterval = time.Hour
+	}
+	return &RollingFilter{
+		current:  New(params.ExpectedItems, params.FalsePositiveRate),
+		previous: New(params.ExpectedItems, params.FalsePositiveRate),
+		params:   params,
+		rotateAt: time.Now().Add(params.RotationInterval),
+	}
+}
+
+func (r *RollingFilter) maybeRotate() {
+	r.mu.RLock()
+	due := time.Now().After(r.rotateAt)
+	r.mu.RUnlock()
+	if !due {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !time.Now().After(r.rotateAt) {
+		return // another goroutine already rotated
+	}
+	r.previous = r.current
+	r.current = New(r.params.ExpectedItems, r.params.FalsePositiveRate)
+	r.rotateAt = time.Now().Add(r.params.RotationInterval)
+}
+
+// Test reports whether key is possibly present in either window (true) or
+// definitely absent from both (false).
+func (r *RollingFilter) Test(key string) bool {
+	r.maybeRotate()
+
+	data := []byte(key)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.current.Test(data) || r.previous.Test(data)
+}
+
+// Add records key as present in the current window.
+func (r *RollingFilter) Add(key string) {
+	r.maybeRotate()
+
+	data := []byte(key)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	r.current.Add(data)
+}
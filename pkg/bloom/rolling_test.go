@@ -0,0 +1,45 @@
+package bloom
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRollingFilterFindsRecentlyAdded(t *testing.T) {
+	r := NewRolling(RollingParams{ExpectedItems: 1000, FalsePositiveRate: 0.01, RotationInterval: time.Hour})
+
+	for i := 0; i < 100; i++ {
+		r.Add(fmt.Sprintf("key-%d", i))
+	}
+
+	for i := 0; i < 100; i++ {
+		assert.True(t, r.Test(fmt.Sprintf("key-%d", i)))
+	}
+	assert.False(t, r.Test("never-added"))
+}
+
+func TestRollingFilterStillFindsKeyAfterOneRotation(t *testing.T) {
+	r := NewRolling(RollingParams{ExpectedItems: 1000, FalsePositiveRate: 0.01, RotationInterval: time.Millisecond})
+
+	r.Add("key")
+	time.Sleep(2 * time.Millisecond)
+
+	// One rotation has elapsed: "key" moved from current into previous, and
+	// a new, empty current took its place. It must still test present.
+	assert.True(t, r.Test("key"))
+}
+
+func TestRollingFilterForgetsKeyAfterTwoRotations(t *testing.T) {
+	r := NewRolling(RollingParams{ExpectedItems: 1000, FalsePositiveRate: 0.01, RotationInterval: time.Millisecond})
+
+	r.Add("key")
+	time.Sleep(2 * time.Millisecond)
+	r.Test("key") // force a rotation check
+	time.Sleep(2 * time.Millisecond)
+	r.Test("unrelated") // force the second rotation
+
+	assert.False(t, r.Test("key"))
+}
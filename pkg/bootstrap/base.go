@@ -10,10 +10,11 @@ import (
 )
 
 type Base struct {
-	Config   *config.Config
-	Logger   logger.Logger
-	Producer broker.Producer
-	Consumer broker.Consumer
+	Config        *config.Config
+	Logger        logger.Logger
+	Producer      broker.Producer
+	Consumer      broker.Consumer
+	ConfigWatcher *config.Watcher
 }
 
 func NewBase(cfg *config.Config, log logger.Logger) *Base {
@@ -23,6 +24,31 @@ func NewBase(cfg *config.Config, log logger.Logger) *Base {
 	}
 }
 
+// InitConfigWatcher starts hot-reloading the config file the running process
+// was started with (SIGHUP or an on-disk change), rebinding the log level
+// when it changes and leaving the running config untouched if a static
+// section (Postgres DSN, Kafka brokers, ...) changed. Additional subsystems
+// can subscribe to their own section via the returned Watcher.
+func (b *Base) InitConfigWatcher(ctx context.Context) *config.Watcher {
+	w := config.NewWatcher(config.CurrentConfigFile(), b.Config, b.Logger)
+
+	w.Subscribe("logging", func(change config.ConfigChange) {
+		newLogging, ok := change.New.(config.LoggingConfig)
+		if !ok {
+			return
+		}
+		if err := b.Logger.SetLevel(newLogging.Level); err != nil {
+			b.Logger.Warnw("failed to apply hot-reloaded log level", "level", newLogging.Level, "error", err)
+			return
+		}
+		b.Logger.Infow("log level updated via config reload", "level", newLogging.Level)
+	})
+
+	w.Start(ctx)
+	b.ConfigWatcher = w
+	return w
+}
+
 func (b *Base) InitBroker(serviceName string) error {
 	producer, err := broker.NewProducer(b.Config.Broker, b.Logger)
 	if err != nil {
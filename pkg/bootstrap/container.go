@@ -0,0 +1,77 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+)
+
+// Hook is a lifecycle callback pair a Module registers against the Container
+// it's installed into, mirroring go.uber.org/fx's fx.Hook: OnStart runs in
+// registration order during Container.Start, OnStop runs in reverse order
+// during Container.Stop, so a subsystem that depends on another started
+// earlier is always torn down before its dependency is. Either field may be
+// nil if the subsystem has nothing to do at that point.
+type Hook struct {
+	OnStart func(ctx context.Context) error
+	OnStop  func(ctx context.Context) error
+}
+
+// Container is a slim in-repo stand-in for an fx.App. It doesn't do
+// reflection-based dependency injection the way go.uber.org/fx does, but it
+// gives every subsystem Module the same OnStart/OnStop lifecycle
+// registration and automatic reverse-order shutdown unwinding, so an
+// App.Initialize stops being a hand-rolled sequence of initRedis/
+// initMongoDB/... calls with implicit ordering and ad-hoc nil-checks
+// scattered across initService/initHTTPServer.
+type Container struct {
+	hooks []Hook
+}
+
+// NewContainer returns an empty Container ready for Modules to Append their
+// lifecycle hooks into.
+func NewContainer() *Container {
+	return &Container{}
+}
+
+// Append registers hook to run after every hook already appended on Start,
+// and before it on Stop — the last subsystem started is the first stopped.
+func (c *Container) Append(hook Hook) {
+	c.hooks = append(c.hooks, hook)
+}
+
+// Start runs every registered hook's OnStart in registration order. If one
+// fails, every hook that already started is unwound in reverse order before
+// Start returns the error, so a partially-initialized Container never leaks
+// the subsystems it did manage to bring up.
+func (c *Container) Start(ctx context.Context) error {
+	for i, h := range c.hooks {
+		if h.OnStart == nil {
+			continue
+		}
+		if err := h.OnStart(ctx); err != nil {
+			c.stopFrom(ctx, i-1)
+			return fmt.Errorf("startup failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// Stop runs every registered hook's OnStop in reverse registration order,
+// collecting every error instead of stopping at the first so one
+// subsystem's shutdown failure doesn't leak the rest.
+func (c *Container) Stop(ctx context.Context) []error {
+	return c.stopFrom(ctx, len(c.hooks)-1)
+}
+
+func (c *Container) stopFrom(ctx context.Context, from int) []error {
+	var errs []error
+	for i := from; i >= 0; i-- {
+		if c.hooks[i].OnStop == nil {
+			continue
+		}
+		if err := c.hooks[i].OnStop(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
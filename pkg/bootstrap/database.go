@@ -68,6 +68,25 @@ func (dc *DatabaseConnector) InitPostgreSQL(ctx context.Context) (*sql.DB, error
 	return db, nil
 }
 
+func (dc *DatabaseConnector) InitSQLite(ctx context.Context) (*sql.DB, error) {
+	if dc.Config.Database.SQLite.Path == "" {
+		return nil, nil // SQLite is optional
+	}
+
+	db, err := sql.Open("sqlite3", dc.Config.Database.SQLite.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database: %w", err)
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping sqlite database: %w", err)
+	}
+
+	dc.Logger.Info("SQLite connected successfully")
+	return db, nil
+}
+
 func (dc *DatabaseConnector) InitMongoDB(ctx context.Context) (*mongo.Client, error) {
 	if dc.Config.Database.MongoDB.URI == "" {
 		return nil, nil // MongoDB is optional
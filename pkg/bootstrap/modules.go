@@ -0,0 +1,221 @@
+package bootstrap
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"yeti/internal/broker"
+	"yeti/internal/config"
+	"yeti/internal/logger"
+	otelmetrics "yeti/pkg/metrics/otel"
+	"yeti/pkg/tracing"
+)
+
+// RedisModule holds the *redis.Client a Container provides once started.
+// The pointer is nil until Container.Start runs the hook NewRedisModule
+// registers.
+type RedisModule struct {
+	Client *redis.Client
+}
+
+// NewRedisModule registers dc's Redis connect/close as a Container hook and
+// returns the holder Client is populated into once the Container starts.
+// Redis is required: a connect failure here fails Container.Start.
+func NewRedisModule(c *Container, dc *DatabaseConnector) *RedisModule {
+	m := &RedisModule{}
+	c.Append(Hook{
+		OnStart: func(ctx context.Context) error {
+			client, err := dc.InitRedis(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to initialize Redis: %w", err)
+			}
+			m.Client = client
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			if m.Client == nil {
+				return nil
+			}
+			return m.Client.Close()
+		},
+	})
+	return m
+}
+
+// MongoModule holds the *mongo.Client a Container provides once started.
+type MongoModule struct {
+	Client *mongo.Client
+}
+
+// NewMongoModule registers dc's MongoDB connect/disconnect as a Container
+// hook. MongoDB is required: a connect failure here fails Container.Start.
+func NewMongoModule(c *Container, dc *DatabaseConnector) *MongoModule {
+	m := &MongoModule{}
+	c.Append(Hook{
+		OnStart: func(ctx context.Context) error {
+			client, err := dc.InitMongoDB(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to initialize MongoDB: %w", err)
+			}
+			m.Client = client
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			if m.Client == nil {
+				return nil
+			}
+			return m.Client.Disconnect(ctx)
+		},
+	})
+	return m
+}
+
+// PostgresModule holds the *sql.DB a Container provides once started, or
+// nil if Postgres isn't configured or failed to connect.
+type PostgresModule struct {
+	DB *sql.DB
+}
+
+// NewPostgresModule registers dc's PostgreSQL connect/close as a Container
+// hook. Postgres is optional: unlike RedisModule/MongoModule, a connect
+// failure here is logged and swallowed rather than returned, so
+// Container.Start still succeeds with DB left nil and the PostgreSQL
+// provider disabled — this is the declarative form of the
+// "log-warning-and-continue" pattern Initialize used to hand-roll.
+func NewPostgresModule(c *Container, dc *DatabaseConnector, log logger.Logger) *PostgresModule {
+	m := &PostgresModule{}
+	c.Append(Hook{
+		OnStart: func(ctx context.Context) error {
+			db, err := dc.InitPostgreSQL(ctx)
+			if err != nil {
+				log.Warnw("PostgreSQL initialization failed, PostgreSQL provider will be disabled", "error", err)
+				return nil
+			}
+			m.DB = db
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			if m.DB == nil {
+				return nil
+			}
+			return m.DB.Close()
+		},
+	})
+	return m
+}
+
+// BrokerModule holds the broker.Producer/broker.Consumer pair a Container
+// provides once started.
+type BrokerModule struct {
+	Producer broker.Producer
+	Consumer broker.Consumer
+}
+
+// NewBrokerModule registers broker.NewProducer/broker.NewConsumer and their
+// Close() pair as a Container hook, replacing Base.InitBroker/ShutdownBroker
+// for callers that assemble via Container.
+func NewBrokerModule(c *Container, cfg config.BrokerConfig, log logger.Logger, serviceName string) *BrokerModule {
+	m := &BrokerModule{}
+	c.Append(Hook{
+		OnStart: func(ctx context.Context) error {
+			producer, err := broker.NewProducer(cfg, log)
+			if err != nil {
+				return fmt.Errorf("failed to create producer: %w", err)
+			}
+
+			consumer, err := broker.NewConsumer(cfg, log)
+			if err != nil {
+				producer.Close()
+				return fmt.Errorf("failed to create consumer: %w", err)
+			}
+
+			if serviceName != "" {
+				consumer.SetServiceName(serviceName)
+			}
+
+			m.Producer = producer
+			m.Consumer = consumer
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			var errs []error
+			if m.Producer != nil {
+				if err := m.Producer.Close(); err != nil {
+					errs = append(errs, fmt.Errorf("producer close error: %w", err))
+				}
+			}
+			if m.Consumer != nil {
+				if err := m.Consumer.Close(); err != nil {
+					errs = append(errs, fmt.Errorf("consumer close error: %w", err))
+				}
+			}
+			if len(errs) > 0 {
+				return fmt.Errorf("broker shutdown errors: %v", errs)
+			}
+			return nil
+		},
+	})
+	return m
+}
+
+// TracingModule holds the *tracing.TracerProvider a Container provides once
+// started.
+type TracingModule struct {
+	Provider *tracing.TracerProvider
+}
+
+// NewTracingModule registers tracing.Init/TracerProvider.Shutdown as a
+// Container hook.
+func NewTracingModule(c *Container, cfg config.TracingConfig, serviceName string) *TracingModule {
+	m := &TracingModule{}
+	c.Append(Hook{
+		OnStart: func(ctx context.Context) error {
+			tp, err := tracing.Init(cfg, serviceName)
+			if err != nil {
+				return fmt.Errorf("failed to initialize tracing: %w", err)
+			}
+			m.Provider = tp
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			if m.Provider == nil {
+				return nil
+			}
+			return m.Provider.Shutdown(ctx)
+		},
+	})
+	return m
+}
+
+// MetricsModule holds the *otelmetrics.MeterProvider a Container provides
+// once started - TracingModule's OTLP-metrics counterpart.
+type MetricsModule struct {
+	Provider *otelmetrics.MeterProvider
+}
+
+// NewMetricsModule registers otelmetrics.Init/MeterProvider.Shutdown as a
+// Container hook.
+func NewMetricsModule(c *Container, cfg config.MetricsConfig, serviceName string) *MetricsModule {
+	m := &MetricsModule{}
+	c.Append(Hook{
+		OnStart: func(ctx context.Context) error {
+			mp, err := otelmetrics.Init(cfg, serviceName)
+			if err != nil {
+				return fmt.Errorf("failed to initialize OTLP metrics: %w", err)
+			}
+			m.Provider = mp
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			if m.Provider == nil {
+				return nil
+			}
+			return m.Provider.Shutdown(ctx)
+		},
+	})
+	return m
+}
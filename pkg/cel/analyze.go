@@ -0,0 +1,65 @@
+package cel
+
+import "github.com/google/cel-go/cel"
+
+// ExpressionAnalysis is an expression's static shape, for a rule author's
+// dry-run/playground view: whether it compiles, what it compiles to, and how
+// expensive cel-go's static cost model thinks it is. It's deliberately
+// read-only - AnalyzeFilterExpression/AnalyzeTransformExpression never
+// evaluate the expression, so they're safe to call on untrusted input before
+// a sample event is even available.
+type ExpressionAnalysis struct {
+	OutputType    string            `json:"output_type,omitempty"`
+	EstimatedCost uint64            `json:"estimated_cost,omitempty"`
+	Issues        []ExpressionIssue `json:"issues,omitempty"`
+}
+
+// ExpressionIssue is one compile error cel-go reported, with its source
+// position so a caller can underline the exact token instead of re-parsing
+// Message.
+type ExpressionIssue struct {
+	Message string `json:"message"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+}
+
+// AnalyzeFilterExpression compiles expression against the filter
+// environment (see ValidateFilterExpression) and reports its output type and
+// estimated cost instead of rejecting it outright, so a caller building a
+// rule-editor playground can show both a compile result and why an
+// expression would (or wouldn't) pass ValidateFilterExpression.
+func (e *Evaluator) AnalyzeFilterExpression(expression string) ExpressionAnalysis {
+	return analyzeExpression(e.env, expression)
+}
+
+// AnalyzeTransformExpression is AnalyzeFilterExpression's transform-env
+// counterpart.
+func (e *Evaluator) AnalyzeTransformExpression(expression string) ExpressionAnalysis {
+	return analyzeExpression(e.transformEnv, expression)
+}
+
+func analyzeExpression(env *cel.Env, expression string) ExpressionAnalysis {
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return ExpressionAnalysis{Issues: issuesToList(issues)}
+	}
+
+	analysis := ExpressionAnalysis{OutputType: ast.OutputType().String()}
+	if estimate, err := env.EstimateCost(ast, unknownCostEstimator{}); err == nil {
+		analysis.EstimatedCost = estimate.Max
+	}
+	return analysis
+}
+
+func issuesToList(issues *cel.Issues) []ExpressionIssue {
+	errs := issues.Errors()
+	out := make([]ExpressionIssue, 0, len(errs))
+	for _, issue := range errs {
+		out = append(out, ExpressionIssue{
+			Message: issue.Message,
+			Line:    issue.Location.Line(),
+			Column:  issue.Location.Column(),
+		})
+	}
+	return out
+}
@@ -2,30 +2,284 @@ package cel
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/checker"
+	"github.com/google/cel-go/ext"
+	lru "github.com/hashicorp/golang-lru/v2"
 
+	"yeti/pkg/metrics"
 	"yeti/pkg/models"
+	"yeti/pkg/retry"
 )
 
+// DefaultProgramCacheSize bounds each of Evaluator's two compiled-program
+// caches (filter and transform) when NewEvaluator is used instead of
+// NewEvaluatorWithCacheSize. It's sized for the common case of a few hundred
+// distinct rule expressions active at once, with headroom for dry-run/ad-hoc
+// expressions that pass through the same evaluator.
+const DefaultProgramCacheSize = 1000
+
+// ErrRuleBreakerOpen is returned by EvaluateFilterForRule/
+// EvaluateTransformForRule instead of evaluating ruleID's expression while
+// its circuit breaker is open.
+var ErrRuleBreakerOpen = errors.New("cel: rule circuit breaker is open")
+
+// EvalBudget bounds a single CEL evaluation two ways: MaxCost is an abstract
+// interpreter-step budget enforced via cel.CostLimit (cel-go aborts the
+// program with a "actual cost limit exceeded" error once crossed), and
+// MaxDuration bounds wall-clock time via a context.WithTimeout wrapped
+// around ContextEval, catching an expression (e.g. a single expensive regex
+// match) that CEL's static cost model underestimates. Either field <= 0
+// disables that half of the budget.
+type EvalBudget struct {
+	MaxCost     uint64
+	MaxDuration time.Duration
+}
+
+// Evaluator compiles and runs CEL expressions against two environments: env
+// (filter/condition expressions - event fields only, must return bool) and
+// transformEnv (enrichment transformations - event fields plus sourceData and
+// the CEL strings extension). A compiled cel.Program is cached by its source
+// expression string in filterCache/transformCache, so EvaluateFilter/
+// EvaluateTransform only pay env.Compile's cost once per distinct expression
+// instead of once per message. A cache is nil (and bypassed) when its size is
+// <= 0, matching the rest of the repo's "Size <= 0 disables the cache"
+// convention (see config.L1CacheConfig).
 type Evaluator struct {
-	env *cel.Env
+	env          *cel.Env
+	transformEnv *cel.Env
+
+	filterCache    *lru.Cache[string, cel.Program]
+	transformCache *lru.Cache[string, cel.Program]
+
+	budget           EvalBudget
+	maxEstimatedCost uint64
+
+	ruleBreakerCfg *retry.CircuitBreakerConfig
+	ruleBreakersMu sync.Mutex
+	ruleBreakers   map[string]*retry.CircuitBreaker
+
+	// ruleCostPrograms caches EvaluateFilterForRuleWithBudget's per-rule
+	// cost-limited programs, keyed by ruleID - see compileRuleCostProgram.
+	ruleCostProgramsMu sync.Mutex
+	ruleCostPrograms   map[string]ruleCostProgram
+
+	geoResolver GeoResolver
+
+	regexMu    sync.Mutex
+	regexCache map[string]*regexp.Regexp
+}
+
+func NewEvaluator(opts ...EvaluatorOption) (*Evaluator, error) {
+	return NewEvaluatorWithCacheSize(DefaultProgramCacheSize, opts...)
 }
 
-func NewEvaluator() (*Evaluator, error) {
-	env, err := cel.NewEnv(
+// NewEvaluatorWithCacheSize builds an Evaluator whose compiled-program caches
+// each hold up to cacheSize entries. cacheSize <= 0 disables caching, so
+// every EvaluateFilter/EvaluateTransform call recompiles, as Evaluator
+// behaved before these caches existed. opts configures the extended function
+// library (see WithExtensions); every extension group is enabled unless opts
+// narrows them, so existing callers that pass no options keep today's
+// behavior once the library ships.
+func NewEvaluatorWithCacheSize(cacheSize int, opts ...EvaluatorOption) (*Evaluator, error) {
+	cfg := evaluatorOptions{extensions: extensionSet(allExtensions)}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	e := &Evaluator{regexCache: make(map[string]*regexp.Regexp)}
+	extFuncs := e.extensionFunctionOptions(cfg.extensions)
+
+	env, err := cel.NewEnv(append([]cel.EnvOption{
 		cel.Variable("id", cel.StringType),
 		cel.Variable("source", cel.StringType),
 		cel.Variable("timestamp", cel.TimestampType),
 		cel.Variable("payload", cel.MapType(cel.StringType, cel.DynType)),
 		cel.Variable("metadata", cel.MapType(cel.StringType, cel.DynType)),
-	)
+	}, extFuncs...)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create CEL environment: %w", err)
 	}
 
-	return &Evaluator{env: env}, nil
+	transformEnv, err := newTransformEnv(extFuncs)
+	if err != nil {
+		return nil, err
+	}
+
+	e.env = env
+	e.transformEnv = transformEnv
+
+	if cacheSize > 0 {
+		filterCache, err := lru.New[string, cel.Program](cacheSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create CEL filter program cache: %w", err)
+		}
+		transformCache, err := lru.New[string, cel.Program](cacheSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create CEL transform program cache: %w", err)
+		}
+		e.filterCache = filterCache
+		e.transformCache = transformCache
+	}
+
+	return e, nil
+}
+
+// WithEvalBudget sets the cost limit and eval timeout every subsequent
+// compile/evaluate on e enforces - see EvalBudget. It mutates and returns e
+// so it can be chained off NewEvaluatorWithCacheSize, following the repo's
+// existing With*-builder convention (e.g.
+// provider.CircuitBreakerProvider.WithOnOpen).
+func (e *Evaluator) WithEvalBudget(maxCost uint64, maxDuration time.Duration) *Evaluator {
+	e.budget = EvalBudget{MaxCost: maxCost, MaxDuration: maxDuration}
+	return e
+}
+
+// WithMaxEstimatedCost sets the worst-case cel.Env.EstimateCost ceiling
+// ValidateFilterExpression/ValidateTransformExpression reject an expression
+// over. limit <= 0 disables the check, as if WithMaxEstimatedCost were never
+// called.
+func (e *Evaluator) WithMaxEstimatedCost(limit uint64) *Evaluator {
+	e.maxEstimatedCost = limit
+	return e
+}
+
+// WithRuleBreaker enables EvaluateFilterForRule/EvaluateTransformForRule's
+// per-rule circuit breaker, configured by cfg (see
+// config.CELRuleBreakerConfig). Without it, EvaluateFilterForRule/
+// EvaluateTransformForRule behave exactly like EvaluateFilter/
+// EvaluateTransform - no breaker tracking, never ErrRuleBreakerOpen.
+func (e *Evaluator) WithRuleBreaker(cfg retry.CircuitBreakerConfig) *Evaluator {
+	e.ruleBreakerCfg = &cfg
+	e.ruleBreakers = make(map[string]*retry.CircuitBreaker)
+	return e
+}
+
+// programOptions returns the cel.ProgramOptions every compiled program
+// (filter, transform, or one-off via Compile*Expression) is built with: cost
+// tracking is always on (cheap, and ContextEval's actual-cost reporting
+// needs it), with a hard cel.CostLimit added on top when budget.MaxCost is
+// configured.
+func (e *Evaluator) programOptions() []cel.ProgramOption {
+	opts := []cel.ProgramOption{cel.EvalOptions(cel.OptTrackCost)}
+	if e.budget.MaxCost > 0 {
+		opts = append(opts, cel.CostLimit(e.budget.MaxCost))
+	}
+	return opts
+}
+
+// boundedContext wraps ctx in a context.WithTimeout derived from
+// budget.MaxDuration, or returns ctx unchanged (with a no-op cancel) when no
+// duration budget is configured.
+func (e *Evaluator) boundedContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if e.budget.MaxDuration <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, e.budget.MaxDuration)
+}
+
+// IsBudgetExceeded reports whether err is ContextEval aborting because the
+// budget's cost limit or eval timeout (rather than an ordinary evaluation
+// error) was crossed, and if so which one ("cost" or "timeout"). Exported so
+// a caller like filtering.Service.evaluateRule, which evaluates through
+// EvaluateFilterForRuleWithBudget rather than EvaluateFilterForRule, can tell
+// a budget failure apart from an ordinary CEL error itself instead of just
+// relying on the metrics this package already records.
+func IsBudgetExceeded(err error) (string, bool) {
+	if err == nil {
+		return "", false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "timeout", true
+	}
+	if strings.Contains(err.Error(), "actual cost limit exceeded") {
+		return "cost", true
+	}
+	return "", false
+}
+
+// unknownCostEstimator is a checker.CostEstimator that declines to estimate
+// variable sizes or call costs (both methods return nil), so
+// cel.Env.EstimateCost falls back to CEL's own conservative built-in
+// defaults for things like comprehensions over payload/metadata maps,
+// rather than this validator guessing at production data shapes it never
+// sees.
+type unknownCostEstimator struct{}
+
+func (unknownCostEstimator) EstimateSize(_ checker.AstNode) *checker.SizeEstimate {
+	return nil
+}
+
+func (unknownCostEstimator) EstimateCallCost(_, _ string, _ *checker.AstNode, _ []checker.AstNode) *checker.CallEstimate {
+	return nil
+}
+
+// checkEstimatedCost rejects ast if its worst-case cel.Env.EstimateCost
+// exceeds maxEstimatedCost. A no-op when maxEstimatedCost <= 0.
+func (e *Evaluator) checkEstimatedCost(env *cel.Env, ast *cel.Ast) error {
+	if e.maxEstimatedCost == 0 {
+		return nil
+	}
+
+	estimate, err := env.EstimateCost(ast, unknownCostEstimator{})
+	if err != nil {
+		return fmt.Errorf("failed to estimate CEL expression cost: %w", err)
+	}
+	if estimate.Max > e.maxEstimatedCost {
+		return fmt.Errorf("expression's estimated worst-case cost (%d) exceeds configured ceiling (%d)", estimate.Max, e.maxEstimatedCost)
+	}
+	return nil
+}
+
+// ruleBreakerFor returns ruleID's circuit breaker, creating it on first use.
+// Only called once ruleBreakerCfg is non-nil (see WithRuleBreaker).
+func (e *Evaluator) ruleBreakerFor(ruleID string) *retry.CircuitBreaker {
+	e.ruleBreakersMu.Lock()
+	defer e.ruleBreakersMu.Unlock()
+
+	if cb, ok := e.ruleBreakers[ruleID]; ok {
+		return cb
+	}
+
+	cfg := *e.ruleBreakerCfg
+	cfg.OnStateChange = func(_, to retry.CircuitState) {
+		metrics.SetCELRuleBreakerState(ruleID, string(to))
+	}
+	cb := retry.NewCircuitBreaker(cfg)
+	e.ruleBreakers[ruleID] = cb
+	return cb
+}
+
+// newTransformEnv builds the environment enrichment transformations compile
+// and run against: the same event variables as the filter env, plus
+// sourceData (the enrichment provider's fetch result) and the CEL strings
+// extension library, since transformations commonly reshape string fields
+// (upperAscii, trim, replace, ...) that aren't part of base CEL. extFuncs is
+// the same cel.Function library the filter env was built with (see
+// extensionFunctionOptions), so a rule author sees matches/ipInCIDR/
+// sha256Hex/... identically in both environments.
+func newTransformEnv(extFuncs []cel.EnvOption) (*cel.Env, error) {
+	env, err := cel.NewEnv(append([]cel.EnvOption{
+		cel.Variable("id", cel.StringType),
+		cel.Variable("source", cel.StringType),
+		cel.Variable("timestamp", cel.TimestampType),
+		cel.Variable("payload", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("metadata", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("sourceData", cel.MapType(cel.StringType, cel.DynType)),
+		ext.Strings(),
+	}, extFuncs...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CEL transform environment: %w", err)
+	}
+	return env, nil
 }
 
 func (e *Evaluator) ValidateExpression(expression string) error {
@@ -36,6 +290,24 @@ func (e *Evaluator) ValidateExpression(expression string) error {
 	return nil
 }
 
+// ValidateTransformExpression compiles expression against the same
+// environment EvaluateTransform runs it in (event fields, sourceData, and
+// the CEL strings extension), so a rule that validates here is guaranteed
+// to compile when it actually runs. It also rejects expression if its
+// worst-case estimated cost exceeds maxEstimatedCost (see
+// WithMaxEstimatedCost), catching a pathological expression before it's
+// ever saved.
+func (e *Evaluator) ValidateTransformExpression(expression string) error {
+	ast, issues := e.transformEnv.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return fmt.Errorf("CEL transform expression validation failed: %w", issues.Err())
+	}
+	return e.checkEstimatedCost(e.transformEnv, ast)
+}
+
+// ValidateFilterExpression compiles expression, checks it returns bool, and
+// rejects it if its worst-case estimated cost exceeds maxEstimatedCost (see
+// WithMaxEstimatedCost).
 func (e *Evaluator) ValidateFilterExpression(expression string) error {
 	ast, issues := e.env.Compile(expression)
 	if issues != nil && issues.Err() != nil {
@@ -46,24 +318,235 @@ func (e *Evaluator) ValidateFilterExpression(expression string) error {
 		return fmt.Errorf("filter expression must return bool, got %v", ast.OutputType())
 	}
 
-	return nil
+	return e.checkEstimatedCost(e.env, ast)
 }
 
 func (e *Evaluator) EvaluateFilter(ctx context.Context, expression string, msg models.MessageEnvelope) (bool, error) {
+	program, err := e.compileFilterCached(expression)
+	if err != nil {
+		return false, err
+	}
+
+	return e.EvaluateFilterCompiled(ctx, program, msg)
+}
+
+// EvaluateFilterForRule is EvaluateFilter's circuit-breaker-gated
+// counterpart: when WithRuleBreaker has been called, ruleID's breaker is
+// consulted before evaluating (returning ErrRuleBreakerOpen instead, if
+// open) and a cost-limit or eval-timeout failure is recorded against it
+// afterward. Without WithRuleBreaker it's identical to EvaluateFilter.
+func (e *Evaluator) EvaluateFilterForRule(ctx context.Context, ruleID, expression string, msg models.MessageEnvelope) (bool, error) {
+	if e.ruleBreakerCfg == nil {
+		return e.EvaluateFilter(ctx, expression, msg)
+	}
+
+	breaker := e.ruleBreakerFor(ruleID)
+	if !breaker.Allow() {
+		return false, fmt.Errorf("%w: rule %s", ErrRuleBreakerOpen, ruleID)
+	}
+
+	result, err := e.EvaluateFilter(ctx, expression, msg)
+	if reason, exceeded := IsBudgetExceeded(err); exceeded {
+		metrics.IncCELBudgetExceeded("filter", reason)
+		breaker.RecordFailure()
+	} else {
+		breaker.RecordSuccess()
+	}
+	return result, err
+}
+
+// ruleCostProgram is ruleCostPrograms' cache entry: program is only valid for
+// this exact (expression, maxCost) pair, since cel.CostLimit is baked into
+// the compiled cel.Program at e.env.Program time and can't be adjusted once
+// built.
+type ruleCostProgram struct {
+	expression string
+	maxCost    uint64
+	program    cel.Program
+}
+
+// EvaluateFilterForRuleWithBudget is EvaluateFilterForRule's per-rule-budget
+// counterpart: budget overrides e.budget for this one call instead of
+// applying the evaluator-wide cost limit/eval timeout every rule shares.
+// Falls straight through to EvaluateFilterForRule when budget is the zero
+// value, so a rule with no override pays none of this path's extra cost.
+//
+// budget.MaxCost requires its own compiled cel.Program (cel.CostLimit is a
+// cel.ProgramOption, baked in at compile time, not adjustable per
+// ContextEval call), so this keeps a second program cache keyed by ruleID
+// rather than expression text - two rules can share identical expression
+// text while wanting different cost ceilings, which filterCache's
+// expression-keyed cache can't represent. The cache entry also records the
+// expression/maxCost it was built from, so an edited rule or a changed
+// MaxCost transparently recompiles on the next call instead of needing an
+// explicit eviction hook like EvictFilterExpression's.
+func (e *Evaluator) EvaluateFilterForRuleWithBudget(ctx context.Context, ruleID, expression string, budget EvalBudget, msg models.MessageEnvelope) (bool, error) {
+	if budget.MaxCost <= 0 && budget.MaxDuration <= 0 {
+		return e.EvaluateFilterForRule(ctx, ruleID, expression, msg)
+	}
+
+	var breaker *retry.CircuitBreaker
+	if e.ruleBreakerCfg != nil {
+		breaker = e.ruleBreakerFor(ruleID)
+		if !breaker.Allow() {
+			return false, fmt.Errorf("%w: rule %s", ErrRuleBreakerOpen, ruleID)
+		}
+	}
+
+	maxCost := budget.MaxCost
+	if maxCost <= 0 {
+		maxCost = e.budget.MaxCost
+	}
+	program, err := e.compileRuleCostProgram(ruleID, expression, maxCost)
+	if err != nil {
+		return false, err
+	}
+
+	maxDuration := budget.MaxDuration
+	if maxDuration <= 0 {
+		maxDuration = e.budget.MaxDuration
+	}
+	evalCtx := ctx
+	cancel := func() {}
+	if maxDuration > 0 {
+		evalCtx, cancel = context.WithTimeout(ctx, maxDuration)
+	}
+	result, err := e.evalFilterProgram(evalCtx, program, msg)
+	cancel()
+
+	if breaker != nil {
+		if reason, exceeded := IsBudgetExceeded(err); exceeded {
+			metrics.IncCELBudgetExceeded("filter", reason)
+			breaker.RecordFailure()
+		} else {
+			breaker.RecordSuccess()
+		}
+	}
+	return result, err
+}
+
+// compileRuleCostProgram returns ruleID's cost-limited program, recompiling
+// if ruleCostPrograms has nothing cached for it yet or what's cached was
+// built from a different expression/maxCost. Guarded by
+// ruleCostProgramsMu since EvaluateFilterForRuleWithBudget can run
+// concurrently across RuleGroup's worker pool (see
+// filtering.Service.evaluateRuleGroup), unlike filterCache's lru.Cache,
+// which is already internally synchronized.
+func (e *Evaluator) compileRuleCostProgram(ruleID, expression string, maxCost uint64) (cel.Program, error) {
+	e.ruleCostProgramsMu.Lock()
+	defer e.ruleCostProgramsMu.Unlock()
+
+	if e.ruleCostPrograms == nil {
+		e.ruleCostPrograms = make(map[string]ruleCostProgram)
+	}
+
+	if cached, ok := e.ruleCostPrograms[ruleID]; ok && cached.expression == expression && cached.maxCost == maxCost {
+		return cached.program, nil
+	}
+
 	ast, issues := e.env.Compile(expression)
 	if issues != nil && issues.Err() != nil {
-		return false, fmt.Errorf("failed to compile CEL expression: %w", issues.Err())
+		return nil, fmt.Errorf("failed to compile CEL expression: %w", issues.Err())
+	}
+	if ast.OutputType() != cel.BoolType {
+		return nil, fmt.Errorf("filter expression must return bool, got %v", ast.OutputType())
+	}
+
+	opts := []cel.ProgramOption{cel.EvalOptions(cel.OptTrackCost)}
+	if maxCost > 0 {
+		opts = append(opts, cel.CostLimit(maxCost))
+	}
+	program, err := e.env.Program(ast, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CEL program: %w", err)
+	}
+
+	e.ruleCostPrograms[ruleID] = ruleCostProgram{expression: expression, maxCost: maxCost, program: program}
+	return program, nil
+}
+
+// CostEstimate is a CEL expression's worst-case cel.Env.EstimateCost result,
+// returned by EstimateFilterCost for a caller that wants to decide what to
+// do about a high estimate itself (e.g. surface it as a warning) rather than
+// ValidateFilterExpression's hard-reject against WithMaxEstimatedCost.
+type CostEstimate struct {
+	Min uint64
+	Max uint64
+}
+
+// EstimateFilterCost compiles expression against the filter environment,
+// checks it returns bool, and returns its worst-case estimated cost without
+// enforcing WithMaxEstimatedCost's ceiling the way ValidateFilterExpression
+// does - see management.ValidateFilteringRule, which uses this to surface
+// the estimate as an advisory warning instead of rejecting the save
+// outright.
+func (e *Evaluator) EstimateFilterCost(expression string) (CostEstimate, error) {
+	ast, issues := e.env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return CostEstimate{}, fmt.Errorf("CEL expression validation failed: %w", issues.Err())
+	}
+	if ast.OutputType() != cel.BoolType {
+		return CostEstimate{}, fmt.Errorf("filter expression must return bool, got %v", ast.OutputType())
+	}
+
+	estimate, err := e.env.EstimateCost(ast, unknownCostEstimator{})
+	if err != nil {
+		return CostEstimate{}, fmt.Errorf("failed to estimate CEL expression cost: %w", err)
+	}
+	return CostEstimate{Min: estimate.Min, Max: estimate.Max}, nil
+}
+
+// compileFilterCached returns expression's compiled program from filterCache
+// if present, else compiles it against env, validates it returns bool, and
+// stores it (when caching is enabled) before returning it.
+func (e *Evaluator) compileFilterCached(expression string) (cel.Program, error) {
+	if e.filterCache != nil {
+		if program, ok := e.filterCache.Get(expression); ok {
+			metrics.IncCELProgramCacheHit("filter")
+			return program, nil
+		}
+	}
+	metrics.IncCELProgramCacheMiss("filter")
+
+	ast, issues := e.env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("failed to compile CEL expression: %w", issues.Err())
 	}
 
 	if ast.OutputType() != cel.BoolType {
-		return false, fmt.Errorf("filter expression must return bool, got %v", ast.OutputType())
+		return nil, fmt.Errorf("filter expression must return bool, got %v", ast.OutputType())
 	}
 
-	program, err := e.env.Program(ast)
+	program, err := e.env.Program(ast, e.programOptions()...)
 	if err != nil {
-		return false, fmt.Errorf("failed to create CEL program: %w", err)
+		return nil, fmt.Errorf("failed to create CEL program: %w", err)
 	}
 
+	if e.filterCache != nil {
+		e.filterCache.Add(expression, program)
+	}
+
+	return program, nil
+}
+
+// EvaluateFilterCompiled runs program - as returned by CompileExpression or
+// an earlier EvaluateFilter call - against msg, skipping both the cache
+// lookup and any possible compile. Use it when the caller already holds the
+// program (e.g. a management.RuleValidator-style pre-warmed rule) and wants
+// to avoid even a cache-map lookup on the hot path.
+func (e *Evaluator) EvaluateFilterCompiled(ctx context.Context, program cel.Program, msg models.MessageEnvelope) (bool, error) {
+	ctx, cancel := e.boundedContext(ctx)
+	defer cancel()
+	return e.evalFilterProgram(ctx, program, msg)
+}
+
+// evalFilterProgram builds program's input vars from msg and runs it via
+// ContextEval, so the result is interruptable by ctx's deadline/cancellation
+// - the one piece EvaluateFilterCompiled and
+// EvaluateFilterForRuleWithBudget's per-rule-budget path share, with each
+// deriving ctx's deadline differently (e.programOptions/e.boundedContext's
+// evaluator-wide budget vs. a per-rule EvalBudget).
+func (e *Evaluator) evalFilterProgram(ctx context.Context, program cel.Program, msg models.MessageEnvelope) (bool, error) {
 	vars := map[string]interface{}{
 		"id":        msg.ID,
 		"source":    msg.Source,
@@ -85,29 +568,170 @@ func (e *Evaluator) EvaluateFilter(ctx context.Context, expression string, msg m
 	return boolVal, nil
 }
 
+// SubexpressionTrace is one AST node's recorded value (or error) from a
+// single EvaluateFilterWithTrace run, identified by its cel-go expression
+// ID rather than source text - cel-go's EvalState tracks state per node ID,
+// not per source span, and recovering the exact substring a given ID came
+// from would need its own unparser. A caller that wants to show this next
+// to the rule's expression text can still correlate IDs across repeated
+// dry runs of the same expression, since a given AST is numbered
+// deterministically.
+type SubexpressionTrace struct {
+	ID    int64  `json:"id"`
+	Value string `json:"value,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// EvaluateFilterWithTrace is EvaluateFilter's tracing counterpart for an
+// operator's rule-authoring dry run: besides the boolean result, it returns
+// a SubexpressionTrace per AST node cel.OptTrackState recorded a value or
+// error for, read back via the evaluation's Details().State() the same way
+// cel-go's own trace tooling does. Tracing has a real per-eval cost
+// (cel-go has to retain every subexpression's result, not just the root's),
+// so this always builds its own uncached Program rather than going through
+// filterCache/EvaluateFilter - it's for a one-off dry run, not the runtime
+// pipeline's hot path.
+func (e *Evaluator) EvaluateFilterWithTrace(ctx context.Context, expression string, msg models.MessageEnvelope) (bool, []SubexpressionTrace, error) {
+	ast, issues := e.env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return false, nil, fmt.Errorf("failed to compile CEL expression: %w", issues.Err())
+	}
+	if ast.OutputType() != cel.BoolType {
+		return false, nil, fmt.Errorf("filter expression must return bool, got %v", ast.OutputType())
+	}
+
+	program, err := e.env.Program(ast, cel.EvalOptions(cel.OptTrackState, cel.OptExhaustiveEval))
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to build trace program: %w", err)
+	}
+
+	ctx, cancel := e.boundedContext(ctx)
+	defer cancel()
+
+	out, details, evalErr := program.ContextEval(ctx, map[string]interface{}{
+		"id":        msg.ID,
+		"source":    msg.Source,
+		"timestamp": msg.Timestamp,
+		"payload":   msg.Payload,
+		"metadata":  e.metadataToMap(msg.Metadata),
+	})
+
+	var trace []SubexpressionTrace
+	if details != nil && details.State() != nil {
+		state := details.State()
+		ids := state.IDs()
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		for _, id := range ids {
+			val, found := state.Value(id)
+			if !found {
+				continue
+			}
+			st := SubexpressionTrace{ID: id}
+			if errVal, ok := val.Value().(error); ok {
+				st.Error = errVal.Error()
+			} else {
+				st.Value = fmt.Sprintf("%v", val.Value())
+			}
+			trace = append(trace, st)
+		}
+	}
+	if evalErr != nil {
+		return false, trace, fmt.Errorf("failed to evaluate CEL expression: %w", evalErr)
+	}
+
+	boolVal, ok := out.Value().(bool)
+	if !ok {
+		return false, trace, fmt.Errorf("CEL expression did not return bool, got %T", out.Value())
+	}
+	return boolVal, trace, nil
+}
+
+// WarmFilterExpression compiles expression and seeds filterCache with it, so
+// the first EvaluateFilter call for it - typically the first live message
+// after a rule reload - hits the cache instead of paying to compile. Callers
+// that have already validated expression (e.g. ValidateFilterExpression) can
+// ignore a non-nil error here, since it just means warming didn't happen;
+// EvaluateFilter still compiles on demand as a fallback.
+func (e *Evaluator) WarmFilterExpression(expression string) error {
+	_, err := e.compileFilterCached(expression)
+	return err
+}
+
+// EvictFilterExpression drops expression from filterCache, if present. A
+// cache miss on the next EvaluateFilter call just recompiles - harmless, and
+// expected if another still-active rule happens to share the exact same
+// expression text.
+func (e *Evaluator) EvictFilterExpression(expression string) {
+	if e.filterCache != nil {
+		e.filterCache.Remove(expression)
+	}
+}
+
 func (e *Evaluator) EvaluateTransform(ctx context.Context, expression string, msg models.MessageEnvelope, sourceData map[string]interface{}) (interface{}, error) {
-	env, err := cel.NewEnv(
-		cel.Variable("id", cel.StringType),
-		cel.Variable("source", cel.StringType),
-		cel.Variable("timestamp", cel.TimestampType),
-		cel.Variable("payload", cel.MapType(cel.StringType, cel.DynType)),
-		cel.Variable("metadata", cel.MapType(cel.StringType, cel.DynType)),
-		cel.Variable("sourceData", cel.MapType(cel.StringType, cel.DynType)),
-	)
+	program, err := e.compileTransformCached(expression)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create CEL environment: %w", err)
+		return nil, err
+	}
+
+	return e.EvaluateTransformCompiled(ctx, program, msg, sourceData)
+}
+
+// EvaluateTransformForRule is EvaluateTransform's circuit-breaker-gated
+// counterpart - see EvaluateFilterForRule's doc comment.
+func (e *Evaluator) EvaluateTransformForRule(ctx context.Context, ruleID, expression string, msg models.MessageEnvelope, sourceData map[string]interface{}) (interface{}, error) {
+	if e.ruleBreakerCfg == nil {
+		return e.EvaluateTransform(ctx, expression, msg, sourceData)
+	}
+
+	breaker := e.ruleBreakerFor(ruleID)
+	if !breaker.Allow() {
+		return nil, fmt.Errorf("%w: rule %s", ErrRuleBreakerOpen, ruleID)
 	}
 
-	ast, issues := env.Compile(expression)
+	result, err := e.EvaluateTransform(ctx, expression, msg, sourceData)
+	if reason, exceeded := IsBudgetExceeded(err); exceeded {
+		metrics.IncCELBudgetExceeded("transform", reason)
+		breaker.RecordFailure()
+	} else {
+		breaker.RecordSuccess()
+	}
+	return result, err
+}
+
+// compileTransformCached is compileFilterCached's transformEnv counterpart -
+// see its doc comment.
+func (e *Evaluator) compileTransformCached(expression string) (cel.Program, error) {
+	if e.transformCache != nil {
+		if program, ok := e.transformCache.Get(expression); ok {
+			metrics.IncCELProgramCacheHit("transform")
+			return program, nil
+		}
+	}
+	metrics.IncCELProgramCacheMiss("transform")
+
+	ast, issues := e.transformEnv.Compile(expression)
 	if issues != nil && issues.Err() != nil {
 		return nil, fmt.Errorf("failed to compile CEL expression: %w", issues.Err())
 	}
 
-	program, err := env.Program(ast)
+	program, err := e.transformEnv.Program(ast, e.programOptions()...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create CEL program: %w", err)
 	}
 
+	if e.transformCache != nil {
+		e.transformCache.Add(expression, program)
+	}
+
+	return program, nil
+}
+
+// EvaluateTransformCompiled is EvaluateFilterCompiled's transformEnv
+// counterpart - see its doc comment.
+func (e *Evaluator) EvaluateTransformCompiled(ctx context.Context, program cel.Program, msg models.MessageEnvelope, sourceData map[string]interface{}) (interface{}, error) {
+	ctx, cancel := e.boundedContext(ctx)
+	defer cancel()
+
 	vars := map[string]interface{}{
 		"id":         msg.ID,
 		"source":     msg.Source,
@@ -125,6 +749,21 @@ func (e *Evaluator) EvaluateTransform(ctx context.Context, expression string, ms
 	return result.Value(), nil
 }
 
+// WarmTransformExpression is WarmFilterExpression's transformEnv
+// counterpart - see its doc comment.
+func (e *Evaluator) WarmTransformExpression(expression string) error {
+	_, err := e.compileTransformCached(expression)
+	return err
+}
+
+// EvictTransformExpression is EvictFilterExpression's transformEnv
+// counterpart - see its doc comment.
+func (e *Evaluator) EvictTransformExpression(expression string) {
+	if e.transformCache != nil {
+		e.transformCache.Remove(expression)
+	}
+}
+
 func (e *Evaluator) metadataToMap(metadata models.Metadata) map[string]interface{} {
 	result := make(map[string]interface{})
 
@@ -153,13 +792,31 @@ func (e *Evaluator) metadataToMap(metadata models.Metadata) map[string]interface
 	return result
 }
 
+// CompileTransformExpression compiles expression against the transform
+// environment and returns the ready-to-run program, so a caller that has
+// already validated a rule's expressions (see ValidateTransformExpression)
+// can cache the same program instead of recompiling it for every event.
+func (e *Evaluator) CompileTransformExpression(expression string) (cel.Program, error) {
+	ast, issues := e.transformEnv.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("failed to compile CEL transform expression: %w", issues.Err())
+	}
+
+	program, err := e.transformEnv.Program(ast, e.programOptions()...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CEL transform program: %w", err)
+	}
+
+	return program, nil
+}
+
 func (e *Evaluator) CompileExpression(expression string) (cel.Program, error) {
 	ast, issues := e.env.Compile(expression)
 	if issues != nil && issues.Err() != nil {
 		return nil, fmt.Errorf("failed to compile CEL expression: %w", issues.Err())
 	}
 
-	program, err := e.env.Program(ast)
+	program, err := e.env.Program(ast, e.programOptions()...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create CEL program: %w", err)
 	}
@@ -13,6 +13,10 @@ var FilterExpressionExamples = map[string]string{
 	"top_level_source":     `source == "api-gateway"`,
 	"has_field":            `has(payload.email) && payload.email != ""`,
 	"complex_logic":        `(payload.status == "active" || payload.status == "pending") && payload.amount > 50.0`,
+	"regex_match":          `matches(payload.user_agent, "(?i)bot|crawler")`,
+	"ip_in_cidr":           `ipInCIDR(payload.client_ip, "10.0.0.0/8")`,
+	"ip_is_private":        `ipIsPrivate(payload.client_ip)`,
+	"geo_country":          `geoCountry(payload.client_ip) == "US"`,
 }
 
 // TransformExpressionExamples provides example CEL expressions for transformations
@@ -26,4 +30,9 @@ var TransformExpressionExamples = map[string]string{
 	"default_value":        `has(sourceData.name) ? sourceData.name : "Unknown"`,
 	"format_number":        `string(sourceData.amount) + " USD"`,
 	"extract_from_payload": `payload.user_id + "-" + string(sourceData.id)`,
+	"json_pointer":         `jsonPointer(payload, "/user/address/0/zip")`,
+	"hash_field":           `sha256Hex(sourceData.email)`,
+	"parse_time":           `parseTime(sourceData.created_at, "2006-01-02T15:04:05Z07:00")`,
+	"duration_since":       `durationBetween(parseTime(sourceData.created_at, "2006-01-02T15:04:05Z07:00"), timestamp)`,
+	"base64_decode":        `base64Decode(sourceData.encoded_payload)`,
 }
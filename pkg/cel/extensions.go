@@ -0,0 +1,416 @@
+package cel
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+)
+
+// Extension group names WithExtensions accepts. Each gates one cluster of
+// cel.Function overloads registered into both the filter and transform
+// environments by extensionFunctionOptions.
+const (
+	ExtRegex    = "regex"    // matches(str, regex) bool
+	ExtNet      = "net"      // ipInCIDR(ip, cidr) bool, ipIsPrivate(ip) bool
+	ExtGeo      = "geo"      // geoCountry(ip) string - see GeoResolver
+	ExtJSON     = "json"     // jsonPointer(value, pointer) dyn
+	ExtHash     = "hash"     // sha256Hex(str)/md5Hex(str) string
+	ExtTime     = "time"     // parseTime(str, layout) timestamp, durationBetween(t1, t2) duration
+	ExtEncoding = "encoding" // base64Encode(str)/base64Decode(str) string
+)
+
+// allExtensions is the default passed to NewEvaluator/NewEvaluatorWithCacheSize
+// when no WithExtensions option narrows it, so every extension function is
+// available unless an operator opts out.
+var allExtensions = []string{ExtRegex, ExtNet, ExtGeo, ExtJSON, ExtHash, ExtTime, ExtEncoding}
+
+// EvaluatorOption configures NewEvaluator/NewEvaluatorWithCacheSize at
+// construction time, since the extension functions below are registered via
+// cel.Function into the (immutable once built) cel.Env, unlike the
+// With*-builder options that reconfigure an already-built Evaluator.
+type EvaluatorOption func(*evaluatorOptions)
+
+type evaluatorOptions struct {
+	extensions map[string]bool
+}
+
+// WithExtensions restricts the extended CEL function library (see the Ext*
+// constants) to exactly the named groups instead of every group enabled by
+// default. Operators can use it to keep a riskier function - geoCountry
+// calls out to a pluggable resolver, jsonPointer walks arbitrary nested
+// payload data - out of an environment whose rule authors are less trusted,
+// without forking the evaluator.
+func WithExtensions(names ...string) EvaluatorOption {
+	return func(o *evaluatorOptions) {
+		o.extensions = extensionSet(names)
+	}
+}
+
+func extensionSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, n := range names {
+		set[n] = true
+	}
+	return set
+}
+
+// extensionFunctionOptions returns the cel.EnvOptions for every extension
+// group enabled, in the stable order the Ext* constants are declared in, for
+// use by both NewEvaluatorWithCacheSize's filter env and newTransformEnv.
+// Some functions (matches, geoCountry) close over e, since they need its
+// regex cache / GeoResolver; the rest are free functions.
+func (e *Evaluator) extensionFunctionOptions(enabled map[string]bool) []cel.EnvOption {
+	var opts []cel.EnvOption
+	if enabled[ExtRegex] {
+		opts = append(opts, e.matchesFunc())
+	}
+	if enabled[ExtNet] {
+		opts = append(opts, ipInCIDRFunc(), ipIsPrivateFunc())
+	}
+	if enabled[ExtGeo] {
+		opts = append(opts, e.geoCountryFunc())
+	}
+	if enabled[ExtJSON] {
+		opts = append(opts, jsonPointerFunc())
+	}
+	if enabled[ExtHash] {
+		opts = append(opts, sha256HexFunc(), md5HexFunc())
+	}
+	if enabled[ExtTime] {
+		opts = append(opts, parseTimeFunc(), durationBetweenFunc())
+	}
+	if enabled[ExtEncoding] {
+		opts = append(opts, base64EncodeFunc(), base64DecodeFunc())
+	}
+	return opts
+}
+
+// matchesFunc registers a global-call-style "matches(str, regex) bool"
+// (cel.Overload, not cel.MemberOverload), with its compiled *regexp.Regexp
+// cached by pattern on e. This coexists with cel-go's own built-in
+// "<string>.matches(<re>)" instance method - the base library declares that
+// one as an instance overload, which the type-checker only matches against
+// target.method(arg) call syntax, while ours only matches the plain
+// matches(target, arg) syntax - so there's no overload collision, and rule
+// authors who already use the built-in method form are unaffected.
+func (e *Evaluator) matchesFunc() cel.EnvOption {
+	return cel.Function("matches",
+		cel.Overload("matches_string_string",
+			[]*cel.Type{cel.StringType, cel.StringType}, cel.BoolType,
+			cel.BinaryBinding(func(lhs, rhs ref.Val) ref.Val {
+				str, ok := lhs.Value().(string)
+				if !ok {
+					return types.NewErr("matches: first argument must be a string")
+				}
+				pattern, ok := rhs.Value().(string)
+				if !ok {
+					return types.NewErr("matches: second argument must be a string")
+				}
+				re, err := e.compileRegex(pattern)
+				if err != nil {
+					return types.NewErr("matches: %v", err)
+				}
+				return types.Bool(re.MatchString(str))
+			}),
+		),
+	)
+}
+
+// compileRegex returns pattern's compiled form, caching it on e so a rule
+// re-evaluated against many messages only pays regexp.Compile once per
+// distinct pattern, the same rationale as filterCache/transformCache.
+func (e *Evaluator) compileRegex(pattern string) (*regexp.Regexp, error) {
+	e.regexMu.Lock()
+	defer e.regexMu.Unlock()
+
+	if re, ok := e.regexCache[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	e.regexCache[pattern] = re
+	return re, nil
+}
+
+// ipInCIDRFunc registers "ipInCIDR(ip, cidr) bool".
+func ipInCIDRFunc() cel.EnvOption {
+	return cel.Function("ipInCIDR",
+		cel.Overload("ip_in_cidr_string_string",
+			[]*cel.Type{cel.StringType, cel.StringType}, cel.BoolType,
+			cel.BinaryBinding(func(lhs, rhs ref.Val) ref.Val {
+				ipStr, ok := lhs.Value().(string)
+				if !ok {
+					return types.NewErr("ipInCIDR: first argument must be a string")
+				}
+				cidrStr, ok := rhs.Value().(string)
+				if !ok {
+					return types.NewErr("ipInCIDR: second argument must be a string")
+				}
+				ip := net.ParseIP(ipStr)
+				if ip == nil {
+					return types.NewErr("ipInCIDR: %q is not a valid IP address", ipStr)
+				}
+				_, network, err := net.ParseCIDR(cidrStr)
+				if err != nil {
+					return types.NewErr("ipInCIDR: %v", err)
+				}
+				return types.Bool(network.Contains(ip))
+			}),
+		),
+	)
+}
+
+// ipIsPrivateFunc registers "ipIsPrivate(ip) bool", true for RFC 1918/4193
+// private ranges as well as loopback and link-local addresses, since all
+// three are equally "not a public internet address" from a rule's
+// perspective.
+func ipIsPrivateFunc() cel.EnvOption {
+	return cel.Function("ipIsPrivate",
+		cel.Overload("ip_is_private_string",
+			[]*cel.Type{cel.StringType}, cel.BoolType,
+			cel.UnaryBinding(func(val ref.Val) ref.Val {
+				ipStr, ok := val.Value().(string)
+				if !ok {
+					return types.NewErr("ipIsPrivate: argument must be a string")
+				}
+				ip := net.ParseIP(ipStr)
+				if ip == nil {
+					return types.NewErr("ipIsPrivate: %q is not a valid IP address", ipStr)
+				}
+				return types.Bool(ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast())
+			}),
+		),
+	)
+}
+
+// GeoResolver resolves an IP address to its country, backing the geoCountry
+// CEL function. An Evaluator has none configured by default - geoCountry
+// returns a CEL error (flowing through each package's existing
+// error-handling path, same as any other evaluation error) rather than
+// silently returning an empty string, so a rule that depends on geo data
+// fails loudly until an operator wires one up with WithGeoResolver.
+type GeoResolver interface {
+	Country(ip string) (string, error)
+}
+
+// WithGeoResolver sets the resolver geoCountry calls, mutating and
+// returning e so it can be chained off NewEvaluatorWithCacheSize, following
+// the repo's existing With*-builder convention.
+func (e *Evaluator) WithGeoResolver(r GeoResolver) *Evaluator {
+	e.geoResolver = r
+	return e
+}
+
+func (e *Evaluator) geoCountryFunc() cel.EnvOption {
+	return cel.Function("geoCountry",
+		cel.Overload("geo_country_string",
+			[]*cel.Type{cel.StringType}, cel.StringType,
+			cel.UnaryBinding(func(val ref.Val) ref.Val {
+				ipStr, ok := val.Value().(string)
+				if !ok {
+					return types.NewErr("geoCountry: argument must be a string")
+				}
+				if e.geoResolver == nil {
+					return types.NewErr("geoCountry: no GeoResolver configured (see Evaluator.WithGeoResolver)")
+				}
+				country, err := e.geoResolver.Country(ipStr)
+				if err != nil {
+					return types.NewErr("geoCountry: %v", err)
+				}
+				return types.String(country)
+			}),
+		),
+	)
+}
+
+// jsonPointerFunc registers "jsonPointer(value, pointer) dyn", resolving an
+// RFC 6901 JSON Pointer (e.g. "/a/b/0") against value - typically payload,
+// metadata, or sourceData, each already a map[string]interface{}/
+// []interface{} tree from the source JSON.
+func jsonPointerFunc() cel.EnvOption {
+	return cel.Function("jsonPointer",
+		cel.Overload("json_pointer_dyn_string",
+			[]*cel.Type{cel.DynType, cel.StringType}, cel.DynType,
+			cel.BinaryBinding(func(lhs, rhs ref.Val) ref.Val {
+				pointer, ok := rhs.Value().(string)
+				if !ok {
+					return types.NewErr("jsonPointer: second argument must be a string")
+				}
+				val, found := resolveJSONPointer(lhs.Value(), pointer)
+				if !found {
+					return types.NewErr("jsonPointer: %q not found", pointer)
+				}
+				return types.DefaultTypeAdapter.NativeToValue(val)
+			}),
+		),
+	)
+}
+
+// resolveJSONPointer walks root - a decoded JSON value, i.e.
+// map[string]interface{}, []interface{}, or a scalar - per RFC 6901,
+// returning the value at pointer and whether it was found. pointer must be
+// "" (root itself) or start with "/", with "~1"/"~0" escaping "/" and "~"
+// within a token exactly as the RFC specifies.
+func resolveJSONPointer(root interface{}, pointer string) (interface{}, bool) {
+	if pointer == "" {
+		return root, true
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, false
+	}
+
+	unescape := strings.NewReplacer("~1", "/", "~0", "~")
+	cur := root
+	for _, tok := range strings.Split(pointer[1:], "/") {
+		tok = unescape.Replace(tok)
+		switch v := cur.(type) {
+		case map[string]interface{}:
+			val, ok := v[tok]
+			if !ok {
+				return nil, false
+			}
+			cur = val
+		case []interface{}:
+			idx, err := strconv.Atoi(tok)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil, false
+			}
+			cur = v[idx]
+		default:
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// sha256HexFunc registers "sha256Hex(str) string".
+func sha256HexFunc() cel.EnvOption {
+	return cel.Function("sha256Hex",
+		cel.Overload("sha256_hex_string",
+			[]*cel.Type{cel.StringType}, cel.StringType,
+			cel.UnaryBinding(func(val ref.Val) ref.Val {
+				str, ok := val.Value().(string)
+				if !ok {
+					return types.NewErr("sha256Hex: argument must be a string")
+				}
+				sum := sha256.Sum256([]byte(str))
+				return types.String(hex.EncodeToString(sum[:]))
+			}),
+		),
+	)
+}
+
+// md5HexFunc registers "md5Hex(str) string". MD5 is offered alongside
+// sha256Hex only for compatibility with upstream systems keyed by it (e.g.
+// legacy dedup hashes, see deduplication's own HashAlgorithm config) - not
+// recommended for anything security-sensitive.
+func md5HexFunc() cel.EnvOption {
+	return cel.Function("md5Hex",
+		cel.Overload("md5_hex_string",
+			[]*cel.Type{cel.StringType}, cel.StringType,
+			cel.UnaryBinding(func(val ref.Val) ref.Val {
+				str, ok := val.Value().(string)
+				if !ok {
+					return types.NewErr("md5Hex: argument must be a string")
+				}
+				sum := md5.Sum([]byte(str))
+				return types.String(hex.EncodeToString(sum[:]))
+			}),
+		),
+	)
+}
+
+// parseTimeFunc registers "parseTime(value, layout) timestamp", parsing
+// value with Go's reference-time layout (the same convention time.Parse and
+// the rest of the repo use, e.g. config's duration-string fields).
+func parseTimeFunc() cel.EnvOption {
+	return cel.Function("parseTime",
+		cel.Overload("parse_time_string_string",
+			[]*cel.Type{cel.StringType, cel.StringType}, cel.TimestampType,
+			cel.BinaryBinding(func(lhs, rhs ref.Val) ref.Val {
+				value, ok := lhs.Value().(string)
+				if !ok {
+					return types.NewErr("parseTime: first argument must be a string")
+				}
+				layout, ok := rhs.Value().(string)
+				if !ok {
+					return types.NewErr("parseTime: second argument must be a string")
+				}
+				t, err := time.Parse(layout, value)
+				if err != nil {
+					return types.NewErr("parseTime: %v", err)
+				}
+				return types.Timestamp{Time: t}
+			}),
+		),
+	)
+}
+
+// durationBetweenFunc registers "durationBetween(from, to) duration",
+// returning to - from (negative if to precedes from).
+func durationBetweenFunc() cel.EnvOption {
+	return cel.Function("durationBetween",
+		cel.Overload("duration_between_timestamp_timestamp",
+			[]*cel.Type{cel.TimestampType, cel.TimestampType}, cel.DurationType,
+			cel.BinaryBinding(func(lhs, rhs ref.Val) ref.Val {
+				from, ok := lhs.(types.Timestamp)
+				if !ok {
+					return types.NewErr("durationBetween: first argument must be a timestamp")
+				}
+				to, ok := rhs.(types.Timestamp)
+				if !ok {
+					return types.NewErr("durationBetween: second argument must be a timestamp")
+				}
+				return types.Duration{Duration: to.Sub(from.Time)}
+			}),
+		),
+	)
+}
+
+// base64EncodeFunc registers "base64Encode(str) string".
+func base64EncodeFunc() cel.EnvOption {
+	return cel.Function("base64Encode",
+		cel.Overload("base64_encode_string",
+			[]*cel.Type{cel.StringType}, cel.StringType,
+			cel.UnaryBinding(func(val ref.Val) ref.Val {
+				str, ok := val.Value().(string)
+				if !ok {
+					return types.NewErr("base64Encode: argument must be a string")
+				}
+				return types.String(base64.StdEncoding.EncodeToString([]byte(str)))
+			}),
+		),
+	)
+}
+
+// base64DecodeFunc registers "base64Decode(str) string".
+func base64DecodeFunc() cel.EnvOption {
+	return cel.Function("base64Decode",
+		cel.Overload("base64_decode_string",
+			[]*cel.Type{cel.StringType}, cel.StringType,
+			cel.UnaryBinding(func(val ref.Val) ref.Val {
+				str, ok := val.Value().(string)
+				if !ok {
+					return types.NewErr("base64Decode: argument must be a string")
+				}
+				decoded, err := base64.StdEncoding.DecodeString(str)
+				if err != nil {
+					return types.NewErr("base64Decode: %v", err)
+				}
+				return types.String(decoded)
+			}),
+		),
+	)
+}
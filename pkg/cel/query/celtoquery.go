@@ -0,0 +1,346 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	googlecel "github.com/google/cel-go/cel"
+	celast "github.com/google/cel-go/common/ast"
+
+	"yeti/internal/enrichment/provider"
+)
+
+// Backend names the enrichment source type a pushed-down provider.Query will
+// be evaluated against. CELToQuery's "safe subset" differs per backend:
+// MongoDBProvider passes Query.Filters straight through as a bson.M, so it
+// understands the full Mongo-style operator set (see database_provider.go),
+// but PostgreSQLProvider only ever builds an equality WHERE clause from
+// Filters today, and the Redis/cache provider doesn't consult Filters at
+// all, keying purely by KeyPattern (see cache_provider.go). Pushing a
+// richer predicate down to either of those would silently change behavior
+// (or, for Postgres, emit SQL that doesn't mean what the predicate says), so
+// CELToQuery narrows per backend rather than always emitting Mongo's full
+// operator set.
+type Backend string
+
+const (
+	BackendMongoDB    Backend = "mongodb"
+	BackendPostgreSQL Backend = "postgresql"
+	BackendRedis      Backend = "redis"
+)
+
+// pushableOps is the set of query operators CELToQuery will emit for a given
+// backend. Redis has no entry: the cache provider never consults
+// Query.Filters, so nothing is ever safe to push down to it.
+var pushableOps = map[Backend]map[string]bool{
+	BackendMongoDB:    {"$eq": true, "$ne": true, "$gt": true, "$gte": true, "$lt": true, "$lte": true, "$in": true, "$nin": true, "$exists": true},
+	BackendPostgreSQL: {"$eq": true},
+}
+
+var celToQueryOps = map[string]string{
+	"_==_": "$eq",
+	"_!=_": "$ne",
+	"_>_":  "$gt",
+	"_>=_": "$gte",
+	"_<_":  "$lt",
+	"_<=_": "$lte",
+}
+
+// CELToQuery walks celAST - typically the result of compiling a filtering
+// rule's Condition or an enrichment rule's Condition via
+// cel.Evaluator.CompileExpression/ValidateFilterExpression's underlying
+// env.Compile - and pushes the subset of it that's safe for backend down
+// into a Mongo-style provider.Query.Filters. It only recognizes a top-level
+// conjunction ("&&") of simple `payload.field <op> literal` comparisons,
+// `payload.field in [literals]`, and `has(payload.field)`; anything else -
+// disjunction, negation, a comparison against a non-literal, a function
+// this package doesn't recognize, or an operator pushableOps excludes for
+// backend - is left out of Filters and instead folded into the returned
+// residual CEL expression, which the caller must still evaluate in-process
+// (e.g. via cel.Evaluator.EvaluateFilter) against the fetched document, the
+// same way an enrichment rule's Condition already is.
+func CELToQuery(celAST *googlecel.Ast, backend Backend) (*provider.Query, string, error) {
+	if celAST == nil {
+		return nil, "", fmt.Errorf("cel/query: ast is nil")
+	}
+
+	nativeAST := celAST.NativeRep()
+	if nativeAST == nil {
+		return nil, "", fmt.Errorf("cel/query: ast has no native representation")
+	}
+
+	allowed := pushableOps[backend]
+	filters := make(map[string]interface{})
+	var residual []celast.Expr
+
+	for _, conjunct := range splitConjuncts(nativeAST.Expr()) {
+		if field, opKey, value, ok := asSimpleComparison(conjunct); ok && allowed[opKey] {
+			mergeFilter(filters, field, opKey, value)
+			continue
+		}
+		if field, list, ok := asInExpr(conjunct); ok && allowed["$in"] {
+			mergeFilter(filters, field, "$in", list)
+			continue
+		}
+		if field, ok := asHasExpr(conjunct); ok && allowed["$exists"] {
+			mergeFilter(filters, field, "$exists", true)
+			continue
+		}
+		residual = append(residual, conjunct)
+	}
+
+	residualCEL, err := unparseConjuncts(residual)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &provider.Query{Filters: filters}, residualCEL, nil
+}
+
+// splitConjuncts flattens a (possibly nested) chain of top-level "&&" calls
+// into its individual operands; an expression with no top-level "&&" is
+// returned as its own single-element slice.
+func splitConjuncts(expr celast.Expr) []celast.Expr {
+	if expr.Kind() != celast.CallKind {
+		return []celast.Expr{expr}
+	}
+	call := expr.AsCall()
+	if call.FunctionName() != "_&&_" {
+		return []celast.Expr{expr}
+	}
+
+	var out []celast.Expr
+	for _, arg := range call.Args() {
+		out = append(out, splitConjuncts(arg)...)
+	}
+	return out
+}
+
+// payloadFieldName returns field for a `payload.field` select expression
+// (the only shape a pushed-down predicate can take - a nested
+// `payload.a.b` doesn't correspond to any single provider.Query.Filters
+// key), and false for anything else.
+func payloadFieldName(expr celast.Expr) (string, bool) {
+	if expr.Kind() != celast.SelectKind {
+		return "", false
+	}
+	sel := expr.AsSelect()
+	if sel.IsTestOnly() {
+		return "", false
+	}
+	operand := sel.Operand()
+	if operand.Kind() != celast.IdentKind || operand.AsIdent() != "payload" {
+		return "", false
+	}
+	return sel.FieldName(), true
+}
+
+func literalValue(expr celast.Expr) (interface{}, bool) {
+	if expr.Kind() != celast.LiteralKind {
+		return nil, false
+	}
+	return expr.AsLiteral().Value(), true
+}
+
+// asSimpleComparison recognizes `payload.field <op> literal`, returning the
+// field, the Mongo-style operator celToQueryOps maps <op> to, and the
+// literal's Go value.
+func asSimpleComparison(expr celast.Expr) (field, opKey string, value interface{}, ok bool) {
+	if expr.Kind() != celast.CallKind {
+		return "", "", nil, false
+	}
+	call := expr.AsCall()
+	opKey, known := celToQueryOps[call.FunctionName()]
+	if !known || len(call.Args()) != 2 {
+		return "", "", nil, false
+	}
+
+	field, isField := payloadFieldName(call.Args()[0])
+	value, isLiteral := literalValue(call.Args()[1])
+	if !isField || !isLiteral {
+		return "", "", nil, false
+	}
+	return field, opKey, value, true
+}
+
+// asInExpr recognizes `payload.field in [literal, ...]`.
+func asInExpr(expr celast.Expr) (field string, values []interface{}, ok bool) {
+	if expr.Kind() != celast.CallKind {
+		return "", nil, false
+	}
+	call := expr.AsCall()
+	if call.FunctionName() != "@in" || len(call.Args()) != 2 {
+		return "", nil, false
+	}
+
+	field, isField := payloadFieldName(call.Args()[0])
+	if !isField {
+		return "", nil, false
+	}
+	listExpr := call.Args()[1]
+	if listExpr.Kind() != celast.ListKind {
+		return "", nil, false
+	}
+
+	elements := listExpr.AsList().Elements()
+	values = make([]interface{}, 0, len(elements))
+	for _, el := range elements {
+		value, isLiteral := literalValue(el)
+		if !isLiteral {
+			return "", nil, false
+		}
+		values = append(values, value)
+	}
+	return field, values, true
+}
+
+// asHasExpr recognizes `has(payload.field)`, the macro-expanded form of
+// which is a Select with IsTestOnly() set.
+func asHasExpr(expr celast.Expr) (field string, ok bool) {
+	if expr.Kind() != celast.SelectKind {
+		return "", false
+	}
+	sel := expr.AsSelect()
+	if !sel.IsTestOnly() {
+		return "", false
+	}
+	operand := sel.Operand()
+	if operand.Kind() != celast.IdentKind || operand.AsIdent() != "payload" {
+		return "", false
+	}
+	return sel.FieldName(), true
+}
+
+func mergeFilter(filters map[string]interface{}, field, opKey string, value interface{}) {
+	if opKey == "$eq" {
+		filters[field] = value
+		return
+	}
+
+	ops, ok := filters[field].(map[string]interface{})
+	if !ok {
+		ops = make(map[string]interface{})
+		filters[field] = ops
+	}
+	ops[opKey] = value
+}
+
+// unparseConjuncts rebuilds a CEL source string ANDing every residual
+// expression back together.
+func unparseConjuncts(residual []celast.Expr) (string, error) {
+	if len(residual) == 0 {
+		return "", nil
+	}
+
+	parts := make([]string, 0, len(residual))
+	for _, expr := range residual {
+		source, err := unparseExpr(expr)
+		if err != nil {
+			return "", fmt.Errorf("cel/query: failed to unparse residual expression: %w", err)
+		}
+		parts = append(parts, fmt.Sprintf("(%s)", source))
+	}
+	return strings.Join(parts, " && "), nil
+}
+
+// celInfixOps are the CEL binary call functions unparseExpr knows how to
+// render back as their original infix operator.
+var celInfixOps = map[string]string{
+	"_&&_": "&&", "_||_": "||",
+	"_==_": "==", "_!=_": "!=", "_>_": ">", "_>=_": ">=", "_<_": "<", "_<=_": "<=",
+	"_+_": "+", "_-_": "-", "_*_": "*", "_/_": "/", "_%_": "%",
+}
+
+// unparseExpr renders expr back to CEL source text. It covers the
+// expression shapes a filtering/enrichment condition actually uses - infix
+// operators, negation, the `in` operator, plain and method calls, selects,
+// lists, and literals/idents - rather than depending on cel-go's own
+// unparser, since residual (non-pushed-down) sub-expressions only ever come
+// from that same limited grammar.
+func unparseExpr(expr celast.Expr) (string, error) {
+	switch expr.Kind() {
+	case celast.LiteralKind:
+		return literalToCEL(expr.AsLiteral().Value()), nil
+	case celast.IdentKind:
+		return expr.AsIdent(), nil
+	case celast.SelectKind:
+		sel := expr.AsSelect()
+		operand, err := unparseExpr(sel.Operand())
+		if err != nil {
+			return "", err
+		}
+		if sel.IsTestOnly() {
+			return fmt.Sprintf("has(%s.%s)", operand, sel.FieldName()), nil
+		}
+		return fmt.Sprintf("%s.%s", operand, sel.FieldName()), nil
+	case celast.ListKind:
+		elements := expr.AsList().Elements()
+		parts := make([]string, len(elements))
+		for i, el := range elements {
+			part, err := unparseExpr(el)
+			if err != nil {
+				return "", err
+			}
+			parts[i] = part
+		}
+		return "[" + strings.Join(parts, ", ") + "]", nil
+	case celast.CallKind:
+		return unparseCall(expr.AsCall())
+	default:
+		return "", fmt.Errorf("cel/query: cannot unparse expression kind %v", expr.Kind())
+	}
+}
+
+func unparseCall(call celast.CallExpr) (string, error) {
+	fn := call.FunctionName()
+	args := call.Args()
+
+	if infix, ok := celInfixOps[fn]; ok && len(args) == 2 {
+		left, err := unparseExpr(args[0])
+		if err != nil {
+			return "", err
+		}
+		right, err := unparseExpr(args[1])
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("(%s %s %s)", left, infix, right), nil
+	}
+	if fn == "!_" && len(args) == 1 {
+		operand, err := unparseExpr(args[0])
+		if err != nil {
+			return "", err
+		}
+		return "!" + operand, nil
+	}
+	if fn == "@in" && len(args) == 2 {
+		left, err := unparseExpr(args[0])
+		if err != nil {
+			return "", err
+		}
+		right, err := unparseExpr(args[1])
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("(%s in %s)", left, right), nil
+	}
+
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		part, err := unparseExpr(arg)
+		if err != nil {
+			return "", err
+		}
+		parts[i] = part
+	}
+
+	target := call.Target()
+	if target != nil {
+		receiver, err := unparseExpr(target)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s.%s(%s)", receiver, fn, strings.Join(parts, ", ")), nil
+	}
+	return fmt.Sprintf("%s(%s)", fn, strings.Join(parts, ", ")), nil
+}
@@ -0,0 +1,199 @@
+// Package query translates between provider.Query's Mongo-style filter maps
+// and CEL boolean expressions over payload.*, so one authoring surface (CEL)
+// can drive both an enrichment source's pre-fetch filter pushdown and a
+// filtering rule's post-fetch condition, instead of operators being hand
+// written twice in two different shapes.
+package query
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"yeti/internal/enrichment/provider"
+)
+
+// queryToCELOps are the comparison operators QueryToCEL and CELToQuery agree
+// on, mapping a Mongo-style filter operator to its CEL infix form.
+var queryToCELOps = map[string]string{
+	"$eq":  "==",
+	"$ne":  "!=",
+	"$gt":  ">",
+	"$gte": ">=",
+	"$lt":  "<",
+	"$lte": "<=",
+}
+
+// QueryToCEL compiles q's Mongo-style Filters - $eq/$ne/$gt/$gte/$lt/$lte/
+// $in/$nin/$and/$or/$not/$exists/$regex - into an equivalent CEL boolean
+// expression over payload.*, so the same predicate a pre-fetch filter pushes
+// down to mongodb/postgresql/redis can also run through
+// cel.Evaluator.EvaluateFilter, e.g. as a filtering rule's Condition or as
+// the residual left over from CELToQuery. A nil Query, or one with no
+// Filters, compiles to the literal "true" - no predicate at all.
+func QueryToCEL(q *provider.Query) (string, error) {
+	if q == nil || len(q.Filters) == 0 {
+		return "true", nil
+	}
+	return filterMapToCEL(q.Filters)
+}
+
+func filterMapToCEL(filters map[string]interface{}) (string, error) {
+	keys := make([]string, 0, len(filters))
+	for k := range filters {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys) // deterministic output: same filters always compile to the same CEL text
+
+	clauses := make([]string, 0, len(keys))
+	for _, key := range keys {
+		clause, err := filterEntryToCEL(key, filters[key])
+		if err != nil {
+			return "", err
+		}
+		clauses = append(clauses, clause)
+	}
+	return joinCEL(clauses, "&&"), nil
+}
+
+func filterEntryToCEL(key string, value interface{}) (string, error) {
+	switch key {
+	case "$and":
+		return combinatorToCEL(value, "&&")
+	case "$or":
+		return combinatorToCEL(value, "||")
+	case "$not":
+		sub, ok := value.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("cel/query: $not requires a filter object, got %T", value)
+		}
+		inner, err := filterMapToCEL(sub)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("!(%s)", inner), nil
+	default:
+		return fieldToCEL(key, value)
+	}
+}
+
+func combinatorToCEL(value interface{}, op string) (string, error) {
+	items, ok := value.([]interface{})
+	if !ok {
+		return "", fmt.Errorf("cel/query: %s requires an array of filter objects, got %T", combinatorName(op), value)
+	}
+
+	clauses := make([]string, 0, len(items))
+	for _, item := range items {
+		sub, ok := item.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("cel/query: %s element must be a filter object, got %T", combinatorName(op), item)
+		}
+		clause, err := filterMapToCEL(sub)
+		if err != nil {
+			return "", err
+		}
+		clauses = append(clauses, fmt.Sprintf("(%s)", clause))
+	}
+	return joinCEL(clauses, op), nil
+}
+
+func combinatorName(op string) string {
+	if op == "&&" {
+		return "$and"
+	}
+	return "$or"
+}
+
+// fieldToCEL translates a single field's filter value - either a direct
+// equality value, or an operator object like {"$gt": 5, "$exists": true} -
+// into a CEL clause (or conjunction of clauses) against payload.<field>.
+func fieldToCEL(field string, value interface{}) (string, error) {
+	path := "payload." + field
+
+	ops, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Sprintf("%s == %s", path, literalToCEL(value)), nil
+	}
+
+	opKeys := make([]string, 0, len(ops))
+	for k := range ops {
+		opKeys = append(opKeys, k)
+	}
+	sort.Strings(opKeys)
+
+	clauses := make([]string, 0, len(opKeys))
+	for _, opKey := range opKeys {
+		clause, err := operatorToCEL(path, opKey, ops[opKey])
+		if err != nil {
+			return "", err
+		}
+		clauses = append(clauses, clause)
+	}
+	return joinCEL(clauses, "&&"), nil
+}
+
+func operatorToCEL(path, opKey string, opVal interface{}) (string, error) {
+	if infix, ok := queryToCELOps[opKey]; ok {
+		return fmt.Sprintf("%s %s %s", path, infix, literalToCEL(opVal)), nil
+	}
+
+	switch opKey {
+	case "$in":
+		list, err := literalListToCEL(opVal)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s in %s", path, list), nil
+	case "$nin":
+		list, err := literalListToCEL(opVal)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("!(%s in %s)", path, list), nil
+	case "$exists":
+		want, _ := opVal.(bool)
+		if want {
+			return fmt.Sprintf("has(%s)", path), nil
+		}
+		return fmt.Sprintf("!has(%s)", path), nil
+	case "$regex":
+		pattern, ok := opVal.(string)
+		if !ok {
+			return "", fmt.Errorf("cel/query: $regex requires a string pattern, got %T", opVal)
+		}
+		return fmt.Sprintf("matches(%s, %s)", path, literalToCEL(pattern)), nil
+	default:
+		return "", fmt.Errorf("cel/query: unsupported filter operator %q", opKey)
+	}
+}
+
+func literalListToCEL(value interface{}) (string, error) {
+	items, ok := value.([]interface{})
+	if !ok {
+		return "", fmt.Errorf("cel/query: expected an array, got %T", value)
+	}
+	parts := make([]string, len(items))
+	for i, item := range items {
+		parts[i] = literalToCEL(item)
+	}
+	return "[" + strings.Join(parts, ", ") + "]", nil
+}
+
+func literalToCEL(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return fmt.Sprintf("%q", v)
+	case bool:
+		return fmt.Sprintf("%t", v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func joinCEL(clauses []string, op string) string {
+	if len(clauses) == 0 {
+		return "true"
+	}
+	return strings.Join(clauses, fmt.Sprintf(" %s ", op))
+}
@@ -0,0 +1,134 @@
+package query
+
+import (
+	"testing"
+
+	googlecel "github.com/google/cel-go/cel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"yeti/internal/enrichment/provider"
+)
+
+func TestQueryToCEL(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   *provider.Query
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "nil query",
+			query: nil,
+			want:  "true",
+		},
+		{
+			name:  "no filters",
+			query: &provider.Query{},
+			want:  "true",
+		},
+		{
+			name:  "equality shorthand",
+			query: &provider.Query{Filters: map[string]interface{}{"status": "active"}},
+			want:  `payload.status == "active"`,
+		},
+		{
+			name: "comparison operators",
+			query: &provider.Query{Filters: map[string]interface{}{
+				"amount": map[string]interface{}{"$gte": 100},
+			}},
+			want: "payload.amount >= 100",
+		},
+		{
+			name: "in and exists",
+			query: &provider.Query{Filters: map[string]interface{}{
+				"tier":   map[string]interface{}{"$in": []interface{}{"gold", "platinum"}},
+				"region": map[string]interface{}{"$exists": true},
+			}},
+			want: `has(payload.region) && payload.tier in ["gold", "platinum"]`,
+		},
+		{
+			name: "not",
+			query: &provider.Query{Filters: map[string]interface{}{
+				"$not": map[string]interface{}{"status": "disabled"},
+			}},
+			want: `!(payload.status == "disabled")`,
+		},
+		{
+			name: "unsupported operator",
+			query: &provider.Query{Filters: map[string]interface{}{
+				"status": map[string]interface{}{"$bogus": 1},
+			}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := QueryToCEL(tt.query)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			if tt.name == "in and exists" {
+				assert.Contains(t, got, `has(payload.region)`)
+				assert.Contains(t, got, `payload.tier in ["gold", "platinum"]`)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func testEnv(t *testing.T) *googlecel.Env {
+	t.Helper()
+	env, err := googlecel.NewEnv(
+		googlecel.Variable("payload", googlecel.MapType(googlecel.StringType, googlecel.DynType)),
+	)
+	require.NoError(t, err)
+	return env
+}
+
+func compile(t *testing.T, expr string) *googlecel.Ast {
+	t.Helper()
+	ast, issues := testEnv(t).Compile(expr)
+	require.NoError(t, issues.Err())
+	return ast
+}
+
+func TestCELToQuery(t *testing.T) {
+	t.Run("pushes down a simple conjunction for mongodb", func(t *testing.T) {
+		ast := compile(t, `payload.status == "active" && payload.amount > 100`)
+		q, residual, err := CELToQuery(ast, BackendMongoDB)
+		require.NoError(t, err)
+		assert.Equal(t, "", residual)
+		assert.Equal(t, "active", q.Filters["status"])
+		assert.Equal(t, map[string]interface{}{"$gt": int64(100)}, q.Filters["amount"])
+	})
+
+	t.Run("narrows to equality-only for postgresql", func(t *testing.T) {
+		ast := compile(t, `payload.status == "active" && payload.amount > 100`)
+		q, residual, err := CELToQuery(ast, BackendPostgreSQL)
+		require.NoError(t, err)
+		assert.Equal(t, "active", q.Filters["status"])
+		assert.NotContains(t, q.Filters, "amount")
+		assert.Contains(t, residual, "payload.amount")
+	})
+
+	t.Run("pushes down nothing for redis", func(t *testing.T) {
+		ast := compile(t, `payload.status == "active"`)
+		q, residual, err := CELToQuery(ast, BackendRedis)
+		require.NoError(t, err)
+		assert.Empty(t, q.Filters)
+		assert.Contains(t, residual, "payload.status")
+	})
+
+	t.Run("disjunction is entirely residual", func(t *testing.T) {
+		ast := compile(t, `payload.status == "active" || payload.status == "pending"`)
+		q, residual, err := CELToQuery(ast, BackendMongoDB)
+		require.NoError(t, err)
+		assert.Empty(t, q.Filters)
+		assert.Contains(t, residual, "||")
+	})
+}
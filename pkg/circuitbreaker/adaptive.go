@@ -0,0 +1,299 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"yeti/pkg/metrics"
+)
+
+// ErrEndpointEjected is returned by AdaptiveWrapper.Execute instead of
+// calling fn when key is currently ejected.
+var ErrEndpointEjected = errors.New("circuit breaker: endpoint ejected")
+
+// AdaptiveConfig configures an AdaptiveWrapper.
+type AdaptiveConfig struct {
+	Name string
+
+	// BucketInterval and BucketCount size the rolling window each
+	// endpoint's stats are tracked over: BucketCount buckets of
+	// BucketInterval each, e.g. 10 buckets of 1s for a 10s window.
+	BucketInterval time.Duration
+	BucketCount    int
+
+	// MinRequestVolume is the minimum number of requests an endpoint must
+	// see within the window before ErrorRateThreshold/LatencyP99Deviation
+	// are evaluated, so a cold or lightly-used endpoint isn't ejected on
+	// one or two unlucky calls.
+	MinRequestVolume int
+	// ErrorRateThreshold ejects an endpoint once its error rate within
+	// the window reaches this fraction (0..1).
+	ErrorRateThreshold float64
+	// LatencyP99Deviation ejects an endpoint once its own p99 latency
+	// exceeds the pool's rolling baseline p99 (the median of every known
+	// endpoint's p99) by this multiple, catching an endpoint that's
+	// slow relative to its peers even if it isn't failing outright.
+	LatencyP99Deviation float64
+
+	// EjectionBaseTimeout is how long a first ejection lasts.
+	// EjectionMaxTimeout caps the exponential backoff applied to an
+	// endpoint ejected repeatedly in a row.
+	EjectionBaseTimeout time.Duration
+	EjectionMaxTimeout  time.Duration
+}
+
+// DefaultAdaptiveConfig returns sane defaults: a 10s rolling window (10
+// buckets of 1s), a 10-request minimum volume, a 50% error rate or 3x
+// latency deviation trip threshold, and ejections starting at 30s and
+// backing off up to 10 minutes.
+func DefaultAdaptiveConfig(name string) AdaptiveConfig {
+	return AdaptiveConfig{
+		Name:                name,
+		BucketInterval:      time.Second,
+		BucketCount:         10,
+		MinRequestVolume:    10,
+		ErrorRateThreshold:  0.5,
+		LatencyP99Deviation: 3.0,
+		EjectionBaseTimeout: 30 * time.Second,
+		EjectionMaxTimeout:  10 * time.Minute,
+	}
+}
+
+// bucket is one BucketInterval-wide slice of an endpoint's rolling window.
+type bucket struct {
+	start     time.Time
+	successes int
+	failures  int
+	latencies []time.Duration
+}
+
+// endpointWindow is the rolling stats and ejection state AdaptiveWrapper
+// keeps for one endpoint key (e.g. one Redis node, one Mongo replica, one
+// HTTP host in a pool of otherwise-interchangeable upstreams).
+type endpointWindow struct {
+	mu      sync.Mutex
+	buckets []bucket
+
+	ejectedUntil time.Time
+	ejections    int
+}
+
+// record appends one call's outcome into the window, evicting buckets
+// older than cfg.BucketCount*cfg.BucketInterval.
+func (e *endpointWindow) record(cfg AdaptiveConfig, at time.Time, success bool, latency time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	bucketStart := at.Truncate(cfg.BucketInterval)
+	if n := len(e.buckets); n == 0 || !e.buckets[n-1].start.Equal(bucketStart) {
+		e.buckets = append(e.buckets, bucket{start: bucketStart})
+	}
+
+	cur := &e.buckets[len(e.buckets)-1]
+	if success {
+		cur.successes++
+	} else {
+		cur.failures++
+	}
+	cur.latencies = append(cur.latencies, latency)
+
+	cutoff := bucketStart.Add(-time.Duration(cfg.BucketCount) * cfg.BucketInterval)
+	i := 0
+	for i < len(e.buckets) && e.buckets[i].start.Before(cutoff) {
+		i++
+	}
+	if i > 0 {
+		e.buckets = e.buckets[i:]
+	}
+}
+
+// snapshot summarizes the window's current error rate and p99 latency,
+// along with how many requests it's based on.
+func (e *endpointWindow) snapshot() (requests int, errorRate float64, p99 time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var successes, failures int
+	var latencies []time.Duration
+	for _, b := range e.buckets {
+		successes += b.successes
+		failures += b.failures
+		latencies = append(latencies, b.latencies...)
+	}
+
+	requests = successes + failures
+	if requests > 0 {
+		errorRate = float64(failures) / float64(requests)
+	}
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		p99 = latencies[int(0.99*float64(len(latencies)-1))]
+	}
+	return requests, errorRate, p99
+}
+
+// isEjected reports whether the endpoint is currently ejected, and if so
+// how much longer the ejection has to run.
+func (e *endpointWindow) isEjected(now time.Time) (bool, time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if now.Before(e.ejectedUntil) {
+		return true, e.ejectedUntil.Sub(now)
+	}
+	return false, 0
+}
+
+// eject ejects the endpoint starting at now, for EjectionBaseTimeout
+// doubled for each ejection already served back-to-back (reset once the
+// endpoint has gone a full rolling window past its last ejection without
+// being ejected again), capped at EjectionMaxTimeout.
+func (e *endpointWindow) eject(cfg AdaptiveConfig, now time.Time) time.Duration {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	window := time.Duration(cfg.BucketCount) * cfg.BucketInterval
+	if e.ejectedUntil.IsZero() || now.Sub(e.ejectedUntil) > window {
+		// The previous ejection (if any) expired more than a window ago:
+		// this is a fresh strike, not a repeat offense, so don't compound
+		// the backoff.
+		e.ejections = 0
+	}
+	e.ejections++
+
+	timeout := cfg.EjectionBaseTimeout << uint(e.ejections-1)
+	if cfg.EjectionMaxTimeout > 0 && timeout > cfg.EjectionMaxTimeout {
+		timeout = cfg.EjectionMaxTimeout
+	}
+
+	e.ejectedUntil = now.Add(timeout)
+	return timeout
+}
+
+// AdaptiveWrapper is a circuit breaker over a pool of same-role endpoints
+// (e.g. the members of a Redis cluster or Mongo replica set), tracking
+// each endpoint's own rolling error rate and p99 latency rather than
+// sharing one counter-based breaker across the whole pool. An endpoint
+// whose error rate or latency deviates past cfg's thresholds is "ejected"
+// — routed around — for a backed-off timeout instead of tripping every
+// other endpoint's availability too.
+type AdaptiveWrapper struct {
+	cfg AdaptiveConfig
+
+	mu        sync.Mutex
+	endpoints map[string]*endpointWindow
+}
+
+func NewAdaptiveWrapper(cfg AdaptiveConfig) *AdaptiveWrapper {
+	if cfg.BucketInterval <= 0 {
+		cfg.BucketInterval = time.Second
+	}
+	if cfg.BucketCount <= 0 {
+		cfg.BucketCount = 10
+	}
+	return &AdaptiveWrapper{
+		cfg:       cfg,
+		endpoints: make(map[string]*endpointWindow),
+	}
+}
+
+func (w *AdaptiveWrapper) windowFor(key string) *endpointWindow {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	e, ok := w.endpoints[key]
+	if !ok {
+		e = &endpointWindow{}
+		w.endpoints[key] = e
+	}
+	return e
+}
+
+// baselineP99 returns the median p99 latency across every endpoint this
+// wrapper has seen, key's own window included, as the peer baseline
+// LatencyP99Deviation compares key's p99 against.
+func (w *AdaptiveWrapper) baselineP99() time.Duration {
+	w.mu.Lock()
+	windows := make([]*endpointWindow, 0, len(w.endpoints))
+	for _, e := range w.endpoints {
+		windows = append(windows, e)
+	}
+	w.mu.Unlock()
+
+	p99s := make([]time.Duration, 0, len(windows))
+	for _, e := range windows {
+		if requests, _, p99 := e.snapshot(); requests > 0 {
+			p99s = append(p99s, p99)
+		}
+	}
+	if len(p99s) == 0 {
+		return 0
+	}
+
+	sort.Slice(p99s, func(i, j int) bool { return p99s[i] < p99s[j] })
+	return p99s[len(p99s)/2]
+}
+
+// ejectedCount reports how many endpoints are ejected right now.
+func (w *AdaptiveWrapper) ejectedCount(now time.Time) int {
+	w.mu.Lock()
+	windows := make([]*endpointWindow, 0, len(w.endpoints))
+	for _, e := range w.endpoints {
+		windows = append(windows, e)
+	}
+	w.mu.Unlock()
+
+	count := 0
+	for _, e := range windows {
+		if ejected, _ := e.isEjected(now); ejected {
+			count++
+		}
+	}
+	return count
+}
+
+// Execute routes around key if it's currently ejected, otherwise runs fn,
+// records its outcome into key's rolling window, and ejects key if the
+// window now exceeds cfg's error-rate or latency-deviation thresholds.
+func (w *AdaptiveWrapper) Execute(ctx context.Context, key string, fn func() (interface{}, error)) (interface{}, error) {
+	e := w.windowFor(key)
+	now := time.Now()
+
+	if ejected, retryAfter := e.isEjected(now); ejected {
+		metrics.SetCircuitBreakerEjectedHosts(w.cfg.Name, w.ejectedCount(now))
+		return nil, fmt.Errorf("%w: %s (retry after %s)", ErrEndpointEjected, key, retryAfter)
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	start := time.Now()
+	result, err := fn()
+	latency := time.Since(start)
+
+	e.record(w.cfg, start, err == nil, latency)
+
+	requests, errorRate, p99 := e.snapshot()
+	metrics.SetCircuitBreakerBucketErrorRate(w.cfg.Name, key, errorRate)
+	metrics.SetCircuitBreakerLatencyP99(w.cfg.Name, key, p99)
+
+	if requests >= w.cfg.MinRequestVolume {
+		baseline := w.baselineP99()
+		overErrorRate := w.cfg.ErrorRateThreshold > 0 && errorRate >= w.cfg.ErrorRateThreshold
+		overLatency := w.cfg.LatencyP99Deviation > 0 && baseline > 0 && float64(p99) >= w.cfg.LatencyP99Deviation*float64(baseline)
+
+		if overErrorRate || overLatency {
+			e.eject(w.cfg, now)
+			metrics.SetCircuitBreakerEjectedHosts(w.cfg.Name, w.ejectedCount(now))
+		}
+	}
+
+	return result, err
+}
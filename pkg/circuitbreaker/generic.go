@@ -0,0 +1,42 @@
+package circuitbreaker
+
+import (
+	"context"
+	"fmt"
+)
+
+// Do executes fn through w and type-asserts the result to T, saving callers
+// from the interface{} cast boilerplate that Execute/ExecuteWithContext
+// otherwise require. Go does not allow generic methods, so this is a
+// package-level function taking the wrapper rather than a method on Wrapper.
+func Do[T any](ctx context.Context, w *Wrapper, fn func() (T, error)) (T, error) {
+	result, err := w.ExecuteWithContext(ctx, func() (interface{}, error) {
+		return fn()
+	})
+
+	w.RecordRequest(err == nil)
+
+	var zero T
+	if err != nil {
+		return zero, err
+	}
+
+	typed, ok := result.(T)
+	if !ok {
+		return zero, fmt.Errorf("circuit breaker %s: unexpected result type", w.Name())
+	}
+
+	return typed, nil
+}
+
+// DoWithFallback behaves like Do, but if the breaker is open when fn fails,
+// onOpen is called instead of surfacing the raw circuit-open error. This lets
+// callers degrade to a cached or last-known-good value rather than failing
+// outright. onOpen may be nil, in which case DoWithFallback behaves like Do.
+func DoWithFallback[T any](ctx context.Context, w *Wrapper, fn func() (T, error), onOpen func(ctx context.Context) (T, error)) (T, error) {
+	result, err := Do(ctx, w, fn)
+	if err != nil && onOpen != nil && w.IsOpen() {
+		return onOpen(ctx)
+	}
+	return result, err
+}
@@ -0,0 +1,231 @@
+// Package cuckoo implements a minimal, dependency-free Cuckoo filter: a
+// fixed-capacity table of buckets, each holding a handful of short
+// fingerprints, supporting Insert/Lookup/Delete with a bounded
+// false-positive rate. Unlike pkg/bloom's Filter, a Cuckoo filter's memory
+// is bounded by capacity rather than growing less accurate forever past
+// the item count it was sized for: once every candidate bucket and every
+// relocation attempt is exhausted, Insert reports the filter full instead
+// of silently degrading.
+package cuckoo
+
+import (
+	"hash/fnv"
+	"math"
+	"math/bits"
+	"math/rand"
+)
+
+const (
+	// bucketSize is b in the standard Cuckoo filter formulation: how many
+	// fingerprints each bucket holds before an Insert has to evict one to
+	// make room.
+	bucketSize = 4
+	// maxKicks bounds how many times Insert relocates an existing
+	// fingerprint to its alternate bucket before giving up and reporting
+	// the filter full, matching the ~500 the original Cuckoo filter paper
+	// uses.
+	maxKicks = 500
+	// targetLoadFactor is the occupancy Insert's eviction chains are sized
+	// to reach before they start getting long, the standard rule of thumb
+	// for b=4 buckets.
+	targetLoadFactor = 0.95
+)
+
+// fingerprint is a short, non-zero digest of an item stored in a bucket in
+// place of the item itself; zero marks an empty slot. A single byte keeps
+// Filter's Redis-packed counterpart (see deduplication.CuckooRepository)
+// simple to read/write a byte at a time, at the cost of a fixed
+// false-positive rate - see FalsePositiveRate.
+type fingerprint = byte
+
+type bucket [bucketSize]fingerprint
+
+// Filter is a fixed-capacity Cuckoo filter sized for capacity items.
+// targetFalsePositiveRate is accepted for forward compatibility with a
+// wider fingerprint in a future revision, but today's single-byte
+// fingerprint fixes the false-positive rate at FalsePositiveRate()
+// regardless of what's requested here.
+type Filter struct {
+	buckets []bucket
+	m       uint64 // number of buckets, always a power of two
+	count   uint64
+}
+
+// New sizes a Filter for capacity items, choosing a power-of-two bucket
+// count (so the alternate-index XOR in altIndex stays a cheap bitmask)
+// large enough that capacity items fit at targetLoadFactor before
+// eviction chains get too long.
+func New(capacity uint64, targetFalsePositiveRate float64) *Filter {
+	return &Filter{
+		buckets: make([]bucket, Size(capacity)),
+		m:       Size(capacity),
+	}
+}
+
+// Size computes the bucket count New sizes a Filter with, for callers that
+// need to size external storage (e.g. deduplication.CuckooRepository's
+// Redis-packed bucket table) the same way.
+func Size(capacity uint64) uint64 {
+	if capacity == 0 {
+		capacity = 1
+	}
+	numBuckets := uint64(math.Ceil(float64(capacity) / (bucketSize * targetLoadFactor)))
+	if numBuckets == 0 {
+		numBuckets = 1
+	}
+	return nextPow2(numBuckets)
+}
+
+// BucketSize returns b, the number of fingerprint slots per bucket.
+func BucketSize() int { return bucketSize }
+
+// MaxKicks returns the eviction-chain length Insert gives up after.
+func MaxKicks() int { return maxKicks }
+
+// FalsePositiveRate returns the filter's false-positive rate, fixed by its
+// single-byte fingerprint and bucket size per the Cuckoo filter paper's
+// bound of approximately 2*b/2^fingerprintBits, independent of load
+// factor (unlike a Bloom filter, whose rate climbs as more items are
+// added).
+func FalsePositiveRate() float64 {
+	return 2 * float64(bucketSize) / 256
+}
+
+func nextPow2(n uint64) uint64 {
+	if n <= 1 {
+		return 1
+	}
+	return 1 << bits.Len64(n-1)
+}
+
+// hash64 is the single hash Locate/altIndex derive both an item's primary
+// bucket and its fingerprint from.
+func hash64(data []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(data)
+	return h.Sum64()
+}
+
+// Locate returns data's primary bucket index i1 (< m) and fingerprint,
+// exactly as Filter.Insert/Lookup compute them internally, for callers
+// maintaining an external bucket table (e.g. CuckooRepository's
+// Redis-packed one) instead of Filter's in-process one.
+func Locate(data []byte, m uint64) (i1 uint64, fp fingerprint) {
+	h := hash64(data)
+	i1 = h % m
+	fp = fingerprint(h >> 32)
+	if fp == 0 {
+		fp = 1
+	}
+	return i1, fp
+}
+
+// altIndex returns the bucket an entry currently at i (holding fp) should
+// relocate to, per the Cuckoo filter identity i2 = i1 XOR hash(fp) - which
+// also makes altIndex its own inverse: altIndex(altIndex(i, fp), fp) == i.
+func altIndex(i uint64, fp fingerprint, m uint64) uint64 {
+	return (i ^ hash64([]byte{fp})) % m
+}
+
+func (f *Filter) insertAt(i uint64, fp fingerprint) bool {
+	b := &f.buckets[i]
+	for slot := range b {
+		if b[slot] == 0 {
+			b[slot] = fp
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Filter) bucketHas(i uint64, fp fingerprint) bool {
+	b := &f.buckets[i]
+	for _, slot := range b {
+		if slot == fp {
+			return true
+		}
+	}
+	return false
+}
+
+// Insert records data as present, relocating existing entries (up to
+// MaxKicks times) to make room if both of data's candidate buckets are
+// already full. It reports false if the filter is full - the caller's
+// cue to fall back to an authoritative store (e.g.
+// deduplication.CuckooRepository falls back to its inner Repository).
+func (f *Filter) Insert(data []byte) bool {
+	i1, fp := Locate(data, f.m)
+	i2 := altIndex(i1, fp, f.m)
+
+	if f.insertAt(i1, fp) || f.insertAt(i2, fp) {
+		f.count++
+		return true
+	}
+
+	i := i1
+	if rand.Intn(2) == 1 {
+		i = i2
+	}
+	for kick := 0; kick < maxKicks; kick++ {
+		slot := rand.Intn(bucketSize)
+		evicted := f.buckets[i][slot]
+		f.buckets[i][slot] = fp
+		fp = evicted
+		i = altIndex(i, fp, f.m)
+		if f.insertAt(i, fp) {
+			f.count++
+			return true
+		}
+	}
+	return false
+}
+
+// Lookup reports whether data is possibly present (true) or definitely
+// absent (false). Like a Bloom filter, a Cuckoo filter never
+// false-negatives an item Insert actually recorded.
+func (f *Filter) Lookup(data []byte) bool {
+	i1, fp := Locate(data, f.m)
+	i2 := altIndex(i1, fp, f.m)
+	return f.bucketHas(i1, fp) || f.bucketHas(i2, fp)
+}
+
+// Delete removes data, reporting whether it was found. This is the one
+// operation a Bloom filter can't offer at all: because a Cuckoo filter
+// stores an actual (if short) fingerprint per item rather than only ever
+// setting bits, removing one doesn't risk un-setting a bit another item
+// still relies on.
+func (f *Filter) Delete(data []byte) bool {
+	i1, fp := Locate(data, f.m)
+	i2 := altIndex(i1, fp, f.m)
+
+	if f.deleteAt(i1, fp) || f.deleteAt(i2, fp) {
+		f.count--
+		return true
+	}
+	return false
+}
+
+func (f *Filter) deleteAt(i uint64, fp fingerprint) bool {
+	b := &f.buckets[i]
+	for slot := range b {
+		if b[slot] == fp {
+			b[slot] = 0
+			return true
+		}
+	}
+	return false
+}
+
+// LoadFactor reports the fraction of slots currently occupied - the same
+// quantity that makes Insert's eviction chains longer as it approaches 1,
+// and the basis for an operator warning before Insert starts failing.
+func (f *Filter) LoadFactor() float64 {
+	return float64(f.count) / float64(f.m*bucketSize)
+}
+
+// Capacity reports the total number of fingerprint slots across every
+// bucket - the denominator LoadFactor divides by - for a caller that wants
+// the raw size alongside the ratio.
+func (f *Filter) Capacity() uint64 {
+	return f.m * bucketSize
+}
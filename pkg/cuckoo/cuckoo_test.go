@@ -0,0 +1,56 @@
+package cuckoo
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterNeverFalseNegative(t *testing.T) {
+	f := New(1000, 0.01)
+
+	for i := 0; i < 1000; i++ {
+		assert.True(t, f.Insert([]byte(fmt.Sprintf("key-%d", i))), "insert %d failed", i)
+	}
+
+	for i := 0; i < 1000; i++ {
+		assert.True(t, f.Lookup([]byte(fmt.Sprintf("key-%d", i))), "inserted key reported absent")
+	}
+}
+
+func TestFilterEmptyReportsAbsent(t *testing.T) {
+	f := New(100, 0.01)
+	assert.False(t, f.Lookup([]byte("anything")))
+}
+
+func TestFilterDeleteForgetsItem(t *testing.T) {
+	f := New(100, 0.01)
+	key := []byte("key-1")
+
+	assert.True(t, f.Insert(key))
+	assert.True(t, f.Lookup(key))
+
+	assert.True(t, f.Delete(key))
+	assert.False(t, f.Lookup(key))
+}
+
+func TestFilterDeleteMissingReportsFalse(t *testing.T) {
+	f := New(100, 0.01)
+	assert.False(t, f.Delete([]byte("never-inserted")))
+}
+
+func TestFilterReportsFullOnceCapacityExceeded(t *testing.T) {
+	f := New(100, 0.01)
+
+	inserted := 0
+	for i := 0; i < 100000; i++ {
+		if !f.Insert([]byte(fmt.Sprintf("key-%d", i))) {
+			break
+		}
+		inserted++
+	}
+
+	assert.Less(t, inserted, 100000, "filter never reported full")
+	assert.Greater(t, f.LoadFactor(), 0.5, "filter gave up well before reaching a reasonable load factor")
+}
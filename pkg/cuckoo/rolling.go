@@ -0,0 +1,128 @@
+package cuckoo
+
+import (
+	"sync"
+	"time"
+)
+
+// RollingParams sizes a RollingFilter's underlying Filter (see New) and
+// controls how often it rotates.
+type RollingParams struct {
+	// Capacity and FalsePositiveRate size each of the two underlying
+	// Filters exactly as New does.
+	Capacity          uint64
+	FalsePositiveRate float64
+	// RotationInterval is how long a Filter serves as the "active"
+	// generation before RollingFilter starts a fresh one and retires the
+	// old one to "previous". Two windows of RotationInterval each bound
+	// how stale a membership result can be at exactly
+	// 2*RotationInterval, matching ttl-based expiry semantics elsewhere
+	// in this package's callers (e.g. a Redis key with the same ttl).
+	RotationInterval time.Duration
+}
+
+// Stats reports a RollingFilter's current saturation: the active
+// generation's load factor and the filter's fixed false-positive rate,
+// plus when the active generation started, for an operator dashboard or a
+// management API warning once it's close to full.
+type Stats struct {
+	LoadFactor             float64
+	EstimatedFalsePositive float64
+	ActiveSince            time.Time
+	// Capacity is the active Filter's total fingerprint slot count (see
+	// Filter.Capacity), the denominator LoadFactor is computed against.
+	Capacity uint64
+}
+
+// RollingFilter is a Cuckoo filter sized for a bounded membership window
+// rather than a fixed item count: it keeps two Filters ("active" and
+// "previous") and rotates every RotationInterval, discarding "previous"
+// and demoting "active" into its place. Test checks both windows (so an
+// item added anywhere in the last up to 2*RotationInterval is still
+// found); Add only ever writes to "active". This keeps memory bounded
+// under sustained traffic, and - since a Cuckoo filter's Insert can fail
+// outright once full, unlike a Bloom filter's Add - keeps each
+// generation's load factor bounded too, instead of one Filter degrading
+// forever past the item count it was sized for.
+type RollingFilter struct {
+	mu          sync.RWMutex
+	active      *Filter
+	previous    *Filter
+	params      RollingParams
+	rotateAt    time.Time
+	activeSince time.Time
+}
+
+// NewRolling returns a RollingFilter with both windows freshly sized per
+// params, rotating for the first time after params.RotationInterval.
+func NewRolling(params RollingParams) *RollingFilter {
+	if params.RotationInterval <= 0 {
+		params.RotationInterval = time.Hour
+	}
+	now := time.Now()
+	return &RollingFilter{
+		active:      New(params.Capacity, params.FalsePositiveRate),
+		previous:    New(params.Capacity, params.FalsePositiveRate),
+		params:      params,
+		rotateAt:    now.Add(params.RotationInterval),
+		activeSince: now,
+	}
+}
+
+func (r *RollingFilter) maybeRotate() {
+	r.mu.RLock()
+	due := time.Now().After(r.rotateAt)
+	r.mu.RUnlock()
+	if !due {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !time.Now().After(r.rotateAt) {
+		return // another goroutine already rotated
+	}
+	r.previous = r.active
+	r.active = New(r.params.Capacity, r.params.FalsePositiveRate)
+	now := time.Now()
+	r.rotateAt = now.Add(r.params.RotationInterval)
+	r.activeSince = now
+}
+
+// Test reports whether key is possibly present in either window (true) or
+// definitely absent from both (false).
+func (r *RollingFilter) Test(key string) bool {
+	r.maybeRotate()
+
+	data := []byte(key)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.active.Lookup(data) || r.previous.Lookup(data)
+}
+
+// Add records key as present in the active window, reporting false if the
+// active Filter is full (see Filter.Insert) - the caller's cue that the
+// fast path can't vouch for this key and should fall back to an
+// authoritative store.
+func (r *RollingFilter) Add(key string) bool {
+	r.maybeRotate()
+
+	data := []byte(key)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.active.Insert(data)
+}
+
+// Stats reports the active generation's current saturation.
+func (r *RollingFilter) Stats() Stats {
+	r.maybeRotate()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return Stats{
+		LoadFactor:             r.active.LoadFactor(),
+		EstimatedFalsePositive: FalsePositiveRate(),
+		ActiveSince:            r.activeSince,
+		Capacity:               r.active.Capacity(),
+	}
+}
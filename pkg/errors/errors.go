@@ -7,14 +7,15 @@ import (
 )
 
 var (
-	ErrNotFound           = NewError("NOT_FOUND", "resource not found", http.StatusNotFound)
-	ErrValidation         = NewError("VALIDATION_ERROR", "validation failed", http.StatusBadRequest)
-	ErrInternal           = NewError("INTERNAL_ERROR", "internal server error", http.StatusInternalServerError)
-	ErrConflict           = NewError("CONFLICT", "resource conflict", http.StatusConflict)
-	ErrUnauthorized       = NewError("UNAUTHORIZED", "unauthorized", http.StatusUnauthorized)
-	ErrForbidden          = NewError("FORBIDDEN", "forbidden", http.StatusForbidden)
-	ErrTimeout            = NewError("TIMEOUT", "operation timed out", http.StatusRequestTimeout)
-	ErrServiceUnavailable = NewError("SERVICE_UNAVAILABLE", "service unavailable", http.StatusServiceUnavailable)
+	ErrNotFound            = NewError("NOT_FOUND", "resource not found", http.StatusNotFound)
+	ErrValidation          = NewError("VALIDATION_ERROR", "validation failed", http.StatusBadRequest)
+	ErrInternal            = NewError("INTERNAL_ERROR", "internal server error", http.StatusInternalServerError)
+	ErrConflict            = NewError("CONFLICT", "resource conflict", http.StatusConflict)
+	ErrUnauthorized        = NewError("UNAUTHORIZED", "unauthorized", http.StatusUnauthorized)
+	ErrForbidden           = NewError("FORBIDDEN", "forbidden", http.StatusForbidden)
+	ErrTimeout             = NewError("TIMEOUT", "operation timed out", http.StatusRequestTimeout)
+	ErrServiceUnavailable  = NewError("SERVICE_UNAVAILABLE", "service unavailable", http.StatusServiceUnavailable)
+	ErrUnprocessableEntity = NewError("UNPROCESSABLE_ENTITY", "unprocessable entity", http.StatusUnprocessableEntity)
 )
 
 type RetryableError interface {
@@ -170,6 +171,35 @@ func ToHTTPStatus(err error) int {
 	return http.StatusInternalServerError
 }
 
+// ClassOf returns a short, stable label for the kind of failure err
+// represents, suitable for an ErrorRecord.ErrorClass or a metric label: the
+// Code of the nearest *Error in err's chain, or "UNKNOWN_ERROR" if err
+// never passed through this package.
+func ClassOf(err error) string {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Code
+	}
+	return "UNKNOWN_ERROR"
+}
+
+// RuleIDOf returns the "rule_id" detail of the nearest *Error in err's
+// chain, or "" if err isn't one of ours or never had one attached. Rule
+// evaluation code can call WithDetail("rule_id", id) on an error before
+// returning it so that a generic consumer - one with no concept of rules
+// itself, like the broker package's DLQ path - can still surface which
+// rule was responsible for the failure.
+func RuleIDOf(err error) string {
+	var appErr *Error
+	if !errors.As(err, &appErr) {
+		return ""
+	}
+	if id, ok := appErr.Details["rule_id"].(string); ok {
+		return id
+	}
+	return ""
+}
+
 func ToErrorResponse(err error) map[string]interface{} {
 	var appErr *Error
 	if !errors.As(err, &appErr) {
@@ -0,0 +1,88 @@
+package errors
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// problemTypeBase prefixes every generated "type" URI. It doesn't need to
+// resolve to anything; RFC 7807 only requires it be a stable identifier.
+const problemTypeBase = "https://yeti-io.dev/problems/"
+
+// problemTypes maps an Error.Code to the RFC 7807 "type" member. A code
+// without an entry falls back to problemTypeBase + "internal-error".
+var problemTypes = map[string]string{
+	ErrNotFound.Code:            problemTypeBase + "not-found",
+	ErrValidation.Code:          problemTypeBase + "validation-error",
+	ErrInternal.Code:            problemTypeBase + "internal-error",
+	ErrConflict.Code:            problemTypeBase + "conflict",
+	ErrUnauthorized.Code:        problemTypeBase + "unauthorized",
+	ErrForbidden.Code:           problemTypeBase + "forbidden",
+	ErrTimeout.Code:             problemTypeBase + "timeout",
+	ErrServiceUnavailable.Code:  problemTypeBase + "service-unavailable",
+	ErrUnprocessableEntity.Code: problemTypeBase + "unprocessable-entity",
+}
+
+// ProblemDetails is an RFC 7807 "problem detail" body.
+type ProblemDetails struct {
+	Type     string                 `json:"type"`
+	Title    string                 `json:"title"`
+	Status   int                    `json:"status"`
+	Detail   string                 `json:"detail,omitempty"`
+	Instance string                 `json:"instance,omitempty"`
+	Extra    map[string]interface{} `json:"-"`
+}
+
+// MarshalJSON flattens Extra alongside the fixed RFC 7807 members, matching
+// how "additional members" are expected to appear on the top-level object.
+func (p ProblemDetails) MarshalJSON() ([]byte, error) {
+	fields := make(map[string]interface{}, len(p.Extra)+5)
+	for k, v := range p.Extra {
+		fields[k] = v
+	}
+	fields["type"] = p.Type
+	fields["title"] = p.Title
+	fields["status"] = p.Status
+	if p.Detail != "" {
+		fields["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		fields["instance"] = p.Instance
+	}
+	return json.Marshal(fields)
+}
+
+// ToProblemDetails converts err into an RFC 7807 problem, using instance
+// (typically the current request's trace ID) as the "instance" member.
+func ToProblemDetails(err error, instance string) ProblemDetails {
+	var appErr *Error
+	if !errors.As(err, &appErr) {
+		appErr = ErrInternal.WithCause(err)
+	}
+
+	problemType, ok := problemTypes[appErr.Code]
+	if !ok {
+		problemType = problemTypes[ErrInternal.Code]
+	}
+
+	return ProblemDetails{
+		Type:     problemType,
+		Title:    appErr.Code,
+		Status:   appErr.Status,
+		Detail:   appErr.Error(),
+		Instance: instance,
+		Extra:    appErr.Details,
+	}
+}
+
+// WriteHTTPError writes err to w as an RFC 7807 application/problem+json
+// response. instance is carried through as the problem's "instance" member,
+// typically the current request's trace ID.
+func WriteHTTPError(w http.ResponseWriter, err error, instance string) {
+	problem := ToProblemDetails(err, instance)
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(problem.Status)
+	_ = json.NewEncoder(w).Encode(problem)
+}
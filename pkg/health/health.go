@@ -4,10 +4,15 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
+	kafka "github.com/segmentio/kafka-go"
 	"go.mongodb.org/mongo-driver/mongo"
+
+	"yeti/internal/broker"
+	"yeti/pkg/supervisor"
 )
 
 type Status string
@@ -18,8 +23,13 @@ const (
 	StatusUnhealthy Status = "unhealthy"
 )
 
+// Checker reports its own health. Check returns StatusUnhealthy with a
+// non-nil error for hard failures, StatusDegraded with a descriptive error
+// for soft failures the caller should still serve traffic through (e.g. a
+// load balancer keeping the pod in rotation while alerts fire), and
+// StatusHealthy with a nil error otherwise.
 type Checker interface {
-	Check(ctx context.Context) error
+	Check(ctx context.Context) (Status, error)
 	Name() string
 }
 
@@ -49,30 +59,36 @@ func (r *CheckerRegistry) Register(checker Checker) {
 	r.checkers = append(r.checkers, checker)
 }
 
+// Check runs every registered checker and aggregates their statuses:
+// Unhealthy if any checker is Unhealthy, else Degraded if any checker is
+// Degraded, else Healthy.
 func (r *CheckerRegistry) Check(ctx context.Context) Health {
 	results := make(map[string]CheckResult)
-	allHealthy := true
+	anyUnhealthy := false
 	anyDegraded := false
 
 	for _, checker := range r.checkers {
-		err := checker.Check(ctx)
+		status, err := checker.Check(ctx)
 		result := CheckResult{
+			Status:    status,
 			Timestamp: time.Now(),
 		}
-
 		if err != nil {
-			result.Status = StatusUnhealthy
 			result.Message = err.Error()
-			allHealthy = false
-		} else {
-			result.Status = StatusHealthy
+		}
+
+		switch status {
+		case StatusUnhealthy:
+			anyUnhealthy = true
+		case StatusDegraded:
+			anyDegraded = true
 		}
 
 		results[checker.Name()] = result
 	}
 
 	overallStatus := StatusHealthy
-	if !allHealthy {
+	if anyUnhealthy {
 		overallStatus = StatusUnhealthy
 	} else if anyDegraded {
 		overallStatus = StatusDegraded
@@ -85,26 +101,53 @@ func (r *CheckerRegistry) Check(ctx context.Context) Health {
 	}
 }
 
+// PostgreSQLCheckerConfig tunes when Check reports StatusDegraded for a
+// database that is still reachable. The zero value disables both checks, so
+// Check only ever reports Healthy/Unhealthy.
+type PostgreSQLCheckerConfig struct {
+	// DegradedLatency is the ping round-trip above which Check reports
+	// Degraded instead of Healthy. Zero disables the latency check.
+	DegradedLatency time.Duration
+	// MaxWaitCount is the sql.DBStats.WaitCount above which Check reports
+	// Degraded, signaling the connection pool is exhausted and callers are
+	// queuing for a connection. Zero disables the wait-count check.
+	MaxWaitCount int64
+}
+
 type PostgreSQLChecker struct {
-	db *sql.DB
+	db  *sql.DB
+	cfg PostgreSQLCheckerConfig
 }
 
-func NewPostgreSQLChecker(db *sql.DB) *PostgreSQLChecker {
-	return &PostgreSQLChecker{db: db}
+func NewPostgreSQLChecker(db *sql.DB, cfg PostgreSQLCheckerConfig) *PostgreSQLChecker {
+	return &PostgreSQLChecker{db: db, cfg: cfg}
 }
 
 func (c *PostgreSQLChecker) Name() string {
 	return "postgresql"
 }
 
-func (c *PostgreSQLChecker) Check(ctx context.Context) error {
+func (c *PostgreSQLChecker) Check(ctx context.Context) (Status, error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
+	start := time.Now()
 	if err := c.db.PingContext(ctx); err != nil {
-		return fmt.Errorf("postgresql ping failed: %w", err)
+		return StatusUnhealthy, fmt.Errorf("postgresql ping failed: %w", err)
 	}
-	return nil
+	latency := time.Since(start)
+
+	if c.cfg.DegradedLatency > 0 && latency > c.cfg.DegradedLatency {
+		return StatusDegraded, fmt.Errorf("postgresql ping latency %s exceeds threshold %s", latency, c.cfg.DegradedLatency)
+	}
+
+	if c.cfg.MaxWaitCount > 0 {
+		if waitCount := c.db.Stats().WaitCount; waitCount > c.cfg.MaxWaitCount {
+			return StatusDegraded, fmt.Errorf("postgresql connection pool wait count %d exceeds threshold %d", waitCount, c.cfg.MaxWaitCount)
+		}
+	}
+
+	return StatusHealthy, nil
 }
 
 type RedisChecker struct {
@@ -119,14 +162,39 @@ func (c *RedisChecker) Name() string {
 	return "redis"
 }
 
-func (c *RedisChecker) Check(ctx context.Context) error {
+// Check pings Redis, then reports Degraded rather than Healthy if the server
+// is in the middle of a Sentinel-driven failover: writes may be briefly
+// rejected or routed to a replica that hasn't caught up yet, but the node is
+// still reachable and shouldn't be pulled out of rotation.
+func (c *RedisChecker) Check(ctx context.Context) (Status, error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	if err := c.client.Ping(ctx).Err(); err != nil {
-		return fmt.Errorf("redis ping failed: %w", err)
+		return StatusUnhealthy, fmt.Errorf("redis ping failed: %w", err)
+	}
+
+	info, err := c.client.Info(ctx, "replication").Result()
+	if err != nil {
+		// INFO failing doesn't mean the server is down; PING already proved
+		// that, so don't fail the check over a missing diagnostic.
+		return StatusHealthy, nil
+	}
+
+	if state := redisFailoverState(info); state != "" && state != "no-failover" {
+		return StatusDegraded, fmt.Errorf("redis is in failover state %q", state)
 	}
-	return nil
+
+	return StatusHealthy, nil
+}
+
+func redisFailoverState(info string) string {
+	for _, line := range strings.Split(info, "\r\n") {
+		if rest, ok := strings.CutPrefix(line, "master_failover_state:"); ok {
+			return strings.TrimSpace(rest)
+		}
+	}
+	return ""
 }
 
 type MongoDBChecker struct {
@@ -141,12 +209,242 @@ func (c *MongoDBChecker) Name() string {
 	return "mongodb"
 }
 
-func (c *MongoDBChecker) Check(ctx context.Context) error {
+func (c *MongoDBChecker) Check(ctx context.Context) (Status, error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	if err := c.client.Ping(ctx, nil); err != nil {
-		return fmt.Errorf("mongodb ping failed: %w", err)
+		return StatusUnhealthy, fmt.Errorf("mongodb ping failed: %w", err)
+	}
+	return StatusHealthy, nil
+}
+
+// KafkaCheckerConfig tunes when KafkaChecker reports StatusDegraded.
+type KafkaCheckerConfig struct {
+	// MaxLag is the consumer group lag (on Topic's partition 0) above which
+	// Check reports Degraded. Zero disables the lag check.
+	MaxLag int64
+}
+
+// KafkaChecker reports Degraded when the consumer group servicing Topic is
+// falling behind, or when messages are landing on DLQTopic, which normal
+// operation never produces. It never reports Unhealthy on its own: a broker
+// that's merely slow to answer an admin request isn't the same as the
+// pipeline being down, so a dial/read failure is surfaced as Degraded too.
+type KafkaChecker struct {
+	brokers  []string
+	groupID  string
+	topic    string
+	dlqTopic string
+	cfg      KafkaCheckerConfig
+
+	haveDLQBaseline bool
+	dlqBaseline     int64
+}
+
+func NewKafkaChecker(brokers []string, groupID, topic, dlqTopic string, cfg KafkaCheckerConfig) *KafkaChecker {
+	return &KafkaChecker{
+		brokers:  brokers,
+		groupID:  groupID,
+		topic:    topic,
+		dlqTopic: dlqTopic,
+		cfg:      cfg,
+	}
+}
+
+func (c *KafkaChecker) Name() string {
+	return "kafka"
+}
+
+func (c *KafkaChecker) Check(ctx context.Context) (Status, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if len(c.brokers) == 0 {
+		return StatusHealthy, nil
+	}
+
+	client := &kafka.Client{Addr: kafka.TCP(c.brokers...), Timeout: 5 * time.Second}
+
+	if c.cfg.MaxLag > 0 && c.topic != "" && c.groupID != "" {
+		lag, err := c.consumerLag(ctx, client)
+		if err != nil {
+			return StatusDegraded, fmt.Errorf("failed to compute kafka consumer lag: %w", err)
+		}
+		if lag > c.cfg.MaxLag {
+			return StatusDegraded, fmt.Errorf("kafka consumer lag %d exceeds threshold %d", lag, c.cfg.MaxLag)
+		}
+	}
+
+	if c.dlqTopic != "" {
+		receiving, err := c.dlqReceivingTraffic(ctx, client)
+		if err != nil {
+			return StatusDegraded, fmt.Errorf("failed to check kafka DLQ topic offset: %w", err)
+		}
+		if receiving {
+			return StatusDegraded, fmt.Errorf("kafka DLQ topic %q is receiving traffic", c.dlqTopic)
+		}
+	}
+
+	return StatusHealthy, nil
+}
+
+// consumerLag compares the group's committed offset against the partition's
+// latest offset for partition 0 of Topic. It's an approximation (a
+// multi-partition topic may have other partitions lagging further), good
+// enough for a cheap liveness signal without pulling in a full metrics
+// client.
+func (c *KafkaChecker) consumerLag(ctx context.Context, client *kafka.Client) (int64, error) {
+	const partition = 0
+
+	committed, err := client.OffsetFetch(ctx, &kafka.OffsetFetchRequest{
+		GroupID: c.groupID,
+		Topics:  map[string][]int{c.topic: {partition}},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("offset fetch failed: %w", err)
+	}
+
+	latest, err := client.ListOffsets(ctx, &kafka.ListOffsetsRequest{
+		Topics: map[string][]kafka.OffsetRequest{c.topic: {{Partition: partition, Timestamp: kafka.LastOffset}}},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("list offsets failed: %w", err)
+	}
+
+	var committedOffset, lastOffset int64
+	for _, p := range committed.Topics[c.topic] {
+		if p.Partition == partition {
+			committedOffset = p.CommittedOffset
+		}
+	}
+	for _, p := range latest.Topics[c.topic] {
+		if p.Partition == partition {
+			lastOffset = p.LastOffset
+		}
+	}
+
+	lag := lastOffset - committedOffset
+	if lag < 0 {
+		lag = 0
+	}
+	return lag, nil
+}
+
+// dlqReceivingTraffic compares the DLQ topic's latest offset on partition 0
+// against the value observed on the previous check. The first call just
+// records a baseline and reports no traffic, since there's nothing yet to
+// compare against.
+func (c *KafkaChecker) dlqReceivingTraffic(ctx context.Context, client *kafka.Client) (bool, error) {
+	const partition = 0
+
+	latest, err := client.ListOffsets(ctx, &kafka.ListOffsetsRequest{
+		Topics: map[string][]kafka.OffsetRequest{c.dlqTopic: {{Partition: partition, Timestamp: kafka.LastOffset}}},
+	})
+	if err != nil {
+		return false, fmt.Errorf("list offsets failed: %w", err)
+	}
+
+	var lastOffset int64
+	for _, p := range latest.Topics[c.dlqTopic] {
+		if p.Partition == partition {
+			lastOffset = p.LastOffset
+		}
+	}
+
+	if !c.haveDLQBaseline {
+		c.haveDLQBaseline = true
+		c.dlqBaseline = lastOffset
+		return false, nil
+	}
+
+	receiving := lastOffset > c.dlqBaseline
+	c.dlqBaseline = lastOffset
+	return receiving, nil
+}
+
+// SupervisorChecker surfaces a supervisor.Supervisor's per-child state:
+// unhealthy if any child has failed fatally and stopped restarting.
+type SupervisorChecker struct {
+	sup *supervisor.Supervisor
+}
+
+func NewSupervisorChecker(sup *supervisor.Supervisor) *SupervisorChecker {
+	return &SupervisorChecker{sup: sup}
+}
+
+func (c *SupervisorChecker) Name() string {
+	return "supervisor"
+}
+
+func (c *SupervisorChecker) Check(ctx context.Context) (Status, error) {
+	for name, h := range c.sup.Health() {
+		if h.State == supervisor.StateFailed {
+			return StatusUnhealthy, fmt.Errorf("child %q failed: %v", name, h.LastError)
+		}
+	}
+	return StatusHealthy, nil
+}
+
+// KafkaConsumerStateChecker surfaces a *broker.KafkaConsumer's
+// broker.ConsumerState: unhealthy while the consumer has given up and
+// stopped, degraded while it's mid-reconnect, healthy otherwise.
+type KafkaConsumerStateChecker struct {
+	consumer *broker.KafkaConsumer
+}
+
+func NewKafkaConsumerStateChecker(consumer *broker.KafkaConsumer) *KafkaConsumerStateChecker {
+	return &KafkaConsumerStateChecker{consumer: consumer}
+}
+
+func (c *KafkaConsumerStateChecker) Name() string {
+	return "kafka_consumer_state"
+}
+
+func (c *KafkaConsumerStateChecker) Check(ctx context.Context) (Status, error) {
+	switch state := c.consumer.State(); state {
+	case broker.StateStopped:
+		return StatusUnhealthy, fmt.Errorf("kafka consumer stopped")
+	case broker.StateReconnecting, broker.StateConnecting, broker.StateRebalancing:
+		return StatusDegraded, fmt.Errorf("kafka consumer %s", state)
+	default:
+		return StatusHealthy, nil
+	}
+}
+
+// BreakerStateReporter is implemented by anything fronting a dependency with
+// a gobreaker circuit breaker and reporting its state as "closed",
+// "half-open", "open", or "disabled" - deduplication.Service.BreakerState is
+// the first implementer. Defined here, not imported from the reporting
+// package, so this checker doesn't force an internal package import onto
+// every binary that wires up health checks.
+type BreakerStateReporter interface {
+	BreakerState() string
+}
+
+// BreakerChecker surfaces a circuit breaker's tripped state as a readiness
+// signal: half-open (actively probing whether the dependency recovered) and
+// open (failing closed) both report Degraded rather than Unhealthy, since
+// the service is still serving traffic through its configured fallback
+// behavior, not down.
+type BreakerChecker struct {
+	name     string
+	reporter BreakerStateReporter
+}
+
+func NewBreakerChecker(name string, reporter BreakerStateReporter) *BreakerChecker {
+	return &BreakerChecker{name: name, reporter: reporter}
+}
+
+func (c *BreakerChecker) Name() string {
+	return c.name
+}
+
+func (c *BreakerChecker) Check(ctx context.Context) (Status, error) {
+	switch state := c.reporter.BreakerState(); state {
+	case "open", "half-open":
+		return StatusDegraded, fmt.Errorf("circuit breaker is %s", state)
+	default:
+		return StatusHealthy, nil
 	}
-	return nil
 }
@@ -2,10 +2,14 @@ package logging
 
 import (
 	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
 	TraceIDKey     = "trace_id"
+	SpanIDKey      = "span_id"
 	MessageIDKey   = "message_id"
 	ServiceNameKey = "service_name"
 )
@@ -22,10 +26,42 @@ func WithServiceName(ctx context.Context, serviceName string) context.Context {
 	return context.WithValue(ctx, ServiceNameKey, serviceName)
 }
 
+// GetTraceID returns the trace ID WithTraceID/WithTraceContext attached to
+// ctx, falling back to ctx's active OTel span (trace.SpanContextFromContext)
+// when neither has run - e.g. a context that only went through
+// ExtractW3CTraceContext or tracing.GinMiddleware/otelgin, with no explicit
+// WithTraceContext call of its own yet.
 func GetTraceID(ctx context.Context) string {
-	if traceID, ok := ctx.Value(TraceIDKey).(string); ok {
+	if traceID, ok := ctx.Value(TraceIDKey).(string); ok && traceID != "" {
 		return traceID
 	}
+	if spanCtx := trace.SpanContextFromContext(ctx); spanCtx.IsValid() {
+		return spanCtx.TraceID().String()
+	}
+	return ""
+}
+
+// WithTraceContext extracts the active OpenTelemetry span's trace and span
+// IDs from ctx and attaches them the same way WithTraceID does, so *wCtx log
+// calls correlate with the span visible in Jaeger/Tempo without every call
+// site having to do the extraction itself. It is a no-op if ctx carries no
+// valid span, and it never overwrites a trace ID already set by WithTraceID
+// (e.g. one propagated through a Kafka envelope rather than an OTel span).
+func WithTraceContext(ctx context.Context) context.Context {
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if !spanCtx.IsValid() {
+		return ctx
+	}
+	if GetTraceID(ctx) == "" {
+		ctx = WithTraceID(ctx, spanCtx.TraceID().String())
+	}
+	return context.WithValue(ctx, SpanIDKey, spanCtx.SpanID().String())
+}
+
+func GetSpanID(ctx context.Context) string {
+	if spanID, ok := ctx.Value(SpanIDKey).(string); ok {
+		return spanID
+	}
 	return ""
 }
 
@@ -54,9 +90,69 @@ func GetLogFields(ctx context.Context) []interface{} {
 		fields = append(fields, "message_id", messageID)
 	}
 
+	if spanID := GetSpanID(ctx); spanID != "" {
+		fields = append(fields, "span_id", spanID)
+	}
+
 	if serviceName := GetServiceName(ctx); serviceName != "" {
 		fields = append(fields, "service_name", serviceName)
 	}
 
 	return fields
 }
+
+// WithOTelSpan attaches span to ctx via the OTel API (trace.ContextWithSpan)
+// and immediately derives trace_id/span_id from it via WithTraceContext, for
+// a caller holding a span from somewhere other than ctx's own lineage (e.g.
+// one handed across a goroutine boundary) that still wants GetTraceID/
+// GetLogFields to see it.
+func WithOTelSpan(ctx context.Context, span trace.Span) context.Context {
+	return WithTraceContext(trace.ContextWithSpan(ctx, span))
+}
+
+// mapCarrier adapts a map[string]string to propagation.TextMapCarrier for
+// ExtractW3CTraceContext/InjectW3CTraceContext, the same shape
+// tracing.kafkaHeaderCarrier adapts for Kafka headers.
+type mapCarrier map[string]string
+
+func (c mapCarrier) Get(key string) string { return c[key] }
+
+func (c mapCarrier) Set(key, value string) { c[key] = value }
+
+func (c mapCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// ExtractW3CTraceContext parses carrier's "traceparent"/"tracestate"
+// entries (W3C Trace Context) via the process's configured
+// otel.TextMapPropagator and attaches the resulting span context to ctx via
+// WithTraceContext, so a message or request arriving with trace context in
+// a plain string-keyed carrier - rather than a broker-specific one like
+// tracing.ExtractTraceContext's kafka.Header slice - flows into
+// GetTraceID/GetLogFields the same way. A nil propagator (tracing disabled)
+// makes this a no-op.
+func ExtractW3CTraceContext(ctx context.Context, carrier map[string]string) context.Context {
+	propagator := otel.GetTextMapPropagator()
+	if propagator == nil {
+		return ctx
+	}
+	return WithTraceContext(propagator.Extract(ctx, mapCarrier(carrier)))
+}
+
+// InjectW3CTraceContext writes ctx's active span context into carrier as
+// "traceparent"/"tracestate" entries, the string-keyed-carrier counterpart
+// to tracing.InjectTraceContext, for an outgoing call whose transport isn't
+// one of the broker-specific carriers pkg/tracing already adapts (e.g. an
+// outbound HTTP request's headers). A nil propagator (tracing disabled)
+// makes this a no-op.
+func InjectW3CTraceContext(ctx context.Context, carrier map[string]string) {
+	propagator := otel.GetTextMapPropagator()
+	if propagator == nil {
+		return
+	}
+	propagator.Inject(ctx, mapCarrier(carrier))
+}
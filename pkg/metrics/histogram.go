@@ -0,0 +1,78 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// HistogramConfig tunes the resolution and classic/native migration
+// behavior of histograms built by NewLatencyHistogram.
+type HistogramConfig struct {
+	// NativeHistogramsEnabled sets NativeHistogramBucketFactor/
+	// NativeHistogramMaxBucketNumber on the built HistogramVec, so
+	// Prometheus also records exponential-resolution native (sparse)
+	// histogram buckets alongside (or instead of) classic ones.
+	NativeHistogramsEnabled bool
+	// BucketFactor is NativeHistogramBucketFactor: the growth factor
+	// between adjacent native histogram buckets. Prometheus recommends 1.1
+	// for general use - smaller values mean finer resolution and more
+	// buckets.
+	BucketFactor float64
+	// MaxBucketNumber is NativeHistogramMaxBucketNumber: the point at which
+	// Prometheus automatically coarsens resolution (merges buckets) rather
+	// than growing the native histogram's bucket count further.
+	MaxBucketNumber uint32
+	// ClassicBucketsEnabled keeps prometheus.DefBuckets active alongside
+	// native histogram buckets - the migration window this was added for,
+	// so PromQL/dashboards built on histogram_quantile over classic buckets
+	// keep working while native-histogram adoption is verified.
+	ClassicBucketsEnabled bool
+}
+
+// DefaultHistogramConfig is what NewLatencyHistogram uses. It isn't wired to
+// config.MetricsConfig: every histogram built with NewLatencyHistogram lives
+// in this package's var (...) block and is constructed at import time,
+// before any service has read its config file, so a YAML-driven override
+// wouldn't reach it without restructuring every RegisterXMetrics call site
+// to build its histograms lazily instead - and registry.go's
+// filteringRuleRegistry/enrichmentRuleRegistry already depend on
+// FilteringRuleEvalDuration being a valid, non-nil collector at package
+// init (see its doc comment), so that restructuring isn't a small change.
+// DefaultHistogramConfig exists as a typed, documented place a future change
+// can plug a config-driven override into.
+var DefaultHistogramConfig = HistogramConfig{
+	NativeHistogramsEnabled: true,
+	BucketFactor:            1.1,
+	MaxBucketNumber:         160,
+	ClassicBucketsEnabled:   true,
+}
+
+// NewLatencyHistogram builds a HistogramVec for a request/provider/query
+// duration metric using DefaultHistogramConfig, replacing what used to be a
+// different hand-picked classic Buckets slice per metric
+// (FilteringProcessingDuration/DedupProcessingDuration/
+// EnrichmentProcessingDuration/KafkaReadDuration/... each had their own) with
+// one shared bucket set plus native-histogram resolution for every family
+// that opts in by calling this instead of prometheus.NewHistogramVec
+// directly. name/help follow this package's usual metric naming - a unit
+// suffix like "_ms" belongs in name, same as every other histogram here.
+func NewLatencyHistogram(name, help string, labels []string) *prometheus.HistogramVec {
+	return NewLatencyHistogramWithConfig(name, help, labels, DefaultHistogramConfig)
+}
+
+// NewLatencyHistogramWithConfig is NewLatencyHistogram with an explicit
+// HistogramConfig, for a family that needs a different resolution/migration
+// tradeoff than DefaultHistogramConfig.
+func NewLatencyHistogramWithConfig(name, help string, labels []string, cfg HistogramConfig) *prometheus.HistogramVec {
+	opts := prometheus.HistogramOpts{
+		Name: name,
+		Help: help,
+	}
+
+	if cfg.ClassicBucketsEnabled {
+		opts.Buckets = prometheus.DefBuckets
+	}
+	if cfg.NativeHistogramsEnabled {
+		opts.NativeHistogramBucketFactor = cfg.BucketFactor
+		opts.NativeHistogramMaxBucketNumber = cfg.MaxBucketNumber
+	}
+
+	return prometheus.NewHistogramVec(opts, labels)
+}
@@ -1,10 +1,44 @@
 package metrics
 
 import (
+	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+
+	otelbridge "yeti/pkg/metrics/otel"
+)
+
+// otelMeter backs every otelbridge instrument declared below. It resolves
+// against OTEL's global MeterProvider lazily (same as otelbridge.Meter
+// itself), so these instruments are safe to create here at package init,
+// before any service calls otelbridge.Init.
+var otelMeter = otelbridge.Meter("yeti-metrics")
+
+// The following otelbridge instruments mirror a subset of the
+// prometheus.CounterVec/HistogramVec/GaugeVec declared below to OTEL, so a
+// deployment pushing metrics to an OTLP collector (via otelbridge.Init) sees
+// the same ingest -> filter -> dedup -> enrich -> publish pipeline signals
+// Prometheus does. This mirrors the core pipeline instruments rather than
+// every metric in this file - the remainder stay Prometheus-only until a
+// consumer of this service actually needs them over OTLP too, following the
+// same "mirror what's asked for, not what's theoretically mirrorable"
+// judgment call this package already makes elsewhere (e.g.
+// ObserveFilteringRuleEvalDuration has no CEL-cache-hit counterpart).
+var (
+	otelFilteringMessagesTotal        = otelbridge.NewCounter(otelMeter, "filtering_messages_total", "Total number of messages processed by filtering service", "status")
+	otelDeduplicateMessagesTotal      = otelbridge.NewCounter(otelMeter, "dedup_messages_total", "Total number of messages processed by deduplication service", "status")
+	otelEnrichmentMessagesTotal       = otelbridge.NewCounter(otelMeter, "enrichment_messages_total", "Total number of messages processed by enrichment service", "status")
+	otelFilteringProcessingDuration   = otelbridge.NewHistogram(otelMeter, "filtering_processing_duration", "Processing duration for filtering service", "ms", "status")
+	otelDedupProcessingDuration       = otelbridge.NewHistogram(otelMeter, "dedup_processing_duration", "Processing duration for deduplication service", "ms", "status")
+	otelEnrichmentProcessingDuration  = otelbridge.NewHistogram(otelMeter, "enrichment_processing_duration", "Processing duration for enrichment service", "ms", "status")
+	otelKafkaMessagesReadTotal        = otelbridge.NewCounter(otelMeter, "kafka_messages_read_total", "Total number of messages read from Kafka", "service", "topic")
+	otelKafkaMessagesWrittenTotal     = otelbridge.NewCounter(otelMeter, "kafka_messages_written_total", "Total number of messages written to Kafka", "service", "topic")
+	otelKafkaReadDuration             = otelbridge.NewHistogram(otelMeter, "kafka_read_duration", "Duration of reading messages from Kafka", "ms", "service", "topic")
+	otelKafkaWriteDuration            = otelbridge.NewHistogram(otelMeter, "kafka_write_duration", "Duration of writing messages to Kafka", "ms", "service", "topic")
+	otelFilteringRuleEvaluationsTotal = otelbridge.NewCounter(otelMeter, "filtering_rule_evaluations_total", "Total number of filtering rule evaluations", "rule_id", "rule_name", "result", "tenant")
 )
 
 var (
@@ -32,30 +66,27 @@ var (
 		[]string{"status"},
 	)
 
-	FilteringProcessingDuration = prometheus.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    "filtering_processing_duration_ms",
-			Help:    "Processing duration for filtering service in milliseconds",
-			Buckets: []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000},
-		},
+	// FilteringProcessingDuration/DedupProcessingDuration/
+	// EnrichmentProcessingDuration each used to hand-tune their own classic
+	// bucket set (11, 9, and 10 buckets respectively, none quite right for
+	// the others) - NewLatencyHistogram replaces all three with one shared
+	// native-histogram-plus-DefBuckets configuration. See
+	// DefaultHistogramConfig.
+	FilteringProcessingDuration = NewLatencyHistogram(
+		"filtering_processing_duration_ms",
+		"Processing duration for filtering service in milliseconds",
 		[]string{"status"},
 	)
 
-	DedupProcessingDuration = prometheus.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    "dedup_processing_duration_ms",
-			Help:    "Processing duration for deduplication service in milliseconds",
-			Buckets: []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000},
-		},
+	DedupProcessingDuration = NewLatencyHistogram(
+		"dedup_processing_duration_ms",
+		"Processing duration for deduplication service in milliseconds",
 		[]string{"status"},
 	)
 
-	EnrichmentProcessingDuration = prometheus.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    "enrichment_processing_duration_ms",
-			Help:    "Processing duration for enrichment service in milliseconds",
-			Buckets: []float64{10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000},
-		},
+	EnrichmentProcessingDuration = NewLatencyHistogram(
+		"enrichment_processing_duration_ms",
+		"Processing duration for enrichment service in milliseconds",
 		[]string{"status"},
 	)
 
@@ -80,6 +111,127 @@ var (
 		},
 	)
 
+	// DedupFilterLoadFactor is the fraction of a deduplication.
+	// CuckooRepository's active generation currently occupied, reported
+	// by Service.updateCacheSizeMetrics whenever the configured Repository
+	// supports Stats() - see CuckooRepository.Stats. It climbs toward 1 as
+	// the filter approaches the point Insert starts failing and
+	// CuckooRepository falls back to Redis for every call.
+	DedupFilterLoadFactor = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "dedup_filter_load_factor",
+			Help: "Load factor of the active deduplication fast-path filter generation, 0-1 (ratio)",
+		},
+	)
+
+	// DedupFilterCapacity is CuckooStats.Capacity/bloom's equivalent - the
+	// active generation's total fingerprint/bit slot count, alongside the
+	// ratio DedupFilterLoadFactor already reports.
+	DedupFilterCapacity = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "dedup_filter_capacity",
+			Help: "Total slot count of the active deduplication fast-path filter generation (count)",
+		},
+	)
+
+	// DedupFastPathSkippedTotal counts SetNX calls the Bloom/Cuckoo fast
+	// path answered on its own - "definitely not present" - without a
+	// Redis round trip, by backend ("bloom" or "cuckoo").
+	DedupFastPathSkippedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "dedup_fast_path_skipped_total",
+			Help: "Total number of deduplication SetNX calls served entirely by the Bloom/Cuckoo fast path, skipping Redis (count)",
+		},
+		[]string{"backend"},
+	)
+
+	// DedupFastPathFalsePositiveRate is the observed rate at which a
+	// "possibly present" fast-path verdict turned out, once Redis was
+	// consulted, to actually be unique - i.e. the filter's real-world
+	// false-positive rate, as opposed to EstimatedFalsePositive's
+	// theoretical one. Set by Service.recordFastPathFallthrough alongside
+	// DedupFastPathFallthroughTotal/DedupFastPathObservedFPTotal.
+	DedupFastPathFalsePositiveRate = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "dedup_fast_path_false_positive_rate",
+			Help: "Observed false-positive rate of the deduplication fast-path filter - fraction of possibly-present verdicts Redis went on to confirm unique (ratio, 0.0 to 1.0)",
+		},
+		[]string{"backend"},
+	)
+
+	DedupFastPathFallthroughTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "dedup_fast_path_fallthrough_total",
+			Help: "Total number of deduplication SetNX calls the Bloom/Cuckoo fast path couldn't answer on its own and fell through to Redis for (count)",
+		},
+		[]string{"backend"},
+	)
+
+	DedupFastPathObservedFPTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "dedup_fast_path_observed_fp_total",
+			Help: "Total number of fast-path fallthroughs where Redis confirmed the message was actually unique, i.e. an observed false positive (count)",
+		},
+		[]string{"backend"},
+	)
+
+	// DedupL1HitRate is the in-process L1 cache hit rate among Process
+	// calls that reach it, set by Service.Process alongside
+	// DedupL1HitsTotal/DedupL1MissesTotal - see deduplication.DedupCache.
+	DedupL1HitRate = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "dedup_l1_hit_rate",
+			Help: "In-process L1 cache hit rate for deduplication lookups, among requests that reach the L1 cache (ratio, 0.0 to 1.0)",
+		},
+	)
+
+	DedupL1HitsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "dedup_l1_hits_total",
+			Help: "Total number of deduplication lookups served from the L1 cache without a Redis round trip (count)",
+		},
+	)
+
+	DedupL1MissesTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "dedup_l1_misses_total",
+			Help: "Total number of deduplication lookups that missed the L1 cache and fell through to Redis (count)",
+		},
+	)
+
+	DedupL1CacheSize = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "dedup_l1_cache_size",
+			Help: "Current number of entries held in deduplication.Service's in-process L1 cache (count)",
+		},
+	)
+
+	// DedupInvalidationsTotal counts dedup:invalidate Pub/Sub messages this
+	// instance has published or received, so operators can tell a quiet
+	// invalidation channel from a broker outage. direction is "published" or
+	// "received".
+	DedupInvalidationsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "dedup_invalidations_total",
+			Help: "Total number of deduplication L1 cache invalidation messages published or received over dedup:invalidate (count)",
+		},
+		[]string{"direction"},
+	)
+
+	// DedupBreakerState is CircuitBreakerState's deduplication.Service
+	// counterpart: CircuitBreakerState is labeled by the generic breaker
+	// name every pkg/circuitbreaker.Wrapper reports under ("redis-dedup"
+	// here), while this is deduplication-specific so a dashboard can chart
+	// it alongside the rest of the dedup_* family without also pulling in
+	// every other service's breakers. Uses the same numeric encoding as
+	// CircuitBreakerState/EnrichmentBreakerState.
+	DedupBreakerState = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "dedup_breaker_state",
+			Help: "Deduplication repository's Redis circuit breaker state (0=closed, 1=half-open, 2=open) (state code)",
+		},
+	)
+
 	EnrichmentCacheHitRate = prometheus.NewGauge(
 		prometheus.GaugeOpts{
 			Name: "enrichment_cache_hit_rate",
@@ -127,12 +279,42 @@ var (
 		[]string{"name"},
 	)
 
+	// CircuitBreakerEjectedHosts is circuitbreaker.AdaptiveWrapper's count
+	// of endpoints currently ejected from a pool, by wrapper name.
+	CircuitBreakerEjectedHosts = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "circuit_breaker_ejected_hosts",
+			Help: "Current number of endpoints ejected from an AdaptiveWrapper's pool (count)",
+		},
+		[]string{"name"},
+	)
+
+	// CircuitBreakerBucketErrorRate is an AdaptiveWrapper endpoint's error
+	// rate within its current rolling window.
+	CircuitBreakerBucketErrorRate = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "circuit_breaker_bucket_error_rate",
+			Help: "AdaptiveWrapper endpoint error rate within its rolling window (ratio, 0.0 to 1.0)",
+		},
+		[]string{"name", "endpoint"},
+	)
+
+	// CircuitBreakerLatencyP99 is an AdaptiveWrapper endpoint's p99
+	// latency within its current rolling window.
+	CircuitBreakerLatencyP99 = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "circuit_breaker_latency_p99_ms",
+			Help: "AdaptiveWrapper endpoint p99 latency within its rolling window (milliseconds)",
+		},
+		[]string{"name", "endpoint"},
+	)
+
 	RateLimitRequestsTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "rate_limit_requests_total",
 			Help: "Total number of requests checked against rate limit (count)",
 		},
-		[]string{"status"},
+		[]string{"status", "tier"},
 	)
 
 	FallbackUsageTotal = prometheus.NewCounterVec(
@@ -176,38 +358,118 @@ var (
 		[]string{"service", "topic", "partition"},
 	)
 
-	KafkaReadDuration = prometheus.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    "kafka_read_duration_ms",
-			Help:    "Duration of reading messages from Kafka in milliseconds",
-			Buckets: []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000},
+	KafkaReadDuration = NewLatencyHistogram(
+		"kafka_read_duration_ms",
+		"Duration of reading messages from Kafka in milliseconds",
+		[]string{"service", "topic"},
+	)
+
+	KafkaWriteDuration = NewLatencyHistogram(
+		"kafka_write_duration_ms",
+		"Duration of writing messages to Kafka in milliseconds",
+		[]string{"service", "topic"},
+	)
+
+	BrokerRateLimitPausedSeconds = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "yeti_broker_ratelimit_paused_seconds",
+			Help: "Cumulative time a broker consumer spent paused waiting for the rate limiter to admit a message (seconds)",
 		},
 		[]string{"service", "topic"},
 	)
 
-	KafkaWriteDuration = prometheus.NewHistogramVec(
+	BrokerRateLimitWaitSeconds = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
-			Name:    "kafka_write_duration_ms",
-			Help:    "Duration of writing messages to Kafka in milliseconds",
-			Buckets: []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000},
+			Name:    "yeti_broker_ratelimit_wait_seconds",
+			Help:    "Time spent waiting for the rate limiter to admit a message, per message (seconds)",
+			Buckets: []float64{0, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5},
 		},
 		[]string{"service", "topic"},
 	)
 
+	KafkaConsumerState = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "yeti_kafka_consumer_state",
+			Help: "Current broker.ConsumerState of a Kafka consumer (0=connecting, 1=rebalancing, 2=running, 3=reconnecting, 4=stopped) (state code)",
+		},
+		[]string{"service", "topic"},
+	)
+
+	// FilteringRuleEvaluationsTotal's tenant label is plumbed but not yet
+	// populated from any call site: filtering.Rule carries no tenant
+	// identifier today, only the management service's storage layer is
+	// multi-tenant (management.models's TenantID). IncFilteringRuleEvaluation
+	// passes "" until that identifier is threaded through filtering.Rule and
+	// models.MessageEnvelope - the label exists now so that wiring won't
+	// require another metric-shape change later.
 	FilteringRuleEvaluationsTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "filtering_rule_evaluations_total",
 			Help: "Total number of filtering rule evaluations (count)",
 		},
-		[]string{"rule_id", "rule_name", "result"},
+		[]string{"rule_id", "rule_name", "result", "tenant"},
+	)
+
+	// FilteringShadowDecisionsTotal counts what a shadow/canary-mode rule
+	// (see Rule.Mode) would have decided, without that decision affecting
+	// the message's actual filter outcome.
+	FilteringShadowDecisionsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "filtering_shadow_decisions_total",
+			Help: "Total number of shadow/canary rule decisions that did not affect the filter outcome (count)",
+		},
+		[]string{"rule_id", "would_have"},
+	)
+
+	// FilteringShadowDivergenceTotal counts messages where a shadow-mode
+	// rule's decision disagreed with its enforce-mode counterpart (the
+	// active rule sharing its Name) - see Service.evaluateShadowRules.
+	FilteringShadowDivergenceTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "filtering_shadow_divergence_total",
+			Help: "Total number of messages where a shadow rule's decision diverged from its enforce-mode counterpart (count)",
+		},
+		[]string{"rule_id"},
+	)
+
+	// FilteringRuleEvalDuration tracks a single filtering rule's
+	// evaluation time (Service.evaluateRule), labeled by rule_id, so an
+	// operator can spot the slow rule inside a RuleGroup instead of only
+	// seeing FilteringProcessingDuration's already-summed-across-rules
+	// total. Named "_seconds" rather than this package's usual "_ms" to
+	// match the per-rule metric name requested when this was added -
+	// FilteringProcessingDuration stays in milliseconds.
+	FilteringRuleEvalDuration = NewLatencyHistogram(
+		"filtering_rule_eval_duration_seconds",
+		"Duration of a single filtering rule's evaluation in seconds",
+		[]string{"rule_id"},
+	)
+
+	// FilteringRuleCostExceededTotal counts a filtering rule's evaluation
+	// aborting because it crossed its resolved cel.EvalBudget (see
+	// Service.resolveRuleBudget/EvaluateFilterForRuleWithBudget) - either its
+	// cost limit or its eval timeout, unlike yeti_cel_budget_exceeded_total,
+	// which only fires when a rule circuit breaker is configured
+	// (WithRuleBreaker). Recorded unconditionally from Service.evaluateRule,
+	// so an operator sees which specific rule_id is tripping its budget even
+	// with no breaker in play.
+	FilteringRuleCostExceededTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "filtering_rule_cost_exceeded_total",
+			Help: "Total number of filtering rule evaluations aborted by a per-rule cost limit or eval timeout (count)",
+		},
+		[]string{"rule_id"},
 	)
 
+	// EnrichmentRuleApplicationsTotal's tenant label has the same gap as
+	// FilteringRuleEvaluationsTotal's above: IncEnrichmentRuleApplication
+	// passes "" until enrichment.Rule carries a tenant identifier.
 	EnrichmentRuleApplicationsTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "enrichment_rule_applications_total",
 			Help: "Total number of enrichment rule applications (count)",
 		},
-		[]string{"rule_id", "rule_name", "status"},
+		[]string{"rule_id", "rule_name", "status", "tenant"},
 	)
 
 	EnrichmentTransformationsTotal = prometheus.NewCounterVec(
@@ -226,12 +488,12 @@ var (
 		[]string{"provider", "status"},
 	)
 
-	EnrichmentProviderDuration = prometheus.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    "enrichment_provider_duration_ms",
-			Help:    "Duration of enrichment provider requests in milliseconds",
-			Buckets: []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000},
-		},
+	// EnrichmentProviderDuration is the clearest beneficiary of native
+	// histograms here: provider tail latency (p99/p999) is exactly what
+	// classic, hand-picked buckets chronically under-resolve.
+	EnrichmentProviderDuration = NewLatencyHistogram(
+		"enrichment_provider_duration_ms",
+		"Duration of enrichment provider requests in milliseconds",
 		[]string{"provider"},
 	)
 
@@ -243,12 +505,9 @@ var (
 		[]string{"service", "database", "operation", "status"},
 	)
 
-	DatabaseQueryDuration = prometheus.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    "database_query_duration_ms",
-			Help:    "Duration of database queries in milliseconds",
-			Buckets: []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000},
-		},
+	DatabaseQueryDuration = NewLatencyHistogram(
+		"database_query_duration_ms",
+		"Duration of database queries in milliseconds",
 		[]string{"service", "database", "operation"},
 	)
 
@@ -268,28 +527,390 @@ var (
 		[]string{"service"},
 	)
 
-	MessageQueueWaitDuration = prometheus.NewHistogramVec(
+	MessageQueueWaitDuration = NewLatencyHistogram(
+		"message_queue_wait_duration_ms",
+		"Duration messages wait in queue before processing in milliseconds",
+		[]string{"service"},
+	)
+
+	TailSamplingKeptTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "tracing_tail_sampling_kept_total",
+			Help: "Total number of traces kept by the tail sampling processor (count)",
+		},
+		[]string{"service"},
+	)
+
+	TailSamplingDroppedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "tracing_tail_sampling_dropped_total",
+			Help: "Total number of traces dropped by the tail sampling processor (count)",
+		},
+		[]string{"service"},
+	)
+
+	TailSamplingEvictedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "tracing_tail_sampling_evicted_total",
+			Help: "Total number of traces evicted from the tail sampling buffer before a decision was reached (count)",
+		},
+		[]string{"service"},
+	)
+
+	JoinTuplesCompletedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "yeti_join_tuples_completed_total",
+			Help: "Total number of join keys for which every subscribed topic produced a message before the window elapsed (count)",
+		},
+		[]string{"group_id"},
+	)
+
+	JoinTuplesExpiredTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "yeti_join_tuples_expired_total",
+			Help: "Total number of join keys evicted after Window elapsed with at least one topic still missing (count)",
+		},
+		[]string{"group_id"},
+	)
+
+	JoinPendingKeys = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "yeti_join_pending_keys",
+			Help: "Current number of join keys waiting on at least one more topic (count)",
+		},
+		[]string{"group_id"},
+	)
+
+	EnrichmentL1HitRate = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "yeti_enrichment_l1_hit_rate",
+			Help: "In-process L1 cache hit rate for enrichment source data, among requests that reach fetchSourceData (ratio, 0.0 to 1.0)",
+		},
+	)
+
+	EnrichmentSingleflightSuppressed = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "yeti_enrichment_singleflight_suppressed_total",
+			Help: "Total number of concurrent fetchSourceData calls for the same (rule, field value) that were suppressed by singleflight and served the in-flight call's result (count)",
+		},
+		[]string{"rule_id"},
+	)
+
+	// EnrichmentCacheHitsTotal/EnrichmentCacheMissesTotal/
+	// EnrichmentCacheCoalescedTotal give a per-(source, layer) breakdown of
+	// the L1/L2/singleflight pipeline fetchSourceData already runs every
+	// source lookup through - layer is "l1", "l2", or "singleflight"
+	// (singleflight only ever coalesces, so only EnrichmentCacheCoalescedTotal
+	// is emitted for it), source is rule.SourceType. These sit alongside the
+	// finer-grained EnrichmentL1HitRate/EnrichmentSingleflightSuppressed
+	// (rule_id-scoped) rather than replacing them.
+	EnrichmentCacheHitsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "enrichment_cache_hits_total",
+			Help: "Total number of enrichment cache lookups served from a cache layer (count)",
+		},
+		[]string{"source", "layer"},
+	)
+
+	EnrichmentCacheMissesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "enrichment_cache_misses_total",
+			Help: "Total number of enrichment cache lookups that missed a cache layer (count)",
+		},
+		[]string{"source", "layer"},
+	)
+
+	EnrichmentCacheCoalescedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "enrichment_cache_coalesced_total",
+			Help: "Total number of concurrent enrichment fetches coalesced onto a single in-flight call by singleflight (count)",
+		},
+		[]string{"source", "layer"},
+	)
+
+	// EnrichmentRuleThrottled counts how many fetchFromProvider calls were
+	// made to wait on a rule's RateLimitPerSecond limiter or MaxConcurrency
+	// semaphore, so an operator can tell a configured throttle apart from
+	// one that's never actually binding.
+	EnrichmentRuleThrottled = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "yeti_enrichment_rule_throttled",
+			Help: "1 if the rule's most recent provider fetch had to wait on its rate limiter or concurrency semaphore, 0 otherwise",
+		},
+		[]string{"rule_id"},
+	)
+
+	// EnrichmentRuleConcurrencyLimit is the rule's current effective
+	// concurrency limit, which adaptive throttling shrinks below
+	// MaxConcurrency when the rule's provider reports an open circuit or
+	// elevated p99 latency, and grows back additively on success streaks.
+	EnrichmentRuleConcurrencyLimit = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "yeti_enrichment_rule_concurrency_limit",
+			Help: "Rule's current effective provider-call concurrency limit (count)",
+		},
+		[]string{"rule_id"},
+	)
+
+	// EnrichmentBreakerState is CircuitBreakerState's per-rule counterpart:
+	// CircuitBreakerState is labeled by source type (shared across every
+	// rule using that source), while this tracks the breaker a rule gets
+	// from its own Rule.CircuitBreaker override (see
+	// serviceImpl.ruleProviderFor). A rule with no override isn't tracked
+	// here since it has no breaker of its own to report.
+	EnrichmentBreakerState = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "yeti_enrichment_breaker_state",
+			Help: "Per-rule enrichment circuit breaker state (0=closed, 1=half-open, 2=open) (state code)",
+		},
+		[]string{"rule_id"},
+	)
+
+	// EnrichmentRetryAttemptsTotal counts every retry RetryingProvider makes
+	// on behalf of an enrichment source, labeled by the same name
+	// WrapWithCircuitBreaker/WrapWithRetry use for a source ("api",
+	// "mongodb", or "rule:<id>" for a per-rule override).
+	EnrichmentRetryAttemptsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "yeti_enrichment_retry_attempts_total",
+			Help: "Total number of retry attempts made by enrichment source providers (count)",
+		},
+		[]string{"source"},
+	)
+
+	// ConfigReloadDuration times how long a rule reload takes once
+	// triggered, split by trigger so a slow targeted reload (driven by a
+	// config_handler event) can be told apart from a slow full reload
+	// (driven by Service.StartReloader's periodic fallback).
+	ConfigReloadDuration = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
-			Name:    "message_queue_wait_duration_ms",
-			Help:    "Duration messages wait in queue before processing in milliseconds",
-			Buckets: []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000},
+			Name:    "yeti_config_reload_duration_ms",
+			Help:    "Duration of a filtering/enrichment rule reload in milliseconds",
+			Buckets: []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500},
+		},
+		[]string{"service", "trigger"},
+	)
+
+	// ConfigReloadNotificationLag is the delay between a ConfigUpdateEvent
+	// being produced (event.Timestamp) and config_handler.Handler
+	// processing it, i.e. how stale a targeted reload already was by the
+	// time it started.
+	ConfigReloadNotificationLag = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "yeti_config_reload_notification_lag_ms",
+			Help:    "Delay between a config update event being produced and the reloader processing it, in milliseconds",
+			Buckets: []float64{10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000},
+		},
+		[]string{"service"},
+	)
+
+	// ConfigReloadForcedCommitsTotal counts how many times
+	// config_handler.Handler's lag-readiness gate hit its deadline and
+	// committed a prepared reload anyway instead of waiting for lag to
+	// reach zero, labeled by service. A nonzero rate means LagGateConfig's
+	// Deadline is consistently too short for this service's consumers to
+	// drain in-flight messages before the gate gives up.
+	ConfigReloadForcedCommitsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "yeti_config_reload_forced_commits_total",
+			Help: "Total number of config reloads force-committed after the lag-readiness deadline elapsed",
 		},
 		[]string{"service"},
 	)
+
+	// RulesLastDeltaTimestamp is the unix timestamp (seconds) at which a
+	// service last applied a RuleDeltaApplier.ApplyRuleDelta fast path
+	// (config_handler.Handler.reloadRule's "delta" trigger), labeled by
+	// service. Staleness of this gauge (compared to wall-clock time) signals
+	// that incoming ConfigUpdateEvents have stopped carrying an embedded
+	// Rule payload and reloads have fallen back to RuleReloader/ConfigReloader.
+	RulesLastDeltaTimestamp = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "yeti_rules_last_delta_ts",
+			Help: "Unix timestamp of the last rule delta applied via the fast in-process path (seconds)",
+		},
+		[]string{"service"},
+	)
+
+	// CELProgramCacheHitsTotal/CELProgramCacheMissesTotal count
+	// cel.Evaluator's compiled-program cache lookups, labeled by which cache
+	// ("filter" or "transform") was consulted. A steady stream of misses
+	// after startup has settled usually means the cache is too small for the
+	// number of distinct expressions in active use - see
+	// config.CELConfig.ProgramCacheSize.
+	CELProgramCacheHitsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "yeti_cel_program_cache_hits_total",
+			Help: "Total number of CEL program cache lookups served from the compiled-program cache (count)",
+		},
+		[]string{"cache"},
+	)
+
+	CELProgramCacheMissesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "yeti_cel_program_cache_misses_total",
+			Help: "Total number of CEL program cache lookups that required compiling the expression (count)",
+		},
+		[]string{"cache"},
+	)
+
+	// CELRuleBreakerState is EnrichmentBreakerState's cel.Evaluator
+	// counterpart: a rule's CEL evaluation circuit breaker, tripped by
+	// cel.Evaluator.EvaluateFilterForRule when that rule's cost-limit or
+	// eval-timeout failures exceed config.CELRuleBreakerConfig's threshold,
+	// rather than by its provider's own breaker. Uses the same numeric
+	// encoding as CircuitBreakerState/EnrichmentBreakerState.
+	CELRuleBreakerState = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "yeti_cel_rule_breaker_state",
+			Help: "Per-rule CEL evaluation circuit breaker state (0=closed, 1=half-open, 2=open) (state code)",
+		},
+		[]string{"rule_id"},
+	)
+
+	// CELBudgetExceededTotal counts EvaluateFilter/EvaluateTransform calls
+	// that failed because the expression exceeded its cost limit or eval
+	// timeout, labeled by which one ("cost" or "timeout") and the CEL
+	// environment ("filter" or "transform"), so an operator can tell a
+	// pathologically expensive rule apart from an ordinary compile/type
+	// error in CELProgramCacheMissesTotal's neighborhood.
+	CELBudgetExceededTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "yeti_cel_budget_exceeded_total",
+			Help: "Total number of CEL evaluations aborted by a cost limit or eval timeout (count)",
+		},
+		[]string{"cel", "reason"},
+	)
+
+	// MetricsDroppedHighCardinalityTotal counts rule_id label values a
+	// Registry evicted from a rule-scoped metric because its cardinality
+	// cap was reached, not because the rule itself was deleted - group
+	// identifies which Registry ("filtering_rules", "enrichment_rules") is
+	// shedding cardinality, so an operator knows where to raise
+	// config.MetricsConfig.RuleCardinalityCap. See pkg/metrics/registry.go.
+	MetricsDroppedHighCardinalityTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "metrics_dropped_high_cardinality_total",
+			Help: "Total number of rule_id label values evicted from a rule-scoped metric due to its cardinality cap (count)",
+		},
+		[]string{"group"},
+	)
+
+	// BackpressureWorkerLimit/BackpressurePausedPartitions/
+	// BackpressureDecisionsTotal are backpressure.Controller's own output
+	// signals, separate from the KafkaConsumerLag/MessageQueueWaitDuration/
+	// CircuitBreakerState gauges it reads as input - so a dashboard can show
+	// both what the controller saw and what it did about it.
+	BackpressureWorkerLimit = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "backpressure_worker_limit",
+			Help: "Current worker concurrency limit set by the backpressure controller's AIMD policy (count)",
+		},
+	)
+
+	BackpressurePausedPartitions = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "backpressure_paused_partitions",
+			Help: "Number of Kafka partitions currently paused by the backpressure controller (count)",
+		},
+	)
+
+	BackpressureDecisionsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "backpressure_decisions_total",
+			Help: "Total number of backpressure controller decisions, labeled by action taken (count)",
+		},
+		[]string{"action"},
+	)
+
+	// PanicsTotal is incremented by pkg/middleware's recovery
+	// middleware/interceptors whenever they catch a panic, labeled by the
+	// subsystem that panicked ("http", "grpc", or a caller-chosen value -
+	// see middleware.RecoveryConfig.Subsystem) so an alert can tell a REST
+	// handler panicking apart from a gRPC one in the same process.
+	PanicsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "panics_total",
+			Help: "Total number of panics recovered by middleware.RecoveryMiddleware/UnaryPanicInterceptor/StreamPanicInterceptor, labeled by subsystem (count)",
+		},
+		[]string{"subsystem"},
+	)
 )
 
+// RegisterBackpressureMetrics registers backpressure.Controller's own
+// gauges/counter. Call alongside RegisterBrokerMetrics in any service that
+// constructs a backpressure.Controller.
+func RegisterBackpressureMetrics() {
+	prometheus.MustRegister(BackpressureWorkerLimit)
+	prometheus.MustRegister(BackpressurePausedPartitions)
+	prometheus.MustRegister(BackpressureDecisionsTotal)
+}
+
+// SetBackpressureWorkerLimit records the backpressure controller's current
+// worker concurrency limit.
+func SetBackpressureWorkerLimit(limit int) {
+	BackpressureWorkerLimit.Set(float64(limit))
+}
+
+// SetBackpressurePausedPartitions records how many partitions the
+// backpressure controller currently has paused.
+func SetBackpressurePausedPartitions(count int) {
+	BackpressurePausedPartitions.Set(float64(count))
+}
+
+// IncBackpressureDecision records one backpressure controller decision
+// (e.g. "decrease_workers", "increase_workers", "pause_partition",
+// "resume_partition").
+func IncBackpressureDecision(action string) {
+	BackpressureDecisionsTotal.WithLabelValues(action).Inc()
+}
+
+// RegisterPanicMetrics registers PanicsTotal. Call once in any service that
+// installs middleware.RecoveryMiddleware or the gRPC panic interceptors -
+// the counter is shared across however many of those a process installs.
+var registerPanicMetricsOnce sync.Once
+
+func RegisterPanicMetrics() {
+	registerPanicMetricsOnce.Do(func() {
+		prometheus.MustRegister(PanicsTotal)
+	})
+}
+
+// IncPanic records one recovered panic for subsystem.
+func IncPanic(subsystem string) {
+	PanicsTotal.WithLabelValues(subsystem).Inc()
+}
+
 func RegisterFilteringMetrics() {
 	prometheus.MustRegister(FilteringMessagesTotal)
 	prometheus.MustRegister(FilteringProcessingDuration)
 	prometheus.MustRegister(FilteringActiveRules)
 	prometheus.MustRegister(FilteringRuleEvaluationsTotal)
+	prometheus.MustRegister(FilteringRuleEvalDuration)
+	prometheus.MustRegister(FilteringRuleCostExceededTotal)
+	prometheus.MustRegister(FilteringShadowDecisionsTotal)
+	prometheus.MustRegister(FilteringShadowDivergenceTotal)
 	registerFallbackUsageTotalOnce()
+	registerConfigReloadMetrics()
+	registerCardinalityMetricsOnce()
 }
 
 func RegisterDedupMetrics() {
 	prometheus.MustRegister(DeduplicateMessagesTotal)
 	prometheus.MustRegister(DedupProcessingDuration)
 	prometheus.MustRegister(DedupCacheSize)
+	prometheus.MustRegister(DedupFilterLoadFactor)
+	prometheus.MustRegister(DedupFilterCapacity)
+	prometheus.MustRegister(DedupFastPathSkippedTotal)
+	prometheus.MustRegister(DedupFastPathFalsePositiveRate)
+	prometheus.MustRegister(DedupFastPathFallthroughTotal)
+	prometheus.MustRegister(DedupFastPathObservedFPTotal)
+	prometheus.MustRegister(DedupL1HitRate)
+	prometheus.MustRegister(DedupL1HitsTotal)
+	prometheus.MustRegister(DedupL1MissesTotal)
+	prometheus.MustRegister(DedupL1CacheSize)
+	prometheus.MustRegister(DedupInvalidationsTotal)
+	prometheus.MustRegister(DedupBreakerState)
 	registerFallbackUsageTotalOnce()
 }
 
@@ -302,13 +923,45 @@ func RegisterEnrichmentMetrics() {
 	prometheus.MustRegister(EnrichmentTransformationsTotal)
 	prometheus.MustRegister(EnrichmentProviderRequestsTotal)
 	prometheus.MustRegister(EnrichmentProviderDuration)
+	prometheus.MustRegister(EnrichmentL1HitRate)
+	prometheus.MustRegister(EnrichmentSingleflightSuppressed)
+	prometheus.MustRegister(EnrichmentCacheHitsTotal)
+	prometheus.MustRegister(EnrichmentCacheMissesTotal)
+	prometheus.MustRegister(EnrichmentCacheCoalescedTotal)
+	prometheus.MustRegister(EnrichmentRuleThrottled)
+	prometheus.MustRegister(EnrichmentRuleConcurrencyLimit)
+	prometheus.MustRegister(EnrichmentBreakerState)
+	prometheus.MustRegister(EnrichmentRetryAttemptsTotal)
 	registerFallbackUsageTotalOnce()
+	registerConfigReloadMetrics()
+	registerCardinalityMetricsOnce()
+}
+
+// RegisterCELMetrics registers pkg/cel's compiled-program cache metrics.
+// Every service that builds a cel.Evaluator (filtering, enrichment,
+// management) calls this alongside its own RegisterXMetrics.
+func RegisterCELMetrics() {
+	prometheus.MustRegister(CELProgramCacheHitsTotal)
+	prometheus.MustRegister(CELProgramCacheMissesTotal)
+	prometheus.MustRegister(CELRuleBreakerState)
+	prometheus.MustRegister(CELBudgetExceededTotal)
 }
 
 func registerFallbackUsageTotalOnce() {
 	prometheus.MustRegister(FallbackUsageTotal)
 }
 
+func registerCardinalityMetricsOnce() {
+	prometheus.MustRegister(MetricsDroppedHighCardinalityTotal)
+}
+
+func registerConfigReloadMetrics() {
+	prometheus.MustRegister(ConfigReloadDuration)
+	prometheus.MustRegister(ConfigReloadNotificationLag)
+	prometheus.MustRegister(RulesLastDeltaTimestamp)
+	prometheus.MustRegister(ConfigReloadForcedCommitsTotal)
+}
+
 func RegisterBrokerMetrics() {
 	prometheus.MustRegister(RetryAttemptsTotal)
 	prometheus.MustRegister(DLQMessagesTotal)
@@ -318,12 +971,27 @@ func RegisterBrokerMetrics() {
 	prometheus.MustRegister(KafkaConsumerLag)
 	prometheus.MustRegister(KafkaReadDuration)
 	prometheus.MustRegister(KafkaWriteDuration)
+	prometheus.MustRegister(BrokerRateLimitPausedSeconds)
+	prometheus.MustRegister(BrokerRateLimitWaitSeconds)
+	prometheus.MustRegister(KafkaConsumerState)
+	prometheus.MustRegister(JoinTuplesCompletedTotal)
+	prometheus.MustRegister(JoinTuplesExpiredTotal)
+	prometheus.MustRegister(JoinPendingKeys)
 }
 
 func RegisterCircuitBreakerMetrics() {
 	prometheus.MustRegister(CircuitBreakerState)
 	prometheus.MustRegister(CircuitBreakerRequests)
 	prometheus.MustRegister(CircuitBreakerFailures)
+	prometheus.MustRegister(CircuitBreakerEjectedHosts)
+	prometheus.MustRegister(CircuitBreakerBucketErrorRate)
+	prometheus.MustRegister(CircuitBreakerLatencyP99)
+}
+
+func RegisterTracingMetrics() {
+	prometheus.MustRegister(TailSamplingKeptTotal)
+	prometheus.MustRegister(TailSamplingDroppedTotal)
+	prometheus.MustRegister(TailSamplingEvictedTotal)
 }
 
 func RegisterManagementMetrics() {
@@ -335,16 +1003,67 @@ func RegisterManagementMetrics() {
 	prometheus.MustRegister(MessageQueueWaitDuration)
 }
 
+// IncFilteringMessage records a message processed by the filtering service,
+// labeled by outcome (e.g. "passed", "filtered"). It exists (rather than
+// callers using FilteringMessagesTotal.WithLabelValues directly, as they did
+// before OTEL mirroring was added) so both backends stay in sync from one
+// call site.
+func IncFilteringMessage(status string) {
+	FilteringMessagesTotal.WithLabelValues(status).Inc()
+	otelFilteringMessagesTotal.Inc(context.Background(), status)
+}
+
+// IncDedupMessage is IncFilteringMessage's deduplication-service
+// counterpart.
+func IncDedupMessage(status string) {
+	DeduplicateMessagesTotal.WithLabelValues(status).Inc()
+	otelDeduplicateMessagesTotal.Inc(context.Background(), status)
+}
+
+// IncEnrichmentMessage is IncFilteringMessage's enrichment-service
+// counterpart.
+func IncEnrichmentMessage(status string) {
+	EnrichmentMessagesTotal.WithLabelValues(status).Inc()
+	otelEnrichmentMessagesTotal.Inc(context.Background(), status)
+}
+
+// AddEnrichmentMessages is IncEnrichmentMessage's batch-count counterpart,
+// used where ProcessBatch already has a per-status tally rather than one
+// message at a time.
+func AddEnrichmentMessages(status string, count int) {
+	EnrichmentMessagesTotal.WithLabelValues(status).Add(float64(count))
+	otelEnrichmentMessagesTotal.Add(context.Background(), float64(count), status)
+}
+
 func ObserveFilteringDuration(duration time.Duration, status string) {
 	FilteringProcessingDuration.WithLabelValues(status).Observe(float64(duration.Milliseconds()))
+	otelFilteringProcessingDuration.Observe(context.Background(), float64(duration.Milliseconds()), status)
 }
 
 func ObserveDedupDuration(duration time.Duration, status string) {
 	DedupProcessingDuration.WithLabelValues(status).Observe(float64(duration.Milliseconds()))
+	otelDedupProcessingDuration.Observe(context.Background(), float64(duration.Milliseconds()), status)
+}
+
+func ObserveConfigReloadDuration(service, trigger string, duration time.Duration) {
+	ConfigReloadDuration.WithLabelValues(service, trigger).Observe(float64(duration.Milliseconds()))
+}
+
+func ObserveConfigReloadNotificationLag(service string, lag time.Duration) {
+	ConfigReloadNotificationLag.WithLabelValues(service).Observe(float64(lag.Milliseconds()))
+}
+
+func IncConfigReloadForcedCommit(service string) {
+	ConfigReloadForcedCommitsTotal.WithLabelValues(service).Inc()
+}
+
+func SetRulesLastDeltaTimestamp(service string, ts time.Time) {
+	RulesLastDeltaTimestamp.WithLabelValues(service).Set(float64(ts.Unix()))
 }
 
 func ObserveEnrichmentDuration(duration time.Duration, status string) {
 	EnrichmentProcessingDuration.WithLabelValues(status).Observe(float64(duration.Milliseconds()))
+	otelEnrichmentProcessingDuration.Observe(context.Background(), float64(duration.Milliseconds()), status)
 }
 
 func SetFilteringActiveRules(count int) {
@@ -359,17 +1078,172 @@ func SetDedupCacheSize(size int) {
 	DedupCacheSize.Set(float64(size))
 }
 
+// SetDedupFilterLoadFactor records the active fast-path filter
+// generation's current load factor, 0-1.
+func SetDedupFilterLoadFactor(loadFactor float64) {
+	DedupFilterLoadFactor.Set(loadFactor)
+}
+
+// IncDedupL1Hit/IncDedupL1Miss record one L1 cache lookup; the caller
+// (Service.recordL1Access) tracks its own hit/attempt counters to derive
+// SetDedupL1HitRate, the same split enrichment's recordL1Access uses for
+// EnrichmentL1HitRate.
+func IncDedupL1Hit() {
+	DedupL1HitsTotal.Inc()
+}
+
+func IncDedupL1Miss() {
+	DedupL1MissesTotal.Inc()
+}
+
+func SetDedupL1HitRate(rate float64) {
+	DedupL1HitRate.Set(rate)
+}
+
+func SetDedupL1CacheSize(size int) {
+	DedupL1CacheSize.Set(float64(size))
+}
+
+func IncDedupInvalidation(direction string) {
+	DedupInvalidationsTotal.WithLabelValues(direction).Inc()
+}
+
+func SetDedupFilterCapacity(capacity uint64) {
+	DedupFilterCapacity.Set(float64(capacity))
+}
+
+// IncDedupFastPathSkipped records one SetNX call the Bloom/Cuckoo fast path
+// answered - "definitely not present" - without a Redis round trip.
+func IncDedupFastPathSkipped(backend string) {
+	DedupFastPathSkippedTotal.WithLabelValues(backend).Inc()
+}
+
+// RecordDedupFastPathFallthrough records one fast-path fallthrough to Redis
+// for backend and the resulting observed false-positive rate - the caller
+// (BloomRepository/CuckooRepository) tracks its own fallthrough/FP counts
+// and computes rate the same way Service.recordL1Access computes
+// DedupL1HitRate.
+func RecordDedupFastPathFallthrough(backend string, isFalsePositive bool, rate float64) {
+	DedupFastPathFallthroughTotal.WithLabelValues(backend).Inc()
+	if isFalsePositive {
+		DedupFastPathObservedFPTotal.WithLabelValues(backend).Inc()
+	}
+	DedupFastPathFalsePositiveRate.WithLabelValues(backend).Set(rate)
+}
+
 func SetEnrichmentCacheHitRate(rate float64) {
 	EnrichmentCacheHitRate.Set(rate)
 }
 
+func SetEnrichmentL1HitRate(rate float64) {
+	EnrichmentL1HitRate.Set(rate)
+}
+
+func IncEnrichmentSingleflightSuppressed(ruleID string) {
+	enrichmentRuleRegistry.Touch(ruleID)
+	EnrichmentSingleflightSuppressed.WithLabelValues(ruleID).Inc()
+}
+
+func IncEnrichmentCacheHit(source, layer string) {
+	EnrichmentCacheHitsTotal.WithLabelValues(source, layer).Inc()
+}
+
+func IncEnrichmentCacheMiss(source, layer string) {
+	EnrichmentCacheMissesTotal.WithLabelValues(source, layer).Inc()
+}
+
+func IncEnrichmentCacheCoalesced(source, layer string) {
+	EnrichmentCacheCoalescedTotal.WithLabelValues(source, layer).Inc()
+}
+
+func SetEnrichmentRuleThrottled(ruleID string, throttled bool) {
+	enrichmentRuleRegistry.Touch(ruleID)
+	value := 0.0
+	if throttled {
+		value = 1.0
+	}
+	EnrichmentRuleThrottled.WithLabelValues(ruleID).Set(value)
+}
+
+func SetEnrichmentRuleConcurrencyLimit(ruleID string, limit int) {
+	enrichmentRuleRegistry.Touch(ruleID)
+	EnrichmentRuleConcurrencyLimit.WithLabelValues(ruleID).Set(float64(limit))
+}
+
+// SetEnrichmentBreakerState records state (gobreaker's State.String():
+// "closed", "half-open", or "open") as its numeric code for ruleID's
+// per-rule circuit breaker, matching CircuitBreakerState's own encoding.
+func SetEnrichmentBreakerState(ruleID, state string) {
+	enrichmentRuleRegistry.Touch(ruleID)
+	var value float64
+	switch state {
+	case "half-open":
+		value = 1
+	case "open":
+		value = 2
+	}
+	EnrichmentBreakerState.WithLabelValues(ruleID).Set(value)
+}
+
+// SetCELRuleBreakerState records state (retry.CircuitState: "closed",
+// "half-open", or "open") as its numeric code for ruleID's CEL evaluation
+// circuit breaker, matching SetEnrichmentBreakerState's own encoding.
+func SetCELRuleBreakerState(ruleID, state string) {
+	var value float64
+	switch state {
+	case "half-open":
+		value = 1
+	case "open":
+		value = 2
+	}
+	CELRuleBreakerState.WithLabelValues(ruleID).Set(value)
+}
+
+// SetDedupBreakerState records state (gobreaker's State.String(): "closed",
+// "half-open", or "open") as its numeric code for deduplication.Service's
+// Redis circuit breaker, matching SetEnrichmentBreakerState's own encoding.
+func SetDedupBreakerState(state string) {
+	var value float64
+	switch state {
+	case "half-open":
+		value = 1
+	case "open":
+		value = 2
+	}
+	DedupBreakerState.Set(value)
+}
+
+// IncCELBudgetExceeded records an EvaluateFilter/EvaluateTransform call that
+// aborted because its compiled program exceeded cel.EvalBudget's cost limit
+// or eval timeout. cel is "filter" or "transform"; reason is "cost" or
+// "timeout".
+func IncCELBudgetExceeded(cel, reason string) {
+	CELBudgetExceededTotal.WithLabelValues(cel, reason).Inc()
+}
+
+func IncEnrichmentRetryAttempts(source string) {
+	EnrichmentRetryAttemptsTotal.WithLabelValues(source).Inc()
+}
+
+// IncCELProgramCacheHit/IncCELProgramCacheMiss record a cel.Evaluator
+// compiled-program cache lookup. cache is "filter" or "transform".
+func IncCELProgramCacheHit(cache string) {
+	CELProgramCacheHitsTotal.WithLabelValues(cache).Inc()
+}
+
+func IncCELProgramCacheMiss(cache string) {
+	CELProgramCacheMissesTotal.WithLabelValues(cache).Inc()
+}
+
 // Helper functions for new metrics
 func IncKafkaMessagesRead(service, topic string) {
 	KafkaMessagesReadTotal.WithLabelValues(service, topic).Inc()
+	otelKafkaMessagesReadTotal.Inc(context.Background(), service, topic)
 }
 
 func IncKafkaMessagesWritten(service, topic string) {
 	KafkaMessagesWrittenTotal.WithLabelValues(service, topic).Inc()
+	otelKafkaMessagesWrittenTotal.Inc(context.Background(), service, topic)
 }
 
 func ObserveKafkaMessageSize(service, topic, direction string, sizeBytes int) {
@@ -380,23 +1254,99 @@ func SetKafkaConsumerLag(service, topic string, partition int, lag int64) {
 	KafkaConsumerLag.WithLabelValues(service, topic, fmt.Sprintf("%d", partition)).Set(float64(lag))
 }
 
+func SetCircuitBreakerEjectedHosts(name string, count int) {
+	CircuitBreakerEjectedHosts.WithLabelValues(name).Set(float64(count))
+}
+
+func SetCircuitBreakerBucketErrorRate(name, endpoint string, rate float64) {
+	CircuitBreakerBucketErrorRate.WithLabelValues(name, endpoint).Set(rate)
+}
+
+func SetCircuitBreakerLatencyP99(name, endpoint string, p99 time.Duration) {
+	CircuitBreakerLatencyP99.WithLabelValues(name, endpoint).Set(float64(p99.Milliseconds()))
+}
+
 func ObserveKafkaReadDuration(service, topic string, duration time.Duration) {
 	KafkaReadDuration.WithLabelValues(service, topic).Observe(float64(duration.Milliseconds()))
+	otelKafkaReadDuration.Observe(context.Background(), float64(duration.Milliseconds()), service, topic)
 }
 
 func ObserveKafkaWriteDuration(service, topic string, duration time.Duration) {
 	KafkaWriteDuration.WithLabelValues(service, topic).Observe(float64(duration.Milliseconds()))
+	otelKafkaWriteDuration.Observe(context.Background(), float64(duration.Milliseconds()), service, topic)
+}
+
+// ObserveBrokerRateLimitWait records how long a consumer blocked waiting
+// for the rate limiter to admit a message. wait == 0 means the message was
+// admitted immediately and is still observed, so the histogram reflects the
+// true distribution of wait times.
+func ObserveBrokerRateLimitWait(service, topic string, wait time.Duration) {
+	BrokerRateLimitWaitSeconds.WithLabelValues(service, topic).Observe(wait.Seconds())
+	if wait > 0 {
+		BrokerRateLimitPausedSeconds.WithLabelValues(service, topic).Add(wait.Seconds())
+	}
+}
+
+// IncFilteringRuleEvaluation records a rule's evaluation outcome and marks
+// ruleID active with filteringRuleRegistry (see pkg/metrics/registry.go),
+// so FilteringRuleEvaluationsTotal/FilteringRuleEvalDuration stay bounded as
+// rules are created and deleted. tenant is currently always "" - see
+// FilteringRuleEvaluationsTotal's doc comment.
+// IncFilteringShadowDecision records a shadow/canary rule's would-have
+// decision and marks ruleID active with filteringRuleRegistry - see
+// Service.evaluateShadowRules, which evaluates a shadow rule directly
+// rather than through evaluateRule (so it doesn't already go through
+// IncFilteringRuleEvaluation's Touch).
+func IncFilteringShadowDecision(ruleID, wouldHave string) {
+	filteringRuleRegistry.Touch(ruleID)
+	FilteringShadowDecisionsTotal.WithLabelValues(ruleID, wouldHave).Inc()
+}
+
+// IncFilteringShadowDivergence records a shadow rule's decision diverging
+// from its enforce-mode counterpart - see Service.evaluateShadowRules.
+func IncFilteringShadowDivergence(ruleID string) {
+	filteringRuleRegistry.Touch(ruleID)
+	FilteringShadowDivergenceTotal.WithLabelValues(ruleID).Inc()
 }
 
 func IncFilteringRuleEvaluation(ruleID, ruleName, result string) {
-	FilteringRuleEvaluationsTotal.WithLabelValues(ruleID, ruleName, result).Inc()
+	const tenant = ""
+	filteringRuleRegistry.Touch(ruleID)
+	FilteringRuleEvaluationsTotal.WithLabelValues(ruleID, ruleName, result, tenant).Inc()
+	otelFilteringRuleEvaluationsTotal.Inc(context.Background(), ruleID, ruleName, result, tenant)
+}
+
+// ObserveFilteringRuleEvalDuration records a single rule's evaluation time
+// against FilteringRuleEvalDuration. Cache hits/misses for the compiled CEL
+// program behind that evaluation are already tracked per-cache by
+// IncCELProgramCacheHit/IncCELProgramCacheMiss (CELProgramCacheHitsTotal{
+// cache="filter"}) - there's no separate filtering-specific cache-hits
+// counter, since the cache itself (pkg/cel.Evaluator.filterCache) isn't
+// filtering-specific either.
+func ObserveFilteringRuleEvalDuration(ruleID string, duration time.Duration) {
+	filteringRuleRegistry.Touch(ruleID)
+	FilteringRuleEvalDuration.WithLabelValues(ruleID).Observe(duration.Seconds())
+}
+
+// IncFilteringRuleCostExceeded records a filtering rule's evaluation
+// aborting because it crossed its resolved cel.EvalBudget - see
+// FilteringRuleCostExceededTotal's doc comment.
+func IncFilteringRuleCostExceeded(ruleID string) {
+	filteringRuleRegistry.Touch(ruleID)
+	FilteringRuleCostExceededTotal.WithLabelValues(ruleID).Inc()
 }
 
+// IncEnrichmentRuleApplication records a rule application outcome and marks
+// ruleID active with enrichmentRuleRegistry. tenant is currently always ""
+// - see EnrichmentRuleApplicationsTotal's doc comment.
 func IncEnrichmentRuleApplication(ruleID, ruleName, status string) {
-	EnrichmentRuleApplicationsTotal.WithLabelValues(ruleID, ruleName, status).Inc()
+	const tenant = ""
+	enrichmentRuleRegistry.Touch(ruleID)
+	EnrichmentRuleApplicationsTotal.WithLabelValues(ruleID, ruleName, status, tenant).Inc()
 }
 
 func IncEnrichmentTransformation(ruleID, ruleName, status string) {
+	enrichmentRuleRegistry.Touch(ruleID)
 	EnrichmentTransformationsTotal.WithLabelValues(ruleID, ruleName, status).Inc()
 }
 
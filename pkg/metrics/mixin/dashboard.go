@@ -0,0 +1,89 @@
+package mixin
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"yeti/internal/config"
+)
+
+// dashboard is a minimal subset of Grafana's dashboard JSON model - just
+// enough to render the panels this package generates.
+type dashboard struct {
+	Title         string           `json:"title"`
+	SchemaVersion int              `json:"schemaVersion"`
+	Panels        []dashboardPanel `json:"panels"`
+}
+
+type dashboardPanel struct {
+	ID      int           `json:"id"`
+	Title   string        `json:"title"`
+	Type    string        `json:"type"`
+	GridPos gridPos       `json:"gridPos"`
+	Targets []panelTarget `json:"targets"`
+}
+
+type gridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type panelTarget struct {
+	Expr         string `json:"expr"`
+	LegendFormat string `json:"legendFormat,omitempty"`
+}
+
+// buildDashboard lays out one full-width panel per row: the four fixed
+// panels the request names (message throughput, Kafka consumer lag by
+// partition, circuit breaker state timeline, dedup cache hit ratio), then
+// one "error budget remaining" panel per configured SLO target.
+func buildDashboard(cfg config.SLOConfig) dashboard {
+	d := dashboard{Title: "Yeti Pipeline SLOs", SchemaVersion: 36}
+
+	addPanel := func(title, panelType string, targets ...panelTarget) {
+		d.Panels = append(d.Panels, dashboardPanel{
+			ID:      len(d.Panels) + 1,
+			Title:   title,
+			Type:    panelType,
+			GridPos: gridPos{H: 8, W: 24, X: 0, Y: len(d.Panels) * 8},
+			Targets: targets,
+		})
+	}
+
+	addPanel("Message throughput", "timeseries",
+		panelTarget{Expr: "sum(rate(filtering_messages_total[5m])) by (status)", LegendFormat: "filtering {{status}}"},
+		panelTarget{Expr: "sum(rate(dedup_messages_total[5m])) by (status)", LegendFormat: "dedup {{status}}"},
+		panelTarget{Expr: "sum(rate(enrichment_messages_total[5m])) by (status)", LegendFormat: "enrichment {{status}}"},
+	)
+
+	addPanel("Kafka consumer lag by partition", "timeseries",
+		panelTarget{Expr: "sum(kafka_consumer_lag) by (topic, partition)", LegendFormat: "{{topic}}/{{partition}}"},
+	)
+
+	addPanel("Circuit breaker state", "state-timeline",
+		panelTarget{Expr: "circuit_breaker_state", LegendFormat: "{{name}}"},
+	)
+
+	// DeduplicateMessagesTotal's "status" label distinguishes a duplicate
+	// (cache hit) from a unique message (cache miss) - there's no
+	// dedicated dedup cache hit/miss counter today, so this is the closest
+	// available proxy for "dedup cache hit ratio".
+	addPanel("Dedup cache hit ratio", "timeseries",
+		panelTarget{Expr: `sum(rate(dedup_messages_total{status="duplicate"}[5m])) / sum(rate(dedup_messages_total[5m]))`},
+	)
+
+	for _, target := range cfg.Targets {
+		allowedBadRatio := 1 - target.Objective
+		addPanel(fmt.Sprintf("%s error budget remaining (%s)", target.Name, target.Window), "timeseries",
+			panelTarget{Expr: fmt.Sprintf("1 - (%s / %g)", badRatioMetric(target, "1h"), allowedBadRatio)},
+		)
+	}
+
+	return d
+}
+
+func marshalDashboard(d dashboard) ([]byte, error) {
+	return json.MarshalIndent(d, "", "  ")
+}
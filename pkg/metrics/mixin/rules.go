@@ -0,0 +1,93 @@
+package mixin
+
+import (
+	"fmt"
+
+	"yeti/internal/config"
+)
+
+// ruleFile mirrors Prometheus's rule file format
+// (https://prometheus.io/docs/prometheus/latest/configuration/recording_rules/),
+// just enough of it for the recording/alert rules this package emits.
+type ruleFile struct {
+	Groups []ruleGroup `yaml:"groups"`
+}
+
+type ruleGroup struct {
+	Name  string `yaml:"name"`
+	Rules []rule `yaml:"rules"`
+}
+
+type rule struct {
+	Record      string            `yaml:"record,omitempty"`
+	Alert       string            `yaml:"alert,omitempty"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// buildRuleFile emits one recording-rules group (the bad-event ratio for
+// every target, at every window a burn-rate alert needs) and one
+// alert-rules group (one multi-window multi-burn-rate alert per target per
+// burnRateWindow pair).
+func buildRuleFile(cfg config.SLOConfig) ruleFile {
+	recording := ruleGroup{Name: "yeti_slo_recording_rules"}
+	alerting := ruleGroup{Name: "yeti_slo_alert_rules"}
+
+	for _, target := range cfg.Targets {
+		for _, window := range recordingWindows {
+			recording.Rules = append(recording.Rules, rule{
+				Record: badRatioMetric(target, window),
+				Expr:   badRatioExpr(target, window),
+			})
+		}
+
+		for _, brw := range burnRateWindows {
+			threshold := brw.factor * (1 - target.Objective)
+			alerting.Rules = append(alerting.Rules, rule{
+				Alert: target.Name + "ErrorBudgetBurn",
+				Expr: fmt.Sprintf("%s > %g and %s > %g",
+					badRatioMetric(target, brw.short), threshold,
+					badRatioMetric(target, brw.long), threshold,
+				),
+				For: brw.forDuration,
+				Labels: map[string]string{
+					"severity": brw.severity,
+					"slo":      target.Name,
+					"window":   brw.short + "_" + brw.long,
+				},
+				Annotations: map[string]string{
+					"summary": fmt.Sprintf("%s is burning its %s error budget %gx faster than sustainable (%s/%s window)",
+						target.Name, target.Window, brw.factor, brw.short, brw.long),
+				},
+			})
+		}
+	}
+
+	return ruleFile{Groups: []ruleGroup{recording, alerting}}
+}
+
+// badRatioMetric is the recording rule name for target's "fraction of bad
+// events" ratio over window, e.g. "slo:filtering_latency:bad_ratio_rate5m".
+func badRatioMetric(target config.SLOTarget, window string) string {
+	return fmt.Sprintf("slo:%s:bad_ratio_rate%s", target.Name, window)
+}
+
+// badRatioExpr builds the PromQL for target's bad-event ratio over window:
+// for a "latency" target, the fraction of observations slower than
+// ThresholdMs (via the histogram's classic buckets - ThresholdMs must match
+// one of that metric's bucket boundaries); for an "error_rate" target, the
+// fraction of events labeled status="error".
+func badRatioExpr(target config.SLOTarget, window string) string {
+	if target.Kind == "latency" {
+		return fmt.Sprintf(
+			`1 - (sum(rate(%s_bucket{le="%g"}[%s])) / sum(rate(%s_bucket{le="+Inf"}[%s])))`,
+			target.Metric, target.ThresholdMs, window, target.Metric, window,
+		)
+	}
+	return fmt.Sprintf(
+		`sum(rate(%s{status="error"}[%s])) / sum(rate(%s[%s]))`,
+		target.Metric, window, target.Metric, window,
+	)
+}
@@ -0,0 +1,102 @@
+package otel
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelmetric "go.opentelemetry.io/otel/metric"
+)
+
+// Counter mirrors a prometheus.CounterVec as an OTEL Float64Counter sharing
+// the same name and label keys, so pkg/metrics' IncXxx/AddXxx helpers can
+// record to both backends with one extra line at the call site. Recording
+// is best-effort: a Counter created before Init (or with a misconfigured
+// exporter) silently no-ops rather than panicking, since the Prometheus
+// recording it sits alongside must never be affected by OTEL export state.
+type Counter struct {
+	instrument otelmetric.Float64Counter
+	labelKeys  []string
+}
+
+func NewCounter(meter otelmetric.Meter, name, help string, labelKeys ...string) *Counter {
+	instrument, err := meter.Float64Counter(name, otelmetric.WithDescription(help))
+	if err != nil {
+		return &Counter{labelKeys: labelKeys}
+	}
+	return &Counter{instrument: instrument, labelKeys: labelKeys}
+}
+
+func (c *Counter) Inc(ctx context.Context, labelValues ...string) {
+	c.Add(ctx, 1, labelValues...)
+}
+
+func (c *Counter) Add(ctx context.Context, delta float64, labelValues ...string) {
+	if c.instrument == nil {
+		return
+	}
+	c.instrument.Add(ctx, delta, otelmetric.WithAttributes(attrs(c.labelKeys, labelValues)...))
+}
+
+// Histogram mirrors a prometheus.HistogramVec as an OTEL Float64Histogram.
+type Histogram struct {
+	instrument otelmetric.Float64Histogram
+	labelKeys  []string
+}
+
+func NewHistogram(meter otelmetric.Meter, name, help, unit string, labelKeys ...string) *Histogram {
+	instrument, err := meter.Float64Histogram(name,
+		otelmetric.WithDescription(help),
+		otelmetric.WithUnit(unit),
+	)
+	if err != nil {
+		return &Histogram{labelKeys: labelKeys}
+	}
+	return &Histogram{instrument: instrument, labelKeys: labelKeys}
+}
+
+func (h *Histogram) Observe(ctx context.Context, value float64, labelValues ...string) {
+	if h.instrument == nil {
+		return
+	}
+	h.instrument.Record(ctx, value, otelmetric.WithAttributes(attrs(h.labelKeys, labelValues)...))
+}
+
+// Gauge mirrors a prometheus.Gauge/GaugeVec as an OTEL synchronous
+// Float64Gauge (Set semantics - unlike Counter/Histogram, a gauge's last
+// recorded value is what gets exported, not a sum).
+type Gauge struct {
+	instrument otelmetric.Float64Gauge
+	labelKeys  []string
+}
+
+func NewGauge(meter otelmetric.Meter, name, help string, labelKeys ...string) *Gauge {
+	instrument, err := meter.Float64Gauge(name, otelmetric.WithDescription(help))
+	if err != nil {
+		return &Gauge{labelKeys: labelKeys}
+	}
+	return &Gauge{instrument: instrument, labelKeys: labelKeys}
+}
+
+func (g *Gauge) Set(ctx context.Context, value float64, labelValues ...string) {
+	if g.instrument == nil {
+		return
+	}
+	g.instrument.Record(ctx, value, otelmetric.WithAttributes(attrs(g.labelKeys, labelValues)...))
+}
+
+// attrs zips keys (a metric's fixed label names, in declaration order) with
+// the values a particular Inc/Observe/Set call passed, mirroring how
+// prometheus.CounterVec.WithLabelValues positionally matches its own
+// constructor's label names. A mismatched count drops the labels rather
+// than panicking or silently truncating wrong - see Counter's doc comment
+// on why this path stays best-effort.
+func attrs(keys, values []string) []attribute.KeyValue {
+	if len(keys) != len(values) {
+		return nil
+	}
+	out := make([]attribute.KeyValue, len(keys))
+	for i, k := range keys {
+		out[i] = attribute.String(k, values[i])
+	}
+	return out
+}
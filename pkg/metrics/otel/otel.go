@@ -0,0 +1,157 @@
+// Package otel mirrors the instruments registered by pkg/metrics with the
+// OpenTelemetry metrics API, so a deployment can push the same
+// counters/histograms/gauges to an OTLP collector instead of (or alongside)
+// scraping Prometheus. It follows pkg/tracing's Init/resource/exporter
+// shape closely on purpose - metrics and traces usually point at the same
+// collector, just a different OTLP signal.
+package otel
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	otelmetric "go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/semconv/v1.26.0"
+	"google.golang.org/grpc/credentials"
+
+	"yeti/internal/config"
+)
+
+const defaultExportInterval = 15 * time.Second
+
+// MeterProvider wraps the SDK MeterProvider Init builds, giving callers a
+// Shutdown they can defer without reaching into the OTEL SDK directly -
+// same role as tracing.TracerProvider.
+type MeterProvider struct {
+	mp *metric.MeterProvider
+}
+
+func (mp *MeterProvider) Shutdown(ctx context.Context) error {
+	if mp.mp != nil {
+		return mp.mp.Shutdown(ctx)
+	}
+	return nil
+}
+
+// Init sets the global OTEL MeterProvider from cfg. When cfg.Enabled is
+// false it installs a provider with no reader, so every Meter() call made
+// before or after Init still returns a cheap no-op instrument rather than
+// nil, matching tracing.Init's disabled-provider behavior.
+func Init(cfg config.MetricsConfig, serviceName string) (*MeterProvider, error) {
+	if !cfg.Enabled {
+		mp := metric.NewMeterProvider()
+		return &MeterProvider{mp: mp}, nil
+	}
+
+	if serviceName == "" {
+		serviceName = cfg.ServiceName
+	}
+	if serviceName == "" {
+		serviceName = "yeti-service"
+	}
+
+	res, err := resource.New(
+		context.Background(),
+		resource.WithAttributes(
+			semconv.ServiceNameKey.String(serviceName),
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	exporter, err := buildExporter(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP metric exporter: %w", err)
+	}
+
+	interval := time.Duration(cfg.ExportIntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = defaultExportInterval
+	}
+
+	mp := metric.NewMeterProvider(
+		metric.WithResource(res),
+		metric.WithReader(metric.NewPeriodicReader(exporter, metric.WithInterval(interval))),
+	)
+
+	otel.SetMeterProvider(mp)
+
+	return &MeterProvider{mp: mp}, nil
+}
+
+func buildExporter(ctx context.Context, cfg config.MetricsConfig) (metric.Exporter, error) {
+	switch cfg.Protocol {
+	case "http/protobuf":
+		opts := []otlpmetrichttp.Option{
+			otlpmetrichttp.WithEndpoint(cfg.OTLP.Endpoint),
+		}
+		if cfg.OTLP.Insecure {
+			opts = append(opts, otlpmetrichttp.WithInsecure())
+		}
+		if len(cfg.OTLP.Headers) > 0 {
+			opts = append(opts, otlpmetrichttp.WithHeaders(cfg.OTLP.Headers))
+		}
+		if cfg.OTLP.TLS.CertFile != "" {
+			tlsCfg, err := loadTLSConfig(cfg.OTLP.TLS)
+			if err != nil {
+				return nil, err
+			}
+			opts = append(opts, otlpmetrichttp.WithTLSClientConfig(tlsCfg))
+		}
+		return otlpmetrichttp.New(ctx, opts...)
+	case "grpc", "":
+		opts := []otlpmetricgrpc.Option{
+			otlpmetricgrpc.WithEndpoint(cfg.OTLP.Endpoint),
+		}
+		if cfg.OTLP.Insecure {
+			opts = append(opts, otlpmetricgrpc.WithInsecure())
+		}
+		if len(cfg.OTLP.Headers) > 0 {
+			opts = append(opts, otlpmetricgrpc.WithHeaders(cfg.OTLP.Headers))
+		}
+		if cfg.OTLP.TLS.CertFile != "" {
+			tlsCfg, err := loadTLSConfig(cfg.OTLP.TLS)
+			if err != nil {
+				return nil, err
+			}
+			opts = append(opts, otlpmetricgrpc.WithTLSCredentials(credentials.NewTLS(tlsCfg)))
+		}
+		return otlpmetricgrpc.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unsupported metrics protocol: %s", cfg.Protocol)
+	}
+}
+
+// loadTLSConfig is pkg/tracing.loadOTLPTLSConfig's metrics-side twin - kept
+// as a separate copy rather than an exported shared helper, since the two
+// packages otherwise have no dependency on each other and neither imports
+// the other just for this.
+func loadTLSConfig(cfg config.OTLPTLSConfig) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load OTLP client certificate: %w", err)
+	}
+	return &tls.Config{
+		Certificates:       []tls.Certificate{cert},
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}, nil
+}
+
+// Meter returns a named Meter off the global MeterProvider, exactly like
+// tracing.GetTracer does for the global TracerProvider. Safe to call before
+// Init - it resolves against OTEL's no-op provider until Init installs a
+// real one.
+func Meter(name string) otelmetric.Meter {
+	return otel.Meter(name)
+}
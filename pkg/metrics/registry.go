@@ -0,0 +1,160 @@
+package metrics
+
+import (
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// defaultRuleCardinalityCap bounds how many distinct rule_id label values a
+// Registry tracks at once, until a service calls SetRuleCardinalityCap with
+// config.MetricsConfig.RuleCardinalityCap.
+const defaultRuleCardinalityCap = 2000
+
+// ruleMetric is the subset of prometheus.CounterVec/GaugeVec/HistogramVec a
+// Registry needs to evict a single rule_id's series from, regardless of
+// what other labels (rule_name, status, result...) that metric also
+// carries - prometheus.MetricVec (embedded by all three Vec types)
+// implements it.
+type ruleMetric interface {
+	DeletePartialMatch(labels prometheus.Labels) int
+}
+
+// Registry bounds the rule_id cardinality of a group of rule-scoped
+// Prometheus metrics (FilteringRuleEvaluationsTotal,
+// EnrichmentRuleApplicationsTotal, and their siblings - see
+// filteringRuleRegistry/enrichmentRuleRegistry below). rule_id comes from
+// the management service's rule CRUD API, so unlike this package's other
+// labels (status, result, source...), which are drawn from a small fixed
+// enum, it can grow without bound as rules churn.
+//
+// Touch(ruleID) marks a rule_id active, evicting the least-recently-touched
+// one (across every metric in the group, via DeletePartialMatch) once the
+// configured cap is reached, and incrementing
+// MetricsDroppedHighCardinalityTotal so an operator can tell the cap is
+// binding. EvictRule(ruleID) removes a specific rule_id immediately,
+// without counting as a drop, for callers (management.service) that know
+// the rule is gone for good rather than just cold.
+type Registry struct {
+	mu       sync.Mutex
+	group    string
+	metrics  []ruleMetric
+	active   *lru.Cache[string, struct{}]
+	evicting bool // true while EvictRule's own Remove unwinds, so the shared onEvict below doesn't also count it as a cap-driven drop
+}
+
+// NewRegistry builds a Registry covering metrics, each keyed by a "rule_id"
+// label, with an initial cardinality cap of capacity (<= 0 uses
+// defaultRuleCardinalityCap). group labels MetricsDroppedHighCardinalityTotal
+// so a drop can be traced back to this Registry.
+func NewRegistry(capacity int, group string, metrics ...ruleMetric) *Registry {
+	if capacity <= 0 {
+		capacity = defaultRuleCardinalityCap
+	}
+	r := &Registry{group: group, metrics: metrics}
+	active, _ := lru.NewWithEvict[string, struct{}](capacity, func(ruleID string, _ struct{}) {
+		r.deleteRule(ruleID)
+		if !r.evicting {
+			MetricsDroppedHighCardinalityTotal.WithLabelValues(group).Inc()
+		}
+	})
+	r.active = active
+	return r
+}
+
+// Touch records ruleID as active, refreshing its recency so it's the last
+// one evicted under cap pressure.
+func (r *Registry) Touch(ruleID string) {
+	if ruleID == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.active.Add(ruleID, struct{}{})
+}
+
+// EvictRule immediately removes ruleID's series from every metric this
+// Registry covers and forgets it was ever active. Safe to call for a
+// ruleID Touch was never called with (e.g. a rule created and deleted
+// before it evaluated a single message).
+func (r *Registry) EvictRule(ruleID string) {
+	if ruleID == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.evicting = true
+	r.active.Remove(ruleID)
+	r.evicting = false
+	r.deleteRule(ruleID)
+}
+
+// Resize changes the cardinality cap, evicting (and counting as dropped)
+// whatever least-recently-touched rule_ids no longer fit if shrinking.
+func (r *Registry) Resize(capacity int) {
+	if capacity <= 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.active.Resize(capacity)
+}
+
+func (r *Registry) deleteRule(ruleID string) {
+	for _, m := range r.metrics {
+		m.DeletePartialMatch(prometheus.Labels{"rule_id": ruleID})
+	}
+}
+
+// filteringRuleRegistry/enrichmentRuleRegistry bound the two families of
+// rule-scoped metrics this package registers. They're declared here (rather
+// than lazily on first use) so Touch/EvictRule calls made before a service
+// finishes starting up still work, the same as the CounterVecs/GaugeVecs
+// they wrap.
+var (
+	filteringRuleRegistry = NewRegistry(defaultRuleCardinalityCap, "filtering_rules",
+		FilteringRuleEvaluationsTotal,
+		FilteringRuleEvalDuration,
+		FilteringShadowDecisionsTotal,
+		FilteringShadowDivergenceTotal,
+	)
+
+	enrichmentRuleRegistry = NewRegistry(defaultRuleCardinalityCap, "enrichment_rules",
+		EnrichmentRuleApplicationsTotal,
+		EnrichmentTransformationsTotal,
+		EnrichmentSingleflightSuppressed,
+		EnrichmentRuleThrottled,
+		EnrichmentRuleConcurrencyLimit,
+		EnrichmentBreakerState,
+	)
+)
+
+// SetRuleCardinalityCap resizes both rule-scoped Registries from
+// config.MetricsConfig.RuleCardinalityCap (<= 0 leaves each at
+// defaultRuleCardinalityCap). Call once during service startup, alongside
+// RegisterFilteringMetrics/RegisterEnrichmentMetrics.
+//
+// CELRuleBreakerState is also rule_id-labeled but isn't covered by either
+// Registry: cel.Evaluator serves both filtering and enrichment rules
+// through the same vec with no dimension saying which, so it's ambiguous
+// which Registry a given rule_id's eviction should belong to. Left as a
+// known gap rather than guessed at.
+func SetRuleCardinalityCap(capacity int) {
+	filteringRuleRegistry.Resize(capacity)
+	enrichmentRuleRegistry.Resize(capacity)
+}
+
+// EvictFilteringRule immediately drops ruleID's series from every
+// filtering rule-scoped metric. Called by management.service on filtering
+// rule delete.
+func EvictFilteringRule(ruleID string) {
+	filteringRuleRegistry.EvictRule(ruleID)
+}
+
+// EvictEnrichmentRule immediately drops ruleID's series from every
+// enrichment rule-scoped metric. Called by management.service on
+// enrichment rule delete.
+func EvictEnrichmentRule(ruleID string) {
+	enrichmentRuleRegistry.EvictRule(ruleID)
+}
@@ -5,8 +5,22 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"yeti/pkg/logging"
 )
 
+// TraceContextMiddleware attaches the active OpenTelemetry span's trace and
+// span IDs (set by tracing.GinMiddleware earlier in the chain) to the
+// request context via logging.WithTraceContext, so every *wCtx log call
+// made while handling this request correlates with the span visible in
+// Jaeger/Tempo. It's a no-op when tracing is disabled or no span is active.
+func TraceContextMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(logging.WithTraceContext(c.Request.Context()))
+		c.Next()
+	}
+}
+
 func LoggerMiddleware(logger interface {
 	Infow(msg string, keysAndValues ...interface{})
 	Errorw(msg string, keysAndValues ...interface{})
@@ -48,21 +62,9 @@ func LoggerMiddleware(logger interface {
 	}
 }
 
-func RecoveryMiddleware(logger interface {
-	Errorw(msg string, keysAndValues ...interface{})
-}) gin.HandlerFunc {
-	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
-		logger.Errorw("Panic recovered",
-			"error", recovered,
-			"path", c.Request.URL.Path,
-			"method", c.Request.Method,
-		)
-		c.AbortWithStatusJSON(500, gin.H{
-			"error":      "internal server error",
-			"error_code": "INTERNAL_ERROR",
-		})
-	})
-}
+// RecoveryMiddleware lives in recovery.go, built on errors.ErrInternal/
+// errors.ToProblemDetails and PanicsTotal instead of the ad hoc JSON body
+// this file used to return directly.
 
 func RequestIDMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -0,0 +1,154 @@
+package middleware
+
+import (
+	"context"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"yeti/internal/logger"
+	"yeti/pkg/errors"
+	"yeti/pkg/logging"
+	"yeti/pkg/metrics"
+)
+
+// RecoveryHandler converts a recovered panic value into the error returned
+// to the caller. The default, DefaultRecoveryHandler, wraps it in
+// errors.ErrInternal the same way every other Service-layer failure is
+// wrapped - see pkg/errors.ToHTTPStatus/ToProblemDetails.
+type RecoveryHandler func(ctx context.Context, panicVal interface{}) error
+
+// DefaultRecoveryHandler wraps panicVal in errors.ErrInternal so it flows
+// through the same errors.ToProblemDetails/ToHTTPStatus path a handled
+// Service error would.
+func DefaultRecoveryHandler(_ context.Context, panicVal interface{}) error {
+	return errors.ErrInternal.WithDetail("panic", panicVal)
+}
+
+// RecoveryConfig configures RecoveryMiddlewareWithConfig and
+// UnaryPanicInterceptor/StreamPanicInterceptor. Subsystem labels the
+// yeti_panics_total counter (metrics.IncPanic) so an operator can tell a
+// spike in the REST API apart from one in a gRPC server sharing the same
+// process.
+type RecoveryConfig struct {
+	Logger    logger.Logger
+	Subsystem string
+	// Handler builds the error surfaced to the caller from the recovered
+	// value. Defaults to DefaultRecoveryHandler when nil.
+	Handler RecoveryHandler
+	// IncludeStackTrace adds the captured stack trace as an error detail
+	// (REST) or a log field (gRPC). Leave false in production - a stack
+	// trace in a client-visible error body is a dev/staging aid, not
+	// something to ship to external callers.
+	IncludeStackTrace bool
+}
+
+func (cfg RecoveryConfig) handler() RecoveryHandler {
+	if cfg.Handler != nil {
+		return cfg.Handler
+	}
+	return DefaultRecoveryHandler
+}
+
+// RecoveryMiddleware is RecoveryMiddlewareWithConfig with
+// DefaultRecoveryHandler and no stack trace in the response body -
+// existing callers (e.g. cmd/management-service/app.go's router chain)
+// keep their current behavior unchanged.
+func RecoveryMiddleware(log logger.Logger) gin.HandlerFunc {
+	return RecoveryMiddlewareWithConfig(RecoveryConfig{Logger: log, Subsystem: "http"})
+}
+
+// RecoveryMiddlewareWithConfig is gin's recovery middleware, rebuilt on
+// errors.ErrInternal/errors.ToProblemDetails instead of an ad hoc JSON
+// body, so a panicking handler produces the same response shape
+// BaseHandler.HandleError would for any other internal error.
+func RecoveryMiddlewareWithConfig(cfg RecoveryConfig) gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
+		ctx := c.Request.Context()
+		stack := debug.Stack()
+		subsystem := cfg.Subsystem
+		if subsystem == "" {
+			subsystem = "http"
+		}
+		metrics.IncPanic(subsystem)
+
+		cfg.Logger.ErrorwCtx(ctx, "Panic recovered",
+			"error", recovered,
+			"path", c.Request.URL.Path,
+			"method", c.Request.Method,
+			"stack", string(stack),
+		)
+
+		appErr := cfg.handler()(ctx, recovered)
+		if cfg.IncludeStackTrace {
+			if wrapped, ok := appErr.(*errors.Error); ok {
+				appErr = wrapped.WithDetail("stack", string(stack))
+			}
+		}
+
+		errors.WriteHTTPError(c.Writer, appErr, logging.GetTraceID(ctx))
+		c.Abort()
+	})
+}
+
+// panicToStatus is UnaryPanicInterceptor/StreamPanicInterceptor's shared
+// panic handling: log with stack trace, bump PanicsTotal, and convert the
+// recovered value (already pulled off the stack by recover() in the
+// caller's deferred func - recover only works called directly from a
+// defer, so it can't live in this helper) into a gRPC status the same way
+// toGRPCStatus (see internal/management/grpcserver.go) converts a
+// returned error - a panic ends up indistinguishable from an explicit
+// errors.ErrInternal return to the client.
+func panicToStatus(ctx context.Context, cfg RecoveryConfig, method string, recovered interface{}) error {
+	stack := debug.Stack()
+	subsystem := cfg.Subsystem
+	if subsystem == "" {
+		subsystem = "grpc"
+	}
+	metrics.IncPanic(subsystem)
+
+	cfg.Logger.ErrorwCtx(ctx, "gRPC panic recovered",
+		"error", recovered,
+		"method", method,
+		"stack", string(stack),
+	)
+
+	appErr := cfg.handler()(ctx, recovered)
+	detail := appErr.Error()
+	if cfg.IncludeStackTrace {
+		detail = detail + "\n" + string(stack)
+	}
+	return status.Error(codes.Internal, detail)
+}
+
+// UnaryPanicInterceptor is a grpc.UnaryServerInterceptor that recovers a
+// panic raised anywhere in handler (e.g. inside a
+// managementpb.FilteringRulesServer method) into the same gRPC status a
+// returned errors.ErrInternal would produce, instead of letting it crash
+// the server's connection goroutine.
+func UnaryPanicInterceptor(cfg RecoveryConfig) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = panicToStatus(ctx, cfg, info.FullMethod, r)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// StreamPanicInterceptor is UnaryPanicInterceptor's streaming-RPC
+// counterpart.
+func StreamPanicInterceptor(cfg RecoveryConfig) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = panicToStatus(ss.Context(), cfg, info.FullMethod, r)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
@@ -0,0 +1,225 @@
+package migrations
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// IndexModelsFromTags reflects over model's fields and builds the
+// mongo.IndexModel set they declare, keeping index definitions co-located
+// with the Go type they cover instead of hand-maintained alongside it in a
+// migration step (and drifting from it).
+//
+// Single-field indexes are declared with an `index` tag on the field:
+//
+//	Priority int `bson:"priority" index:"-1"`
+//
+// The tag's first, required part is the sort direction ("1" or "-1").
+// Remaining comma-separated parts are modifiers: "unique", "sparse",
+// "expireAfterSeconds=<n>" (TTL), "allowNull" - an alternative to "sparse"
+// that excludes missing-field documents via partialFilterExpression rather
+// than Mongo's native sparse option, for a field that still needs to combine
+// with another partial condition later - and "name=<name>", to keep a
+// pre-existing hand-written index name instead of the derived
+// "idx_<collection>_<fields>" one.
+//
+// Compound indexes are declared on a blank ("_") field with an `indexes`
+// tag, since a compound index isn't owned by any single field:
+//
+//	_ struct{} `indexes:"enabled:1,priority:-1|enabled:1,field_to_enrich:1,priority:-1;unique"`
+//
+// Groups are separated by "|"; each group is "field:dir,field:dir[;modifier,modifier]".
+//
+// collection names the target collection, used only to generate
+// deterministic index names in this package's existing "idx_<collection>_<fields>"
+// style.
+func IndexModelsFromTags(collection string, model interface{}) ([]mongo.IndexModel, error) {
+	t := reflect.TypeOf(model)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("index tags require a struct, got %s", t.Kind())
+	}
+
+	var models []mongo.IndexModel
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if tag, ok := field.Tag.Lookup("indexes"); ok {
+			compound, err := buildCompoundIndexes(collection, tag)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", field.Name, err)
+			}
+			models = append(models, compound...)
+			continue
+		}
+
+		tag, ok := field.Tag.Lookup("index")
+		if !ok {
+			continue
+		}
+		fieldName := bsonFieldName(field)
+		if fieldName == "" {
+			return nil, fmt.Errorf("field %s has an index tag but no usable bson name", field.Name)
+		}
+
+		model, err := buildFieldIndex(collection, fieldName, tag)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		models = append(models, model)
+	}
+
+	return models, nil
+}
+
+// bsonFieldName returns the name a field is stored under, the same name the
+// index its tag declares has to key on - its bson tag's name component, or
+// its Go field name lowercased if the field has no bson tag.
+func bsonFieldName(field reflect.StructField) string {
+	bsonTag, ok := field.Tag.Lookup("bson")
+	if !ok {
+		return strings.ToLower(field.Name)
+	}
+	name := strings.Split(bsonTag, ",")[0]
+	if name == "" || name == "-" {
+		return strings.ToLower(field.Name)
+	}
+	return name
+}
+
+func buildFieldIndex(collection, fieldName, tag string) (mongo.IndexModel, error) {
+	parts := strings.Split(tag, ",")
+	direction, err := parseDirection(parts[0])
+	if err != nil {
+		return mongo.IndexModel{}, err
+	}
+
+	name, modifiers := extractNameOverride(indexName(collection, []string{fieldName}), parts[1:])
+	opts := options.Index().SetName(name)
+	for _, modifier := range modifiers {
+		if err := applyModifier(opts, bson.M{fieldName: bson.M{"$exists": true}}, modifier); err != nil {
+			return mongo.IndexModel{}, err
+		}
+	}
+
+	return mongo.IndexModel{
+		Keys:    bson.D{{Key: fieldName, Value: direction}},
+		Options: opts,
+	}, nil
+}
+
+func buildCompoundIndexes(collection, tag string) ([]mongo.IndexModel, error) {
+	var models []mongo.IndexModel
+	for _, group := range strings.Split(tag, "|") {
+		group = strings.TrimSpace(group)
+		if group == "" {
+			continue
+		}
+
+		fieldsPart := group
+		var modifiers []string
+		if idx := strings.Index(group, ";"); idx >= 0 {
+			fieldsPart = group[:idx]
+			modifiers = strings.Split(group[idx+1:], ",")
+		}
+
+		var keys bson.D
+		var names []string
+		partialFilter := bson.M{}
+		for _, fieldSpec := range strings.Split(fieldsPart, ",") {
+			nameDir := strings.SplitN(strings.TrimSpace(fieldSpec), ":", 2)
+			if len(nameDir) != 2 {
+				return nil, fmt.Errorf("compound index field %q must be name:direction", fieldSpec)
+			}
+			direction, err := parseDirection(nameDir[1])
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, bson.E{Key: nameDir[0], Value: direction})
+			names = append(names, nameDir[0])
+			partialFilter[nameDir[0]] = bson.M{"$exists": true}
+		}
+
+		name, modifiers := extractNameOverride(indexName(collection, names), modifiers)
+		opts := options.Index().SetName(name)
+		for _, modifier := range modifiers {
+			if err := applyModifier(opts, partialFilter, modifier); err != nil {
+				return nil, err
+			}
+		}
+
+		models = append(models, mongo.IndexModel{Keys: keys, Options: opts})
+	}
+
+	return models, nil
+}
+
+func parseDirection(raw string) (int, error) {
+	switch strings.TrimSpace(raw) {
+	case "1":
+		return 1, nil
+	case "-1":
+		return -1, nil
+	default:
+		return 0, fmt.Errorf("invalid index direction %q, want 1 or -1", raw)
+	}
+}
+
+// applyModifier mutates opts for one comma-split modifier token. partialFilter
+// is the $exists filter "allowNull" falls back to if it's used - built from
+// whichever field(s) the index covers.
+func applyModifier(opts *options.IndexOptions, partialFilter bson.M, modifier string) error {
+	modifier = strings.TrimSpace(modifier)
+	switch {
+	case modifier == "":
+		return nil
+	case modifier == "unique":
+		opts.SetUnique(true)
+	case modifier == "sparse":
+		opts.SetSparse(true)
+	case modifier == "allowNull":
+		opts.SetPartialFilterExpression(partialFilter)
+	case strings.HasPrefix(modifier, "expireAfterSeconds="):
+		seconds, err := strconv.Atoi(strings.TrimPrefix(modifier, "expireAfterSeconds="))
+		if err != nil {
+			return fmt.Errorf("invalid expireAfterSeconds modifier %q: %w", modifier, err)
+		}
+		opts.SetExpireAfterSeconds(int32(seconds))
+	default:
+		return fmt.Errorf("unknown index modifier %q", modifier)
+	}
+	return nil
+}
+
+// indexName generates this package's existing "idx_<collection>_<fields>"
+// naming convention deterministically from the fields an index covers, so a
+// tag-driven index lines up with the name a hand-written one would have had.
+func indexName(collection string, fields []string) string {
+	return "idx_" + collection + "_" + strings.Join(fields, "_")
+}
+
+// extractNameOverride looks for a "name=<name>" modifier and, if present,
+// returns it in place of fallback (with that modifier removed from the
+// returned list) - an escape hatch for a tagged index that needs to keep a
+// pre-existing hand-written name (e.g. one abbreviated from its field list)
+// rather than the one indexName would derive.
+func extractNameOverride(fallback string, modifiers []string) (string, []string) {
+	kept := modifiers[:0:0]
+	name := fallback
+	for _, modifier := range modifiers {
+		if n, ok := strings.CutPrefix(strings.TrimSpace(modifier), "name="); ok {
+			name = n
+			continue
+		}
+		kept = append(kept, modifier)
+	}
+	return name, kept
+}
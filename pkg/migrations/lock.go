@@ -0,0 +1,84 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// lockKey is the single Redis key contended for exclusive migration-running
+// rights across Yeti instances. A bare idempotent CreateMany/CREATE TABLE
+// doesn't fully solve "multiple applications provisioning original
+// collections on first boot": two processes can both observe nothing
+// present and both race CreateMany, one of them returning a transient error
+// instead of "already exists". DistributedLock makes Run hold the same kind
+// of SETNX-based lease scheduler.LeaderElector uses for schedule-evaluation
+// leadership and deduplication.RedisRepository uses for fingerprint locking,
+// so only one instance actually runs migrations at a time.
+const lockKey = "migrations:lock"
+
+// releaseScript deletes lockKey only if it still holds ARGV[1] - this
+// holder's id - so Release can never delete a lease some other instance
+// acquired after this one's expired. A plain GET-then-DEL can't tell the
+// two apart: the GET can observe this holder's own (already-expired) value
+// a moment before another instance's SETNX replaces it, and the DEL that
+// follows then deletes the new holder's lock instead, letting a third
+// instance jump the queue while the second believes it still holds
+// exclusive rights. Same pattern as deduplication.Lock's unlockScript.
+const releaseScript = `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+  return redis.call("DEL", KEYS[1])
+else
+  return 0
+end
+`
+
+var releaseLuaScript = redis.NewScript(releaseScript)
+
+// DistributedLock is a Redis SETNX-with-TTL mutual exclusion lock held by
+// Run for the duration of a migration pass.
+type DistributedLock struct {
+	client *redis.Client
+	id     string
+	ttl    time.Duration
+}
+
+// NewDistributedLock returns a lock contending lockKey under holderID, which
+// should be unique per process (e.g. hostname:pid) so Release can tell its
+// own lease apart from one still held by another instance.
+func NewDistributedLock(client *redis.Client, holderID string, ttl time.Duration) *DistributedLock {
+	return &DistributedLock{client: client, id: holderID, ttl: ttl}
+}
+
+// AcquireBlocking polls every pollInterval until it holds the lock or ctx is
+// done, since instances starting at the same time should wait their turn to
+// migrate rather than give up outright.
+func (l *DistributedLock) AcquireBlocking(ctx context.Context, pollInterval time.Duration) error {
+	for {
+		acquired, err := l.client.SetNX(ctx, lockKey, l.id, l.ttl).Result()
+		if err != nil {
+			return fmt.Errorf("redis migration lock SETNX failed: %w", err)
+		}
+		if acquired {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for migration lock: %w", ctx.Err())
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// Release gives up the lock if this holder still holds it, so a surviving
+// instance that also wants to run migrations doesn't have to wait out the
+// full TTL.
+func (l *DistributedLock) Release(ctx context.Context) error {
+	if err := releaseLuaScript.Run(ctx, l.client, []string{lockKey}, l.id).Err(); err != nil {
+		return fmt.Errorf("redis migration lock release script failed: %w", err)
+	}
+	return nil
+}
@@ -2,65 +2,299 @@ package migrations
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
-	"strings"
+	"sort"
+	"sync"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"yeti/internal/management"
+)
+
+// IndexReport records whether one named index on a Mongo collection was
+// newly created by a step's Apply or was already present, so an operator
+// can audit "index declared but not present" drift instead of a step
+// silently swallowing CreateMany's "already exists" error.
+type IndexReport struct {
+	Collection string
+	Name       string
+	Created    bool
+}
+
+// MongoStep is one numbered, idempotent migration step - an index-ensure or
+// a BSON transform over existing documents - applied to a MongoDB database.
+// Unlike the SQL runner, a step has no paired Down: Mongo migrations here
+// are additive (new indexes, field backfills) rather than schema changes
+// that need reversing. Checksum is filled in by Register, not authored by
+// hand - a Mongo step is a Go func, not a file, so there's no script body to
+// hash the way SQLMigration's Up/Down are; it instead fingerprints the
+// step's Version/Name/Description so a later registration that changes one
+// without bumping Version is at least detectable.
+type MongoStep struct {
+	Version     int
+	Name        string
+	Description string
+	Checksum    string
+	Apply       func(ctx context.Context, db *mongo.Database) ([]IndexReport, error)
+}
+
+// mongoSchemaMigration is the schema_migrations collection's document shape.
+type mongoSchemaMigration struct {
+	Version   int       `bson:"_id"`
+	Name      string    `bson:"name"`
+	Checksum  string    `bson:"checksum"`
+	AppliedAt time.Time `bson:"applied_at"`
+}
+
+var (
+	registeredMu sync.Mutex
+	registered   []MongoStep
 )
 
-func EnsureMongoCollection(ctx context.Context, db *mongo.Database) error {
-	collection := db.Collection("enrichment_rules")
+// Register adds step to the set Run/RunMongoMigrations apply, computing its
+// Checksum along the way. Steps are applied in Version order regardless of
+// registration order, so out-of-tree callers can Register their own steps
+// in an init() alongside this package's.
+func Register(step MongoStep) {
+	step.Checksum = stepChecksum(step)
+	registeredMu.Lock()
+	registered = append(registered, step)
+	registeredMu.Unlock()
+}
+
+// Registered returns every step Register has recorded, sorted by Version.
+func Registered() []MongoStep {
+	registeredMu.Lock()
+	steps := make([]MongoStep, len(registered))
+	copy(steps, registered)
+	registeredMu.Unlock()
+	sort.Slice(steps, func(i, j int) bool { return steps[i].Version < steps[j].Version })
+	return steps
+}
+
+func stepChecksum(step MongoStep) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d:%s:%s", step.Version, step.Name, step.Description)))
+	return hex.EncodeToString(sum[:])
+}
+
+func init() {
+	Register(MongoStep{
+		Version:     1,
+		Name:        "ensure_enrichment_rules_indexes",
+		Description: "Index enrichment_rules for the lookups EnrichmentRepository needs: enabled+priority, priority alone, updated_at, field_to_enrich, and the combined enabled+field_to_enrich+priority path.",
+		Apply:       ensureEnrichmentRulesIndexes,
+	})
+	Register(MongoStep{
+		Version:     2,
+		Name:        "ensure_enrichment_rules_audit_indexes",
+		Description: "Index enrichment_rules_audit on (rule_id, version) for GetEnrichmentRuleHistory.",
+		Apply:       ensureEnrichmentRulesAuditIndexes,
+	})
+	Register(MongoStep{
+		Version:     3,
+		Name:        "ensure_sequences_bootstrap",
+		Description: "Seed the sequences collection's enrichment_rules group from the current enrichment_rules document count, so sequences.Repository.Next starts numbering past whatever rules already exist.",
+		Apply:       EnsureSequences,
+	})
+}
+
+// DefaultMongoSteps returns the migration steps a fresh or upgrading
+// enrichment_rules collection needs, in order. It's Registered's result,
+// kept under its own name since existing RunMongoMigrations callers already
+// spell it that way.
+func DefaultMongoSteps() []MongoStep {
+	return Registered()
+}
+
+// StepReport is one step's outcome from a Run, applied or (in dry-run mode)
+// merely reported as pending.
+type StepReport struct {
+	Version int
+	Name    string
+	DryRun  bool
+	Indexes []IndexReport
+}
+
+// Report is the structured result of a migration run.
+type Report struct {
+	// Steps lists, in version order, every step that wasn't already
+	// recorded as applied - i.e. what Run did (or, in dry-run mode, would
+	// do).
+	Steps []StepReport
+}
+
+// RunOptions configures Run beyond RunMongoMigrations' fixed behavior.
+type RunOptions struct {
+	// DryRun, if true, reports which steps and indexes would be applied
+	// without writing anything: Apply is never called and nothing is
+	// recorded in schema_migrations.
+	DryRun bool
+	// Lock, if set, is held for the duration of the run so that concurrent
+	// Yeti instances starting at once serialize rather than race
+	// CreateMany/collection creation against an empty database.
+	Lock *DistributedLock
+}
+
+// Run applies every step in steps that isn't already recorded in the
+// schema_migrations collection, in version order, optionally under a
+// DistributedLock and/or in dry-run mode, and returns a structured Report of
+// what happened (or, for dry-run, what would).
+func Run(ctx context.Context, db *mongo.Database, steps []MongoStep, opts RunOptions) (*Report, error) {
+	if opts.Lock != nil {
+		if err := opts.Lock.AcquireBlocking(ctx, 500*time.Millisecond); err != nil {
+			return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		defer opts.Lock.Release(ctx)
+	}
+
+	collection := db.Collection("schema_migrations")
 
-	collections, err := db.ListCollectionNames(ctx, map[string]interface{}{"name": "enrichment_rules"})
+	applied := map[int]bool{}
+	cursor, err := collection.Find(ctx, bson.M{})
 	if err != nil {
-		return fmt.Errorf("failed to list collections: %w", err)
+		return nil, fmt.Errorf("failed to query schema_migrations: %w", err)
+	}
+	var records []mongoSchemaMigration
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("failed to decode schema_migrations: %w", err)
+	}
+	for _, r := range records {
+		applied[r.Version] = true
 	}
 
-	collectionExists := false
-	for _, name := range collections {
-		if name == "enrichment_rules" {
-			collectionExists = true
-			break
+	report := &Report{}
+	for _, step := range steps {
+		if applied[step.Version] {
+			continue
 		}
-	}
 
-	indexes := []mongo.IndexModel{
-		{
-			Keys:    bson.D{{Key: "enabled", Value: 1}, {Key: "priority", Value: -1}},
-			Options: options.Index().SetName("idx_enrichment_rules_enabled_priority"),
-		},
-		{
-			Keys:    bson.D{{Key: "priority", Value: -1}},
-			Options: options.Index().SetName("idx_enrichment_rules_priority"),
-		},
-		{
-			Keys:    bson.D{{Key: "updated_at", Value: -1}},
-			Options: options.Index().SetName("idx_enrichment_rules_updated_at"),
-		},
-		{
-			Keys:    bson.D{{Key: "field_to_enrich", Value: 1}},
-			Options: options.Index().SetName("idx_enrichment_rules_field_to_enrich"),
-		},
-		{
-			Keys:    bson.D{{Key: "enabled", Value: 1}, {Key: "field_to_enrich", Value: 1}, {Key: "priority", Value: -1}},
-			Options: options.Index().SetName("idx_enrichment_rules_enabled_field_priority"),
-		},
+		if opts.DryRun {
+			report.Steps = append(report.Steps, StepReport{Version: step.Version, Name: step.Name, DryRun: true})
+			continue
+		}
+
+		indexes, err := step.Apply(ctx, db)
+		if err != nil {
+			return report, fmt.Errorf("failed to apply mongo migration %d (%s): %w", step.Version, step.Name, err)
+		}
+		report.Steps = append(report.Steps, StepReport{Version: step.Version, Name: step.Name, Indexes: indexes})
+
+		record := mongoSchemaMigration{Version: step.Version, Name: step.Name, Checksum: step.Checksum, AppliedAt: time.Now()}
+		if _, err := collection.InsertOne(ctx, record); err != nil {
+			return report, fmt.Errorf("failed to record mongo migration %d (%s): %w", step.Version, step.Name, err)
+		}
 	}
 
-	_, err = collection.Indexes().CreateMany(ctx, indexes)
+	return report, nil
+}
+
+// RunMongoMigrations applies every step in steps that isn't already recorded
+// in the schema_migrations collection, in version order. It's Run without a
+// lock or dry-run, kept for callers that don't need either.
+func RunMongoMigrations(ctx context.Context, db *mongo.Database, steps []MongoStep) error {
+	_, err := Run(ctx, db, steps, RunOptions{})
+	return err
+}
+
+// ensureIndexes creates every index in indexes that collection doesn't
+// already have by name, returning a report of which were newly created vs.
+// already present - the "index declared but not present" audit trail a bare
+// CreateMany-and-swallow-"already exists" can't give an operator.
+func ensureIndexes(ctx context.Context, collection *mongo.Collection, indexes []mongo.IndexModel) ([]IndexReport, error) {
+	cursor, err := collection.Indexes().List(ctx)
 	if err != nil {
-		if !strings.Contains(err.Error(), "already exists") {
-			return fmt.Errorf("failed to create indexes: %w", err)
+		return nil, fmt.Errorf("failed to list existing indexes: %w", err)
+	}
+	var existingDocs []bson.M
+	if err := cursor.All(ctx, &existingDocs); err != nil {
+		return nil, fmt.Errorf("failed to decode existing indexes: %w", err)
+	}
+	existing := make(map[string]bool, len(existingDocs))
+	for _, doc := range existingDocs {
+		if name, ok := doc["name"].(string); ok {
+			existing[name] = true
+		}
+	}
+
+	reports := make([]IndexReport, 0, len(indexes))
+	var toCreate []mongo.IndexModel
+	for _, idx := range indexes {
+		name := ""
+		if idx.Options != nil && idx.Options.Name != nil {
+			name = *idx.Options.Name
+		}
+		created := !existing[name]
+		reports = append(reports, IndexReport{Collection: collection.Name(), Name: name, Created: created})
+		if created {
+			toCreate = append(toCreate, idx)
+		}
+	}
+
+	if len(toCreate) > 0 {
+		if _, err := collection.Indexes().CreateMany(ctx, toCreate); err != nil {
+			return nil, fmt.Errorf("failed to create indexes: %w", err)
 		}
 	}
 
-	if !collectionExists {
-		// Collection will be created automatically on first insert
-		// But we can create it explicitly if needed
-		// For now, just log that indexes are created
+	return reports, nil
+}
+
+// ensureEnrichmentRulesIndexes is version 1: the index set enrichment_rules
+// has always needed, derived from management.EnrichmentRule's own index tags
+// (see IndexModelsFromTags) instead of hand-maintained here, so the two
+// can't drift apart.
+func ensureEnrichmentRulesIndexes(ctx context.Context, db *mongo.Database) ([]IndexReport, error) {
+	indexes, err := IndexModelsFromTags("enrichment_rules", management.EnrichmentRule{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build enrichment_rules indexes from tags: %w", err)
+	}
+
+	return ensureIndexes(ctx, db.Collection("enrichment_rules"), indexes)
+}
+
+// ensureEnrichmentRulesAuditIndexes is version 2: the index
+// enrichment_rules_audit needs to serve
+// management.EnrichmentRepository.GetEnrichmentRuleHistory efficiently,
+// derived the same way from management.EnrichmentRuleAudit's tags.
+func ensureEnrichmentRulesAuditIndexes(ctx context.Context, db *mongo.Database) ([]IndexReport, error) {
+	indexes, err := IndexModelsFromTags("enrichment_rules_audit", management.EnrichmentRuleAudit{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build enrichment_rules_audit indexes from tags: %w", err)
+	}
+
+	return ensureIndexes(ctx, db.Collection("enrichment_rules_audit"), indexes)
+}
+
+// EnsureSequences is version 3: it seeds the sequences collection's
+// "enrichment_rules" group (see pkg/sequences) from the current
+// enrichment_rules document count, so a deployment upgrading onto
+// sequences.Repository doesn't hand out an index that collides with a rule
+// numbered by some earlier, sequence-less scheme. It's exported, unlike the
+// other two steps, because pkg/sequences callers outside this package may
+// need to run the bootstrap themselves - e.g. a test seeding rules directly
+// and then needing Next to pick up where they left off - without going
+// through the full Run/RunMongoMigrations machinery.
+//
+// This returns no IndexReports: it isn't an index at all, and MongoStep has
+// no other shape to report a seeded count through, so it reports none rather
+// than overload IndexReport with a field it doesn't mean.
+func EnsureSequences(ctx context.Context, db *mongo.Database) ([]IndexReport, error) {
+	count, err := db.Collection("enrichment_rules").CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count enrichment_rules: %w", err)
+	}
+
+	filter := bson.M{"_id": "enrichment_rules"}
+	update := bson.M{"$max": bson.M{"max_index": count}}
+	opts := options.Update().SetUpsert(true)
+	if _, err := db.Collection("sequences").UpdateOne(ctx, filter, update, opts); err != nil {
+		return nil, fmt.Errorf("failed to seed enrichment_rules sequence: %w", err)
 	}
 
-	return nil
+	return nil, nil
 }
@@ -0,0 +1,192 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sqlMigrationFilePattern matches the numbered up/down files a SQL migration
+// directory is made of, e.g. "0003_enrichment_rules.up.sql". The number is
+// the version recorded in schema_migrations; the name is cosmetic, carried
+// along only so SQLMigration and log output can refer to something more
+// readable than a bare integer.
+var sqlMigrationFilePattern = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// SQLMigration is one numbered step of a SQL migration directory, paired
+// up/down statements applied to a schema_migrations-tracked database.
+type SQLMigration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// LoadSQLMigrations reads every "NNNN_name.up.sql"/"NNNN_name.down.sql" pair
+// in dir and returns them sorted by version. A version missing its .down.sql
+// is an error: RunSQLMigrations never needs Down today, but a migration
+// directory that can't be rolled back is a trap for whoever reaches for one
+// later.
+func LoadSQLMigrations(dir string) ([]SQLMigration, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations directory %s: %w", dir, err)
+	}
+
+	byVersion := map[int]*SQLMigration{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := sqlMigrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+
+		contents, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration file %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &SQLMigration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		switch match[3] {
+		case "up":
+			m.Up = string(contents)
+		case "down":
+			m.Down = string(contents)
+		}
+	}
+
+	migrations := make([]SQLMigration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d (%s) is missing an .up.sql file", m.Version, m.Name)
+		}
+		if m.Down == "" {
+			return nil, fmt.Errorf("migration %d (%s) is missing a .down.sql file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// placeholder returns the bind-parameter syntax for n ("$1", "$2", ... for
+// postgres, "?" for every other driver name, e.g. sqlite3), since that's the
+// only part of the runner's own SQL (not the migration files, which are
+// already driver-specific) that differs between the two.
+func placeholder(driverName string, n int) string {
+	if driverName == "postgres" {
+		return "$" + strconv.Itoa(n)
+	}
+	return "?"
+}
+
+// RunSQLMigrations applies every migration in dir that isn't already
+// recorded in schema_migrations, in version order, each in its own
+// transaction. driverName is the database/sql driver name db was opened
+// with ("postgres" or "sqlite3") and only affects bind-parameter syntax for
+// the runner's own bookkeeping queries - the migration files themselves are
+// plain SQL already written for their target dialect.
+func RunSQLMigrations(ctx context.Context, db *sql.DB, driverName, dir string) error {
+	migrations, err := LoadSQLMigrations(dir)
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMP NOT NULL
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	applied := map[int]bool{}
+	rows, err := db.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return fmt.Errorf("failed to query schema_migrations: %w", err)
+	}
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan schema_migrations row: %w", err)
+		}
+		applied[version] = true
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return err
+	}
+	rows.Close()
+
+	for _, m := range migrations {
+		if applied[m.Version] {
+			continue
+		}
+
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction for migration %d: %w", m.Version, err)
+		}
+
+		for _, stmt := range splitSQLStatements(m.Up) {
+			if _, err := tx.ExecContext(ctx, stmt); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("failed to apply migration %d (%s): %w", m.Version, m.Name, err)
+			}
+		}
+
+		insert := fmt.Sprintf(
+			"INSERT INTO schema_migrations (version, name, applied_at) VALUES (%s, %s, %s)",
+			placeholder(driverName, 1), placeholder(driverName, 2), placeholder(driverName, 3),
+		)
+		if _, err := tx.ExecContext(ctx, insert, m.Version, m.Name, time.Now()); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record migration %d (%s): %w", m.Version, m.Name, err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit migration %d (%s): %w", m.Version, m.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// splitSQLStatements splits a migration file on statement-terminating
+// semicolons so each one can be sent to database/sql individually - neither
+// lib/pq nor mattn/go-sqlite3 support multi-statement ExecContext calls.
+// Migration files in this tree don't use semicolons inside string literals
+// or dollar-quoted bodies, so this stays a plain split rather than a real
+// SQL tokenizer.
+func splitSQLStatements(script string) []string {
+	var stmts []string
+	for _, raw := range strings.Split(script, ";") {
+		stmt := strings.TrimSpace(raw)
+		if stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+	}
+	return stmts
+}
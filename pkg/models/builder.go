@@ -45,6 +45,13 @@ func (b *MessageEnvelopeBuilder) WithTraceID(traceID string) *MessageEnvelopeBui
 	return b
 }
 
+// WithSchema records which schema registry subject+version the payload was
+// (or should be) encoded against. version of 0 means "latest".
+func (b *MessageEnvelopeBuilder) WithSchema(subject string, version int) *MessageEnvelopeBuilder {
+	b.envelope.Metadata.Schema = &SchemaRef{Subject: subject, Version: version}
+	return b
+}
+
 func (b *MessageEnvelopeBuilder) Build() *MessageEnvelope {
 	if b.envelope.Timestamp.IsZero() {
 		b.envelope.Timestamp = time.Now()
@@ -0,0 +1,42 @@
+package models
+
+import "encoding/json"
+
+// Codec encodes and decodes a MessageEnvelope to and from its wire
+// representation, so producers and consumers can swap payload formats
+// (plain JSON, or Avro/Protobuf via a schema registry) without the broker
+// layer knowing which one is in use.
+type Codec interface {
+	Name() string
+	// ContentType identifies the wire format on a Kafka message header (e.g.
+	// "application/json"), so a consumer can dispatch decoding by header
+	// instead of assuming every message on a topic uses the same codec.
+	ContentType() string
+	Encode(msg MessageEnvelope) ([]byte, error)
+	Decode(data []byte) (MessageEnvelope, error)
+}
+
+// JSONCodec is the default Codec and matches the wire format brokers used
+// before pluggable codecs existed: the envelope marshaled directly as JSON,
+// with no framing.
+type JSONCodec struct{}
+
+func (JSONCodec) Name() string {
+	return "json"
+}
+
+func (JSONCodec) ContentType() string {
+	return "application/json"
+}
+
+func (JSONCodec) Encode(msg MessageEnvelope) ([]byte, error) {
+	return json.Marshal(msg)
+}
+
+func (JSONCodec) Decode(data []byte) (MessageEnvelope, error) {
+	var msg MessageEnvelope
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return MessageEnvelope{}, err
+	}
+	return msg, nil
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type ConfigUpdateEvent struct {
 	EventType   string                 `json:"event_type"`   // "filtering_rule_updated", "enrichment_rule_updated", "dedup_config_updated"
@@ -10,20 +13,35 @@ type ConfigUpdateEvent struct {
 	Timestamp   time.Time              `json:"timestamp"`
 	ChangedBy   string                 `json:"changed_by,omitempty"`
 	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	// Rule, if set, is the changed rule itself (filtering.Rule/
+	// enrichment.Rule-shaped JSON, matching ServiceType), letting a
+	// consumer apply the delta straight from the event instead of
+	// re-fetching it from the repository. Empty for Action "delete" (there's
+	// nothing left to fetch) and for events published before this field
+	// existed; either way, a consumer falls back to a targeted repository
+	// fetch when it's absent.
+	Rule json.RawMessage `json:"rule,omitempty"`
 }
 
 const (
 	EventTypeFilteringRuleUpdated  = "filtering_rule_updated"
 	EventTypeEnrichmentRuleUpdated = "enrichment_rule_updated"
 	EventTypeDedupConfigUpdated    = "dedup_config_updated"
+	// EventTypeEnrichmentBreakerStateChanged is published whenever an
+	// enrichment source or rule-scoped circuit breaker changes state; see
+	// provider.BreakerEventPublisher. Unlike the *RuleUpdated event types,
+	// it isn't a config change: Metadata carries "source", "from", and "to"
+	// instead of an embedded Rule.
+	EventTypeEnrichmentBreakerStateChanged = "enrichment_breaker_state_changed"
 )
 
 const (
-	ActionCreate = "create"
-	ActionUpdate = "update"
-	ActionDelete = "delete"
-	ActionToggle = "toggle"
-	ActionReload = "reload"
+	ActionCreate   = "create"
+	ActionUpdate   = "update"
+	ActionDelete   = "delete"
+	ActionToggle   = "toggle"
+	ActionReload   = "reload"
+	ActionRollback = "rollback"
 )
 
 const (
@@ -15,6 +15,38 @@ type Metadata struct {
 	FiltersApplied *FiltersApplied        `json:"filters_applied,omitempty"`
 	Deduplication  *DeduplicationInfo     `json:"deduplication,omitempty"`
 	Enrichment     map[string]interface{} `json:"enrichment,omitempty"`
+	Schema         *SchemaRef             `json:"schema,omitempty"`
+	// Errors accumulates one ErrorRecord per broker-level processing
+	// failure this envelope has been through (retried-out of
+	// filtering/enrichment, sent to a DLQ, etc.), oldest first. It rides
+	// along with the envelope itself so a consumer reading it off a DLQ
+	// topic - or a management API inspecting it later - sees the full
+	// failure history without a separate lookup.
+	Errors []ErrorRecord `json:"errors,omitempty"`
+}
+
+// ErrorRecord captures one processing failure for an envelope: which
+// service saw it, what kind of error it was, and how many attempts were
+// made before giving up. RuleID is populated only when the failing error
+// carries one (see errors.Error's "rule_id" detail) - the broker layer that
+// appends ErrorRecords has no concept of rules itself, so a failure that
+// never passes through rule evaluation simply leaves it empty.
+type ErrorRecord struct {
+	Service     string    `json:"service"`
+	RuleID      string    `json:"rule_id,omitempty"`
+	ErrorClass  string    `json:"error_class"`
+	Message     string    `json:"message"`
+	Attempts    int       `json:"attempts"`
+	FirstSeenAt time.Time `json:"first_seen_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+}
+
+// SchemaRef names the schema registry entry a producer encoded this
+// envelope's payload against, so a consumer using a schema-aware Codec
+// knows which subject+version to fetch without a separate lookup.
+type SchemaRef struct {
+	Subject string `json:"subject"`
+	Version int    `json:"version,omitempty"` // 0 means "latest"
 }
 
 type FiltersApplied struct {
@@ -1,6 +1,11 @@
 package models
 
-import "fmt"
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
 
 type ValidationError struct {
 	Field   string
@@ -50,6 +55,94 @@ func ValidateMessageEnvelope(msg *MessageEnvelope) error {
 	return nil
 }
 
+// ValidateTypedEnvelope extends ValidateMessageEnvelope with struct-tag-driven
+// validation of env.Payload, so a service can declare its payload's required
+// fields once as `validate:"required,min=1"` tags instead of hand-writing
+// presence checks after every decode. Supported rules are "required" (zero
+// value fails) and "min=N" (length for strings/slices/maps, value for
+// numbers).
+func ValidateTypedEnvelope[T any](env *TypedEnvelope[T]) error {
+	if env == nil {
+		return &ValidationError{
+			Field:   "envelope",
+			Message: "typed envelope cannot be nil",
+		}
+	}
+
+	if err := ValidateMessageEnvelope(&env.MessageEnvelope); err != nil {
+		return err
+	}
+
+	return validatePayloadTags(reflect.ValueOf(env.Payload), "")
+}
+
+func validatePayloadTags(v reflect.Value, fieldPrefix string) error {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("validate")
+		if tag == "" {
+			continue
+		}
+
+		fieldName := fieldPrefix + t.Field(i).Name
+		for _, rule := range strings.Split(tag, ",") {
+			if err := applyValidateRule(fieldName, v.Field(i), rule); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func applyValidateRule(fieldName string, value reflect.Value, rule string) error {
+	name, param, hasParam := strings.Cut(rule, "=")
+
+	switch name {
+	case "required":
+		if value.IsZero() {
+			return &ValidationError{Field: fieldName, Message: "is required"}
+		}
+
+	case "min":
+		if !hasParam {
+			return nil
+		}
+		min, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return nil
+		}
+
+		switch value.Kind() {
+		case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+			if float64(value.Len()) < min {
+				return &ValidationError{Field: fieldName, Message: fmt.Sprintf("must have length >= %s", param)}
+			}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if float64(value.Int()) < min {
+				return &ValidationError{Field: fieldName, Message: fmt.Sprintf("must be >= %s", param)}
+			}
+		case reflect.Float32, reflect.Float64:
+			if value.Float() < min {
+				return &ValidationError{Field: fieldName, Message: fmt.Sprintf("must be >= %s", param)}
+			}
+		}
+	}
+
+	return nil
+}
+
 func (msg *MessageEnvelope) GetPayloadField(name string) (interface{}, bool) {
 	if msg.Payload == nil {
 		return nil, false
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// TypedEnvelope decorates a MessageEnvelope with a strongly-typed Payload,
+// decoded from the envelope's untyped map[string]interface{}. Services that
+// expect one payload shape can declare it once as T instead of pulling
+// individual fields out of Payload by hand at every call site.
+type TypedEnvelope[T any] struct {
+	MessageEnvelope
+	Payload T
+}
+
+// DecodeTypedEnvelope re-marshals msg.Payload into T and returns it wrapped
+// in a TypedEnvelope alongside the rest of msg's untyped fields.
+func DecodeTypedEnvelope[T any](msg MessageEnvelope) (*TypedEnvelope[T], error) {
+	data, err := json.Marshal(msg.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	var payload T
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal payload into %T: %w", payload, err)
+	}
+
+	return &TypedEnvelope[T]{MessageEnvelope: msg, Payload: payload}, nil
+}
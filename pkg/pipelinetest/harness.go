@@ -0,0 +1,89 @@
+package pipelinetest
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Harness drives one input-topic/processed-topic pair through a Broker the
+// way goka's tester drives a processor graph: PushInput/WaitProcessed/
+// ExpectDropped replace hand-rolled kafka.Writer/kafka.Reader plumbing and
+// sleep-then-check polling with a handful of deterministic calls. Harness
+// itself is Broker-agnostic - pass it a FakeBroker in a unit test or a
+// real-Kafka Broker in an e2e test and the same calls work against either.
+type Harness struct {
+	broker         Broker
+	inputTopic     string
+	processedTopic string
+
+	rulesMu sync.Mutex
+	rules   []interface{}
+}
+
+func NewHarness(broker Broker, inputTopic, processedTopic string) *Harness {
+	return &Harness{broker: broker, inputTopic: inputTopic, processedTopic: processedTopic}
+}
+
+// PushInput writes value to the input topic under key id, the harness
+// equivalent of sendMessageToKafka.
+func (h *Harness) PushInput(ctx context.Context, id string, value []byte) error {
+	w := h.broker.NewWriter(h.inputTopic)
+	defer w.Close()
+	return w.WriteMessages(ctx, Message{Key: []byte(id), Value: value})
+}
+
+// WaitProcessed reads the processed topic from its beginning until it finds
+// a message keyed by id, or ctx is done. Against FakeBroker this returns as
+// soon as the message is produced - no MaxWait/poll-interval tuning needed,
+// unlike waitForProcessedMessage's real-Kafka loop.
+func (h *Harness) WaitProcessed(ctx context.Context, id string) (Message, bool) {
+	r := h.broker.NewReader(ReaderConfig{
+		Topic:       h.processedTopic,
+		GroupID:     "pipelinetest-wait-" + id,
+		StartOffset: FirstOffset,
+	})
+	defer r.Close()
+
+	for {
+		msg, err := r.FetchMessage(ctx)
+		if err != nil {
+			return Message{}, false
+		}
+		_ = r.CommitMessages(ctx, msg)
+		if string(msg.Key) == id {
+			return msg, true
+		}
+	}
+}
+
+// ExpectDropped reports whether id never shows up on the processed topic
+// within window - the harness equivalent of tryGetProcessedMessage's
+// sleep-then-read-LastOffset pattern, but timer-bounded instead of relying
+// on a fixed sleep before the check.
+func (h *Harness) ExpectDropped(ctx context.Context, id string, window time.Duration) bool {
+	waitCtx, cancel := context.WithTimeout(ctx, window)
+	defer cancel()
+	_, found := h.WaitProcessed(waitCtx, id)
+	return !found
+}
+
+// SetRules installs rules as the harness's current rule set, bypassing the
+// config-update Kafka topic that filtering.Service/enrichment.Service
+// otherwise reload from. Harness only holds rules here for a test's own
+// pipeline stand-in to read back (via Rules) in place of its normal
+// config-update subscription when wired to a FakeBroker - Harness does not
+// itself run filtering.Service or enrichment.Service in-process, so pushing
+// a rule here has no effect unless the code under test calls Rules.
+func (h *Harness) SetRules(rules ...interface{}) {
+	h.rulesMu.Lock()
+	h.rules = append([]interface{}(nil), rules...)
+	h.rulesMu.Unlock()
+}
+
+// Rules returns the rule set most recently installed by SetRules.
+func (h *Harness) Rules() []interface{} {
+	h.rulesMu.Lock()
+	defer h.rulesMu.Unlock()
+	return append([]interface{}(nil), h.rules...)
+}
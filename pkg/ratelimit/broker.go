@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// BrokerStrategy selects the algorithm BrokerLimiter uses to throttle
+// broker consumption.
+type BrokerStrategy string
+
+const (
+	// TokenBucket allows short bursts up to BrokerLimiterConfig.Burst
+	// messages, then throttles to MessagesPerSecond.
+	TokenBucket BrokerStrategy = "token_bucket"
+	// LeakyBucket meters consumption at a constant rate with no burst
+	// allowance, which is gentler on a downstream like Redis/dedup that
+	// degrades under spiky load rather than sustained load.
+	LeakyBucket BrokerStrategy = "leaky_bucket"
+)
+
+// BrokerLimiterConfig configures a BrokerLimiter.
+type BrokerLimiterConfig struct {
+	MessagesPerSecond float64
+	Burst             int
+	Strategy          BrokerStrategy
+}
+
+// BrokerLimiter throttles a broker.Consumer's fetch loop to a configured
+// message rate. Unlike the per-client limiters in RateLimitMiddleware,
+// there is a single limiter per consumer, since the caller here is one
+// partition reader or subject subscription rather than many remote
+// clients.
+type BrokerLimiter struct {
+	limiter *rate.Limiter
+}
+
+// NewBrokerLimiter builds a BrokerLimiter from cfg. LeakyBucket is
+// implemented as a token bucket with its burst forced to 1: the bucket
+// holds a single token that refills at MessagesPerSecond, so messages are
+// admitted at a constant rate with no burst allowance.
+func NewBrokerLimiter(cfg BrokerLimiterConfig) *BrokerLimiter {
+	burst := cfg.Burst
+	if cfg.Strategy == LeakyBucket || burst <= 0 {
+		burst = 1
+	}
+
+	return &BrokerLimiter{
+		limiter: rate.NewLimiter(rate.Limit(cfg.MessagesPerSecond), burst),
+	}
+}
+
+// Wait blocks until the limiter admits one message, returning how long the
+// call was blocked. A returned duration of 0 means the message was
+// admitted immediately.
+func (l *BrokerLimiter) Wait(ctx context.Context) (time.Duration, error) {
+	start := time.Now()
+	if err := l.limiter.Wait(ctx); err != nil {
+		return time.Since(start), err
+	}
+	return time.Since(start), nil
+}
@@ -3,25 +3,21 @@ package ratelimit
 import (
 	"net/http"
 	"strconv"
-	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"golang.org/x/time/rate"
 	"yeti/pkg/metrics"
 )
 
-type Limiter struct {
-	limiter  *rate.Limiter
-	lastSeen time.Time
-	mu       sync.Mutex
-}
-
+// RateLimitConfig configures RateLimitMiddleware. RPS/Burst are the limit
+// applied when a request's tier (see KeyFunc) isn't found in Tiers, or
+// Tiers is nil - the original single-tier behavior.
 type RateLimitConfig struct {
 	RPS             float64
 	Burst           int
 	CleanupInterval time.Duration
 	MaxAge          time.Duration
+	Tiers           map[string]TierLimit
 }
 
 func DefaultConfig() RateLimitConfig {
@@ -33,60 +29,49 @@ func DefaultConfig() RateLimitConfig {
 	}
 }
 
-func RateLimitMiddleware(config RateLimitConfig) gin.HandlerFunc {
-	limiters := make(map[string]*Limiter)
-	var mu sync.RWMutex
+// limitFor resolves tier against cfg.Tiers, falling back to cfg's own
+// RPS/Burst for an empty or unrecognized tier.
+func (cfg RateLimitConfig) limitFor(tier string) TierLimit {
+	if limit, ok := cfg.Tiers[tier]; ok {
+		return limit
+	}
+	return TierLimit{RPS: cfg.RPS, Burst: cfg.Burst}
+}
 
-	go func() {
-		ticker := time.NewTicker(config.CleanupInterval)
-		defer ticker.Stop()
-		for range ticker.C {
-			mu.Lock()
-			now := time.Now()
-			for ip, limiter := range limiters {
-				limiter.mu.Lock()
-				lastSeen := limiter.lastSeen
-				limiter.mu.Unlock()
-				if now.Sub(lastSeen) > config.MaxAge {
-					delete(limiters, ip)
-				}
-			}
-			mu.Unlock()
-		}
-	}()
+// RateLimitMiddleware checks each request against store, bucketed and
+// tiered by keyFunc (IPKeyFunc if nil). store is typically an
+// InMemoryStore (this middleware's original per-process behavior) or a
+// RedisStore shared across every replica - see Store. A store error fails
+// open (the request proceeds unthrottled) rather than taking the API down,
+// since the in-memory behavior this replaced never had a failure mode of
+// its own to compare against.
+func RateLimitMiddleware(config RateLimitConfig, store Store, keyFunc KeyFunc) gin.HandlerFunc {
+	if keyFunc == nil {
+		keyFunc = IPKeyFunc
+	}
 
 	return func(c *gin.Context) {
-		clientIP := c.ClientIP()
-		if clientIP == "" {
-			clientIP = c.RemoteIP()
+		key, tier := keyFunc(c)
+		limit := config.limitFor(tier)
+
+		result, err := store.Allow(c.Request.Context(), key, limit)
+		if err != nil {
+			c.Next()
+			return
 		}
 
-		mu.RLock()
-		limiter, exists := limiters[clientIP]
-		mu.RUnlock()
+		c.Header("X-RateLimit-Limit", formatRate(limit.RPS))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
 
-		if !exists {
-			mu.Lock()
-			limiter, exists = limiters[clientIP]
-			if !exists {
-				limiter = &Limiter{
-					limiter:  rate.NewLimiter(rate.Limit(config.RPS), config.Burst),
-					lastSeen: time.Now(),
-				}
-				limiters[clientIP] = limiter
-			}
-			mu.Unlock()
-		}
+		if !result.Allowed {
+			metrics.RateLimitRequestsTotal.WithLabelValues("limited", tier).Inc()
 
-		limiter.mu.Lock()
-		limiter.lastSeen = time.Now()
-		limiter.mu.Unlock()
+			retrySeconds := int(result.RetryAfter.Round(time.Second).Seconds())
+			if retrySeconds < 1 {
+				retrySeconds = 1
+			}
+			c.Header("Retry-After", strconv.Itoa(retrySeconds))
 
-		if !limiter.limiter.Allow() {
-			metrics.RateLimitRequestsTotal.WithLabelValues("limited").Inc()
-			c.Header("X-RateLimit-Limit", formatRate(config.RPS))
-			c.Header("X-RateLimit-Remaining", "0")
-			c.Header("Retry-After", "1")
 			c.JSON(http.StatusTooManyRequests, gin.H{
 				"error":      "rate limit exceeded",
 				"error_code": "RATE_LIMIT_EXCEEDED",
@@ -95,14 +80,7 @@ func RateLimitMiddleware(config RateLimitConfig) gin.HandlerFunc {
 			return
 		}
 
-		metrics.RateLimitRequestsTotal.WithLabelValues("allowed").Inc()
-
-		c.Header("X-RateLimit-Limit", formatRate(config.RPS))
-		remaining := limiter.limiter.Burst() - int(limiter.limiter.Tokens())
-		if remaining < 0 {
-			remaining = 0
-		}
-		c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		metrics.RateLimitRequestsTotal.WithLabelValues("allowed", tier).Inc()
 
 		c.Next()
 	}
@@ -0,0 +1,36 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// TierLimit is the RPS/Burst a Store enforces for one bucket key.
+type TierLimit struct {
+	RPS   float64
+	Burst int
+}
+
+// Result is a Store.Allow outcome.
+type Result struct {
+	// Allowed reports whether the request consumed a token and should
+	// proceed.
+	Allowed bool
+	// Remaining is the number of tokens left in the bucket after this
+	// check, for the X-RateLimit-Remaining header.
+	Remaining int
+	// RetryAfter is how long the caller should wait before its next
+	// request would be admitted. Only meaningful when !Allowed.
+	RetryAfter time.Duration
+}
+
+// Store is the rate-limiting backend RateLimitMiddleware checks each
+// request against. InMemoryStore keeps limiter state in a per-process map -
+// useless behind more than one replica, since each one enforces its own
+// independent limit. RedisStore shares state across every replica sharing
+// its client instead, via a single atomic Lua script, so a caller can't
+// evade its limit by landing on a different instance behind a load
+// balancer.
+type Store interface {
+	Allow(ctx context.Context, key string, limit TierLimit) (Result, error)
+}
@@ -0,0 +1,100 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+type memoryEntry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+	mu       sync.Mutex
+}
+
+// InMemoryStore is Store's original implementation: one golang.org/x/time/rate
+// limiter per key, held in a per-process map. It's RateLimitMiddleware's
+// default backend, and its only one before RedisStore existed - fine for a
+// single replica, but each replica behind a load balancer enforces its own
+// independent limit rather than sharing one, since nothing here is shared
+// across processes.
+type InMemoryStore struct {
+	mu      sync.RWMutex
+	entries map[string]*memoryEntry
+}
+
+// NewInMemoryStore builds an InMemoryStore that evicts a key's limiter once
+// it's gone untouched for maxAge, checked every cleanupInterval.
+// cleanupInterval <= 0 disables the background sweep, so entries accumulate
+// for the process lifetime, matching how RateLimitMiddleware behaved before
+// Store existed.
+func NewInMemoryStore(cleanupInterval, maxAge time.Duration) *InMemoryStore {
+	s := &InMemoryStore{entries: make(map[string]*memoryEntry)}
+	if cleanupInterval > 0 {
+		go s.cleanupLoop(cleanupInterval, maxAge)
+	}
+	return s
+}
+
+func (s *InMemoryStore) cleanupLoop(interval, maxAge time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		now := time.Now()
+		s.mu.Lock()
+		for key, entry := range s.entries {
+			entry.mu.Lock()
+			lastSeen := entry.lastSeen
+			entry.mu.Unlock()
+			if now.Sub(lastSeen) > maxAge {
+				delete(s.entries, key)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+func (s *InMemoryStore) Allow(_ context.Context, key string, limit TierLimit) (Result, error) {
+	entry := s.entryFor(key, limit)
+
+	entry.mu.Lock()
+	entry.lastSeen = time.Now()
+	entry.mu.Unlock()
+
+	if !entry.limiter.Allow() {
+		retryAfter := time.Second
+		if limit.RPS > 0 {
+			retryAfter = time.Duration(float64(time.Second) / limit.RPS)
+		}
+		return Result{Allowed: false, Remaining: 0, RetryAfter: retryAfter}, nil
+	}
+
+	remaining := int(entry.limiter.Tokens())
+	if remaining < 0 {
+		remaining = 0
+	}
+	return Result{Allowed: true, Remaining: remaining}, nil
+}
+
+func (s *InMemoryStore) entryFor(key string, limit TierLimit) *memoryEntry {
+	s.mu.RLock()
+	entry, ok := s.entries[key]
+	s.mu.RUnlock()
+	if ok {
+		return entry
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry, ok := s.entries[key]; ok {
+		return entry
+	}
+	entry = &memoryEntry{
+		limiter:  rate.NewLimiter(rate.Limit(limit.RPS), limit.Burst),
+		lastSeen: time.Now(),
+	}
+	s.entries[key] = entry
+	return entry
+}
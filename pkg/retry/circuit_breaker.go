@@ -0,0 +1,169 @@
+package retry
+
+import (
+	"sync"
+	"time"
+)
+
+// CircuitState is one of the three states a CircuitBreaker can be in.
+type CircuitState string
+
+const (
+	StateClosed   CircuitState = "closed"
+	StateOpen     CircuitState = "open"
+	StateHalfOpen CircuitState = "half-open"
+)
+
+// CircuitBreakerConfig parameterizes a CircuitBreaker.
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of failures within the sliding window
+	// that trips the breaker from closed to open.
+	FailureThreshold int
+	// SuccessThreshold is the number of consecutive successes required in
+	// the half-open state before the breaker closes again.
+	SuccessThreshold int
+	// OpenTimeout is how long the breaker stays open before Allow moves it
+	// to the half-open state.
+	OpenTimeout time.Duration
+	// WindowSize bounds how many recent outcomes are kept to evaluate
+	// FailureThreshold. Defaults to FailureThreshold if unset.
+	WindowSize int
+	// OnStateChange, if set, is called whenever the breaker transitions
+	// between states.
+	OnStateChange func(from, to CircuitState)
+}
+
+// CircuitBreaker is a lightweight, dependency-free circuit breaker that
+// Retry can short-circuit through instead of retrying against a downstream
+// dependency it already knows is failing. Unlike pkg/circuitbreaker.Wrapper
+// (which wraps sony/gobreaker for HTTP/Redis/Mongo decorators), this type
+// lives in pkg/retry so Policy can reference it without an import cycle.
+type CircuitBreaker struct {
+	cfg CircuitBreakerConfig
+
+	mu                   sync.Mutex
+	state                CircuitState
+	outcomes             []bool
+	consecutiveSuccesses int
+	openedAt             time.Time
+}
+
+// NewCircuitBreaker creates a CircuitBreaker in the closed state.
+func NewCircuitBreaker(cfg CircuitBreakerConfig) *CircuitBreaker {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 1
+	}
+	if cfg.SuccessThreshold <= 0 {
+		cfg.SuccessThreshold = 1
+	}
+	if cfg.WindowSize <= 0 {
+		cfg.WindowSize = cfg.FailureThreshold
+	}
+	return &CircuitBreaker{
+		cfg:   cfg,
+		state: StateClosed,
+	}
+}
+
+// Allow reports whether a call should be let through. When the breaker is
+// open and OpenTimeout has elapsed, it transitions to half-open and lets the
+// call through. While half-open, every call is let through; the number of
+// concurrent probes is not limited.
+func (cb *CircuitBreaker) Allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case StateOpen:
+		if time.Since(cb.openedAt) >= cb.cfg.OpenTimeout {
+			cb.transitionLocked(StateHalfOpen)
+			return true
+		}
+		return false
+	case StateHalfOpen:
+		// No in-flight probe is tracked: every call is let through until a
+		// recorded outcome closes or reopens the breaker.
+		return true
+	default:
+		return true
+	}
+}
+
+// RecordSuccess reports a successful call outcome.
+func (cb *CircuitBreaker) RecordSuccess() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.recordLocked(true)
+
+	switch cb.state {
+	case StateHalfOpen:
+		cb.consecutiveSuccesses++
+		if cb.consecutiveSuccesses >= cb.cfg.SuccessThreshold {
+			cb.transitionLocked(StateClosed)
+		}
+	case StateOpen:
+		// A success while open shouldn't occur outside of Allow() having
+		// already moved to half-open, but reset defensively.
+		cb.transitionLocked(StateHalfOpen)
+	}
+}
+
+// RecordFailure reports a failed call outcome.
+func (cb *CircuitBreaker) RecordFailure() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.recordLocked(false)
+
+	switch cb.state {
+	case StateHalfOpen:
+		cb.transitionLocked(StateOpen)
+	case StateClosed:
+		if cb.failuresLocked() >= cb.cfg.FailureThreshold {
+			cb.transitionLocked(StateOpen)
+		}
+	}
+}
+
+// State returns the breaker's current state.
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
+
+func (cb *CircuitBreaker) recordLocked(success bool) {
+	cb.outcomes = append(cb.outcomes, success)
+	if len(cb.outcomes) > cb.cfg.WindowSize {
+		cb.outcomes = cb.outcomes[len(cb.outcomes)-cb.cfg.WindowSize:]
+	}
+}
+
+func (cb *CircuitBreaker) failuresLocked() int {
+	failures := 0
+	for _, ok := range cb.outcomes {
+		if !ok {
+			failures++
+		}
+	}
+	return failures
+}
+
+func (cb *CircuitBreaker) transitionLocked(to CircuitState) {
+	from := cb.state
+	if from == to {
+		return
+	}
+	cb.state = to
+	cb.consecutiveSuccesses = 0
+	if to == StateOpen {
+		cb.openedAt = time.Now()
+	}
+	if to == StateClosed {
+		cb.outcomes = nil
+	}
+	if cb.cfg.OnStateChange != nil {
+		cb.cfg.OnStateChange(from, to)
+	}
+}
@@ -0,0 +1,98 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreakerTripsOpenAfterFailureThreshold(t *testing.T) {
+	cb := NewCircuitBreaker(CircuitBreakerConfig{
+		FailureThreshold: 3,
+		SuccessThreshold: 1,
+		OpenTimeout:      50 * time.Millisecond,
+	})
+
+	assert.Equal(t, StateClosed, cb.State())
+
+	for i := 0; i < 3; i++ {
+		assert.True(t, cb.Allow())
+		cb.RecordFailure()
+	}
+
+	assert.Equal(t, StateOpen, cb.State())
+	assert.False(t, cb.Allow())
+}
+
+func TestCircuitBreakerHalfOpensAfterTimeoutAndCloses(t *testing.T) {
+	cb := NewCircuitBreaker(CircuitBreakerConfig{
+		FailureThreshold: 1,
+		SuccessThreshold: 2,
+		OpenTimeout:      10 * time.Millisecond,
+	})
+
+	cb.RecordFailure()
+	assert.Equal(t, StateOpen, cb.State())
+
+	time.Sleep(15 * time.Millisecond)
+	assert.True(t, cb.Allow())
+	assert.Equal(t, StateHalfOpen, cb.State())
+
+	cb.RecordSuccess()
+	assert.Equal(t, StateHalfOpen, cb.State())
+
+	cb.RecordSuccess()
+	assert.Equal(t, StateClosed, cb.State())
+}
+
+func TestCircuitBreakerHalfOpenFailureReopens(t *testing.T) {
+	cb := NewCircuitBreaker(CircuitBreakerConfig{
+		FailureThreshold: 1,
+		SuccessThreshold: 1,
+		OpenTimeout:      10 * time.Millisecond,
+	})
+
+	cb.RecordFailure()
+	time.Sleep(15 * time.Millisecond)
+	assert.True(t, cb.Allow())
+	assert.Equal(t, StateHalfOpen, cb.State())
+
+	cb.RecordFailure()
+	assert.Equal(t, StateOpen, cb.State())
+}
+
+func TestCircuitBreakerReportsStateChanges(t *testing.T) {
+	var transitions []CircuitState
+	cb := NewCircuitBreaker(CircuitBreakerConfig{
+		FailureThreshold: 1,
+		SuccessThreshold: 1,
+		OpenTimeout:      time.Millisecond,
+		OnStateChange: func(from, to CircuitState) {
+			transitions = append(transitions, to)
+		},
+	})
+
+	cb.RecordFailure()
+	assert.Equal(t, []CircuitState{StateOpen}, transitions)
+}
+
+func TestRetryShortCircuitsWhenBreakerOpen(t *testing.T) {
+	cb := NewCircuitBreaker(CircuitBreakerConfig{
+		FailureThreshold: 1,
+		SuccessThreshold: 1,
+		OpenTimeout:      time.Minute,
+	})
+	cb.RecordFailure()
+
+	calls := 0
+	err := Retry(context.Background(), Policy{MaxAttempts: 3, Breaker: cb}, func() error {
+		calls++
+		return errors.New("should not be called")
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, 0, calls)
+}
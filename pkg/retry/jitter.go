@@ -0,0 +1,150 @@
+package retry
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// JitterStrategy selects how retry delays are randomized to keep many
+// workers retrying against the same downstream dependency (Redis, Mongo, an
+// enrichment HTTP source) from synchronizing and producing a thundering herd
+// during outage recovery.
+type JitterStrategy string
+
+const (
+	JitterNone         JitterStrategy = "none"
+	JitterFull         JitterStrategy = "full"
+	JitterEqual        JitterStrategy = "equal"
+	JitterDecorrelated JitterStrategy = "decorrelated"
+)
+
+// FullJitter implements the "full jitter" strategy described in
+// https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/:
+// the delay is chosen uniformly between 0 and the exponential backoff
+// ceiling for the given attempt, so retries from many callers spread out
+// instead of landing on the same schedule.
+func FullJitter(attempt int, base, cap time.Duration) time.Duration {
+	ceiling := float64(base) * math.Pow(2, float64(attempt))
+	if ceiling > float64(cap) {
+		ceiling = float64(cap)
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// EqualJitter implements the "equal jitter" strategy described in the same
+// AWS post: half of the exponential backoff ceiling is kept fixed and the
+// other half is randomized, so delays spread out less aggressively than
+// FullJitter while still avoiding perfectly synchronized retries.
+func EqualJitter(attempt int, base, cap time.Duration) time.Duration {
+	ceiling := float64(base) * math.Pow(2, float64(attempt))
+	if ceiling > float64(cap) {
+		ceiling = float64(cap)
+	}
+	half := int64(ceiling / 2)
+	if half <= 0 {
+		return 0
+	}
+	return time.Duration(half) + time.Duration(rand.Int63n(half+1))
+}
+
+// DecorrelatedJitter implements AWS's "decorrelated jitter" strategy: each
+// delay is derived from the previous delay rather than the attempt count,
+// which spreads retries out further than FullJitter under sustained load.
+func DecorrelatedJitter(prev, base, cap time.Duration) time.Duration {
+	upper := prev * 3
+	if upper <= base {
+		upper = base + 1
+	}
+	delay := base + time.Duration(rand.Int63n(int64(upper-base)))
+	if delay > cap {
+		return cap
+	}
+	return delay
+}
+
+// FullJitterBackOff is a backoff.BackOff implementation of FullJitter, so
+// call sites that build a backoff.BackOff from ExponentialBackoff can swap
+// in jittered backoff without changing their retry loop.
+type FullJitterBackOff struct {
+	Base    time.Duration
+	Cap     time.Duration
+	attempt int
+}
+
+func NewFullJitterBackOff(base, cap time.Duration) *FullJitterBackOff {
+	return &FullJitterBackOff{Base: base, Cap: cap}
+}
+
+func (b *FullJitterBackOff) NextBackOff() time.Duration {
+	d := FullJitter(b.attempt, b.Base, b.Cap)
+	b.attempt++
+	return d
+}
+
+func (b *FullJitterBackOff) Reset() {
+	b.attempt = 0
+}
+
+// EqualJitterBackOff is a backoff.BackOff implementation of EqualJitter.
+type EqualJitterBackOff struct {
+	Base    time.Duration
+	Cap     time.Duration
+	attempt int
+}
+
+func NewEqualJitterBackOff(base, cap time.Duration) *EqualJitterBackOff {
+	return &EqualJitterBackOff{Base: base, Cap: cap}
+}
+
+func (b *EqualJitterBackOff) NextBackOff() time.Duration {
+	d := EqualJitter(b.attempt, b.Base, b.Cap)
+	b.attempt++
+	return d
+}
+
+func (b *EqualJitterBackOff) Reset() {
+	b.attempt = 0
+}
+
+// DecorrelatedJitterBackOff is a backoff.BackOff implementation of
+// DecorrelatedJitter.
+type DecorrelatedJitterBackOff struct {
+	Base time.Duration
+	Cap  time.Duration
+	prev time.Duration
+}
+
+func NewDecorrelatedJitterBackOff(base, cap time.Duration) *DecorrelatedJitterBackOff {
+	return &DecorrelatedJitterBackOff{Base: base, Cap: cap}
+}
+
+func (b *DecorrelatedJitterBackOff) NextBackOff() time.Duration {
+	if b.prev == 0 {
+		b.prev = b.Base
+	}
+	b.prev = DecorrelatedJitter(b.prev, b.Base, b.Cap)
+	return b.prev
+}
+
+func (b *DecorrelatedJitterBackOff) Reset() {
+	b.prev = 0
+}
+
+// FullJitterBackoff and DecorrelatedJitterBackoff mirror ExponentialBackoff's
+// signature so existing call sites can drop in a jittered strategy in place
+// of exponential backoff.
+func FullJitterBackoff(initialInterval, maxInterval time.Duration) *FullJitterBackOff {
+	return NewFullJitterBackOff(initialInterval, maxInterval)
+}
+
+func DecorrelatedJitterBackoff(initialInterval, maxInterval time.Duration) *DecorrelatedJitterBackOff {
+	return NewDecorrelatedJitterBackOff(initialInterval, maxInterval)
+}
+
+func EqualJitterBackoff(initialInterval, maxInterval time.Duration) *EqualJitterBackOff {
+	return NewEqualJitterBackOff(initialInterval, maxInterval)
+}
@@ -0,0 +1,72 @@
+package retry
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// simulateOutageRecovery fans out workerCount goroutines that all start
+// retrying at the same instant (as they would after a shared dependency
+// comes back up) and reports the maximum number observed sleeping at once.
+// That peak is the thundering-herd metric: pure exponential backoff
+// synchronizes every worker's delay, so the peak stays near workerCount;
+// jittered strategies spread delays out and the peak should be lower.
+func simulateOutageRecovery(b *testing.B, backOffFor func() func() time.Duration) {
+	const workerCount = 200
+	const attempts = 5
+
+	for i := 0; i < b.N; i++ {
+		var inFlight int64
+		var peak int64
+		var wg sync.WaitGroup
+
+		for w := 0; w < workerCount; w++ {
+			wg.Add(1)
+			nextDelay := backOffFor()
+			go func() {
+				defer wg.Done()
+				for attempt := 0; attempt < attempts; attempt++ {
+					cur := atomic.AddInt64(&inFlight, 1)
+					for {
+						p := atomic.LoadInt64(&peak)
+						if cur <= p || atomic.CompareAndSwapInt64(&peak, p, cur) {
+							break
+						}
+					}
+					time.Sleep(time.Microsecond)
+					atomic.AddInt64(&inFlight, -1)
+					_ = nextDelay()
+				}
+			}()
+		}
+		wg.Wait()
+		b.ReportMetric(float64(peak), "peak_concurrency")
+	}
+}
+
+func BenchmarkOutageRecoveryExponential(b *testing.B) {
+	simulateOutageRecovery(b, func() func() time.Duration {
+		attempt := 0
+		return func() time.Duration {
+			d := CalculateBackoffDuration(attempt, time.Millisecond, 2.0, 100*time.Millisecond)
+			attempt++
+			return d
+		}
+	})
+}
+
+func BenchmarkOutageRecoveryFullJitter(b *testing.B) {
+	simulateOutageRecovery(b, func() func() time.Duration {
+		bo := NewFullJitterBackOff(time.Millisecond, 100*time.Millisecond)
+		return bo.NextBackOff
+	})
+}
+
+func BenchmarkOutageRecoveryDecorrelatedJitter(b *testing.B) {
+	simulateOutageRecovery(b, func() func() time.Duration {
+		bo := NewDecorrelatedJitterBackOff(time.Millisecond, 100*time.Millisecond)
+		return bo.NextBackOff
+	})
+}
@@ -0,0 +1,79 @@
+package retry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFullJitterStaysWithinCeiling(t *testing.T) {
+	base := 10 * time.Millisecond
+	cap := 100 * time.Millisecond
+
+	for attempt := 0; attempt < 10; attempt++ {
+		for i := 0; i < 100; i++ {
+			d := FullJitter(attempt, base, cap)
+			assert.GreaterOrEqual(t, d, time.Duration(0))
+			assert.LessOrEqual(t, d, cap)
+		}
+	}
+}
+
+func TestEqualJitterStaysWithinCeiling(t *testing.T) {
+	base := 10 * time.Millisecond
+	cap := 100 * time.Millisecond
+
+	for attempt := 0; attempt < 10; attempt++ {
+		for i := 0; i < 100; i++ {
+			d := EqualJitter(attempt, base, cap)
+			assert.GreaterOrEqual(t, d, time.Duration(0))
+			assert.LessOrEqual(t, d, cap)
+		}
+	}
+}
+
+func TestDecorrelatedJitterStaysWithinBounds(t *testing.T) {
+	base := 10 * time.Millisecond
+	cap := 100 * time.Millisecond
+
+	prev := base
+	for i := 0; i < 100; i++ {
+		d := DecorrelatedJitter(prev, base, cap)
+		assert.GreaterOrEqual(t, d, base)
+		assert.LessOrEqual(t, d, cap)
+		prev = d
+	}
+}
+
+func TestFullJitterBackOffResetsAttemptCounter(t *testing.T) {
+	bo := NewFullJitterBackOff(10*time.Millisecond, 100*time.Millisecond)
+
+	for i := 0; i < 5; i++ {
+		assert.LessOrEqual(t, bo.NextBackOff(), 100*time.Millisecond)
+	}
+
+	bo.Reset()
+	assert.Equal(t, 0, bo.attempt)
+}
+
+func TestEqualJitterBackOffResetsAttemptCounter(t *testing.T) {
+	bo := NewEqualJitterBackOff(10*time.Millisecond, 100*time.Millisecond)
+
+	for i := 0; i < 5; i++ {
+		assert.LessOrEqual(t, bo.NextBackOff(), 100*time.Millisecond)
+	}
+
+	bo.Reset()
+	assert.Equal(t, 0, bo.attempt)
+}
+
+func TestDecorrelatedJitterBackOffResetsPrevious(t *testing.T) {
+	bo := NewDecorrelatedJitterBackOff(10*time.Millisecond, 100*time.Millisecond)
+
+	_ = bo.NextBackOff()
+	assert.NotZero(t, bo.prev)
+
+	bo.Reset()
+	assert.Zero(t, bo.prev)
+}
@@ -3,6 +3,7 @@ package retry
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
@@ -70,6 +71,18 @@ type Policy struct {
 	MaxInterval     time.Duration
 	Multiplier      float64
 	MaxElapsedTime  time.Duration
+	// Jitter selects the randomization strategy applied to each delay.
+	// Empty (or JitterNone) preserves the existing pure exponential
+	// behavior. JitterFull, JitterEqual and JitterDecorrelated do not honor
+	// MaxElapsedTime, since none of the AWS strategies are defined in terms
+	// of it.
+	Jitter JitterStrategy
+	// Breaker, if set, is consulted before each attempt and short-circuits
+	// to a FatalError once open, giving the call a unified resilience
+	// primitive instead of retrying against a dependency already known to
+	// be down. State transitions are reported through
+	// CircuitBreakerConfig.OnStateChange, not onRetry.
+	Breaker *CircuitBreaker
 }
 
 func DefaultPolicy() Policy {
@@ -82,34 +95,81 @@ func DefaultPolicy() Policy {
 	}
 }
 
-func Retry(ctx context.Context, policy Policy, fn func() error) error {
-	if policy.MaxAttempts <= 0 {
-		policy.MaxAttempts = 3
-	}
-
-	var b backoff.BackOff
-	if policy.MaxElapsedTime > 0 {
-		b = ExponentialBackoffWithMaxElapsed(
+func backOffForPolicy(policy Policy) backoff.BackOff {
+	switch policy.Jitter {
+	case JitterFull:
+		return FullJitterBackoff(policy.InitialInterval, policy.MaxInterval)
+	case JitterEqual:
+		return EqualJitterBackoff(policy.InitialInterval, policy.MaxInterval)
+	case JitterDecorrelated:
+		return DecorrelatedJitterBackoff(policy.InitialInterval, policy.MaxInterval)
+	default:
+		if policy.MaxElapsedTime > 0 {
+			return ExponentialBackoffWithMaxElapsed(
+				policy.InitialInterval,
+				policy.MaxInterval,
+				policy.MaxElapsedTime,
+				policy.Multiplier,
+			)
+		}
+		return ExponentialBackoff(
 			policy.InitialInterval,
 			policy.MaxInterval,
-			policy.MaxElapsedTime,
 			policy.Multiplier,
 		)
+	}
+}
+
+func nextDelayForPolicy(policy Policy, attempt int, prevDelay time.Duration) time.Duration {
+	switch policy.Jitter {
+	case JitterFull:
+		return FullJitter(attempt, policy.InitialInterval, policy.MaxInterval)
+	case JitterEqual:
+		return EqualJitter(attempt, policy.InitialInterval, policy.MaxInterval)
+	case JitterDecorrelated:
+		if prevDelay == 0 {
+			prevDelay = policy.InitialInterval
+		}
+		return DecorrelatedJitter(prevDelay, policy.InitialInterval, policy.MaxInterval)
+	default:
+		return CalculateBackoffDuration(attempt, policy.InitialInterval, policy.Multiplier, policy.MaxInterval)
+	}
+}
+
+// callThroughBreaker runs fn unless policy.Breaker is open, in which case it
+// short-circuits to a FatalError without invoking fn. A nil Breaker leaves
+// the call path unchanged.
+func callThroughBreaker(breaker *CircuitBreaker, fn func() error) error {
+	if breaker == nil {
+		return fn()
+	}
+
+	if !breaker.Allow() {
+		return NewFatalError(fmt.Errorf("circuit breaker is open"))
+	}
+
+	err := fn()
+	if err == nil {
+		breaker.RecordSuccess()
 	} else {
-		b = ExponentialBackoff(
-			policy.InitialInterval,
-			policy.MaxInterval,
-			policy.Multiplier,
-		)
+		breaker.RecordFailure()
+	}
+	return err
+}
+
+func Retry(ctx context.Context, policy Policy, fn func() error) error {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 3
 	}
 
+	b := backOffForPolicy(policy)
 	b = backoff.WithContext(b, ctx)
 	b = backoff.WithMaxRetries(b, uint64(policy.MaxAttempts-1))
 
 	attempt := 0
 	operation := func() error {
 		attempt++
-		err := fn()
+		err := callThroughBreaker(policy.Breaker, fn)
 
 		if err == nil {
 			return nil
@@ -137,29 +197,15 @@ func RetryWithCallback(ctx context.Context, policy Policy, fn func() error, onRe
 		policy.MaxAttempts = 3
 	}
 
-	var b backoff.BackOff
-	if policy.MaxElapsedTime > 0 {
-		b = ExponentialBackoffWithMaxElapsed(
-			policy.InitialInterval,
-			policy.MaxInterval,
-			policy.MaxElapsedTime,
-			policy.Multiplier,
-		)
-	} else {
-		b = ExponentialBackoff(
-			policy.InitialInterval,
-			policy.MaxInterval,
-			policy.Multiplier,
-		)
-	}
-
+	b := backOffForPolicy(policy)
 	b = backoff.WithContext(b, ctx)
 	b = backoff.WithMaxRetries(b, uint64(policy.MaxAttempts-1))
 
 	attempt := 0
+	var lastDelay time.Duration
 	operation := func() error {
 		attempt++
-		err := fn()
+		err := callThroughBreaker(policy.Breaker, fn)
 
 		if err == nil {
 			return nil
@@ -177,7 +223,8 @@ func RetryWithCallback(ctx context.Context, policy Policy, fn func() error, onRe
 		}
 
 		if onRetry != nil && attempt < policy.MaxAttempts {
-			nextDelay := CalculateBackoffDuration(attempt, policy.InitialInterval, policy.Multiplier, policy.MaxInterval)
+			nextDelay := nextDelayForPolicy(policy, attempt, lastDelay)
+			lastDelay = nextDelay
 			onRetry(attempt, err, nextDelay)
 		}
 
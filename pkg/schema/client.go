@@ -0,0 +1,184 @@
+// Package schema provides a minimal Confluent-style Schema Registry client
+// and the Codec implementations (Avro, Protobuf) that use it to give
+// MessageEnvelope payloads contract validation on top of the broker's
+// default JSON wire format.
+package schema
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"yeti/internal/constants"
+)
+
+// Schema is a fetched schema registry entry: the raw schema text plus the
+// globally unique ID used in the Confluent wire framing.
+type Schema struct {
+	ID      int
+	Version int
+	Subject string
+	Schema  string
+}
+
+// Client is a minimal Schema Registry REST client. Schemas are immutable
+// once registered, so both subject+version and ID lookups are cached for
+// the lifetime of the client.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+
+	mu        sync.RWMutex
+	bySubject map[string]*Schema
+	byID      map[int]*Schema
+}
+
+func NewClient(baseURL string, timeout time.Duration) *Client {
+	if timeout <= 0 {
+		timeout = constants.DefaultHTTPTimeout
+	}
+	return &Client{
+		baseURL:    baseURL,
+		httpClient: &http.Client{Timeout: timeout},
+		bySubject:  make(map[string]*Schema),
+		byID:       make(map[int]*Schema),
+	}
+}
+
+func subjectCacheKey(subject string, version int) string {
+	return fmt.Sprintf("%s@%d", subject, version)
+}
+
+// GetSchema fetches the schema registered for subject at version (0 means
+// "latest"), returning the cached copy on repeat calls.
+func (c *Client) GetSchema(ctx context.Context, subject string, version int) (*Schema, error) {
+	key := subjectCacheKey(subject, version)
+
+	c.mu.RLock()
+	if s, ok := c.bySubject[key]; ok {
+		c.mu.RUnlock()
+		return s, nil
+	}
+	c.mu.RUnlock()
+
+	versionPath := "latest"
+	if version > 0 {
+		versionPath = fmt.Sprintf("%d", version)
+	}
+
+	var body struct {
+		Subject string `json:"subject"`
+		ID      int    `json:"id"`
+		Version int    `json:"version"`
+		Schema  string `json:"schema"`
+	}
+	url := fmt.Sprintf("%s/subjects/%s/versions/%s", c.baseURL, subject, versionPath)
+	if err := c.getJSON(ctx, url, &body); err != nil {
+		return nil, fmt.Errorf("failed to fetch schema for subject %s version %s: %w", subject, versionPath, err)
+	}
+
+	s := &Schema{ID: body.ID, Version: body.Version, Subject: body.Subject, Schema: body.Schema}
+
+	c.mu.Lock()
+	c.bySubject[key] = s
+	c.byID[s.ID] = s
+	c.mu.Unlock()
+
+	return s, nil
+}
+
+// GetSchemaByID fetches (or returns the cached) schema by its registry ID,
+// used when decoding a message whose wire framing names an ID rather than a
+// subject+version.
+func (c *Client) GetSchemaByID(ctx context.Context, id int) (*Schema, error) {
+	c.mu.RLock()
+	if s, ok := c.byID[id]; ok {
+		c.mu.RUnlock()
+		return s, nil
+	}
+	c.mu.RUnlock()
+
+	var body struct {
+		Schema string `json:"schema"`
+	}
+	url := fmt.Sprintf("%s/schemas/ids/%d", c.baseURL, id)
+	if err := c.getJSON(ctx, url, &body); err != nil {
+		return nil, fmt.Errorf("failed to fetch schema id %d: %w", id, err)
+	}
+
+	s := &Schema{ID: id, Schema: body.Schema}
+
+	c.mu.Lock()
+	c.byID[id] = s
+	c.mu.Unlock()
+
+	return s, nil
+}
+
+// CheckCompatibility asks the registry whether schema would be compatible
+// with subject's already-registered versions, per the subject's configured
+// compatibility level (BACKWARD by default in Confluent Schema Registry).
+// Used to validate schema evolution before a producer starts publishing
+// against a new subject/version.
+func (c *Client) CheckCompatibility(ctx context.Context, subject string, schema string) (bool, error) {
+	reqBody, err := json.Marshal(struct {
+		Schema string `json:"schema"`
+	}{Schema: schema})
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal compatibility check request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/compatibility/subjects/%s/versions/latest", c.baseURL, subject)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(reqBody))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to check compatibility for subject %s: %w", subject, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		// No prior version registered for subject - nothing to be
+		// incompatible with, so the new schema is trivially compatible.
+		return true, nil
+	}
+	if resp.StatusCode < constants.HTTPStatusOKMin || resp.StatusCode >= constants.HTTPStatusOKMax {
+		return false, fmt.Errorf("schema registry returned status %d checking compatibility for subject %s", resp.StatusCode, subject)
+	}
+
+	var body struct {
+		IsCompatible bool `json:"is_compatible"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return false, fmt.Errorf("failed to decode compatibility response for subject %s: %w", subject, err)
+	}
+
+	return body.IsCompatible, nil
+}
+
+func (c *Client) getJSON(ctx context.Context, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < constants.HTTPStatusOKMin || resp.StatusCode >= constants.HTTPStatusOKMax {
+		return fmt.Errorf("schema registry returned status %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,37 @@
+package schema
+
+import (
+	"fmt"
+
+	"yeti/internal/config"
+	"yeti/pkg/models"
+)
+
+// NewCodec builds the models.Codec described by cfg: plain JSON when
+// disabled or cfg.Codec is "json" (the default, preserving existing
+// behavior), otherwise an Avro or Protobuf codec backed by a Schema
+// Registry client, optionally wrapped to fall back to JSON on decode
+// failure.
+func NewCodec(cfg config.SchemaRegistryConfig) (models.Codec, error) {
+	if !cfg.Enabled || cfg.Codec == "" || cfg.Codec == "json" {
+		return models.JSONCodec{}, nil
+	}
+
+	client := NewClient(cfg.URL, cfg.Timeout)
+
+	var codec models.Codec
+	switch cfg.Codec {
+	case "avro":
+		codec = NewAvroCodec(client, cfg.Subject, cfg.Version)
+	case "protobuf":
+		codec = NewProtobufCodec(client, cfg.Subject, cfg.Version)
+	default:
+		return nil, fmt.Errorf("unknown schema registry codec: %s", cfg.Codec)
+	}
+
+	if cfg.FallbackJSON {
+		codec = WrapWithFallback(codec, models.JSONCodec{})
+	}
+
+	return codec, nil
+}
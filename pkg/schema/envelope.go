@@ -0,0 +1,84 @@
+package schema
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"yeti/pkg/models"
+)
+
+const timeLayout = time.RFC3339Nano
+
+func parseTimestamp(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(timeLayout, value)
+}
+
+// envelopeHeader carries every MessageEnvelope field except Payload. Only
+// Payload is schema-encoded (Avro/Protobuf schemas describe business event
+// data, not envelope plumbing), so the header travels alongside it as JSON,
+// length-prefixed ahead of the schema-encoded payload bytes.
+type envelopeHeader struct {
+	ID        string          `json:"id"`
+	Source    string          `json:"source"`
+	Timestamp string          `json:"timestamp"`
+	Metadata  models.Metadata `json:"metadata"`
+}
+
+// packBody joins a JSON-encoded header with schema-encoded payload bytes
+// behind a 4-byte big-endian length prefix for the header.
+func packBody(msg models.MessageEnvelope, payload []byte) ([]byte, error) {
+	header := envelopeHeader{
+		ID:        msg.ID,
+		Source:    msg.Source,
+		Timestamp: msg.Timestamp.Format(timeLayout),
+		Metadata:  msg.Metadata,
+	}
+
+	headerBytes, err := json.Marshal(header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal envelope header: %w", err)
+	}
+
+	body := make([]byte, 4+len(headerBytes)+len(payload))
+	binary.BigEndian.PutUint32(body[:4], uint32(len(headerBytes)))
+	copy(body[4:], headerBytes)
+	copy(body[4+len(headerBytes):], payload)
+	return body, nil
+}
+
+// unpackBody splits a packed body back into its envelope header and the
+// remaining schema-encoded payload bytes.
+func unpackBody(body []byte) (models.MessageEnvelope, []byte, error) {
+	if len(body) < 4 {
+		return models.MessageEnvelope{}, nil, fmt.Errorf("message body too short for envelope header length")
+	}
+
+	headerLen := int(binary.BigEndian.Uint32(body[:4]))
+	if len(body) < 4+headerLen {
+		return models.MessageEnvelope{}, nil, fmt.Errorf("message body too short for envelope header: want %d bytes", headerLen)
+	}
+
+	var header envelopeHeader
+	if err := json.Unmarshal(body[4:4+headerLen], &header); err != nil {
+		return models.MessageEnvelope{}, nil, fmt.Errorf("failed to unmarshal envelope header: %w", err)
+	}
+
+	timestamp, err := parseTimestamp(header.Timestamp)
+	if err != nil {
+		return models.MessageEnvelope{}, nil, fmt.Errorf("failed to parse envelope timestamp: %w", err)
+	}
+
+	msg := models.MessageEnvelope{
+		ID:        header.ID,
+		Source:    header.Source,
+		Timestamp: timestamp,
+		Metadata:  header.Metadata,
+	}
+
+	return msg, body[4+headerLen:], nil
+}
@@ -0,0 +1,53 @@
+package schema
+
+import (
+	"context"
+
+	"yeti/pkg/models"
+)
+
+// FallbackCodec decorates a schema-aware Codec so that a Decode failure
+// (schema not found, payload doesn't match it, registry unreachable) falls
+// back to decoding as plain JSON instead of failing the message outright.
+// This is meant for migrating a topic from JSON to a schema-enforced codec:
+// Encode always uses the primary codec, so new messages are schema-validated
+// immediately, while old JSON messages already in flight still decode.
+type FallbackCodec struct {
+	primary  models.Codec
+	fallback models.Codec
+}
+
+func WrapWithFallback(primary models.Codec, fallback models.Codec) *FallbackCodec {
+	return &FallbackCodec{primary: primary, fallback: fallback}
+}
+
+func (c *FallbackCodec) Name() string {
+	return c.primary.Name()
+}
+
+func (c *FallbackCodec) ContentType() string {
+	return c.primary.ContentType()
+}
+
+func (c *FallbackCodec) Encode(msg models.MessageEnvelope) ([]byte, error) {
+	return c.primary.Encode(msg)
+}
+
+func (c *FallbackCodec) Decode(data []byte) (models.MessageEnvelope, error) {
+	msg, err := c.primary.Decode(data)
+	if err == nil {
+		return msg, nil
+	}
+	return c.fallback.Decode(data)
+}
+
+// ValidateSchema implements SchemaValidator by delegating to primary, if it
+// implements SchemaValidator itself - the fallback codec (always JSONCodec
+// today) has no schema to validate.
+func (c *FallbackCodec) ValidateSchema(ctx context.Context) error {
+	v, ok := c.primary.(SchemaValidator)
+	if !ok {
+		return nil
+	}
+	return v.ValidateSchema(ctx)
+}
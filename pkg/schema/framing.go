@@ -0,0 +1,35 @@
+package schema
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// magicByte is the leading byte Confluent's wire format prepends to every
+// schema-registry-encoded message, ahead of the big-endian schema ID.
+const magicByte byte = 0x0
+
+// frameHeaderLen is the length of the magic byte plus the 4-byte schema ID.
+const frameHeaderLen = 5
+
+// WriteFrame prepends the magic-byte + schema-ID header to a schema-encoded
+// payload, producing the bytes that go on the wire.
+func WriteFrame(schemaID int, payload []byte) []byte {
+	framed := make([]byte, frameHeaderLen+len(payload))
+	framed[0] = magicByte
+	binary.BigEndian.PutUint32(framed[1:frameHeaderLen], uint32(schemaID))
+	copy(framed[frameHeaderLen:], payload)
+	return framed
+}
+
+// ReadFrame splits a framed message into its schema ID and payload.
+func ReadFrame(data []byte) (schemaID int, payload []byte, err error) {
+	if len(data) < frameHeaderLen {
+		return 0, nil, fmt.Errorf("message too short for schema registry framing: %d bytes", len(data))
+	}
+	if data[0] != magicByte {
+		return 0, nil, fmt.Errorf("unexpected magic byte: %#x", data[0])
+	}
+	schemaID = int(binary.BigEndian.Uint32(data[1:frameHeaderLen]))
+	return schemaID, data[frameHeaderLen:], nil
+}
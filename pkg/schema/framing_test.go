@@ -0,0 +1,31 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFrameReadFrameRoundTrip(t *testing.T) {
+	payload := []byte(`{"field":"value"}`)
+	framed := WriteFrame(42, payload)
+
+	schemaID, decoded, err := ReadFrame(framed)
+	require.NoError(t, err)
+	assert.Equal(t, 42, schemaID)
+	assert.Equal(t, payload, decoded)
+}
+
+func TestReadFrameRejectsShortMessages(t *testing.T) {
+	_, _, err := ReadFrame([]byte{0x0, 0x1})
+	assert.Error(t, err)
+}
+
+func TestReadFrameRejectsWrongMagicByte(t *testing.T) {
+	framed := WriteFrame(1, []byte("payload"))
+	framed[0] = 0x1
+
+	_, _, err := ReadFrame(framed)
+	assert.Error(t, err)
+}
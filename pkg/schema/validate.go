@@ -0,0 +1,50 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+)
+
+// SchemaValidator is an optional, richer Codec: if the codec NewCodec built
+// implements it, NewProducer calls ValidateSchema once at startup so a
+// backward/forward-incompatible schema change fails fast instead of only
+// surfacing the first time a consumer can't decode a published message.
+// NewConsumer does not call this - a consumer only ever reads schemas some
+// producer has already had validated.
+type SchemaValidator interface {
+	ValidateSchema(ctx context.Context) error
+}
+
+// ValidateSchema fetches the codec's configured subject/version and checks
+// it for compatibility against the subject's latest registered version,
+// per the registry's configured compatibility level (BACKWARD by default).
+// A subject with no prior version registered is trivially compatible - see
+// Client.CheckCompatibility.
+func validateSubjectSchema(ctx context.Context, client *Client, subject string, version int) error {
+	s, err := client.GetSchema(ctx, subject, version)
+	if err != nil {
+		return err
+	}
+
+	compatible, err := client.CheckCompatibility(ctx, subject, s.Schema)
+	if err != nil {
+		return err
+	}
+	if !compatible {
+		return &IncompatibleSchemaError{Subject: subject, Version: s.Version}
+	}
+
+	return nil
+}
+
+// IncompatibleSchemaError is returned by ValidateSchema when the registry
+// reports the codec's configured schema version is not compatible with
+// subject's latest registered version.
+type IncompatibleSchemaError struct {
+	Subject string
+	Version int
+}
+
+func (e *IncompatibleSchemaError) Error() string {
+	return fmt.Sprintf("schema %s version %d is not backward/forward-compatible with the latest registered version of subject %s", e.Subject, e.Version, e.Subject)
+}
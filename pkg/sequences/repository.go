@@ -0,0 +1,61 @@
+// Package sequences hands out gap-free, monotonically increasing integers
+// per named group, backed by MongoDB. It exists so a caller needing a
+// stable display index - one that survives the row it numbers being
+// deleted and recreated, unlike re-deriving a position from ObjectId
+// ordering or a COUNT - doesn't have to hand-roll its own findAndModify
+// counter document.
+package sequences
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Repository hands out group's next value. Distinct groups (e.g. one per
+// tenant, or one shared group for every enrichment rule ever created) are
+// numbered independently.
+type Repository interface {
+	// Next returns the next value for group, starting at 1 the first time
+	// group is seen.
+	Next(ctx context.Context, group string) (int64, error)
+}
+
+type mongoRepository struct {
+	collection *mongo.Collection
+}
+
+// NewRepository builds a Repository backed by db's sequences collection.
+func NewRepository(db *mongo.Database) Repository {
+	return &mongoRepository{collection: db.Collection("sequences")}
+}
+
+// sequenceDoc is the sequences collection's document shape: one per group,
+// GroupID doubling as _id so Next's findAndModify is a single equality
+// match against the collection's primary index.
+type sequenceDoc struct {
+	GroupID  string `bson:"_id"`
+	MaxIndex int64  `bson:"max_index"`
+}
+
+// Next increments group's max_index by one and returns the new value via a
+// single findAndModify, so concurrent callers - including across every
+// Yeti instance sharing db - never observe or hand out the same value
+// twice, and never skip one. The document is upserted on first use with
+// max_index implicitly starting at 0 before the $inc applies, so group's
+// first Next call returns 1.
+func (r *mongoRepository) Next(ctx context.Context, group string) (int64, error) {
+	filter := bson.M{"_id": group}
+	update := bson.M{"$inc": bson.M{"max_index": int64(1)}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var doc sequenceDoc
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&doc); err != nil {
+		return 0, fmt.Errorf("failed to increment sequence %q: %w", group, err)
+	}
+
+	return doc.MaxIndex, nil
+}
@@ -0,0 +1,214 @@
+// Package supervisor provides a small suture-style supervision tree: each
+// long-running worker in a service's App.Run (HTTP server, broker consumer,
+// background updater) registers as a Service, and the Supervisor restarts it
+// with exponential backoff when it exits with a non-fatal error instead of
+// letting one failing goroutine unwind the whole process.
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"yeti/internal/logger"
+	apperrors "yeti/pkg/errors"
+	"yeti/pkg/logging"
+)
+
+// Service is a worker the Supervisor manages. Serve blocks until ctx is
+// canceled or the worker fails; Stop requests an early, graceful shutdown
+// of whatever resource Serve is blocked on (e.g. an HTTP listener).
+type Service interface {
+	Serve(ctx context.Context) error
+	Stop()
+}
+
+type State string
+
+const (
+	StateRunning State = "running"
+	StateBackoff State = "backoff"
+	StateStopped State = "stopped"
+	StateFailed  State = "failed"
+)
+
+// ChildHealth is the supervisor's current view of one registered Service,
+// meant to be surfaced through a service's /health endpoint.
+type ChildHealth struct {
+	State     State
+	Restarts  int
+	LastError error
+}
+
+// Spec configures restart backoff. The zero value falls back to the
+// defaults below, which mirror retry.Policy's exponential defaults.
+type Spec struct {
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+}
+
+func (s Spec) withDefaults() Spec {
+	if s.InitialBackoff <= 0 {
+		s.InitialBackoff = time.Second
+	}
+	if s.MaxBackoff <= 0 {
+		s.MaxBackoff = 30 * time.Second
+	}
+	if s.Multiplier <= 0 {
+		s.Multiplier = 2.0
+	}
+	return s
+}
+
+type child struct {
+	name    string
+	service Service
+
+	mu       sync.Mutex
+	state    State
+	restarts int
+	lastErr  error
+}
+
+func (c *child) setState(state State, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.state = state
+	if err != nil {
+		c.lastErr = err
+	}
+}
+
+func (c *child) recordRestart(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.restarts++
+	c.lastErr = err
+}
+
+func (c *child) health() ChildHealth {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return ChildHealth{State: c.state, Restarts: c.restarts, LastError: c.lastErr}
+}
+
+// Supervisor runs a fixed set of Services for the lifetime of a context,
+// restarting any that exit with a non-fatal error. A Service's error is
+// treated as fatal (no restart) when it satisfies errors.FatalError and
+// IsFatal() returns true; every other non-nil error is retried.
+type Supervisor struct {
+	name   string
+	spec   Spec
+	logger logger.Logger
+
+	mu       sync.Mutex
+	children []*child
+}
+
+func New(name string, spec Spec, log logger.Logger) *Supervisor {
+	return &Supervisor{name: name, spec: spec.withDefaults(), logger: log}
+}
+
+// Add registers a Service under name. Add must be called before Serve.
+func (s *Supervisor) Add(name string, service Service) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.children = append(s.children, &child{name: name, service: service, state: StateStopped})
+}
+
+// Serve starts every registered child and blocks until ctx is canceled and
+// all children have stopped.
+func (s *Supervisor) Serve(ctx context.Context) error {
+	logCtx := logging.WithServiceName(ctx, s.name)
+
+	var wg sync.WaitGroup
+	for _, c := range s.children {
+		wg.Add(1)
+		go func(c *child) {
+			defer wg.Done()
+			s.runChild(logCtx, ctx, c)
+		}(c)
+	}
+	wg.Wait()
+
+	return ctx.Err()
+}
+
+func (s *Supervisor) runChild(logCtx context.Context, ctx context.Context, c *child) {
+	backoffDelay := s.spec.InitialBackoff
+
+	for {
+		if ctx.Err() != nil {
+			c.setState(StateStopped, nil)
+			return
+		}
+
+		c.setState(StateRunning, nil)
+		s.logger.InfowCtx(logCtx, "Supervisor starting child", "supervisor", s.name, "child", c.name)
+
+		err := c.service.Serve(ctx)
+		if err == nil || ctx.Err() != nil {
+			c.setState(StateStopped, nil)
+			return
+		}
+
+		c.recordRestart(err)
+
+		var fatalErr apperrors.FatalError
+		if errors.As(err, &fatalErr) && fatalErr.IsFatal() {
+			c.setState(StateFailed, err)
+			s.logger.ErrorwCtx(logCtx, "Supervisor child failed fatally, not restarting",
+				"supervisor", s.name,
+				"child", c.name,
+				"error", err,
+			)
+			return
+		}
+
+		c.setState(StateBackoff, err)
+		s.logger.WarnwCtx(logCtx, "Supervisor child exited, restarting after backoff",
+			"supervisor", s.name,
+			"child", c.name,
+			"error", err,
+			"backoff", backoffDelay,
+		)
+
+		select {
+		case <-ctx.Done():
+			c.setState(StateStopped, nil)
+			return
+		case <-time.After(backoffDelay):
+		}
+
+		backoffDelay = time.Duration(float64(backoffDelay) * s.spec.Multiplier)
+		if backoffDelay > s.spec.MaxBackoff {
+			backoffDelay = s.spec.MaxBackoff
+		}
+	}
+}
+
+// Stop requests every registered child to stop. Callers must also cancel
+// the context passed to Serve, since Stop only unblocks a child's own Serve
+// call and does not itself make Supervisor.Serve return.
+func (s *Supervisor) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.children {
+		c.service.Stop()
+	}
+}
+
+// Health returns the current state of every registered child, keyed by
+// name.
+func (s *Supervisor) Health() map[string]ChildHealth {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make(map[string]ChildHealth, len(s.children))
+	for _, c := range s.children {
+		out[c.name] = c.health()
+	}
+	return out
+}
@@ -0,0 +1,13 @@
+package tracing
+
+import (
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+)
+
+// GRPCClientDialOption returns a grpc.DialOption that propagates the active
+// span context over gRPC metadata on every call made through the dialed
+// connection, the gRPC-client equivalent of GinMiddleware for HTTP servers.
+func GRPCClientDialOption() grpc.DialOption {
+	return grpc.WithStatsHandler(otelgrpc.NewClientHandler())
+}
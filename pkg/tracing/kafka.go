@@ -5,6 +5,7 @@ import (
 
 	"github.com/segmentio/kafka-go"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -75,3 +76,16 @@ func StartSpanFromKafkaMessage(ctx context.Context, operationName string, header
 	tracer := GetTracer("yeti-kafka")
 	return tracer.Start(ctx, operationName)
 }
+
+// SetKafkaMessageAttributes records a Kafka message's coordinates (topic,
+// partition, and key) on span, so a trace can be correlated back to the
+// exact partition/offset an operator is looking at in Kafka tooling. key is
+// recorded as-is since dedup/filtering/enrichment keys are message IDs, not
+// secrets.
+func SetKafkaMessageAttributes(span trace.Span, topic string, partition int, key string) {
+	span.SetAttributes(
+		attribute.String("messaging.destination", topic),
+		attribute.Int("messaging.kafka.partition", partition),
+		attribute.String("messaging.kafka.message_key", key),
+	)
+}
@@ -0,0 +1,59 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func InjectTraceContextNATS(ctx context.Context, headers nats.Header) nats.Header {
+	propagator := otel.GetTextMapPropagator()
+	if propagator == nil {
+		return headers
+	}
+	if headers == nil {
+		headers = nats.Header{}
+	}
+
+	propagator.Inject(ctx, natsHeaderCarrier{headers: headers})
+
+	return headers
+}
+
+func ExtractTraceContextNATS(ctx context.Context, headers nats.Header) context.Context {
+	propagator := otel.GetTextMapPropagator()
+	if propagator == nil {
+		return ctx
+	}
+
+	return propagator.Extract(ctx, natsHeaderCarrier{headers: headers})
+}
+
+type natsHeaderCarrier struct {
+	headers nats.Header
+}
+
+func (c natsHeaderCarrier) Get(key string) string {
+	return c.headers.Get(key)
+}
+
+func (c natsHeaderCarrier) Set(key, value string) {
+	c.headers.Set(key, value)
+}
+
+func (c natsHeaderCarrier) Keys() []string {
+	keys := make([]string, 0, len(c.headers))
+	for k := range c.headers {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func StartSpanFromNATSMessage(ctx context.Context, operationName string, headers nats.Header) (context.Context, trace.Span) {
+	ctx = ExtractTraceContextNATS(ctx, headers)
+
+	tracer := GetTracer("yeti-nats")
+	return tracer.Start(ctx, operationName)
+}
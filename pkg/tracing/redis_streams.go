@@ -0,0 +1,77 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InjectTraceContextRedisStreams writes the current trace context into
+// values as plain string entries (traceparent/tracestate), the same fields
+// XAddArgs.Values ends up as stream entry fields. Redis Streams has no
+// notion of message headers distinct from its fields, so the trace context
+// rides alongside "payload" as ordinary entries instead.
+func InjectTraceContextRedisStreams(ctx context.Context, values map[string]interface{}) map[string]interface{} {
+	propagator := otel.GetTextMapPropagator()
+	if propagator == nil {
+		return values
+	}
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+
+	propagator.Inject(ctx, redisStreamsCarrier{values: values})
+
+	return values
+}
+
+func ExtractTraceContextRedisStreams(ctx context.Context, values map[string]interface{}) context.Context {
+	propagator := otel.GetTextMapPropagator()
+	if propagator == nil {
+		return ctx
+	}
+
+	return propagator.Extract(ctx, redisStreamsCarrier{values: values})
+}
+
+type redisStreamsCarrier struct {
+	values map[string]interface{}
+}
+
+func (c redisStreamsCarrier) Get(key string) string {
+	v, ok := c.values[key].(string)
+	if !ok {
+		return ""
+	}
+	return v
+}
+
+func (c redisStreamsCarrier) Set(key, value string) {
+	c.values[key] = value
+}
+
+func (c redisStreamsCarrier) Keys() []string {
+	keys := make([]string, 0, len(c.values))
+	for k := range c.values {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func StartSpanFromRedisStreamsMessage(ctx context.Context, operationName string, values map[string]interface{}) (context.Context, trace.Span) {
+	ctx = ExtractTraceContextRedisStreams(ctx, values)
+
+	tracer := GetTracer("yeti-redis-streams")
+	return tracer.Start(ctx, operationName)
+}
+
+// SetRedisStreamsMessageAttributes records a stream entry's coordinates
+// (stream name and entry ID), mirroring SetKafkaMessageAttributes.
+func SetRedisStreamsMessageAttributes(span trace.Span, stream, entryID string) {
+	span.SetAttributes(
+		attribute.String("messaging.destination", stream),
+		attribute.String("messaging.redis_streams.entry_id", entryID),
+	)
+}
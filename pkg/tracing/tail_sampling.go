@@ -0,0 +1,257 @@
+package tracing
+
+import (
+	"container/list"
+	"context"
+	"math/rand"
+	"regexp"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"yeti/internal/config"
+	"yeti/pkg/metrics"
+)
+
+const (
+	defaultMaxTracesInFlight   = 50000
+	defaultDecisionWaitSeconds = 10
+)
+
+// tailSamplingPolicy is a compiled config.TailSamplingPolicyConfig. Policies
+// are combined with OR: a trace is kept if any policy matches.
+type tailSamplingPolicy struct {
+	policyType     string
+	latencyMS      int64
+	attributeKey   string
+	attributeRegex *regexp.Regexp
+	probability    float64
+}
+
+func compileTailSamplingPolicies(cfgs []config.TailSamplingPolicyConfig) []tailSamplingPolicy {
+	policies := make([]tailSamplingPolicy, 0, len(cfgs))
+	for _, c := range cfgs {
+		p := tailSamplingPolicy{
+			policyType:   c.Type,
+			latencyMS:    c.LatencyMS,
+			attributeKey: c.AttributeKey,
+			probability:  c.Probability,
+		}
+		if c.Type == "attribute_regex" && c.AttributeRegex != "" {
+			if re, err := regexp.Compile(c.AttributeRegex); err == nil {
+				p.attributeRegex = re
+			}
+		}
+		policies = append(policies, p)
+	}
+	return policies
+}
+
+func (p tailSamplingPolicy) matches(spans []sdktrace.ReadOnlySpan, root sdktrace.ReadOnlySpan) bool {
+	switch p.policyType {
+	case "latency_ms":
+		return root.EndTime().Sub(root.StartTime()).Milliseconds() > p.latencyMS
+	case "status_error":
+		for _, s := range spans {
+			if s.Status().Code == codes.Error {
+				return true
+			}
+		}
+		return false
+	case "attribute_regex":
+		if p.attributeRegex == nil {
+			return false
+		}
+		for _, s := range spans {
+			for _, attr := range s.Attributes() {
+				if string(attr.Key) == p.attributeKey && p.attributeRegex.MatchString(attr.Value.Emit()) {
+					return true
+				}
+			}
+		}
+		return false
+	case "probabilistic":
+		return rand.Float64() < p.probability
+	default:
+		return false
+	}
+}
+
+// traceBuffer holds the spans seen so far for a single trace while the
+// tail sampling decision is pending.
+type traceBuffer struct {
+	traceID   trace.TraceID
+	spans     []sdktrace.ReadOnlySpan
+	firstSeen time.Time
+}
+
+// TailSampler is an sdktrace.SpanProcessor that defers the sample/drop
+// decision until a trace's root span ends (or DecisionWaitSeconds elapses).
+// Every span is recorded by the underlying sampler; TailSampler buffers
+// spans per trace-id in a bounded LRU and only forwards a trace to the
+// downstream processor once a policy decides to keep it.
+type TailSampler struct {
+	downstream   sdktrace.SpanProcessor
+	policies     []tailSamplingPolicy
+	maxTraces    int
+	decisionWait time.Duration
+	serviceName  string
+
+	mu      sync.Mutex
+	buffers map[trace.TraceID]*list.Element
+	order   *list.List
+
+	stopSweep chan struct{}
+}
+
+// NewTailSampler wraps downstream (typically an sdktrace.NewBatchSpanProcessor
+// built around the real OTLP exporter) with tail-based sampling.
+func NewTailSampler(downstream sdktrace.SpanProcessor, cfg config.TailSamplingConfig, serviceName string) *TailSampler {
+	maxTraces := cfg.MaxTracesInFlight
+	if maxTraces <= 0 {
+		maxTraces = defaultMaxTracesInFlight
+	}
+	decisionWaitSeconds := cfg.DecisionWaitSeconds
+	if decisionWaitSeconds <= 0 {
+		decisionWaitSeconds = defaultDecisionWaitSeconds
+	}
+
+	t := &TailSampler{
+		downstream:   downstream,
+		policies:     compileTailSamplingPolicies(cfg.Policies),
+		maxTraces:    maxTraces,
+		decisionWait: time.Duration(decisionWaitSeconds) * time.Second,
+		serviceName:  serviceName,
+		buffers:      make(map[trace.TraceID]*list.Element),
+		order:        list.New(),
+		stopSweep:    make(chan struct{}),
+	}
+
+	go t.sweepLoop()
+
+	return t
+}
+
+func (t *TailSampler) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {}
+
+func (t *TailSampler) OnEnd(s sdktrace.ReadOnlySpan) {
+	traceID := s.SpanContext().TraceID()
+	isRoot := !s.Parent().IsValid()
+
+	t.mu.Lock()
+	el, ok := t.buffers[traceID]
+	var buf *traceBuffer
+	if ok {
+		buf = el.Value.(*traceBuffer)
+		t.order.MoveToFront(el)
+	} else {
+		buf = &traceBuffer{traceID: traceID, firstSeen: time.Now()}
+		el = t.order.PushFront(buf)
+		t.buffers[traceID] = el
+		t.evictIfNeededLocked()
+	}
+	buf.spans = append(buf.spans, s)
+	t.mu.Unlock()
+
+	if isRoot {
+		t.decide(traceID)
+	}
+}
+
+// evictIfNeededLocked drops the oldest buffered traces once the in-flight
+// count exceeds maxTraces. Callers must hold t.mu.
+func (t *TailSampler) evictIfNeededLocked() {
+	for len(t.buffers) > t.maxTraces {
+		oldest := t.order.Back()
+		if oldest == nil {
+			return
+		}
+		buf := oldest.Value.(*traceBuffer)
+		t.order.Remove(oldest)
+		delete(t.buffers, buf.traceID)
+		metrics.TailSamplingEvictedTotal.WithLabelValues(t.serviceName).Inc()
+	}
+}
+
+func (t *TailSampler) sweepLoop() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			t.sweepExpired()
+		case <-t.stopSweep:
+			return
+		}
+	}
+}
+
+// sweepExpired forces a decision on traces whose root span never arrived
+// within decisionWait, so buffer entries aren't held forever.
+func (t *TailSampler) sweepExpired() {
+	now := time.Now()
+	t.mu.Lock()
+	var expired []trace.TraceID
+	for id, el := range t.buffers {
+		buf := el.Value.(*traceBuffer)
+		if now.Sub(buf.firstSeen) > t.decisionWait {
+			expired = append(expired, id)
+		}
+	}
+	t.mu.Unlock()
+
+	for _, id := range expired {
+		t.decide(id)
+	}
+}
+
+func (t *TailSampler) decide(traceID trace.TraceID) {
+	t.mu.Lock()
+	el, ok := t.buffers[traceID]
+	if !ok {
+		t.mu.Unlock()
+		return
+	}
+	buf := el.Value.(*traceBuffer)
+	t.order.Remove(el)
+	delete(t.buffers, traceID)
+	t.mu.Unlock()
+
+	root := buf.spans[len(buf.spans)-1]
+	for _, s := range buf.spans {
+		if !s.Parent().IsValid() {
+			root = s
+			break
+		}
+	}
+
+	keep := false
+	for _, p := range t.policies {
+		if p.matches(buf.spans, root) {
+			keep = true
+			break
+		}
+	}
+
+	if !keep {
+		metrics.TailSamplingDroppedTotal.WithLabelValues(t.serviceName).Inc()
+		return
+	}
+
+	metrics.TailSamplingKeptTotal.WithLabelValues(t.serviceName).Inc()
+	for _, s := range buf.spans {
+		t.downstream.OnEnd(s)
+	}
+}
+
+func (t *TailSampler) Shutdown(ctx context.Context) error {
+	close(t.stopSweep)
+	return t.downstream.Shutdown(ctx)
+}
+
+func (t *TailSampler) ForceFlush(ctx context.Context) error {
+	return t.downstream.ForceFlush(ctx)
+}
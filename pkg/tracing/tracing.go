@@ -2,16 +2,19 @@ package tracing
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.opentelemetry.io/otel/semconv/v1.26.0"
 	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/credentials"
 
 	"yeti/internal/config"
 )
@@ -57,25 +60,24 @@ func Init(cfg config.TracingConfig, serviceName string) (*TracerProvider, error)
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	opts := []otlptracegrpc.Option{
-		otlptracegrpc.WithEndpoint(cfg.OTLP.Endpoint),
-	}
-	if cfg.OTLP.Insecure {
-		opts = append(opts, otlptracegrpc.WithInsecure())
-	}
-
-	exporter, err := otlptracegrpc.New(ctx, opts...)
+	exporter, err := buildExporter(ctx, cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
 	}
 
 	sampler := createSampler(cfg.Sampler)
 
-	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithBatcher(exporter),
-		sdktrace.WithResource(res),
-		sdktrace.WithSampler(sampler),
-	)
+	var tpOpts []sdktrace.TracerProviderOption
+	tpOpts = append(tpOpts, sdktrace.WithResource(res), sdktrace.WithSampler(sampler))
+
+	if cfg.Sampler.Type == "tail_sampling" {
+		batcher := sdktrace.NewBatchSpanProcessor(exporter)
+		tpOpts = append(tpOpts, sdktrace.WithSpanProcessor(NewTailSampler(batcher, cfg.TailSampling, serviceName)))
+	} else {
+		tpOpts = append(tpOpts, sdktrace.WithBatcher(exporter))
+	}
+
+	tp := sdktrace.NewTracerProvider(tpOpts...)
 
 	otel.SetTracerProvider(tp)
 
@@ -97,6 +99,10 @@ func createSampler(cfg config.SamplerConfig) sdktrace.Sampler {
 		return sdktrace.ParentBased(sdktrace.AlwaysSample())
 	case "parentbased_traceidratio":
 		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.Param))
+	case "tail_sampling":
+		// The actual keep/drop decision is made by TailSampler after a trace
+		// completes, so every span must be recorded here.
+		fallthrough
 	case "always_on":
 		fallthrough
 	default:
@@ -104,6 +110,63 @@ func createSampler(cfg config.SamplerConfig) sdktrace.Sampler {
 	}
 }
 
+// buildExporter constructs the OTLP span exporter for the configured
+// protocol, applying TLS credentials and custom headers (e.g. for auth
+// against SaaS collectors) when provided.
+func buildExporter(ctx context.Context, cfg config.TracingConfig) (sdktrace.SpanExporter, error) {
+	switch cfg.Protocol {
+	case "http/protobuf":
+		opts := []otlptracehttp.Option{
+			otlptracehttp.WithEndpoint(cfg.OTLP.Endpoint),
+		}
+		if cfg.OTLP.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		if len(cfg.OTLP.Headers) > 0 {
+			opts = append(opts, otlptracehttp.WithHeaders(cfg.OTLP.Headers))
+		}
+		if cfg.OTLP.TLS.CertFile != "" {
+			tlsCfg, err := loadOTLPTLSConfig(cfg.OTLP.TLS)
+			if err != nil {
+				return nil, err
+			}
+			opts = append(opts, otlptracehttp.WithTLSClientConfig(tlsCfg))
+		}
+		return otlptracehttp.New(ctx, opts...)
+	case "grpc", "":
+		opts := []otlptracegrpc.Option{
+			otlptracegrpc.WithEndpoint(cfg.OTLP.Endpoint),
+		}
+		if cfg.OTLP.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		if len(cfg.OTLP.Headers) > 0 {
+			opts = append(opts, otlptracegrpc.WithHeaders(cfg.OTLP.Headers))
+		}
+		if cfg.OTLP.TLS.CertFile != "" {
+			tlsCfg, err := loadOTLPTLSConfig(cfg.OTLP.TLS)
+			if err != nil {
+				return nil, err
+			}
+			opts = append(opts, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(tlsCfg)))
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unsupported tracing protocol: %s", cfg.Protocol)
+	}
+}
+
+func loadOTLPTLSConfig(cfg config.OTLPTLSConfig) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load OTLP client certificate: %w", err)
+	}
+	return &tls.Config{
+		Certificates:       []tls.Certificate{cert},
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}, nil
+}
+
 func GetTracer(name string) trace.Tracer {
 	return otel.Tracer(name)
 }
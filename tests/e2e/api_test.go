@@ -170,6 +170,50 @@ func TestAuditLogs(t *testing.T) {
 	assert.GreaterOrEqual(t, len(filteredLogs), 1)
 }
 
+// TestCrossTenantRuleIsolation verifies that a filtering rule created under
+// one tenant's API key is invisible to a different tenant: neither a direct
+// GET by ID nor a list call leaks it across the tenant boundary. The two
+// keys are minted through the unauthenticated default identity, which
+// AuthMiddleware grants every scope (including config:write) when no
+// X-API-Key header is presented and Management.Auth.Enabled is off (with it
+// on, such a request is rejected) - the same bootstrap path an operator would
+// use before provisioning any keys.
+func TestCrossTenantRuleIsolation(t *testing.T) {
+	tenantAKey := createAPIKey(t, "tenant-a", []management.Scope{
+		management.ScopeRulesRead, management.ScopeRulesWrite,
+	})
+	tenantBKey := createAPIKey(t, "tenant-b", []management.Scope{
+		management.ScopeRulesRead, management.ScopeRulesWrite,
+	})
+
+	createReq := management.CreateFilteringRuleRequest{
+		Name:       "tenant_a_only_rule",
+		Expression: "payload.status == 'active'",
+		Priority:   10,
+		Enabled:    boolPtr(true),
+	}
+	ruleID := createFilteringRuleAs(t, tenantAKey, createReq)
+
+	resp := getFilteringRuleAsRaw(t, tenantBKey, ruleID)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "tenant B should not be able to read tenant A's rule")
+
+	rulesForB := listFilteringRulesAs(t, tenantBKey)
+	for _, r := range rulesForB {
+		assert.NotEqual(t, ruleID, r.ID, "tenant A's rule must not appear in tenant B's rule list")
+	}
+
+	rulesForA := listFilteringRulesAs(t, tenantAKey)
+	found := false
+	for _, r := range rulesForA {
+		if r.ID == ruleID {
+			found = true
+			break
+		}
+	}
+	assert.True(t, found, "tenant A should still see its own rule")
+}
+
 func TestValidationErrors(t *testing.T) {
 	invalidReq := management.CreateFilteringRuleRequest{
 		Name: "",
@@ -532,6 +576,106 @@ func createEnrichmentRuleWithError(t *testing.T, req management.CreateEnrichment
 	return resp
 }
 
+func createAPIKey(t *testing.T, tenantID string, scopes []management.Scope) string {
+	t.Helper()
+
+	req := management.CreateAPIKeyRequest{
+		TenantID: tenantID,
+		Name:     fmt.Sprintf("e2e-%s", tenantID),
+		Scopes:   scopes,
+	}
+	body, err := json.Marshal(req)
+	require.NoError(t, err)
+
+	resp, err := http.Post(
+		fmt.Sprintf("%s/api/v1/keys", managementServiceURL),
+		"application/json",
+		bytes.NewBuffer(body),
+	)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var created management.CreateAPIKeyResponse
+	err = json.NewDecoder(resp.Body).Decode(&created)
+	require.NoError(t, err)
+
+	return created.Key
+}
+
+func createFilteringRuleAs(t *testing.T, apiKey string, req management.CreateFilteringRuleRequest) string {
+	t.Helper()
+
+	body, err := json.Marshal(req)
+	require.NoError(t, err)
+
+	httpReq, err := http.NewRequest(
+		"POST",
+		fmt.Sprintf("%s/api/v1/rules/filtering", managementServiceURL),
+		bytes.NewBuffer(body),
+	)
+	require.NoError(t, err)
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("X-API-Key", apiKey)
+
+	client := &http.Client{}
+	resp, err := client.Do(httpReq)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var rule management.FilteringRule
+	err = json.NewDecoder(resp.Body).Decode(&rule)
+	require.NoError(t, err)
+
+	return rule.ID
+}
+
+func getFilteringRuleAsRaw(t *testing.T, apiKey, id string) *http.Response {
+	t.Helper()
+
+	httpReq, err := http.NewRequest(
+		"GET",
+		fmt.Sprintf("%s/api/v1/rules/filtering/%s", managementServiceURL, id),
+		nil,
+	)
+	require.NoError(t, err)
+	httpReq.Header.Set("X-API-Key", apiKey)
+
+	client := &http.Client{}
+	resp, err := client.Do(httpReq)
+	require.NoError(t, err)
+
+	return resp
+}
+
+func listFilteringRulesAs(t *testing.T, apiKey string) []management.FilteringRule {
+	t.Helper()
+
+	httpReq, err := http.NewRequest(
+		"GET",
+		fmt.Sprintf("%s/api/v1/rules/filtering", managementServiceURL),
+		nil,
+	)
+	require.NoError(t, err)
+	httpReq.Header.Set("X-API-Key", apiKey)
+
+	client := &http.Client{}
+	resp, err := client.Do(httpReq)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var rules []management.FilteringRule
+	err = json.NewDecoder(resp.Body).Decode(&rules)
+	require.NoError(t, err)
+
+	return rules
+}
+
 func boolPtr(b bool) *bool {
 	return &b
 }
@@ -0,0 +1,13 @@
+//go:build fake
+
+package e2e
+
+import "yeti/pkg/pipelinetest"
+
+// newBroker returns pipelinetest's in-process FakeBroker instead of a real
+// Kafka client, for `go test -tags fake ./tests/e2e/...`: no live broker on
+// kafkaBroker required, no per-test consumer-group churn, and waits resolve
+// the instant a message is produced instead of on a fixed poll interval.
+func newBroker() pipelinetest.Broker {
+	return pipelinetest.NewFakeBroker()
+}
@@ -0,0 +1,49 @@
+package e2e
+
+import (
+	"os"
+	"strconv"
+
+	"yeti/internal/config"
+	"yeti/pkg/models"
+	"yeti/pkg/schema"
+)
+
+// envelopeCodec is used by sendMessageToKafka/waitForProcessedMessage/
+// tryGetProcessedMessage to encode/decode MessageEnvelope payloads, set up
+// in TestMain from the same kind of schema_registry config block an
+// operator sets on filtering-service/enrichment-service - so this package
+// exercises whatever codec the pipeline under test is actually running,
+// instead of assuming every topic is plain JSON. Defaults to JSONCodec
+// (this package's behavior before codec support existed) unless
+// YETI_E2E_SCHEMA_REGISTRY_URL is set.
+var envelopeCodec models.Codec
+
+func newEnvelopeCodec() models.Codec {
+	url := os.Getenv("YETI_E2E_SCHEMA_REGISTRY_URL")
+	if url == "" {
+		return models.JSONCodec{}
+	}
+
+	cfg := config.SchemaRegistryConfig{
+		Enabled:      true,
+		URL:          url,
+		Codec:        os.Getenv("YETI_E2E_CODEC"),
+		Subject:      os.Getenv("YETI_E2E_SCHEMA_SUBJECT"),
+		FallbackJSON: true,
+	}
+	if v := os.Getenv("YETI_E2E_SCHEMA_VERSION"); v != "" {
+		if version, err := strconv.Atoi(v); err == nil {
+			cfg.Version = version
+		}
+	}
+
+	codec, err := schema.NewCodec(cfg)
+	if err != nil {
+		// A malformed env var shouldn't fail every test in the package -
+		// fall back to JSON, the same way FallbackJSON covers a decode
+		// failure once the codec is up and running.
+		return models.JSONCodec{}
+	}
+	return codec
+}
@@ -0,0 +1,23 @@
+package e2e
+
+import (
+	"os"
+	"testing"
+
+	"yeti/pkg/pipelinetest"
+)
+
+// broker is shared by every pipeline test in this package:
+// sendMessageToKafka, waitForProcessedMessage and tryGetProcessedMessage
+// all read/write through it instead of constructing a kafka.Writer/
+// kafka.Reader directly, so the same test bodies run against either a real
+// Kafka cluster (the default) or pipelinetest's in-process FakeBroker
+// (build with -tags fake). See newBroker in broker_real_test.go/
+// broker_fake_test.go.
+var broker pipelinetest.Broker
+
+func TestMain(m *testing.M) {
+	broker = newBroker()
+	envelopeCodec = newEnvelopeCodec()
+	os.Exit(m.Run())
+}
@@ -4,15 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/segmentio/kafka-go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"yeti/internal/management"
 	"yeti/pkg/models"
+	"yeti/pkg/pipelinetest"
 )
 
 const (
@@ -46,10 +47,10 @@ func TestPipelineEndToEnd(t *testing.T) {
 		Metadata: models.Metadata{},
 	}
 
-	err := sendMessageToKafka(t, inputTopic, testMessage)
+	err := sendMessageToKafka(t, broker, inputTopic, testMessage)
 	require.NoError(t, err, "failed to send message to input topic")
 
-	processedMessage := waitForProcessedMessage(t, testMessage.ID)
+	processedMessage := waitForProcessedMessage(t, broker, testMessage.ID)
 	require.NotNil(t, processedMessage, "message should be processed")
 
 	assert.Equal(t, testMessage.ID, processedMessage.ID)
@@ -86,10 +87,10 @@ func TestPipelineFiltering(t *testing.T) {
 		Metadata: models.Metadata{},
 	}
 
-	err := sendMessageToKafka(t, inputTopic, passingMessage)
+	err := sendMessageToKafka(t, broker, inputTopic, passingMessage)
 	require.NoError(t, err)
 
-	processedMessage := waitForProcessedMessage(t, passingMessage.ID)
+	processedMessage := waitForProcessedMessage(t, broker, passingMessage.ID)
 	require.NotNil(t, processedMessage, "message with value 100 should pass filter")
 
 	filteredMessage := models.MessageEnvelope{
@@ -102,11 +103,11 @@ func TestPipelineFiltering(t *testing.T) {
 		Metadata: models.Metadata{},
 	}
 
-	err = sendMessageToKafka(t, inputTopic, filteredMessage)
+	err = sendMessageToKafka(t, broker, inputTopic, filteredMessage)
 	require.NoError(t, err)
 
 	time.Sleep(3 * time.Second)
-	notProcessed := tryGetProcessedMessage(t, filteredMessage.ID)
+	notProcessed := tryGetProcessedMessage(t, broker, filteredMessage.ID)
 	assert.Nil(t, notProcessed, "message with value 30 should be filtered out")
 }
 
@@ -129,10 +130,10 @@ func TestPipelineDeduplication(t *testing.T) {
 		Metadata: models.Metadata{},
 	}
 
-	err := sendMessageToKafka(t, inputTopic, testMessage)
+	err := sendMessageToKafka(t, broker, inputTopic, testMessage)
 	require.NoError(t, err)
 
-	firstProcessed := waitForProcessedMessage(t, testMessage.ID)
+	firstProcessed := waitForProcessedMessage(t, broker, testMessage.ID)
 	require.NotNil(t, firstProcessed, "first message should be processed")
 	if firstProcessed.Metadata.Deduplication != nil {
 		assert.True(t, firstProcessed.Metadata.Deduplication.IsUnique, "first message should be unique")
@@ -150,12 +151,12 @@ func TestPipelineDeduplication(t *testing.T) {
 		Metadata: models.Metadata{},
 	}
 
-	err = sendMessageToKafka(t, inputTopic, duplicateMessage)
+	err = sendMessageToKafka(t, broker, inputTopic, duplicateMessage)
 	require.NoError(t, err)
 
 	time.Sleep(3 * time.Second)
 
-	duplicateProcessed := tryGetProcessedMessage(t, duplicateMessage.ID)
+	duplicateProcessed := tryGetProcessedMessage(t, broker, duplicateMessage.ID)
 	assert.Nil(t, duplicateProcessed, "duplicate message should be dropped and not appear in processed_events")
 }
 
@@ -193,16 +194,111 @@ func TestPipelineEnrichment(t *testing.T) {
 		Metadata: models.Metadata{},
 	}
 
-	err := sendMessageToKafka(t, inputTopic, testMessage)
+	err := sendMessageToKafka(t, broker, inputTopic, testMessage)
 	require.NoError(t, err)
 
-	processedMessage := waitForProcessedMessage(t, testMessage.ID)
+	processedMessage := waitForProcessedMessage(t, broker, testMessage.ID)
 	require.NotNil(t, processedMessage)
 
 	assert.NotNil(t, processedMessage.Metadata)
 	assert.NotEmpty(t, processedMessage.Metadata.Enrichment)
 }
 
+// TestPipelineEnrichmentDeadLetter is TestPipelineEnrichment's counterpart
+// for the "fail" ErrorHandling path: a rule pointed at an unreachable API
+// provider with ErrorHandling "fail" (rather than TestPipelineEnrichment's
+// "skip_field") makes every fetch error out, so the message should exhaust
+// the enrichment-service's Kafka retry policy and land on its DLQ topic
+// instead of processed_events. It then polls GET /api/v1/dead-letters until
+// the dead-lettered entry shows up, asserting Attempts matches the
+// configured retry policy's attempt count and the envelope's ErrorRecord(s)
+// (see pkg/models.ErrorRecord) carry a non-empty error class.
+func TestPipelineEnrichmentDeadLetter(t *testing.T) {
+	createReq := management.CreateEnrichmentRuleRequest{
+		Name:          "enrichment_dlq_test_rule",
+		FieldToEnrich: "enrichment_data",
+		SourceType:    "api",
+		SourceConfig: management.EnrichmentSourceConfig{
+			URL:    "http://127.0.0.1:1/unreachable",
+			Method: "GET",
+		},
+		Priority:      10,
+		Enabled:       boolPtr(true),
+		ErrorHandling: "fail",
+	}
+	ruleID := createEnrichmentRule(t, createReq)
+	defer deleteEnrichmentRule(t, ruleID)
+
+	filterReq := management.CreateFilteringRuleRequest{
+		Name:       "allow_all_for_enrichment_dlq",
+		Expression: "true",
+		Priority:   5,
+		Enabled:    boolPtr(true),
+	}
+	filterRuleID := createFilteringRule(t, filterReq)
+	defer deleteFilteringRule(t, filterRuleID)
+
+	time.Sleep(3 * time.Second)
+
+	testMessage := models.MessageEnvelope{
+		ID:        uuid.New().String(),
+		Source:    "enrichment_dlq_test",
+		Timestamp: time.Now(),
+		Payload: map[string]interface{}{
+			"status": "active",
+		},
+		Metadata: models.Metadata{},
+	}
+
+	err := sendMessageToKafka(t, broker, inputTopic, testMessage)
+	require.NoError(t, err)
+
+	notProcessed := tryGetProcessedMessage(t, broker, testMessage.ID)
+	assert.Nil(t, notProcessed, "message that fails enrichment with ErrorHandling \"fail\" should never reach processed_events")
+
+	entry := waitForDeadLetterEntry(t, testMessage.ID)
+	require.NotNil(t, entry, "message should be dead-lettered after exhausting the retry policy")
+
+	assert.Equal(t, testMessage.ID, entry.Envelope.ID)
+	assert.Greater(t, entry.Attempts, 0, "dead letter entry should record how many attempts were made")
+	assert.NotEmpty(t, entry.ErrorClass)
+	require.NotEmpty(t, entry.Envelope.Metadata.Errors, "the republished envelope should carry its failure history in Metadata.Errors")
+	lastErr := entry.Envelope.Metadata.Errors[len(entry.Envelope.Metadata.Errors)-1]
+	assert.Equal(t, entry.Attempts, lastErr.Attempts)
+}
+
+func waitForDeadLetterEntry(t *testing.T, messageID string) *management.DeadLetterEntry {
+	t.Helper()
+
+	deadline := time.Now().Add(messageWaitTimeout)
+	for time.Now().Before(deadline) {
+		entries := listDeadLetters(t)
+		for _, e := range entries {
+			if e.Envelope.ID == messageID {
+				return &e
+			}
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	return nil
+}
+
+func listDeadLetters(t *testing.T) []management.DeadLetterEntry {
+	t.Helper()
+
+	resp, err := http.Get(fmt.Sprintf("%s/api/v1/dead-letters", managementServiceURL))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var entries []management.DeadLetterEntry
+	err = json.NewDecoder(resp.Body).Decode(&entries)
+	require.NoError(t, err)
+
+	return entries
+}
+
 func TestPipelineMultipleMessages(t *testing.T) {
 	createReq := management.CreateFilteringRuleRequest{
 		Name:       "multi_msg_test_rule",
@@ -249,67 +345,56 @@ func TestPipelineMultipleMessages(t *testing.T) {
 	}
 
 	for _, msg := range messages {
-		err := sendMessageToKafka(t, inputTopic, msg)
+		err := sendMessageToKafka(t, broker, inputTopic, msg)
 		require.NoError(t, err)
 	}
 
-	msg1 := waitForProcessedMessage(t, messages[0].ID)
+	msg1 := waitForProcessedMessage(t, broker, messages[0].ID)
 	assert.NotNil(t, msg1, "first message should be processed")
 
-	msg2 := waitForProcessedMessage(t, messages[1].ID)
+	msg2 := waitForProcessedMessage(t, broker, messages[1].ID)
 	assert.NotNil(t, msg2, "second message should be processed")
 
 	time.Sleep(3 * time.Second)
-	msg3 := tryGetProcessedMessage(t, messages[2].ID)
+	msg3 := tryGetProcessedMessage(t, broker, messages[2].ID)
 	assert.Nil(t, msg3, "third message should be filtered out")
 }
 
-func sendMessageToKafka(t *testing.T, topic string, message models.MessageEnvelope) error {
+// sendMessageToKafka, waitForProcessedMessage and tryGetProcessedMessage
+// all go through a pipelinetest.Broker (the package-level broker, set up in
+// TestMain) instead of constructing a kafka.Writer/kafka.Reader directly,
+// so these same bodies run against either a real Kafka cluster or
+// pipelinetest.FakeBroker - see main_test.go and broker_real_test.go/
+// broker_fake_test.go.
+
+func sendMessageToKafka(t *testing.T, broker pipelinetest.Broker, topic string, message models.MessageEnvelope) error {
 	t.Helper()
 
-	writer := &kafka.Writer{
-		Addr:         kafka.TCP("localhost:29092"),
-		Topic:        topic,
-		Balancer:     &kafka.LeastBytes{},
-		WriteTimeout: 10 * time.Second,
-		RequiredAcks: kafka.RequireOne,
-	}
+	writer := broker.NewWriter(topic)
 	defer writer.Close()
 
-	body, err := json.Marshal(message)
+	body, err := envelopeCodec.Encode(message)
 	if err != nil {
-		return fmt.Errorf("failed to marshal message: %w", err)
+		return fmt.Errorf("failed to encode message: %w", err)
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	err = writer.WriteMessages(ctx,
-		kafka.Message{
-			Key:   []byte(message.ID),
-			Value: body,
-			Time:  time.Now(),
-		},
-	)
-	if err != nil {
+	if err := writer.WriteMessages(ctx, pipelinetest.Message{Key: []byte(message.ID), Value: body}); err != nil {
 		return fmt.Errorf("failed to write message: %w", err)
 	}
 
 	return nil
 }
 
-func waitForProcessedMessage(t *testing.T, messageID string) *models.MessageEnvelope {
+func waitForProcessedMessage(t *testing.T, broker pipelinetest.Broker, messageID string) *models.MessageEnvelope {
 	t.Helper()
 
-	reader := kafka.NewReader(kafka.ReaderConfig{
-		Brokers:        []string{kafkaBroker},
-		Topic:          processedTopic,
-		GroupID:        fmt.Sprintf("e2e-test-waiter-%s", uuid.New().String()),
-		StartOffset:    kafka.FirstOffset,
-		MinBytes:       1,
-		MaxBytes:       10e6,
-		CommitInterval: time.Second,
-		MaxWait:        2 * time.Second,
+	reader := broker.NewReader(pipelinetest.ReaderConfig{
+		Topic:       processedTopic,
+		GroupID:     fmt.Sprintf("e2e-test-waiter-%s", uuid.New().String()),
+		StartOffset: pipelinetest.FirstOffset,
 	})
 	defer reader.Close()
 
@@ -326,8 +411,8 @@ func waitForProcessedMessage(t *testing.T, messageID string) *models.MessageEnve
 			continue
 		}
 
-		var envelope models.MessageEnvelope
-		if err := json.Unmarshal(msg.Value, &envelope); err != nil {
+		envelope, err := envelopeCodec.Decode(msg.Value)
+		if err != nil {
 			_ = reader.CommitMessages(ctx, msg)
 			continue
 		}
@@ -340,18 +425,13 @@ func waitForProcessedMessage(t *testing.T, messageID string) *models.MessageEnve
 	}
 }
 
-func tryGetProcessedMessage(t *testing.T, messageID string) *models.MessageEnvelope {
+func tryGetProcessedMessage(t *testing.T, broker pipelinetest.Broker, messageID string) *models.MessageEnvelope {
 	t.Helper()
 
-	reader := kafka.NewReader(kafka.ReaderConfig{
-		Brokers:        []string{kafkaBroker},
-		Topic:          processedTopic,
-		GroupID:        fmt.Sprintf("e2e-test-reader-%s", uuid.New().String()),
-		StartOffset:    kafka.LastOffset,
-		MinBytes:       1,
-		MaxBytes:       10e6,
-		CommitInterval: time.Second,
-		MaxWait:        2 * time.Second,
+	reader := broker.NewReader(pipelinetest.ReaderConfig{
+		Topic:       processedTopic,
+		GroupID:     fmt.Sprintf("e2e-test-reader-%s", uuid.New().String()),
+		StartOffset: pipelinetest.LastOffset,
 	})
 	defer reader.Close()
 
@@ -368,8 +448,8 @@ func tryGetProcessedMessage(t *testing.T, messageID string) *models.MessageEnvel
 			continue
 		}
 
-		var envelope models.MessageEnvelope
-		if err := json.Unmarshal(msg.Value, &envelope); err != nil {
+		envelope, err := envelopeCodec.Decode(msg.Value)
+		if err != nil {
 			_ = reader.CommitMessages(ctx, msg)
 			continue
 		}
@@ -406,10 +486,10 @@ func TestPipelineWithRuleUpdate(t *testing.T) {
 		Metadata: models.Metadata{},
 	}
 
-	err := sendMessageToKafka(t, inputTopic, msg1)
+	err := sendMessageToKafka(t, broker, inputTopic, msg1)
 	require.NoError(t, err)
 
-	processed1 := waitForProcessedMessage(t, msg1.ID)
+	processed1 := waitForProcessedMessage(t, broker, msg1.ID)
 	require.NotNil(t, processed1, "message should pass with initial rule")
 
 	updateReq := management.UpdateFilteringRuleRequest{
@@ -430,9 +510,9 @@ func TestPipelineWithRuleUpdate(t *testing.T) {
 		Metadata: models.Metadata{},
 	}
 
-	err = sendMessageToKafka(t, inputTopic, msg2)
+	err = sendMessageToKafka(t, broker, inputTopic, msg2)
 	require.NoError(t, err)
 
-	processed2 := waitForProcessedMessage(t, msg2.ID)
+	processed2 := waitForProcessedMessage(t, broker, msg2.ID)
 	assert.Nil(t, processed2, "Message with status 'active' should be filtered out after rule update to filter 'inactive' (hot reload should work)")
 }
@@ -0,0 +1,49 @@
+package integration
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"yeti/internal/management"
+)
+
+// TestAuthMiddleware_EnabledRejectsMissingAPIKey guards against
+// AuthMiddleware's "no X-API-Key" fallback applying regardless of
+// Management.Auth.Enabled: with enabled=true, a request with no header must
+// be rejected rather than silently scoped to DefaultTenantID with every
+// scope.
+func TestAuthMiddleware_EnabledRejectsMissingAPIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(management.AuthMiddleware(nil, true))
+	router.GET("/probe", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/probe", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+// TestAuthMiddleware_DisabledAllowsMissingAPIKey documents the complementary
+// default-mode behavior: with enabled=false, a missing header still falls
+// back to DefaultTenantID with every scope, so existing single-tenant
+// deployments that haven't provisioned keys keep working unauthenticated.
+func TestAuthMiddleware_DisabledAllowsMissingAPIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(management.AuthMiddleware(nil, false))
+	router.GET("/probe", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/probe", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,88 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"yeti/internal/deduplication"
+	"yeti/internal/testutil"
+)
+
+func TestDeduplicationLock_AcquireAndUnlock(t *testing.T) {
+	infra := SetupTestInfra(t)
+	ctx := context.Background()
+
+	locked, err := deduplication.Lock(ctx, infra.RedisClient, "test:lock:resource1", 5*time.Second)
+	require.NoError(t, err)
+	require.NotNil(t, locked)
+
+	require.NoError(t, locked.Unlock(ctx))
+
+	// Released, so a second Lock call for the same resource succeeds.
+	locked2, err := deduplication.Lock(ctx, infra.RedisClient, "test:lock:resource1", 5*time.Second)
+	require.NoError(t, err)
+	require.NoError(t, locked2.Unlock(ctx))
+}
+
+func TestDeduplicationLock_SecondCallerIsRejected(t *testing.T) {
+	infra := SetupTestInfra(t)
+	ctx := context.Background()
+
+	locked, err := deduplication.Lock(ctx, infra.RedisClient, "test:lock:resource2", 5*time.Second)
+	require.NoError(t, err)
+	defer locked.Unlock(ctx)
+
+	_, err = deduplication.Lock(ctx, infra.RedisClient, "test:lock:resource2", 5*time.Second)
+	assert.ErrorIs(t, err, deduplication.ErrLockHeld)
+}
+
+func TestDeduplicationLock_FenceTokenIncreasesAcrossAcquisitions(t *testing.T) {
+	infra := SetupTestInfra(t)
+	ctx := context.Background()
+
+	first, err := deduplication.Lock(ctx, infra.RedisClient, "test:lock:resource3", 5*time.Second)
+	require.NoError(t, err)
+	require.NoError(t, first.Unlock(ctx))
+
+	second, err := deduplication.Lock(ctx, infra.RedisClient, "test:lock:resource3", 5*time.Second)
+	require.NoError(t, err)
+	defer second.Unlock(ctx)
+
+	assert.Greater(t, second.Fence, first.Fence)
+}
+
+func TestDeduplicationLock_AutoRefreshExtendsLeasePastOriginalTTL(t *testing.T) {
+	infra := SetupTestInfra(t)
+	ctx := context.Background()
+
+	ttl := testutil.WaitShort
+	locked, err := deduplication.Lock(ctx, infra.RedisClient, "test:lock:resource4", ttl)
+	require.NoError(t, err)
+	defer locked.Unlock(ctx)
+
+	// Wait past the original TTL; the auto-refresh goroutine should have
+	// re-PEXPIREd it around ttl/2, so a competing Lock call still fails.
+	time.Sleep(ttl + ttl/2)
+
+	_, err = deduplication.Lock(ctx, infra.RedisClient, "test:lock:resource4", ttl)
+	assert.ErrorIs(t, err, deduplication.ErrLockHeld)
+}
+
+func TestDeduplicationLock_RefreshReportsLostLease(t *testing.T) {
+	infra := SetupTestInfra(t)
+	ctx := context.Background()
+
+	locked, err := deduplication.Lock(ctx, infra.RedisClient, "test:lock:resource5", 5*time.Second)
+	require.NoError(t, err)
+	defer locked.Unlock(ctx)
+
+	// Simulate another holder winning the key out from under this handle.
+	require.NoError(t, infra.RedisClient.Set(ctx, "lock:test:lock:resource5", "someone-else", 5*time.Second).Err())
+
+	held, err := locked.Refresh(ctx)
+	require.NoError(t, err)
+	assert.False(t, held)
+}
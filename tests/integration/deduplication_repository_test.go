@@ -2,12 +2,16 @@ package integration
 
 import (
 	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"yeti/internal/deduplication"
+	"yeti/internal/testutil"
 )
 
 func TestDeduplicationRepository_SetNX(t *testing.T) {
@@ -44,7 +48,7 @@ func TestDeduplicationRepository_SetNX_TTL(t *testing.T) {
 	assert.True(t, success)
 
 	// Wait for TTL to expire
-	time.Sleep(2 * time.Second)
+	time.Sleep(testutil.WaitLong)
 
 	// Should be able to set again after TTL expires
 	success, err = repo.SetNX(ctx, key, value+1, ttl)
@@ -85,3 +89,90 @@ func TestDeduplicationRepository_SetNX_ContextCancellation(t *testing.T) {
 	_, err := repo.SetNX(ctx, key, value, ttl)
 	require.Error(t, err)
 }
+
+func TestDeduplicationRepository_Remember_CachesFirstResult(t *testing.T) {
+	infra := SetupTestInfra(t)
+
+	ctx := context.Background()
+	repo := deduplication.NewRepository(infra.RedisClient)
+
+	var calls int
+	fn := func() (deduplication.IdempotentResult, error) {
+		calls++
+		return deduplication.IdempotentResult{Status: 201, Body: []byte(`{"ok":true}`)}, nil
+	}
+
+	result, replayed, err := repo.Remember(ctx, "test:idem:key1", 5*time.Second, time.Second, fn)
+	require.NoError(t, err)
+	assert.False(t, replayed)
+	assert.Equal(t, 201, result.Status)
+	assert.Equal(t, 1, calls)
+
+	result, replayed, err = repo.Remember(ctx, "test:idem:key1", 5*time.Second, time.Second, fn)
+	require.NoError(t, err)
+	assert.True(t, replayed)
+	assert.Equal(t, 201, result.Status)
+	assert.Equal(t, 1, calls, "fn must not run again for a key already cached")
+}
+
+func TestDeduplicationRepository_Remember_ConcurrentCallersShareOneRun(t *testing.T) {
+	infra := SetupTestInfra(t)
+
+	ctx := context.Background()
+	repo := deduplication.NewRepository(infra.RedisClient)
+
+	var calls int32
+	fn := func() (deduplication.IdempotentResult, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(testutil.WaitShort)
+		return deduplication.IdempotentResult{Status: 200}, nil
+	}
+
+	const concurrency = 5
+	var wg sync.WaitGroup
+	replayedCount := make([]bool, concurrency)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, replayed, err := repo.Remember(ctx, "test:idem:key2", 5*time.Second, 5*time.Second, fn)
+			assert.NoError(t, err)
+			replayedCount[i] = replayed
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "only one concurrent caller should run fn")
+	ran := 0
+	for _, replayed := range replayedCount {
+		if !replayed {
+			ran++
+		}
+	}
+	assert.Equal(t, 1, ran, "exactly one caller should have actually run fn")
+}
+
+func TestDeduplicationRepository_Remember_ErrorIsNotCached(t *testing.T) {
+	infra := SetupTestInfra(t)
+
+	ctx := context.Background()
+	repo := deduplication.NewRepository(infra.RedisClient)
+
+	var calls int
+	fn := func() (deduplication.IdempotentResult, error) {
+		calls++
+		if calls == 1 {
+			return deduplication.IdempotentResult{}, fmt.Errorf("boom")
+		}
+		return deduplication.IdempotentResult{Status: 200}, nil
+	}
+
+	_, _, err := repo.Remember(ctx, "test:idem:key3", 5*time.Second, time.Second, fn)
+	require.Error(t, err)
+
+	result, replayed, err := repo.Remember(ctx, "test:idem:key3", 5*time.Second, time.Second, fn)
+	require.NoError(t, err)
+	assert.False(t, replayed)
+	assert.Equal(t, 200, result.Status)
+	assert.Equal(t, 2, calls, "a failed attempt must not block a later retry")
+}
@@ -7,7 +7,9 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"yeti/internal/config"
 	"yeti/internal/deduplication"
+	"yeti/internal/testutil"
 )
 
 func TestDeduplicationService_Process_Unique(t *testing.T) {
@@ -19,7 +21,8 @@ func TestDeduplicationService_Process_Unique(t *testing.T) {
 
 	repo := deduplication.NewRepository(infra.RedisClient)
 	cfg := createTestDeduplicationConfig()
-	svc := deduplication.NewService(repo, cfg, log)
+	svc, err := deduplication.NewService(repo, cfg, log)
+	require.NoError(t, err)
 
 	msg := createTestMessage("msg-1", "test", map[string]interface{}{"data": "value"})
 
@@ -37,7 +40,8 @@ func TestDeduplicationService_Process_Duplicate(t *testing.T) {
 
 	repo := deduplication.NewRepository(infra.RedisClient)
 	cfg := createTestDeduplicationConfig()
-	svc := deduplication.NewService(repo, cfg, log)
+	svc, err := deduplication.NewService(repo, cfg, log)
+	require.NoError(t, err)
 
 	msg := createTestMessage("msg-1", "test", map[string]interface{}{"data": "value"})
 
@@ -59,7 +63,8 @@ func TestDeduplicationService_Process_DifferentMessages(t *testing.T) {
 
 	repo := deduplication.NewRepository(infra.RedisClient)
 	cfg := createTestDeduplicationConfig()
-	svc := deduplication.NewService(repo, cfg, log)
+	svc, err := deduplication.NewService(repo, cfg, log)
+	require.NoError(t, err)
 
 	msg1 := createTestMessage("msg-1", "test", map[string]interface{}{"data": "value1"})
 	msg2 := createTestMessage("msg-2", "test", map[string]interface{}{"data": "value2"})
@@ -82,7 +87,8 @@ func TestDeduplicationService_Process_CustomFields(t *testing.T) {
 
 	repo := deduplication.NewRepository(infra.RedisClient)
 	cfg := createTestDeduplicationConfigWithFields([]string{"payload.user_id", "payload.order_id"})
-	svc := deduplication.NewService(repo, cfg, log)
+	svc, err := deduplication.NewService(repo, cfg, log)
+	require.NoError(t, err)
 
 	msg := createTestMessage("msg-1", "test", map[string]interface{}{
 		"user_id":  "user-123",
@@ -106,9 +112,10 @@ func TestDeduplicationService_UpdateFieldsToHash(t *testing.T) {
 
 	repo := deduplication.NewRepository(infra.RedisClient)
 	cfg := createTestDeduplicationConfig()
-	svc := deduplication.NewService(repo, cfg, log)
+	svc, err := deduplication.NewService(repo, cfg, log)
+	require.NoError(t, err)
 
-	err := svc.UpdateFieldsToHash([]string{"payload.field1", "payload.field2"})
+	err = svc.UpdateFieldsToHash([]string{"payload.field1", "payload.field2"})
 	require.NoError(t, err)
 
 	fields := svc.GetFieldsToHash()
@@ -130,7 +137,8 @@ func TestDeduplicationService_Process_FallbackAllow_OnRedisError(t *testing.T) {
 	repo := deduplication.NewRepository(infra.RedisClient)
 	cfg := createTestDeduplicationConfig()
 	cfg.OnRedisError = "allow" // Set fallback to allow
-	svc := deduplication.NewService(repo, cfg, log)
+	svc, err := deduplication.NewService(repo, cfg, log)
+	require.NoError(t, err)
 
 	msg := createTestMessage("msg-1", "test", map[string]interface{}{"data": "value"})
 
@@ -155,7 +163,8 @@ func TestDeduplicationService_Process_FallbackDeny_OnRedisError(t *testing.T) {
 	repo := deduplication.NewRepository(infra.RedisClient)
 	cfg := createTestDeduplicationConfig()
 	cfg.OnRedisError = "deny" // Set fallback to deny
-	svc := deduplication.NewService(repo, cfg, log)
+	svc, err := deduplication.NewService(repo, cfg, log)
+	require.NoError(t, err)
 
 	msg := createTestMessage("msg-1", "test", map[string]interface{}{"data": "value"})
 
@@ -177,7 +186,8 @@ func TestDeduplicationService_Process_SHA256Hash(t *testing.T) {
 	repo := deduplication.NewRepository(infra.RedisClient)
 	cfg := createTestDeduplicationConfig()
 	cfg.HashAlgorithm = "sha256" // Use SHA256 instead of default MD5
-	svc := deduplication.NewService(repo, cfg, log)
+	svc, err := deduplication.NewService(repo, cfg, log)
+	require.NoError(t, err)
 
 	msg := createTestMessage("msg-1", "test", map[string]interface{}{"data": "value"})
 
@@ -208,10 +218,11 @@ func TestDeduplicationService_UpdateFieldsToHash_EmptyList(t *testing.T) {
 
 	repo := deduplication.NewRepository(infra.RedisClient)
 	cfg := createTestDeduplicationConfig()
-	svc := deduplication.NewService(repo, cfg, log)
+	svc, err := deduplication.NewService(repo, cfg, log)
+	require.NoError(t, err)
 
 	// Trying to set empty fields list should return an error
-	err := svc.UpdateFieldsToHash([]string{})
+	err = svc.UpdateFieldsToHash([]string{})
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "fields list cannot be empty")
 }
@@ -225,14 +236,12 @@ func TestDeduplicationService_Process_ContextTimeout(t *testing.T) {
 
 	repo := deduplication.NewRepository(infra.RedisClient)
 	cfg := createTestDeduplicationConfig()
-	svc := deduplication.NewService(repo, cfg, log)
+	svc, err := deduplication.NewService(repo, cfg, log)
+	require.NoError(t, err)
 
-	// Create a context with a very short timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Nanosecond)
+	// Create a context with a deadline that's already passed
+	ctx, cancel := testutil.AlreadyExpiredContext()
 	defer cancel()
-	
-	// Wait a bit to ensure timeout
-	time.Sleep(10 * time.Millisecond)
 
 	msg := createTestMessage("msg-1", "test", map[string]interface{}{"data": "value"})
 
@@ -252,7 +261,8 @@ func TestDeduplicationService_Process_ContextCancellation(t *testing.T) {
 
 	repo := deduplication.NewRepository(infra.RedisClient)
 	cfg := createTestDeduplicationConfig()
-	svc := deduplication.NewService(repo, cfg, log)
+	svc, err := deduplication.NewService(repo, cfg, log)
+	require.NoError(t, err)
 
 	// Create a context and cancel it immediately
 	ctx, cancel := context.WithCancel(context.Background())
@@ -266,3 +276,123 @@ func TestDeduplicationService_Process_ContextCancellation(t *testing.T) {
 	assert.Contains(t, err.Error(), "context canceled")
 	assert.False(t, isUnique)
 }
+
+// TestDeduplicationService_L1Cache_InvalidatedAcrossInstances verifies that
+// two Service instances sharing the same Redis see a PurgeCache call on one
+// instance reflected in the other's L1 cache via the dedup:invalidate
+// Pub/Sub channel, rather than requiring the second instance's L1 TTL to
+// expire on its own.
+func TestDeduplicationService_L1Cache_InvalidatedAcrossInstances(t *testing.T) {
+	infra := SetupTestInfraWithOptions(t, false, false, true)
+
+	log := createTestLogger()
+
+	cfg := createTestDeduplicationConfig()
+	cfg.L1Cache = config.DedupL1CacheConfig{Size: 100, TTLSeconds: 300}
+
+	repoA := deduplication.NewRepository(infra.RedisClient)
+	svcA, err := deduplication.NewService(repoA, cfg, log)
+	require.NoError(t, err)
+	defer svcA.StopCacheMetricsUpdater()
+
+	repoB := deduplication.NewRepository(infra.RedisClient)
+	svcB, err := deduplication.NewService(repoB, cfg, log)
+	require.NoError(t, err)
+	defer svcB.StopCacheMetricsUpdater()
+
+	msg := createTestMessage("msg-1", "test", map[string]interface{}{"data": "value"})
+
+	isUnique, err := svcA.Process(context.Background(), msg)
+	require.NoError(t, err)
+	assert.True(t, isUnique)
+
+	// svcB's L1 cache is now primed via its own Redis SetNX check.
+	isUnique, err = svcB.Process(context.Background(), msg)
+	require.NoError(t, err)
+	assert.False(t, isUnique)
+
+	// Purging svcA's cache broadcasts over dedup:invalidate; give the
+	// subscription goroutine a moment to apply it to svcB's L1.
+	svcA.PurgeCache(context.Background())
+	time.Sleep(testutil.WaitMedium)
+
+	// The Redis key is still set, so svcB still reports a duplicate, but it
+	// now has to go back to Redis to learn that instead of serving a stale
+	// svcB-local L1 hit for a key that was never written through svcB.
+	isUnique, err = svcB.Process(context.Background(), msg)
+	require.NoError(t, err)
+	assert.False(t, isUnique)
+}
+
+// TestDeduplicationService_Process_PolicyOverridesFields verifies that a
+// matching Policies entry's Fields, not the top-level FieldsToHash, decides
+// what a message hashes on.
+func TestDeduplicationService_Process_PolicyOverridesFields(t *testing.T) {
+	infra := SetupTestInfraWithOptions(t, false, false, true)
+
+	ctx := context.Background()
+	log := createTestLogger()
+
+	repo := deduplication.NewRepository(infra.RedisClient)
+	cfg := createTestDeduplicationConfig()
+	cfg.Policies = []config.DedupPolicyConfig{
+		{Match: `source == "stripe"`, Fields: []string{"payload.event_id"}},
+	}
+	svc, err := deduplication.NewService(repo, cfg, log)
+	require.NoError(t, err)
+
+	// Matches the policy: dedup key comes from payload.event_id alone, so
+	// two messages with different IDs but the same event_id collide.
+	msg1 := createTestMessage("msg-1", "stripe", map[string]interface{}{"event_id": "evt-1"})
+	msg2 := createTestMessage("msg-2", "stripe", map[string]interface{}{"event_id": "evt-1"})
+
+	isUnique, err := svc.Process(ctx, msg1)
+	require.NoError(t, err)
+	assert.True(t, isUnique)
+
+	isUnique, err = svc.Process(ctx, msg2)
+	require.NoError(t, err)
+	assert.False(t, isUnique)
+
+	// Doesn't match the policy: falls back to the top-level id+source
+	// fields, so a distinct ID is unique even with the same event_id.
+	msg3 := createTestMessage("msg-3", "other", map[string]interface{}{"event_id": "evt-1"})
+	isUnique, err = svc.Process(ctx, msg3)
+	require.NoError(t, err)
+	assert.True(t, isUnique)
+}
+
+// TestDeduplicationService_Process_PolicyOverridesTTL verifies that a
+// matching Policies entry's TTLSeconds, not the top-level TTLSeconds,
+// bounds the Redis key Process writes.
+func TestDeduplicationService_Process_PolicyOverridesTTL(t *testing.T) {
+	infra := SetupTestInfraWithOptions(t, false, false, true)
+
+	ctx := context.Background()
+	log := createTestLogger()
+
+	repo := deduplication.NewRepository(infra.RedisClient)
+	cfg := createTestDeduplicationConfig()
+	cfg.Policies = []config.DedupPolicyConfig{
+		{Match: `source == "batch"`, TTLSeconds: 1},
+	}
+	svc, err := deduplication.NewService(repo, cfg, log)
+	require.NoError(t, err)
+
+	msg := createTestMessage("msg-1", "batch", map[string]interface{}{"data": "value"})
+
+	isUnique, err := svc.Process(ctx, msg)
+	require.NoError(t, err)
+	assert.True(t, isUnique)
+
+	isUnique, err = svc.Process(ctx, msg)
+	require.NoError(t, err)
+	assert.False(t, isUnique)
+
+	// The policy's 1s TTL, not the 300s top-level default, is what expires
+	// the key.
+	time.Sleep(testutil.WaitLong)
+	isUnique, err = svc.Process(ctx, msg)
+	require.NoError(t, err)
+	assert.True(t, isUnique)
+}
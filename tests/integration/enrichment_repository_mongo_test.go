@@ -0,0 +1,79 @@
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+
+	"yeti/internal/management"
+	"yeti/internal/testutil"
+)
+
+func TestEnrichmentRepositorySuite_MongoDB(t *testing.T) {
+	infra := SetupTestInfraWithOptions(t, false, true, false)
+
+	repo := management.NewEnrichmentRepository(infra.MongoDB)
+	runEnrichmentRepositoryTestSuite(t, repo)
+}
+
+// TestEnrichmentRepository_MongoDB_WriteConcern asserts that Acknowledged on
+// InsertResult/UpdateResult/DeleteResult actually reflects the WriteConcern
+// WithWriteConcern configured the repository with, not just a hardcoded
+// true - both the default acknowledged path and an explicit unacknowledged
+// one.
+func TestEnrichmentRepository_MongoDB_WriteConcern(t *testing.T) {
+	infra := SetupTestInfraWithOptions(t, false, true, false)
+	ctx := context.Background()
+
+	t.Run("AcknowledgedByDefault", func(t *testing.T) {
+		repo := management.NewEnrichmentRepository(infra.MongoDB)
+		rule := newTestEnrichmentRule("write_concern_ack_default")
+
+		insertResult, err := repo.CreateEnrichmentRule(ctx, rule)
+		require.NoError(t, err)
+		assert.True(t, insertResult.Acknowledged)
+
+		rule.Priority = 3
+		updateResult, err := repo.UpdateEnrichmentRule(ctx, rule)
+		require.NoError(t, err)
+		assert.True(t, updateResult.Acknowledged)
+		assert.Equal(t, int64(1), updateResult.MatchedCount)
+
+		deleteResult, err := repo.DeleteEnrichmentRule(ctx, rule.ID)
+		require.NoError(t, err)
+		assert.True(t, deleteResult.Acknowledged)
+	})
+
+	t.Run("Unacknowledged", func(t *testing.T) {
+		repo := management.NewEnrichmentRepository(infra.MongoDB, management.WithWriteConcern(writeconcern.Unacknowledged()))
+		rule := newTestEnrichmentRule("write_concern_unack")
+
+		insertResult, err := repo.CreateEnrichmentRule(ctx, rule)
+		require.NoError(t, err)
+		assert.False(t, insertResult.Acknowledged)
+
+		// The server isn't waited on, but the write still lands - a
+		// separately constructed, acknowledged repository against the same
+		// database can read it straight back.
+		verifyRepo := management.NewEnrichmentRepository(infra.MongoDB)
+		require.Eventually(t, func() bool {
+			fetched, err := verifyRepo.GetEnrichmentRule(ctx, rule.ID)
+			return err == nil && fetched != nil
+		}, testutil.WaitLong, testutil.WaitShort, "unacknowledged create should still become visible")
+
+		rule.Priority = 3
+		updateResult, err := repo.UpdateEnrichmentRule(ctx, rule)
+		require.NoError(t, err)
+		assert.False(t, updateResult.Acknowledged)
+		// MatchedCount/ModifiedCount can't be trusted under an
+		// unacknowledged WriteConcern - the server never reports them.
+		assert.Equal(t, int64(0), updateResult.MatchedCount)
+
+		deleteResult, err := repo.DeleteEnrichmentRule(ctx, rule.ID)
+		require.NoError(t, err)
+		assert.False(t, deleteResult.Acknowledged)
+	})
+}
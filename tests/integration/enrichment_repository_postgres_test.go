@@ -0,0 +1,17 @@
+package integration
+
+import (
+	"testing"
+
+	"yeti/internal/management"
+)
+
+func TestEnrichmentRepositorySuite_Postgres(t *testing.T) {
+	infra := SetupTestInfraWithOptions(t, true, false, false)
+
+	repo, err := management.NewEnrichmentRepositoryFromConfig("postgres", nil, infra.PostgresDB, nil)
+	if err != nil {
+		t.Fatalf("failed to build postgres enrichment repository: %v", err)
+	}
+	runEnrichmentRepositoryTestSuite(t, repo)
+}
@@ -0,0 +1,39 @@
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"yeti/internal/management"
+	"yeti/pkg/migrations"
+)
+
+func TestEnrichmentRepositorySuite_SQLite(t *testing.T) {
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "enrichment.db"))
+	if err != nil {
+		t.Fatalf("failed to open sqlite database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	workDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get work dir: %v", err)
+	}
+	migrationsDir := filepath.Join(workDir, "..", "..", "migrations", "sqlite")
+
+	if err := migrations.RunSQLMigrations(context.Background(), db, "sqlite3", migrationsDir); err != nil {
+		t.Fatalf("failed to run sqlite migrations: %v", err)
+	}
+
+	repo, err := management.NewEnrichmentRepositoryFromConfig("sqlite", nil, nil, db)
+	if err != nil {
+		t.Fatalf("failed to build sqlite enrichment repository: %v", err)
+	}
+	runEnrichmentRepositoryTestSuite(t, repo)
+}
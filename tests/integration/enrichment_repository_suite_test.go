@@ -0,0 +1,210 @@
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"yeti/internal/management"
+	"yeti/pkg/errors"
+)
+
+// runEnrichmentRepositoryTestSuite exercises a management.EnrichmentRepository
+// the same way regardless of which driver built it, so MongoDB, PostgreSQL,
+// and SQLite stay behaviorally identical as required by that interface's
+// contract (see management.NewEnrichmentRepositoryFromConfig). Each driver's
+// test wraps this in its own *testing.T with its own backing store, rather
+// than this taking a driver name, so a failure reports against the right
+// driver's subtests directly instead of through an extra layer of t.Run.
+func runEnrichmentRepositoryTestSuite(t *testing.T, repo management.EnrichmentRepository) {
+	t.Helper()
+	ctx := context.Background()
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		rule := newTestEnrichmentRule("create_and_get")
+		insertResult, err := repo.CreateEnrichmentRule(ctx, rule)
+		require.NoError(t, err)
+		assert.True(t, insertResult.Acknowledged)
+		assert.Equal(t, rule.ID, insertResult.InsertedID)
+		assert.NotEmpty(t, rule.ID)
+		assert.Equal(t, 1, rule.Version)
+
+		fetched, err := repo.GetEnrichmentRule(ctx, rule.ID)
+		require.NoError(t, err)
+		require.NotNil(t, fetched)
+		assert.Equal(t, rule.Name, fetched.Name)
+		assert.Equal(t, rule.FieldToEnrich, fetched.FieldToEnrich)
+		assert.Equal(t, rule.SourceConfig.URL, fetched.SourceConfig.URL)
+	})
+
+	t.Run("GetNotFound", func(t *testing.T) {
+		rule, err := repo.GetEnrichmentRule(ctx, "does-not-exist")
+		require.NoError(t, err)
+		assert.Nil(t, rule)
+	})
+
+	t.Run("List_OrderedByPriorityThenCreatedAt", func(t *testing.T) {
+		low := newTestEnrichmentRule("list_low_priority")
+		low.Priority = 1
+		high := newTestEnrichmentRule("list_high_priority")
+		high.Priority = 10
+		_, err := repo.CreateEnrichmentRule(ctx, low)
+		require.NoError(t, err)
+		_, err = repo.CreateEnrichmentRule(ctx, high)
+		require.NoError(t, err)
+
+		rules, err := repo.ListEnrichmentRules(ctx)
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, len(rules), 2)
+		assert.Equal(t, "list_high_priority", rules[0].Name)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		rule := newTestEnrichmentRule("update_rule")
+		_, err := repo.CreateEnrichmentRule(ctx, rule)
+		require.NoError(t, err)
+
+		rule.Priority = 42
+		updateResult, err := repo.UpdateEnrichmentRule(ctx, rule)
+		require.NoError(t, err)
+		assert.True(t, updateResult.Acknowledged)
+		assert.Equal(t, int64(1), updateResult.MatchedCount)
+		assert.Equal(t, 2, rule.Version)
+
+		fetched, err := repo.GetEnrichmentRule(ctx, rule.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 42, fetched.Priority)
+		assert.Equal(t, 2, fetched.Version)
+	})
+
+	t.Run("UpdateCAS_RejectsStaleVersion", func(t *testing.T) {
+		rule := newTestEnrichmentRule("cas_rule")
+		_, err := repo.CreateEnrichmentRule(ctx, rule)
+		require.NoError(t, err)
+
+		rule.Priority = 7
+		_, err = repo.UpdateEnrichmentRuleCAS(ctx, rule, rule.Version+1)
+		require.Error(t, err)
+		assert.True(t, errors.IsConflict(err))
+	})
+
+	t.Run("UpdateCAS_AcceptsCurrentVersion", func(t *testing.T) {
+		rule := newTestEnrichmentRule("cas_rule_current")
+		_, err := repo.CreateEnrichmentRule(ctx, rule)
+		require.NoError(t, err)
+
+		rule.Priority = 7
+		updateResult, err := repo.UpdateEnrichmentRuleCAS(ctx, rule, 1)
+		require.NoError(t, err)
+		assert.True(t, updateResult.Acknowledged)
+		assert.Equal(t, 2, rule.Version)
+	})
+
+	t.Run("UpdateCAS_NotFoundWhenRuleMissing", func(t *testing.T) {
+		rule := newTestEnrichmentRule("cas_missing_rule")
+		rule.ID = "does-not-exist"
+
+		_, err := repo.UpdateEnrichmentRuleCAS(ctx, rule, 1)
+		require.Error(t, err)
+		assert.False(t, errors.IsConflict(err))
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		rule := newTestEnrichmentRule("delete_rule")
+		_, err := repo.CreateEnrichmentRule(ctx, rule)
+		require.NoError(t, err)
+
+		deleteResult, err := repo.DeleteEnrichmentRule(ctx, rule.ID)
+		require.NoError(t, err)
+		assert.True(t, deleteResult.Acknowledged)
+		assert.Equal(t, int64(1), deleteResult.DeletedCount)
+
+		fetched, err := repo.GetEnrichmentRule(ctx, rule.ID)
+		require.NoError(t, err)
+		assert.Nil(t, fetched)
+	})
+
+	t.Run("GetEnrichmentRuleHistory_RecordsCreateUpdateDelete", func(t *testing.T) {
+		rule := newTestEnrichmentRule("history_rule")
+		_, err := repo.CreateEnrichmentRule(ctx, rule)
+		require.NoError(t, err)
+
+		rule.Priority = 5
+		_, err = repo.UpdateEnrichmentRule(ctx, rule)
+		require.NoError(t, err)
+
+		_, err = repo.DeleteEnrichmentRule(ctx, rule.ID)
+		require.NoError(t, err)
+
+		history, err := repo.GetEnrichmentRuleHistory(ctx, rule.ID)
+		require.NoError(t, err)
+		require.Len(t, history, 3)
+
+		// Newest first: delete, update, create.
+		assert.Equal(t, "delete", history[0].Action)
+		assert.Nil(t, history[0].After)
+		assert.Equal(t, "update", history[1].Action)
+		assert.Equal(t, 5, history[1].After.Priority)
+		assert.Equal(t, "create", history[2].Action)
+		assert.Nil(t, history[2].Before)
+	})
+
+	t.Run("RollbackEnrichmentRule_RestoresPriorFieldValues", func(t *testing.T) {
+		rule := newTestEnrichmentRule("rollback_rule")
+		_, err := repo.CreateEnrichmentRule(ctx, rule)
+		require.NoError(t, err)
+		original := *rule
+
+		rule.Priority = 99
+		_, err = repo.UpdateEnrichmentRule(ctx, rule)
+		require.NoError(t, err)
+
+		restored, err := repo.RollbackEnrichmentRule(ctx, rule.ID, &original, 1)
+		require.NoError(t, err)
+		assert.Equal(t, original.Priority, restored.Priority)
+		assert.Equal(t, 3, restored.Version)
+
+		fetched, err := repo.GetEnrichmentRule(ctx, rule.ID)
+		require.NoError(t, err)
+		assert.Equal(t, original.Priority, fetched.Priority)
+
+		history, err := repo.GetEnrichmentRuleHistory(ctx, rule.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "rollback", history[0].Action)
+		assert.Equal(t, 1, history[0].FromVersion)
+	})
+
+	t.Run("RollbackEnrichmentRule_RecreatesDeletedRuleWithSameID", func(t *testing.T) {
+		rule := newTestEnrichmentRule("rollback_deleted_rule")
+		_, err := repo.CreateEnrichmentRule(ctx, rule)
+		require.NoError(t, err)
+		original := *rule
+
+		_, err = repo.DeleteEnrichmentRule(ctx, rule.ID)
+		require.NoError(t, err)
+
+		restored, err := repo.RollbackEnrichmentRule(ctx, rule.ID, &original, 1)
+		require.NoError(t, err)
+		assert.Equal(t, rule.ID, restored.ID)
+		assert.Equal(t, 1, restored.Version)
+
+		fetched, err := repo.GetEnrichmentRule(ctx, rule.ID)
+		require.NoError(t, err)
+		require.NotNil(t, fetched)
+		assert.Equal(t, original.Name, fetched.Name)
+	})
+}
+
+func newTestEnrichmentRule(name string) *management.EnrichmentRule {
+	return &management.EnrichmentRule{
+		Name:          name,
+		FieldToEnrich: "user_id",
+		SourceType:    "api",
+		SourceConfig:  management.EnrichmentSourceConfig{URL: "http://api.example.com/user"},
+		ErrorHandling: "fail",
+		Priority:      0,
+		Enabled:       true,
+	}
+}
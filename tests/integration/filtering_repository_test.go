@@ -50,6 +50,32 @@ func TestFilteringRepository_GetActiveRules_Empty(t *testing.T) {
 	assert.Empty(t, activeRules)
 }
 
+func TestFilteringRepository_GetRuleByID(t *testing.T) {
+	infra := SetupTestInfra(t)
+
+	ctx := context.Background()
+
+	mgmtRepo := management.NewRepository(infra.PostgresDB)
+	rule := createTestFilteringRule("by-id", "payload.status == 'active'", 10, true)
+	require.NoError(t, mgmtRepo.CreateFilteringRule(ctx, rule))
+
+	filteringRepo := filtering.NewRepository(infra.PostgresDB)
+	got, err := filteringRepo.GetRuleByID(ctx, rule.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "by-id", got.Name)
+	assert.True(t, got.Enabled)
+}
+
+func TestFilteringRepository_GetRuleByID_NotFound(t *testing.T) {
+	infra := SetupTestInfra(t)
+
+	ctx := context.Background()
+
+	filteringRepo := filtering.NewRepository(infra.PostgresDB)
+	_, err := filteringRepo.GetRuleByID(ctx, "does-not-exist")
+	assert.ErrorIs(t, err, filtering.ErrRuleNotFound)
+}
+
 func TestFilteringRepository_GetActiveRules_Ordering(t *testing.T) {
 	infra := SetupTestInfra(t)
 	
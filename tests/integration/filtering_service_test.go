@@ -2,39 +2,49 @@ package integration
 
 import (
 	"context"
+	"sync"
 	"testing"
-	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"yeti/internal/constants"
 	"yeti/internal/filtering"
+	"yeti/internal/filtering/audit"
 	"yeti/internal/management"
+	"yeti/internal/testutil"
 )
 
+// fakeAuditSink is an audit.Sink that records every Record it's given, for
+// assertions in the TestFilteringService_Filter_Audit* tests below. Writes
+// are guarded by a mutex since Filter is exercised concurrently elsewhere
+// in this suite.
+type fakeAuditSink struct {
+	mu      sync.Mutex
+	records []audit.Record
+}
 
-func TestFilteringService_Filter_Pass(t *testing.T) {
-	infra := SetupTestInfra(t)
-	
-
-	ctx := context.Background()
-	log := createTestLogger()
-
-	mgmtRepo := management.NewRepository(infra.PostgresDB)
-	rule := createTestFilteringRule("test_rule", "payload.status == 'active'", 10, true)
-	err := mgmtRepo.CreateFilteringRule(ctx, rule)
-	require.NoError(t, err)
+func (s *fakeAuditSink) Write(_ context.Context, record audit.Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, record)
+	return nil
+}
 
-	filteringRepo := filtering.NewRepository(infra.PostgresDB)
-	cfg := createTestFilteringConfig()
-	svc, err := filtering.NewService(filteringRepo, cfg, log)
-	require.NoError(t, err)
+func (s *fakeAuditSink) Close() error { return nil }
 
-	err = svc.ReloadRules(ctx, true)
-	require.NoError(t, err)
+func (s *fakeAuditSink) all() []audit.Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]audit.Record, len(s.records))
+	copy(out, s.records)
+	return out
+}
 
-	msg := createTestMessage("msg-1", "test", map[string]interface{}{"status": "active"})
+func TestFilteringService_Filter_Pass(t *testing.T) {
+	h := testutil.NewFilteringHarness(t)
+	rule := h.AddRule("test_rule", "payload.status == 'active'", 10)
 
-	passed, appliedRules, err := svc.Filter(ctx, msg)
+	passed, appliedRules, _, err := h.Send("msg-1", "test", map[string]interface{}{"status": "active"})
 	require.NoError(t, err)
 	assert.True(t, passed)
 	assert.Len(t, appliedRules, 1)
@@ -42,94 +52,37 @@ func TestFilteringService_Filter_Pass(t *testing.T) {
 }
 
 func TestFilteringService_Filter_Reject(t *testing.T) {
-	infra := SetupTestInfra(t)
-	
-
-	ctx := context.Background()
-	log := createTestLogger()
+	h := testutil.NewFilteringHarness(t)
+	h.AddRule("test_rule", "payload.status == 'active'", 10)
 
-	mgmtRepo := management.NewRepository(infra.PostgresDB)
-	rule := createTestFilteringRule("test_rule", "payload.status == 'active'", 10, true)
-	err := mgmtRepo.CreateFilteringRule(ctx, rule)
-	require.NoError(t, err)
-
-	filteringRepo := filtering.NewRepository(infra.PostgresDB)
-	cfg := createTestFilteringConfig()
-	svc, err := filtering.NewService(filteringRepo, cfg, log)
-	require.NoError(t, err)
-
-	err = svc.ReloadRules(ctx, true)
-	require.NoError(t, err)
-
-	msg := createTestMessage("msg-1", "test", map[string]interface{}{"status": "inactive"})
-
-	passed, appliedRules, err := svc.Filter(ctx, msg)
+	passed, appliedRules, _, err := h.Send("msg-1", "test", map[string]interface{}{"status": "inactive"})
 	require.NoError(t, err)
 	assert.False(t, passed)
 	assert.Empty(t, appliedRules)
 }
 
 func TestFilteringService_Filter_MultipleRules(t *testing.T) {
-	infra := SetupTestInfra(t)
-	
-
-	ctx := context.Background()
-	log := createTestLogger()
-
-	mgmtRepo := management.NewRepository(infra.PostgresDB)
-	rules := []*management.FilteringRule{
-		createTestFilteringRule("rule1", "payload.status == 'active'", 10, true),
-		createTestFilteringRule("rule2", "payload.type == 'event'", 20, true),
-	}
-
-	for _, rule := range rules {
-		err := mgmtRepo.CreateFilteringRule(ctx, rule)
-		require.NoError(t, err)
-		time.Sleep(timestampDelay)
-	}
-
-	filteringRepo := filtering.NewRepository(infra.PostgresDB)
-	cfg := createTestFilteringConfig()
-	svc, err := filtering.NewService(filteringRepo, cfg, log)
-	require.NoError(t, err)
-
-	err = svc.ReloadRules(ctx, true)
-	require.NoError(t, err)
-
-	msg := createTestMessage("msg-1", "test", map[string]interface{}{"status": "active", "type": "event"})
+	h := testutil.NewFilteringHarness(t)
+	h.AddRule("rule1", "payload.status == 'active'", 10)
+	h.AddRule("rule2", "payload.type == 'event'", 20)
 
-	passed, appliedRules, err := svc.Filter(ctx, msg)
+	passed, appliedRules, _, err := h.Send("msg-1", "test", map[string]interface{}{"status": "active", "type": "event"})
 	require.NoError(t, err)
 	assert.True(t, passed)
 	assert.Len(t, appliedRules, 2)
 }
 
 func TestFilteringService_ReloadRules(t *testing.T) {
-	infra := SetupTestInfra(t)
-	
-
-	ctx := context.Background()
-	log := createTestLogger()
-
-	filteringRepo := filtering.NewRepository(infra.PostgresDB)
-	cfg := createTestFilteringConfig()
-	svc, err := filtering.NewService(filteringRepo, cfg, log)
-	require.NoError(t, err)
-
-	err = svc.ReloadRules(ctx, true)
-	require.NoError(t, err)
+	h := testutil.NewFilteringHarness(t)
 
-	mgmtRepo := management.NewRepository(infra.PostgresDB)
-	rule := createTestFilteringRule("test_rule", "payload.status == 'active'", 10, true)
-	err = mgmtRepo.CreateFilteringRule(ctx, rule)
-	require.NoError(t, err)
-
-	err = svc.ReloadRules(ctx, true)
+	passed, appliedRules, _, err := h.Send("msg-1", "test", map[string]interface{}{"status": "active"})
 	require.NoError(t, err)
+	assert.False(t, passed)
+	assert.Empty(t, appliedRules)
 
-	msg := createTestMessage("msg-1", "test", map[string]interface{}{"status": "active"})
+	h.AddRule("test_rule", "payload.status == 'active'", 10)
 
-	passed, appliedRules, err := svc.Filter(ctx, msg)
+	passed, appliedRules, _, err = h.Send("msg-1", "test", map[string]interface{}{"status": "active"})
 	require.NoError(t, err)
 	assert.True(t, passed)
 	assert.Len(t, appliedRules, 1)
@@ -138,32 +91,15 @@ func TestFilteringService_ReloadRules(t *testing.T) {
 // TestFilteringService_Filter_FallbackAllow_OnCELError tests that when a CEL expression
 // fails to evaluate and fallback is set to "allow", the message is allowed
 func TestFilteringService_Filter_FallbackAllow_OnCELError(t *testing.T) {
-	infra := SetupTestInfra(t)
-	
-
-	ctx := context.Background()
-	log := createTestLogger()
-
-	mgmtRepo := management.NewRepository(infra.PostgresDB)
+	cfg := testutil.DefaultFilteringConfig()
+	cfg.Fallback.OnError = constants.FallbackAllow
+	h := testutil.NewFilteringHarnessWithConfig(t, cfg)
 	// Create a rule with an expression that will cause a runtime error
 	// Using a non-existent field access that will fail at runtime
-	rule := createTestFilteringRule("error_rule", "payload.nonexistent.field == 'value'", 10, true)
-	err := mgmtRepo.CreateFilteringRule(ctx, rule)
-	require.NoError(t, err)
-
-	filteringRepo := filtering.NewRepository(infra.PostgresDB)
-	cfg := createTestFilteringConfig()
-	cfg.Fallback.OnError = "allow" // Set fallback to allow
-	svc, err := filtering.NewService(filteringRepo, cfg, log)
-	require.NoError(t, err)
-
-	err = svc.ReloadRules(ctx, true)
-	require.NoError(t, err)
-
-	msg := createTestMessage("msg-1", "test", map[string]interface{}{"status": "active"})
+	h.AddRule("error_rule", "payload.nonexistent.field == 'value'", 10)
 
 	// With fallback allow, even if CEL evaluation fails, message should pass
-	passed, appliedRules, err := svc.Filter(ctx, msg)
+	passed, appliedRules, _, err := h.Send("msg-1", "test", map[string]interface{}{"status": "active"})
 	require.NoError(t, err)
 	assert.True(t, passed, "Message should be allowed when fallback is 'allow'")
 	assert.Empty(t, appliedRules, "No rules should be applied when evaluation fails")
@@ -172,31 +108,14 @@ func TestFilteringService_Filter_FallbackAllow_OnCELError(t *testing.T) {
 // TestFilteringService_Filter_FallbackDeny_OnCELError tests that when a CEL expression
 // fails to evaluate and fallback is set to "deny", the message is denied
 func TestFilteringService_Filter_FallbackDeny_OnCELError(t *testing.T) {
-	infra := SetupTestInfra(t)
-	
-
-	ctx := context.Background()
-	log := createTestLogger()
-
-	mgmtRepo := management.NewRepository(infra.PostgresDB)
+	cfg := testutil.DefaultFilteringConfig()
+	cfg.Fallback.OnError = constants.FallbackDeny
+	h := testutil.NewFilteringHarnessWithConfig(t, cfg)
 	// Create a rule with an expression that will cause a runtime error
-	rule := createTestFilteringRule("error_rule", "payload.nonexistent.field == 'value'", 10, true)
-	err := mgmtRepo.CreateFilteringRule(ctx, rule)
-	require.NoError(t, err)
-
-	filteringRepo := filtering.NewRepository(infra.PostgresDB)
-	cfg := createTestFilteringConfig()
-	cfg.Fallback.OnError = "deny" // Set fallback to deny
-	svc, err := filtering.NewService(filteringRepo, cfg, log)
-	require.NoError(t, err)
-
-	err = svc.ReloadRules(ctx, true)
-	require.NoError(t, err)
-
-	msg := createTestMessage("msg-1", "test", map[string]interface{}{"status": "active"})
+	h.AddRule("error_rule", "payload.nonexistent.field == 'value'", 10)
 
 	// With fallback deny, if CEL evaluation fails, message should be denied
-	passed, appliedRules, err := svc.Filter(ctx, msg)
+	passed, appliedRules, _, err := h.Send("msg-1", "test", map[string]interface{}{"status": "active"})
 	require.NoError(t, err)
 	assert.False(t, passed, "Message should be denied when fallback is 'deny'")
 	assert.Empty(t, appliedRules, "No rules should be applied when evaluation fails")
@@ -205,70 +124,145 @@ func TestFilteringService_Filter_FallbackDeny_OnCELError(t *testing.T) {
 // TestFilteringService_Filter_InvalidCELExpression tests that invalid CEL expressions
 // are handled according to fallback strategy
 func TestFilteringService_Filter_InvalidCELExpression(t *testing.T) {
-	infra := SetupTestInfra(t)
-	
-
-	ctx := context.Background()
-	log := createTestLogger()
-
-	mgmtRepo := management.NewRepository(infra.PostgresDB)
+	cfg := testutil.DefaultFilteringConfig()
+	cfg.Fallback.OnError = constants.FallbackDeny
+	h := testutil.NewFilteringHarnessWithConfig(t, cfg)
 	// Create a rule with a syntactically invalid CEL expression
 	// This will fail at compile time, not runtime
 	// Note: We need to bypass validation to insert invalid expression
 	// In real scenario, this shouldn't happen, but we test the behavior
-	rule := createTestFilteringRule("invalid_rule", "invalid syntax here!!!", 10, true)
-	err := mgmtRepo.CreateFilteringRule(ctx, rule)
+	h.AddRule("invalid_rule", "invalid syntax here!!!", 10)
+
+	// Invalid CEL expression should trigger fallback
+	passed, appliedRules, _, err := h.Send("msg-1", "test", map[string]interface{}{"status": "active"})
 	require.NoError(t, err)
+	assert.False(t, passed, "Message should be denied when CEL expression is invalid and fallback is 'deny'")
+	assert.Empty(t, appliedRules)
+}
 
-	filteringRepo := filtering.NewRepository(infra.PostgresDB)
-	cfg := createTestFilteringConfig()
-	cfg.Fallback.OnError = "deny"
-	svc, err := filtering.NewService(filteringRepo, cfg, log)
+// TestFilteringService_Filter_RuleCostBudgetExceeded tests that a per-rule
+// MaxCost override denies evaluation once the expression's actual CEL cost
+// crosses it, going through the same fallback path as
+// TestFilteringService_Filter_FallbackDeny_OnCELError.
+func TestFilteringService_Filter_RuleCostBudgetExceeded(t *testing.T) {
+	cfg := testutil.DefaultFilteringConfig()
+	cfg.Fallback.OnError = constants.FallbackDeny
+	h := testutil.NewFilteringHarnessWithConfig(t, cfg)
+	// An ordinary comparison already costs more than 1 unit (field select
+	// plus equality), so MaxCost: 1 guarantees the budget is exceeded
+	// without needing a pathologically expensive expression.
+	rule := &management.FilteringRule{
+		Name:       "cost_budget_rule",
+		Expression: "payload.status == 'active'",
+		Priority:   10,
+		Enabled:    true,
+		MaxCost:    1,
+	}
+	require.NoError(t, h.MgmtRepo.CreateFilteringRule(context.Background(), rule))
+	require.NoError(t, h.Service.ReloadRules(context.Background()))
+
+	passed, appliedRules, _, err := h.Send("msg-1", "test", map[string]interface{}{"status": "active"})
 	require.NoError(t, err)
+	assert.False(t, passed, "Message should be denied when the rule's cost budget is exceeded and fallback is 'deny'")
+	assert.Empty(t, appliedRules)
+}
 
-	err = svc.ReloadRules(ctx, true)
+// TestFilteringService_Filter_RuleEvalTimeoutExceeded tests that a per-rule
+// MaxEvalDurationMs override denies evaluation once the expression runs
+// longer than it, going through the same fallback path as
+// TestFilteringService_Filter_FallbackDeny_OnCELError.
+func TestFilteringService_Filter_RuleEvalTimeoutExceeded(t *testing.T) {
+	cfg := testutil.DefaultFilteringConfig()
+	cfg.Fallback.OnError = constants.FallbackDeny
+	h := testutil.NewFilteringHarnessWithConfig(t, cfg)
+	// A quadratic nested comprehension over a sizeable list takes long
+	// enough, interpreted, to reliably blow a 1ms per-rule budget.
+	rule := &management.FilteringRule{
+		Name:              "eval_timeout_rule",
+		Expression:        "payload.items.all(x, payload.items.all(y, x + y >= 0))",
+		Priority:          10,
+		Enabled:           true,
+		MaxEvalDurationMs: 1,
+	}
+	require.NoError(t, h.MgmtRepo.CreateFilteringRule(context.Background(), rule))
+	require.NoError(t, h.Service.ReloadRules(context.Background()))
+
+	items := make([]interface{}, 1000)
+	for i := range items {
+		items[i] = i
+	}
+
+	passed, appliedRules, _, err := h.Send("msg-1", "test", map[string]interface{}{"items": items})
 	require.NoError(t, err)
+	assert.False(t, passed, "Message should be denied when the rule's eval duration budget is exceeded and fallback is 'deny'")
+	assert.Empty(t, appliedRules)
+}
 
-	msg := createTestMessage("msg-1", "test", map[string]interface{}{"status": "active"})
+// TestFilteringService_Filter_ShadowMode_RecordsVerdict tests that a rule
+// in shadow mode never denies the message, and Filter reports what it
+// would have decided via its shadowResults return value instead.
+func TestFilteringService_Filter_ShadowMode_RecordsVerdict(t *testing.T) {
+	h := testutil.NewFilteringHarness(t)
+	rule := &management.FilteringRule{
+		Name:       "shadow_rule",
+		Expression: "payload.status == 'active'",
+		Priority:   10,
+		Enabled:    true,
+		Mode:       "shadow",
+	}
+	require.NoError(t, h.MgmtRepo.CreateFilteringRule(context.Background(), rule))
+	require.NoError(t, h.Service.ReloadRules(context.Background()))
 
-	// Invalid CEL expression should trigger fallback
-	passed, appliedRules, err := svc.Filter(ctx, msg)
+	// The shadow rule's expression would reject this message, but shadow
+	// mode must never let that affect the real decision.
+	passed, appliedRules, shadowResults, err := h.Send("msg-1", "test", map[string]interface{}{"status": "inactive"})
 	require.NoError(t, err)
-	assert.False(t, passed, "Message should be denied when CEL expression is invalid and fallback is 'deny'")
+	assert.True(t, passed, "A shadow-only rule set must never deny a message")
 	assert.Empty(t, appliedRules)
+	require.Len(t, shadowResults, 1)
+	assert.Equal(t, rule.ID, shadowResults[0].RuleID)
+	assert.False(t, shadowResults[0].WouldPass, "Shadow rule should have recorded a would-be deny")
 }
 
-// TestFilteringService_Filter_ContextTimeout tests that filtering respects context timeout
-func TestFilteringService_Filter_ContextTimeout(t *testing.T) {
-	infra := SetupTestInfra(t)
-	
+// TestFilteringService_Filter_CanaryMode_Deterministic tests that a
+// "canary:<percent>" rule's enforce-or-shadow treatment of a given message
+// ID is stable across repeated evaluations, since it's derived from a hash
+// of the message ID rather than a coin flip per call.
+func TestFilteringService_Filter_CanaryMode_Deterministic(t *testing.T) {
+	h := testutil.NewFilteringHarness(t)
+	rule := &management.FilteringRule{
+		Name:       "canary_rule",
+		Expression: "payload.status == 'active'",
+		Priority:   10,
+		Enabled:    true,
+		Mode:       "canary:50",
+	}
+	require.NoError(t, h.MgmtRepo.CreateFilteringRule(context.Background(), rule))
+	require.NoError(t, h.Service.ReloadRules(context.Background()))
 
-	log := createTestLogger()
+	payload := map[string]interface{}{"status": "inactive"}
 
-	mgmtRepo := management.NewRepository(infra.PostgresDB)
-	rule := createTestFilteringRule("test_rule", "payload.status == 'active'", 10, true)
-	err := mgmtRepo.CreateFilteringRule(context.Background(), rule)
+	_, _, firstShadowResults, err := h.Send("msg-stable-id", "test", payload)
 	require.NoError(t, err)
+	firstEnforced := len(firstShadowResults) == 0
 
-	filteringRepo := filtering.NewRepository(infra.PostgresDB)
-	cfg := createTestFilteringConfig()
-	svc, err := filtering.NewService(filteringRepo, cfg, log)
-	require.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		_, _, shadowResults, err := h.Send("msg-stable-id", "test", payload)
+		require.NoError(t, err)
+		assert.Equal(t, firstEnforced, len(shadowResults) == 0,
+			"The same message ID must resolve to the same enforce-or-shadow treatment on every call")
+	}
+}
 
-	err = svc.ReloadRules(context.Background(), true)
-	require.NoError(t, err)
+func TestFilteringService_Filter_ContextTimeout(t *testing.T) {
+	h := testutil.NewFilteringHarness(t)
+	h.AddRule("test_rule", "payload.status == 'active'", 10)
 
-	// Create a context with a very short timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Nanosecond)
+	ctx, cancel := testutil.AlreadyExpiredContext()
 	defer cancel()
-	
-	// Wait a bit to ensure timeout
-	time.Sleep(10 * time.Millisecond)
-
-	msg := createTestMessage("msg-1", "test", map[string]interface{}{"status": "active"})
 
 	// Should return context deadline exceeded error
-	passed, appliedRules, err := svc.Filter(ctx, msg)
+	passed, appliedRules, _, err := h.SendCtx(ctx, "msg-1", "test", map[string]interface{}{"status": "active"})
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "context deadline exceeded")
 	assert.False(t, passed)
@@ -277,34 +271,75 @@ func TestFilteringService_Filter_ContextTimeout(t *testing.T) {
 
 // TestFilteringService_Filter_ContextCancellation tests that filtering respects context cancellation
 func TestFilteringService_Filter_ContextCancellation(t *testing.T) {
-	infra := SetupTestInfra(t)
-	
-
-	log := createTestLogger()
-
-	mgmtRepo := management.NewRepository(infra.PostgresDB)
-	rule := createTestFilteringRule("test_rule", "payload.status == 'active'", 10, true)
-	err := mgmtRepo.CreateFilteringRule(context.Background(), rule)
-	require.NoError(t, err)
-
-	filteringRepo := filtering.NewRepository(infra.PostgresDB)
-	cfg := createTestFilteringConfig()
-	svc, err := filtering.NewService(filteringRepo, cfg, log)
-	require.NoError(t, err)
-
-	err = svc.ReloadRules(context.Background(), true)
-	require.NoError(t, err)
+	h := testutil.NewFilteringHarness(t)
+	h.AddRule("test_rule", "payload.status == 'active'", 10)
 
 	// Create a context and cancel it immediately
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel() // Cancel immediately
 
-	msg := createTestMessage("msg-1", "test", map[string]interface{}{"status": "active"})
-
 	// Should return context canceled error
-	passed, appliedRules, err := svc.Filter(ctx, msg)
+	passed, appliedRules, _, err := h.SendCtx(ctx, "msg-1", "test", map[string]interface{}{"status": "active"})
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "context canceled")
 	assert.False(t, passed)
 	assert.Empty(t, appliedRules)
 }
+
+// TestFilteringService_Filter_Audit_RecordsPassAndReject tests that Filter
+// emits one audit.Record per call - with SampleRate at 1.0, every call is
+// recorded - carrying the expected message ID, matched/rejecting rule IDs,
+// and a non-negative latency.
+func TestFilteringService_Filter_Audit_RecordsPassAndReject(t *testing.T) {
+	cfg := testutil.DefaultFilteringConfig()
+	cfg.Audit.SampleRate = 1.0
+	sink := &fakeAuditSink{}
+	h := testutil.NewFilteringHarnessWithConfig(t, cfg, filtering.WithAuditSink(sink))
+	rule := h.AddRule("test_rule", "payload.status == 'active'", 10)
+
+	passed, _, _, err := h.Send("msg-pass", "test", map[string]interface{}{"status": "active"})
+	require.NoError(t, err)
+	require.True(t, passed)
+
+	passed, _, _, err = h.Send("msg-reject", "test", map[string]interface{}{"status": "inactive"})
+	require.NoError(t, err)
+	require.False(t, passed)
+
+	records := sink.all()
+	require.Len(t, records, 2)
+
+	assert.Equal(t, "msg-pass", records[0].MessageID)
+	assert.True(t, records[0].Passed)
+	assert.Equal(t, []string{rule.ID}, records[0].MatchedRuleIDs)
+	assert.Empty(t, records[0].RejectedByRuleID)
+	assert.GreaterOrEqual(t, records[0].LatencyMs, float64(0))
+
+	assert.Equal(t, "msg-reject", records[1].MessageID)
+	assert.False(t, records[1].Passed)
+	assert.Equal(t, rule.ID, records[1].RejectedByRuleID)
+}
+
+// TestFilteringService_Filter_Audit_SamplingAndOverrides tests that a
+// SampleRate of 0 drops a passing message's audit record, but
+// AlwaysLogOnReject still forces a record through for a denied one.
+func TestFilteringService_Filter_Audit_SamplingAndOverrides(t *testing.T) {
+	cfg := testutil.DefaultFilteringConfig()
+	cfg.Audit.SampleRate = 0
+	cfg.Audit.AlwaysLogOnReject = true
+	sink := &fakeAuditSink{}
+	h := testutil.NewFilteringHarnessWithConfig(t, cfg, filtering.WithAuditSink(sink))
+	h.AddRule("test_rule", "payload.status == 'active'", 10)
+
+	passed, _, _, err := h.Send("msg-pass", "test", map[string]interface{}{"status": "active"})
+	require.NoError(t, err)
+	require.True(t, passed)
+
+	passed, _, _, err = h.Send("msg-reject", "test", map[string]interface{}{"status": "inactive"})
+	require.NoError(t, err)
+	require.False(t, passed)
+
+	records := sink.all()
+	require.Len(t, records, 1, "sample_rate=0 should drop the passing call, always_log_on_reject should keep the rejected one")
+	assert.Equal(t, "msg-reject", records[0].MessageID)
+	assert.False(t, records[0].Passed)
+}
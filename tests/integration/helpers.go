@@ -1,19 +1,18 @@
 package integration
 
 import (
-	"time"
-
 	"yeti/internal/config"
 	"yeti/internal/constants"
 	"yeti/internal/logger"
 	"yeti/internal/management"
+	"yeti/internal/testutil"
 	"yeti/pkg/models"
 )
 
-const (
-	containerStartupTimeout = 60
-	timestampDelay          = 10 * time.Millisecond
-)
+// timestampDelay is testutil.IntervalFast under its original name, kept so
+// the tests in this package that predate testutil don't all need touching
+// just to rename a constant.
+const timestampDelay = testutil.IntervalFast
 
 func createTestLogger() logger.Logger {
 	return logger.NopLogger()
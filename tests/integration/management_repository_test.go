@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"yeti/internal/management"
+	"yeti/pkg/errors"
 )
 
 func TestManagementRepository_CreateFilteringRule(t *testing.T) {
@@ -112,6 +113,54 @@ func TestManagementRepository_UpdateFilteringRule(t *testing.T) {
 	assert.True(t, retrieved.UpdatedAt.After(originalUpdatedAt))
 }
 
+func TestManagementRepository_UpdateFilteringRuleCAS(t *testing.T) {
+	infra := SetupTestInfra(t)
+
+	repo := management.NewRepository(infra.PostgresDB)
+	ctx := context.Background()
+
+	rule := createTestFilteringRule("test_rule", "payload.status == 'active'", 10, true)
+	err := repo.CreateFilteringRule(ctx, rule)
+	require.NoError(t, err)
+	assert.Equal(t, 1, rule.Version)
+
+	rule.Name = "updated_rule"
+	err = repo.UpdateFilteringRuleCAS(ctx, rule, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, rule.Version)
+
+	retrieved, err := repo.GetFilteringRule(ctx, rule.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "updated_rule", retrieved.Name)
+	assert.Equal(t, 2, retrieved.Version)
+}
+
+func TestManagementRepository_UpdateFilteringRuleCAS_Conflict(t *testing.T) {
+	infra := SetupTestInfra(t)
+
+	repo := management.NewRepository(infra.PostgresDB)
+	ctx := context.Background()
+
+	rule := createTestFilteringRule("test_rule", "payload.status == 'active'", 10, true)
+	err := repo.CreateFilteringRule(ctx, rule)
+	require.NoError(t, err)
+
+	// Simulate a concurrent writer that already bumped the version.
+	rule.Name = "first_writer"
+	err = repo.UpdateFilteringRuleCAS(ctx, rule, 1)
+	require.NoError(t, err)
+
+	rule.Name = "second_writer"
+	err = repo.UpdateFilteringRuleCAS(ctx, rule, 1)
+	require.Error(t, err)
+	assert.True(t, errors.IsConflict(err))
+
+	current, err := repo.GetForUpdate(ctx, rule.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "first_writer", current.Name)
+	assert.Equal(t, 2, current.Version)
+}
+
 func TestManagementRepository_DeleteFilteringRule(t *testing.T) {
 	infra := SetupTestInfra(t)
 
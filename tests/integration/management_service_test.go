@@ -2,6 +2,8 @@ package integration
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -9,7 +11,9 @@ import (
 	"github.com/stretchr/testify/require"
 	"yeti/internal/config"
 	"yeti/internal/constants"
+	"yeti/internal/enrichment/provider"
 	"yeti/internal/management"
+	"yeti/internal/testutil"
 	pkgerrors "yeti/pkg/errors"
 )
 
@@ -349,6 +353,221 @@ func TestManagementService_UpdateFilteringRule_WithVersioning(t *testing.T) {
 	assert.Equal(t, 1, versions[1].Version)
 }
 
+func TestManagementService_RollbackFilteringRule(t *testing.T) {
+	infra := SetupTestInfra(t)
+
+	ctx := context.Background()
+	repo := management.NewRepository(infra.PostgresDB)
+	versioningRepo := management.NewVersioningRepository(infra.PostgresDB)
+	svc := management.NewService(repo, management.WithVersioning(versioningRepo))
+
+	req := management.CreateFilteringRuleRequest{
+		Name:       "test_rule",
+		Expression: "payload.status == 'active'",
+		Priority:   10,
+	}
+
+	created, err := svc.CreateFilteringRule(ctx, req)
+	require.NoError(t, err)
+
+	_, err = svc.UpdateFilteringRule(ctx, created.ID, management.UpdateFilteringRuleRequest{
+		Name: stringPtr("updated_rule"),
+	})
+	require.NoError(t, err)
+
+	restored, err := svc.RollbackFilteringRule(ctx, created.ID, 1, "tester")
+	require.NoError(t, err)
+	assert.Equal(t, 3, restored.Version)
+
+	rule, err := svc.GetFilteringRule(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "test_rule", rule.Name)
+
+	logs, err := svc.GetAuditLogs(ctx, &created.ID, "filtering", 100)
+	require.NoError(t, err)
+	hasRollback := false
+	for _, log := range logs {
+		if log.Action == "rollback" {
+			hasRollback = true
+		}
+	}
+	assert.True(t, hasRollback, "should have a rollback audit entry")
+}
+
+func TestManagementService_RollbackFilteringRule_RecreatesDeletedRule(t *testing.T) {
+	infra := SetupTestInfra(t)
+
+	ctx := context.Background()
+	repo := management.NewRepository(infra.PostgresDB)
+	versioningRepo := management.NewVersioningRepository(infra.PostgresDB)
+	svc := management.NewService(repo, management.WithVersioning(versioningRepo))
+
+	req := management.CreateFilteringRuleRequest{
+		Name:       "test_rule",
+		Expression: "payload.status == 'active'",
+		Priority:   10,
+	}
+
+	created, err := svc.CreateFilteringRule(ctx, req)
+	require.NoError(t, err)
+
+	err = svc.DeleteFilteringRule(ctx, created.ID)
+	require.NoError(t, err)
+
+	_, err = svc.GetFilteringRule(ctx, created.ID)
+	require.Error(t, err)
+	require.True(t, pkgerrors.IsNotFound(err))
+
+	restored, err := svc.RollbackFilteringRule(ctx, created.ID, 1, "tester")
+	require.NoError(t, err)
+	assert.NotNil(t, restored)
+
+	rule, err := svc.GetFilteringRule(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, rule.ID)
+	assert.Equal(t, "test_rule", rule.Name)
+}
+
+func TestManagementService_RollbackFilteringRule_InvalidCELExpression(t *testing.T) {
+	infra := SetupTestInfra(t)
+
+	ctx := context.Background()
+	repo := management.NewRepository(infra.PostgresDB)
+	versioningRepo := management.NewVersioningRepository(infra.PostgresDB)
+	svc := management.NewService(repo, management.WithVersioning(versioningRepo))
+
+	req := management.CreateFilteringRuleRequest{
+		Name:       "test_rule",
+		Expression: "payload.status == 'active'",
+		Priority:   10,
+	}
+
+	created, err := svc.CreateFilteringRule(ctx, req)
+	require.NoError(t, err)
+
+	// A version with an expression that could never have passed
+	// CreateFilteringRule's own validation, inserted directly through the
+	// versioning repository the way a long-gone CEL environment might have
+	// once accepted it. Rollback has to catch this itself rather than
+	// trusting that anything already in rule_versions is still valid.
+	invalidVersion := &management.RuleVersion{
+		TenantID:  management.DefaultTenantID,
+		RuleID:    created.ID,
+		RuleType:  "filtering",
+		RuleData:  `{"name":"test_rule","expression":"payload.status ===","priority":10,"enabled":true}`,
+		Version:   2,
+		ChangedBy: "tester",
+	}
+	require.NoError(t, versioningRepo.CreateVersion(ctx, invalidVersion))
+
+	restored, err := svc.RollbackFilteringRule(ctx, created.ID, 2, "tester")
+	assert.Error(t, err)
+	assert.Nil(t, restored)
+	assert.True(t, pkgerrors.IsValidation(err))
+
+	rule, err := svc.GetFilteringRule(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "payload.status == 'active'", rule.Expression, "failed rollback must not mutate the current rule")
+}
+
+func TestManagementService_DiffRuleVersions_Stable(t *testing.T) {
+	infra := SetupTestInfra(t)
+
+	ctx := context.Background()
+	repo := management.NewRepository(infra.PostgresDB)
+	versioningRepo := management.NewVersioningRepository(infra.PostgresDB)
+	svc := management.NewService(repo, management.WithVersioning(versioningRepo))
+
+	req := management.CreateFilteringRuleRequest{
+		Name:       "test_rule",
+		Expression: "payload.status == 'active'",
+		Priority:   10,
+	}
+
+	created, err := svc.CreateFilteringRule(ctx, req)
+	require.NoError(t, err)
+
+	_, err = svc.UpdateFilteringRule(ctx, created.ID, management.UpdateFilteringRuleRequest{
+		Name: stringPtr("updated_rule"),
+	})
+	require.NoError(t, err)
+
+	first, err := svc.DiffRuleVersions(ctx, created.ID, 1, 2)
+	require.NoError(t, err)
+	second, err := svc.DiffRuleVersions(ctx, created.ID, 1, 2)
+	require.NoError(t, err)
+
+	assert.Equal(t, first, second)
+	assert.Len(t, first, 1)
+	assert.Equal(t, "name", first[0].Field)
+}
+
+func TestManagementService_RollbackEnrichmentRule(t *testing.T) {
+	infra := SetupTestInfra(t)
+
+	ctx := context.Background()
+	repo := management.NewRepository(infra.PostgresDB)
+	enrichmentRepo := management.NewEnrichmentRepository(infra.MongoDB)
+	svc := management.NewService(repo, management.WithEnrichment(enrichmentRepo))
+
+	req := management.CreateEnrichmentRuleRequest{
+		Name:          "test_enrichment_rule",
+		FieldToEnrich: "user_id",
+		SourceType:    "cache",
+		SourceConfig: management.EnrichmentSourceConfig{
+			KeyPattern: "user:{user_id}",
+		},
+	}
+
+	created, err := svc.CreateEnrichmentRule(ctx, req)
+	require.NoError(t, err)
+
+	_, err = svc.UpdateEnrichmentRule(ctx, created.ID, management.UpdateEnrichmentRuleRequest{
+		Name: stringPtr("updated_enrichment_rule"),
+	})
+	require.NoError(t, err)
+
+	restored, err := svc.RollbackEnrichmentRule(ctx, created.ID, 1, "tester")
+	require.NoError(t, err)
+	assert.Equal(t, "test_enrichment_rule", restored.Name)
+
+	rule, err := svc.GetEnrichmentRule(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "test_enrichment_rule", rule.Name)
+}
+
+func TestManagementService_RollbackEnrichmentRule_RecreatesDeletedRule(t *testing.T) {
+	infra := SetupTestInfra(t)
+
+	ctx := context.Background()
+	repo := management.NewRepository(infra.PostgresDB)
+	enrichmentRepo := management.NewEnrichmentRepository(infra.MongoDB)
+	svc := management.NewService(repo, management.WithEnrichment(enrichmentRepo))
+
+	req := management.CreateEnrichmentRuleRequest{
+		Name:          "test_enrichment_rule",
+		FieldToEnrich: "user_id",
+		SourceType:    "cache",
+		SourceConfig: management.EnrichmentSourceConfig{
+			KeyPattern: "user:{user_id}",
+		},
+	}
+
+	created, err := svc.CreateEnrichmentRule(ctx, req)
+	require.NoError(t, err)
+
+	err = svc.DeleteEnrichmentRule(ctx, created.ID)
+	require.NoError(t, err)
+
+	restored, err := svc.RollbackEnrichmentRule(ctx, created.ID, 1, "tester")
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, restored.ID)
+
+	rule, err := svc.GetEnrichmentRule(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, rule.ID)
+}
+
 func TestManagementService_GetAuditLogs(t *testing.T) {
 	infra := SetupTestInfra(t)
 
@@ -691,9 +910,8 @@ func TestManagementService_CreateFilteringRule_ContextTimeout(t *testing.T) {
 	repo := management.NewRepository(infra.PostgresDB)
 	svc := management.NewService(repo)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Nanosecond)
+	ctx, cancel := testutil.AlreadyExpiredContext()
 	defer cancel()
-	time.Sleep(10 * time.Millisecond)
 
 	req := management.CreateFilteringRuleRequest{
 		Name:       "test_rule",
@@ -728,6 +946,506 @@ func TestManagementService_CreateFilteringRule_ContextCancellation(t *testing.T)
 	assert.Contains(t, err.Error(), "context canceled")
 }
 
+func TestManagementService_EvaluateFilteringRule_Match(t *testing.T) {
+	infra := SetupTestInfra(t)
+
+	ctx := context.Background()
+	repo := management.NewRepository(infra.PostgresDB)
+	svc := management.NewService(repo)
+
+	req := management.FilteringEvaluateRequest{
+		Expression: stringPtr("payload.status == 'active'"),
+		Event: management.SampleEvent{
+			Source:  "test",
+			Payload: map[string]interface{}{"status": "active"},
+		},
+	}
+
+	resp, err := svc.EvaluateFilteringRule(ctx, req)
+	require.NoError(t, err)
+	assert.Empty(t, resp.CompileError)
+	assert.Empty(t, resp.Error)
+	assert.True(t, resp.Matched)
+	assert.NotEmpty(t, resp.Trace, "should report a per-subexpression trace")
+}
+
+func TestManagementService_EvaluateFilteringRule_NonBoolCEL(t *testing.T) {
+	infra := SetupTestInfra(t)
+
+	ctx := context.Background()
+	repo := management.NewRepository(infra.PostgresDB)
+	svc := management.NewService(repo)
+
+	req := management.FilteringEvaluateRequest{
+		Expression: stringPtr("payload.status"),
+		Event: management.SampleEvent{
+			Source:  "test",
+			Payload: map[string]interface{}{"status": "active"},
+		},
+	}
+
+	resp, err := svc.EvaluateFilteringRule(ctx, req)
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.CompileError)
+	assert.False(t, resp.Matched)
+}
+
+func TestManagementService_EvaluateEnrichmentRule_MissingField(t *testing.T) {
+	infra := SetupTestInfra(t)
+
+	ctx := context.Background()
+	repo := management.NewRepository(infra.PostgresDB)
+	svc := management.NewService(repo)
+
+	req := management.EnrichmentEvaluateRequest{
+		Rule: &management.CreateEnrichmentRuleRequest{
+			Name:          "test_enrichment_rule",
+			FieldToEnrich: "user_id",
+			SourceType:    "cache",
+			SourceConfig: management.EnrichmentSourceConfig{
+				KeyPattern: "user:{user_id}",
+			},
+		},
+		Event: management.SampleEvent{
+			Source:  "test",
+			Payload: map[string]interface{}{"other_field": "value"},
+		},
+	}
+
+	resp, err := svc.EvaluateEnrichmentRule(ctx, req)
+	require.NoError(t, err)
+	assert.Contains(t, resp.Error, "not present in sample event payload")
+}
+
+func TestManagementService_EvaluateEnrichmentRule_MockedSource(t *testing.T) {
+	infra := SetupTestInfra(t)
+
+	ctx := context.Background()
+	repo := management.NewRepository(infra.PostgresDB)
+	svc := management.NewService(repo)
+
+	req := management.EnrichmentEvaluateRequest{
+		Rule: &management.CreateEnrichmentRuleRequest{
+			Name:          "test_enrichment_rule",
+			FieldToEnrich: "user_id",
+			SourceType:    "cache",
+			SourceConfig: management.EnrichmentSourceConfig{
+				KeyPattern: "user:{user_id}",
+			},
+			Transformations: []management.EnrichmentTransformation{
+				{SourcePath: "name", TargetField: "user_name"},
+			},
+		},
+		Event: management.SampleEvent{
+			Source:  "test",
+			Payload: map[string]interface{}{"user_id": "u1"},
+		},
+		MockSources: map[string]map[string]interface{}{
+			"cache": {"name": "Ada Lovelace"},
+		},
+	}
+
+	resp, err := svc.EvaluateEnrichmentRule(ctx, req)
+	require.NoError(t, err)
+	require.Empty(t, resp.Error)
+	assert.True(t, resp.Matched)
+	assert.Equal(t, "Ada Lovelace", resp.EnrichmentDiff["user_name"])
+}
+
+func TestManagementService_CreateEnrichmentRule_HTTPSource(t *testing.T) {
+	infra := SetupTestInfra(t)
+
+	ctx := context.Background()
+	repo := management.NewRepository(infra.PostgresDB)
+	enrichmentRepo := management.NewEnrichmentRepository(infra.MongoDB)
+	svc := management.NewService(repo, management.WithEnrichment(enrichmentRepo))
+
+	req := management.CreateEnrichmentRuleRequest{
+		Name:          "test_http_enrichment_rule",
+		FieldToEnrich: "user_id",
+		SourceType:    "http",
+		SourceConfig: management.EnrichmentSourceConfig{
+			URL:     "https://users.example.com/{field_value}",
+			Method:  "GET",
+			Headers: map[string]string{"X-Api-Key": "{header:api_key}", "api_key": "secret"},
+		},
+	}
+
+	rule, err := svc.CreateEnrichmentRule(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, "http", rule.SourceType)
+}
+
+func TestManagementService_CreateEnrichmentRule_HTTPSource_UndeclaredHeaderTemplate(t *testing.T) {
+	infra := SetupTestInfra(t)
+
+	ctx := context.Background()
+	repo := management.NewRepository(infra.PostgresDB)
+	enrichmentRepo := management.NewEnrichmentRepository(infra.MongoDB)
+	svc := management.NewService(repo, management.WithEnrichment(enrichmentRepo))
+
+	req := management.CreateEnrichmentRuleRequest{
+		Name:          "test_http_enrichment_rule",
+		FieldToEnrich: "user_id",
+		SourceType:    "http",
+		SourceConfig: management.EnrichmentSourceConfig{
+			URL: "https://users.example.com/{field_value}?token={header:api_key}",
+		},
+	}
+
+	_, err := svc.CreateEnrichmentRule(ctx, req)
+	require.Error(t, err)
+	assert.True(t, pkgerrors.IsValidation(err))
+}
+
+func TestManagementService_UpdateEnrichmentRule_HTTPSource_UndeclaredQueryTemplate(t *testing.T) {
+	infra := SetupTestInfra(t)
+
+	ctx := context.Background()
+	repo := management.NewRepository(infra.PostgresDB)
+	enrichmentRepo := management.NewEnrichmentRepository(infra.MongoDB)
+	svc := management.NewService(repo, management.WithEnrichment(enrichmentRepo))
+
+	created, err := svc.CreateEnrichmentRule(ctx, management.CreateEnrichmentRuleRequest{
+		Name:          "test_http_enrichment_rule",
+		FieldToEnrich: "user_id",
+		SourceType:    "http",
+		SourceConfig: management.EnrichmentSourceConfig{
+			URL: "https://users.example.com/{field_value}",
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = svc.UpdateEnrichmentRule(ctx, created.ID, management.UpdateEnrichmentRuleRequest{
+		SourceConfig: &management.EnrichmentSourceConfig{
+			URL: "https://users.example.com/{field_value}?region={query:region}",
+		},
+	})
+	require.Error(t, err)
+	assert.True(t, pkgerrors.IsValidation(err))
+}
+
+func TestManagementService_EvaluateEnrichmentRule_HTTPSource(t *testing.T) {
+	infra := SetupTestInfra(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/users/u1", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"name": "Ada Lovelace"}`))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	repo := management.NewRepository(infra.PostgresDB)
+	enrichmentRepo := management.NewEnrichmentRepository(infra.MongoDB)
+	svc := management.NewService(repo,
+		management.WithEnrichment(enrichmentRepo),
+		management.WithEnrichmentProviders(map[string]provider.DataProvider{
+			"http": provider.NewHTTPProvider(nil),
+		}),
+	)
+
+	req := management.EnrichmentEvaluateRequest{
+		Rule: &management.CreateEnrichmentRuleRequest{
+			Name:          "test_http_enrichment_rule",
+			FieldToEnrich: "user_id",
+			SourceType:    "http",
+			SourceConfig: management.EnrichmentSourceConfig{
+				URL:    server.URL + "/users/{field_value}",
+				Method: "GET",
+			},
+			Transformations: []management.EnrichmentTransformation{
+				{SourcePath: "name", TargetField: "user_name"},
+			},
+		},
+		Event: management.SampleEvent{
+			Source:  "test",
+			Payload: map[string]interface{}{"user_id": "u1"},
+		},
+	}
+
+	resp, err := svc.EvaluateEnrichmentRule(ctx, req)
+	require.NoError(t, err)
+	require.Empty(t, resp.Error)
+	assert.True(t, resp.Matched)
+	assert.Equal(t, "Ada Lovelace", resp.EnrichmentDiff["user_name"])
+}
+
+func TestManagementService_DryRunEnrichmentRule_HTTPSource(t *testing.T) {
+	infra := SetupTestInfra(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"name": "Ada Lovelace"}`))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	repo := management.NewRepository(infra.PostgresDB)
+	enrichmentRepo := management.NewEnrichmentRepository(infra.MongoDB)
+	svc := management.NewService(repo,
+		management.WithEnrichment(enrichmentRepo),
+		management.WithEnrichmentProviders(map[string]provider.DataProvider{
+			"http": provider.NewHTTPProvider(nil),
+		}),
+	)
+
+	rule := management.EnrichmentRule{
+		Name:          "test_http_enrichment_rule",
+		FieldToEnrich: "user_id",
+		SourceType:    "http",
+		SourceConfig: management.EnrichmentSourceConfig{
+			URL:    server.URL + "/users/{field_value}",
+			Method: "GET",
+		},
+		Transformations: []management.EnrichmentTransformation{
+			{SourcePath: "name", TargetField: "user_name"},
+		},
+		Enabled: true,
+	}
+
+	result, err := svc.DryRunEnrichmentRule(ctx, rule, []management.SampleEvent{
+		{Source: "test", Payload: map[string]interface{}{"user_id": "u1"}},
+	}, nil)
+	require.NoError(t, err)
+	require.Len(t, result.Results, 1)
+	assert.True(t, result.Results[0].Matched)
+	assert.Equal(t, "Ada Lovelace", result.Results[0].Extractions["user_name"])
+}
+
+func TestManagementService_ImportRuleBundle_RoundTrip(t *testing.T) {
+	infra := SetupTestInfra(t)
+
+	ctx := context.Background()
+	repo := management.NewRepository(infra.PostgresDB)
+	enrichmentRepo := management.NewEnrichmentRepository(infra.MongoDB)
+	svc := management.NewService(repo, management.WithEnrichment(enrichmentRepo))
+
+	_, err := svc.CreateFilteringRule(ctx, management.CreateFilteringRuleRequest{
+		Name:       "test_filtering_rule",
+		Expression: "payload.status == 'active'",
+		Priority:   5,
+		Enabled:    boolPtr(true),
+	})
+	require.NoError(t, err)
+
+	_, err = svc.CreateEnrichmentRule(ctx, management.CreateEnrichmentRuleRequest{
+		Name:          "test_enrichment_rule",
+		FieldToEnrich: "user_id",
+		SourceType:    "cache",
+		SourceConfig: management.EnrichmentSourceConfig{
+			KeyPattern: "user:{user_id}",
+		},
+		Priority: 1,
+		Enabled:  boolPtr(true),
+	})
+	require.NoError(t, err)
+
+	exported, err := svc.ExportRuleBundle(ctx)
+	require.NoError(t, err)
+	require.Len(t, exported.FilteringRules, 1)
+	require.Len(t, exported.EnrichmentRules, 1)
+
+	filteringRules, err := svc.ListFilteringRules(ctx)
+	require.NoError(t, err)
+	for _, rule := range filteringRules {
+		require.NoError(t, svc.DeleteFilteringRule(ctx, rule.ID))
+	}
+	enrichmentRules, err := svc.ListEnrichmentRules(ctx)
+	require.NoError(t, err)
+	for _, rule := range enrichmentRules {
+		require.NoError(t, svc.DeleteEnrichmentRule(ctx, rule.ID))
+	}
+
+	result, err := svc.ImportRuleBundle(ctx, *exported, "tester", management.ImportOptions{Mode: management.ImportModeCreateOnly})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"test_filtering_rule"}, result.FilteringRules.Create)
+	assert.Equal(t, []string{"test_enrichment_rule"}, result.EnrichmentRules.Create)
+
+	reimported, err := svc.ExportRuleBundle(ctx)
+	require.NoError(t, err)
+	require.Len(t, reimported.FilteringRules, 1)
+	require.Len(t, reimported.EnrichmentRules, 1)
+	assert.Equal(t, exported.FilteringRules[0].Expression, reimported.FilteringRules[0].Expression)
+	assert.Equal(t, exported.FilteringRules[0].Priority, reimported.FilteringRules[0].Priority)
+	assert.Equal(t, exported.EnrichmentRules[0].SourceConfig, reimported.EnrichmentRules[0].SourceConfig)
+	assert.Equal(t, exported.EnrichmentRules[0].FieldToEnrich, reimported.EnrichmentRules[0].FieldToEnrich)
+}
+
+func TestManagementService_ImportRuleBundle_DryRunDoesNotMutate(t *testing.T) {
+	infra := SetupTestInfra(t)
+
+	ctx := context.Background()
+	repo := management.NewRepository(infra.PostgresDB)
+	enrichmentRepo := management.NewEnrichmentRepository(infra.MongoDB)
+	svc := management.NewService(repo, management.WithEnrichment(enrichmentRepo))
+
+	exported, err := svc.ExportRuleBundle(ctx)
+	require.NoError(t, err)
+
+	exported.FilteringRules = append(exported.FilteringRules, management.FilteringRule{
+		Name:       "dry_run_only_rule",
+		Expression: "payload.status == 'active'",
+		Priority:   1,
+		Enabled:    true,
+	})
+	require.NoError(t, exported.RecomputeContentHash())
+
+	result, err := svc.ImportRuleBundle(ctx, *exported, "tester", management.ImportOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.True(t, result.DryRun)
+	assert.Equal(t, []string{"dry_run_only_rule"}, result.FilteringRules.Create)
+
+	rules, err := svc.ListFilteringRules(ctx)
+	require.NoError(t, err)
+	for _, rule := range rules {
+		assert.NotEqual(t, "dry_run_only_rule", rule.Name)
+	}
+}
+
+func TestManagementService_ImportRuleBundle_InvalidCEL_ReplaceAllRollsBack(t *testing.T) {
+	infra := SetupTestInfra(t)
+
+	ctx := context.Background()
+	repo := management.NewRepository(infra.PostgresDB)
+	enrichmentRepo := management.NewEnrichmentRepository(infra.MongoDB)
+	svc := management.NewService(repo, management.WithEnrichment(enrichmentRepo))
+
+	_, err := svc.CreateFilteringRule(ctx, management.CreateFilteringRuleRequest{
+		Name:       "kept_rule",
+		Expression: "payload.status == 'active'",
+		Priority:   1,
+		Enabled:    boolPtr(true),
+	})
+	require.NoError(t, err)
+
+	exported, err := svc.ExportRuleBundle(ctx)
+	require.NoError(t, err)
+
+	exported.FilteringRules = append(exported.FilteringRules, management.FilteringRule{
+		Name:       "broken_rule",
+		Expression: "payload.status ==", // invalid CEL
+		Priority:   1,
+		Enabled:    true,
+	})
+	require.NoError(t, exported.RecomputeContentHash())
+
+	_, err = svc.ImportRuleBundle(ctx, *exported, "tester", management.ImportOptions{Mode: management.ImportModeReplaceAll})
+	require.Error(t, err)
+	assert.True(t, pkgerrors.IsValidation(err))
+
+	rules, err := svc.ListFilteringRules(ctx)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	assert.Equal(t, "kept_rule", rules[0].Name)
+}
+
+func TestManagementService_ListFilteringRules_TenantIsolation(t *testing.T) {
+	infra := SetupTestInfra(t)
+
+	repo := management.NewRepository(infra.PostgresDB)
+	svc := management.NewService(repo)
+
+	ctxA := management.ContextWithTenant(context.Background(), "tenant-a")
+	ctxB := management.ContextWithTenant(context.Background(), "tenant-b")
+
+	_, err := svc.CreateFilteringRule(ctxA, management.CreateFilteringRuleRequest{
+		Name:       "a_only_rule",
+		Expression: "payload.status == 'active'",
+		Priority:   1,
+		Enabled:    boolPtr(true),
+	})
+	require.NoError(t, err)
+
+	rulesA, err := svc.ListFilteringRules(ctxA)
+	require.NoError(t, err)
+	require.Len(t, rulesA, 1)
+	assert.Equal(t, "a_only_rule", rulesA[0].Name)
+
+	rulesB, err := svc.ListFilteringRules(ctxB)
+	require.NoError(t, err)
+	assert.Empty(t, rulesB, "tenant B must not see tenant A's rules")
+}
+
+func TestManagementService_ListFilteringRules_InheritsGlobalUnlessShadowed(t *testing.T) {
+	infra := SetupTestInfra(t)
+
+	repo := management.NewRepository(infra.PostgresDB)
+	svc := management.NewService(repo)
+
+	ctxGlobal := management.ContextWithTenant(context.Background(), management.DefaultTenantID)
+	ctxTenant := management.ContextWithTenant(context.Background(), "tenant-a")
+
+	_, err := svc.CreateFilteringRule(ctxGlobal, management.CreateFilteringRuleRequest{
+		Name:       "shared_rule",
+		Expression: "payload.status == 'active'",
+		Priority:   1,
+		Enabled:    boolPtr(true),
+	})
+	require.NoError(t, err)
+	_, err = svc.CreateFilteringRule(ctxGlobal, management.CreateFilteringRuleRequest{
+		Name:       "global_only_rule",
+		Expression: "payload.status == 'active'",
+		Priority:   1,
+		Enabled:    boolPtr(true),
+	})
+	require.NoError(t, err)
+	_, err = svc.CreateFilteringRule(ctxTenant, management.CreateFilteringRuleRequest{
+		Name:       "shared_rule",
+		Expression: "payload.status == 'inactive'",
+		Priority:   1,
+		Enabled:    boolPtr(true),
+	})
+	require.NoError(t, err)
+
+	withoutInherit, err := svc.ListFilteringRules(ctxTenant)
+	require.NoError(t, err)
+	require.Len(t, withoutInherit, 1)
+	assert.Equal(t, "shared_rule", withoutInherit[0].Name)
+
+	rules, err := svc.ListFilteringRules(management.ContextWithIncludeGlobal(ctxTenant))
+	require.NoError(t, err)
+	byName := map[string]string{}
+	for _, r := range rules {
+		byName[r.Name] = r.Expression
+	}
+	require.Len(t, rules, 2, "tenant's own shared_rule should shadow the global one, plus global_only_rule inherited")
+	assert.Equal(t, "payload.status == 'inactive'", byName["shared_rule"], "tenant rule must shadow the global rule of the same name")
+	assert.Contains(t, byName, "global_only_rule")
+}
+
+func TestManagementService_DeduplicationConfig_TenantIsolation(t *testing.T) {
+	infra := SetupTestInfra(t)
+
+	repo := management.NewRepository(infra.PostgresDB)
+	dedupCfg := config.DeduplicationConfig{
+		HashAlgorithm: "md5",
+		TTLSeconds:    300,
+		OnRedisError:  constants.FallbackAllow,
+		FieldsToHash:  []string{"id", "source"},
+	}
+	svc := management.NewService(repo, management.WithDeduplicationConfig(dedupCfg))
+
+	ctxA := management.ContextWithTenant(context.Background(), "tenant-a")
+	ctxB := management.ContextWithTenant(context.Background(), "tenant-b")
+	ctxGlobal := management.ContextWithTenant(context.Background(), management.DefaultTenantID)
+
+	updated, err := svc.UpdateDeduplicationConfig(ctxA, management.UpdateDeduplicationConfigRequest{
+		HashAlgorithm: stringPtr("sha256"),
+		TTLSeconds:    intPtr(600),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "sha256", updated.HashAlgorithm)
+
+	cfgB, err := svc.GetDeduplicationConfig(ctxB)
+	require.NoError(t, err)
+	assert.Equal(t, "md5", cfgB.HashAlgorithm, "tenant B's config must be unaffected by tenant A's update")
+
+	cfgGlobal, err := svc.GetDeduplicationConfig(ctxGlobal)
+	require.NoError(t, err)
+	assert.Equal(t, "md5", cfgGlobal.HashAlgorithm, "the global default must be unaffected by tenant A's update")
+}
+
 func boolPtr(b bool) *bool {
 	return &b
 }
@@ -0,0 +1,50 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"yeti/pkg/migrations"
+)
+
+func TestMigrationsLock_AcquireAndRelease(t *testing.T) {
+	infra := SetupTestInfra(t)
+	ctx := context.Background()
+
+	lock := migrations.NewDistributedLock(infra.RedisClient, "holder-1", 5*time.Second)
+	require.NoError(t, lock.AcquireBlocking(ctx, 50*time.Millisecond))
+	require.NoError(t, lock.Release(ctx))
+
+	// Released, so a second holder can acquire it right away rather than
+	// waiting out the full TTL.
+	other := migrations.NewDistributedLock(infra.RedisClient, "holder-2", 5*time.Second)
+	acquireCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	require.NoError(t, other.AcquireBlocking(acquireCtx, 50*time.Millisecond))
+	require.NoError(t, other.Release(ctx))
+}
+
+// TestMigrationsLock_ReleaseDoesNotStealAnotherHolderLock guards against the
+// classic GET-then-DEL race: if this holder's lease already expired (or was
+// otherwise taken over) and a new holder has since acquired the key, Release
+// must not delete the new holder's lock out from under it.
+func TestMigrationsLock_ReleaseDoesNotStealAnotherHolderLock(t *testing.T) {
+	infra := SetupTestInfra(t)
+	ctx := context.Background()
+
+	lock := migrations.NewDistributedLock(infra.RedisClient, "stale-holder", 5*time.Second)
+	require.NoError(t, lock.AcquireBlocking(ctx, 50*time.Millisecond))
+
+	// Simulate the lease expiring and another instance winning it.
+	require.NoError(t, infra.RedisClient.Set(ctx, "migrations:lock", "new-holder", 5*time.Second).Err())
+
+	require.NoError(t, lock.Release(ctx))
+
+	current, err := infra.RedisClient.Get(ctx, "migrations:lock").Result()
+	require.NoError(t, err)
+	assert.Equal(t, "new-holder", current, "stale holder's Release deleted another instance's lock")
+}
@@ -0,0 +1,85 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"yeti/internal/management/scheduler"
+)
+
+func TestSchedulerLeaderElector_AcquireRenewAndRelease(t *testing.T) {
+	infra := SetupTestInfra(t)
+	ctx := context.Background()
+
+	elector := scheduler.NewLeaderElector(infra.RedisClient, "replica-1", 5*time.Second)
+
+	held, err := elector.TryAcquire(ctx)
+	require.NoError(t, err)
+	assert.True(t, held)
+
+	// Same replica renewing its own lease stays leader.
+	held, err = elector.TryAcquire(ctx)
+	require.NoError(t, err)
+	assert.True(t, held)
+
+	other := scheduler.NewLeaderElector(infra.RedisClient, "replica-2", 5*time.Second)
+	held, err = other.TryAcquire(ctx)
+	require.NoError(t, err)
+	assert.False(t, held, "second replica must not become leader while the first still holds the lease")
+
+	require.NoError(t, elector.Release(ctx))
+
+	held, err = other.TryAcquire(ctx)
+	require.NoError(t, err)
+	assert.True(t, held, "replica-2 should win leadership once replica-1 releases")
+}
+
+// TestSchedulerLeaderElector_RenewDoesNotStealAnotherReplicaLease guards
+// against the classic GET-then-EXPIRE race: once a stale replica's lease
+// has been taken over by another replica, a renewal from the stale replica
+// must not extend the new leader's TTL or report itself as still leader.
+func TestSchedulerLeaderElector_RenewDoesNotStealAnotherReplicaLease(t *testing.T) {
+	infra := SetupTestInfra(t)
+	ctx := context.Background()
+
+	stale := scheduler.NewLeaderElector(infra.RedisClient, "stale-replica", 5*time.Second)
+	held, err := stale.TryAcquire(ctx)
+	require.NoError(t, err)
+	require.True(t, held)
+
+	// Simulate the lease expiring and another replica winning it.
+	require.NoError(t, infra.RedisClient.Set(ctx, "management:scheduler:leader", "new-replica", 5*time.Second).Err())
+
+	held, err = stale.TryAcquire(ctx)
+	require.NoError(t, err)
+	assert.False(t, held, "stale replica's renewal must not report leadership over another replica's lease")
+
+	current, err := infra.RedisClient.Get(ctx, "management:scheduler:leader").Result()
+	require.NoError(t, err)
+	assert.Equal(t, "new-replica", current, "stale replica's renewal must not extend another replica's lease")
+}
+
+// TestSchedulerLeaderElector_ReleaseDoesNotStealAnotherReplicaLease guards
+// the equivalent race on Release: a stale replica releasing after its
+// lease has already been taken over must not delete the new leader's key.
+func TestSchedulerLeaderElector_ReleaseDoesNotStealAnotherReplicaLease(t *testing.T) {
+	infra := SetupTestInfra(t)
+	ctx := context.Background()
+
+	stale := scheduler.NewLeaderElector(infra.RedisClient, "stale-replica", 5*time.Second)
+	held, err := stale.TryAcquire(ctx)
+	require.NoError(t, err)
+	require.True(t, held)
+
+	require.NoError(t, infra.RedisClient.Set(ctx, "management:scheduler:leader", "new-replica", 5*time.Second).Err())
+
+	require.NoError(t, stale.Release(ctx))
+
+	current, err := infra.RedisClient.Get(ctx, "management:scheduler:leader").Result()
+	require.NoError(t, err)
+	assert.Equal(t, "new-replica", current, "stale replica's Release deleted another replica's lease")
+}
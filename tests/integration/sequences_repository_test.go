@@ -0,0 +1,91 @@
+package integration
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"yeti/pkg/migrations"
+	"yeti/pkg/sequences"
+)
+
+func TestSequencesRepository_Next(t *testing.T) {
+	infra := SetupTestInfraWithOptions(t, false, true, false)
+	ctx := context.Background()
+	repo := sequences.NewRepository(infra.MongoDB)
+
+	t.Run("StartsAtOneAndIncrements", func(t *testing.T) {
+		group := "next_starts_at_one"
+
+		first, err := repo.Next(ctx, group)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), first)
+
+		second, err := repo.Next(ctx, group)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), second)
+	})
+
+	t.Run("GroupsAreNumberedIndependently", func(t *testing.T) {
+		a, err := repo.Next(ctx, "independent_group_a")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), a)
+
+		b, err := repo.Next(ctx, "independent_group_b")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), b)
+
+		a, err = repo.Next(ctx, "independent_group_a")
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), a)
+	})
+
+	t.Run("ConcurrentCallsNeverRepeatOrSkip", func(t *testing.T) {
+		group := "concurrent_group"
+		const callers = 50
+
+		values := make([]int64, callers)
+		var wg sync.WaitGroup
+		wg.Add(callers)
+		for i := 0; i < callers; i++ {
+			go func(i int) {
+				defer wg.Done()
+				v, err := repo.Next(ctx, group)
+				assert.NoError(t, err)
+				values[i] = v
+			}(i)
+		}
+		wg.Wait()
+
+		seen := make(map[int64]bool, callers)
+		for _, v := range values {
+			assert.False(t, seen[v], "value %d handed out more than once", v)
+			seen[v] = true
+		}
+		for i := int64(1); i <= callers; i++ {
+			assert.True(t, seen[i], "value %d was never handed out", i)
+		}
+	})
+}
+
+func TestEnsureSequences_BootstrapsFromExistingRuleCount(t *testing.T) {
+	infra := SetupTestInfraWithOptions(t, false, true, false)
+	ctx := context.Background()
+
+	const seedCount = 4
+	for i := 0; i < seedCount; i++ {
+		_, err := infra.MongoDB.Collection("enrichment_rules").InsertOne(ctx, map[string]any{"name": "seed"})
+		require.NoError(t, err)
+	}
+
+	_, err := migrations.EnsureSequences(ctx, infra.MongoDB)
+	require.NoError(t, err)
+
+	repo := sequences.NewRepository(infra.MongoDB)
+	next, err := repo.Next(ctx, "enrichment_rules")
+	require.NoError(t, err)
+	assert.Equal(t, int64(seedCount+1), next)
+}